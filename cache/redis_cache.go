@@ -85,3 +85,22 @@ func (cache *RedisCache) Get(ctx context.Context, key string, returnValue interf
 
 	return returnValue, nil
 }
+
+func (cache *RedisCache) Publish(ctx context.Context, channel string, message []byte) error {
+	return cache.redisRemoteCache.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe returns the messages published to channel, the subscription is closed when ctx is done
+func (cache *RedisCache) Subscribe(ctx context.Context, channel string) (<-chan *redis.Message, error) {
+	pubsub := cache.redisRemoteCache.Subscribe(ctx, channel)
+	// wait for the confirmation so messages published after Subscribe returns are received
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		pubsub.Close()
+	}()
+	return pubsub.Channel(), nil
+}
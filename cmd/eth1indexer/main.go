@@ -47,6 +47,7 @@ func main() {
 	startData := flag.Int64("data.start", 0, "Block to start indexing")
 	endData := flag.Int64("data.end", 0, "Block to finish indexing")
 	offsetData := flag.Int64("data.offset", 1000, "Data offset")
+	transformersData := flag.String("data.transformers", "", "Comma separated transformers (block, tx, itx, erc20, erc721, erc1155, uncle) to run when indexing the data of -data.start to -data.end, e.g. to backfill a new index. Defaults to all")
 	checkDataGaps := flag.Bool("data.gaps", false, "Check for gaps in the data table")
 	checkDataGapsLookback := flag.Int("data.gaps.lookback", 1000000, "Lookback for gaps check of the blocks table")
 
@@ -180,6 +181,27 @@ func main() {
 	transforms := make([]func(blk *types.Eth1Block, cache *ccache.Cache) (*types.BulkMutations, *types.BulkMutations, error), 0)
 	transforms = append(transforms, bt.TransformBlock, bt.TransformTx, bt.TransformItx, bt.TransformERC20, bt.TransformERC721, bt.TransformERC1155, bt.TransformUncle)
 
+	transformsByName := map[string]func(blk *types.Eth1Block, cache *ccache.Cache) (*types.BulkMutations, *types.BulkMutations, error){
+		"block":   bt.TransformBlock,
+		"tx":      bt.TransformTx,
+		"itx":     bt.TransformItx,
+		"erc20":   bt.TransformERC20,
+		"erc721":  bt.TransformERC721,
+		"erc1155": bt.TransformERC1155,
+		"uncle":   bt.TransformUncle,
+	}
+	dataTransforms := transforms
+	if *transformersData != "" {
+		dataTransforms = make([]func(blk *types.Eth1Block, cache *ccache.Cache) (*types.BulkMutations, *types.BulkMutations, error), 0)
+		for _, name := range strings.Split(*transformersData, ",") {
+			transform, ok := transformsByName[strings.TrimSpace(name)]
+			if !ok {
+				logrus.Fatalf("unknown transformer %q", name)
+			}
+			dataTransforms = append(dataTransforms, transform)
+		}
+	}
+
 	if *block != 0 {
 		err = IndexFromNode(bt, client, *block, *block, *concurrencyBlocks)
 		if err != nil {
@@ -213,7 +235,7 @@ func main() {
 	}
 
 	if *endData != 0 && *startData < *endData {
-		err = IndexFromBigtable(bt, int64(*startData), int64(*endData), transforms, *concurrencyData)
+		err = IndexFromBigtable(bt, int64(*startData), int64(*endData), dataTransforms, *concurrencyData)
 		if err != nil {
 			logrus.WithError(err).Fatalf("error indexing from bigtable")
 		}
@@ -190,6 +190,15 @@ func main() {
 
 	logrus.Infof("database connection established")
 
+	// the frontend shows the current price, the indexer only needs the sources for the historic prices
+	logrus.Infof("initializing prices")
+	if cfg.Frontend.Enabled {
+		price.Init(utils.Config.Chain.Config.DepositChainID, &utils.Config.Price)
+	} else if cfg.Indexer.Enabled {
+		price.Configure(utils.Config.Chain.Config.DepositChainID, &utils.Config.Price)
+	}
+	logrus.Infof("prices initialized")
+
 	if utils.Config.Indexer.Enabled {
 
 		err = services.InitLastAttestationCache(utils.Config.LastAttestationCachePath)
@@ -237,6 +246,11 @@ func main() {
 
 	if cfg.Frontend.Enabled {
 
+		err = services.InitEventStream(utils.Config.RedisCacheEndpoint, false)
+		if err != nil {
+			logrus.Errorf("error initializing the event stream, websocket events are disabled: %v", err)
+		}
+
 		if cfg.Frontend.OnlyAPI {
 			services.ReportStatus("api", "Running", nil)
 		} else {
@@ -247,14 +261,16 @@ func main() {
 
 		apiV1Router := router.PathPrefix("/api/v1").Subrouter()
 		router.PathPrefix("/api/v1/docs/").Handler(httpSwagger.WrapHandler)
-		apiV1Router.HandleFunc("/epoch/{epoch}", handlers.ApiEpoch).Methods("GET", "OPTIONS")
-		apiV1Router.HandleFunc("/epoch/{epoch}/blocks", handlers.ApiEpochBlocks).Methods("GET", "OPTIONS")
-		apiV1Router.HandleFunc("/block/{slotOrHash}", handlers.ApiBlock).Methods("GET", "OPTIONS")
-		apiV1Router.HandleFunc("/block/{slot}/attestations", handlers.ApiBlockAttestations).Methods("GET", "OPTIONS")
-		apiV1Router.HandleFunc("/block/{slot}/deposits", handlers.ApiBlockDeposits).Methods("GET", "OPTIONS")
-		apiV1Router.HandleFunc("/block/{slot}/attesterslashings", handlers.ApiBlockAttesterSlashings).Methods("GET", "OPTIONS")
-		apiV1Router.HandleFunc("/block/{slot}/proposerslashings", handlers.ApiBlockProposerSlashings).Methods("GET", "OPTIONS")
-		apiV1Router.HandleFunc("/block/{slot}/voluntaryexits", handlers.ApiBlockVoluntaryExits).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/epoch/{epoch}", handlers.ApiCacheFinalizedEpoch(handlers.ApiEpoch, "epoch")).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/epoch/{epoch}/blocks", handlers.ApiCacheFinalizedEpoch(handlers.ApiEpochBlocks, "epoch")).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/epoch/{epoch}/committees", handlers.ApiCacheFinalizedEpoch(handlers.ApiEpochCommittees, "epoch")).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/epoch/{epoch}/proposers", handlers.ApiCacheFinalizedEpoch(handlers.ApiEpochProposers, "epoch")).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/block/{slotOrHash}", handlers.ApiCacheFinalizedSlot(handlers.ApiBlock, "slotOrHash")).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/block/{slot}/attestations", handlers.ApiCacheFinalizedSlot(handlers.ApiBlockAttestations, "slot")).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/block/{slot}/deposits", handlers.ApiCacheFinalizedSlot(handlers.ApiBlockDeposits, "slot")).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/block/{slot}/attesterslashings", handlers.ApiCacheFinalizedSlot(handlers.ApiBlockAttesterSlashings, "slot")).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/block/{slot}/proposerslashings", handlers.ApiCacheFinalizedSlot(handlers.ApiBlockProposerSlashings, "slot")).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/block/{slot}/voluntaryexits", handlers.ApiCacheFinalizedSlot(handlers.ApiBlockVoluntaryExits, "slot")).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/sync_committee/{period}", handlers.ApiSyncCommittee).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/eth1deposit/{txhash}", handlers.ApiEth1Deposit).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/leaderboard", handlers.ApiValidatorLeaderboard).Methods("GET", "OPTIONS")
@@ -262,15 +278,20 @@ func main() {
 		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/balancehistory", handlers.ApiValidatorBalanceHistory).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/incomedetailhistory", handlers.ApiValidatorIncomeDetailsHistory).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/performance", handlers.ApiValidatorPerformance).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/percentiles", handlers.ApiValidatorPercentiles).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/apr", handlers.ApiValidatorAPRHistory).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/pool", handlers.ApiValidatorPool).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/execution/performance", handlers.ApiValidatorExecutionPerformance).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/attestations", handlers.ApiValidatorAttestations).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/proposals", handlers.ApiValidatorProposals).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/deposits", handlers.ApiValidatorDeposits).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/attestationefficiency", handlers.ApiValidatorAttestationEfficiency).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/attestationeffectiveness", handlers.ApiValidatorAttestationEffectiveness).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/validator/{index}/duties", handlers.ApiValidatorDuties).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/stats/{index}", handlers.ApiValidatorDailyStats).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/eth1/{address}", handlers.ApiValidatorByEth1Address).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validators/queue", handlers.ApiValidatorQueue).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/validators/snapshot", handlers.ApiValidatorSnapshot).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/graffitiwall", handlers.ApiGraffitiwall).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/chart/{chart}", handlers.ApiChart).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/user/token", handlers.APIGetToken).Methods("POST", "OPTIONS")
@@ -294,16 +315,16 @@ func main() {
 
 		// query params: token
 		apiV1Router.HandleFunc("/execution/address/{address}", handlers.ApiEth1Address).Methods("GET", "OPTIONS")
-		// apiV1Router.HandleFunc("/execution/address/{address}/tx", handlers.ApiEth1AddressTx).Methods("GET", "OPTIONS")
-		// apiV1Router.HandleFunc("/execution/address/{address}/itx", handlers.ApiEth1AddressItx).Methods("GET", "OPTIONS")
-		// apiV1Router.HandleFunc("/execution/address/{address}/blocks", handlers.ApiEth1AddressBlocks).Methods("GET", "OPTIONS")
-		// apiV1Router.HandleFunc("/execution/address/{address}/uncles", handlers.ApiEth1AddressUncles).Methods("GET", "OPTIONS")
-		// // query params: type={erc20,erc721,erc1155}, address
-		// apiV1Router.HandleFunc("/execution/address/{address}/tokens", handlers.ApiEth1AddressTokens).Methods("GET", "OPTIONS")
-
-		// apiV1Router.HandleFunc("/execution/transactions", handlers.ApiEth1Tx).Methods("GET", "OPTIONS")
-		// apiV1Router.HandleFunc("/execution/transaction/{txhash}/itx", handlers.ApiEth1TxItx).Methods("GET", "OPTIONS")
-		// apiV1Router.HandleFunc("/execution/transaction/{txhash}/status", handlers.ApiEth1TxStatus).Methods("GET", "OPTIONS")
+		// query params: limit, pageToken
+		apiV1Router.HandleFunc("/execution/address/{address}/tx", handlers.ApiEth1AddressTx).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/execution/address/{address}/itx", handlers.ApiEth1AddressItx).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/execution/address/{address}/blocks", handlers.ApiEth1AddressBlocks).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/execution/address/{address}/uncles", handlers.ApiEth1AddressUncles).Methods("GET", "OPTIONS")
+		// query params: type={erc20,erc721,erc1155}, token, limit, pageToken
+		apiV1Router.HandleFunc("/execution/address/{address}/tokens", handlers.ApiEth1AddressTokens).Methods("GET", "OPTIONS")
+
+		apiV1Router.HandleFunc("/execution/transaction/{txhash}", handlers.ApiEth1Tx).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/execution/transaction/{txhash}/itx", handlers.ApiEth1TxItx).Methods("GET", "OPTIONS")
 		// apiV1Router.HandleFunc("/execution/token/{token}", handlers.ApiEth1).Methods("GET", "OPTIONS")
 		// apiV1Router.HandleFunc("/stats/overall/epoch/{epoch}/rewards", handlers.ApiEth1).Methods("GET", "OPTIONS")
 		// apiV1Router.HandleFunc("/stats/overall/daily/eth-price?offset={timestamp}&limit={limit}&order={order}", handlers.ApiEth1).Methods("GET", "OPTIONS")
@@ -318,7 +339,22 @@ func main() {
 
 		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/widget", handlers.GetMobileWidgetStatsGet).Methods("GET")
 		apiV1Router.HandleFunc("/dashboard/widget", handlers.GetMobileWidgetStatsPost).Methods("POST")
+		apiV1Router.HandleFunc("/ws", handlers.ApiWebsocket).Methods("GET")
+		apiV1Router.HandleFunc("/validators/export/{id}/download", handlers.ApiValidatorExportDownload).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/reports/runs/{id}/download", handlers.ApiScheduledReportRunDownload).Methods("GET", "OPTIONS")
 		apiV1Router.Use(utils.CORSMiddleware)
+		apiV1Router.Use(handlers.ApiRateLimitMiddleware)
+
+		apiV2Router := router.PathPrefix("/api/v2").Subrouter()
+		router.PathPrefix("/api/v2/docs/").Handler(httpSwagger.Handler(httpSwagger.URL("/api/v2/openapi.json")))
+		handlers.RegisterApiV2Routes(apiV2Router)
+		apiV2Router.Use(utils.CORSMiddleware)
+		apiV2Router.Use(handlers.ApiV2RateLimitMiddleware)
+
+		graphqlHandler := utils.CORSMiddleware(handlers.ApiGraphqlRateLimitMiddleware(http.HandlerFunc(handlers.ApiGraphql)))
+		router.Handle("/graphql", graphqlHandler).Methods("GET", "POST", "OPTIONS")
+		// alias next to the other api endpoints
+		router.Handle("/api/graphql", graphqlHandler).Methods("GET", "POST", "OPTIONS")
 
 		apiV1AuthRouter := apiV1Router.PathPrefix("/user").Subrouter()
 		apiV1AuthRouter.HandleFunc("/mobile/notify/register", handlers.MobileNotificationUpdatePOST).Methods("POST", "OPTIONS")
@@ -334,13 +370,22 @@ func main() {
 		apiV1AuthRouter.HandleFunc("/notifications/bundled/unsubscribe", handlers.MultipleUsersNotificationsUnsubscribe).Methods("POST", "OPTIONS")
 		apiV1AuthRouter.HandleFunc("/notifications/subscribe", handlers.UserNotificationsSubscribe).Methods("POST", "OPTIONS")
 		apiV1AuthRouter.HandleFunc("/notifications/unsubscribe", handlers.UserNotificationsUnsubscribe).Methods("POST", "OPTIONS")
+		apiV1AuthRouter.HandleFunc("/notifications/rules", handlers.UserNotificationsRules).Methods("GET", "OPTIONS")
+		apiV1AuthRouter.HandleFunc("/notifications/locale", handlers.UserNotificationsLocale).Methods("GET", "POST", "OPTIONS")
 		apiV1AuthRouter.HandleFunc("/notifications", handlers.UserNotificationsSubscribed).Methods("POST", "GET", "OPTIONS")
+		apiV1AuthRouter.HandleFunc("/notifications/simulate", handlers.UserNotificationsSimulate).Methods("POST", "OPTIONS")
 		apiV1AuthRouter.HandleFunc("/stats", handlers.ClientStats).Methods("GET", "OPTIONS")
 		apiV1AuthRouter.HandleFunc("/stats/{offset}/{limit}", handlers.ClientStats).Methods("GET", "OPTIONS")
 		apiV1AuthRouter.HandleFunc("/ethpool", handlers.RegisterEthpoolSubscription).Methods("POST", "OPTIONS")
+		apiV1AuthRouter.HandleFunc("/validators/export", handlers.ApiValidatorExportCreate).Methods("POST", "OPTIONS")
+		apiV1AuthRouter.HandleFunc("/validators/export/{id}", handlers.ApiValidatorExportStatus).Methods("GET", "OPTIONS")
+		apiV1AuthRouter.HandleFunc("/reports", handlers.ApiScheduledReports).Methods("GET", "OPTIONS")
+		apiV1AuthRouter.HandleFunc("/reports", handlers.ApiScheduledReportCreate).Methods("POST", "OPTIONS")
+		apiV1AuthRouter.HandleFunc("/reports/{id}/delete", handlers.ApiScheduledReportDelete).Methods("POST", "OPTIONS")
+		apiV1AuthRouter.HandleFunc("/reports/{id}/runs", handlers.ApiScheduledReportRuns).Methods("GET", "OPTIONS")
 
 		apiV1AuthRouter.Use(utils.CORSMiddleware)
-		apiV1AuthRouter.Use(utils.AuthorizedAPIMiddleware)
+		apiV1AuthRouter.Use(handlers.ApiUserAuthMiddleware)
 
 		router.HandleFunc("/api/healthz", handlers.ApiHealthz).Methods("GET", "HEAD")
 		router.HandleFunc("/api/healthz-loadbalancer", handlers.ApiHealthzLoadbalancer).Methods("GET", "HEAD")
@@ -349,9 +394,14 @@ func main() {
 		// services.Init() // Init frontend services
 		// logrus.Infof("frontend services initiated")
 
-		logrus.Infof("initializing prices")
-		price.Init(utils.Config.Chain.Config.DepositChainID)
-		logrus.Infof("prices initialized")
+		if cfg.Indexer.Node.Host != "" {
+			// used to serve the duties of epochs which have not been exported yet
+			rpc.CurrentLighthouseClient, err = rpc.NewLighthouseClient("http://"+cfg.Indexer.Node.Host+":"+cfg.Indexer.Node.Port, new(big.Int).SetUint64(utils.Config.Chain.Config.DepositChainID))
+			if err != nil {
+				logrus.Fatalf("error initializing lighthouse client: %v", err)
+			}
+		}
+
 		if !utils.Config.Frontend.Debug {
 			logrus.Infof("initializing ethclients")
 			ethclients.Init()
@@ -453,9 +503,11 @@ func main() {
 			router.HandleFunc("/validators/included-deposits/data", handlers.Eth2DepositsData).Methods("GET")
 
 			router.HandleFunc("/heatmap", handlers.Heatmap).Methods("GET")
+			router.HandleFunc("/heatmap/tiles/{set}/{zoom:[0-9]+}/{x:[0-9]+}/{y:[0-9]+}.png", handlers.HeatmapTile).Methods("GET")
 
 			router.HandleFunc("/dashboard", handlers.Dashboard).Methods("GET")
 			router.HandleFunc("/dashboard/save", handlers.UserDashboardWatchlistAdd).Methods("POST")
+			router.HandleFunc("/dashboard/shared/{token}", handlers.SharedDashboardView).Methods("GET")
 
 			router.HandleFunc("/dashboard/data/allbalances", handlers.DashboardDataBalanceCombined).Methods("GET")
 			router.HandleFunc("/dashboard/data/balance", handlers.DashboardDataBalance).Methods("GET")
@@ -464,6 +516,7 @@ func main() {
 			router.HandleFunc("/dashboard/data/validators", handlers.DashboardDataValidators).Methods("GET")
 			router.HandleFunc("/dashboard/data/effectiveness", handlers.DashboardDataEffectiveness).Methods("GET")
 			router.HandleFunc("/dashboard/data/earnings", handlers.DashboardDataEarnings).Methods("GET")
+			router.HandleFunc("/dashboard/data/percentiles", handlers.DashboardDataPercentiles).Methods("GET")
 			router.HandleFunc("/graffitiwall", handlers.Graffitiwall).Methods("GET")
 			router.HandleFunc("/calculator", handlers.StakingCalculator).Methods("GET")
 			router.HandleFunc("/search", handlers.Search).Methods("POST")
@@ -494,6 +547,9 @@ func main() {
 			router.HandleFunc("/pools/rocketpool/data/nodes", handlers.PoolsRocketpoolDataNodes).Methods("GET")
 			router.HandleFunc("/pools/rocketpool/data/dao_proposals", handlers.PoolsRocketpoolDataDAOProposals).Methods("GET")
 			router.HandleFunc("/pools/rocketpool/data/dao_members", handlers.PoolsRocketpoolDataDAOMembers).Methods("GET")
+			router.HandleFunc("/pools/lido", handlers.PoolsLido).Methods("GET")
+			router.HandleFunc("/pools/lido/data/node_operators", handlers.PoolsLidoDataNodeOperators).Methods("GET")
+			router.HandleFunc("/pools/lido/data/oracle_reports", handlers.PoolsLidoDataOracleReports).Methods("GET")
 
 			router.HandleFunc("/advertisewithus", handlers.AdvertiseWithUs).Methods("GET")
 			router.HandleFunc("/advertisewithus", handlers.AdvertiseWithUsPost).Methods("POST")
@@ -538,6 +594,7 @@ func main() {
 			authRouter.HandleFunc("/mobile/delete", handlers.MobileDeviceDeletePOST).Methods("POST", "OPTIONS")
 			authRouter.HandleFunc("/authorize", handlers.UserAuthorizeConfirmPost).Methods("POST")
 			authRouter.HandleFunc("/settings", handlers.UserSettings).Methods("GET")
+			authRouter.HandleFunc("/settings/api", handlers.UserApiUsage).Methods("GET")
 			authRouter.HandleFunc("/settings/password", handlers.UserUpdatePasswordPost).Methods("POST")
 			authRouter.HandleFunc("/settings/flags", handlers.UserUpdateFlagsPost).Methods("POST")
 			authRouter.HandleFunc("/settings/delete", handlers.UserDeletePost).Methods("POST")
@@ -551,6 +608,9 @@ func main() {
 			authRouter.HandleFunc("/watchlist/remove", handlers.UserModalRemoveSelectedValidator).Methods("POST")
 			authRouter.HandleFunc("/watchlist/update", handlers.UserModalManageNotificationModal).Methods("POST")
 			authRouter.HandleFunc("/notifications/unsubscribe", handlers.UserNotificationsUnsubscribe).Methods("POST")
+			authRouter.HandleFunc("/notifications/rules", handlers.UserNotificationsRules).Methods("GET")
+			authRouter.HandleFunc("/notifications/locale", handlers.UserNotificationsLocale).Methods("GET", "POST")
+			authRouter.HandleFunc("/notifications/simulate", handlers.UserNotificationsSimulate).Methods("POST")
 			authRouter.HandleFunc("/notifications/bundled/subscribe", handlers.MultipleUsersNotificationsSubscribeWeb).Methods("POST", "OPTIONS")
 
 			authRouter.HandleFunc("/notifications-center", handlers.UserNotificationsCenter).Methods("GET")
@@ -561,6 +621,9 @@ func main() {
 
 			authRouter.HandleFunc("/subscriptions/data", handlers.UserSubscriptionsData).Methods("GET")
 			authRouter.HandleFunc("/generateKey", handlers.GenerateAPIKey).Methods("POST")
+			authRouter.HandleFunc("/tokens", handlers.UserPersonalAccessTokens).Methods("GET")
+			authRouter.HandleFunc("/tokens", handlers.UserPersonalAccessTokenCreate).Methods("POST")
+			authRouter.HandleFunc("/tokens/{tokenID}/revoke", handlers.UserPersonalAccessTokenRevoke).Methods("POST")
 			authRouter.HandleFunc("/ethClients", handlers.EthClientsServices).Methods("GET")
 			authRouter.HandleFunc("/rewards", handlers.ValidatorRewards).Methods("GET")
 			authRouter.HandleFunc("/rewards/subscribe", handlers.RewardNotificationSubscribe).Methods("POST")
@@ -570,6 +633,12 @@ func main() {
 			authRouter.HandleFunc("/webhooks/add", handlers.UsersAddWebhook).Methods("POST")
 			authRouter.HandleFunc("/webhooks/{webhookID}/update", handlers.UsersEditWebhook).Methods("POST")
 			authRouter.HandleFunc("/webhooks/{webhookID}/delete", handlers.UsersDeleteWebhook).Methods("POST")
+			authRouter.HandleFunc("/dashboards", handlers.UserDashboards).Methods("GET")
+			authRouter.HandleFunc("/dashboards", handlers.UserDashboardCreate).Methods("POST")
+			authRouter.HandleFunc("/dashboards/{dashboardID}", handlers.UserDashboardView).Methods("GET")
+			authRouter.HandleFunc("/dashboards/{dashboardID}/update", handlers.UserDashboardUpdate).Methods("POST")
+			authRouter.HandleFunc("/dashboards/{dashboardID}/delete", handlers.UserDashboardDelete).Methods("POST")
+			authRouter.HandleFunc("/dashboards/{dashboardID}/share", handlers.UserDashboardShare).Methods("POST")
 
 			err = initStripe(authRouter)
 			if err != nil {
@@ -653,6 +722,20 @@ func main() {
 		services.InitGitCoinFeed()
 	}
 
+	if utils.Config.Frontend.ValidatorExport.Enabled {
+		err := services.InitValidatorExport()
+		if err != nil {
+			logrus.Fatalf("error initializing validator export: %v", err)
+		}
+	}
+
+	if utils.Config.Frontend.ScheduledReports.Enabled {
+		err := services.InitScheduledReports()
+		if err != nil {
+			logrus.Fatalf("error initializing scheduled reports: %v", err)
+		}
+	}
+
 	// if utils.Config.Frontend.PoolsUpdater.Enabled {
 	// services.InitPools() // making sure the website is available before updating
 	// }
@@ -68,6 +68,11 @@ func main() {
 		logrus.Fatalf("No cache provider set. Please set TierdCacheProvider (example redis, bigtable)")
 	}
 
+	err = services.InitEventStream(utils.Config.RedisCacheEndpoint, true)
+	if err != nil {
+		logrus.Fatalf("error initializing the event stream: %v", err)
+	}
+
 	logrus.Infof("initializing frontend services")
 	services.Init() // Init frontend services
 	logrus.Infof("frontend services initiated")
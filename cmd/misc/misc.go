@@ -1,11 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"eth2-exporter/db"
+	"eth2-exporter/services"
 	"eth2-exporter/types"
 	"eth2-exporter/utils"
 	"eth2-exporter/version"
 	"fmt"
+	"os"
 	"time"
 
 	_ "github.com/jackc/pgx/v4/stdlib"
@@ -16,14 +19,18 @@ import (
 )
 
 var opts = struct {
-	Command string
-	User    uint64
+	Command      string
+	User         uint64
+	Subscription uint64
+	Deliver      bool
 }{}
 
 func main() {
 	configPath := flag.String("config", "config/default.config.yml", "Path to the config file")
-	flag.StringVar(&opts.Command, "command", "", "command to run, available: updateAPIKey")
+	flag.StringVar(&opts.Command, "command", "", "command to run, available: updateAPIKey, simulateNotification")
 	flag.Uint64Var(&opts.User, "user", 0, "user id")
+	flag.Uint64Var(&opts.Subscription, "subscription", 0, "subscription id (simulateNotification)")
+	flag.BoolVar(&opts.Deliver, "deliver", false, "queue the simulated notification for delivery (simulateNotification)")
 	flag.Parse()
 
 	logrus.WithField("config", *configPath).WithField("version", version.Version).Printf("starting")
@@ -71,6 +78,11 @@ func main() {
 		if err != nil {
 			logrus.WithError(err).Fatal("error updating API key")
 		}
+	case "simulateNotification":
+		err := SimulateNotification(opts.User, opts.Subscription, opts.Deliver)
+		if err != nil {
+			logrus.WithError(err).Fatal("error simulating notification")
+		}
 	case "checkTransactions":
 
 	default:
@@ -133,3 +145,25 @@ func UpdateAPIKey(user uint64) error {
 
 	return nil
 }
+
+// Renders a synthetic notification for a subscription on all channels of the user and prints it as json
+func SimulateNotification(user, subscription uint64, deliver bool) error {
+	sub, err := services.GetUserSubscription(user, subscription)
+	if err != nil {
+		return fmt.Errorf("error getting subscription %v of user %v: %w", subscription, user, err)
+	}
+
+	n, err := services.SimulateNotification(sub)
+	if err != nil {
+		return err
+	}
+
+	preview, err := services.PreviewNotification(sub, n, deliver)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(preview)
+}
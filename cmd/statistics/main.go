@@ -94,7 +94,7 @@ func main() {
 
 	db.InitBigtable(cfg.Bigtable.Project, cfg.Bigtable.Instance, fmt.Sprintf("%d", utils.Config.Chain.Config.DepositChainID))
 
-	price.Init(utils.Config.Chain.Config.DepositChainID)
+	price.Init(utils.Config.Chain.Config.DepositChainID, &utils.Config.Price)
 
 	if *statisticsDaysToExport != "" {
 		s := strings.Split(*statisticsDaysToExport, "-")
@@ -121,6 +121,12 @@ func main() {
 				err = db.WriteValidatorStatisticsForDay(uint64(d))
 				if err != nil {
 					logrus.Errorf("error exporting stats for day %v: %v", d, err)
+					continue
+				}
+
+				err = db.WriteValidatorAPRForDay(uint64(d))
+				if err != nil {
+					logrus.Errorf("error exporting apr for day %v: %v", d, err)
 				}
 			}
 		}
@@ -152,6 +158,11 @@ func main() {
 			err = db.WriteValidatorStatisticsForDay(uint64(*statisticsDayToExport))
 			if err != nil {
 				logrus.Errorf("error exporting stats for day %v: %v", *statisticsDayToExport, err)
+			} else {
+				err = db.WriteValidatorAPRForDay(uint64(*statisticsDayToExport))
+				if err != nil {
+					logrus.Errorf("error exporting apr for day %v: %v", *statisticsDayToExport, err)
+				}
 			}
 		}
 
@@ -219,10 +230,28 @@ func statisticsLoop() {
 					err := db.WriteValidatorStatisticsForDay(day)
 					if err != nil {
 						logrus.Errorf("error exporting stats for day %v: %v", day, err)
+						continue
+					}
+
+					err = db.WriteValidatorAPRForDay(day)
+					if err != nil {
+						logrus.Errorf("error exporting apr for day %v: %v", day, err)
 					}
 				}
 			}
 
+			// the execution layer income is exported to eth_store_stats independently, days that were written before are updated once it is available
+			aprDays, err := db.GetValidatorAPRDaysMissingExecution()
+			if err != nil {
+				logrus.Errorf("error retrieving apr days missing execution layer income: %v", err)
+			}
+			for _, day := range aprDays {
+				err = db.WriteValidatorAPRForDay(day)
+				if err != nil {
+					logrus.Errorf("error exporting apr for day %v: %v", day, err)
+				}
+			}
+
 		}
 
 		if opt.statisticsChartToggle {
@@ -253,8 +282,17 @@ func statisticsLoop() {
 }
 
 func poolsLoop() {
+	var lastAttribution time.Time
 	for {
 		db.UpdatePoolInfo()
+		if time.Since(lastAttribution) > time.Hour {
+			err := services.UpdatePoolAttributions()
+			if err != nil {
+				logrus.Errorf("error updating pool attributions: %v", err)
+			} else {
+				lastAttribution = time.Now()
+			}
+		}
 		services.ReportStatus("poolInfoUpdater", "Running", nil)
 		time.Sleep(time.Minute * 10)
 	}
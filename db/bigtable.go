@@ -411,25 +411,44 @@ func (bigtable *Bigtable) SaveAttestationAssignments(epoch uint64, assignments m
 	start := time.Now()
 	ts := gcp_bigtable.Timestamp(0)
 
-	validatorsPerSlot := make(map[uint64][]uint64)
+	type assignment struct {
+		validator uint64
+		// committee index and position of the validator within the committee as uvarints
+		value []byte
+	}
+	assignmentsPerSlot := make(map[uint64][]assignment)
 	for key, validator := range assignments {
 		keySplit := strings.Split(key, "-")
+		if len(keySplit) != 3 {
+			return fmt.Errorf("invalid attestation assignment key %v", key)
+		}
 
 		attesterslot, err := strconv.ParseUint(keySplit[0], 10, 64)
 		if err != nil {
 			return err
 		}
+		committeeIndex, err := strconv.ParseUint(keySplit[1], 10, 64)
+		if err != nil {
+			return err
+		}
+		position, err := strconv.ParseUint(keySplit[2], 10, 64)
+		if err != nil {
+			return err
+		}
+		value := make([]byte, binary.MaxVarintLen64*2)
+		n := binary.PutUvarint(value, committeeIndex)
+		n += binary.PutUvarint(value[n:], position)
 
-		if validatorsPerSlot[attesterslot] == nil {
-			validatorsPerSlot[attesterslot] = make([]uint64, 0, len(assignments)/32)
+		if assignmentsPerSlot[attesterslot] == nil {
+			assignmentsPerSlot[attesterslot] = make([]assignment, 0, len(assignments)/32)
 		}
-		validatorsPerSlot[attesterslot] = append(validatorsPerSlot[attesterslot], validator)
+		assignmentsPerSlot[attesterslot] = append(assignmentsPerSlot[attesterslot], assignment{validator: validator, value: value[:n]})
 	}
 
-	for slot, validators := range validatorsPerSlot {
+	for slot, slotAssignments := range assignmentsPerSlot {
 		mut := gcp_bigtable.NewMutation()
-		for _, validator := range validators {
-			mut.Set(ATTESTATIONS_FAMILY, fmt.Sprintf("%d", validator), ts, []byte{})
+		for _, a := range slotAssignments {
+			mut.Set(ATTESTATIONS_FAMILY, fmt.Sprintf("%d", a.validator), ts, a.value)
 		}
 		err := bigtable.tableBeaconchain.Apply(ctx, fmt.Sprintf("%s:e:%s:s:%s", bigtable.chainId, reversedPaddedEpoch(epoch), reversedPaddedSlot(slot)), mut)
 
@@ -442,6 +461,58 @@ func (bigtable *Bigtable) SaveAttestationAssignments(epoch uint64, assignments m
 	return nil
 }
 
+// GetAttestationAssignments returns the attestation assignments of an epoch keyed by utils.FormatAttestorAssignmentKey.
+// Assignments exported without the committee index and position can not be restored, for those epochs an empty map is returned.
+func (bigtable *Bigtable) GetAttestationAssignments(epoch uint64) (map[string]uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// the assignments are stored with timestamp 0, the cells of the attestation inclusions have a timestamp derived from the inclusion slot
+	filter := gcp_bigtable.ChainFilters(
+		gcp_bigtable.FamilyFilter(ATTESTATIONS_FAMILY),
+		gcp_bigtable.TimestampRangeFilterMicros(0, 1000),
+	)
+
+	res := make(map[string]uint64)
+	complete := true
+	err := bigtable.tableBeaconchain.ReadRows(ctx, gcp_bigtable.PrefixRange(fmt.Sprintf("%s:e:%s:s:", bigtable.chainId, reversedPaddedEpoch(epoch))), func(r gcp_bigtable.Row) bool {
+		keySplit := strings.Split(r.Key(), ":")
+		attesterSlot, err := strconv.ParseUint(keySplit[4], 10, 64)
+		if err != nil {
+			logger.Errorf("error parsing slot from row key %v: %v", r.Key(), err)
+			return false
+		}
+		attesterSlot = max_block_number - attesterSlot
+
+		for _, ri := range r[ATTESTATIONS_FAMILY] {
+			validator, err := strconv.ParseUint(strings.TrimPrefix(ri.Column, ATTESTATIONS_FAMILY+":"), 10, 64)
+			if err != nil {
+				logger.Errorf("error parsing validator from column key %v: %v", ri.Column, err)
+				return false
+			}
+			committeeIndex, n := binary.Uvarint(ri.Value)
+			if n <= 0 {
+				complete = false
+				return false
+			}
+			position, m := binary.Uvarint(ri.Value[n:])
+			if m <= 0 {
+				complete = false
+				return false
+			}
+			res[utils.FormatAttestorAssignmentKey(attesterSlot, committeeIndex, position)] = validator
+		}
+		return true
+	}, gcp_bigtable.RowFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+	if !complete {
+		return map[string]uint64{}, nil
+	}
+	return res, nil
+}
+
 func (bigtable *Bigtable) SaveProposalAssignments(epoch uint64, assignments map[uint64]uint64) error {
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
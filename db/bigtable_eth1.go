@@ -591,7 +591,7 @@ func reversePaddedBigtableTimestamp(timestamp *timestamppb.Timestamp) string {
 
 func reversePaddedIndex(i int, maxValue int) string {
 	if i > maxValue {
-		logrus.Fatal("padded index %v is greater than the max index of %v", maxValue)
+		logrus.Fatalf("padded index %v is greater than the max index of %v", i, maxValue)
 	}
 	length := fmt.Sprintf("%d", len(fmt.Sprintf("%d", maxValue))-1)
 	fmtStr := "%0" + length + "d"
@@ -954,6 +954,7 @@ func (bigtable *Bigtable) TransformTx(blk *types.Eth1Block, cache *ccache.Cache)
 			IsContractCreation: isContract,
 			InvokesContract:    invokesContract,
 			ErrorMsg:           tx.GetErrorMsg(),
+			Status:             proto.Uint64(tx.GetStatus()),
 		}
 		// Mark Sender and Recipient for balance update
 		bigtable.markBalanceUpdate(indexedTx.From, []byte{0x0}, bulkMetadataUpdates, cache)
@@ -1033,6 +1034,14 @@ func (bigtable *Bigtable) TransformTx(blk *types.Eth1Block, cache *ccache.Cache)
 // Family: f
 // Column: <chainID>:ITX:<HASH>:<paddedITXIndex>
 // Cell:   nil
+//
+// Contract creations, including those without value that are not listed by the indexes above, are indexed by their creator:
+// Row:    <chainID>:I:ITX:<FROM_ADDRESS>:CONTRACT:<reversePaddedBigtableTimestamp>:<paddedTxIndex>:<paddedITXIndex>
+// Family: f
+// Column: <chainID>:ITX:<HASH>:<paddedITXIndex>
+// Cell:   nil
+// Blocks indexed before the contract creation index was added are backfilled by running the eth1indexer with
+// -data.start, -data.end and -data.transformers itx.
 func (bigtable *Bigtable) TransformItx(blk *types.Eth1Block, cache *ccache.Cache) (bulkData *types.BulkMutations, bulkMetadataUpdates *types.BulkMutations, err error) {
 	bulkData = &types.BulkMutations{}
 	bulkMetadataUpdates = &types.BulkMutations{}
@@ -1049,7 +1058,9 @@ func (bigtable *Bigtable) TransformItx(blk *types.Eth1Block, cache *ccache.Cache
 			}
 			jReversed := reversePaddedIndex(j, 100000)
 
-			if idx.Path == "[]" || bytes.Equal(idx.Value, []byte{0x0}) { // skip top level call & empty calls
+			empty := bytes.Equal(idx.Value, []byte{0x0})
+			isCreation := idx.GetType() == "create"
+			if idx.Path == "[]" || (empty && !isCreation) { // skip top level call & empty calls
 				continue
 			}
 
@@ -1064,8 +1075,10 @@ func (bigtable *Bigtable) TransformItx(blk *types.Eth1Block, cache *ccache.Cache
 				Value:       idx.GetValue(),
 			}
 
-			bigtable.markBalanceUpdate(indexedItx.To, []byte{0x0}, bulkMetadataUpdates, cache)
-			bigtable.markBalanceUpdate(indexedItx.From, []byte{0x0}, bulkMetadataUpdates, cache)
+			if !empty {
+				bigtable.markBalanceUpdate(indexedItx.To, []byte{0x0}, bulkMetadataUpdates, cache)
+				bigtable.markBalanceUpdate(indexedItx.From, []byte{0x0}, bulkMetadataUpdates, cache)
+			}
 
 			b, err := proto.Marshal(indexedItx)
 			if err != nil {
@@ -1078,12 +1091,18 @@ func (bigtable *Bigtable) TransformItx(blk *types.Eth1Block, cache *ccache.Cache
 			bulkData.Keys = append(bulkData.Keys, key)
 			bulkData.Muts = append(bulkData.Muts, mut)
 
-			indexes := []string{
-				// fmt.Sprintf("%s:i:ITX::%s:%s:%s", bigtable.chainId, reversePaddedBigtableTimestamp(blk.GetTime()), fmt.Sprintf("%04d", i), fmt.Sprintf("%05d", j)),
-				fmt.Sprintf("%s:I:ITX:%x:TO:%x:%s:%s:%s", bigtable.chainId, idx.GetFrom(), idx.GetTo(), reversePaddedBigtableTimestamp(blk.GetTime()), iReversed, jReversed),
-				fmt.Sprintf("%s:I:ITX:%x:FROM:%x:%s:%s:%s", bigtable.chainId, idx.GetTo(), idx.GetFrom(), reversePaddedBigtableTimestamp(blk.GetTime()), iReversed, jReversed),
-				fmt.Sprintf("%s:I:ITX:%x:TIME:%s:%s:%s", bigtable.chainId, idx.GetFrom(), reversePaddedBigtableTimestamp(blk.GetTime()), iReversed, jReversed),
-				fmt.Sprintf("%s:I:ITX:%x:TIME:%s:%s:%s", bigtable.chainId, idx.GetTo(), reversePaddedBigtableTimestamp(blk.GetTime()), iReversed, jReversed),
+			indexes := []string{}
+			if !empty {
+				indexes = append(indexes,
+					// fmt.Sprintf("%s:i:ITX::%s:%s:%s", bigtable.chainId, reversePaddedBigtableTimestamp(blk.GetTime()), fmt.Sprintf("%04d", i), fmt.Sprintf("%05d", j)),
+					fmt.Sprintf("%s:I:ITX:%x:TO:%x:%s:%s:%s", bigtable.chainId, idx.GetFrom(), idx.GetTo(), reversePaddedBigtableTimestamp(blk.GetTime()), iReversed, jReversed),
+					fmt.Sprintf("%s:I:ITX:%x:FROM:%x:%s:%s:%s", bigtable.chainId, idx.GetTo(), idx.GetFrom(), reversePaddedBigtableTimestamp(blk.GetTime()), iReversed, jReversed),
+					fmt.Sprintf("%s:I:ITX:%x:TIME:%s:%s:%s", bigtable.chainId, idx.GetFrom(), reversePaddedBigtableTimestamp(blk.GetTime()), iReversed, jReversed),
+					fmt.Sprintf("%s:I:ITX:%x:TIME:%s:%s:%s", bigtable.chainId, idx.GetTo(), reversePaddedBigtableTimestamp(blk.GetTime()), iReversed, jReversed),
+				)
+			}
+			if isCreation {
+				indexes = append(indexes, fmt.Sprintf("%s:I:ITX:%x:CONTRACT:%s:%s:%s", bigtable.chainId, idx.GetFrom(), reversePaddedBigtableTimestamp(blk.GetTime()), iReversed, jReversed))
 			}
 
 			for _, idx := range indexes {
@@ -1659,6 +1678,15 @@ func (bigtable *Bigtable) TransformUncle(block *types.Eth1Block, cache *ccache.C
 	return bulkData, bulkMetadataUpdates, nil
 }
 
+// addressIndexPageToken returns the key of the last index row read for a page of an address index. If fewer rows than the limit
+// were read the end of the index is reached and no token is returned.
+func addressIndexPageToken(indexes []string, limit int64) string {
+	if len(indexes) == 0 || int64(len(indexes)) < limit {
+		return ""
+	}
+	return indexes[len(indexes)-1]
+}
+
 func (bigtable *Bigtable) GetEth1TxForAddress(prefix string, limit int64) ([]*types.Eth1TransactionIndexed, string, error) {
 	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Second*30))
 	defer cancel()
@@ -1704,7 +1732,7 @@ func (bigtable *Bigtable) GetEth1TxForAddress(prefix string, limit int64) ([]*ty
 
 	// logger.Infof("returning data len: %v lastkey: %v", len(data), lastKey)
 
-	return data, indexes[len(indexes)-1], nil
+	return data, addressIndexPageToken(indexes, limit), nil
 }
 
 func (bigtable *Bigtable) GetAddressesNamesArMetadata(inputName *map[string]string, inputMetadata *map[string]*types.ERC20Metadata) (map[string]string, map[string]*types.ERC20Metadata, error) {
@@ -1870,7 +1898,7 @@ func (bigtable *Bigtable) GetEth1BlocksForAddress(prefix string, limit int64) ([
 
 	// logger.Infof("returning data len: %v lastkey: %v", len(data), lastKey)
 
-	return data, indexes[len(indexes)-1], nil
+	return data, addressIndexPageToken(indexes, limit), nil
 }
 
 func (bigtable *Bigtable) GetAddressBlocksMinedTableData(address string, search string, pageToken string) (*types.DataTableResponse, error) {
@@ -1955,7 +1983,7 @@ func (bigtable *Bigtable) GetEth1UnclesForAddress(prefix string, limit int64) ([
 
 	// logger.Infof("returning data len: %v lastkey: %v", len(data), lastKey)
 
-	return data, indexes[len(indexes)-1], nil
+	return data, addressIndexPageToken(indexes, limit), nil
 }
 
 func (bigtable *Bigtable) GetAddressUnclesMinedTableData(address string, search string, pageToken string) (*types.DataTableResponse, error) {
@@ -2036,7 +2064,7 @@ func (bigtable *Bigtable) GetEth1ItxForAddress(prefix string, address []byte, li
 		}
 	}
 
-	return data, indexes[len(indexes)-1], nil
+	return data, addressIndexPageToken(indexes, limit), nil
 }
 
 func (bigtable *Bigtable) GetAddressInternalTableData(address []byte, search string, pageToken string) (*types.DataTableResponse, error) {
@@ -2088,7 +2116,9 @@ func (bigtable *Bigtable) GetAddressInternalTableData(address []byte, search str
 	return data, nil
 }
 
-func (bigtable *Bigtable) GetInternalTransfersForTransaction(transaction []byte, from []byte) ([]types.Transfer, error) {
+// GetEth1ItxForTransaction returns the internal transactions of a transaction in the order of their execution.
+// The initial transfer from the sender of the transaction and zero-value calls are skipped.
+func (bigtable *Bigtable) GetEth1ItxForTransaction(transaction []byte, from []byte) ([]*types.Eth1InternalTransactionIndexed, error) {
 	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Second*30))
 	defer cancel()
 
@@ -2126,6 +2156,26 @@ func (bigtable *Bigtable) GetInternalTransfersForTransaction(transaction []byte,
 		return nil, err
 	}
 
+	// sort by event id
+	keys := make([]int, 0, len(transfers))
+	for k := range transfers {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	data := make([]*types.Eth1InternalTransactionIndexed, len(keys))
+	for i, k := range keys {
+		data[i] = transfers[k]
+	}
+	return data, nil
+}
+
+func (bigtable *Bigtable) GetInternalTransfersForTransaction(transaction []byte, from []byte) ([]types.Transfer, error) {
+	transfers, err := bigtable.GetEth1ItxForTransaction(transaction, from)
+	if err != nil {
+		return nil, err
+	}
+
 	names := make(map[string]string)
 	// init
 	for _, t := range transfers {
@@ -2133,6 +2183,7 @@ func (bigtable *Bigtable) GetInternalTransfersForTransaction(transaction []byte,
 		names[string(t.To)] = ""
 	}
 
+	mux := sync.Mutex{}
 	g := new(errgroup.Group)
 	g.SetLimit(25)
 	for address := range names {
@@ -2155,17 +2206,7 @@ func (bigtable *Bigtable) GetInternalTransfersForTransaction(transaction []byte,
 	}
 
 	data := make([]types.Transfer, len(transfers))
-
-	// sort by event id
-	keys := make([]int, 0, len(transfers))
-	for k := range transfers {
-		keys = append(keys, k)
-	}
-	sort.Ints(keys)
-
-	for i, k := range keys {
-		t := transfers[k]
-
+	for i, t := range transfers {
 		fromName := names[string(t.From)]
 		toName := names[string(t.To)]
 		from := utils.FormatAddress(t.From, nil, fromName, false, false, true)
@@ -2338,7 +2379,7 @@ func (bigtable *Bigtable) GetEth1ERC20ForAddress(prefix string, limit int64) ([]
 		data = append(data, keysMap[key])
 	}
 
-	return data, indexes[len(indexes)-1], nil
+	return data, addressIndexPageToken(indexes, limit), nil
 }
 
 func (bigtable *Bigtable) GetAddressErc20TableData(address []byte, search string, pageToken string) (*types.DataTableResponse, error) {
@@ -2446,7 +2487,7 @@ func (bigtable *Bigtable) GetEth1ERC721ForAddress(prefix string, limit int64) ([
 	for _, key := range keys {
 		data = append(data, keysMap[key])
 	}
-	return data, indexes[len(indexes)-1], nil
+	return data, addressIndexPageToken(indexes, limit), nil
 }
 
 func (bigtable *Bigtable) GetAddressErc721TableData(address string, search string, pageToken string) (*types.DataTableResponse, error) {
@@ -2528,7 +2569,7 @@ func (bigtable *Bigtable) GetEth1ERC1155ForAddress(prefix string, limit int64) (
 	for _, key := range keys {
 		data = append(data, keysMap[key])
 	}
-	return data, indexes[len(indexes)-1], nil
+	return data, addressIndexPageToken(indexes, limit), nil
 }
 
 func (bigtable *Bigtable) GetAddressErc1155TableData(address string, search string, pageToken string) (*types.DataTableResponse, error) {
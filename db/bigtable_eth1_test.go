@@ -0,0 +1,62 @@
+package db
+
+import (
+	"eth2-exporter/types"
+	"strings"
+	"testing"
+
+	"github.com/karlseguin/ccache/v2"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestAddressIndexPageToken(t *testing.T) {
+	indexes := []string{"1:I:TX:ab:TIME:9999:0001", "1:I:TX:ab:TIME:9999:0002"}
+	if token := addressIndexPageToken(indexes, 2); token != indexes[1] {
+		t.Errorf("expected the last index row as token of a full page, got %q", token)
+	}
+	if token := addressIndexPageToken(indexes, 3); token != "" {
+		t.Errorf("expected no token when fewer rows than the limit were read, got %q", token)
+	}
+	if token := addressIndexPageToken(nil, 3); token != "" {
+		t.Errorf("expected no token for an empty page, got %q", token)
+	}
+}
+
+func TestTransformItxIndexesContractCreations(t *testing.T) {
+	bt := &Bigtable{chainId: "1"}
+	blk := &types.Eth1Block{
+		Number: 1,
+		Time:   timestamppb.Now(),
+		Transactions: []*types.Eth1Transaction{{
+			Hash: make([]byte, 32),
+			Itx: []*types.Eth1InternalTransaction{
+				{Type: "call", From: []byte{0xaa}, To: []byte{0xbb}, Value: []byte{0x0}, Path: "[0]"},
+				{Type: "create", From: []byte{0xaa}, To: []byte{0xcc}, Value: []byte{0x0}, Path: "[1]"},
+				{Type: "create", From: []byte{0xaa}, To: []byte{0xdd}, Value: []byte{0x1}, Path: "[2]"},
+			},
+		}},
+	}
+
+	data, _, err := bt.TransformItx(blk, ccache.New(ccache.Configure()))
+	if err != nil {
+		t.Fatalf("error transforming internal transactions: %v", err)
+	}
+	contracts, listed := 0, 0
+	for _, key := range data.Keys {
+		switch {
+		case strings.HasPrefix(key, "1:I:ITX:aa:CONTRACT:"):
+			contracts++
+		case strings.HasPrefix(key, "1:I:ITX:cc:"):
+			t.Errorf("contract creation without value is listed for the contract: %v", key)
+		case strings.HasPrefix(key, "1:I:ITX:aa:TIME:"):
+			listed++
+		}
+	}
+	if contracts != 2 {
+		t.Errorf("got %v contract creation index rows, want 2", contracts)
+	}
+	// only the creation with value is listed on the address page of the factory
+	if listed != 1 {
+		t.Errorf("got %v time index rows of the factory, want 1", listed)
+	}
+}
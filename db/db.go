@@ -693,7 +693,7 @@ func SaveEpoch(data *types.EpochData, client rpc.Client) error {
 
 	cachedEpochKey, found := epochsCache.Get(fmt.Sprintf("%v", data.Epoch))
 	if found && epochCacheKey == cachedEpochKey.(string) {
-		logger.Infof("skipping export of epoch %v as it did not change compared to the previous export run")
+		logger.Infof("skipping export of epoch %v as it did not change compared to the previous export run", data.Epoch)
 		return nil
 	}
 
@@ -729,30 +729,30 @@ func SaveEpoch(data *types.EpochData, client rpc.Client) error {
 
 			validatorsTx, err := WriterDb.Beginx()
 			if err != nil {
-				logger.Errorf("error starting validators tx: %w", err)
+				logger.Errorf("error starting validators tx: %v", err)
 				return
 			}
 			defer validatorsTx.Rollback()
 
 			err = saveValidators(data, validatorsTx, client)
 			if err != nil {
-				logger.Errorf("error saving validators to db: %w", err)
+				logger.Errorf("error saving validators to db: %v", err)
 			}
 			err = updateQueueDeposits()
 			if err != nil {
-				logger.Errorf("error updating queue deposits cache: %w", err)
+				logger.Errorf("error updating queue deposits cache: %v", err)
 			}
 
 			if data.Epoch%9 == 0 { // update the validator performance every hour
 				err = updateValidatorPerformance(validatorsTx)
 				if err != nil {
-					logger.Errorf("error updating validator performance: %w", err)
+					logger.Errorf("error updating validator performance: %v", err)
 				}
 			}
 
 			err = validatorsTx.Commit()
 			if err != nil {
-				logger.Errorf("error committing validators tx: %w", err)
+				logger.Errorf("error committing validators tx: %v", err)
 			}
 		}()
 	}
@@ -1209,6 +1209,24 @@ func saveValidators(data *types.EpochData, tx *sqlx.Tx, client rpc.Client) error
 			end = len(validators)
 		}
 
+		// record changes of the withdrawal credentials (e.g. a 0x00 to 0x01 change) before they get overwritten
+		indices := make([]uint64, 0, end-start)
+		credentials := make([][]byte, 0, end-start)
+		for _, v := range validators[start:end] {
+			indices = append(indices, v.Index)
+			credentials = append(credentials, v.WithdrawalCredentials)
+		}
+		_, err := tx.Exec(`
+			INSERT INTO validator_withdrawalcredentials_changes (validatorindex, epoch, old_withdrawalcredentials, new_withdrawalcredentials)
+			SELECT v.validatorindex, $1, v.withdrawalcredentials, n.withdrawalcredentials
+			FROM validators v
+			INNER JOIN (SELECT UNNEST($2::int[]) AS validatorindex, UNNEST($3::bytea[]) AS withdrawalcredentials) n ON n.validatorindex = v.validatorindex
+			WHERE v.withdrawalcredentials != n.withdrawalcredentials
+			ON CONFLICT (validatorindex, epoch) DO NOTHING`, data.Epoch, pq.Array(indices), pq.ByteaArray(credentials))
+		if err != nil {
+			return fmt.Errorf("error saving withdrawal credential changes: %w", err)
+		}
+
 		numArgs := 16
 		valueStrings := make([]string, 0, batchSize)
 		valueArgs := make([]interface{}, 0, batchSize*numArgs)
@@ -1253,6 +1271,7 @@ func saveValidators(data *types.EpochData, tx *sqlx.Tx, client rpc.Client) error
 			VALUES %[3]s
 			ON CONFLICT (validatorindex) DO UPDATE SET 
 				withdrawableepoch          = EXCLUDED.withdrawableepoch,
+				withdrawalcredentials      = EXCLUDED.withdrawalcredentials,
 				balance                    = EXCLUDED.balance,
 				effectivebalance           = EXCLUDED.effectivebalance,
 				slashed                    = EXCLUDED.slashed,
@@ -1277,7 +1296,7 @@ func saveValidators(data *types.EpochData, tx *sqlx.Tx, client rpc.Client) error
 					ELSE 'active_online'
 					END`,
 			latestEpoch, thresholdSlot, strings.Join(valueStrings, ","))
-		_, err := tx.Exec(stmt, valueArgs...)
+		_, err = tx.Exec(stmt, valueArgs...)
 		if err != nil {
 			return err
 		}
@@ -2000,6 +2019,95 @@ func GetValidatorsGotSlashed(epoch uint64) ([]struct {
 	return dbResult, nil
 }
 
+// GetValidatorsExitInitiated returns the validators whose voluntary exit has been included in a canonical block of the given epoch
+func GetValidatorsExitInitiated(epoch uint64) ([]*types.ValidatorExitInitiated, error) {
+	exits := []*types.ValidatorExitInitiated{}
+	err := ReaderDb.Select(&exits, `
+		SELECT
+			blocks.slot,
+			blocks.epoch,
+			blocks_voluntaryexits.validatorindex,
+			validators.pubkey
+		FROM blocks_voluntaryexits
+		INNER JOIN blocks ON blocks_voluntaryexits.block_root = blocks.blockroot
+		INNER JOIN validators ON blocks_voluntaryexits.validatorindex = validators.validatorindex
+		WHERE blocks.status = '1' AND blocks.epoch = $1`, epoch)
+	if err != nil {
+		return nil, err
+	}
+	return exits, nil
+}
+
+// GetValidatorsWithdrawalCredentialsChanged returns the changes of withdrawal credentials that have been detected in the given epoch
+func GetValidatorsWithdrawalCredentialsChanged(epoch uint64) ([]*types.ValidatorWithdrawalCredentialsChange, error) {
+	changes := []*types.ValidatorWithdrawalCredentialsChange{}
+	err := ReaderDb.Select(&changes, `
+		SELECT
+			c.epoch,
+			c.validatorindex,
+			validators.pubkey,
+			c.old_withdrawalcredentials,
+			c.new_withdrawalcredentials
+		FROM validator_withdrawalcredentials_changes c
+		INNER JOIN validators ON c.validatorindex = validators.validatorindex
+		WHERE c.epoch = $1`, epoch)
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// GetLatestDepositBlock returns the last block that contains an indexed deposit
+func GetLatestDepositBlock() (uint64, error) {
+	var block uint64
+	err := ReaderDb.Get(&block, `SELECT COALESCE(MAX(block_number), 0) FROM eth1_deposits`)
+	return block, err
+}
+
+// GetDepositCredentials returns the deposits of the blocks after afterBlock up to toBlock together with the withdrawal credentials
+// of the first valid deposit of their pubkey and of their validator. Deposits are selected by block instead of time, so deposits
+// that are indexed late are not missed.
+func GetDepositCredentials(afterBlock, toBlock uint64) ([]*types.DepositCredentials, error) {
+	deposits := []*types.DepositCredentials{}
+	err := ReaderDb.Select(&deposits, `
+		SELECT
+			d.tx_hash,
+			d.block_ts,
+			d.from_address,
+			d.publickey,
+			validators.validatorindex,
+			d.amount,
+			d.withdrawal_credentials,
+			f.tx_hash AS first_tx_hash,
+			f.withdrawal_credentials AS first_withdrawal_credentials,
+			validators.withdrawalcredentials AS validator_withdrawalcredentials
+		FROM eth1_deposits d
+		LEFT JOIN LATERAL (
+			SELECT tx_hash, withdrawal_credentials
+			FROM eth1_deposits
+			WHERE publickey = d.publickey AND valid_signature AND NOT removed
+			ORDER BY block_number, tx_index
+			LIMIT 1
+		) f ON true
+		LEFT JOIN validators ON validators.pubkey = d.publickey
+		WHERE d.block_number > $1 AND d.block_number <= $2 AND NOT d.removed`,
+		afterBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	return deposits, nil
+}
+
+// GetDepositsNotifiedBlock returns the last block whose deposits have been checked for mismatched withdrawal credentials
+func GetDepositsNotifiedBlock() (uint64, bool, error) {
+	var block sql.NullInt64
+	err := WriterDb.Get(&block, `SELECT MAX(block_number) FROM deposits_notified`)
+	if err != nil {
+		return 0, false, err
+	}
+	return uint64(block.Int64), block.Valid, nil
+}
+
 func GetSlotVizData(latestEpoch uint64) ([]*types.SlotVizEpochs, error) {
 	type sqlBlocks struct {
 		Slot                    uint64
@@ -51,6 +51,39 @@ func GetUserEmailsByIds(ids []uint64) (map[uint64]string, error) {
 	return mailsByID, nil
 }
 
+// GetUserLocalesByIds returns the locale selected by each of the given users
+func GetUserLocalesByIds(ids []uint64) (map[uint64]string, error) {
+	localesByID := map[uint64]string{}
+	if len(ids) == 0 {
+		return localesByID, nil
+	}
+	var rows []struct {
+		ID     uint64 `db:"id"`
+		Locale string `db:"locale"`
+	}
+	err := FrontendWriterDB.Select(&rows, "SELECT id, locale FROM users WHERE id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		localesByID[r.ID] = r.Locale
+	}
+	return localesByID, nil
+}
+
+// GetUserLocale returns the locale selected by the user
+func GetUserLocale(userID uint64) (string, error) {
+	var locale string
+	err := FrontendWriterDB.Get(&locale, "SELECT locale FROM users WHERE id = $1", userID)
+	return locale, err
+}
+
+// SetUserLocale sets the locale notifications and emails are sent in for the user
+func SetUserLocale(userID uint64, locale string) error {
+	_, err := FrontendWriterDB.Exec("UPDATE users SET locale = $1 WHERE id = $2", locale, userID)
+	return err
+}
+
 // DeleteUserByEmail deletes a user.
 func DeleteUserByEmail(email string) error {
 	_, err := FrontendWriterDB.Exec("DELETE FROM users WHERE email = $1", email)
@@ -281,7 +314,8 @@ func DeleteSubscription(userID uint64, network string, eventName types.EventName
 		name = strings.ToLower(network) + ":" + string(eventName)
 	}
 
-	_, err := FrontendWriterDB.Exec("DELETE FROM users_subscriptions WHERE user_id = $1 and event_name = $2 and event_filter = $3", userID, name, eventFilter)
+	// subscription rules are removed by DeleteSubscriptionRule, the validators they cover are not affected
+	_, err := FrontendWriterDB.Exec("DELETE FROM users_subscriptions WHERE user_id = $1 and event_name = $2 and event_filter = $3 and event_filter NOT LIKE 'tag:%'", userID, name, eventFilter)
 	return err
 }
 
@@ -530,7 +564,7 @@ func GetHistoricPrices(currency string) (map[uint64]float64, error) {
 		return nil, fmt.Errorf("currency %v not supported", currency)
 	}
 
-	err := ReaderDb.Select(&data, fmt.Sprintf("SELECT ts, %s AS currency FROM price", currency))
+	err := ReaderDb.Select(&data, fmt.Sprintf("SELECT ts, %[1]s AS currency FROM price WHERE %[1]s IS NOT NULL", currency))
 	if err != nil {
 		return nil, err
 	}
@@ -574,10 +608,80 @@ func GetUserAPIKeyStatistics(apikey *string) (*types.ApiStatistics, error) {
 	return stats, nil
 }
 
+// GetApiKeyPlan returns the user of the api key and the price of the user's active api subscription, if any
+func GetApiKeyPlan(apiKey string) (uint64, string, error) {
+	var plan struct {
+		UserID  uint64         `db:"id"`
+		PriceID sql.NullString `db:"price_id"`
+	}
+	err := FrontendWriterDB.Get(&plan, `
+		SELECT users.id, us.price_id
+		FROM users
+		LEFT JOIN users_stripe_subscriptions us ON us.customer_id = users.stripe_customer_id AND us.active AND us.purchase_group = $2
+		WHERE users.api_key = $1
+		ORDER BY us.price_id NULLS LAST
+		LIMIT 1`, apiKey, utils.GROUP_API)
+	return plan.UserID, plan.PriceID.String, err
+}
+
+// GetApiKeyMonthlyUsage returns the number of calls made with the api key in the current calendar month
+func GetApiKeyMonthlyUsage(apiKey string) (int64, error) {
+	var count int64
+	err := FrontendWriterDB.Get(&count, `SELECT COALESCE(SUM(count), 0) FROM api_statistics WHERE ts >= date_trunc('month', NOW()) AND apikey = $1`, apiKey)
+	return count, err
+}
+
+// SaveApiStatistics adds the given call counts to the api_statistics table
+func SaveApiStatistics(counts []*types.ApiStatisticsCount) error {
+	tx, err := FrontendWriterDB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, c := range counts {
+		_, err = tx.Exec(`
+			INSERT INTO api_statistics (ts, apikey, call, count)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (ts, apikey, call) DO UPDATE SET count = api_statistics.count + excluded.count`,
+			c.Ts, c.ApiKey, c.Call, c.Count)
+		if err != nil {
+			return fmt.Errorf("error saving api statistics of call %v: %w", c.Call, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetApiKeyUsage returns the calls made with the api key since the given time, summed up per call and per day
+func GetApiKeyUsage(apiKey string, since time.Time) ([]*types.ApiStatisticsCount, []*types.ApiStatisticsCount, error) {
+	calls := []*types.ApiStatisticsCount{}
+	err := FrontendWriterDB.Select(&calls, `
+		SELECT $2::timestamp AS ts, call, SUM(count) AS count
+		FROM api_statistics
+		WHERE apikey = $1 AND ts >= $2
+		GROUP BY call
+		ORDER BY count DESC`, apiKey, since)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	days := []*types.ApiStatisticsCount{}
+	err = FrontendWriterDB.Select(&days, `
+		SELECT date_trunc('day', ts) AS ts, '' AS call, SUM(count) AS count
+		FROM api_statistics
+		WHERE apikey = $1 AND ts >= $2
+		GROUP BY date_trunc('day', ts)
+		ORDER BY ts DESC`, apiKey, since)
+	if err != nil {
+		return nil, nil, err
+	}
+	return calls, days, nil
+}
+
 func GetSubsForEventFilter(eventName types.EventName) ([][]byte, map[string][]types.Subscription, error) {
 	var subs []types.Subscription
 	subQuery := `
-		SELECT id, user_id, event_filter, last_sent_epoch, created_epoch, event_threshold, ENCODE(unsubscribe_hash, 'hex') as unsubscribe_hash, internal_state from users_subscriptions where event_name = $1
+		SELECT id, user_id, event_filter, last_sent_epoch, created_epoch, event_threshold, ENCODE(unsubscribe_hash, 'hex') as unsubscribe_hash, internal_state from users_subscriptions where event_name = $1 AND event_filter NOT LIKE 'tag:%'
 		`
 
 	subMap := make(map[string][]types.Subscription, 0)
@@ -586,6 +690,12 @@ func GetSubsForEventFilter(eventName types.EventName) ([][]byte, map[string][]ty
 		return nil, nil, err
 	}
 
+	ruleSubs, err := GetSubscriptionRuleSubs(eventName, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	subs = append(subs, ruleSubs...)
+
 	filtersEncode := make([][]byte, 0, len(subs))
 	for _, sub := range subs {
 		if _, ok := subMap[sub.EventFilter]; !ok {
@@ -666,3 +776,432 @@ func GetDataTablesState(user uint64, key string) (*types.DataTableSaveState, err
 
 	return &state, err
 }
+
+// CreateValidatorExportJob inserts a pending validator export job and returns its id
+func CreateValidatorExportJob(job *types.ValidatorExportJob) error {
+	return FrontendWriterDB.Get(&job.ID, `
+		INSERT INTO validator_export_jobs (user_id, network, validators, start_day, end_day, format, status, download_token, created_ts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`,
+		job.UserID, job.Network, job.Validators, job.StartDay, job.EndDay, job.Format, job.Status, job.DownloadToken, job.CreatedTime)
+}
+
+// GetValidatorExportJob returns the export job with the given id, only the number of validators of the job is loaded
+func GetValidatorExportJob(id uint64) (*types.ValidatorExportJob, error) {
+	job := &types.ValidatorExportJob{}
+	err := FrontendReaderDB.Get(job, `
+		SELECT id, user_id, network, '{}'::int[] AS validators, cardinality(validators) AS validators_count, start_day, end_day, format, status, error, file_key, file_size, download_token, created_ts, started_ts, finished_ts
+		FROM validator_export_jobs
+		WHERE id = $1`, id)
+	return job, err
+}
+
+// CountUnfinishedValidatorExportJobs returns the number of pending or running export jobs of a user
+func CountUnfinishedValidatorExportJobs(userID uint64) (uint64, error) {
+	var count uint64
+	err := FrontendWriterDB.Get(&count, `SELECT COUNT(*) FROM validator_export_jobs WHERE user_id = $1 AND status IN ('pending', 'running')`, userID)
+	return count, err
+}
+
+// ClaimValidatorExportJob marks the oldest pending export job of the network as running and returns it.
+// Jobs which are running for longer than staleAfter are claimed again, their worker is assumed to have stopped.
+// If there is no job sql.ErrNoRows is returned.
+func ClaimValidatorExportJob(network string, staleAfter time.Duration) (*types.ValidatorExportJob, error) {
+	job := &types.ValidatorExportJob{}
+	err := FrontendWriterDB.Get(job, `
+		UPDATE validator_export_jobs
+		SET status = 'running', started_ts = NOW()
+		WHERE id = (
+			SELECT id
+			FROM validator_export_jobs
+			WHERE network = $1 AND (status = 'pending' OR (status = 'running' AND started_ts < NOW() - $2 * INTERVAL '1 second'))
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, network, validators, start_day, end_day, format, status, error, file_key, file_size, download_token, created_ts, started_ts, finished_ts`,
+		network, staleAfter.Seconds())
+	return job, err
+}
+
+// FinishValidatorExportJob stores the result of an export job, failed jobs have an error and no file
+func FinishValidatorExportJob(id uint64, status string, fileKey *string, fileSize *int64, errorMessage *string) error {
+	_, err := FrontendWriterDB.Exec(`
+		UPDATE validator_export_jobs
+		SET status = $2, file_key = $3, file_size = $4, error = $5, finished_ts = NOW()
+		WHERE id = $1`, id, status, fileKey, fileSize, errorMessage)
+	return err
+}
+
+// CreateDashboard inserts the dashboard of a user with its groups and sets their ids
+func CreateDashboard(dashboard *types.Dashboard) error {
+	tx, err := FrontendWriterDB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.Get(&dashboard.ID, `
+		INSERT INTO users_dashboards (user_id, network, name, share_token, created_ts, updated_ts)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		dashboard.UserID, dashboard.Network, dashboard.Name, dashboard.ShareToken, dashboard.CreatedTime, dashboard.UpdatedTime)
+	if err != nil {
+		return err
+	}
+	err = insertDashboardGroups(tx, dashboard)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateDashboard renames a dashboard of a user and replaces its groups, it returns sql.ErrNoRows if the user has no such dashboard
+func UpdateDashboard(dashboard *types.Dashboard) error {
+	tx, err := FrontendWriterDB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE users_dashboards SET name = $3, updated_ts = $4 WHERE user_id = $1 AND id = $2`,
+		dashboard.UserID, dashboard.ID, dashboard.Name, dashboard.UpdatedTime)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = tx.Exec(`DELETE FROM users_dashboard_groups WHERE dashboard_id = $1`, dashboard.ID)
+	if err != nil {
+		return err
+	}
+	err = insertDashboardGroups(tx, dashboard)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertDashboardGroups(tx *sqlx.Tx, dashboard *types.Dashboard) error {
+	for _, group := range dashboard.Groups {
+		group.DashboardID = dashboard.ID
+		err := tx.Get(&group.ID, `INSERT INTO users_dashboard_groups (dashboard_id, name, validators) VALUES ($1, $2, $3) RETURNING id`,
+			group.DashboardID, group.Name, group.Validators)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetDashboardShareToken sets or, if token is nil, removes the share token of a dashboard of a user.
+// It returns sql.ErrNoRows if the user has no such dashboard.
+func SetDashboardShareToken(userID, id uint64, token []byte) error {
+	res, err := FrontendWriterDB.Exec(`UPDATE users_dashboards SET share_token = $3 WHERE user_id = $1 AND id = $2`, userID, id, token)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteDashboard deletes a dashboard of a user with its groups, it returns sql.ErrNoRows if the user has no such dashboard
+func DeleteDashboard(userID, id uint64) error {
+	tx, err := FrontendWriterDB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM users_dashboards WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = tx.Exec(`DELETE FROM users_dashboard_groups WHERE dashboard_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CountDashboards returns the number of dashboards of a user on the network
+func CountDashboards(userID uint64, network string) (uint64, error) {
+	var count uint64
+	err := FrontendWriterDB.Get(&count, `SELECT COUNT(*) FROM users_dashboards WHERE user_id = $1 AND network = $2`, userID, network)
+	return count, err
+}
+
+// GetDashboards returns the dashboards of a user on the network with their groups
+func GetDashboards(userID uint64, network string) ([]*types.Dashboard, error) {
+	dashboards := []*types.Dashboard{}
+	err := FrontendReaderDB.Select(&dashboards, `
+		SELECT id, user_id, network, name, share_token, created_ts, updated_ts
+		FROM users_dashboards
+		WHERE user_id = $1 AND network = $2
+		ORDER BY id`, userID, network)
+	if err != nil {
+		return nil, err
+	}
+	return dashboards, getDashboardGroups(dashboards)
+}
+
+// GetDashboard returns a dashboard of a user with its groups, it returns sql.ErrNoRows if the user has no such dashboard
+func GetDashboard(userID, id uint64) (*types.Dashboard, error) {
+	dashboard := &types.Dashboard{}
+	err := FrontendReaderDB.Get(dashboard, `
+		SELECT id, user_id, network, name, share_token, created_ts, updated_ts
+		FROM users_dashboards
+		WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return dashboard, getDashboardGroups([]*types.Dashboard{dashboard})
+}
+
+// GetSharedDashboard returns the dashboard of the network with the share token, it returns sql.ErrNoRows if there is no such dashboard
+func GetSharedDashboard(network string, token []byte) (*types.Dashboard, error) {
+	dashboard := &types.Dashboard{}
+	err := FrontendReaderDB.Get(dashboard, `
+		SELECT id, user_id, network, name, share_token, created_ts, updated_ts
+		FROM users_dashboards
+		WHERE network = $1 AND share_token = $2`, network, token)
+	if err != nil {
+		return nil, err
+	}
+	return dashboard, getDashboardGroups([]*types.Dashboard{dashboard})
+}
+
+func getDashboardGroups(dashboards []*types.Dashboard) error {
+	if len(dashboards) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(dashboards))
+	byID := make(map[uint64]*types.Dashboard, len(dashboards))
+	for _, dashboard := range dashboards {
+		ids = append(ids, int64(dashboard.ID))
+		byID[dashboard.ID] = dashboard
+		dashboard.Groups = []*types.DashboardGroup{}
+	}
+
+	groups := []*types.DashboardGroup{}
+	err := FrontendReaderDB.Select(&groups, `
+		SELECT id, dashboard_id, name, validators
+		FROM users_dashboard_groups
+		WHERE dashboard_id = ANY($1)
+		ORDER BY id`, pq.Int64Array(ids))
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if dashboard, ok := byID[group.DashboardID]; ok {
+			dashboard.Groups = append(dashboard.Groups, group)
+		}
+	}
+	return nil
+}
+
+const scheduledReportColumns = `id, user_id, network, name, validators, tag, period, currency, sections, format, next_run_ts, last_run_ts, failed_runs, created_ts`
+
+// CreateScheduledReport inserts the scheduled report of a user and sets its id
+func CreateScheduledReport(report *types.ScheduledReport) error {
+	return FrontendWriterDB.Get(&report.ID, `
+		INSERT INTO users_scheduled_reports (user_id, network, name, validators, tag, period, currency, sections, format, next_run_ts, created_ts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id`,
+		report.UserID, report.Network, report.Name, report.Validators, report.Tag, report.Period, report.Currency, report.Sections, report.Format, report.NextRunTime, report.CreatedTime)
+}
+
+// GetScheduledReports returns the scheduled reports of a user on the network
+func GetScheduledReports(userID uint64, network string) ([]*types.ScheduledReport, error) {
+	reports := []*types.ScheduledReport{}
+	err := FrontendReaderDB.Select(&reports, `SELECT `+scheduledReportColumns+` FROM users_scheduled_reports WHERE user_id = $1 AND network = $2 ORDER BY id`, userID, network)
+	return reports, err
+}
+
+// GetScheduledReport returns the scheduled report of a user, it returns sql.ErrNoRows if the user has no such report
+func GetScheduledReport(userID, id uint64) (*types.ScheduledReport, error) {
+	report := &types.ScheduledReport{}
+	err := FrontendReaderDB.Get(report, `SELECT `+scheduledReportColumns+` FROM users_scheduled_reports WHERE user_id = $1 AND id = $2`, userID, id)
+	return report, err
+}
+
+// CountScheduledReports returns the number of scheduled reports of a user
+func CountScheduledReports(userID uint64) (uint64, error) {
+	var count uint64
+	err := FrontendWriterDB.Get(&count, `SELECT COUNT(*) FROM users_scheduled_reports WHERE user_id = $1`, userID)
+	return count, err
+}
+
+// DeleteScheduledReport deletes a scheduled report of a user, its archived runs stay downloadable.
+// It returns sql.ErrNoRows if the user has no such report.
+func DeleteScheduledReport(userID, id uint64) error {
+	res, err := FrontendWriterDB.Exec(`DELETE FROM users_scheduled_reports WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ClaimDueScheduledReport returns a scheduled report of the network whose next run is due and leases it for leaseDuration,
+// so concurrent workers do not generate the same report. If there is no due report sql.ErrNoRows is returned.
+func ClaimDueScheduledReport(network string, leaseDuration time.Duration) (*types.ScheduledReport, error) {
+	report := &types.ScheduledReport{}
+	err := FrontendWriterDB.Get(report, `
+		UPDATE users_scheduled_reports
+		SET locked_until = NOW() + $2 * INTERVAL '1 second'
+		WHERE id = (
+			SELECT id
+			FROM users_scheduled_reports
+			WHERE network = $1 AND next_run_ts <= NOW() AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY next_run_ts
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+scheduledReportColumns, network, leaseDuration.Seconds())
+	return report, err
+}
+
+// FinishScheduledReportRun stores the run of a scheduled report, schedules its next run and releases the lease of the report
+func FinishScheduledReportRun(run *types.ScheduledReportRun, nextRunTime time.Time) error {
+	tx, err := FrontendWriterDB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.Get(&run.ID, `
+		INSERT INTO users_scheduled_report_runs (report_id, user_id, period_start, period_end, format, status, error, file_key, file_size, download_token, created_ts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id`,
+		run.ReportID, run.UserID, run.PeriodStart, run.PeriodEnd, run.Format, run.Status, run.Error, run.FileKey, run.FileSize, run.DownloadToken, run.CreatedTime)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE users_scheduled_reports SET next_run_ts = $2, last_run_ts = $3, locked_until = NULL, failed_runs = 0 WHERE id = $1`, run.ReportID, nextRunTime, run.CreatedTime)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RetryScheduledReport counts a failed attempt to generate the current period of a scheduled report and leases the report until retryTime,
+// the next run is not changed so the period is generated again afterwards
+func RetryScheduledReport(reportID uint64, retryTime time.Time) error {
+	_, err := FrontendWriterDB.Exec(`UPDATE users_scheduled_reports SET failed_runs = failed_runs + 1, locked_until = $2 WHERE id = $1`, reportID, retryTime)
+	return err
+}
+
+// DeferScheduledReport leases a scheduled report until retryTime without counting a failed attempt, e.g. while the data of its period is not exported yet
+func DeferScheduledReport(reportID uint64, retryTime time.Time) error {
+	_, err := FrontendWriterDB.Exec(`UPDATE users_scheduled_reports SET locked_until = $2 WHERE id = $1`, reportID, retryTime)
+	return err
+}
+
+// GetScheduledReportRuns returns the archived runs of a scheduled report of a user, most recent first
+func GetScheduledReportRuns(userID, reportID uint64) ([]*types.ScheduledReportRun, error) {
+	runs := []*types.ScheduledReportRun{}
+	err := FrontendReaderDB.Select(&runs, `
+		SELECT id, report_id, user_id, period_start, period_end, format, status, error, file_key, file_size, download_token, created_ts
+		FROM users_scheduled_report_runs
+		WHERE user_id = $1 AND report_id = $2
+		ORDER BY id DESC`, userID, reportID)
+	return runs, err
+}
+
+// GetScheduledReportRun returns the archived run with the given id
+func GetScheduledReportRun(id uint64) (*types.ScheduledReportRun, error) {
+	run := &types.ScheduledReportRun{}
+	err := FrontendReaderDB.Get(run, `
+		SELECT id, report_id, user_id, period_start, period_end, format, status, error, file_key, file_size, download_token, created_ts
+		FROM users_scheduled_report_runs
+		WHERE id = $1`, id)
+	return run, err
+}
+
+// CreatePersonalAccessToken inserts the personal access token and sets its id
+func CreatePersonalAccessToken(token *types.PersonalAccessToken) error {
+	return FrontendWriterDB.Get(&token.ID, `
+		INSERT INTO personal_access_tokens (user_id, name, token_hash, token_prefix, scopes, created_ts, expires_ts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`,
+		token.UserID, token.Name, token.TokenHash, token.TokenPrefix, token.Scopes, token.CreatedTime, token.ExpiresTime)
+}
+
+// GetPersonalAccessTokens returns the personal access tokens of a user, most recent first
+func GetPersonalAccessTokens(userID uint64) ([]*types.PersonalAccessToken, error) {
+	tokens := []*types.PersonalAccessToken{}
+	err := FrontendReaderDB.Select(&tokens, `
+		SELECT id, user_id, name, token_hash, token_prefix, scopes, created_ts, expires_ts, last_used_ts
+		FROM personal_access_tokens
+		WHERE user_id = $1
+		ORDER BY id DESC`, userID)
+	return tokens, err
+}
+
+// CountPersonalAccessTokens returns the number of personal access tokens of a user
+func CountPersonalAccessTokens(userID uint64) (uint64, error) {
+	var count uint64
+	err := FrontendWriterDB.Get(&count, `SELECT COUNT(*) FROM personal_access_tokens WHERE user_id = $1`, userID)
+	return count, err
+}
+
+// DeletePersonalAccessToken revokes a personal access token of a user, it returns sql.ErrNoRows if the user has no such token
+func DeletePersonalAccessToken(userID, id uint64) error {
+	res, err := FrontendWriterDB.Exec(`DELETE FROM personal_access_tokens WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UsePersonalAccessToken returns the unexpired personal access token with the hash and records its usage.
+// The last used timestamp is updated at most once per minute. If there is no such token sql.ErrNoRows is returned.
+func UsePersonalAccessToken(tokenHash []byte) (*types.PersonalAccessToken, error) {
+	token := &types.PersonalAccessToken{}
+	err := FrontendWriterDB.Get(token, `
+		SELECT id, user_id, name, token_hash, token_prefix, scopes, created_ts, expires_ts, last_used_ts
+		FROM personal_access_tokens
+		WHERE token_hash = $1 AND (expires_ts IS NULL OR expires_ts > NOW())`, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if token.LastUsedTime == nil || time.Since(*token.LastUsedTime) > time.Minute {
+		_, err = FrontendWriterDB.Exec(`UPDATE personal_access_tokens SET last_used_ts = NOW() WHERE id = $1`, token.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return token, nil
+}
@@ -5,6 +5,7 @@ import (
 	"eth2-exporter/types"
 	"eth2-exporter/utils"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
@@ -280,3 +281,167 @@ func deleteOldChartEntries() {
 		logger.Errorf("error removing old staking pool chart data: %v", err)
 	}
 }
+
+// GetPoolAttributionRules returns the configured pool attribution rules
+func GetPoolAttributionRules() ([]*types.PoolAttributionRule, error) {
+	var rules []*types.PoolAttributionRule
+	err := ReaderDb.Select(&rules, `SELECT id, pool, rule_type, pattern, confidence FROM pool_attribution_rules ORDER BY id`)
+	return rules, err
+}
+
+// GetPoolFactoryDeployments returns the stored contracts deployed by the factory and the time of its latest deployment,
+// the time is zero if no deployment is stored
+func GetPoolFactoryDeployments(factory []byte) ([][]byte, time.Time, error) {
+	var deployments []*types.PoolFactoryDeployment
+	err := ReaderDb.Select(&deployments, `SELECT factory, contract, created_ts FROM pool_factory_deployments WHERE factory = $1`, factory)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	contracts := make([][]byte, 0, len(deployments))
+	latest := time.Time{}
+	for _, d := range deployments {
+		contracts = append(contracts, d.Contract)
+		if d.CreatedTime.After(latest) {
+			latest = d.CreatedTime
+		}
+	}
+	return contracts, latest, nil
+}
+
+// SavePoolFactoryDeployments stores contracts deployed by a factory, contracts that are already stored are skipped
+func SavePoolFactoryDeployments(deployments []*types.PoolFactoryDeployment) error {
+	batchSize := 20000 // max parameters: 65535
+	for b := 0; b < len(deployments); b += batchSize {
+		end := b + batchSize
+		if len(deployments) < end {
+			end = len(deployments)
+		}
+
+		numArgs := 3
+		valueStrings := make([]string, 0, end-b)
+		valueArgs := make([]interface{}, 0, (end-b)*numArgs)
+		for i, d := range deployments[b:end] {
+			valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d)", i*numArgs+1, i*numArgs+2, i*numArgs+3))
+			valueArgs = append(valueArgs, d.Factory, d.Contract, d.CreatedTime)
+		}
+		_, err := WriterDb.Exec(fmt.Sprintf(`
+			INSERT INTO pool_factory_deployments (factory, contract, created_ts)
+			VALUES %s
+			ON CONFLICT (factory, contract) DO NOTHING`, strings.Join(valueStrings, ",")), valueArgs...)
+		if err != nil {
+			return fmt.Errorf("error inserting pool factory deployments: %w", err)
+		}
+	}
+	return nil
+}
+
+// SavePoolAttributions replaces the audit trail of the attribution rules and the pools assigned by them.
+// Pools without a confidence are assigned by authoritative sources (e.g. the rocketpool exporter) and are kept.
+func SavePoolAttributions(attributions []*types.PoolAttribution, resolved []*types.PoolAttribution) error {
+	tx, err := WriterDb.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM validator_pool_attributions`)
+	if err != nil {
+		return fmt.Errorf("error deleting pool attributions: %w", err)
+	}
+
+	batchSize := 9000 // max parameters: 65535
+	for b := 0; b < len(attributions); b += batchSize {
+		end := b + batchSize
+		if len(attributions) < end {
+			end = len(attributions)
+		}
+
+		numArgs := 7
+		valueStrings := make([]string, 0, end-b)
+		valueArgs := make([]interface{}, 0, (end-b)*numArgs)
+		for i, a := range attributions[b:end] {
+			valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)", i*numArgs+1, i*numArgs+2, i*numArgs+3, i*numArgs+4, i*numArgs+5, i*numArgs+6, i*numArgs+7))
+			valueArgs = append(valueArgs, a.Publickey, a.Pool, a.RuleType, a.RuleID, a.Evidence, a.Confidence, a.UpdatedTime)
+		}
+		_, err = tx.Exec(fmt.Sprintf(`
+			INSERT INTO validator_pool_attributions (publickey, pool, rule_type, rule_id, evidence, confidence, updated_ts) 
+			VALUES %s`, strings.Join(valueStrings, ",")), valueArgs...)
+		if err != nil {
+			return fmt.Errorf("error inserting pool attributions: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(`DELETE FROM validator_pool WHERE confidence IS NOT NULL`)
+	if err != nil {
+		return fmt.Errorf("error deleting attributed pools: %w", err)
+	}
+
+	batchSize = 20000
+	for b := 0; b < len(resolved); b += batchSize {
+		end := b + batchSize
+		if len(resolved) < end {
+			end = len(resolved)
+		}
+
+		numArgs := 3
+		valueStrings := make([]string, 0, end-b)
+		valueArgs := make([]interface{}, 0, (end-b)*numArgs)
+		for i, a := range resolved[b:end] {
+			valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d)", i*numArgs+1, i*numArgs+2, i*numArgs+3))
+			valueArgs = append(valueArgs, a.Publickey, a.Pool, a.Confidence)
+		}
+		_, err = tx.Exec(fmt.Sprintf(`
+			INSERT INTO validator_pool (publickey, pool, confidence) 
+			VALUES %s 
+			ON CONFLICT (publickey) DO NOTHING`, strings.Join(valueStrings, ",")), valueArgs...)
+		if err != nil {
+			return fmt.Errorf("error inserting attributed pools: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetValidatorPoolAttributions returns the audit trail of the pool attribution of the validators
+func GetValidatorPoolAttributions(pubkeys [][]byte) ([]*types.PoolAttribution, error) {
+	var attributions []*types.PoolAttribution
+	err := ReaderDb.Select(&attributions, `
+		SELECT publickey, pool, rule_type, rule_id, evidence, confidence, updated_ts 
+		FROM validator_pool_attributions 
+		WHERE publickey = ANY($1) 
+		ORDER BY publickey, confidence DESC`, pq.ByteaArray(pubkeys))
+	return attributions, err
+}
+
+// GetValidatorPools returns the pools of the validators including the audit trail of their attribution
+func GetValidatorPools(validators []uint64) ([]*types.ApiValidatorPoolResponse, error) {
+	var res []*types.ApiValidatorPoolResponse
+	err := ReaderDb.Select(&res, `
+		SELECT validators.validatorindex, validators.pubkey, validator_pool.pool, validator_pool.confidence
+		FROM validators
+		LEFT JOIN validator_pool ON validator_pool.publickey = validators.pubkey
+		WHERE validators.validatorindex = ANY($1)
+		ORDER BY validators.validatorindex`, pq.Array(validators))
+	if err != nil {
+		return nil, fmt.Errorf("error getting validator pools: %w", err)
+	}
+
+	pubkeys := make([][]byte, 0, len(res))
+	byPubkey := make(map[string]*types.ApiValidatorPoolResponse, len(res))
+	for _, v := range res {
+		v.Attributions = make([]*types.PoolAttribution, 0)
+		pubkeys = append(pubkeys, v.Pubkey)
+		byPubkey[string(v.Pubkey)] = v
+	}
+
+	attributions, err := GetValidatorPoolAttributions(pubkeys)
+	if err != nil {
+		return nil, fmt.Errorf("error getting validator pool attributions: %w", err)
+	}
+	for _, a := range attributions {
+		if v, ok := byPubkey[string(a.Publickey)]; ok {
+			v.Attributions = append(v.Attributions, a)
+		}
+	}
+	return res, nil
+}
@@ -271,6 +271,102 @@ func WriteValidatorStatisticsForDay(day uint64) error {
 	logger.Infof("statistics export of day %v completed, took %v", day, time.Since(exportStart))
 	return nil
 }
+
+// WriteValidatorAPRForDay persists the consensus and execution layer APR of every validator for the day.
+// The consensus layer income is taken from validator_stats, the execution layer income from eth_store_stats.
+// If eth_store_stats of the day has not been exported yet, the day is marked so it can be written again later.
+func WriteValidatorAPRForDay(day uint64) error {
+	exportStart := time.Now()
+	defer func() {
+		metrics.TaskDuration.WithLabelValues("db_update_validator_apr").Observe(time.Since(exportStart).Seconds())
+	}()
+
+	tx, err := WriterDb.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var execution bool
+	err = tx.Get(&execution, "select exists (select 1 from eth_store_stats where day = $1 and validator = -1)", day)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		insert into validator_apr (validatorindex, day, effective_balance, cl_income, el_income, cl_apr, el_apr)
+		(
+			select validatorindex, day, effective_balance, cl_income, el_income, cl_income::float8 * 365 / effective_balance, el_income::float8 * 365 / (effective_balance * 1e9)
+			from (
+				select 
+					s.validatorindex, 
+					s.day, 
+					s.start_effective_balance as effective_balance,
+					s.end_balance - coalesce(p.end_balance, s.start_balance) - coalesce(s.deposits_amount, 0) as cl_income,
+					coalesce(e.tx_fees_sum_wei, 0) as el_income
+				from validator_stats s
+				left join validator_stats p on p.validatorindex = s.validatorindex and p.day = s.day - 1
+				left join eth_store_stats e on e.validator = s.validatorindex and e.day = s.day
+				where s.day = $1 and s.start_effective_balance > 0 and s.end_balance is not null
+			) as income
+		)
+		on conflict (validatorindex, day) do update set 
+			effective_balance = excluded.effective_balance, 
+			cl_income = excluded.cl_income, 
+			el_income = excluded.el_income, 
+			cl_apr = excluded.cl_apr, 
+			el_apr = excluded.el_apr;`, day)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		insert into validator_apr_status (day, execution) values ($1, $2)
+		on conflict (day) do update set execution = excluded.execution`, day, execution)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("apr export of day %v completed (execution layer income available: %v), took %v", day, execution, time.Since(exportStart))
+	return nil
+}
+
+// GetValidatorAPRDaysMissingExecution returns the exported apr days whose execution layer income has since been exported to eth_store_stats
+func GetValidatorAPRDaysMissingExecution() ([]uint64, error) {
+	var days []uint64
+	err := WriterDb.Select(&days, `
+		select day from validator_apr_status 
+		where not execution and day in (select day from eth_store_stats where validator = -1)
+		order by day`)
+	return days, err
+}
+
+// GetValidatorAPRHistory returns the apr of the validators per day, weighted by their effective balance, along with the apr of ETH.STORE
+func GetValidatorAPRHistory(validators []uint64, lowerBoundDay uint64, upperBoundDay uint64) ([]*types.ValidatorAPRHistory, error) {
+	var result []*types.ValidatorAPRHistory
+	err := ReaderDb.Select(&result, `
+		select 
+			a.day,
+			count(*) as validators,
+			sum(a.effective_balance) as effective_balance,
+			sum(a.cl_income) as cl_income,
+			(sum(a.el_income) / 1e9)::bigint as el_income,
+			sum(a.cl_income)::float8 * 365 / sum(a.effective_balance) as cl_apr,
+			sum(a.el_income)::float8 * 365 / (sum(a.effective_balance) * 1e9) as el_apr,
+			(sum(a.cl_income) + sum(a.el_income) / 1e9)::float8 * 365 / sum(a.effective_balance) as apr,
+			(select e.apr from eth_store_stats e where e.validator = -1 and e.day = a.day) as eth_store_apr
+		from validator_apr a
+		where a.validatorindex = ANY($1) and a.day between $2 and $3
+		group by a.day
+		order by a.day`, pq.Array(validators), lowerBoundDay, upperBoundDay)
+	return result, err
+}
+
 func GetValidatorIncomeHistoryChart(validator_indices []uint64, currency string) ([]*types.ChartDataPoint, error) {
 	incomeHistory, err := GetValidatorIncomeHistory(validator_indices, 0, 0)
 	if err != nil {
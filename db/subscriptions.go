@@ -135,7 +135,7 @@ func GetTaggedValidators(filter WatchlistFilter) ([]*types.TaggedValidators, err
 			logger.Errorf("error could not get validators for watchlist. Expected to retrieve %v validators but got %v", len(list), len(validators))
 			for i, li := range list {
 				if li == nil {
-					logger.Errorf("empty validator entry", list[i])
+					logger.Errorf("empty validator entry %v", i)
 				} else {
 					li.Validator = &types.Validator{}
 				}
@@ -144,7 +144,7 @@ func GetTaggedValidators(filter WatchlistFilter) ([]*types.TaggedValidators, err
 		}
 		for i, li := range list {
 			if li == nil {
-				logger.Errorf("empty validator entry", list[i])
+				logger.Errorf("empty validator entry %v", i)
 			} else {
 				li.Validator = validators[i]
 			}
@@ -219,8 +219,8 @@ func GetSubscriptions(filter GetSubscriptionsFilter) ([]*types.Subscription, err
 }
 
 // UpdateSubscriptionsLastSent updates `last_sent_ts` column of the `users_subscriptions` table.
-func UpdateSubscriptionsLastSent(subscriptionIDs []uint64, sent time.Time, epoch uint64, useDB *sqlx.DB) error {
-	_, err := useDB.Exec(`
+func UpdateSubscriptionsLastSent(subscriptionIDs []uint64, sent time.Time, epoch uint64, tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
 		UPDATE users_subscriptions
 		SET last_sent_ts = TO_TIMESTAMP($1), last_sent_epoch = $2
 		WHERE id = ANY($3)`, sent.Unix(), epoch, pq.Array(subscriptionIDs))
@@ -255,3 +255,200 @@ func GetMailsSentCount(email string, t time.Time) (int, error) {
 	}
 	return count, err
 }
+
+// AddSubscriptionRule adds a rule that subscribes the user to the event for all of their validators tagged with tag.
+// A rule is stored as a single subscription with the event filter "tag:<tag>", its validators are resolved when notifications are collected.
+// The validator limit of the current premium package of the user applies to all of their rules together.
+func AddSubscriptionRule(userID uint64, network string, eventName types.EventName, tag string, eventThreshold float64) error {
+	now := time.Now()
+	name := strings.ToLower(network) + ":" + string(eventName)
+
+	_, err := FrontendWriterDB.Exec(`
+		INSERT INTO users_subscriptions (user_id, event_name, event_filter, created_ts, created_epoch, event_threshold)
+		VALUES ($1, $2, $3, TO_TIMESTAMP($4), $5, $6)
+		ON CONFLICT (user_id, event_name, event_filter) DO UPDATE SET event_threshold = EXCLUDED.event_threshold`,
+		userID, name, types.SubscriptionRuleFilterPrefix+tag, now.Unix(), utils.TimeToEpoch(now), eventThreshold)
+	if err != nil {
+		return fmt.Errorf("error adding subscription rule: %w", err)
+	}
+	return nil
+}
+
+// DeleteSubscriptionRule removes a subscription rule together with the state of its validators
+func DeleteSubscriptionRule(userID uint64, network string, eventName types.EventName, tag string) error {
+	name := strings.ToLower(network) + ":" + string(eventName)
+
+	tx, err := FrontendWriterDB.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting db transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		DELETE FROM users_subscription_rule_states
+		WHERE subscription_id IN (SELECT id FROM users_subscriptions WHERE user_id = $1 AND event_name = $2 AND event_filter = $3)`,
+		userID, name, types.SubscriptionRuleFilterPrefix+tag)
+	if err != nil {
+		return fmt.Errorf("error deleting subscription rule states: %w", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM users_subscriptions WHERE user_id = $1 AND event_name = $2 AND event_filter = $3`, userID, name, types.SubscriptionRuleFilterPrefix+tag)
+	if err != nil {
+		return fmt.Errorf("error deleting subscription rule: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// subscriptionRuleValidatorsQuery resolves the subscription rules of a network ($1) to their validators. The validators of a user are
+// ranked (n) across all of their rules so that the validator limit of the user covers the same validators for every rule, the limit
+// depends on the package of the current premium subscription of the user and is applied by subscriptionRuleWithinLimit. Validators
+// with a subscription of their own are left out, they are notified through that subscription.
+const subscriptionRuleValidatorsQuery = `
+	SELECT
+		id,
+		user_id,
+		event_name,
+		event_filter,
+		last_sent_ts,
+		last_sent_epoch,
+		created_ts,
+		created_epoch,
+		event_threshold,
+		unsubscribe_hash,
+		internal_state,
+		n,
+		package
+	FROM (
+		SELECT
+			us.id,
+			us.user_id,
+			us.event_name,
+			ENCODE(uvt.validator_publickey, 'hex') AS event_filter,
+			s.last_sent_ts,
+			s.last_sent_epoch,
+			us.created_ts,
+			us.created_epoch,
+			us.event_threshold,
+			ENCODE(us.unsubscribe_hash, 'hex') AS unsubscribe_hash,
+			s.internal_state,
+			COALESCE(p.product_id, '') AS package,
+			DENSE_RANK() OVER (PARTITION BY us.user_id ORDER BY uvt.validator_publickey) AS n
+		FROM users_subscriptions us
+		LEFT JOIN LATERAL (
+			SELECT product_id FROM users_app_subscriptions WHERE user_id = us.user_id AND active = true ORDER BY id DESC LIMIT 1
+		) p ON true
+		INNER JOIN users_validators_tags uvt ON uvt.user_id = us.user_id AND uvt.tag = $1 || ':' || SUBSTRING(us.event_filter FROM 5)
+		LEFT JOIN users_subscription_rule_states s ON s.subscription_id = us.id AND s.event_filter = ENCODE(uvt.validator_publickey, 'hex')
+		WHERE us.event_name LIKE $1 || ':%' AND us.event_filter LIKE 'tag:%'
+	) rules
+	WHERE
+		NOT EXISTS (SELECT 1 FROM users_subscriptions m WHERE m.user_id = rules.user_id AND m.event_name = rules.event_name AND m.event_filter = rules.event_filter)`
+
+// GetSubscriptionRuleSubs resolves the subscription rules of the event to one subscription per covered validator. The subscriptions
+// carry the id of their rule and the pubkey of the validator as event filter, last sent and internal state are those of the validator.
+// If pubkeys is not nil only the subscriptions of these validators are returned.
+func GetSubscriptionRuleSubs(eventName types.EventName, pubkeys []string) ([]types.Subscription, error) {
+	network := utils.GetNetwork()
+	qry := subscriptionRuleValidatorsQuery + " AND rules.event_name = $2"
+	args := []interface{}{network, network + ":" + string(eventName)}
+	if pubkeys != nil {
+		qry += " AND rules.event_filter = ANY($3)"
+		args = append(args, pq.StringArray(pubkeys))
+	}
+
+	rows := []subscriptionRuleValidator{}
+	err := FrontendWriterDB.Select(&rows, qry, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving subscription rules of %v: %w", eventName, err)
+	}
+	subs := make([]types.Subscription, 0, len(rows))
+	for _, row := range rows {
+		if subscriptionRuleWithinLimit(row) {
+			subs = append(subs, row.Subscription)
+		}
+	}
+	return subs, nil
+}
+
+// subscriptionRuleValidator is a validator covered by a subscription rule together with its rank among the validators of the
+// rules of the user and the premium package of the user
+type subscriptionRuleValidator struct {
+	types.Subscription
+	N       int    `db:"n"`
+	Package string `db:"package"`
+}
+
+// subscriptionRuleWithinLimit reports whether the validator is within the validator limit of the current premium package of the user
+func subscriptionRuleWithinLimit(v subscriptionRuleValidator) bool {
+	return v.N <= utils.GetMaxValidators(v.Package)
+}
+
+// GetSubscriptionRules returns the subscription rules of a user together with the number of validators they currently cover
+func GetSubscriptionRules(userID uint64, network string) ([]*types.SubscriptionRule, error) {
+	network = strings.ToLower(network)
+	rules := []*types.SubscriptionRule{}
+	err := FrontendWriterDB.Select(&rules, `
+		SELECT
+			us.id,
+			us.user_id,
+			us.event_name,
+			SUBSTRING(us.event_filter FROM 5) AS tag,
+			us.event_threshold,
+			us.created_ts
+		FROM users_subscriptions us
+		WHERE us.user_id = $2 AND us.event_name LIKE $1 || ':%' AND us.event_filter LIKE 'tag:%'
+		ORDER BY tag, us.event_name`, network, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return rules, nil
+	}
+
+	pkg, err := GetUserPremiumPackage(userID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("error getting premium package of user %v: %w", userID, err)
+	}
+	validators := []subscriptionRuleValidator{}
+	err = FrontendWriterDB.Select(&validators, subscriptionRuleValidatorsQuery+" AND rules.user_id = $2", network, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving subscription rules of user %v: %w", userID, err)
+	}
+	validatorsByRule := make(map[uint64]uint64, len(rules))
+	for _, v := range validators {
+		if subscriptionRuleWithinLimit(v) {
+			validatorsByRule[*v.ID]++
+		}
+	}
+	for _, rule := range rules {
+		rule.MaxValidators = uint64(utils.GetMaxValidators(pkg.Package))
+		rule.Validators = validatorsByRule[rule.ID]
+	}
+	return rules, nil
+}
+
+// UpdateSubscriptionRuleStates stores the last sent epoch and internal state of the validators of subscription rules that notifications
+// have been queued for. Notifications of regular subscriptions are ignored.
+func UpdateSubscriptionRuleStates(subscriptionIDs []uint64, eventFilters []string, epochs []uint64, states []string, sent time.Time, tx *sqlx.Tx) error {
+	ids := make(pq.Int64Array, 0, len(subscriptionIDs))
+	for _, id := range subscriptionIDs {
+		ids = append(ids, int64(id))
+	}
+	notifiedEpochs := make(pq.Int64Array, 0, len(epochs))
+	for _, epoch := range epochs {
+		notifiedEpochs = append(notifiedEpochs, int64(epoch))
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO users_subscription_rule_states (subscription_id, event_filter, last_sent_ts, last_sent_epoch, internal_state)
+		SELECT n.subscription_id, n.event_filter, TO_TIMESTAMP($5), n.epoch, NULLIF(n.state, '')
+		FROM UNNEST($1::int[], $2::text[], $3::int[], $4::text[]) AS n(subscription_id, event_filter, epoch, state)
+		INNER JOIN users_subscriptions us ON us.id = n.subscription_id AND us.event_filter LIKE 'tag:%'
+		ON CONFLICT (subscription_id, event_filter) DO UPDATE SET
+			last_sent_ts = EXCLUDED.last_sent_ts,
+			last_sent_epoch = EXCLUDED.last_sent_epoch,
+			internal_state = COALESCE(EXCLUDED.internal_state, users_subscription_rule_states.internal_state)`,
+		ids, pq.StringArray(eventFilters), notifiedEpochs, pq.StringArray(states), sent.Unix())
+	return err
+}
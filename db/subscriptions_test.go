@@ -0,0 +1,187 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestMain(m *testing.M) {
+	utils.Config = &types.Config{}
+	utils.Config.Chain.Config.ConfigName = "mainnet"
+	os.Exit(m.Run())
+}
+
+// scriptedDriver is a database driver that records the executed statements and answers queries with the rows of the first
+// result whose key is contained in the query
+type scriptedDriver struct {
+	mu         sync.Mutex
+	statements []string
+	results    []scriptedRows
+}
+
+type scriptedRows struct {
+	key     string
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (d *scriptedDriver) Open(name string) (driver.Conn, error) { return &scriptedConn{d}, nil }
+
+type scriptedConn struct{ d *scriptedDriver }
+
+func (c *scriptedConn) Prepare(query string) (driver.Stmt, error) {
+	return &scriptedStmt{c.d, query}, nil
+}
+func (c *scriptedConn) Close() error              { return nil }
+func (c *scriptedConn) Begin() (driver.Tx, error) { return scriptedTx{}, nil }
+
+type scriptedTx struct{}
+
+func (scriptedTx) Commit() error   { return nil }
+func (scriptedTx) Rollback() error { return nil }
+
+type scriptedStmt struct {
+	d     *scriptedDriver
+	query string
+}
+
+func (s *scriptedStmt) Close() error  { return nil }
+func (s *scriptedStmt) NumInput() int { return -1 }
+func (s *scriptedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.statements = append(s.d.statements, s.query)
+	return driver.RowsAffected(1), nil
+}
+func (s *scriptedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.statements = append(s.d.statements, s.query)
+	for _, result := range s.d.results {
+		if strings.Contains(s.query, result.key) {
+			return &scriptedRowsIter{result: result}, nil
+		}
+	}
+	return &scriptedRowsIter{}, nil
+}
+
+type scriptedRowsIter struct {
+	result scriptedRows
+	i      int
+}
+
+func (r *scriptedRowsIter) Columns() []string { return r.result.columns }
+func (r *scriptedRowsIter) Close() error      { return nil }
+func (r *scriptedRowsIter) Next(dest []driver.Value) error {
+	if r.i >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.i])
+	r.i++
+	return nil
+}
+
+var scriptedDriverCount = 0
+
+// useScriptedFrontendDB replaces the frontend database with a scripted one for the duration of the test
+func useScriptedFrontendDB(t *testing.T, results ...scriptedRows) *scriptedDriver {
+	d := &scriptedDriver{results: results}
+	scriptedDriverCount++
+	name := fmt.Sprintf("scripted%d", scriptedDriverCount)
+	sql.Register(name, d)
+	conn, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := FrontendWriterDB
+	FrontendWriterDB = sqlx.NewDb(conn, "postgres")
+	t.Cleanup(func() { FrontendWriterDB = previous })
+	return d
+}
+
+func subscriptionRuleRow(id, userID int64, pubkey string, n int64, pkg string) []driver.Value {
+	return []driver.Value{id, userID, "mainnet:validator_balance_decreased", pubkey, nil, nil, time.Unix(0, 0), int64(0), float64(0), nil, nil, n, pkg}
+}
+
+var subscriptionRuleColumns = []string{"id", "user_id", "event_name", "event_filter", "last_sent_ts", "last_sent_epoch", "created_ts", "created_epoch", "event_threshold", "unsubscribe_hash", "internal_state", "n", "package"}
+
+func TestGetSubscriptionRuleSubsAppliesCurrentPackageLimit(t *testing.T) {
+	rows := [][]driver.Value{}
+	// user 1 has no premium package, user 2 is a whale, their validators are ranked across all of their rules
+	for n := int64(1); n <= 150; n++ {
+		rows = append(rows, subscriptionRuleRow(1, 1, "aa", n, ""))
+	}
+	for n := int64(1); n <= 350; n++ {
+		rows = append(rows, subscriptionRuleRow(2, 2, "bb", n, "whale"))
+	}
+	useScriptedFrontendDB(t, scriptedRows{key: "users_validators_tags", columns: subscriptionRuleColumns, rows: rows})
+
+	subs, err := GetSubscriptionRuleSubs(types.ValidatorBalanceDecreasedEventName, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subsByUser := map[uint64]int{}
+	for _, sub := range subs {
+		subsByUser[*sub.UserID]++
+	}
+	if subsByUser[1] != 100 || subsByUser[2] != 300 {
+		t.Errorf("expected 100 validators of the standard user and 300 of the whale, got %v", subsByUser)
+	}
+}
+
+func TestGetSubscriptionRulesCountsValidatorsWithinCurrentPackageLimit(t *testing.T) {
+	rows := [][]driver.Value{}
+	// the first rule covers the first 250 validators of the user, the second the following 100
+	for n := int64(1); n <= 350; n++ {
+		id := int64(1)
+		if n > 250 {
+			id = 2
+		}
+		rows = append(rows, subscriptionRuleRow(id, 1, "aa", n, "whale"))
+	}
+	useScriptedFrontendDB(t,
+		scriptedRows{key: "users_validators_tags", columns: subscriptionRuleColumns, rows: rows},
+		scriptedRows{key: "AS tag", columns: []string{"id", "user_id", "event_name", "tag", "event_threshold", "created_ts"}, rows: [][]driver.Value{
+			{int64(1), int64(1), "mainnet:validator_balance_decreased", "teku-eu", float64(0), time.Unix(0, 0)},
+			{int64(2), int64(1), "mainnet:validator_got_slashed", "teku-us", float64(0), time.Unix(0, 0)},
+		}},
+		scriptedRows{key: "as product_id", columns: []string{"product_id", "store"}, rows: [][]driver.Value{{"whale", "ios-appstore"}}},
+	)
+
+	rules, err := GetSubscriptionRules(1, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %v", len(rules))
+	}
+	if rules[0].MaxValidators != 300 || rules[0].Validators != 250 || rules[1].Validators != 50 {
+		t.Errorf("expected the rules to cover 250 and 50 of the 300 validators of a whale, got %+v %+v", rules[0], rules[1])
+	}
+}
+
+func TestDeleteSubscriptionKeepsSubscriptionRules(t *testing.T) {
+	d := useScriptedFrontendDB(t)
+
+	err := DeleteSubscription(1, "mainnet", types.ValidatorBalanceDecreasedEventName, "aa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.statements) != 1 {
+		t.Fatalf("expected a single statement, got %v", d.statements)
+	}
+	if !strings.Contains(d.statements[0], "DELETE FROM users_subscriptions") || !strings.Contains(d.statements[0], "event_filter NOT LIKE 'tag:%'") {
+		t.Errorf("expected only explicit subscriptions to be deleted, got %v", d.statements[0])
+	}
+}
@@ -43,6 +43,9 @@ func Start(client rpc.Client) error {
 	if utils.Config.RocketpoolExporter.Enabled {
 		go rocketpoolExporter()
 	}
+	if utils.Config.LidoExporter.Enabled {
+		go lidoExporter()
+	}
 
 	if utils.Config.Indexer.PubKeyTagsExporter.Enabled {
 		go UpdatePubkeyTag()
@@ -333,6 +336,8 @@ func doFullCheck(client rpc.Client, lookback uint64) {
 	}
 
 	epochsToExport := make(map[uint64]bool)
+	// exported blocks which are not on the node anymore or have changed were reorged
+	reorgedEpochs := make(map[uint64]bool)
 
 	for key, block := range blocksMap {
 		if block.Db == nil {
@@ -342,10 +347,12 @@ func doFullCheck(client rpc.Client, lookback uint64) {
 			if !strings.HasSuffix(key, "-00") {
 				logger.Printf("queuing epoch %v for export as block %v is present on the db but missing in the node", block.Epoch, key)
 				epochsToExport[block.Epoch] = true
+				reorgedEpochs[block.Epoch] = true
 			}
 		} else if !bytes.Equal(block.Db.BlockRoot, block.Node.BlockRoot) {
 			logger.Printf("queuing epoch %v for export as block %v has a different hash in the db as on the node", block.Epoch, key)
 			epochsToExport[block.Epoch] = true
+			reorgedEpochs[block.Epoch] = true
 		}
 	}
 
@@ -418,6 +425,19 @@ func doFullCheck(client rpc.Client, lookback uint64) {
 		logger.Errorf("error marking orphaned blocks: %v", err)
 	}
 
+	if len(reorgedEpochs) > 0 {
+		invalidated := make([]uint64, 0, len(reorgedEpochs))
+		for epoch := range reorgedEpochs {
+			invalidated = append(invalidated, epoch)
+		}
+		sort.Slice(invalidated, func(i, j int) bool { return invalidated[i] < invalidated[j] })
+		logger.Infof("invalidating the api response cache of epochs %v after a reorg", invalidated)
+		err = services.InvalidateApiResponseCache(invalidated)
+		if err != nil {
+			logger.Errorf("error invalidating the api response cache: %v", err)
+		}
+	}
+
 	// Update epoch statistics up to 10 epochs after the last finalized epoch
 	startEpoch = uint64(0)
 	if head.FinalizedEpoch > 10 {
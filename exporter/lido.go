@@ -0,0 +1,503 @@
+package exporter
+
+import (
+	"context"
+	"database/sql"
+	"eth2-exporter/db"
+	"eth2-exporter/utils"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// the events of the node operators registry and the oracle are only exported once they are this many blocks deep
+const lidoBlockConfirmations = 64
+
+// lidoMaxEventLogIntervals limits the number of event log requests per update so the progress of the initial sync is saved regularly
+const lidoMaxEventLogIntervals = 20
+
+// https://github.com/lidofinance/lido-dao/blob/master/contracts/0.4.24/nos/NodeOperatorsRegistry.sol
+const lidoNodeOperatorsRegistryABI = `[
+	{"constant":true,"inputs":[],"name":"getNodeOperatorsCount","outputs":[{"name":"","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"},
+	{"constant":true,"inputs":[{"name":"_id","type":"uint256"},{"name":"_fullInfo","type":"bool"}],"name":"getNodeOperator","outputs":[{"name":"active","type":"bool"},{"name":"name","type":"string"},{"name":"rewardAddress","type":"address"},{"name":"stakingLimit","type":"uint64"},{"name":"stoppedValidators","type":"uint64"},{"name":"totalSigningKeys","type":"uint64"},{"name":"usedSigningKeys","type":"uint64"}],"payable":false,"stateMutability":"view","type":"function"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"operatorId","type":"uint256"},{"indexed":false,"name":"pubkey","type":"bytes"}],"name":"SigningKeyAdded","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"operatorId","type":"uint256"},{"indexed":false,"name":"pubkey","type":"bytes"}],"name":"SigningKeyRemoved","type":"event"}
+]`
+
+// https://github.com/lidofinance/lido-dao/blob/master/contracts/0.4.24/oracle/LidoOracle.sol
+const lidoOracleABI = `[
+	{"anonymous":false,"inputs":[{"indexed":false,"name":"epochId","type":"uint256"},{"indexed":false,"name":"beaconBalance","type":"uint128"},{"indexed":false,"name":"beaconValidators","type":"uint128"}],"name":"Completed","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":false,"name":"postTotalPooledEther","type":"uint256"},{"indexed":false,"name":"preTotalPooledEther","type":"uint256"},{"indexed":false,"name":"timeElapsed","type":"uint256"},{"indexed":false,"name":"totalShares","type":"uint256"}],"name":"PostTotalShares","type":"event"}
+]`
+
+var lidoRegistryContractABI = mustParseABI(lidoNodeOperatorsRegistryABI)
+var lidoOracleContractABI = mustParseABI(lidoOracleABI)
+
+func mustParseABI(s string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(s))
+	if err != nil {
+		logger.Fatalf("error parsing abi: %v", err)
+	}
+	return parsed
+}
+
+func lidoExporter() {
+	_, eth1Client, err := dialProtocolEth1Client()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	cfg := utils.Config.LidoExporter
+	le, err := NewLidoExporter(eth1Client, cfg.NodeOperatorsRegistryAddress, cfg.NodeOperatorsRegistryFirstBlock, cfg.OracleAddress, db.WriterDb)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	runProtocolExporter(le, le.UpdateInterval)
+}
+
+type LidoExporter struct {
+	Eth1Client         *ethclient.Client
+	Registry           *bind.BoundContract
+	RegistryAddress    common.Address
+	RegistryFirstBlock uint64
+	OracleAddress      common.Address
+	DB                 *sqlx.DB
+	UpdateInterval     time.Duration
+	// LastBlock is the last block whose events are saved, PendingBlock the last block whose events are fetched but not saved yet
+	LastBlock           uint64
+	PendingBlock        uint64
+	NodeOperatorsByID   map[uint64]*LidoNodeOperator
+	SigningKeysByPubkey map[string]*LidoSigningKey
+	OracleReports       []*LidoOracleReport
+	// UntaggedPubkeys are the saved keys that were not tagged yet, all keys are tagged again after Init if TagAll is set
+	UntaggedPubkeys [][]byte
+	TagAll          bool
+}
+
+type LidoNodeOperator struct {
+	ID                uint64 `db:"id"`
+	Name              string `db:"name"`
+	RewardAddress     []byte `db:"reward_address"`
+	Active            bool   `db:"active"`
+	StakingLimit      uint64 `db:"staking_limit"`
+	StoppedValidators uint64 `db:"stopped_validators"`
+	TotalSigningKeys  uint64 `db:"total_signing_keys"`
+	UsedSigningKeys   uint64 `db:"used_signing_keys"`
+}
+
+// LidoSigningKey is the state of a validator key after the fetched events, Added is not set if only its removal was fetched
+type LidoSigningKey struct {
+	Pubkey       []byte
+	OperatorID   uint64
+	Added        bool
+	AddedBlock   uint64
+	RemovedBlock sql.NullInt64
+}
+
+type LidoOracleReport struct {
+	Epoch                uint64
+	Block                uint64
+	TxHash               []byte
+	BeaconBalance        *big.Int
+	BeaconValidators     uint64
+	PreTotalPooledEther  *big.Int
+	PostTotalPooledEther *big.Int
+	TimeElapsed          uint64
+	TotalShares          *big.Int
+}
+
+func NewLidoExporter(eth1Client *ethclient.Client, registryAddressHex string, registryFirstBlock uint64, oracleAddressHex string, db *sqlx.DB) (*LidoExporter, error) {
+	if !common.IsHexAddress(registryAddressHex) || !common.IsHexAddress(oracleAddressHex) {
+		return nil, fmt.Errorf("invalid lido node operators registry (%v) or oracle (%v) address", registryAddressHex, oracleAddressHex)
+	}
+	le := &LidoExporter{}
+	le.Eth1Client = eth1Client
+	le.RegistryAddress = common.HexToAddress(registryAddressHex)
+	le.RegistryFirstBlock = registryFirstBlock
+	le.OracleAddress = common.HexToAddress(oracleAddressHex)
+	le.Registry = bind.NewBoundContract(le.RegistryAddress, lidoRegistryContractABI, eth1Client, eth1Client, eth1Client)
+	le.DB = db
+	le.UpdateInterval = time.Second * 60
+	le.NodeOperatorsByID = map[uint64]*LidoNodeOperator{}
+	le.SigningKeysByPubkey = map[string]*LidoSigningKey{}
+	le.OracleReports = []*LidoOracleReport{}
+	return le, nil
+}
+
+func (le *LidoExporter) Name() string {
+	return "lido"
+}
+
+// Init continues the export of the events after the last saved block
+func (le *LidoExporter) Init() error {
+	le.LastBlock = 0
+	if le.RegistryFirstBlock > 0 {
+		le.LastBlock = le.RegistryFirstBlock - 1
+	}
+	err := le.DB.Get(&le.LastBlock, `select last_block from lido_exporter_status where registry_address = $1`, le.RegistryAddress.Bytes())
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error getting lido exporter status: %w", err)
+	}
+	// keys saved before a restart might not have been tagged
+	le.TagAll = true
+	return nil
+}
+
+func (le *LidoExporter) Update(count int64) error {
+	var wg errgroup.Group
+	wg.Go(func() error { return le.UpdateNodeOperators() })
+	wg.Go(func() error { return le.UpdateEvents() })
+	return wg.Wait()
+}
+
+func (le *LidoExporter) Save(count int64) error {
+	err := le.SaveNodeOperators()
+	if err != nil {
+		return err
+	}
+	return le.SaveEvents()
+}
+
+func (le *LidoExporter) UpdateNodeOperators() error {
+	t0 := time.Now()
+	defer func(t0 time.Time) {
+		logger.WithFields(logrus.Fields{"duration": time.Since(t0)}).Infof("updated lido-node-operators")
+	}(t0)
+
+	var out []interface{}
+	err := le.Registry.Call(nil, &out, "getNodeOperatorsCount")
+	if err != nil {
+		return fmt.Errorf("error getting lido node operators count: %w", err)
+	}
+	count := out[0].(*big.Int).Uint64()
+
+	for id := uint64(0); id < count; id++ {
+		var out []interface{}
+		err := le.Registry.Call(nil, &out, "getNodeOperator", new(big.Int).SetUint64(id), true)
+		if err != nil {
+			return fmt.Errorf("error getting lido node operator %v: %w", id, err)
+		}
+		rewardAddress := out[2].(common.Address)
+		le.NodeOperatorsByID[id] = &LidoNodeOperator{
+			ID:                id,
+			Active:            out[0].(bool),
+			Name:              out[1].(string),
+			RewardAddress:     rewardAddress.Bytes(),
+			StakingLimit:      out[3].(uint64),
+			StoppedValidators: out[4].(uint64),
+			TotalSigningKeys:  out[5].(uint64),
+			UsedSigningKeys:   out[6].(uint64),
+		}
+	}
+	return nil
+}
+
+// UpdateEvents fetches the signing key events of the registry and the reports of the oracle after the last saved block
+func (le *LidoExporter) UpdateEvents() error {
+	t0 := time.Now()
+	defer func(t0 time.Time) {
+		logger.WithFields(logrus.Fields{"duration": time.Since(t0)}).Infof("updated lido-events")
+	}(t0)
+
+	le.SigningKeysByPubkey = map[string]*LidoSigningKey{}
+	le.OracleReports = []*LidoOracleReport{}
+	le.PendingBlock = le.LastBlock
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+	defer cancel()
+
+	head, err := le.Eth1Client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting eth1 head: %w", err)
+	}
+	if head < lidoBlockConfirmations {
+		return nil
+	}
+	head -= lidoBlockConfirmations
+
+	topics := [][]common.Hash{{
+		lidoRegistryContractABI.Events["SigningKeyAdded"].ID,
+		lidoRegistryContractABI.Events["SigningKeyRemoved"].ID,
+		lidoOracleContractABI.Events["Completed"].ID,
+		lidoOracleContractABI.Events["PostTotalShares"].ID,
+	}}
+
+	for i := 0; i < lidoMaxEventLogIntervals && le.PendingBlock < head; i++ {
+		from := le.PendingBlock + 1
+		to := from + GethEventLogInterval - 1
+		if to > head {
+			to = head
+		}
+		logs, err := le.Eth1Client.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: []common.Address{le.RegistryAddress, le.OracleAddress},
+			Topics:    topics,
+		})
+		if err != nil {
+			return fmt.Errorf("error getting lido events from block %v to %v: %w", from, to, err)
+		}
+		err = le.applyEvents(logs)
+		if err != nil {
+			return err
+		}
+		le.PendingBlock = to
+	}
+	return nil
+}
+
+func (le *LidoExporter) applyEvents(logs []types.Log) error {
+	sort.Slice(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+
+	// the Completed and PostTotalShares events of a report are emitted in the same transaction
+	reportsByTx := map[common.Hash]*LidoOracleReport{}
+	reportByTx := func(log types.Log) *LidoOracleReport {
+		if r, exists := reportsByTx[log.TxHash]; exists {
+			return r
+		}
+		r := &LidoOracleReport{Block: log.BlockNumber, TxHash: log.TxHash.Bytes()}
+		reportsByTx[log.TxHash] = r
+		le.OracleReports = append(le.OracleReports, r)
+		return r
+	}
+
+	for _, log := range logs {
+		if log.Removed || len(log.Topics) == 0 {
+			continue
+		}
+		switch {
+		case log.Address == le.RegistryAddress && len(log.Topics) == 2 && log.Topics[0] == lidoRegistryContractABI.Events["SigningKeyAdded"].ID:
+			pubkey, err := unpackLidoSigningKey(log)
+			if err != nil {
+				return err
+			}
+			le.SigningKeysByPubkey[string(pubkey)] = &LidoSigningKey{
+				Pubkey:     pubkey,
+				OperatorID: new(big.Int).SetBytes(log.Topics[1].Bytes()).Uint64(),
+				Added:      true,
+				AddedBlock: log.BlockNumber,
+			}
+		case log.Address == le.RegistryAddress && len(log.Topics) == 2 && log.Topics[0] == lidoRegistryContractABI.Events["SigningKeyRemoved"].ID:
+			pubkey, err := unpackLidoSigningKey(log)
+			if err != nil {
+				return err
+			}
+			key, exists := le.SigningKeysByPubkey[string(pubkey)]
+			if !exists {
+				key = &LidoSigningKey{Pubkey: pubkey, OperatorID: new(big.Int).SetBytes(log.Topics[1].Bytes()).Uint64()}
+				le.SigningKeysByPubkey[string(pubkey)] = key
+			}
+			key.RemovedBlock = sql.NullInt64{Int64: int64(log.BlockNumber), Valid: true}
+		case log.Address == le.OracleAddress && log.Topics[0] == lidoOracleContractABI.Events["Completed"].ID:
+			ev := struct {
+				EpochId          *big.Int
+				BeaconBalance    *big.Int
+				BeaconValidators *big.Int
+			}{}
+			err := lidoOracleContractABI.UnpackIntoInterface(&ev, "Completed", log.Data)
+			if err != nil {
+				return fmt.Errorf("error unpacking lido oracle report in tx %v: %w", log.TxHash, err)
+			}
+			r := reportByTx(log)
+			r.Epoch = ev.EpochId.Uint64()
+			r.BeaconBalance = ev.BeaconBalance
+			r.BeaconValidators = ev.BeaconValidators.Uint64()
+		case log.Address == le.OracleAddress && log.Topics[0] == lidoOracleContractABI.Events["PostTotalShares"].ID:
+			ev := struct {
+				PostTotalPooledEther *big.Int
+				PreTotalPooledEther  *big.Int
+				TimeElapsed          *big.Int
+				TotalShares          *big.Int
+			}{}
+			err := lidoOracleContractABI.UnpackIntoInterface(&ev, "PostTotalShares", log.Data)
+			if err != nil {
+				return fmt.Errorf("error unpacking lido oracle total shares in tx %v: %w", log.TxHash, err)
+			}
+			r := reportByTx(log)
+			r.PostTotalPooledEther = ev.PostTotalPooledEther
+			r.PreTotalPooledEther = ev.PreTotalPooledEther
+			r.TimeElapsed = ev.TimeElapsed.Uint64()
+			r.TotalShares = ev.TotalShares
+		}
+	}
+	return nil
+}
+
+func unpackLidoSigningKey(log types.Log) ([]byte, error) {
+	ev := struct {
+		Pubkey []byte
+	}{}
+	err := lidoRegistryContractABI.UnpackIntoInterface(&ev, "SigningKeyAdded", log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("error unpacking lido signing key event in tx %v: %w", log.TxHash, err)
+	}
+	return ev.Pubkey, nil
+}
+
+func (le *LidoExporter) SaveNodeOperators() error {
+	if len(le.NodeOperatorsByID) == 0 {
+		return nil
+	}
+
+	t0 := time.Now()
+	defer func(t0 time.Time) {
+		logger.WithFields(logrus.Fields{"duration": time.Since(t0)}).Debugf("saved lido-node-operators")
+	}(t0)
+
+	n := 9
+	valueStrings := make([]string, 0, len(le.NodeOperatorsByID))
+	valueArgs := make([]interface{}, 0, len(le.NodeOperatorsByID)*n)
+	i := 0
+	for _, op := range le.NodeOperatorsByID {
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", i*n+1, i*n+2, i*n+3, i*n+4, i*n+5, i*n+6, i*n+7, i*n+8, i*n+9))
+		valueArgs = append(valueArgs, le.RegistryAddress.Bytes(), op.ID, op.Name, op.RewardAddress, op.Active, op.StakingLimit, op.StoppedValidators, op.TotalSigningKeys, op.UsedSigningKeys)
+		i++
+	}
+	_, err := le.DB.Exec(fmt.Sprintf(`
+		insert into lido_node_operators (registry_address, id, name, reward_address, active, staking_limit, stopped_validators, total_signing_keys, used_signing_keys)
+		values %s
+		on conflict (registry_address, id) do update set
+			name = excluded.name,
+			reward_address = excluded.reward_address,
+			active = excluded.active,
+			staking_limit = excluded.staking_limit,
+			stopped_validators = excluded.stopped_validators,
+			total_signing_keys = excluded.total_signing_keys,
+			used_signing_keys = excluded.used_signing_keys`, strings.Join(valueStrings, ",")), valueArgs...)
+	if err != nil {
+		return fmt.Errorf("error saving lido node operators: %w", err)
+	}
+	return nil
+}
+
+// SaveEvents saves the fetched signing keys and oracle reports together with the block they were exported up to
+func (le *LidoExporter) SaveEvents() error {
+	if le.PendingBlock == le.LastBlock {
+		return nil
+	}
+
+	t0 := time.Now()
+	defer func(t0 time.Time) {
+		logger.WithFields(logrus.Fields{"duration": time.Since(t0), "keys": len(le.SigningKeysByPubkey), "reports": len(le.OracleReports)}).Debugf("saved lido-events")
+	}(t0)
+
+	tx, err := le.DB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	added := make([]*LidoSigningKey, 0, len(le.SigningKeysByPubkey))
+	removed := make(map[int64]pq.ByteaArray)
+	for _, key := range le.SigningKeysByPubkey {
+		if key.Added {
+			added = append(added, key)
+		} else {
+			removed[key.RemovedBlock.Int64] = append(removed[key.RemovedBlock.Int64], key.Pubkey)
+		}
+	}
+
+	batchSize := 5000 // max parameters: 65535
+	for b := 0; b < len(added); b += batchSize {
+		start := b
+		end := b + batchSize
+		if len(added) < end {
+			end = len(added)
+		}
+		n := 5
+		valueStrings := make([]string, 0, batchSize)
+		valueArgs := make([]interface{}, 0, batchSize*n)
+		for i, key := range added[start:end] {
+			valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d)", i*n+1, i*n+2, i*n+3, i*n+4, i*n+5))
+			valueArgs = append(valueArgs, le.RegistryAddress.Bytes(), key.Pubkey, key.OperatorID, key.AddedBlock, key.RemovedBlock)
+		}
+		_, err := tx.Exec(fmt.Sprintf(`
+			insert into lido_signing_keys (registry_address, pubkey, operator_id, added_block, removed_block)
+			values %s
+			on conflict (registry_address, pubkey) do update set
+				operator_id = excluded.operator_id,
+				added_block = excluded.added_block,
+				removed_block = excluded.removed_block`, strings.Join(valueStrings, ",")), valueArgs...)
+		if err != nil {
+			return fmt.Errorf("error saving lido signing keys: %w", err)
+		}
+	}
+	for block, pubkeys := range removed {
+		_, err := tx.Exec(`update lido_signing_keys set removed_block = $1 where registry_address = $2 and pubkey = any($3)`, block, le.RegistryAddress.Bytes(), pubkeys)
+		if err != nil {
+			return fmt.Errorf("error saving removed lido signing keys: %w", err)
+		}
+	}
+
+	for _, r := range le.OracleReports {
+		if r.BeaconBalance == nil || r.PostTotalPooledEther == nil {
+			logger.Warnf("skipping incomplete lido oracle report in tx %x", r.TxHash)
+			continue
+		}
+		_, err := tx.Exec(`
+			insert into lido_oracle_reports (oracle_address, epoch, block, tx_hash, beacon_balance, beacon_validators, pre_total_pooled_ether, post_total_pooled_ether, time_elapsed, total_shares)
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			on conflict (oracle_address, epoch) do nothing`,
+			le.OracleAddress.Bytes(), r.Epoch, r.Block, r.TxHash, r.BeaconBalance.String(), r.BeaconValidators, r.PreTotalPooledEther.String(), r.PostTotalPooledEther.String(), r.TimeElapsed, r.TotalShares.String())
+		if err != nil {
+			return fmt.Errorf("error saving lido oracle report of epoch %v: %w", r.Epoch, err)
+		}
+	}
+
+	_, err = tx.Exec(`
+		insert into lido_exporter_status (registry_address, last_block) values ($1, $2)
+		on conflict (registry_address) do update set last_block = excluded.last_block`, le.RegistryAddress.Bytes(), le.PendingBlock)
+	if err != nil {
+		return fmt.Errorf("error saving lido exporter status: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	le.LastBlock = le.PendingBlock
+	for _, key := range added {
+		if !key.RemovedBlock.Valid {
+			le.UntaggedPubkeys = append(le.UntaggedPubkeys, key.Pubkey)
+		}
+	}
+	return nil
+}
+
+// TagValidators tags the keys added since the last tagging, or all keys of the registry after Init. Removed keys were never deposited.
+func (le *LidoExporter) TagValidators() error {
+	pubkeys := le.UntaggedPubkeys
+	if le.TagAll {
+		// the saved keys include the untagged ones, sqlx appends to the destination so the keys are selected into a new slice
+		all := [][]byte{}
+		err := le.DB.Select(&all, `select pubkey from lido_signing_keys where registry_address = $1 and removed_block is null`, le.RegistryAddress.Bytes())
+		if err != nil {
+			return fmt.Errorf("error getting lido signing keys: %w", err)
+		}
+		pubkeys = all
+	}
+	err := tagProtocolValidators(le.Name(), pubkeys)
+	if err != nil {
+		return err
+	}
+	le.UntaggedPubkeys = nil
+	le.TagAll = false
+	return nil
+}
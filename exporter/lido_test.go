@@ -0,0 +1,253 @@
+package exporter
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"eth2-exporter/db"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/jmoiron/sqlx"
+)
+
+var testLidoRegistryAddress = common.HexToAddress("0x55032650b14df07b85bF18A3a3eC8E0Af2e028d5")
+var testLidoOracleAddress = common.HexToAddress("0x442af784A788A5bd6F42A01Ebe9F287a871243fb")
+
+func newTestLidoExporter() *LidoExporter {
+	return &LidoExporter{
+		RegistryAddress:     testLidoRegistryAddress,
+		OracleAddress:       testLidoOracleAddress,
+		SigningKeysByPubkey: map[string]*LidoSigningKey{},
+		OracleReports:       []*LidoOracleReport{},
+	}
+}
+
+func testLidoSigningKeyLog(t *testing.T, event string, operatorID uint64, pubkey []byte, block uint64, index uint) types.Log {
+	data, err := lidoRegistryContractABI.Events[event].Inputs.NonIndexed().Pack(pubkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types.Log{
+		Address:     testLidoRegistryAddress,
+		Topics:      []common.Hash{lidoRegistryContractABI.Events[event].ID, common.BigToHash(new(big.Int).SetUint64(operatorID))},
+		Data:        data,
+		BlockNumber: block,
+		Index:       index,
+	}
+}
+
+func testLidoOracleLog(t *testing.T, event string, txHash common.Hash, block uint64, index uint, args ...interface{}) types.Log {
+	data, err := lidoOracleContractABI.Events[event].Inputs.Pack(args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types.Log{
+		Address:     testLidoOracleAddress,
+		Topics:      []common.Hash{lidoOracleContractABI.Events[event].ID},
+		Data:        data,
+		BlockNumber: block,
+		TxHash:      txHash,
+		Index:       index,
+	}
+}
+
+func TestLidoApplySigningKeyEvents(t *testing.T) {
+	keyA := bytes.Repeat([]byte{0xaa}, 48)
+	keyB := bytes.Repeat([]byte{0xbb}, 48)
+	keyC := bytes.Repeat([]byte{0xcc}, 48)
+
+	le := newTestLidoExporter()
+	// the logs are not in order, the removal of keyA happened after its addition
+	err := le.applyEvents([]types.Log{
+		testLidoSigningKeyLog(t, "SigningKeyRemoved", 1, keyA, 11, 0),
+		testLidoSigningKeyLog(t, "SigningKeyAdded", 2, keyB, 10, 3),
+		testLidoSigningKeyLog(t, "SigningKeyAdded", 1, keyA, 10, 2),
+		testLidoSigningKeyLog(t, "SigningKeyRemoved", 3, keyC, 12, 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(le.SigningKeysByPubkey) != 3 {
+		t.Fatalf("expected 3 signing keys, got %v", len(le.SigningKeysByPubkey))
+	}
+
+	a := le.SigningKeysByPubkey[string(keyA)]
+	if !a.Added || a.AddedBlock != 10 || a.OperatorID != 1 || !a.RemovedBlock.Valid || a.RemovedBlock.Int64 != 11 {
+		t.Errorf("expected keyA to be added in block 10 and removed in block 11, got %+v", a)
+	}
+	b := le.SigningKeysByPubkey[string(keyB)]
+	if !b.Added || b.AddedBlock != 10 || b.OperatorID != 2 || b.RemovedBlock.Valid {
+		t.Errorf("expected keyB to be added in block 10, got %+v", b)
+	}
+	// only the removal of keyC was fetched, it was added in an earlier batch
+	c := le.SigningKeysByPubkey[string(keyC)]
+	if c.Added || c.OperatorID != 3 || !c.RemovedBlock.Valid || c.RemovedBlock.Int64 != 12 {
+		t.Errorf("expected keyC to only be removed in block 12, got %+v", c)
+	}
+}
+
+func TestLidoApplySigningKeyReAdded(t *testing.T) {
+	key := bytes.Repeat([]byte{0xaa}, 48)
+
+	le := newTestLidoExporter()
+	err := le.applyEvents([]types.Log{
+		testLidoSigningKeyLog(t, "SigningKeyAdded", 1, key, 20, 0),
+		testLidoSigningKeyLog(t, "SigningKeyRemoved", 1, key, 10, 1),
+		testLidoSigningKeyLog(t, "SigningKeyAdded", 1, key, 10, 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k := le.SigningKeysByPubkey[string(key)]
+	if !k.Added || k.AddedBlock != 20 || k.RemovedBlock.Valid {
+		t.Errorf("expected the key to be added again in block 20, got %+v", k)
+	}
+}
+
+func TestLidoApplyOracleReportEvents(t *testing.T) {
+	tx1 := common.HexToHash("0x01")
+	tx2 := common.HexToHash("0x02")
+
+	le := newTestLidoExporter()
+	err := le.applyEvents([]types.Log{
+		testLidoOracleLog(t, "PostTotalShares", tx2, 200, 5, big.NewInt(2100), big.NewInt(2000), big.NewInt(86400), big.NewInt(1900)),
+		testLidoOracleLog(t, "Completed", tx1, 100, 3, big.NewInt(225), big.NewInt(1000), big.NewInt(30)),
+		testLidoOracleLog(t, "Completed", tx2, 200, 4, big.NewInt(450), big.NewInt(2050), big.NewInt(60)),
+		testLidoOracleLog(t, "PostTotalShares", tx1, 100, 4, big.NewInt(1010), big.NewInt(1000), big.NewInt(86400), big.NewInt(950)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(le.OracleReports) != 2 {
+		t.Fatalf("expected 2 oracle reports, got %v", len(le.OracleReports))
+	}
+
+	r := le.OracleReports[0]
+	if r.Epoch != 225 || r.Block != 100 || !bytes.Equal(r.TxHash, tx1.Bytes()) || r.BeaconBalance.Int64() != 1000 || r.BeaconValidators != 30 {
+		t.Errorf("unexpected first report %+v", r)
+	}
+	if r.PreTotalPooledEther.Int64() != 1000 || r.PostTotalPooledEther.Int64() != 1010 || r.TimeElapsed != 86400 || r.TotalShares.Int64() != 950 {
+		t.Errorf("expected the total shares of tx1 to be paired with its report, got %+v", r)
+	}
+
+	r = le.OracleReports[1]
+	if r.Epoch != 450 || r.Block != 200 || !bytes.Equal(r.TxHash, tx2.Bytes()) || r.BeaconBalance.Int64() != 2050 || r.BeaconValidators != 60 {
+		t.Errorf("unexpected second report %+v", r)
+	}
+	if r.PreTotalPooledEther.Int64() != 2000 || r.PostTotalPooledEther.Int64() != 2100 || r.TotalShares.Int64() != 1900 {
+		t.Errorf("expected the total shares of tx2 to be paired with its report, got %+v", r)
+	}
+}
+
+func TestLidoApplyIgnoresRemovedLogs(t *testing.T) {
+	key := bytes.Repeat([]byte{0xaa}, 48)
+	log := testLidoSigningKeyLog(t, "SigningKeyAdded", 1, key, 10, 0)
+	log.Removed = true
+
+	le := newTestLidoExporter()
+	err := le.applyEvents([]types.Log{log})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(le.SigningKeysByPubkey) != 0 {
+		t.Errorf("expected reorged logs to be ignored, got %v keys", len(le.SigningKeysByPubkey))
+	}
+}
+
+// recordingDriver is a database driver that answers every query with the pubkeys and records the arguments of the executed statements
+type recordingDriver struct {
+	pubkeys [][]byte
+	execs   [][]driver.Value
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) { return &recordingConn{d}, nil }
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) { return &recordingStmt{c.d}, nil }
+func (c *recordingConn) Close() error                              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error)                 { return recordingTx{}, nil }
+
+type recordingTx struct{}
+
+func (recordingTx) Commit() error   { return nil }
+func (recordingTx) Rollback() error { return nil }
+
+type recordingStmt struct{ d *recordingDriver }
+
+func (s *recordingStmt) Close() error  { return nil }
+func (s *recordingStmt) NumInput() int { return -1 }
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.execs = append(s.d.execs, args)
+	return driver.RowsAffected(0), nil
+}
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &recordingRows{pubkeys: s.d.pubkeys}, nil
+}
+
+type recordingRows struct {
+	pubkeys [][]byte
+	i       int
+}
+
+func (r *recordingRows) Columns() []string { return []string{"pubkey"} }
+func (r *recordingRows) Close() error      { return nil }
+func (r *recordingRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.pubkeys) {
+		return io.EOF
+	}
+	dest[0] = r.pubkeys[r.i]
+	r.i++
+	return nil
+}
+
+func TestLidoTagAllDoesNotDuplicateUntaggedKeys(t *testing.T) {
+	keyA := bytes.Repeat([]byte{0xaa}, 48)
+	keyB := bytes.Repeat([]byte{0xbb}, 48)
+
+	d := &recordingDriver{pubkeys: [][]byte{keyA, keyB}}
+	sql.Register("lidoRecording", d)
+	conn, err := sql.Open("lidoRecording", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := db.WriterDb
+	db.WriterDb = sqlx.NewDb(conn, "postgres")
+	t.Cleanup(func() { db.WriterDb = previous })
+
+	le := newTestLidoExporter()
+	le.DB = db.WriterDb
+	le.UntaggedPubkeys = [][]byte{keyA}
+	le.TagAll = true
+	err = le.TagValidators()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.execs) == 0 {
+		t.Fatal("expected the keys to be tagged")
+	}
+	for _, args := range d.execs {
+		seen := map[string]bool{}
+		for i := 0; i < len(args); i += 2 {
+			key := string(args[i].([]byte))
+			if seen[key] {
+				t.Errorf("key %x is tagged twice in one statement", key)
+			}
+			seen[key] = true
+		}
+		if len(seen) != 2 {
+			t.Errorf("expected both saved keys to be tagged, got %v", len(seen))
+		}
+	}
+	if le.TagAll || le.UntaggedPubkeys != nil {
+		t.Errorf("expected the pending tags to be reset")
+	}
+}
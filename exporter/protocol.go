@@ -0,0 +1,130 @@
+package exporter
+
+import (
+	"eth2-exporter/db"
+	"eth2-exporter/utils"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	gethRPC "github.com/ethereum/go-ethereum/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+// ProtocolExporter exports the state of a staking protocol like Rocket Pool or Lido, the lifecycle shared by all protocols is driven by runProtocolExporter
+type ProtocolExporter interface {
+	// Name is used as tag of the validators of the protocol in validator_tags and as their pool in validator_pool
+	Name() string
+	// Init is called once before the first update, it is retried until it succeeds
+	Init() error
+	// Update fetches the state of the protocol, count is the number of completed exports
+	Update(count int64) error
+	// Save writes the fetched state to the db
+	Save(count int64) error
+	// TagValidators tags the validators of the protocol after every export
+	TagValidators() error
+}
+
+func runProtocolExporter(pe ProtocolExporter, updateInterval time.Duration) {
+	errorInterval := time.Second * 60
+	logger := logger.WithField("protocol", pe.Name())
+
+	for {
+		err := pe.Init()
+		if err == nil {
+			break
+		}
+		logger.WithError(err).Errorf("error initializing %v exporter", pe.Name())
+		time.Sleep(errorInterval)
+	}
+	logger.Infof("%v exporter initialized", pe.Name())
+
+	t := time.NewTicker(updateInterval)
+	defer t.Stop()
+	var count int64 = 0
+	for {
+		t0 := time.Now()
+		err := pe.Update(count)
+		if err != nil {
+			logger.WithError(err).Errorf("error updating %v-data", pe.Name())
+			time.Sleep(errorInterval)
+			continue
+		}
+		err = pe.Save(count)
+		if err != nil {
+			logger.WithError(err).Errorf("error saving %v-data", pe.Name())
+			time.Sleep(errorInterval)
+			continue
+		}
+		err = pe.TagValidators()
+		if err != nil {
+			logger.WithError(err).Errorf("error tagging %v-validators", pe.Name())
+			time.Sleep(errorInterval)
+			continue
+		}
+
+		logger.WithFields(logrus.Fields{"duration": time.Since(t0)}).Infof("exported %v-data", pe.Name())
+		count++
+		<-t.C
+	}
+}
+
+// dialProtocolEth1Client connects to the eth1 node via websocket, protocol exporters subscribe to and filter a lot of contract events
+func dialProtocolEth1Client() (*gethRPC.Client, *ethclient.Client, error) {
+	endpoint := utils.Config.Eth1GethEndpoint
+	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		endpoint = "ws" + endpoint[4:]
+	}
+
+	rpcClient, err := gethRPC.Dial(endpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rpcClient, ethclient.NewClient(rpcClient), nil
+}
+
+// tagProtocolValidators adds the tag of the protocol to the validators and assigns them to its pool.
+// Pools attributed by heuristics are replaced, the protocol is an authoritative source.
+func tagProtocolValidators(tag string, pubkeys [][]byte) error {
+	if len(pubkeys) == 0 {
+		return nil
+	}
+
+	t0 := time.Now()
+	defer func(t0 time.Time) {
+		logger.WithFields(logrus.Fields{"duration": time.Since(t0)}).Debugf("saved %v-validator-tags", tag)
+	}(t0)
+
+	tx, err := db.WriterDb.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	batchSize := 5000
+	for b := 0; b < len(pubkeys); b += batchSize {
+		start := b
+		end := b + batchSize
+		if len(pubkeys) < end {
+			end = len(pubkeys)
+		}
+		n := 2
+		valueStrings := make([]string, 0, batchSize)
+		valueArgs := make([]interface{}, 0, batchSize*n)
+		for i, pubkey := range pubkeys[start:end] {
+			valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d)", i*n+1, i*n+2))
+			valueArgs = append(valueArgs, pubkey, tag)
+		}
+		_, err := tx.Exec(fmt.Sprintf(`insert into validator_tags (publickey, tag) values %s on conflict (publickey, tag) do nothing`, strings.Join(valueStrings, ",")), valueArgs...)
+		if err != nil {
+			return fmt.Errorf("error inserting into validator_tags: %w", err)
+		}
+		_, err = tx.Exec(fmt.Sprintf(`insert into validator_pool (publickey, pool) values %s on conflict (publickey) do update set pool = excluded.pool, confidence = null where validator_pool.confidence is not null`, strings.Join(valueStrings, ",")), valueArgs...)
+		if err != nil {
+			return fmt.Errorf("error inserting into validator_pool: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -25,6 +25,7 @@ import (
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
 	"github.com/klauspost/compress/zstd"
+	"github.com/lib/pq"
 	"github.com/rocket-pool/rocketpool-go/dao"
 	rpDAO "github.com/rocket-pool/rocketpool-go/dao"
 	rpDAOTrustedNode "github.com/rocket-pool/rocketpool-go/dao/trustednode"
@@ -96,22 +97,16 @@ var rewardsSubmissionBlockMaps = map[string][]uint64{
 }
 
 func rocketpoolExporter() {
-	endpoint := utils.Config.Eth1GethEndpoint
-	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
-		endpoint = "ws" + endpoint[4:]
-	}
-
 	var err error
-	rpEth1RPRCClient, err = gethRPC.Dial(endpoint)
+	rpEth1RPRCClient, rpEth1Client, err = dialProtocolEth1Client()
 	if err != nil {
 		logger.Fatal(err)
 	}
-	rpEth1Client = ethclient.NewClient(rpEth1RPRCClient)
 	rpExporter, err := NewRocketpoolExporter(rpEth1Client, utils.Config.RocketpoolExporter.StorageContractAddress, db.WriterDb)
 	if err != nil {
 		logger.Fatal(err)
 	}
-	rpExporter.Run()
+	runProtocolExporter(rpExporter, rpExporter.UpdateInterval)
 }
 
 type RocketpoolNetworkStats struct {
@@ -126,6 +121,7 @@ type RocketpoolNetworkStats struct {
 	RETHPrice              float64
 	TotalEthStaking        *big.Int
 	TotalEthBalance        *big.Int
+	SmoothingPoolBalance   *big.Int
 }
 
 type RocketpoolExporter struct {
@@ -171,92 +167,21 @@ func NewRocketpoolExporter(eth1Client *ethclient.Client, storageContractAddressH
 	return rpe, nil
 }
 
-func (rp *RocketpoolExporter) Init() error {
-	var err error
-	err = rp.InitMinipools()
-	if err != nil {
-		return err
-	}
-	err = rp.InitNodes()
-	if err != nil {
-		return err
-	}
-	err = rp.InitDAOProposals()
-	if err != nil {
-		return err
-	}
-	err = rp.InitDAOMembers()
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-func (rp *RocketpoolExporter) InitMinipools() error {
-	dbRes := []RocketpoolMinipool{}
-	err := rp.DB.Select(&dbRes, `select * from rocketpool_minipools`)
-	if err != nil {
-		return err
-	}
-	for _, val := range dbRes {
-		rp.MinipoolsByAddress[fmt.Sprintf("%x", val.Address)] = &val
-	}
-	return nil
+func (rp *RocketpoolExporter) Name() string {
+	return "rocketpool"
 }
 
-func (rp *RocketpoolExporter) InitNodes() error {
-	dbRes := []RocketpoolNode{}
-	err := rp.DB.Select(&dbRes, `select * from rocketpool_nodes`)
-	if err != nil {
-		return err
-	}
-	for _, val := range dbRes {
-		rp.NodesByAddress[fmt.Sprintf("%x", val.Address)] = &val
-	}
-	return nil
-}
-
-func (rp *RocketpoolExporter) InitDAOProposals() error {
-	dbRes := []RocketpoolDAOProposal{}
-	err := rp.DB.Select(&dbRes, `select * from rocketpool_proposals`)
-	if err != nil {
-		return err
-	}
-	for _, val := range dbRes {
-		rp.DAOProposalsByID[val.ID] = &val
-	}
-	return nil
-}
-
-func (rp *RocketpoolExporter) InitDAOMembers() error {
-	dbRes := []RocketpoolDAOMember{}
-	err := rp.DB.Select(&dbRes, `select * from rocketpool_dao_members`)
-	if err != nil {
-		return err
-	}
-	for _, val := range dbRes {
-		rp.DAOMembersByAddress[fmt.Sprintf("%x", val.Address)] = &val
-	}
-	return nil
-}
-
-func (rp *RocketpoolExporter) Run() error {
-	errorInterval := time.Second * 60
-	t := time.NewTicker(rp.UpdateInterval)
-	defer t.Stop()
-	var count int64 = 0
-
+// Init loads the known reward trees once the redstone rewards are deployed
+func (rp *RocketpoolExporter) Init() error {
 	isMergeUpdateDeployed, err := IsMergeUpdateDeployed(rp.API)
 	if err != nil {
-		logger.WithError(err).Errorf("error retrieving rocketpool redstone deploy status")
-		return err
+		return fmt.Errorf("error retrieving rocketpool redstone deploy status: %w", err)
 	}
 
 	if isMergeUpdateDeployed {
 		rp.RocketpoolRewardTreeData, err = rp.getRocketpoolRewardTrees()
 		if err != nil {
-			logger.WithError(err).Errorf("error retrieving known rocketpool reward tree data from db")
-			return err
+			return fmt.Errorf("error retrieving known rocketpool reward tree data from db: %w", err)
 		}
 
 		for _, data := range rp.RocketpoolRewardTreeData {
@@ -265,29 +190,7 @@ func (rp *RocketpoolExporter) Run() error {
 			}
 		}
 	}
-
-	logger.Infof("rocketpool exporter initialized")
-
-	for {
-		t0 := time.Now()
-		var err error
-		err = rp.Update(count)
-		if err != nil {
-			logger.WithError(err).Errorf("error updating rocketpool-data")
-			time.Sleep(errorInterval)
-			continue
-		}
-		err = rp.Save(count)
-		if err != nil {
-			logger.WithError(err).Errorf("error saving rocketpool-data")
-			time.Sleep(errorInterval)
-			continue
-		}
-
-		logger.WithFields(logrus.Fields{"duration": time.Since(t0)}).Infof("exported rocketpool-data")
-		count++
-		<-t.C
-	}
+	return nil
 }
 
 // Get the event for a rewards snapshot
@@ -706,15 +609,15 @@ func (rp *RocketpoolExporter) Save(count int64) error {
 	if err != nil {
 		return err
 	}
-	err = rp.TagValidators()
-	if err != nil {
-		return err
-	}
 	if count%60 == 0 { // every hour (smart contracts aren't updated that often)
 		err = rp.SaveNetworkStats()
 		if err != nil {
 			return err
 		}
+		err = rp.SaveRewardProjections()
+		if err != nil {
+			return err
+		}
 	}
 	err = rp.SaveRewardTrees()
 	if err != nil {
@@ -964,6 +867,18 @@ func (rp *RocketpoolExporter) UpdateNetworkStats() error {
 		return err
 	}
 
+	smoothingPoolBalance := big.NewInt(0)
+	if isMergeUpdateDeployed {
+		smoothingPoolAddress, err := rp.API.GetAddress("rocketSmoothingPool")
+		if err != nil {
+			return err
+		}
+		smoothingPoolBalance, err = rp.Eth1Client.BalanceAt(context.Background(), *smoothingPoolAddress, nil)
+		if err != nil {
+			return err
+		}
+	}
+
 	rp.NetworkStats = RocketpoolNetworkStats{
 		RPLPrice:               price,
 		ClaimIntervalTime:      claimIntervalTime,
@@ -976,6 +891,7 @@ func (rp *RocketpoolExporter) UpdateNetworkStats() error {
 		RETHPrice:              exchangeRate,
 		TotalEthStaking:        totalEthStaking,
 		TotalEthBalance:        totalEthBalance,
+		SmoothingPoolBalance:   smoothingPoolBalance,
 	}
 	return err
 }
@@ -1417,75 +1333,225 @@ func (rp *RocketpoolExporter) SaveDAOMembers() error {
 }
 
 func (rp *RocketpoolExporter) TagValidators() error {
-	if len(rp.MinipoolsByAddress) == 0 {
+	pubkeys := make([][]byte, 0, len(rp.MinipoolsByAddress))
+	for _, mp := range rp.MinipoolsByAddress {
+		pubkeys = append(pubkeys, mp.Pubkey)
+	}
+	return tagProtocolValidators(rp.Name(), pubkeys)
+}
+
+func (rp *RocketpoolExporter) SaveNetworkStats() error {
+	_, err := db.WriterDb.Exec("INSERT INTO rocketpool_network_stats (ts, rpl_price, claim_interval_time, claim_interval_time_start, current_node_fee, current_node_demand, reth_supply, effective_rpl_staked, node_operator_rewards, reth_exchange_rate, node_count, minipool_count, odao_member_count, total_eth_staking, total_eth_balance) VALUES(now(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)",
+		rp.NetworkStats.RPLPrice.String(),
+		rp.NetworkStats.ClaimIntervalTime.String(),
+		rp.NetworkStats.ClaimIntervalTimeStart,
+		rp.NetworkStats.CurrentNodeFee,
+		rp.NetworkStats.CurrentNodeDemand.String(),
+		rp.NetworkStats.RETHSupply.String(),
+		rp.NetworkStats.EffectiveRPLStake.String(),
+		rp.NetworkStats.NodeOperatorRewards.String(),
+		rp.NetworkStats.RETHPrice,
+		len(rp.NodesByAddress),
+		len(rp.MinipoolsByAddress),
+		len(rp.DAOMembersByAddress),
+		rp.NetworkStats.TotalEthStaking.String(),
+		rp.NetworkStats.TotalEthBalance.String(),
+	)
+	return err
+}
+
+// RocketpoolRewardProjection is the projected RPL and smoothing pool ETH a node earns in the whole current interval
+type RocketpoolRewardProjection struct {
+	NodeAddress                []byte
+	RPLCollateral              *big.Int
+	SmoothingPoolETH           *big.Int
+	SmoothingPoolMinipools     uint64
+	SmoothingPoolParticipation float64
+}
+
+// smoothingPoolMinipoolShare is the share of the rewards of a minipool that goes to its node, the bond plus the commission on the borrowed ETH
+func smoothingPoolMinipoolShare(mp *RocketpoolMinipool) float64 {
+	bond := 0.5
+	if mp.DepositType == "Empty" {
+		bond = 0
+	}
+	return bond + (1-bond)*mp.NodeFee
+}
+
+// projectRocketpoolRewards projects the rewards of the current interval like the reward trees distribute them:
+// the RPL of the node operators by effective RPL stake and the smoothing pool ETH by the participation weighted share of
+// every eligible minipool. The smoothing pool balance is extrapolated to the whole interval, early in an interval the
+// total of the previous interval is used instead.
+func (rp *RocketpoolExporter) projectRocketpoolRewards(participation map[string]float64) []*RocketpoolRewardProjection {
+	stats := rp.NetworkStats
+	elapsed := time.Since(stats.ClaimIntervalTimeStart)
+
+	smoothingPoolEth := new(big.Float)
+	if elapsed >= time.Hour*24 {
+		smoothingPoolEth.SetInt(stats.SmoothingPoolBalance)
+		smoothingPoolEth.Mul(smoothingPoolEth, big.NewFloat(stats.ClaimIntervalTime.Seconds()/elapsed.Seconds()))
+	} else if tree, exists := rp.RocketpoolRewardTreeData[rp.LastRewardTree]; exists && tree.TotalRewards != nil && tree.TotalRewards.TotalSmoothingPoolEth != nil {
+		smoothingPoolEth.SetInt(&tree.TotalRewards.TotalSmoothingPoolEth.Int)
+	}
+
+	minipoolsByNode := make(map[string][]*RocketpoolMinipool)
+	eligibleMinipools := 0
+	for _, mp := range rp.MinipoolsByAddress {
+		minipoolsByNode[string(mp.NodeAddress)] = append(minipoolsByNode[string(mp.NodeAddress)], mp)
+	}
+	for _, node := range rp.NodesByAddress {
+		if !node.SmoothingPoolOptedIn {
+			continue
+		}
+		for _, mp := range minipoolsByNode[string(node.Address)] {
+			if mp.Status == "Staking" {
+				eligibleMinipools++
+			}
+		}
+	}
+
+	projections := make([]*RocketpoolRewardProjection, 0, len(rp.NodesByAddress))
+	for _, node := range rp.NodesByAddress {
+		p := &RocketpoolRewardProjection{
+			NodeAddress:      node.Address,
+			RPLCollateral:    big.NewInt(0),
+			SmoothingPoolETH: big.NewInt(0),
+		}
+
+		// the effective stake is capped at the max stake and nodes below the min stake get no rewards
+		if node.RPLStake != nil && node.MinRPLStake != nil && node.MaxRPLStake != nil && node.RPLStake.Cmp(node.MinRPLStake) >= 0 &&
+			stats.NodeOperatorRewards != nil && stats.EffectiveRPLStake != nil && stats.EffectiveRPLStake.Sign() > 0 {
+			effectiveStake := node.RPLStake
+			if effectiveStake.Cmp(node.MaxRPLStake) > 0 {
+				effectiveStake = node.MaxRPLStake
+			}
+			p.RPLCollateral.Mul(stats.NodeOperatorRewards, effectiveStake)
+			p.RPLCollateral.Div(p.RPLCollateral, stats.EffectiveRPLStake)
+		}
+
+		if node.SmoothingPoolOptedIn && eligibleMinipools > 0 {
+			score := 0.0
+			for _, mp := range minipoolsByNode[string(node.Address)] {
+				if mp.Status != "Staking" {
+					continue
+				}
+				rate, exists := participation[string(mp.Pubkey)]
+				if !exists {
+					rate = 1
+				}
+				score += rate * smoothingPoolMinipoolShare(mp)
+				p.SmoothingPoolParticipation += rate
+				p.SmoothingPoolMinipools++
+			}
+			if p.SmoothingPoolMinipools > 0 {
+				p.SmoothingPoolParticipation /= float64(p.SmoothingPoolMinipools)
+			}
+			eth := new(big.Float).Mul(smoothingPoolEth, big.NewFloat(score/float64(eligibleMinipools)))
+			eth.Int(p.SmoothingPoolETH)
+		}
+		projections = append(projections, p)
+	}
+	return projections
+}
+
+// getSmoothingPoolParticipation returns the share of attestations that were not missed since the start of the interval of the staking minipools of opted in nodes
+func (rp *RocketpoolExporter) getSmoothingPoolParticipation() (map[string]float64, error) {
+	pubkeys := make(pq.ByteaArray, 0)
+	for _, mp := range rp.MinipoolsByAddress {
+		if mp.Status != "Staking" {
+			continue
+		}
+		if node, exists := rp.NodesByAddress[common.BytesToAddress(mp.NodeAddress).Hex()]; exists && node.SmoothingPoolOptedIn {
+			pubkeys = append(pubkeys, mp.Pubkey)
+		}
+	}
+	if len(pubkeys) == 0 {
+		return map[string]float64{}, nil
+	}
+
+	epochsPerDay := (24 * 60 * 60) / utils.Config.Chain.Config.SlotsPerEpoch / utils.Config.Chain.Config.SecondsPerSlot
+	var rows []struct {
+		Pubkey        []byte  `db:"pubkey"`
+		Participation float64 `db:"participation"`
+	}
+	err := rp.DB.Select(&rows, `
+		SELECT validators.pubkey, 1 - SUM(validator_stats.missed_attestations)::float8 / (COUNT(validator_stats.missed_attestations) * $2) AS participation
+		FROM validators
+		INNER JOIN validator_stats ON validator_stats.validatorindex = validators.validatorindex
+		WHERE validators.pubkey = ANY($1) AND validator_stats.day >= $3
+		GROUP BY validators.pubkey
+		HAVING COUNT(validator_stats.missed_attestations) > 0`, pubkeys, epochsPerDay, utils.TimeToDay(uint64(rp.NetworkStats.ClaimIntervalTimeStart.Unix())))
+	if err != nil {
+		return nil, fmt.Errorf("error getting smoothing pool participation: %w", err)
+	}
+
+	participation := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		if row.Participation < 0 {
+			row.Participation = 0
+		}
+		participation[string(row.Pubkey)] = row.Participation
+	}
+	return participation, nil
+}
+
+func (rp *RocketpoolExporter) SaveRewardProjections() error {
+	if rp.NetworkStats.SmoothingPoolBalance == nil || len(rp.NodesByAddress) == 0 {
 		return nil
 	}
 
 	t0 := time.Now()
 	defer func(t0 time.Time) {
-		logger.WithFields(logrus.Fields{"duration": time.Since(t0)}).Debugf("saved rocketpool-validator-tags")
+		logger.WithFields(logrus.Fields{"duration": time.Since(t0)}).Debugf("saved rocketpool-reward-projections")
 	}(t0)
 
-	tx, err := db.WriterDb.Beginx()
+	participation, err := rp.getSmoothingPoolParticipation()
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
+	projections := rp.projectRocketpoolRewards(participation)
 
-	data := make([]*RocketpoolMinipool, len(rp.MinipoolsByAddress))
-	i := 0
-	for _, mp := range rp.MinipoolsByAddress {
-		data[i] = mp
-		i++
+	tx, err := rp.DB.Beginx()
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	batchSize := 5000
-	for b := 0; b < len(data); b += batchSize {
+	intervalStart := rp.NetworkStats.ClaimIntervalTimeStart
+	intervalEnd := intervalStart.Add(rp.NetworkStats.ClaimIntervalTime)
+	batchSize := 5000 // max parameters: 65535
+	for b := 0; b < len(projections); b += batchSize {
 		start := b
 		end := b + batchSize
-		if len(data) < end {
-			end = len(data)
+		if len(projections) < end {
+			end = len(projections)
 		}
-		n := 1
+		n := 8
 		valueStrings := make([]string, 0, batchSize)
 		valueArgs := make([]interface{}, 0, batchSize*n)
-		for i, d := range data[start:end] {
-			valueStrings = append(valueStrings, fmt.Sprintf("($%d, 'rocketpool')", i*n+1))
-			valueArgs = append(valueArgs, d.Pubkey)
-		}
-		_, err := tx.Exec(fmt.Sprintf(`insert into validator_tags (publickey, tag) values %s on conflict (publickey, tag) do nothing`, strings.Join(valueStrings, ",")), valueArgs...)
-		if err != nil {
-			return fmt.Errorf("error inserting into validator_tags: %w", err)
-		}
-		_, err = tx.Exec(fmt.Sprintf(`insert into validator_pool (publickey, pool) values %s on conflict (publickey) do nothing`, strings.Join(valueStrings, ",")), valueArgs...)
+		for i, p := range projections[start:end] {
+			valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, now())", i*n+1, i*n+2, i*n+3, i*n+4, i*n+5, i*n+6, i*n+7, i*n+8))
+			valueArgs = append(valueArgs, rp.API.RocketStorageContract.Address.Bytes(), p.NodeAddress, intervalStart, intervalEnd, p.RPLCollateral.String(), p.SmoothingPoolETH.String(), p.SmoothingPoolMinipools, p.SmoothingPoolParticipation)
+		}
+		_, err := tx.Exec(fmt.Sprintf(`
+			insert into rocketpool_reward_projections (rocketpool_storage_address, node_address, interval_start, interval_end, rpl_collateral, smoothing_pool_eth, smoothing_pool_minipools, smoothing_pool_participation, updated_ts)
+			values %s
+			on conflict (rocketpool_storage_address, node_address) do update set
+				interval_start = excluded.interval_start,
+				interval_end = excluded.interval_end,
+				rpl_collateral = excluded.rpl_collateral,
+				smoothing_pool_eth = excluded.smoothing_pool_eth,
+				smoothing_pool_minipools = excluded.smoothing_pool_minipools,
+				smoothing_pool_participation = excluded.smoothing_pool_participation,
+				updated_ts = excluded.updated_ts`, strings.Join(valueStrings, ",")), valueArgs...)
 		if err != nil {
-			return fmt.Errorf("error inserting into validator_pool: %w", err)
+			return fmt.Errorf("error saving rocketpool reward projections: %w", err)
 		}
 	}
 
 	return tx.Commit()
 }
 
-func (rp *RocketpoolExporter) SaveNetworkStats() error {
-	_, err := db.WriterDb.Exec("INSERT INTO rocketpool_network_stats (ts, rpl_price, claim_interval_time, claim_interval_time_start, current_node_fee, current_node_demand, reth_supply, effective_rpl_staked, node_operator_rewards, reth_exchange_rate, node_count, minipool_count, odao_member_count, total_eth_staking, total_eth_balance) VALUES(now(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)",
-		rp.NetworkStats.RPLPrice.String(),
-		rp.NetworkStats.ClaimIntervalTime.String(),
-		rp.NetworkStats.ClaimIntervalTimeStart,
-		rp.NetworkStats.CurrentNodeFee,
-		rp.NetworkStats.CurrentNodeDemand.String(),
-		rp.NetworkStats.RETHSupply.String(),
-		rp.NetworkStats.EffectiveRPLStake.String(),
-		rp.NetworkStats.NodeOperatorRewards.String(),
-		rp.NetworkStats.RETHPrice,
-		len(rp.NodesByAddress),
-		len(rp.MinipoolsByAddress),
-		len(rp.DAOMembersByAddress),
-		rp.NetworkStats.TotalEthStaking.String(),
-		rp.NetworkStats.TotalEthBalance.String(),
-	)
-	return err
-}
-
 type RocketpoolMinipool struct {
 	Address      []byte    `db:"address"`
 	Pubkey       []byte    `db:"pubkey"`
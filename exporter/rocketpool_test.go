@@ -0,0 +1,183 @@
+package exporter
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var testRocketpoolNodeA = common.HexToAddress("0x000000000000000000000000000000000000000a")
+var testRocketpoolNodeB = common.HexToAddress("0x000000000000000000000000000000000000000b")
+var testRocketpoolNodeC = common.HexToAddress("0x000000000000000000000000000000000000000c")
+
+func testEther(eth float64) *big.Int {
+	wei, _ := new(big.Float).Mul(big.NewFloat(eth), big.NewFloat(1e18)).Int(nil)
+	return wei
+}
+
+func testRocketpoolNode(address common.Address, optedIn bool, stake, min, max int64) *RocketpoolNode {
+	return &RocketpoolNode{
+		Address:              address.Bytes(),
+		SmoothingPoolOptedIn: optedIn,
+		RPLStake:             big.NewInt(stake),
+		MinRPLStake:          big.NewInt(min),
+		MaxRPLStake:          big.NewInt(max),
+	}
+}
+
+func testRocketpoolMinipool(node common.Address, pubkey byte, status, depositType string, fee float64) *RocketpoolMinipool {
+	return &RocketpoolMinipool{
+		Address:     []byte{pubkey},
+		Pubkey:      []byte{pubkey},
+		NodeAddress: node.Bytes(),
+		NodeFee:     fee,
+		DepositType: depositType,
+		Status:      status,
+	}
+}
+
+func newTestRocketpoolExporter(elapsed time.Duration, smoothingPoolBalance float64, nodes []*RocketpoolNode, minipools []*RocketpoolMinipool) *RocketpoolExporter {
+	rp := &RocketpoolExporter{
+		NodesByAddress:           map[string]*RocketpoolNode{},
+		MinipoolsByAddress:       map[string]*RocketpoolMinipool{},
+		RocketpoolRewardTreeData: map[uint64]RewardsFile{},
+		NetworkStats: RocketpoolNetworkStats{
+			ClaimIntervalTime:      time.Hour * 24 * 28,
+			ClaimIntervalTimeStart: time.Now().Add(-elapsed),
+			SmoothingPoolBalance:   testEther(smoothingPoolBalance),
+			NodeOperatorRewards:    big.NewInt(1000),
+			EffectiveRPLStake:      big.NewInt(10000),
+		},
+	}
+	for _, node := range nodes {
+		rp.NodesByAddress[common.BytesToAddress(node.Address).Hex()] = node
+	}
+	for _, mp := range minipools {
+		rp.MinipoolsByAddress[common.BytesToAddress(mp.Address).Hex()] = mp
+	}
+	return rp
+}
+
+func TestProjectRocketpoolRewards(t *testing.T) {
+	type projection struct {
+		rpl           int64
+		eth           float64
+		minipools     uint64
+		participation float64
+	}
+
+	previousTree := RewardsFile{TotalRewards: &TotalRewards{TotalSmoothingPoolEth: &QuotedBigInt{Int: *testEther(100)}}}
+
+	tests := []struct {
+		name          string
+		exporter      *RocketpoolExporter
+		previousTree  *RewardsFile
+		participation map[string]float64
+		expected      map[common.Address]projection
+	}{
+		{
+			name: "extrapolates the smoothing pool balance to the whole interval",
+			exporter: newTestRocketpoolExporter(time.Hour*24*7, 10,
+				[]*RocketpoolNode{testRocketpoolNode(testRocketpoolNodeA, true, 0, 100, 1500)},
+				[]*RocketpoolMinipool{testRocketpoolMinipool(testRocketpoolNodeA, 1, "Staking", "Half", 0.1)}),
+			previousTree: &previousTree,
+			expected: map[common.Address]projection{
+				// 7 of 28 days passed, the node earns its bond and the commission on the borrowed half
+				testRocketpoolNodeA: {eth: 40 * 0.55, minipools: 1, participation: 1},
+			},
+		},
+		{
+			name: "uses the previous tree during the first day of the interval",
+			exporter: newTestRocketpoolExporter(time.Hour*12, 10,
+				[]*RocketpoolNode{testRocketpoolNode(testRocketpoolNodeA, true, 0, 100, 1500)},
+				[]*RocketpoolMinipool{testRocketpoolMinipool(testRocketpoolNodeA, 1, "Staking", "Half", 0.1)}),
+			previousTree: &previousTree,
+			expected: map[common.Address]projection{
+				testRocketpoolNodeA: {eth: 100 * 0.55, minipools: 1, participation: 1},
+			},
+		},
+		{
+			name: "projects no smoothing pool rewards during the first day without a previous tree",
+			exporter: newTestRocketpoolExporter(time.Hour*12, 10,
+				[]*RocketpoolNode{testRocketpoolNode(testRocketpoolNodeA, true, 0, 100, 1500)},
+				[]*RocketpoolMinipool{testRocketpoolMinipool(testRocketpoolNodeA, 1, "Staking", "Half", 0.1)}),
+			expected: map[common.Address]projection{
+				testRocketpoolNodeA: {eth: 0, minipools: 1, participation: 1},
+			},
+		},
+		{
+			name: "caps the rpl stake at the max stake and skips nodes below the min stake",
+			exporter: newTestRocketpoolExporter(time.Hour*24*7, 10,
+				[]*RocketpoolNode{
+					testRocketpoolNode(testRocketpoolNodeA, false, 500, 100, 1500),
+					testRocketpoolNode(testRocketpoolNodeB, false, 3000, 100, 1500),
+					testRocketpoolNode(testRocketpoolNodeC, false, 50, 100, 1500),
+				}, nil),
+			expected: map[common.Address]projection{
+				testRocketpoolNodeA: {rpl: 50},
+				testRocketpoolNodeB: {rpl: 150},
+				testRocketpoolNodeC: {rpl: 0},
+			},
+		},
+		{
+			name: "weights the minipool share by participation",
+			exporter: newTestRocketpoolExporter(time.Hour*24*28, 30,
+				[]*RocketpoolNode{
+					testRocketpoolNode(testRocketpoolNodeA, true, 0, 100, 1500),
+					testRocketpoolNode(testRocketpoolNodeB, true, 0, 100, 1500),
+					testRocketpoolNode(testRocketpoolNodeC, false, 0, 100, 1500),
+				},
+				[]*RocketpoolMinipool{
+					testRocketpoolMinipool(testRocketpoolNodeA, 1, "Staking", "Half", 0.1),
+					testRocketpoolMinipool(testRocketpoolNodeA, 2, "Staking", "Half", 0.1),
+					testRocketpoolMinipool(testRocketpoolNodeA, 3, "Dissolved", "Half", 0.1),
+					testRocketpoolMinipool(testRocketpoolNodeB, 4, "Staking", "Empty", 0.15),
+					testRocketpoolMinipool(testRocketpoolNodeC, 5, "Staking", "Half", 0.1),
+				}),
+			participation: map[string]float64{string([]byte{1}): 1, string([]byte{2}): 0.5},
+			expected: map[common.Address]projection{
+				// 3 eligible minipools share the 30 ETH, minipools without participation data count as fully participating
+				testRocketpoolNodeA: {eth: 30 * (0.55 + 0.5*0.55) / 3, minipools: 2, participation: 0.75},
+				testRocketpoolNodeB: {eth: 30 * 0.15 / 3, minipools: 1, participation: 1},
+				testRocketpoolNodeC: {eth: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.previousTree != nil {
+				tt.exporter.LastRewardTree = 5
+				tt.exporter.RocketpoolRewardTreeData[5] = *tt.previousTree
+			}
+
+			projections := tt.exporter.projectRocketpoolRewards(tt.participation)
+			if len(projections) != len(tt.expected) {
+				t.Fatalf("expected %v projections, got %v", len(tt.expected), len(projections))
+			}
+			for _, p := range projections {
+				node := common.BytesToAddress(p.NodeAddress)
+				expected, exists := tt.expected[node]
+				if !exists {
+					t.Fatalf("unexpected projection of node %v", node.Hex())
+				}
+				if p.RPLCollateral.Int64() != expected.rpl {
+					t.Errorf("expected %v rpl for node %v, got %v", expected.rpl, node.Hex(), p.RPLCollateral)
+				}
+				eth, _ := new(big.Float).Quo(new(big.Float).SetInt(p.SmoothingPoolETH), big.NewFloat(1e18)).Float64()
+				if math.Abs(eth-expected.eth) > 1e-6*math.Max(1, expected.eth) {
+					t.Errorf("expected %v smoothing pool eth for node %v, got %v", expected.eth, node.Hex(), eth)
+				}
+				if p.SmoothingPoolMinipools != expected.minipools {
+					t.Errorf("expected %v smoothing pool minipools for node %v, got %v", expected.minipools, node.Hex(), p.SmoothingPoolMinipools)
+				}
+				if math.Abs(p.SmoothingPoolParticipation-expected.participation) > 1e-9 {
+					t.Errorf("expected %v participation for node %v, got %v", expected.participation, node.Hex(), p.SmoothingPoolParticipation)
+				}
+			}
+		})
+	}
+}
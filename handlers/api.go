@@ -308,6 +308,148 @@ func ApiEpochBlocks(w http.ResponseWriter, r *http.Request) {
 	returnQueryResults(rows, w, r)
 }
 
+// ApiEpochCommittees godoc
+// @Summary Get the attestation committees of an epoch
+// @Tags Epoch
+// @Description Returns the validators of all attestation committees of an epoch ordered by their position in the committee. The committees of the next epoch are available as well.
+// @Produce  json
+// @Param  epoch path string true "Epoch number, the string latest or the string next"
+// @Success 200 {object} types.ApiResponse{data=[]types.ApiEpochCommittee}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/epoch/{epoch}/committees [get]
+func ApiEpochCommittees(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	j := json.NewEncoder(w)
+
+	epoch, err := parseApiDutiesEpoch(mux.Vars(r)["epoch"])
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	committees, err := services.GetEpochCommittees(epoch)
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving committees of epoch %v", epoch)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve committees", http.StatusInternalServerError)
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{committees})
+}
+
+// ApiEpochProposers godoc
+// @Summary Get the proposer duties of an epoch
+// @Tags Epoch
+// @Description Returns the proposer of every slot of an epoch with the status of the block (0 = scheduled, 1 = proposed, 2 = missed, 3 = orphaned). The proposers of the next epoch are available as well.
+// @Produce  json
+// @Param  epoch path string true "Epoch number, the string latest or the string next"
+// @Success 200 {object} types.ApiResponse{data=[]types.ApiEpochProposer}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/epoch/{epoch}/proposers [get]
+func ApiEpochProposers(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	j := json.NewEncoder(w)
+
+	epoch, err := parseApiDutiesEpoch(mux.Vars(r)["epoch"])
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	proposers, err := services.GetEpochProposers(epoch)
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving proposers of epoch %v", epoch)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve proposers", http.StatusInternalServerError)
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{proposers})
+}
+
+// ApiValidatorDuties godoc
+// @Summary Get the duties of a validator
+// @Tags Validator
+// @Description Returns the attestation, proposal and sync committee duties of a validator for a range of up to 100 epochs, including the scheduled duties of the next epoch.
+// @Produce  json
+// @Param  index path string true "Validator index"
+// @Param  fromEpoch query int false "First epoch of the range, defaults to toEpoch"
+// @Param  toEpoch query int false "Last epoch of the range, defaults to the latest epoch"
+// @Success 200 {object} types.ApiResponse{data=types.ApiValidatorDuties}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/validator/{index}/duties [get]
+func ApiValidatorDuties(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	j := json.NewEncoder(w)
+
+	index, err := strconv.ParseUint(mux.Vars(r)["index"], 10, 64)
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), "invalid validator index provided")
+		return
+	}
+
+	q := r.URL.Query()
+	toEpoch := services.LatestEpoch()
+	if q.Get("toEpoch") != "" {
+		toEpoch, err = parseApiDutiesEpoch(q.Get("toEpoch"))
+		if err != nil {
+			sendErrorResponse(w, r.URL.String(), "invalid toEpoch: "+err.Error())
+			return
+		}
+	}
+	fromEpoch := toEpoch
+	if q.Get("fromEpoch") != "" {
+		fromEpoch, err = parseApiDutiesEpoch(q.Get("fromEpoch"))
+		if err != nil {
+			sendErrorResponse(w, r.URL.String(), "invalid fromEpoch: "+err.Error())
+			return
+		}
+	}
+	if fromEpoch > toEpoch {
+		sendErrorResponse(w, r.URL.String(), "fromEpoch must not be after toEpoch")
+		return
+	}
+	if toEpoch-fromEpoch >= apiDutiesMaxEpochs {
+		sendErrorResponse(w, r.URL.String(), fmt.Sprintf("only a maximum of %v epochs can be requested", apiDutiesMaxEpochs))
+		return
+	}
+
+	duties, err := services.GetValidatorDuties(index, fromEpoch, toEpoch)
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving duties of validator %v", index)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve duties", http.StatusInternalServerError)
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{duties})
+}
+
+const apiDutiesMaxEpochs = 100
+
+// parseApiDutiesEpoch parses an epoch number or the tags latest and next, epochs after the next epoch are rejected
+func parseApiDutiesEpoch(param string) (uint64, error) {
+	latest := services.LatestEpoch()
+	switch param {
+	case "latest":
+		return latest, nil
+	case "next":
+		return latest + 1, nil
+	}
+	epoch, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid epoch provided")
+	}
+	if epoch > latest+1 {
+		return 0, fmt.Errorf("duties are only available up to the next epoch %v", latest+1)
+	}
+	return epoch, nil
+}
+
 // ApiBlock godoc
 // @Summary Get block
 // @Tags Block
@@ -671,7 +813,7 @@ func ApiRocketpoolStats(w http.ResponseWriter, r *http.Request) {
 }
 
 // ApiRocketpoolValidators godoc
-// @Summary Get rocketpool specific data for given validators
+// @Summary Get rocketpool specific data for given validators, including the projected rewards of their nodes in the current interval
 // @Tags Rocketpool
 // @Param  indexOrPubkey path string true "Up to 100 validator indicesOrPubkeys, comma separated"
 // @Produce  json
@@ -899,10 +1041,14 @@ func getRocketpoolValidators(queryIndices []uint64) ([]interface{}, error) {
 			rpln.claimed_smoothing_pool     AS claimed_smoothing_pool,
 			rpln.unclaimed_smoothing_pool   AS unclaimed_smoothing_pool,
 			rpln.unclaimed_rpl_rewards      AS unclaimed_rpl_rewards,
-			COALESCE(rpln.smoothing_pool_opted_in, false)    AS smoothing_pool_opted_in  
+			COALESCE(rpln.smoothing_pool_opted_in, false)    AS smoothing_pool_opted_in,
+			rplrp.rpl_collateral            AS projected_rpl,
+			rplrp.smoothing_pool_eth        AS projected_smoothing_pool_eth,
+			rplrp.interval_end              AS projection_interval_end
 		FROM rocketpool_minipools rplm 
 		LEFT JOIN validators validators ON rplm.pubkey = validators.pubkey 
 		LEFT JOIN rocketpool_nodes rpln ON rplm.node_address = rpln.address
+		LEFT JOIN rocketpool_reward_projections rplrp ON rplm.node_address = rplrp.node_address
 		WHERE validatorindex = ANY($1)`, pq.Array(queryIndices))
 
 	if err != nil {
@@ -1288,6 +1434,115 @@ func ApiValidatorPerformance(w http.ResponseWriter, r *http.Request) {
 	returnQueryResults(rows, w, r)
 }
 
+// ApiValidatorPercentiles godoc
+// @Summary Compare the average income and attestation participation of up to 100 validators with the network. Income values are in ETH, the percentile is the share of validators of the network that performed worse.
+// @Tags Validator
+// @Produce  json
+// @Param  indexOrPubkey path string true "Up to 100 validator indicesOrPubkeys, comma separated"
+// @Success 200 {object} types.ApiResponse{data=[]types.PerformancePercentile}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/validator/{indexOrPubkey}/percentiles [get]
+func ApiValidatorPercentiles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	j := json.NewEncoder(w)
+	vars := mux.Vars(r)
+	maxValidators := getUserPremium(r).MaxValidators
+
+	queryIndices, err := parseApiValidatorParamToIndices(vars["indexOrPubkey"], maxValidators)
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	percentiles, err := services.GetPerformancePercentiles(queryIndices)
+	if err != nil {
+		logger.WithError(err).Error("error retrieving performance percentiles")
+		sendErrorResponse(w, r.URL.String(), "could not retrieve db results")
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{percentiles})
+}
+
+// ApiValidatorAPRHistory godoc
+// @Summary Get the daily APR of up to 100 validators, split into consensus and execution layer income and compared to ETH.STORE. The APR of multiple validators is weighted by their effective balance. Incomes are in Gwei, APRs are fractions.
+// @Tags Validator
+// @Produce  json
+// @Param  indexOrPubkey path string true "Up to 100 validator indicesOrPubkeys, comma separated"
+// @Param  days query int false "Number of days to return, 31 by default and up to 365"
+// @Success 200 {object} types.ApiResponse{data=[]types.ValidatorAPRHistory}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/validator/{indexOrPubkey}/apr [get]
+func ApiValidatorAPRHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	j := json.NewEncoder(w)
+	vars := mux.Vars(r)
+	maxValidators := getUserPremium(r).MaxValidators
+
+	queryIndices, err := parseApiValidatorParamToIndices(vars["indexOrPubkey"], maxValidators)
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	days := uint64(31)
+	if q := r.URL.Query().Get("days"); q != "" {
+		days, err = strconv.ParseUint(q, 10, 64)
+		if err != nil || days < 1 || days > 365 {
+			sendErrorResponse(w, r.URL.String(), "invalid days parameter")
+			return
+		}
+	}
+
+	lastDay := utils.TimeToDay(uint64(time.Now().Unix()))
+	firstDay := uint64(0)
+	if lastDay >= days {
+		firstDay = lastDay - days
+	}
+
+	history, err := db.GetValidatorAPRHistory(queryIndices, firstDay, lastDay)
+	if err != nil {
+		logger.WithError(err).Error("error retrieving validator apr history")
+		sendErrorResponse(w, r.URL.String(), "could not retrieve db results")
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{history})
+}
+
+// ApiValidatorPool godoc
+// @Summary Get the staking pool up to 100 validators are attributed to. Pools derived by heuristics come with their confidence and the matched rules, pools from authoritative sources have no confidence.
+// @Tags Validator
+// @Produce  json
+// @Param  indexOrPubkey path string true "Up to 100 validator indicesOrPubkeys, comma separated"
+// @Success 200 {object} types.ApiResponse{data=[]types.ApiValidatorPoolResponse}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/validator/{indexOrPubkey}/pool [get]
+func ApiValidatorPool(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	j := json.NewEncoder(w)
+	vars := mux.Vars(r)
+	maxValidators := getUserPremium(r).MaxValidators
+
+	queryIndices, err := parseApiValidatorParamToIndices(vars["indexOrPubkey"], maxValidators)
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	pools, err := db.GetValidatorPools(queryIndices)
+	if err != nil {
+		logger.WithError(err).Error("error retrieving validator pools")
+		sendErrorResponse(w, r.URL.String(), "could not retrieve db results")
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{pools})
+}
+
 // ApiValidatorExecutionPerformance godoc
 // @Summary Get the current execution reward performance of up to 100 validators. If block was produced via mev relayer, this endpoint will use the relayer data as block reward instead of the normal block reward.
 // @Tags Validator
@@ -1898,7 +2153,7 @@ func RegisterMobileSubscriptions(w http.ResponseWriter, r *http.Request) {
 	err := json.Unmarshal(gorillacontext.Get(r, utils.JsonBodyNakedKey).([]byte), &parsedBase)
 
 	if err != nil {
-		logger.Errorf("error parsing body | err: %v %v", err)
+		logger.Errorf("error parsing body | err: %v", err)
 		sendErrorResponse(w, r.URL.String(), "could not parse body")
 		return
 	}
@@ -1973,7 +2228,7 @@ func getUserPremium(r *http.Request) PremiumUser {
 func GetUserPremiumByPackage(pkg string) PremiumUser {
 	result := PremiumUser{
 		Package:                "standard",
-		MaxValidators:          100,
+		MaxValidators:          utils.GetMaxValidators(pkg),
 		MaxStats:               180,
 		MaxNodes:               1,
 		WidgetSupport:          false,
@@ -1998,7 +2253,6 @@ func GetUserPremiumByPackage(pkg string) PremiumUser {
 		result.MaxNodes = 2
 	}
 	if result.Package == "whale" {
-		result.MaxValidators = 300
 		result.MaxNodes = 10
 	}
 
@@ -2310,7 +2564,7 @@ func ClientStatsPostNew(w http.ResponseWriter, r *http.Request) {
 // @Summary Used in eth2 clients to submit stats to your beaconcha.in account. This data can be accessed by the app or the user stats api call.
 // @Tags User
 // @Produce json
-// @Param apiKey query string true "User API key, can be found on https://beaconcha.in/user/settings"
+// @Param apiKey query string true "User API key, can be found on https://beaconcha.in/user/settings, or a personal access token with the stats:write scope"
 // @Param machine query string false "Name your device if you have multiple devices you want to monitor"
 // @Success 200 {object} types.ApiResponse
 // @Failure 400 {object} types.ApiResponse
@@ -2331,7 +2585,7 @@ func clientStatsPost(w http.ResponseWriter, r *http.Request, apiKey, machine str
 		return
 	}
 
-	userData, err := db.GetUserIdByApiKey(apiKey)
+	userData, err := getClientStatsUser(apiKey)
 	if err != nil {
 		sendErrorResponse(w, r.URL.String(), "no user found with api key")
 		return
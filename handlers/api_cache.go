@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"eth2-exporter/cache"
+	"eth2-exporter/services"
+	"eth2-exporter/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	apiFinalizedCacheExpiration      = time.Hour * 24 * 7
+	apiFinalizedCacheLocalExpiration = time.Hour
+	apiFinalizedCacheControl         = "public, max-age=31536000, immutable"
+	// the exporter rewrites the epochs from 10 epochs before the finalized epoch on, see doFullCheck
+	apiFinalizedCacheEpochMargin = 10
+)
+
+// apiCachedResponse is a response of an api route for finalized data as it is stored in the shared response cache
+type apiCachedResponse struct {
+	ContentType string `json:"content_type"`
+	ETag        string `json:"etag"`
+	Body        []byte `json:"body"`
+}
+
+// ApiCacheFinalizedEpoch serves the responses of handler for finalized epochs which are not rewritten by the exporter anymore
+// from the shared response cache and marks them as immutable. The epoch is read from the path variable of the route.
+func ApiCacheFinalizedEpoch(handler http.HandlerFunc, variable string) http.HandlerFunc {
+	return apiCacheFinalized(handler, func(r *http.Request) (uint64, bool) {
+		epoch, err := strconv.ParseUint(mux.Vars(r)[variable], 10, 64)
+		return epoch, err == nil && isImmutableEpoch(epoch, services.LatestFinalizedEpoch())
+	})
+}
+
+// ApiCacheFinalizedSlot serves the responses of handler for slots of finalized epochs which are not rewritten by the exporter anymore
+// from the shared response cache and marks them as immutable. The slot is read from the path variable of the route, requests with a block root are not cached.
+func ApiCacheFinalizedSlot(handler http.HandlerFunc, variable string) http.HandlerFunc {
+	return apiCacheFinalized(handler, func(r *http.Request) (uint64, bool) {
+		slot, err := strconv.ParseUint(mux.Vars(r)[variable], 10, 64)
+		epoch := utils.EpochOfSlot(slot)
+		return epoch, err == nil && isImmutableEpoch(epoch, services.LatestFinalizedEpoch())
+	})
+}
+
+// isImmutableEpoch returns true if the data of the epoch is not changed by the exporter anymore
+func isImmutableEpoch(epoch, finalized uint64) bool {
+	return finalized > apiFinalizedCacheEpochMargin && epoch < finalized-apiFinalizedCacheEpochMargin
+}
+
+func apiCacheFinalized(handler http.HandlerFunc, immutableEpoch func(r *http.Request) (uint64, bool)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			handler(w, r)
+			return
+		}
+		epoch, immutable := immutableEpoch(r)
+		if !immutable {
+			handler(w, r)
+			return
+		}
+
+		cacheKey := apiResponseCacheKey(r, epoch)
+		cached := &apiCachedResponse{}
+		if wanted, err := cache.TieredCache.GetWithLocalTimeout(cacheKey, apiFinalizedCacheLocalExpiration, cached); err == nil {
+			writeApiCachedResponse(w, r, wanted.(*apiCachedResponse))
+			return
+		}
+
+		recorder := &apiResponseRecorder{header: http.Header{}, status: http.StatusOK}
+		handler(recorder, r)
+
+		// errors are passed through as they are, only successful responses are cached
+		if recorder.status != http.StatusOK {
+			for key, values := range recorder.header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(recorder.status)
+			w.Write(recorder.body.Bytes())
+			return
+		}
+
+		hash := sha256.Sum256(recorder.body.Bytes())
+		cached = &apiCachedResponse{
+			ContentType: recorder.header.Get("Content-Type"),
+			ETag:        `"` + hex.EncodeToString(hash[:16]) + `"`,
+			Body:        recorder.body.Bytes(),
+		}
+		err := cache.TieredCache.Set(cacheKey, cached, apiFinalizedCacheExpiration)
+		if err != nil {
+			logger.Errorf("error caching api response of %v: %v", r.URL.Path, err)
+		}
+		writeApiCachedResponse(w, r, cached)
+	}
+}
+
+// apiResponseCacheKey returns the cache key of the route and its parameters within the cache generation of the epoch,
+// the api key does not change the response and is ignored
+func apiResponseCacheKey(r *http.Request, epoch uint64) string {
+	q := r.URL.Query()
+	q.Del("apikey")
+	return fmt.Sprintf("%d:frontend:api:%d:%s?%s", utils.Config.Chain.Config.DepositChainID, services.ApiResponseCacheGeneration(epoch), r.URL.Path, q.Encode())
+}
+
+func writeApiCachedResponse(w http.ResponseWriter, r *http.Request, cached *apiCachedResponse) {
+	w.Header().Set("ETag", cached.ETag)
+	w.Header().Set("Cache-Control", apiFinalizedCacheControl)
+	if etagMatches(r.Header.Get("If-None-Match"), cached.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if cached.ContentType != "" {
+		w.Header().Set("Content-Type", cached.ContentType)
+	}
+	w.Write(cached.Body)
+}
+
+// etagMatches returns true if the If-None-Match header contains the etag or is a wildcard
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// apiResponseRecorder buffers the response of a handler so it can be cached
+type apiResponseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *apiResponseRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *apiResponseRecorder) WriteHeader(status int) {
+	rec.status = status
+}
+
+func (rec *apiResponseRecorder) Write(b []byte) (int, error) {
+	return rec.body.Write(b)
+}
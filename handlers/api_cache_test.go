@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteApiCachedResponse(t *testing.T) {
+	cached := &apiCachedResponse{ContentType: "application/json", ETag: `"abc"`, Body: []byte(`{"status":"OK"}`)}
+
+	tests := []struct {
+		ifNoneMatch string
+		status      int
+	}{
+		{"", http.StatusOK},
+		{`"abc"`, http.StatusNotModified},
+		{`"def", W/"abc"`, http.StatusNotModified},
+		{"*", http.StatusNotModified},
+		{`"def"`, http.StatusOK},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/api/v1/epoch/1", nil)
+		if test.ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", test.ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		writeApiCachedResponse(w, r, cached)
+
+		if w.Code != test.status {
+			t.Errorf("If-None-Match %q: got status %v, expected %v", test.ifNoneMatch, w.Code, test.status)
+		}
+		if w.Header().Get("ETag") != cached.ETag || w.Header().Get("Cache-Control") != apiFinalizedCacheControl {
+			t.Errorf("If-None-Match %q: unexpected headers %v", test.ifNoneMatch, w.Header())
+		}
+		if test.status == http.StatusOK && w.Body.String() != string(cached.Body) {
+			t.Errorf("If-None-Match %q: unexpected body %v", test.ifNoneMatch, w.Body.String())
+		}
+	}
+}
+
+func TestIsImmutableEpoch(t *testing.T) {
+	tests := []struct {
+		epoch, finalized uint64
+		immutable        bool
+	}{
+		{epoch: 89, finalized: 100, immutable: true},
+		// the exporter still rewrites the 10 epochs before the finalized epoch
+		{epoch: 90, finalized: 100, immutable: false},
+		{epoch: 100, finalized: 100, immutable: false},
+		{epoch: 101, finalized: 100, immutable: false},
+		{epoch: 0, finalized: 10, immutable: false},
+		{epoch: 0, finalized: 11, immutable: true},
+		{epoch: 0, finalized: 0, immutable: false},
+	}
+	for _, test := range tests {
+		if immutable := isImmutableEpoch(test.epoch, test.finalized); immutable != test.immutable {
+			t.Errorf("epoch %v with finalized epoch %v: got immutable %v, expected %v", test.epoch, test.finalized, immutable, test.immutable)
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"eth2-exporter/db"
+	"eth2-exporter/eth1data"
 	"eth2-exporter/price"
 	"eth2-exporter/services"
 	"eth2-exporter/types"
@@ -280,47 +282,516 @@ func ApiEth1Address(w http.ResponseWriter, r *http.Request) {
 	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{response})
 }
 
+const (
+	apiEth1PageDefaultLimit = 25
+	apiEth1PageMaxLimit     = 100
+)
+
+// ApiEth1AddressTx godoc
+// @Summary Get the transactions of an address
+// @Tags Execution
+// @Description Returns the transactions sent or received by an address, most recent first. The status is 1 for successful and 0 for failed transactions.
+// @Produce json
+// @Param address path string true "Execution layer address"
+// @Param limit query int false "Number of transactions per page, max 100"
+// @Param pageToken query string false "Page token of the previous response"
+// @Success 200 {object} types.ApiResponse{data=types.ExecutionPageApiResponse{data=[]types.ExecutionTransactionApiResponse}}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/execution/address/{address}/tx [get]
 func ApiEth1AddressTx(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	results := ""
-	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{results})
+
+	address, err := parseApiEth1Address(mux.Vars(r)["address"])
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+	index := fmt.Sprintf("%d:I:TX:%x:", utils.Config.Chain.Config.DepositChainID, address)
+	prefix, limit, err := parseApiEth1Page(r, index, index+string(db.FILTER_TIME)+":")
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	transactions, lastKey, err := db.BigtableClient.GetEth1TxForAddress(prefix, limit)
+	if err != nil {
+		logger.Errorf("error retrieving transactions of address 0x%x: %v", address, err)
+		sendErrorResponse(w, r.URL.String(), "could not retrieve transactions")
+		return
+	}
+
+	results := make([]*types.ExecutionTransactionApiResponse, 0, len(transactions))
+	for _, tx := range transactions {
+		if tx != nil {
+			results = append(results, formatEth1TxForApiResponse(tx))
+		}
+	}
+
+	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{newApiEth1Page(results, lastKey)})
 }
 
+// ApiEth1AddressItx godoc
+// @Summary Get the internal transactions of an address
+// @Tags Execution
+// @Description Returns the internal transactions sent or received by an address, most recent first
+// @Produce json
+// @Param address path string true "Execution layer address"
+// @Param limit query int false "Number of internal transactions per page, max 100"
+// @Param pageToken query string false "Page token of the previous response"
+// @Success 200 {object} types.ApiResponse{data=types.ExecutionPageApiResponse{data=[]types.ExecutionInternalTxApiResponse}}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/execution/address/{address}/itx [get]
 func ApiEth1AddressItx(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	results := ""
-	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{results})
+
+	address, err := parseApiEth1Address(mux.Vars(r)["address"])
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+	index := fmt.Sprintf("%d:I:ITX:%x:", utils.Config.Chain.Config.DepositChainID, address)
+	prefix, limit, err := parseApiEth1Page(r, index, index+string(db.FILTER_TIME)+":")
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	internalTxs, lastKey, err := db.BigtableClient.GetEth1ItxForAddress(prefix, address, limit)
+	if err != nil {
+		logger.Errorf("error retrieving internal transactions of address 0x%x: %v", address, err)
+		sendErrorResponse(w, r.URL.String(), "could not retrieve internal transactions")
+		return
+	}
+
+	results := make([]*types.ExecutionInternalTxApiResponse, 0, len(internalTxs))
+	for _, itx := range internalTxs {
+		results = append(results, formatEth1ItxForApiResponse(itx))
+	}
+
+	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{newApiEth1Page(results, lastKey)})
 }
 
+// ApiEth1AddressBlocks godoc
+// @Summary Get the blocks produced by an address
+// @Tags Execution
+// @Description Returns the blocks with the address as fee recipient or miner, most recent first
+// @Produce json
+// @Param address path string true "Execution layer address"
+// @Param limit query int false "Number of blocks per page, max 100"
+// @Param pageToken query string false "Page token of the previous response"
+// @Success 200 {object} types.ApiResponse{data=types.ExecutionPageApiResponse{data=[]types.ExecutionBlockApiResponse}}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/execution/address/{address}/blocks [get]
 func ApiEth1AddressBlocks(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	results := ""
-	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{results})
+
+	address, err := parseApiEth1Address(mux.Vars(r)["address"])
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+	index := fmt.Sprintf("%d:I:B:%x:", utils.Config.Chain.Config.DepositChainID, address)
+	prefix, limit, err := parseApiEth1Page(r, index, index)
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	blocks, lastKey, err := db.BigtableClient.GetEth1BlocksForAddress(prefix, limit)
+	if err != nil {
+		logger.Errorf("error retrieving blocks of address 0x%x: %v", address, err)
+		sendErrorResponse(w, r.URL.String(), "could not retrieve blocks")
+		return
+	}
+
+	blockNumbers := make([]uint64, 0, len(blocks))
+	for _, block := range blocks {
+		blockNumbers = append(blockNumbers, block.GetNumber())
+	}
+	_, beaconDataMap, err := findExecBlockNumbersByExecBlockNumber(blockNumbers, 0, uint64(limit))
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), "can not retrieve proposer information")
+		return
+	}
+
+	relaysData, err := db.GetRelayDataForIndexedBlocks(blocks)
+	if err != nil {
+		logger.Errorf("can not load mev data %v", err)
+		sendErrorResponse(w, r.URL.String(), "can not retrieve mev data")
+		return
+	}
+
+	results := formatBlocksForApiResponse(blocks, relaysData, beaconDataMap)
+
+	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{newApiEth1Page(results, lastKey)})
 }
 
+// ApiEth1AddressUncles godoc
+// @Summary Get the uncles mined by an address
+// @Tags Execution
+// @Description Returns the uncle blocks mined by an address, most recent first
+// @Produce json
+// @Param address path string true "Execution layer address"
+// @Param limit query int false "Number of uncles per page, max 100"
+// @Param pageToken query string false "Page token of the previous response"
+// @Success 200 {object} types.ApiResponse{data=types.ExecutionPageApiResponse{data=[]types.ExecutionUncleApiResponse}}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/execution/address/{address}/uncles [get]
 func ApiEth1AddressUncles(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	results := ""
-	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{results})
+
+	address, err := parseApiEth1Address(mux.Vars(r)["address"])
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+	index := fmt.Sprintf("%d:I:U:%x:", utils.Config.Chain.Config.DepositChainID, address)
+	prefix, limit, err := parseApiEth1Page(r, index, index)
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	uncles, lastKey, err := db.BigtableClient.GetEth1UnclesForAddress(prefix, limit)
+	if err != nil {
+		logger.Errorf("error retrieving uncles of address 0x%x: %v", address, err)
+		sendErrorResponse(w, r.URL.String(), "could not retrieve uncles")
+		return
+	}
+
+	results := make([]*types.ExecutionUncleApiResponse, 0, len(uncles))
+	for _, uncle := range uncles {
+		results = append(results, &types.ExecutionUncleApiResponse{
+			BlockNumber: uncle.GetBlockNumber(),
+			UncleNumber: uncle.GetNumber(),
+			Timestamp:   uint64(uncle.GetTime().AsTime().Unix()),
+			GasLimit:    uncle.GetGasLimit(),
+			GasUsed:     uncle.GetGasUsed(),
+			BaseFee:     new(big.Int).SetBytes(uncle.GetBaseFee()),
+			Difficulty:  new(big.Int).SetBytes(uncle.GetDifficulty()),
+			Reward:      new(big.Int).SetBytes(uncle.GetReward()),
+		})
+	}
+
+	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{newApiEth1Page(results, lastKey)})
 }
 
+// ApiEth1AddressTokens godoc
+// @Summary Get the token transfers of an address
+// @Tags Execution
+// @Description Returns the erc20, erc721 or erc1155 token transfers sent or received by an address, most recent first.
+// @Description Values and token ids are returned without applying the decimals of the token.
+// @Produce json
+// @Param address path string true "Execution layer address"
+// @Param type query string false "Token standard, erc20 (default), erc721 or erc1155"
+// @Param token query string false "Only return the erc20 transfers of this token contract"
+// @Param limit query int false "Number of transfers per page, max 100"
+// @Param pageToken query string false "Page token of the previous response"
+// @Success 200 {object} types.ApiResponse{data=types.ExecutionPageApiResponse{data=[]types.ExecutionTokenTransferApiResponse}}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/execution/address/{address}/tokens [get]
 func ApiEth1AddressTokens(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
-	results := ""
+	address, err := parseApiEth1Address(mux.Vars(r)["address"])
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	q := r.URL.Query()
+	standard := strings.ToLower(q.Get("type"))
+	if standard == "" {
+		standard = "erc20"
+	}
+	var token []byte
+	if q.Get("token") != "" {
+		if standard != "erc20" {
+			sendErrorResponse(w, r.URL.String(), "the token filter is only supported for erc20 transfers")
+			return
+		}
+		token, err = parseApiEth1Address(q.Get("token"))
+		if err != nil {
+			sendErrorResponse(w, r.URL.String(), "invalid token query param: "+err.Error())
+			return
+		}
+	}
+
+	var index string
+	switch {
+	case len(token) > 0:
+		index = fmt.Sprintf("%d:I:ERC20:%x:%x:", utils.Config.Chain.Config.DepositChainID, token, address)
+	case standard == "erc20" || standard == "erc721" || standard == "erc1155":
+		index = fmt.Sprintf("%d:I:%s:%x:", utils.Config.Chain.Config.DepositChainID, strings.ToUpper(standard), address)
+	default:
+		sendErrorResponse(w, r.URL.String(), "invalid type, expected erc20, erc721 or erc1155")
+		return
+	}
+	prefix, limit, err := parseApiEth1Page(r, index, index+string(db.FILTER_TIME)+":")
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	results := []*types.ExecutionTokenTransferApiResponse{}
+	var lastKey string
+	switch standard {
+	case "erc20":
+		var transfers []*types.Eth1ERC20Indexed
+		if len(token) > 0 {
+			// the token index does not contain the trailing separator after the filter
+			transfers, lastKey, err = db.BigtableClient.GetEth1TxForToken(strings.TrimSuffix(prefix, ":"), limit)
+		} else {
+			transfers, lastKey, err = db.BigtableClient.GetEth1ERC20ForAddress(prefix, limit)
+		}
+		for _, t := range transfers {
+			results = append(results, &types.ExecutionTokenTransferApiResponse{
+				ParentHash:   fmt.Sprintf("0x%x", t.GetParentHash()),
+				BlockNumber:  t.GetBlockNumber(),
+				Timestamp:    uint64(t.GetTime().AsTime().Unix()),
+				Standard:     standard,
+				TokenAddress: common.BytesToAddress(t.GetTokenAddress()).Hex(),
+				From:         common.BytesToAddress(t.GetFrom()).Hex(),
+				To:           common.BytesToAddress(t.GetTo()).Hex(),
+				Value:        new(big.Int).SetBytes(t.GetValue()),
+			})
+		}
+	case "erc721":
+		var transfers []*types.Eth1ERC721Indexed
+		transfers, lastKey, err = db.BigtableClient.GetEth1ERC721ForAddress(prefix, limit)
+		for _, t := range transfers {
+			results = append(results, &types.ExecutionTokenTransferApiResponse{
+				ParentHash:   fmt.Sprintf("0x%x", t.GetParentHash()),
+				BlockNumber:  t.GetBlockNumber(),
+				Timestamp:    uint64(t.GetTime().AsTime().Unix()),
+				Standard:     standard,
+				TokenAddress: common.BytesToAddress(t.GetTokenAddress()).Hex(),
+				From:         common.BytesToAddress(t.GetFrom()).Hex(),
+				To:           common.BytesToAddress(t.GetTo()).Hex(),
+				TokenId:      new(big.Int).SetBytes(t.GetTokenId()),
+			})
+		}
+	case "erc1155":
+		var transfers []*types.ETh1ERC1155Indexed
+		transfers, lastKey, err = db.BigtableClient.GetEth1ERC1155ForAddress(prefix, limit)
+		for _, t := range transfers {
+			results = append(results, &types.ExecutionTokenTransferApiResponse{
+				ParentHash:   fmt.Sprintf("0x%x", t.GetParentHash()),
+				BlockNumber:  t.GetBlockNumber(),
+				Timestamp:    uint64(t.GetTime().AsTime().Unix()),
+				Standard:     standard,
+				TokenAddress: common.BytesToAddress(t.GetTokenAddress()).Hex(),
+				From:         common.BytesToAddress(t.GetFrom()).Hex(),
+				To:           common.BytesToAddress(t.GetTo()).Hex(),
+				Value:        new(big.Int).SetBytes(t.GetValue()),
+				TokenId:      new(big.Int).SetBytes(t.GetTokenId()),
+				Operator:     common.BytesToAddress(t.GetOperator()).Hex(),
+			})
+		}
+	}
+	if err != nil {
+		logger.Errorf("error retrieving %v transfers of address 0x%x: %v", standard, address, err)
+		sendErrorResponse(w, r.URL.String(), "could not retrieve token transfers")
+		return
+	}
+
+	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{newApiEth1Page(results, lastKey)})
+}
+
+// ApiEth1Tx godoc
+// @Summary Get a transaction
+// @Tags Execution
+// @Description Returns a transaction with the gas data and status of its receipt. The status is 1 for successful and 0 for failed transactions.
+// @Produce json
+// @Param txhash path string true "Transaction hash"
+// @Success 200 {object} types.ApiResponse{data=types.ExecutionTransactionDetailApiResponse}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/execution/transaction/{txhash} [get]
+func ApiEth1Tx(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	tx, err := getApiEth1Transaction(mux.Vars(r)["txhash"])
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	result := &types.ExecutionTransactionDetailApiResponse{
+		ExecutionTransactionApiResponse: types.ExecutionTransactionApiResponse{
+			Hash:               tx.Hash.Hex(),
+			BlockNumber:        uint64(tx.BlockNumber),
+			Timestamp:          tx.Timestamp,
+			From:               tx.From.Hex(),
+			To:                 tx.To.Hex(),
+			Value:              new(big.Int).SetBytes(tx.Value),
+			TxFee:              new(big.Int).SetBytes(tx.Gas.TxFee),
+			GasPrice:           new(big.Int).SetBytes(tx.Gas.EffectiveFee),
+			IsContractCreation: tx.IsContractCreation,
+			InvokesContract:    tx.TargetIsContract,
+			Status:             tx.Receipt.Status,
+			ErrorMessage:       tx.ErrorMsg,
+		},
+		Nonce:          tx.Nonce,
+		Type:           tx.Type,
+		Position:       tx.TxnPosition,
+		GasLimit:       tx.Gas.Limit,
+		GasUsed:        tx.Gas.Used,
+		BaseFee:        new(big.Int).SetBytes(tx.Gas.BlockBaseFee),
+		MaxFee:         new(big.Int).SetBytes(tx.Gas.MaxFee),
+		MaxPriorityFee: new(big.Int).SetBytes(tx.Gas.MaxPriorityFee),
+		Finalized:      tx.Epoch.Finalized,
+	}
+	if len(tx.CallData) >= 10 {
+		result.Method = tx.CallData[:10]
+	}
+	if tx.IsContractCreation {
+		result.ContractAddress = tx.Receipt.ContractAddress.Hex()
+	}
+
+	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{result})
+}
+
+// ApiEth1TxItx godoc
+// @Summary Get the internal transactions of a transaction
+// @Tags Execution
+// @Description Returns the internal transactions of a transaction in the order of their execution, zero-value calls are omitted
+// @Produce json
+// @Param txhash path string true "Transaction hash"
+// @Success 200 {object} types.ApiResponse{data=[]types.ExecutionInternalTxApiResponse}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/execution/transaction/{txhash}/itx [get]
+func ApiEth1TxItx(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	tx, err := getApiEth1Transaction(mux.Vars(r)["txhash"])
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	internalTxs, err := db.BigtableClient.GetEth1ItxForTransaction(tx.Hash.Bytes(), tx.From.Bytes())
+	if err != nil {
+		logger.Errorf("error retrieving internal transactions of tx %v: %v", tx.Hash, err)
+		sendErrorResponse(w, r.URL.String(), "could not retrieve internal transactions")
+		return
+	}
+
+	results := make([]*types.ExecutionInternalTxApiResponse, 0, len(internalTxs))
+	for _, itx := range internalTxs {
+		results = append(results, formatEth1ItxForApiResponse(itx))
+	}
+
 	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{results})
 }
 
+// getApiEth1Transaction parses the hash and returns the mined transaction
+func getApiEth1Transaction(hash string) (*types.Eth1TxData, error) {
+	if !utils.IsValidEth1Tx(hash) {
+		return nil, fmt.Errorf("invalid transaction hash")
+	}
+	tx, err := eth1data.GetEth1Transaction(common.HexToHash(hash))
+	if err != nil {
+		logger.Warnf("error retrieving tx %v: %v", hash, err)
+		return nil, fmt.Errorf("transaction not found or still pending")
+	}
+	return tx, nil
+}
+
+// parseApiEth1Address parses an execution layer address with an optional 0x prefix
+func parseApiEth1Address(address string) ([]byte, error) {
+	address = strings.ToLower(strings.TrimPrefix(address, "0x"))
+	if !utils.IsValidEth1Address(address) {
+		return nil, fmt.Errorf("error invalid address. A ethereum address consists of an optional 0x prefix followed by 40 hexadecimal characters.")
+	}
+	return common.FromHex(address), nil
+}
+
+// parseApiEth1Page returns the bigtable prefix to continue reading from and the page size of a paginated execution request.
+// The page token is the encoded key of the last row of the previous page, it has to belong to the index of the request.
+func parseApiEth1Page(r *http.Request, index, start string) (string, int64, error) {
+	q := r.URL.Query()
+
+	limit := int64(apiEth1PageDefaultLimit)
+	if q.Get("limit") != "" {
+		var err error
+		limit, err = strconv.ParseInt(q.Get("limit"), 10, 64)
+		if err != nil || limit <= 0 {
+			return "", 0, fmt.Errorf("invalid limit provided")
+		}
+		if limit > apiEth1PageMaxLimit {
+			limit = apiEth1PageMaxLimit
+		}
+	}
+
+	if q.Get("pageToken") == "" {
+		return start, limit, nil
+	}
+	key, err := base64.RawURLEncoding.DecodeString(q.Get("pageToken"))
+	if err != nil || !strings.HasPrefix(string(key), index) {
+		return "", 0, fmt.Errorf("invalid page token provided")
+	}
+	return string(key), limit, nil
+}
+
+func newApiEth1Page(data interface{}, lastKey string) *types.ExecutionPageApiResponse {
+	page := &types.ExecutionPageApiResponse{Data: data}
+	if lastKey != "" {
+		page.PageToken = base64.RawURLEncoding.EncodeToString([]byte(lastKey))
+	}
+	return page
+}
+
+func formatEth1TxForApiResponse(tx *types.Eth1TransactionIndexed) *types.ExecutionTransactionApiResponse {
+	result := &types.ExecutionTransactionApiResponse{
+		Hash:               fmt.Sprintf("0x%x", tx.GetHash()),
+		BlockNumber:        tx.GetBlockNumber(),
+		Timestamp:          uint64(tx.GetTime().AsTime().Unix()),
+		From:               common.BytesToAddress(tx.GetFrom()).Hex(),
+		To:                 common.BytesToAddress(tx.GetTo()).Hex(),
+		Value:              new(big.Int).SetBytes(tx.GetValue()),
+		TxFee:              new(big.Int).SetBytes(tx.GetTxFee()),
+		GasPrice:           new(big.Int).SetBytes(tx.GetGasPrice()),
+		IsContractCreation: tx.GetIsContractCreation(),
+		InvokesContract:    tx.GetInvokesContract(),
+		Status:             tx.GetStatus(),
+		ErrorMessage:       tx.GetErrorMsg(),
+	}
+	if len(tx.GetMethodId()) > 0 {
+		result.Method = fmt.Sprintf("0x%x", tx.GetMethodId())
+	}
+	// transactions indexed before the receipt status was added only have the error of failed transactions
+	if tx.Status == nil && result.ErrorMessage == "" {
+		result.Status = 1
+	}
+	return result
+}
+
+func formatEth1ItxForApiResponse(itx *types.Eth1InternalTransactionIndexed) *types.ExecutionInternalTxApiResponse {
+	return &types.ExecutionInternalTxApiResponse{
+		ParentHash:  fmt.Sprintf("0x%x", itx.GetParentHash()),
+		BlockNumber: itx.GetBlockNumber(),
+		Timestamp:   uint64(itx.GetTime().AsTime().Unix()),
+		Type:        itx.GetType(),
+		From:        common.BytesToAddress(itx.GetFrom()).Hex(),
+		To:          common.BytesToAddress(itx.GetTo()).Hex(),
+		Value:       new(big.Int).SetBytes(itx.GetValue()),
+	}
+}
+
 func formatBlocksForApiResponse(blocks []*types.Eth1BlockIndexed, relaysData map[common.Hash]types.RelaysData, beaconDataMap map[uint64]types.ExecBlockProposer) []types.ExecutionBlockApiResponse {
 	results := []types.ExecutionBlockApiResponse{}
 
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"eth2-exporter/types"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseApiEth1Page(t *testing.T) {
+	index := "1:I:TX:00000000219ab540356cbb839cbe05303d7705fa:"
+	start := index + "TIME:"
+
+	prefix, limit, err := parseApiEth1Page(httptest.NewRequest("GET", "/?limit=1000", nil), index, start)
+	if err != nil || prefix != start || limit != apiEth1PageMaxLimit {
+		t.Errorf("unexpected first page %v %v %v", prefix, limit, err)
+	}
+
+	page := newApiEth1Page(nil, index+"TIME:9999:0001")
+	prefix, limit, err = parseApiEth1Page(httptest.NewRequest("GET", "/?pageToken="+page.PageToken, nil), index, start)
+	if err != nil || prefix != index+"TIME:9999:0001" || limit != apiEth1PageDefaultLimit {
+		t.Errorf("unexpected next page %v %v %v", prefix, limit, err)
+	}
+
+	otherIndex := base64.RawURLEncoding.EncodeToString([]byte("1:I:TX:ab5801a7d398351b8be11c439e05c5b3259aec9b:TIME:1"))
+	for _, query := range []string{"limit=0", "limit=abc", "pageToken=%21%21", "pageToken=" + otherIndex} {
+		if _, _, err := parseApiEth1Page(httptest.NewRequest("GET", "/?"+query, nil), index, start); err == nil {
+			t.Errorf("expected an error for %v", query)
+		}
+	}
+
+	if newApiEth1Page(nil, "").PageToken != "" {
+		t.Errorf("expected no page token for the last page")
+	}
+}
+
+func TestFormatEth1TxForApiResponse(t *testing.T) {
+	tx := formatEth1TxForApiResponse(&types.Eth1TransactionIndexed{Hash: []byte{0xab}, MethodId: []byte{0xa9, 0x05, 0x9c, 0xbb}, Value: []byte{0x01}})
+	if tx.Status != 1 || tx.Method != "0xa9059cbb" || tx.Value.Int64() != 1 || tx.Hash != "0xab" {
+		t.Errorf("unexpected transaction %+v", tx)
+	}
+
+	tx = formatEth1TxForApiResponse(&types.Eth1TransactionIndexed{ErrorMsg: "execution reverted"})
+	if tx.Status != 0 || tx.Method != "" {
+		t.Errorf("unexpected failed transaction %+v", tx)
+	}
+
+	// the indexed receipt status is used whenever it is set, failed transactions do not always have an error
+	tx = formatEth1TxForApiResponse(&types.Eth1TransactionIndexed{Status: proto.Uint64(0)})
+	if tx.Status != 0 {
+		t.Errorf("expected the receipt status of a failed transaction without an error, got %+v", tx)
+	}
+	tx = formatEth1TxForApiResponse(&types.Eth1TransactionIndexed{Status: proto.Uint64(1)})
+	if tx.Status != 1 {
+		t.Errorf("expected the receipt status of a successful transaction, got %+v", tx)
+	}
+}
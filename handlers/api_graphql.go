@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// maximum cost and nesting depth of a single graphql query, see graphqlQueryCost
+const graphqlMaxCost = 50000
+const graphqlMaxDepth = 10
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// ApiGraphql godoc
+// @Summary Graphql endpoint over validators, epochs, slots and execution blocks
+// @Tags GraphQL
+// @Description Accepts a query either as POST json body {"query", "variables", "operationName"} or as GET query parameters.
+// @Description Related entities are loaded in batches per level of the query, the cost of a query is limited by the number of items it can return.
+// @Produce  json
+// @Param  query query string false "GraphQL query"
+// @Success 200 {object} graphql.Result
+// @Router /graphql [get]
+func ApiGraphql(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	req := graphqlRequest{}
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if variables := q.Get("variables"); variables != "" {
+			err := json.Unmarshal([]byte(variables), &req.Variables)
+			if err != nil {
+				sendGraphqlErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(fmt.Errorf("invalid variables provided")))
+				return
+			}
+		}
+	case http.MethodPost:
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req)
+		if err != nil {
+			sendGraphqlErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(fmt.Errorf("invalid request body")))
+			return
+		}
+	}
+
+	schema, err := getGraphqlSchema()
+	if err != nil {
+		logger.WithError(err).Error("error building graphql schema")
+		sendGraphqlErrors(w, http.StatusInternalServerError, gqlerrors.FormatErrors(fmt.Errorf("could not build schema")))
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		sendGraphqlErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+		return
+	}
+	validation := graphql.ValidateDocument(schema, doc, nil)
+	if !validation.IsValid {
+		sendGraphqlErrors(w, http.StatusBadRequest, validation.Errors)
+		return
+	}
+
+	cost, depth, err := graphqlQueryCost(schema, doc, req.OperationName, req.Variables)
+	if err != nil {
+		sendGraphqlErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(err))
+		return
+	}
+	if depth > graphqlMaxDepth {
+		sendGraphqlErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(fmt.Errorf("query depth of %v exceeds the maximum depth of %v", depth, graphqlMaxDepth)))
+		return
+	}
+	if cost > graphqlMaxCost {
+		sendGraphqlErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(fmt.Errorf("query cost of %v exceeds the maximum cost of %v, reduce the limit arguments or the number of nested fields", cost, graphqlMaxCost)))
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), graphqlContextKey{}, &graphqlRequestContext{maxValidators: getUserPremium(r).MaxValidators})
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        *schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+
+	err = json.NewEncoder(w).Encode(result)
+	if err != nil {
+		logger.WithError(err).Error("error encoding graphql result")
+	}
+}
+
+// ApiGraphqlRateLimitMiddleware applies the same limits as ApiRateLimitMiddleware but responds with graphql formatted errors
+func ApiGraphqlRateLimitMiddleware(next http.Handler) http.Handler {
+	return apiRateLimitMiddleware(next, func(w http.ResponseWriter, r *http.Request, status int, message string) {
+		w.Header().Set("Content-Type", "application/json")
+		sendGraphqlErrors(w, status, gqlerrors.FormatErrors(fmt.Errorf("%s", message)))
+	})
+}
+
+func sendGraphqlErrors(w http.ResponseWriter, status int, errors []gqlerrors.FormattedError) {
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(&graphql.Result{Errors: errors})
+	if err != nil {
+		logger.WithError(err).Error("error encoding graphql errors")
+	}
+}
+
+// graphqlQueryCost returns the cost and the maximum field depth of the operation that will be executed.
+// Every field costs 1 or its entry in graphqlCosts, the cost of the selection of a list field is multiplied
+// by the number of items it can return. Introspection fields are free.
+func graphqlQueryCost(schema *graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}) (int, int, error) {
+	var operation *ast.OperationDefinition
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				if operation != nil && operationName == "" {
+					return 0, 0, fmt.Errorf("must provide operation name if query contains multiple operations")
+				}
+				operation = def
+			}
+		case *ast.FragmentDefinition:
+			fragments[def.Name.Value] = def
+		}
+	}
+	if operation == nil {
+		return 0, 0, fmt.Errorf("unknown operation named %q", operationName)
+	}
+	if operation.Operation != ast.OperationTypeQuery {
+		return 0, 0, fmt.Errorf("only query operations are supported")
+	}
+
+	// variables which are not provided use the default of their definition
+	resolved := make(map[string]interface{}, len(variables))
+	for _, def := range operation.VariableDefinitions {
+		if def.DefaultValue != nil {
+			if list, ok := def.DefaultValue.(*ast.ListValue); ok {
+				resolved[def.Variable.Name.Value] = list.Values
+			} else {
+				resolved[def.Variable.Name.Value] = def.DefaultValue.GetValue()
+			}
+		}
+	}
+	for k, v := range variables {
+		resolved[k] = v
+	}
+
+	c := &graphqlCostCalculator{fragments: fragments, variables: resolved}
+	return c.selectionSetCost(schema.QueryType(), operation.SelectionSet, 1)
+}
+
+type graphqlCostCalculator struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+func (c *graphqlCostCalculator) selectionSetCost(parent *graphql.Object, set *ast.SelectionSet, depth int) (int, int, error) {
+	if set == nil {
+		return 0, depth - 1, nil
+	}
+
+	cost := 0
+	maxDepth := depth
+	for _, selection := range set.Selections {
+		selectionCost := 0
+		selectionDepth := depth
+		var err error
+		switch selection := selection.(type) {
+		case *ast.Field:
+			selectionCost, selectionDepth, err = c.fieldCost(parent, selection, depth)
+		case *ast.InlineFragment:
+			selectionCost, selectionDepth, err = c.selectionSetCost(parent, selection.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			fragment, exists := c.fragments[selection.Name.Value]
+			if !exists {
+				return 0, 0, fmt.Errorf("unknown fragment %q", selection.Name.Value)
+			}
+			selectionCost, selectionDepth, err = c.selectionSetCost(parent, fragment.SelectionSet, depth)
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		cost += selectionCost
+		if selectionDepth > maxDepth {
+			maxDepth = selectionDepth
+		}
+		if cost > graphqlMaxCost {
+			// stop early, deeply nested fragments can otherwise take very long to evaluate
+			return cost, maxDepth, nil
+		}
+	}
+	return cost, maxDepth, nil
+}
+
+func (c *graphqlCostCalculator) fieldCost(parent *graphql.Object, field *ast.Field, depth int) (int, int, error) {
+	name := field.Name.Value
+	if strings.HasPrefix(name, "__") {
+		return 0, depth, nil
+	}
+	def, exists := parent.Fields()[name]
+	if !exists {
+		return 0, 0, fmt.Errorf("cannot query field %q on type %q", name, parent.Name())
+	}
+
+	cost, exists := graphqlCosts[parent.Name()+"."+name]
+	if !exists {
+		cost = 1
+	}
+
+	items := 1
+	for _, arg := range def.Args {
+		switch arg.Name() {
+		case "limit":
+			limit, ok := arg.DefaultValue.(int)
+			if value, set := c.argumentValue(field, arg.Name()); set {
+				limit, ok = graphqlToInt(value)
+			}
+			if !ok || limit < 1 || limit > graphqlMaxLimit {
+				return 0, 0, fmt.Errorf("invalid limit for field %q, it has to be between 1 and %v", name, graphqlMaxLimit)
+			}
+			items = limit
+		case "indices":
+			value, _ := c.argumentValue(field, arg.Name())
+			switch value := value.(type) {
+			case []interface{}:
+				items = len(value)
+			case []ast.Value:
+				items = len(value)
+			}
+		}
+	}
+
+	childType := graphql.GetNamed(def.Type)
+	object, ok := childType.(*graphql.Object)
+	if !ok {
+		return cost, depth, nil
+	}
+	childCost, childDepth, err := c.selectionSetCost(object, field.SelectionSet, depth+1)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cost + items*childCost, childDepth, nil
+}
+
+// argumentValue returns the value of an argument of field, variables are resolved. Literals are returned as ast values
+func (c *graphqlCostCalculator) argumentValue(field *ast.Field, name string) (interface{}, bool) {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != name {
+			continue
+		}
+		switch value := arg.Value.(type) {
+		case *ast.Variable:
+			v, exists := c.variables[value.Name.Value]
+			return v, exists && v != nil
+		case *ast.ListValue:
+			return value.Values, true
+		default:
+			return value.GetValue(), true
+		}
+	}
+	return nil, false
+}
+
+func graphqlToInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), v == float64(int(v))
+	case string:
+		var i int
+		_, err := fmt.Sscanf(v, "%d", &i)
+		return i, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+)
+
+type graphqlContextKey struct{}
+
+// graphqlRequestContext holds the state shared by all resolvers of a single graphql request
+type graphqlRequestContext struct {
+	maxValidators int
+
+	mux     sync.Mutex
+	loaders map[string]*graphqlLoader
+}
+
+func getGraphqlRequestContext(ctx context.Context) *graphqlRequestContext {
+	return ctx.Value(graphqlContextKey{}).(*graphqlRequestContext)
+}
+
+// loader returns the loader of the given name, creating it with fetch on first use.
+// Resolvers that need different arguments for the underlying db call have to use distinct names.
+func (c *graphqlRequestContext) loader(name string, fetch func(keys []interface{}) (map[interface{}]interface{}, error)) *graphqlLoader {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.loaders == nil {
+		c.loaders = make(map[string]*graphqlLoader)
+	}
+	l, exists := c.loaders[name]
+	if !exists {
+		l = &graphqlLoader{fetch: fetch, results: make(map[interface{}]interface{}), errors: make(map[interface{}]error)}
+		c.loaders[name] = l
+	}
+	return l
+}
+
+// graphqlLoader collects the keys requested by all resolvers of one level of the query and fetches them with a single call.
+// The executor resolves a level completely before it calls the returned thunks, so the first thunk sees all keys of the level.
+type graphqlLoader struct {
+	mux     sync.Mutex
+	fetch   func(keys []interface{}) (map[interface{}]interface{}, error)
+	pending []interface{}
+	queued  map[interface{}]bool
+	results map[interface{}]interface{}
+	errors  map[interface{}]error
+}
+
+func (l *graphqlLoader) load(key interface{}) func() (interface{}, error) {
+	l.mux.Lock()
+	_, fetched := l.results[key]
+	if _, failed := l.errors[key]; !fetched && !failed && !l.queued[key] {
+		if l.queued == nil {
+			l.queued = make(map[interface{}]bool)
+		}
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mux.Unlock()
+
+	return func() (interface{}, error) {
+		l.mux.Lock()
+		defer l.mux.Unlock()
+
+		if len(l.pending) > 0 {
+			keys := l.pending
+			l.pending = nil
+			l.queued = nil
+
+			res, err := l.fetch(keys)
+			for _, k := range keys {
+				if err != nil {
+					l.errors[k] = err
+					continue
+				}
+				l.results[k] = res[k]
+			}
+		}
+		return l.results[key], l.errors[key]
+	}
+}
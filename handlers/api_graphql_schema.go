@@ -0,0 +1,864 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"eth2-exporter/db"
+	"eth2-exporter/services"
+	"eth2-exporter/types"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/lib/pq"
+)
+
+// maximum number of epochs or items list fields with a limit argument return
+const graphqlMaxLimit = 100
+
+var graphqlUint64 = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Uint64",
+	Description: "Unsigned 64 bit integer, serialized as a json number",
+	Serialize: func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case uint64, int64, int:
+			return v
+		case *uint64:
+			if v == nil {
+				return nil
+			}
+			return *v
+		}
+		return nil
+	},
+	ParseValue: func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case int:
+			if v >= 0 {
+				return uint64(v)
+			}
+		case float64:
+			if v >= 0 && v == float64(uint64(v)) {
+				return uint64(v)
+			}
+		}
+		return nil
+	},
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		if v, ok := valueAST.(*ast.IntValue); ok {
+			i, err := strconv.ParseUint(v.Value, 10, 64)
+			if err == nil {
+				return i
+			}
+		}
+		return nil
+	},
+})
+
+var graphqlBytes = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Bytes",
+	Description: "Byte array, serialized as a 0x prefixed hex string",
+	Serialize: func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case hexutil.Bytes:
+			return v.String()
+		case []byte:
+			return hexutil.Encode(v)
+		}
+		return nil
+	},
+	ParseValue: func(value interface{}) interface{} {
+		if s, ok := value.(string); ok {
+			if b, err := hexutil.Decode(s); err == nil {
+				return hexutil.Bytes(b)
+			}
+		}
+		return nil
+	},
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		if v, ok := valueAST.(*ast.StringValue); ok {
+			if b, err := hexutil.Decode(v.Value); err == nil {
+				return hexutil.Bytes(b)
+			}
+		}
+		return nil
+	},
+})
+
+// graphqlCosts holds the base cost of fields which are resolved with an additional db or bigtable request,
+// all other fields cost 1. Fields with a limit argument multiply the cost of their selection by the limit.
+var graphqlCosts = map[string]int{
+	"Query.validator":                      5,
+	"Query.validators":                     5,
+	"Query.epoch":                          5,
+	"Query.epochs":                         5,
+	"Query.slot":                           5,
+	"Query.execution_block":                10,
+	"Query.address":                        10,
+	"Validator.balance_history":            10,
+	"Validator.attestations":               10,
+	"Validator.proposals":                  10,
+	"Validator.deposits":                   5,
+	"Epoch.slots":                          5,
+	"Slot.proposer_validator":              5,
+	"Slot.execution_block":                 10,
+	"Proposal.block":                       5,
+	"Deposit.from":                         10,
+	"Deposit.validator":                    5,
+	"Address.deposits":                     5,
+	"Address.validators":                   5,
+	"ExecutionBlock.slot":                  5,
+	"ExecutionBlock.fee_recipient_address": 10,
+}
+
+var graphqlSchema graphql.Schema
+var graphqlSchemaErr error
+var graphqlSchemaOnce sync.Once
+
+func getGraphqlSchema() (*graphql.Schema, error) {
+	graphqlSchemaOnce.Do(func() {
+		graphqlSchema, graphqlSchemaErr = buildGraphqlSchema()
+	})
+	return &graphqlSchema, graphqlSchemaErr
+}
+
+func buildGraphqlSchema() (graphql.Schema, error) {
+	var validatorType, epochType, slotType, proposalType, depositType, executionBlockType, addressType *graphql.Object
+
+	latestEpochArgs := graphql.FieldConfigArgument{
+		"latest_epoch": &graphql.ArgumentConfig{Type: graphqlUint64, Description: "Most recent epoch to return, defaults to the latest epoch"},
+		"limit":        &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10, Description: fmt.Sprintf("Number of epochs to return, up to %v", graphqlMaxLimit)},
+	}
+
+	balanceType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ValidatorBalance",
+		Fields: graphql.Fields{
+			"epoch":             &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+			"balance":           &graphql.Field{Type: graphql.NewNonNull(graphqlUint64), Description: "Balance in Gwei"},
+			"effective_balance": &graphql.Field{Type: graphql.NewNonNull(graphqlUint64), Description: "Effective balance in Gwei"},
+		},
+	})
+
+	attestationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Attestation",
+		Fields: graphql.Fields{
+			"epoch":           &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+			"attester_slot":   &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+			"committee_index": &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+			"status":          &graphql.Field{Type: graphql.NewNonNull(graphqlUint64), Description: "0 = missed, 1 = attested"},
+			"inclusion_slot":  &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+			"delay":           &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	validatorType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Validator",
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			return graphql.Fields{
+				"validator_index":              &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"pubkey":                       &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"withdrawal_credentials":       &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"balance":                      &graphql.Field{Type: graphql.NewNonNull(graphqlUint64), Description: "Balance in Gwei"},
+				"effective_balance":            &graphql.Field{Type: graphql.NewNonNull(graphqlUint64), Description: "Effective balance in Gwei"},
+				"slashed":                      &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+				"activation_eligibility_epoch": &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"activation_epoch":             &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"exit_epoch":                   &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"withdrawable_epoch":           &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"last_attestation_slot":        &graphql.Field{Type: graphqlUint64},
+				"status":                       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"balance_history": &graphql.Field{
+					Type:        graphql.NewList(graphql.NewNonNull(balanceType)),
+					Description: "Balances of the validator per epoch, ordered descending by epoch",
+					Args:        latestEpochArgs,
+					Resolve:     resolveGraphqlBalanceHistory,
+				},
+				"attestations": &graphql.Field{
+					Type:        graphql.NewList(graphql.NewNonNull(attestationType)),
+					Description: "Attestation duties of the validator, ordered descending by epoch",
+					Args:        latestEpochArgs,
+					Resolve:     resolveGraphqlAttestations,
+				},
+				"proposals": &graphql.Field{
+					Type:        graphql.NewList(graphql.NewNonNull(proposalType)),
+					Description: "Block proposal duties of the validator, ordered descending by slot",
+					Args:        latestEpochArgs,
+					Resolve:     resolveGraphqlProposals,
+				},
+				"deposits": &graphql.Field{
+					Type:    graphql.NewList(graphql.NewNonNull(depositType)),
+					Resolve: resolveGraphqlValidatorDeposits,
+				},
+			}
+		}),
+	})
+
+	epochType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Epoch",
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			return graphql.Fields{
+				"epoch":                     &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"blocks_count":              &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"proposer_slashings_count":  &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"attester_slashings_count":  &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"attestations_count":        &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"deposits_count":            &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"voluntary_exits_count":     &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"validators_count":          &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"average_validator_balance": &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"total_validator_balance":   &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"finalized":                 &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+				"eligible_ether":            &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"global_participation_rate": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+				"voted_ether":               &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"slots": &graphql.Field{
+					Type:        graphql.NewList(graphql.NewNonNull(slotType)),
+					Description: "Blocks of the epoch ordered by slot",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						epoch := p.Source.(*types.ApiV2Epoch).Epoch
+						return getGraphqlRequestContext(p.Context).loader("epochSlots", fetchGraphqlEpochSlots).load(epoch), nil
+					},
+				},
+			}
+		}),
+	})
+
+	slotType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Slot",
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			return graphql.Fields{
+				"epoch":                        &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"slot":                         &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"block_root":                   &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"parent_root":                  &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"state_root":                   &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"proposer":                     &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"status":                       &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "0 = scheduled, 1 = proposed, 2 = missed, 3 = orphaned"},
+				"graffiti":                     &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"graffiti_text":                &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"attestations_count":           &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"deposits_count":               &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"voluntary_exits_count":        &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"proposer_slashings_count":     &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"attester_slashings_count":     &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"sync_aggregate_participation": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+				"exec_block_number":            &graphql.Field{Type: graphqlUint64},
+				"exec_block_hash":              &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"exec_fee_recipient":           &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"exec_transactions_count":      &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"proposer_validator": &graphql.Field{
+					Type: validatorType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return loadGraphqlValidator(p, p.Source.(*types.ApiV2Block).Proposer), nil
+					},
+				},
+				"execution_block": &graphql.Field{
+					Type: executionBlockType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						number := p.Source.(*types.ApiV2Block).ExecBlockNumber
+						if number == nil || *number == 0 {
+							return nil, nil
+						}
+						return getGraphqlRequestContext(p.Context).loader("executionBlocks", fetchGraphqlExecutionBlocks).load(*number), nil
+					},
+				},
+			}
+		}),
+	})
+
+	proposalType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Proposal",
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			return graphql.Fields{
+				"slot":   &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"status": &graphql.Field{Type: graphql.NewNonNull(graphqlUint64), Description: "0 = scheduled, 1 = proposed, 2 = missed, 3 = orphaned"},
+				"block": &graphql.Field{
+					Type: slotType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return getGraphqlRequestContext(p.Context).loader("slots", fetchGraphqlSlots).load(p.Source.(*types.ApiV2Proposal).Slot), nil
+					},
+				},
+			}
+		}),
+	})
+
+	depositType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Deposit",
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			return graphql.Fields{
+				"tx_hash":                &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"block_number":           &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"block_ts":               &graphql.Field{Type: graphql.NewNonNull(graphqlUint64), Description: "Unix timestamp of the execution block"},
+				"from_address":           &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"pubkey":                 &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"withdrawal_credentials": &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"amount":                 &graphql.Field{Type: graphql.NewNonNull(graphqlUint64), Description: "Amount in Gwei"},
+				"valid_signature":        &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+				"from": &graphql.Field{
+					Type: addressType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return getGraphqlRequestContext(p.Context).loader("addresses", fetchGraphqlAddresses).load(hex.EncodeToString(p.Source.(*types.ApiV2Deposit).FromAddress)), nil
+					},
+				},
+				"validator": &graphql.Field{
+					Type: validatorType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return getGraphqlRequestContext(p.Context).loader("validatorsByPubkey", fetchGraphqlValidatorsByPubkey).load(hex.EncodeToString(p.Source.(*types.ApiV2Deposit).Pubkey)), nil
+					},
+				},
+			}
+		}),
+	})
+
+	executionBlockType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ExecutionBlock",
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			return graphql.Fields{
+				"number":            &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"hash":              &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"parent_hash":       &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"fee_recipient":     &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"gas_limit":         &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"gas_used":          &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"timestamp":         &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"base_fee":          &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "Base fee per gas in Wei"},
+				"transaction_count": &graphql.Field{Type: graphql.NewNonNull(graphqlUint64)},
+				"slot": &graphql.Field{
+					Type:        slotType,
+					Description: "Consensus layer slot which included the block, null before the merge",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return getGraphqlRequestContext(p.Context).loader("executionBlockSlots", fetchGraphqlExecutionBlockSlots).load(p.Source.(*types.ApiV2ExecutionBlock).Number), nil
+					},
+				},
+				"fee_recipient_address": &graphql.Field{
+					Type: addressType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return getGraphqlRequestContext(p.Context).loader("addresses", fetchGraphqlAddresses).load(hex.EncodeToString(p.Source.(*types.ApiV2ExecutionBlock).FeeRecipient)), nil
+					},
+				},
+			}
+		}),
+	})
+
+	addressType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Address",
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			return graphql.Fields{
+				"address":     &graphql.Field{Type: graphql.NewNonNull(graphqlBytes)},
+				"name":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"eth_balance": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "Balance in Wei"},
+				"deposits": &graphql.Field{
+					Type:        graphql.NewList(graphql.NewNonNull(depositType)),
+					Description: "Deposits sent from the address, ordered descending by block",
+					Args: graphql.FieldConfigArgument{
+						"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10, Description: fmt.Sprintf("Number of deposits to return, up to %v", graphqlMaxLimit)},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						limit, err := getGraphqlLimit(p)
+						if err != nil {
+							return nil, err
+						}
+						name := fmt.Sprintf("addressDeposits:%d", limit)
+						return getGraphqlRequestContext(p.Context).loader(name, fetchGraphqlAddressDeposits(limit)).load(hex.EncodeToString(p.Source.(*types.ApiV2Address).Address)), nil
+					},
+				},
+				"validators": &graphql.Field{
+					Type:        graphql.NewList(graphql.NewNonNull(validatorType)),
+					Description: "Validators which received a deposit from the address, ordered by index",
+					Args: graphql.FieldConfigArgument{
+						"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10, Description: fmt.Sprintf("Number of validators to return, up to %v", graphqlMaxLimit)},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						limit, err := getGraphqlLimit(p)
+						if err != nil {
+							return nil, err
+						}
+						name := fmt.Sprintf("addressValidators:%d", limit)
+						return getGraphqlRequestContext(p.Context).loader(name, fetchGraphqlAddressValidators(limit)).load(hex.EncodeToString(p.Source.(*types.ApiV2Address).Address)), nil
+					},
+				},
+			}
+		}),
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"validator": &graphql.Field{
+				Type: validatorType,
+				Args: graphql.FieldConfigArgument{
+					"index_or_pubkey": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String), Description: "Validator index or 0x prefixed public key"},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					param := p.Args["index_or_pubkey"].(string)
+					if strings.HasPrefix(param, "0x") {
+						pubkey, err := hex.DecodeString(param[2:])
+						if err != nil || len(pubkey) != 48 {
+							return nil, fmt.Errorf("invalid validator public key provided")
+						}
+						return getGraphqlRequestContext(p.Context).loader("validatorsByPubkey", fetchGraphqlValidatorsByPubkey).load(param[2:]), nil
+					}
+					index, err := strconv.ParseUint(param, 10, 64)
+					if err != nil {
+						return nil, fmt.Errorf("invalid validator index provided")
+					}
+					return loadGraphqlValidator(p, index), nil
+				},
+			},
+			"validators": &graphql.Field{
+				Type: graphql.NewList(validatorType),
+				Args: graphql.FieldConfigArgument{
+					"indices": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphqlUint64)))},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					indices := p.Args["indices"].([]interface{})
+					if max := getGraphqlRequestContext(p.Context).maxValidators; len(indices) > max {
+						return nil, fmt.Errorf("only a maximum of %v validators can be queried at once", max)
+					}
+					res := make([]interface{}, 0, len(indices))
+					for _, i := range indices {
+						res = append(res, loadGraphqlValidator(p, i.(uint64)))
+					}
+					return res, nil
+				},
+			},
+			"epoch": &graphql.Field{
+				Type: epochType,
+				Args: graphql.FieldConfigArgument{
+					"epoch": &graphql.ArgumentConfig{Type: graphqlUint64, Description: "Epoch number, defaults to the latest epoch"},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					epoch, ok := p.Args["epoch"].(uint64)
+					if !ok {
+						epoch = services.LatestEpoch()
+					}
+					return getGraphqlRequestContext(p.Context).loader("epochs", fetchGraphqlEpochs).load(epoch), nil
+				},
+			},
+			"epochs": &graphql.Field{
+				Type:        graphql.NewList(graphql.NewNonNull(epochType)),
+				Description: "Epochs ordered descending",
+				Args:        latestEpochArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					latestEpoch, limit, err := getGraphqlEpochRange(p)
+					if err != nil {
+						return nil, err
+					}
+					epochs := []*types.ApiV2Epoch{}
+					err = db.ReaderDb.Select(&epochs, `SELECT `+apiV2EpochColumns+` FROM epochs WHERE epoch <= $1 ORDER BY epoch DESC LIMIT $2`, latestEpoch, limit)
+					if err != nil {
+						logger.WithError(err).Error("error retrieving epochs for graphql")
+						return nil, fmt.Errorf("could not retrieve db results")
+					}
+					return epochs, nil
+				},
+			},
+			"slot": &graphql.Field{
+				Type: slotType,
+				Args: graphql.FieldConfigArgument{
+					"slot": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphqlUint64)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return getGraphqlRequestContext(p.Context).loader("slots", fetchGraphqlSlots).load(p.Args["slot"].(uint64)), nil
+				},
+			},
+			"execution_block": &graphql.Field{
+				Type: executionBlockType,
+				Args: graphql.FieldConfigArgument{
+					"number": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphqlUint64)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return getGraphqlRequestContext(p.Context).loader("executionBlocks", fetchGraphqlExecutionBlocks).load(p.Args["number"].(uint64)), nil
+				},
+			},
+			"address": &graphql.Field{
+				Type: addressType,
+				Args: graphql.FieldConfigArgument{
+					"address": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphqlBytes), Description: "0x prefixed execution layer address"},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					address, ok := p.Args["address"].(hexutil.Bytes)
+					if !ok || len(address) != 20 {
+						return nil, fmt.Errorf("invalid address provided")
+					}
+					return getGraphqlRequestContext(p.Context).loader("addresses", fetchGraphqlAddresses).load(hex.EncodeToString(address)), nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+func loadGraphqlValidator(p graphql.ResolveParams, index uint64) func() (interface{}, error) {
+	return getGraphqlRequestContext(p.Context).loader("validators", fetchGraphqlValidators).load(index)
+}
+
+func getGraphqlLimit(p graphql.ResolveParams) (int64, error) {
+	limit, ok := p.Args["limit"].(int)
+	if !ok || limit < 1 || limit > graphqlMaxLimit {
+		return 0, fmt.Errorf("invalid limit, it has to be between 1 and %v", graphqlMaxLimit)
+	}
+	return int64(limit), nil
+}
+
+// getGraphqlEpochRange returns the latest_epoch and limit arguments, the limit is reduced so the range does not go below epoch 0
+func getGraphqlEpochRange(p graphql.ResolveParams) (uint64, int64, error) {
+	limit, err := getGraphqlLimit(p)
+	if err != nil {
+		return 0, 0, err
+	}
+	onChainLatestEpoch := services.LatestEpoch()
+	latestEpoch, ok := p.Args["latest_epoch"].(uint64)
+	if !ok {
+		latestEpoch = onChainLatestEpoch
+	}
+	if latestEpoch > onChainLatestEpoch {
+		return 0, 0, fmt.Errorf("invalid latest_epoch, the latest epoch is %v", onChainLatestEpoch)
+	}
+	if uint64(limit) > latestEpoch+1 {
+		limit = int64(latestEpoch + 1)
+	}
+	return latestEpoch, limit, nil
+}
+
+func resolveGraphqlBalanceHistory(p graphql.ResolveParams) (interface{}, error) {
+	latestEpoch, limit, err := getGraphqlEpochRange(p)
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("balanceHistory:%d:%d", latestEpoch, limit)
+	return getGraphqlRequestContext(p.Context).loader(name, func(keys []interface{}) (map[interface{}]interface{}, error) {
+		history, err := db.BigtableClient.GetValidatorBalanceHistory(graphqlUint64Keys(keys), latestEpoch, limit)
+		if err != nil {
+			logger.WithError(err).Error("error retrieving validator balance history for graphql")
+			return nil, fmt.Errorf("could not retrieve db results")
+		}
+		res := make(map[interface{}]interface{}, len(keys))
+		for _, k := range keys {
+			balances := make([]*types.ApiV2ValidatorBalance, 0, len(history[k.(uint64)]))
+			for _, b := range history[k.(uint64)] {
+				balances = append(balances, &types.ApiV2ValidatorBalance{Epoch: b.Epoch, Balance: b.Balance, EffectiveBalance: b.EffectiveBalance})
+			}
+			res[k] = balances
+		}
+		return res, nil
+	}).load(p.Source.(*types.ApiV2Validator).ValidatorIndex), nil
+}
+
+func resolveGraphqlAttestations(p graphql.ResolveParams) (interface{}, error) {
+	latestEpoch, limit, err := getGraphqlEpochRange(p)
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("attestations:%d:%d", latestEpoch, limit)
+	return getGraphqlRequestContext(p.Context).loader(name, func(keys []interface{}) (map[interface{}]interface{}, error) {
+		history, err := db.BigtableClient.GetValidatorAttestationHistory(graphqlUint64Keys(keys), latestEpoch, limit)
+		if err != nil {
+			logger.WithError(err).Error("error retrieving validator attestation history for graphql")
+			return nil, fmt.Errorf("could not retrieve db results")
+		}
+		res := make(map[interface{}]interface{}, len(keys))
+		for _, k := range keys {
+			attestations := make([]*types.ApiV2Attestation, 0, len(history[k.(uint64)]))
+			for _, a := range history[k.(uint64)] {
+				attestations = append(attestations, &types.ApiV2Attestation{
+					Epoch:          a.Epoch,
+					AttesterSlot:   a.AttesterSlot,
+					CommitteeIndex: a.CommitteeIndex,
+					Status:         a.Status,
+					InclusionSlot:  a.InclusionSlot,
+					Delay:          a.Delay,
+				})
+			}
+			res[k] = attestations
+		}
+		return res, nil
+	}).load(p.Source.(*types.ApiV2Validator).ValidatorIndex), nil
+}
+
+func resolveGraphqlProposals(p graphql.ResolveParams) (interface{}, error) {
+	latestEpoch, limit, err := getGraphqlEpochRange(p)
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("proposals:%d:%d", latestEpoch, limit)
+	return getGraphqlRequestContext(p.Context).loader(name, func(keys []interface{}) (map[interface{}]interface{}, error) {
+		history, err := db.BigtableClient.GetValidatorProposalHistory(graphqlUint64Keys(keys), latestEpoch, limit)
+		if err != nil {
+			logger.WithError(err).Error("error retrieving validator proposal history for graphql")
+			return nil, fmt.Errorf("could not retrieve db results")
+		}
+		res := make(map[interface{}]interface{}, len(keys))
+		for _, k := range keys {
+			proposals := make([]*types.ApiV2Proposal, 0, len(history[k.(uint64)]))
+			for _, proposal := range history[k.(uint64)] {
+				proposals = append(proposals, &types.ApiV2Proposal{Slot: proposal.Slot, Status: proposal.Status})
+			}
+			res[k] = proposals
+		}
+		return res, nil
+	}).load(p.Source.(*types.ApiV2Validator).ValidatorIndex), nil
+}
+
+func resolveGraphqlValidatorDeposits(p graphql.ResolveParams) (interface{}, error) {
+	return getGraphqlRequestContext(p.Context).loader("validatorDeposits", func(keys []interface{}) (map[interface{}]interface{}, error) {
+		rows := []struct {
+			ValidatorIndex uint64 `db:"validatorindex"`
+			types.ApiV2Deposit
+		}{}
+		err := db.ReaderDb.Select(&rows, `
+			SELECT v.validatorindex, `+graphqlDepositColumns+`
+			FROM validators v
+			INNER JOIN eth1_deposits d ON d.publickey = v.pubkey
+			WHERE v.validatorindex = ANY($1)
+			ORDER BY d.block_number, d.tx_index`, pq.Array(graphqlUint64Keys(keys)))
+		if err != nil {
+			logger.WithError(err).Error("error retrieving validator deposits for graphql")
+			return nil, fmt.Errorf("could not retrieve db results")
+		}
+		res := make(map[interface{}]interface{}, len(keys))
+		for _, k := range keys {
+			res[k] = []*types.ApiV2Deposit{}
+		}
+		for i := range rows {
+			res[rows[i].ValidatorIndex] = append(res[rows[i].ValidatorIndex].([]*types.ApiV2Deposit), &rows[i].ApiV2Deposit)
+		}
+		return res, nil
+	}).load(p.Source.(*types.ApiV2Validator).ValidatorIndex), nil
+}
+
+const graphqlValidatorColumns = `v.validatorindex, v.pubkey, v.withdrawalcredentials, v.balance, v.effectivebalance, v.slashed, v.activationeligibilityepoch,
+	v.activationepoch, v.exitepoch, v.withdrawableepoch, v.lastattestationslot, v.status`
+
+const graphqlDepositColumns = `d.tx_hash, d.block_number, EXTRACT(epoch FROM d.block_ts)::bigint AS block_ts, d.from_address, d.publickey,
+	d.withdrawal_credentials, d.amount, d.valid_signature`
+
+func fetchGraphqlValidators(keys []interface{}) (map[interface{}]interface{}, error) {
+	validators := []*types.ApiV2Validator{}
+	err := db.ReaderDb.Select(&validators, `SELECT `+graphqlValidatorColumns+` FROM validators v WHERE v.validatorindex = ANY($1)`, pq.Array(graphqlUint64Keys(keys)))
+	if err != nil {
+		logger.WithError(err).Error("error retrieving validators for graphql")
+		return nil, fmt.Errorf("could not retrieve db results")
+	}
+	res := make(map[interface{}]interface{}, len(validators))
+	for _, v := range validators {
+		res[v.ValidatorIndex] = v
+	}
+	return res, nil
+}
+
+func fetchGraphqlValidatorsByPubkey(keys []interface{}) (map[interface{}]interface{}, error) {
+	pubkeys, err := graphqlBytesKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+	validators := []*types.ApiV2Validator{}
+	err = db.ReaderDb.Select(&validators, `SELECT `+graphqlValidatorColumns+` FROM validators v WHERE v.pubkey = ANY($1)`, pubkeys)
+	if err != nil {
+		logger.WithError(err).Error("error retrieving validators by pubkey for graphql")
+		return nil, fmt.Errorf("could not retrieve db results")
+	}
+	res := make(map[interface{}]interface{}, len(validators))
+	for _, v := range validators {
+		res[hex.EncodeToString(v.Pubkey)] = v
+	}
+	return res, nil
+}
+
+func fetchGraphqlEpochs(keys []interface{}) (map[interface{}]interface{}, error) {
+	epochs := []*types.ApiV2Epoch{}
+	err := db.ReaderDb.Select(&epochs, `SELECT `+apiV2EpochColumns+` FROM epochs WHERE epoch = ANY($1)`, pq.Array(graphqlUint64Keys(keys)))
+	if err != nil {
+		logger.WithError(err).Error("error retrieving epochs for graphql")
+		return nil, fmt.Errorf("could not retrieve db results")
+	}
+	res := make(map[interface{}]interface{}, len(epochs))
+	for _, e := range epochs {
+		res[e.Epoch] = e
+	}
+	return res, nil
+}
+
+// fetchGraphqlSlots returns the block of each slot, proposed blocks take precedence over orphaned ones
+func fetchGraphqlSlots(keys []interface{}) (map[interface{}]interface{}, error) {
+	blocks := []*types.ApiV2Block{}
+	err := db.ReaderDb.Select(&blocks, `SELECT `+apiV2BlockColumns+` FROM blocks WHERE slot = ANY($1) ORDER BY slot, status = '1'`, pq.Array(graphqlUint64Keys(keys)))
+	if err != nil {
+		logger.WithError(err).Error("error retrieving slots for graphql")
+		return nil, fmt.Errorf("could not retrieve db results")
+	}
+	res := make(map[interface{}]interface{}, len(blocks))
+	for _, b := range blocks {
+		res[b.Slot] = b
+	}
+	return res, nil
+}
+
+func fetchGraphqlEpochSlots(keys []interface{}) (map[interface{}]interface{}, error) {
+	blocks := []*types.ApiV2Block{}
+	err := db.ReaderDb.Select(&blocks, `SELECT `+apiV2BlockColumns+` FROM blocks WHERE epoch = ANY($1) ORDER BY slot, blockroot`, pq.Array(graphqlUint64Keys(keys)))
+	if err != nil {
+		logger.WithError(err).Error("error retrieving epoch slots for graphql")
+		return nil, fmt.Errorf("could not retrieve db results")
+	}
+	res := make(map[interface{}]interface{}, len(keys))
+	for _, k := range keys {
+		res[k] = []*types.ApiV2Block{}
+	}
+	for _, b := range blocks {
+		res[b.Epoch] = append(res[b.Epoch].([]*types.ApiV2Block), b)
+	}
+	return res, nil
+}
+
+func fetchGraphqlExecutionBlockSlots(keys []interface{}) (map[interface{}]interface{}, error) {
+	blocks := []*types.ApiV2Block{}
+	err := db.ReaderDb.Select(&blocks, `SELECT `+apiV2BlockColumns+` FROM blocks WHERE exec_block_number = ANY($1) AND status = '1'`, pq.Array(graphqlUint64Keys(keys)))
+	if err != nil {
+		logger.WithError(err).Error("error retrieving slots of execution blocks for graphql")
+		return nil, fmt.Errorf("could not retrieve db results")
+	}
+	res := make(map[interface{}]interface{}, len(blocks))
+	for _, b := range blocks {
+		res[*b.ExecBlockNumber] = b
+	}
+	return res, nil
+}
+
+func fetchGraphqlExecutionBlocks(keys []interface{}) (map[interface{}]interface{}, error) {
+	numbers := graphqlUint64Keys(keys)
+	blocks, err := db.BigtableClient.GetBlocksIndexedMultiple(numbers, uint64(len(numbers)))
+	if err != nil {
+		logger.WithError(err).Error("error retrieving execution blocks for graphql")
+		return nil, fmt.Errorf("could not retrieve db results")
+	}
+	res := make(map[interface{}]interface{}, len(blocks))
+	for _, b := range blocks {
+		res[b.Number] = &types.ApiV2ExecutionBlock{
+			Number:           b.Number,
+			Hash:             b.Hash,
+			ParentHash:       b.ParentHash,
+			FeeRecipient:     b.Coinbase,
+			GasLimit:         b.GasLimit,
+			GasUsed:          b.GasUsed,
+			Timestamp:        b.GetTime().GetSeconds(),
+			BaseFee:          new(big.Int).SetBytes(b.BaseFee).String(),
+			TransactionCount: b.TransactionCount,
+		}
+	}
+	return res, nil
+}
+
+func fetchGraphqlAddresses(keys []interface{}) (map[interface{}]interface{}, error) {
+	res := make(map[interface{}]interface{}, len(keys))
+	for _, k := range keys {
+		address, err := hex.DecodeString(k.(string))
+		if err != nil {
+			return nil, fmt.Errorf("invalid address provided")
+		}
+		metadata, err := db.BigtableClient.GetMetadataForAddress(address)
+		if err != nil {
+			logger.WithError(err).Errorf("error retrieving metadata of address %x for graphql", address)
+			return nil, fmt.Errorf("could not retrieve db results")
+		}
+		ethBalance := new(big.Int)
+		if metadata.EthBalance != nil {
+			ethBalance.SetBytes(metadata.EthBalance.Balance)
+		}
+		res[k] = &types.ApiV2Address{Address: address, Name: metadata.Name, EthBalance: ethBalance.String()}
+	}
+	return res, nil
+}
+
+func fetchGraphqlAddressDeposits(limit int64) func(keys []interface{}) (map[interface{}]interface{}, error) {
+	return func(keys []interface{}) (map[interface{}]interface{}, error) {
+		addresses, err := graphqlBytesKeys(keys)
+		if err != nil {
+			return nil, err
+		}
+		deposits := []*types.ApiV2Deposit{}
+		err = db.ReaderDb.Select(&deposits, `
+			SELECT tx_hash, block_number, block_ts, from_address, publickey, withdrawal_credentials, amount, valid_signature
+			FROM (
+				SELECT `+graphqlDepositColumns+`, ROW_NUMBER() OVER (PARTITION BY d.from_address ORDER BY d.block_number DESC, d.tx_index DESC) AS n
+				FROM eth1_deposits d
+				WHERE d.from_address = ANY($1)
+			) d
+			WHERE n <= $2
+			ORDER BY block_number DESC`, addresses, limit)
+		if err != nil {
+			logger.WithError(err).Error("error retrieving address deposits for graphql")
+			return nil, fmt.Errorf("could not retrieve db results")
+		}
+		res := make(map[interface{}]interface{}, len(keys))
+		for _, k := range keys {
+			res[k] = []*types.ApiV2Deposit{}
+		}
+		for _, d := range deposits {
+			key := hex.EncodeToString(d.FromAddress)
+			res[key] = append(res[key].([]*types.ApiV2Deposit), d)
+		}
+		return res, nil
+	}
+}
+
+func fetchGraphqlAddressValidators(limit int64) func(keys []interface{}) (map[interface{}]interface{}, error) {
+	return func(keys []interface{}) (map[interface{}]interface{}, error) {
+		addresses, err := graphqlBytesKeys(keys)
+		if err != nil {
+			return nil, err
+		}
+		rows := []struct {
+			FromAddress []byte `db:"from_address"`
+			types.ApiV2Validator
+		}{}
+		err = db.ReaderDb.Select(&rows, `
+			SELECT from_address, validatorindex, pubkey, withdrawalcredentials, balance, effectivebalance, slashed, activationeligibilityepoch,
+				activationepoch, exitepoch, withdrawableepoch, lastattestationslot, status
+			FROM (
+				SELECT d.from_address, `+graphqlValidatorColumns+`, ROW_NUMBER() OVER (PARTITION BY d.from_address ORDER BY v.validatorindex) AS n
+				FROM (SELECT DISTINCT from_address, publickey FROM eth1_deposits WHERE from_address = ANY($1)) d
+				INNER JOIN validators v ON v.pubkey = d.publickey
+			) v
+			WHERE n <= $2
+			ORDER BY validatorindex`, addresses, limit)
+		if err != nil {
+			logger.WithError(err).Error("error retrieving address validators for graphql")
+			return nil, fmt.Errorf("could not retrieve db results")
+		}
+		res := make(map[interface{}]interface{}, len(keys))
+		for _, k := range keys {
+			res[k] = []*types.ApiV2Validator{}
+		}
+		for i := range rows {
+			key := hex.EncodeToString(rows[i].FromAddress)
+			res[key] = append(res[key].([]*types.ApiV2Validator), &rows[i].ApiV2Validator)
+		}
+		return res, nil
+	}
+}
+
+func graphqlUint64Keys(keys []interface{}) []uint64 {
+	res := make([]uint64, 0, len(keys))
+	for _, k := range keys {
+		res = append(res, k.(uint64))
+	}
+	return res
+}
+
+func graphqlBytesKeys(keys []interface{}) (pq.ByteaArray, error) {
+	res := make(pq.ByteaArray, 0, len(keys))
+	for _, k := range keys {
+		b, err := hex.DecodeString(k.(string))
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex key %v", k)
+		}
+		res = append(res, b)
+	}
+	return res, nil
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+func TestGraphqlLoaderBatchesKeys(t *testing.T) {
+	c := &graphqlRequestContext{}
+	calls := [][]interface{}{}
+	fetch := func(keys []interface{}) (map[interface{}]interface{}, error) {
+		calls = append(calls, keys)
+		res := map[interface{}]interface{}{}
+		for _, k := range keys {
+			res[k] = k.(uint64) * 2
+		}
+		return res, nil
+	}
+
+	thunks := []func() (interface{}, error){
+		c.loader("test", fetch).load(uint64(1)),
+		c.loader("test", fetch).load(uint64(2)),
+		c.loader("test", fetch).load(uint64(1)),
+	}
+	for i, want := range []uint64{2, 4, 2} {
+		got, err := thunks[i]()
+		if err != nil || got != want {
+			t.Errorf("thunk %v returned %v, %v, want %v", i, got, err, want)
+		}
+	}
+	if len(calls) != 1 || len(calls[0]) != 2 {
+		t.Fatalf("expected a single fetch of 2 keys, got %v", calls)
+	}
+
+	// cached keys are not fetched again
+	got, _ := c.loader("test", fetch).load(uint64(2))()
+	if got != uint64(4) || len(calls) != 1 {
+		t.Errorf("expected cached result without fetch, got %v after %v fetches", got, len(calls))
+	}
+}
+
+func TestGraphqlQueryCost(t *testing.T) {
+	schema, err := getGraphqlSchema()
+	if err != nil {
+		t.Fatalf("error building schema: %v", err)
+	}
+
+	tests := []struct {
+		query     string
+		variables map[string]interface{}
+		cost      int
+		depth     int
+		err       bool
+	}{
+		{query: `{ epoch { epoch } }`, cost: 6, depth: 2},
+		{query: `{ epochs(limit: 10) { epoch slots { slot } } }`, cost: 5 + 10*(1+5+1), depth: 3},
+		{query: `{ epochs { epoch } }`, cost: 5 + 10, depth: 2},
+		{query: `query($l: Int) { epochs(limit: $l) { epoch } }`, variables: map[string]interface{}{"l": float64(100)}, cost: 5 + 100, depth: 2},
+		{query: `query($l: Int = 50) { epochs(limit: $l) { epoch } }`, cost: 5 + 50, depth: 2},
+		{query: `{ validators(indices: [1, 2, 3]) { ...v } } fragment v on Validator { validator_index }`, cost: 5 + 3, depth: 2},
+		{query: `{ __schema { types { name } } }`, cost: 0, depth: 1},
+		{query: `{ epochs(limit: 101) { epoch } }`, err: true},
+	}
+	for _, test := range tests {
+		doc, err := parser.Parse(parser.ParseParams{Source: test.query})
+		if err != nil {
+			t.Fatalf("error parsing %v: %v", test.query, err)
+		}
+		cost, depth, err := graphqlQueryCost(schema, doc, "", test.variables)
+		if test.err {
+			if err == nil {
+				t.Errorf("expected an error for %v", test.query)
+			}
+			continue
+		}
+		if err != nil || cost != test.cost || depth != test.depth {
+			t.Errorf("%v: got cost %v, depth %v, err %v, want cost %v, depth %v", test.query, cost, depth, err, test.cost, test.depth)
+		}
+	}
+}
+
+func TestApiGraphqlLimits(t *testing.T) {
+	nested := `{ validator(index_or_pubkey: "1") { deposits { validator { deposits { validator { deposits { validator { deposits { validator { deposits { validator { validator_index } } } } } } } } } } } }`
+	expensive := `{ epochs(limit: 100) { slots { proposer_validator { proposals(limit: 100) { block { slot } } } } } }`
+
+	for _, query := range []string{nested, expensive, `{ unknown }`} {
+		rec := httptest.NewRecorder()
+		ApiGraphql(rec, httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(query), nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for %v, got %v", query, rec.Code)
+		}
+		res := struct {
+			Errors []struct{ Message string }
+		}{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil || len(res.Errors) == 0 {
+			t.Errorf("expected graphql errors for %v, got %v", query, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	body := `{"query": "query($i: [Uint64!]!) { validators(indices: $i) { validator_index } }", "variables": {"i": [1, 2]}}`
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+	ApiGraphql(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %v: %v", rec.Code, rec.Body.String())
+	}
+	res := struct {
+		Data struct {
+			Validators []interface{}
+		}
+		Errors []interface{}
+	}{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil || len(res.Errors) != 0 || len(res.Data.Validators) != 2 {
+		t.Errorf("unexpected response %v", rec.Body.String())
+	}
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+const (
+	personalAccessTokenPrefix        = "bcpat_"
+	personalAccessTokenMaxPerUser    = 20
+	personalAccessTokenMaxNameLength = 100
+	personalAccessTokenMaxExpiryDays = 365
+)
+
+// personalAccessTokenRouteScopes maps the method and path template of the user api routes to the scope a personal access token needs to access them.
+// Routes which are not listed can not be accessed with a personal access token.
+var personalAccessTokenRouteScopes = map[string]string{
+	"GET /api/v1/user/validator/saved":                    types.PersonalAccessTokenScopeWatchlistRead,
+	"POST /api/v1/user/validator/{pubkey}/add":            types.PersonalAccessTokenScopeWatchlistWrite,
+	"POST /api/v1/user/validator/{pubkey}/remove":         types.PersonalAccessTokenScopeWatchlistWrite,
+	"POST /api/v1/user/dashboard/save":                    types.PersonalAccessTokenScopeWatchlistWrite,
+	"GET /api/v1/user/notifications":                      types.PersonalAccessTokenScopeNotificationsRead,
+	"POST /api/v1/user/notifications":                     types.PersonalAccessTokenScopeNotificationsRead,
+	"GET /api/v1/user/notifications/rules":                types.PersonalAccessTokenScopeNotificationsRead,
+	"GET /api/v1/user/notifications/locale":               types.PersonalAccessTokenScopeNotificationsRead,
+	"POST /api/v1/user/notifications/locale":              types.PersonalAccessTokenScopeNotificationsWrite,
+	"POST /api/v1/user/notifications/subscribe":           types.PersonalAccessTokenScopeNotificationsWrite,
+	"POST /api/v1/user/notifications/unsubscribe":         types.PersonalAccessTokenScopeNotificationsWrite,
+	"POST /api/v1/user/notifications/bundled/subscribe":   types.PersonalAccessTokenScopeNotificationsWrite,
+	"POST /api/v1/user/notifications/bundled/unsubscribe": types.PersonalAccessTokenScopeNotificationsWrite,
+	"POST /api/v1/user/notifications/simulate":            types.PersonalAccessTokenScopeNotificationsWrite,
+	"GET /api/v1/user/stats":                              types.PersonalAccessTokenScopeStatsRead,
+	"GET /api/v1/user/stats/{offset}/{limit}":             types.PersonalAccessTokenScopeStatsRead,
+}
+
+type personalAccessTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays uint64   `json:"expires_in_days"`
+}
+
+// ApiUserAuthMiddleware authorizes requests to the user api. Requests with a personal access token are
+// only allowed to access the routes covered by the scopes of the token, all other requests need an oauth access token.
+func ApiUserAuthMiddleware(next http.Handler) http.Handler {
+	oauthHandler := utils.AuthorizedAPIMiddleware(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accessToken := getPersonalAccessTokenFromHeader(r)
+		if accessToken == "" {
+			oauthHandler.ServeHTTP(w, r)
+			return
+		}
+
+		j := json.NewEncoder(w)
+		token, err := getPersonalAccessToken(accessToken)
+		if err != nil {
+			if err != sql.ErrNoRows {
+				logger.WithError(err).Errorf("error retrieving personal access token")
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			utils.SendOAuthErrorResponse(j, r.URL.String(), utils.UnauthorizedClient, "authorization failed")
+			return
+		}
+
+		scope := personalAccessTokenRouteScope(r)
+		if scope == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			utils.SendOAuthErrorResponse(j, r.URL.String(), utils.AccessDenied, "this route can not be accessed with a personal access token")
+			return
+		}
+		if !token.HasScope(scope) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			utils.SendOAuthErrorResponse(j, r.URL.String(), utils.InvalidScope, fmt.Sprintf("the access token does not have the required scope %v", scope))
+			return
+		}
+
+		claims := &utils.CustomClaims{UserID: token.UserID}
+		pkg, err := db.GetUserPremiumPackage(token.UserID)
+		if err != nil && err != sql.ErrNoRows {
+			logger.WithError(err).Errorf("error retrieving premium package of user %v", token.UserID)
+		}
+		claims.Package = pkg.Package
+
+		utils.SetAuthorizedAPIContext(r, claims)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// getPersonalAccessTokenFromHeader returns the personal access token of the authorization header, if there is one
+func getPersonalAccessTokenFromHeader(r *http.Request) string {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		header = strings.TrimSpace(header[7:])
+	}
+	if !strings.HasPrefix(header, personalAccessTokenPrefix) {
+		return ""
+	}
+	return header
+}
+
+// getPersonalAccessToken returns the unexpired personal access token and records its usage
+func getPersonalAccessToken(accessToken string) (*types.PersonalAccessToken, error) {
+	hash := sha256.Sum256([]byte(accessToken))
+	return db.UsePersonalAccessToken(hash[:])
+}
+
+// personalAccessTokenRouteScope returns the scope required to access the route of the request, it is empty if the route can not be accessed with a personal access token
+func personalAccessTokenRouteScope(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return personalAccessTokenRouteScopes[r.Method+" "+template]
+}
+
+// getClientStatsUser returns the user of the api key or personal access token used to submit client stats,
+// personal access tokens need the stats:write scope
+func getClientStatsUser(apiKey string) (*types.UserWithPremium, error) {
+	if !strings.HasPrefix(apiKey, personalAccessTokenPrefix) {
+		return db.GetUserIdByApiKey(apiKey)
+	}
+
+	token, err := getPersonalAccessToken(apiKey)
+	if err != nil {
+		return nil, err
+	}
+	if !token.HasScope(types.PersonalAccessTokenScopeStatsWrite) {
+		return nil, fmt.Errorf("personal access token %v does not have the %v scope", token.ID, types.PersonalAccessTokenScopeStatsWrite)
+	}
+	user := &types.UserWithPremium{ID: token.UserID}
+	pkg, err := db.GetUserPremiumPackage(token.UserID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if pkg.Package != "" {
+		user.Product = sql.NullString{String: pkg.Package, Valid: true}
+	}
+	return user, nil
+}
+
+// UserPersonalAccessTokens returns the personal access tokens of the user as json
+func UserPersonalAccessTokens(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+
+	tokens, err := db.GetPersonalAccessTokens(user.UserID)
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving personal access tokens of user %v", user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve db results", http.StatusInternalServerError)
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{tokens})
+}
+
+// UserPersonalAccessTokenCreate creates a personal access token for the user.
+// The token is only part of this response, afterwards only its prefix can be retrieved.
+func UserPersonalAccessTokenCreate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+
+	req := &personalAccessTokenRequest{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(req)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	token, err := newPersonalAccessToken(req)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), err.Error(), http.StatusBadRequest)
+		return
+	}
+	token.UserID = user.UserID
+
+	count, err := db.CountPersonalAccessTokens(user.UserID)
+	if err != nil {
+		logger.WithError(err).Errorf("error counting personal access tokens of user %v", user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve db results", http.StatusInternalServerError)
+		return
+	}
+	if count >= personalAccessTokenMaxPerUser {
+		sendErrorWithCodeResponse(w, r.URL.String(), fmt.Sprintf("only %v access tokens can be created, please revoke an unused token first", personalAccessTokenMaxPerUser), http.StatusBadRequest)
+		return
+	}
+
+	err = db.CreatePersonalAccessToken(token)
+	if err != nil {
+		logger.WithError(err).Errorf("error creating personal access token of user %v", user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not update db", http.StatusInternalServerError)
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{token})
+}
+
+// newPersonalAccessToken validates the request and returns a new token for it
+func newPersonalAccessToken(req *personalAccessTokenRequest) (*types.PersonalAccessToken, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > personalAccessTokenMaxNameLength {
+		return nil, fmt.Errorf("the name of the token must be between 1 and %v characters long", personalAccessTokenMaxNameLength)
+	}
+
+	scopes := pq.StringArray{}
+	for _, scope := range req.Scopes {
+		valid := false
+		for _, s := range types.PersonalAccessTokenScopes {
+			if s == scope {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid scope %q", scope)
+		}
+		if !utils.SliceContains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("no scopes provided")
+	}
+	if req.ExpiresInDays > personalAccessTokenMaxExpiryDays {
+		return nil, fmt.Errorf("tokens can expire in at most %v days", personalAccessTokenMaxExpiryDays)
+	}
+
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return nil, err
+	}
+	accessToken := personalAccessTokenPrefix + hex.EncodeToString(secret)
+	hash := sha256.Sum256([]byte(accessToken))
+
+	token := &types.PersonalAccessToken{
+		Name:        name,
+		TokenHash:   hash[:],
+		TokenPrefix: accessToken[:len(personalAccessTokenPrefix)+6],
+		Scopes:      scopes,
+		CreatedTime: time.Now(),
+		Token:       accessToken,
+	}
+	if req.ExpiresInDays > 0 {
+		expires := token.CreatedTime.Add(time.Hour * 24 * time.Duration(req.ExpiresInDays))
+		token.ExpiresTime = &expires
+	}
+	return token, nil
+}
+
+// UserPersonalAccessTokenRevoke revokes a personal access token of the user
+func UserPersonalAccessTokenRevoke(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+
+	id, err := strconv.ParseUint(mux.Vars(r)["tokenID"], 10, 64)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "invalid token id", http.StatusBadRequest)
+		return
+	}
+
+	err = db.DeletePersonalAccessToken(user.UserID, id)
+	if err == sql.ErrNoRows {
+		sendErrorWithCodeResponse(w, r.URL.String(), "token not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("error revoking personal access token %v of user %v", id, user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not update db", http.StatusInternalServerError)
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), nil)
+}
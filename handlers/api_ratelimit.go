@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"database/sql"
+	"eth2-exporter/db"
+	"eth2-exporter/metrics"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// plans and monthly usage of api keys are reloaded from the db after this interval
+const apiRateLimitReloadInterval = time.Minute * 10
+
+// call counts are aggregated in memory and written to api_statistics after this interval
+const apiStatisticsFlushInterval = time.Second * 10
+
+// apiRateLimitEntry holds the token bucket of an api key, the bucket holds up to one second of requests and is refilled continuously
+type apiRateLimitEntry struct {
+	mux         sync.Mutex
+	tokens      float64
+	tokensTs    time.Time
+	plan        ApiPlan
+	monthlyUsed int64
+	loadedTs    time.Time
+	lastSeenTs  time.Time
+}
+
+type apiStatisticsKey struct {
+	ts     time.Time
+	apiKey string
+	call   string
+}
+
+var apiRateLimits = struct {
+	sync.Mutex
+	entries map[string]*apiRateLimitEntry
+}{entries: make(map[string]*apiRateLimitEntry)}
+
+var apiStatistics = struct {
+	sync.Mutex
+	counts map[apiStatisticsKey]int64
+}{counts: make(map[apiStatisticsKey]int64)}
+
+var apiStatisticsFlushOnce sync.Once
+
+// apiUserPathPrefix is the path of the oauth authenticated user api, its requests are made by logged in app clients and not with an api key
+const apiUserPathPrefix = "/api/v1/user/"
+
+// ApiRateLimitMiddleware resolves the api key of a request from the query string or the apikey header and
+// applies the per second and per month limits of the key's plan. Requests without a key are limited by ip with the free plan.
+// Calls made with a key are counted and written to api_statistics asynchronously.
+// Requests to the user api are not limited, the middleware of the v1 router also applies to its /user subrouter.
+func ApiRateLimitMiddleware(next http.Handler) http.Handler {
+	limited := apiRateLimitMiddleware(next, func(w http.ResponseWriter, r *http.Request, status int, message string) {
+		w.Header().Set("Content-Type", "application/json")
+		sendErrorWithCodeResponse(w, r.URL.String(), message, status)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, apiUserPathPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		limited.ServeHTTP(w, r)
+	})
+}
+
+// ApiV2RateLimitMiddleware applies the same limits as ApiRateLimitMiddleware but responds with the v2 error schema
+func ApiV2RateLimitMiddleware(next http.Handler) http.Handler {
+	return apiRateLimitMiddleware(next, func(w http.ResponseWriter, r *http.Request, status int, message string) {
+		code := types.ApiV2ErrorCodeInternal
+		switch status {
+		case http.StatusUnauthorized:
+			code = types.ApiV2ErrorCodeUnauthorized
+		case http.StatusTooManyRequests:
+			code = types.ApiV2ErrorCodeRateLimited
+		}
+		sendApiV2Error(w, r, status, code, message)
+	})
+}
+
+func apiRateLimitMiddleware(next http.Handler, sendError func(w http.ResponseWriter, r *http.Request, status int, message string)) http.Handler {
+	if !utils.Config.Frontend.ApiRateLimit.Enabled {
+		return next
+	}
+
+	apiStatisticsFlushOnce.Do(func() {
+		go flushApiStatistics()
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		apiKey := r.URL.Query().Get("apikey")
+		if apiKey == "" {
+			apiKey = r.Header.Get("apikey")
+		}
+
+		entry, err := getApiRateLimitEntry(apiKey, r)
+		if err == sql.ErrNoRows {
+			sendError(w, r, http.StatusUnauthorized, "invalid api key")
+			return
+		}
+		if err != nil {
+			logger.WithError(err).Errorf("error getting rate limit of api key")
+			sendError(w, r, http.StatusInternalServerError, "could not retrieve db results")
+			return
+		}
+
+		now := time.Now()
+		entry.mux.Lock()
+		entry.lastSeenTs = now
+		monthEnd := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+		monthlyRemaining := int64(entry.plan.RequestsPerMonth) - entry.monthlyUsed
+
+		w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=1, %d;w=%d", entry.plan.RequestsPerSecond, entry.plan.RequestsPerMonth, int(monthEnd.Sub(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())).Seconds())))
+		w.Header().Set("X-RateLimit-Limit-Month", strconv.Itoa(entry.plan.RequestsPerMonth))
+
+		if monthlyRemaining <= 0 {
+			entry.mux.Unlock()
+			metrics.ApiRateLimited.WithLabelValues(entry.plan.Package, "month").Inc()
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(entry.plan.RequestsPerMonth))
+			w.Header().Set("RateLimit-Remaining", "0")
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(int(monthEnd.Sub(now).Seconds())))
+			w.Header().Set("X-RateLimit-Remaining-Month", "0")
+			w.Header().Set("Retry-After", strconv.Itoa(int(monthEnd.Sub(now).Seconds())))
+			sendError(w, r, http.StatusTooManyRequests, "monthly api request limit reached")
+			return
+		}
+
+		allowed, remaining := entry.take(now)
+		if allowed {
+			entry.monthlyUsed++
+			monthlyRemaining--
+		}
+		entry.mux.Unlock()
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(entry.plan.RequestsPerSecond))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("RateLimit-Reset", "1")
+		w.Header().Set("X-RateLimit-Remaining-Month", strconv.FormatInt(monthlyRemaining, 10))
+
+		if !allowed {
+			metrics.ApiRateLimited.WithLabelValues(entry.plan.Package, "second").Inc()
+			w.Header().Set("Retry-After", "1")
+			sendError(w, r, http.StatusTooManyRequests, "api request limit per second reached")
+			return
+		}
+
+		if apiKey != "" {
+			countApiCall(now, apiKey, r)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ApiPlan holds the request limits of an api product
+type ApiPlan struct {
+	Package           string
+	RequestsPerSecond int
+	RequestsPerMonth  int
+}
+
+// GetApiPlanByPackage returns the limits of an api product, the app premium packages do not change the api limits and get the limits of the free plan
+func GetApiPlanByPackage(pkg string) ApiPlan {
+	switch pkg {
+	case "sapphire":
+		return ApiPlan{Package: pkg, RequestsPerSecond: 10, RequestsPerMonth: 500000}
+	case "emerald":
+		return ApiPlan{Package: pkg, RequestsPerSecond: 20, RequestsPerMonth: 1000000}
+	case "diamond":
+		return ApiPlan{Package: pkg, RequestsPerSecond: 30, RequestsPerMonth: 4000000}
+	}
+	return ApiPlan{Package: "standard", RequestsPerSecond: 5, RequestsPerMonth: 30000}
+}
+
+// getApiRateLimitEntry returns the cached limits of the api key or of the client's ip if no key is used
+func getApiRateLimitEntry(apiKey string, r *http.Request) (*apiRateLimitEntry, error) {
+	cacheKey := "key:" + apiKey
+	if apiKey == "" {
+		cacheKey = "ip:" + utils.GetClientIP(r)
+	}
+
+	apiRateLimits.Lock()
+	entry, exists := apiRateLimits.entries[cacheKey]
+	if !exists {
+		entry = &apiRateLimitEntry{}
+		apiRateLimits.entries[cacheKey] = entry
+	}
+	apiRateLimits.Unlock()
+
+	entry.mux.Lock()
+	defer entry.mux.Unlock()
+
+	now := time.Now()
+	if !entry.loadedTs.IsZero() && now.Sub(entry.loadedTs) < apiRateLimitReloadInterval && entry.loadedTs.Month() == now.Month() {
+		return entry, nil
+	}
+
+	plan := GetApiPlanByPackage("standard")
+	var monthlyUsed int64
+	if apiKey != "" {
+		_, priceID, err := db.GetApiKeyPlan(apiKey)
+		if err != nil {
+			apiRateLimits.Lock()
+			delete(apiRateLimits.entries, cacheKey)
+			apiRateLimits.Unlock()
+			return nil, err
+		}
+		plan = GetApiPlanByPackage(utils.GetApiPackage(priceID))
+
+		monthlyUsed, err = db.GetApiKeyMonthlyUsage(apiKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if entry.loadedTs.IsZero() {
+		entry.tokens = float64(plan.RequestsPerSecond)
+		entry.tokensTs = now
+	}
+	entry.plan = plan
+	if apiKey != "" {
+		entry.monthlyUsed = monthlyUsed
+	} else if entry.loadedTs.Month() != now.Month() {
+		entry.monthlyUsed = 0
+	}
+	entry.loadedTs = now
+	return entry, nil
+}
+
+// take removes a token from the bucket if one is available and returns the number of tokens left
+func (entry *apiRateLimitEntry) take(now time.Time) (bool, int) {
+	perSecond := float64(entry.plan.RequestsPerSecond)
+	entry.tokens += now.Sub(entry.tokensTs).Seconds() * perSecond
+	if entry.tokens > perSecond {
+		entry.tokens = perSecond
+	}
+	entry.tokensTs = now
+
+	if entry.tokens < 1 {
+		return false, 0
+	}
+	entry.tokens--
+	return true, int(entry.tokens)
+}
+
+func countApiCall(ts time.Time, apiKey string, r *http.Request) {
+	call := r.URL.Path
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			call = tpl
+		}
+	}
+	if len(call) > 64 {
+		call = call[:64]
+	}
+
+	key := apiStatisticsKey{ts: ts.UTC().Truncate(time.Hour), apiKey: apiKey, call: call}
+	apiStatistics.Lock()
+	apiStatistics.counts[key]++
+	apiStatistics.Unlock()
+}
+
+// flushApiStatistics periodically writes the aggregated call counts to api_statistics and drops idle rate limit entries
+func flushApiStatistics() {
+	for {
+		time.Sleep(apiStatisticsFlushInterval)
+
+		apiStatistics.Lock()
+		counts := apiStatistics.counts
+		apiStatistics.counts = make(map[apiStatisticsKey]int64)
+		apiStatistics.Unlock()
+
+		if len(counts) > 0 {
+			batch := make([]*types.ApiStatisticsCount, 0, len(counts))
+			for k, c := range counts {
+				batch = append(batch, &types.ApiStatisticsCount{Ts: k.ts, ApiKey: k.apiKey, Call: k.call, Count: c})
+			}
+			err := db.SaveApiStatistics(batch)
+			if err != nil {
+				logger.WithError(err).Errorf("error saving api statistics")
+				metrics.Errors.WithLabelValues("api_save_statistics").Inc()
+
+				// keep the counts for the next flush
+				apiStatistics.Lock()
+				for k, c := range counts {
+					apiStatistics.counts[k] += c
+				}
+				apiStatistics.Unlock()
+			}
+		}
+
+		apiRateLimits.Lock()
+		for k, entry := range apiRateLimits.entries {
+			entry.mux.Lock()
+			if time.Since(entry.lastSeenTs) > apiRateLimitReloadInterval {
+				delete(apiRateLimits.entries, k)
+			}
+			entry.mux.Unlock()
+		}
+		apiRateLimits.Unlock()
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"eth2-exporter/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestApiRateLimitMiddlewareSkipsUserApi(t *testing.T) {
+	utils.Config.Frontend.ApiRateLimit.Enabled = true
+	defer func() { utils.Config.Frontend.ApiRateLimit.Enabled = false }()
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	// same layout as the explorer, the limiter of the v1 router also runs for its /user subrouter
+	router := mux.NewRouter()
+	apiV1Router := router.PathPrefix("/api/v1").Subrouter()
+	apiV1Router.HandleFunc("/epoch/{epoch}", ok)
+	apiV1Router.Use(ApiRateLimitMiddleware)
+	apiV1AuthRouter := apiV1Router.PathPrefix("/user").Subrouter()
+	apiV1AuthRouter.HandleFunc("/notifications", ok)
+
+	request := func(path, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	free := GetApiPlanByPackage("standard").RequestsPerSecond
+	for i := 0; i < free*3; i++ {
+		if rec := request("/api/v1/user/notifications", "192.0.2.1:1234"); rec.Code != http.StatusOK {
+			t.Fatalf("user api request %v was limited: %v", i, rec.Code)
+		}
+	}
+
+	limited := false
+	for i := 0; i < free*3; i++ {
+		if request("/api/v1/epoch/1", "192.0.2.2:1234").Code == http.StatusTooManyRequests {
+			limited = true
+			break
+		}
+	}
+	if !limited {
+		t.Errorf("expected anonymous api requests to be limited after %v requests per second", free)
+	}
+}
+
+func TestApiPlanByPackageOnlyUsesApiProducts(t *testing.T) {
+	free := GetApiPlanByPackage("standard")
+	for _, pkg := range []string{"", "plankton", "goldfish", "whale"} {
+		if plan := GetApiPlanByPackage(pkg); plan != free {
+			t.Errorf("expected the app package %q to get the free api plan, got %+v", pkg, plan)
+		}
+	}
+	if plan := GetApiPlanByPackage("diamond"); plan.Package != "diamond" || plan.RequestsPerSecond <= free.RequestsPerSecond {
+		t.Errorf("expected the diamond api plan to raise the limits, got %+v", plan)
+	}
+}
+
+func TestApiRateLimitKeysAnonymousClientsBehindTrustedProxies(t *testing.T) {
+	utils.Config.Frontend.ApiRateLimit.TrustedProxies = []string{"10.0.0.0/8"}
+	defer func() { utils.Config.Frontend.ApiRateLimit.TrustedProxies = nil }()
+
+	request := func(remoteAddr, forwardedFor string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/epoch/1", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		return req
+	}
+
+	first, err := getApiRateLimitEntry("", request("10.0.0.1:1234", "198.51.100.1, 10.0.0.2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := getApiRateLimitEntry("", request("10.0.0.1:1234", "198.51.100.2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Errorf("expected clients behind the trusted proxy to get separate limits")
+	}
+
+	// the header of untrusted clients is ignored, otherwise they could pick a fresh bucket per request
+	spoofed, err := getApiRateLimitEntry("", request("192.0.2.3:1234", "198.51.100.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spoofed == first {
+		t.Errorf("expected the forwarded ip of an untrusted client to be ignored")
+	}
+	if ip := utils.GetClientIP(request("192.0.2.3:1234", "198.51.100.1")); ip != "192.0.2.3" {
+		t.Errorf("expected the remote address of an untrusted client, got %v", ip)
+	}
+	if ip := utils.GetClientIP(request("10.0.0.1:1234", "203.0.113.9, 198.51.100.1, 10.0.0.2")); ip != "198.51.100.1" {
+		t.Errorf("expected the first untrusted address from the right, got %v", ip)
+	}
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"eth2-exporter/db"
+	"eth2-exporter/services"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+const (
+	scheduledReportMaxReports         = 10
+	scheduledReportMaxNameLength      = 100
+	scheduledReportMaxRequestBodySize = 1 << 20
+)
+
+type scheduledReportRequest struct {
+	Name       string   `json:"name"`
+	Validators []uint64 `json:"validators"`
+	Tag        string   `json:"tag"`
+	Period     string   `json:"period"`
+	Currency   string   `json:"currency"`
+	Sections   []string `json:"sections"`
+	Format     string   `json:"format"`
+}
+
+// ApiScheduledReports godoc
+// @Summary Get the scheduled reports of the user
+// @Tags User
+// @Produce json
+// @Success 200 {object} types.ApiResponse{data=[]types.ScheduledReport}
+// @Security ApiKeyAuth
+// @Router /api/v1/user/reports [get]
+func ApiScheduledReports(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+	if !user.Authenticated {
+		sendErrorWithCodeResponse(w, r.URL.String(), "not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	reports, err := db.GetScheduledReports(user.UserID, utils.GetNetwork())
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving scheduled reports of user %v", user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve db results", http.StatusInternalServerError)
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{reports})
+}
+
+// ApiScheduledReportCreate godoc
+// @Summary Create a report that is generated for every week, month or year
+// @Tags User
+// @Description The report covers either up to 1000 validators or all validators of the user with the given tag. Weekly reports start on monday, monthly and yearly reports on the first day of the month or year (UTC).
+// @Description Once a period ended the report is sent by email and to the webhooks of the user subscribed to the user_scheduled_report event, pdf and csv reports are attached to the email. All reports are archived and can be downloaded again.
+// @Produce json
+// @Param body body scheduledReportRequest true "name, validators or tag, period (weekly, monthly or yearly), currency, sections (income, effectiveness, missed_duties, proposals) and format (pdf, csv or html)"
+// @Success 200 {object} types.ApiResponse{data=types.ScheduledReport}
+// @Security ApiKeyAuth
+// @Router /api/v1/user/reports [post]
+func ApiScheduledReportCreate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+	if !user.Authenticated {
+		sendErrorWithCodeResponse(w, r.URL.String(), "not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	req := &scheduledReportRequest{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, scheduledReportMaxRequestBodySize)).Decode(req)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	report, err := newScheduledReport(req, time.Now())
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), err.Error(), http.StatusBadRequest)
+		return
+	}
+	report.UserID = user.UserID
+
+	count, err := db.CountScheduledReports(user.UserID)
+	if err != nil {
+		logger.WithError(err).Errorf("error counting scheduled reports of user %v", user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve db results", http.StatusInternalServerError)
+		return
+	}
+	if count >= scheduledReportMaxReports {
+		sendErrorWithCodeResponse(w, r.URL.String(), fmt.Sprintf("only a maximum of %v reports can be scheduled", scheduledReportMaxReports), http.StatusBadRequest)
+		return
+	}
+
+	err = db.CreateScheduledReport(report)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			sendErrorWithCodeResponse(w, r.URL.String(), "a report with this name already exists", http.StatusConflict)
+			return
+		}
+		logger.WithError(err).Errorf("error creating scheduled report of user %v", user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not update db", http.StatusInternalServerError)
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{report})
+}
+
+// newScheduledReport validates the request and returns the report for it, its first run is at the end of the current period
+func newScheduledReport(req *scheduledReportRequest, now time.Time) (*types.ScheduledReport, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > scheduledReportMaxNameLength {
+		return nil, fmt.Errorf("invalid name, expected 1 to %v characters", scheduledReportMaxNameLength)
+	}
+
+	tag := strings.TrimSpace(req.Tag)
+	if (tag == "") == (len(req.Validators) == 0) {
+		return nil, fmt.Errorf("either validators or a tag must be provided")
+	}
+	validators := make(pq.Int64Array, 0, len(req.Validators))
+	seen := make(map[uint64]bool, len(req.Validators))
+	for _, v := range req.Validators {
+		if v > math.MaxInt32 {
+			return nil, fmt.Errorf("invalid validator index %v", v)
+		}
+		if !seen[v] {
+			seen[v] = true
+			validators = append(validators, int64(v))
+		}
+	}
+	if len(validators) > services.ScheduledReportMaxValidators {
+		return nil, fmt.Errorf("only a maximum of %v validators can be included in a report", services.ScheduledReportMaxValidators)
+	}
+
+	nextRun, err := services.ScheduledReportNextRun(req.Period, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid period, expected weekly, monthly or yearly")
+	}
+
+	currency := strings.ToLower(req.Currency)
+	if currency == "" {
+		currency = "usd"
+	}
+	if !services.IsReportCurrency(currency) {
+		return nil, fmt.Errorf("invalid currency %v", req.Currency)
+	}
+
+	if req.Format == "" {
+		req.Format = types.ScheduledReportFormatPDF
+	}
+	if req.Format != types.ScheduledReportFormatPDF && req.Format != types.ScheduledReportFormatCSV && req.Format != types.ScheduledReportFormatHTML {
+		return nil, fmt.Errorf("invalid format, expected pdf, csv or html")
+	}
+
+	sections := pq.StringArray{}
+	for _, section := range req.Sections {
+		if !utils.ElementExists(types.ScheduledReportSections, section) {
+			return nil, fmt.Errorf("invalid section %v, expected one of %v", section, strings.Join(types.ScheduledReportSections, ", "))
+		}
+		if !utils.ElementExists(sections, section) {
+			sections = append(sections, section)
+		}
+	}
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("at least one section must be included")
+	}
+
+	report := &types.ScheduledReport{
+		Network:     utils.GetNetwork(),
+		Name:        name,
+		Validators:  validators,
+		Period:      req.Period,
+		Currency:    currency,
+		Sections:    sections,
+		Format:      req.Format,
+		NextRunTime: nextRun,
+		CreatedTime: now,
+	}
+	if tag != "" {
+		report.Tag = &tag
+		report.Validators = nil
+	}
+	return report, nil
+}
+
+// ApiScheduledReportDelete godoc
+// @Summary Delete a scheduled report of the user
+// @Tags User
+// @Description The archived reports can still be downloaded with their links.
+// @Produce json
+// @Param id path int true "Id of the report"
+// @Success 200 {object} types.ApiResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/user/reports/{id}/delete [post]
+func ApiScheduledReportDelete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+	if !user.Authenticated {
+		sendErrorWithCodeResponse(w, r.URL.String(), "not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "invalid report id", http.StatusBadRequest)
+		return
+	}
+
+	err = db.DeleteScheduledReport(user.UserID, id)
+	if err == sql.ErrNoRows {
+		sendErrorWithCodeResponse(w, r.URL.String(), "report not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("error deleting scheduled report %v of user %v", id, user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not update db", http.StatusInternalServerError)
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), nil)
+}
+
+// ApiScheduledReportRuns godoc
+// @Summary Get the archived runs of a scheduled report of the user
+// @Tags User
+// @Description The download_url of a run can be used without authentication.
+// @Produce json
+// @Param id path int true "Id of the report"
+// @Success 200 {object} types.ApiResponse{data=[]types.ScheduledReportRun}
+// @Security ApiKeyAuth
+// @Router /api/v1/user/reports/{id}/runs [get]
+func ApiScheduledReportRuns(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+	if !user.Authenticated {
+		sendErrorWithCodeResponse(w, r.URL.String(), "not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "invalid report id", http.StatusBadRequest)
+		return
+	}
+
+	runs, err := db.GetScheduledReportRuns(user.UserID, id)
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving runs of scheduled report %v", id)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve db results", http.StatusInternalServerError)
+		return
+	}
+	for _, run := range runs {
+		if run.Status == types.ScheduledReportRunStatusDone {
+			run.DownloadURL = services.ScheduledReportDownloadURL(run)
+		}
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{runs})
+}
+
+// ApiScheduledReportRunDownload godoc
+// @Summary Download an archived scheduled report
+// @Tags User
+// @Description The link including the token is sent with the report and returned by /api/v1/user/reports/{id}/runs.
+// @Produce octet-stream
+// @Param id path int true "Id of the report run"
+// @Param token query string true "Download token of the report run"
+// @Router /api/v1/reports/runs/{id}/download [get]
+func ApiScheduledReportRunDownload(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "invalid report id", http.StatusBadRequest)
+		return
+	}
+
+	run, err := db.GetScheduledReportRun(id)
+	if err != nil && err != sql.ErrNoRows {
+		logger.WithError(err).Errorf("error retrieving scheduled report run %v", id)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve db results", http.StatusInternalServerError)
+		return
+	}
+	token, tokenErr := hex.DecodeString(r.URL.Query().Get("token"))
+	if err == sql.ErrNoRows || tokenErr != nil || len(run.DownloadToken) == 0 || subtle.ConstantTimeCompare(token, run.DownloadToken) != 1 {
+		sendErrorWithCodeResponse(w, r.URL.String(), "report not found", http.StatusNotFound)
+		return
+	}
+
+	f, err := services.OpenScheduledReportRun(r.Context(), run)
+	if err != nil {
+		logger.WithError(err).Errorf("error opening scheduled report run %v", run.ID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve report", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	contentType := "application/pdf"
+	switch run.Format {
+	case types.ScheduledReportFormatCSV:
+		contentType = "text/csv"
+	case types.ScheduledReportFormatHTML:
+		contentType = "text/html"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", services.ScheduledReportFileName(run)))
+	if run.FileSize != nil {
+		w.Header().Set("Content-Length", strconv.FormatInt(*run.FileSize, 10))
+	}
+	_, err = io.Copy(w, f)
+	if err != nil {
+		logger.WithError(err).Warnf("error sending scheduled report run %v", run.ID)
+	}
+}
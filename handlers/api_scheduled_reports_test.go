@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"eth2-exporter/types"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewScheduledReport(t *testing.T) {
+	now := time.Date(2023, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	report, err := newScheduledReport(&scheduledReportRequest{
+		Name:       " weekly ",
+		Validators: []uint64{2, 1, 2},
+		Period:     types.ScheduledReportPeriodWeekly,
+		Currency:   "EUR",
+		Sections:   []string{types.ScheduledReportSectionIncome, types.ScheduledReportSectionProposals, types.ScheduledReportSectionIncome},
+	}, now)
+	if err != nil {
+		t.Fatalf("error creating report: %v", err)
+	}
+	if report.Name != "weekly" || len(report.Validators) != 2 || report.Currency != "eur" || len(report.Sections) != 2 || report.Format != types.ScheduledReportFormatPDF {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if !report.NextRunTime.Equal(time.Date(2023, 3, 20, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected next run %v", report.NextRunTime)
+	}
+
+	report, err = newScheduledReport(&scheduledReportRequest{Name: "tag", Tag: "home", Period: types.ScheduledReportPeriodYearly, Sections: []string{types.ScheduledReportSectionMissedDuties}, Format: types.ScheduledReportFormatHTML}, now)
+	if err != nil {
+		t.Fatalf("error creating tag report: %v", err)
+	}
+	if report.Tag == nil || *report.Tag != "home" || report.Validators != nil || report.Currency != "usd" {
+		t.Errorf("unexpected tag report %+v", report)
+	}
+
+	invalid := []*scheduledReportRequest{
+		{Name: "", Validators: []uint64{1}, Period: "weekly", Sections: []string{"income"}},
+		{Name: "both", Validators: []uint64{1}, Tag: "home", Period: "weekly", Sections: []string{"income"}},
+		{Name: "none", Period: "weekly", Sections: []string{"income"}},
+		{Name: "period", Validators: []uint64{1}, Period: "daily", Sections: []string{"income"}},
+		{Name: "currency", Validators: []uint64{1}, Period: "weekly", Currency: "xyz", Sections: []string{"income"}},
+		{Name: "section", Validators: []uint64{1}, Period: "weekly", Sections: []string{"balances"}},
+		{Name: "sections", Validators: []uint64{1}, Period: "weekly"},
+		{Name: "format", Validators: []uint64{1}, Period: "weekly", Sections: []string{"income"}, Format: "xlsx"},
+	}
+	for _, req := range invalid {
+		if _, err := newScheduledReport(req, now); err == nil {
+			t.Errorf("expected an error for request %+v", req)
+		}
+	}
+}
+
+func TestApiScheduledReportCreateReadsBodyBehindAuthMiddleware(t *testing.T) {
+	handler := ApiUserAuthMiddleware(http.HandlerFunc(ApiScheduledReportCreate))
+
+	// the body is valid json but requests validators and a tag, the handler has to reject it after decoding it
+	req := httptest.NewRequest("POST", "/api/v1/user/reports", strings.NewReader(`{"name":"weekly","validators":[1],"tag":"home","period":"weekly","sections":["income"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+newTestAccessToken(t, 1))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	response := struct {
+		Status string `json:"status"`
+	}{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response %v: %v", rec.Body.String(), err)
+	}
+	if rec.Code != http.StatusBadRequest || !strings.Contains(response.Status, "either validators or a tag") {
+		t.Errorf("expected the handler to decode the body and reject it, got %v: %v", rec.Code, rec.Body.String())
+	}
+}
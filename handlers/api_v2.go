@@ -0,0 +1,756 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"eth2-exporter/db"
+	"eth2-exporter/services"
+	"eth2-exporter/types"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const apiV2DefaultLimit = 100
+
+// apiV2Param describes a path or query parameter of a v2 route in the OpenAPI spec
+type apiV2Param struct {
+	Name        string
+	In          string
+	Description string
+	Type        string
+	Required    bool
+}
+
+// apiV2Route describes a v2 endpoint. The router and the OpenAPI spec are both built from ApiV2Routes so they can not diverge.
+type apiV2Route struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Description string
+	Tag         string
+	Params      []apiV2Param
+	// Response is a value of the type returned in the data field, list endpoints return a paginated array of it
+	Response  interface{}
+	Paginated bool
+	// Errors lists the status codes apart from 500 the endpoint can fail with
+	Errors  []int
+	Handler http.HandlerFunc
+}
+
+var apiV2EpochParam = apiV2Param{Name: "epoch", In: "path", Description: "Epoch number or one of the strings latest and finalized", Type: "string", Required: true}
+var apiV2IndexOrPubkeyParam = apiV2Param{Name: "indexOrPubkey", In: "path", Description: "Validator index or 0x prefixed public key", Type: "string", Required: true}
+var apiV2AddressParam = apiV2Param{Name: "address", In: "path", Description: "0x prefixed execution layer address", Type: "string", Required: true}
+
+// the cache and bigtable lookups of the v2 history endpoints, tests replace them as neither is available there
+var apiV2LatestEpoch = services.LatestEpoch
+var apiV2BalanceHistory = func(index uint64, startEpoch uint64, limit int64) ([]*types.ValidatorBalance, error) {
+	history, err := db.BigtableClient.GetValidatorBalanceHistory([]uint64{index}, startEpoch, limit)
+	return history[index], err
+}
+var apiV2ValidatorActivationEpoch = func(index uint64) (uint64, error) {
+	var activationEpoch uint64
+	err := db.ReaderDb.Get(&activationEpoch, "SELECT activationepoch FROM validators WHERE validatorindex = $1", index)
+	return activationEpoch, err
+}
+var apiV2AttestationHistory = func(index uint64, startEpoch uint64, limit int64) ([]*types.ValidatorAttestation, error) {
+	history, err := db.BigtableClient.GetValidatorAttestationHistory([]uint64{index}, startEpoch, limit)
+	return history[index], err
+}
+
+// ApiV2Routes lists all endpoints served under /api/v2
+var ApiV2Routes = []apiV2Route{
+	{
+		Method:      "GET",
+		Path:        "/epochs",
+		OperationID: "listEpochs",
+		Summary:     "List epochs",
+		Description: "Returns epochs ordered from the most recent to the oldest",
+		Tag:         "Epoch",
+		Response:    types.ApiV2Epoch{},
+		Paginated:   true,
+		Errors:      []int{http.StatusBadRequest},
+		Handler:     ApiV2Epochs,
+	},
+	{
+		Method:      "GET",
+		Path:        "/epochs/{epoch}",
+		OperationID: "getEpoch",
+		Summary:     "Get epoch",
+		Description: "Returns information for a specified epoch by the epoch number or an epoch tag (can be latest or finalized)",
+		Tag:         "Epoch",
+		Params:      []apiV2Param{apiV2EpochParam},
+		Response:    types.ApiV2Epoch{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+		Handler:     ApiV2Epoch,
+	},
+	{
+		Method:      "GET",
+		Path:        "/epochs/{epoch}/blocks",
+		OperationID: "listEpochBlocks",
+		Summary:     "List epoch blocks",
+		Description: "Returns the blocks of a specified epoch ordered by slot",
+		Tag:         "Epoch",
+		Params:      []apiV2Param{apiV2EpochParam},
+		Response:    types.ApiV2Block{},
+		Paginated:   true,
+		Errors:      []int{http.StatusBadRequest},
+		Handler:     ApiV2EpochBlocks,
+	},
+	{
+		Method:      "GET",
+		Path:        "/validators/{indexOrPubkey}",
+		OperationID: "getValidator",
+		Summary:     "Get validator",
+		Description: "Returns a validator by its index or public key",
+		Tag:         "Validator",
+		Params:      []apiV2Param{apiV2IndexOrPubkeyParam},
+		Response:    types.ApiV2Validator{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+		Handler:     ApiV2Validator,
+	},
+	{
+		Method:      "GET",
+		Path:        "/validators/{indexOrPubkey}/proposals",
+		OperationID: "listValidatorProposals",
+		Summary:     "List validator proposals",
+		Description: "Returns the blocks proposed or missed by a validator ordered from the most recent to the oldest",
+		Tag:         "Validator",
+		Params:      []apiV2Param{apiV2IndexOrPubkeyParam},
+		Response:    types.ApiV2Block{},
+		Paginated:   true,
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+		Handler:     ApiV2ValidatorProposals,
+	},
+	{
+		Method:      "GET",
+		Path:        "/validators/{indexOrPubkey}/balances",
+		OperationID: "listValidatorBalances",
+		Summary:     "List validator balances",
+		Description: "Returns the balance of a validator at the start of every epoch ordered from the most recent epoch to the oldest",
+		Tag:         "Validator",
+		Params:      []apiV2Param{apiV2IndexOrPubkeyParam},
+		Response:    types.ApiV2ValidatorBalance{},
+		Paginated:   true,
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+		Handler:     ApiV2ValidatorBalances,
+	},
+	{
+		Method:      "GET",
+		Path:        "/validators/{indexOrPubkey}/attestations",
+		OperationID: "listValidatorAttestations",
+		Summary:     "List validator attestations",
+		Description: "Returns the attestations of a validator ordered from the most recent epoch to the oldest",
+		Tag:         "Validator",
+		Params:      []apiV2Param{apiV2IndexOrPubkeyParam},
+		Response:    types.ApiV2Attestation{},
+		Paginated:   true,
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+		Handler:     ApiV2ValidatorAttestations,
+	},
+	{
+		Method:      "GET",
+		Path:        "/validators/{indexOrPubkey}/deposits",
+		OperationID: "listValidatorDeposits",
+		Summary:     "List validator deposits",
+		Description: "Returns the execution layer deposits of a validator ordered from the most recent to the oldest",
+		Tag:         "Validator",
+		Params:      []apiV2Param{apiV2IndexOrPubkeyParam},
+		Response:    types.ApiV2Deposit{},
+		Paginated:   true,
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+		Handler:     ApiV2ValidatorDeposits,
+	},
+	{
+		Method:      "GET",
+		Path:        "/addresses/{address}/validators",
+		OperationID: "listAddressValidators",
+		Summary:     "List validators deposited by an address",
+		Description: "Returns the validators an execution layer address sent deposits for ordered by public key. Validators that are not yet part of the validator set have no index.",
+		Tag:         "Validator",
+		Params:      []apiV2Param{apiV2AddressParam},
+		Response:    types.ApiV2AddressValidator{},
+		Paginated:   true,
+		Errors:      []int{http.StatusBadRequest},
+		Handler:     ApiV2AddressValidators,
+	},
+	{
+		Method:      "GET",
+		Path:        "/graffitiwall",
+		OperationID: "listGraffitiwall",
+		Summary:     "List graffitiwall pixels",
+		Description: "Returns the pixels of the graffitiwall ordered from the most recently painted to the oldest",
+		Tag:         "Graffitiwall",
+		Response:    types.ApiV2GraffitiwallPixel{},
+		Paginated:   true,
+		Errors:      []int{http.StatusBadRequest},
+		Handler:     ApiV2Graffitiwall,
+	},
+}
+
+// RegisterApiV2Routes adds all v2 endpoints and the OpenAPI spec to the router
+func RegisterApiV2Routes(router *mux.Router) {
+	router.HandleFunc("/openapi.json", ApiV2OpenApiSpec).Methods("GET", "OPTIONS")
+	for _, route := range ApiV2Routes {
+		router.HandleFunc(route.Path, route.Handler).Methods(route.Method, "OPTIONS")
+	}
+}
+
+// ApiV2Epochs returns a page of epochs ordered descending
+func ApiV2Epochs(w http.ResponseWriter, r *http.Request) {
+	limit, err := getApiV2Limit(r)
+	if err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidParameter, err.Error())
+		return
+	}
+
+	cursor := struct {
+		Epoch int64 `json:"epoch"`
+	}{Epoch: -1}
+	if err := decodeApiV2Cursor(r, &cursor); err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidCursor, err.Error())
+		return
+	}
+
+	epochs := []*types.ApiV2Epoch{}
+	err = db.ReaderDb.Select(&epochs, `
+		SELECT `+apiV2EpochColumns+`
+		FROM epochs
+		WHERE $1 < 0 OR epoch < $1
+		ORDER BY epoch DESC
+		LIMIT $2`, cursor.Epoch, limit+1)
+	if err != nil {
+		logger.WithError(err).Error("error retrieving epochs for api v2")
+		sendApiV2Error(w, r, http.StatusInternalServerError, types.ApiV2ErrorCodeInternal, "could not retrieve db results")
+		return
+	}
+
+	next := ""
+	if len(epochs) > limit {
+		epochs = epochs[:limit]
+		cursor.Epoch = int64(epochs[limit-1].Epoch)
+		next = encodeApiV2Cursor(cursor)
+	}
+	sendApiV2Page(w, r, epochs, limit, next)
+}
+
+// ApiV2Epoch returns a single epoch
+func ApiV2Epoch(w http.ResponseWriter, r *http.Request) {
+	epoch, err := getApiV2Epoch(mux.Vars(r)["epoch"])
+	if err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidParameter, err.Error())
+		return
+	}
+
+	data := &types.ApiV2Epoch{}
+	err = db.ReaderDb.Get(data, `SELECT `+apiV2EpochColumns+` FROM epochs WHERE epoch = $1`, epoch)
+	if err == sql.ErrNoRows {
+		sendApiV2Error(w, r, http.StatusNotFound, types.ApiV2ErrorCodeNotFound, "epoch not found")
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving epoch %v for api v2", epoch)
+		sendApiV2Error(w, r, http.StatusInternalServerError, types.ApiV2ErrorCodeInternal, "could not retrieve db results")
+		return
+	}
+	sendApiV2Data(w, r, data)
+}
+
+// ApiV2EpochBlocks returns a page of the blocks of an epoch ordered by slot
+func ApiV2EpochBlocks(w http.ResponseWriter, r *http.Request) {
+	epoch, err := getApiV2Epoch(mux.Vars(r)["epoch"])
+	if err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidParameter, err.Error())
+		return
+	}
+	limit, err := getApiV2Limit(r)
+	if err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidParameter, err.Error())
+		return
+	}
+
+	// blocks are unique by slot and root only, orphaned blocks can share the slot of a canonical one
+	cursor := struct {
+		Slot      int64  `json:"slot"`
+		BlockRoot string `json:"root"`
+	}{Slot: -1}
+	if err := decodeApiV2Cursor(r, &cursor); err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidCursor, err.Error())
+		return
+	}
+	blockRoot, err := hex.DecodeString(cursor.BlockRoot)
+	if err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidCursor, "invalid cursor")
+		return
+	}
+
+	blocks := []*types.ApiV2Block{}
+	err = db.ReaderDb.Select(&blocks, `
+		SELECT `+apiV2BlockColumns+`
+		FROM blocks
+		WHERE epoch = $1 AND ($2 < 0 OR (slot, blockroot) > ($2, $3))
+		ORDER BY slot, blockroot
+		LIMIT $4`, epoch, cursor.Slot, blockRoot, limit+1)
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving blocks of epoch %v for api v2", epoch)
+		sendApiV2Error(w, r, http.StatusInternalServerError, types.ApiV2ErrorCodeInternal, "could not retrieve db results")
+		return
+	}
+
+	next := ""
+	if len(blocks) > limit {
+		blocks = blocks[:limit]
+		cursor.Slot = int64(blocks[limit-1].Slot)
+		cursor.BlockRoot = hex.EncodeToString(blocks[limit-1].BlockRoot)
+		next = encodeApiV2Cursor(cursor)
+	}
+	sendApiV2Page(w, r, blocks, limit, next)
+}
+
+// ApiV2Validator returns a single validator by index or public key
+func ApiV2Validator(w http.ResponseWriter, r *http.Request) {
+	index, _, ok := getApiV2ValidatorIndex(w, r, mux.Vars(r)["indexOrPubkey"])
+	if !ok {
+		return
+	}
+
+	data := &types.ApiV2Validator{}
+	err := db.ReaderDb.Get(data, `
+		SELECT validatorindex, pubkey, withdrawalcredentials, balance, effectivebalance, slashed, activationeligibilityepoch,
+			activationepoch, exitepoch, withdrawableepoch, lastattestationslot, status
+		FROM validators
+		WHERE validatorindex = $1`, index)
+	if err == sql.ErrNoRows {
+		sendApiV2Error(w, r, http.StatusNotFound, types.ApiV2ErrorCodeNotFound, "validator not found")
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving validator %v for api v2", index)
+		sendApiV2Error(w, r, http.StatusInternalServerError, types.ApiV2ErrorCodeInternal, "could not retrieve db results")
+		return
+	}
+	sendApiV2Data(w, r, data)
+}
+
+// ApiV2ValidatorProposals returns a page of the blocks assigned to a validator ordered descending by slot
+func ApiV2ValidatorProposals(w http.ResponseWriter, r *http.Request) {
+	limit, err := getApiV2Limit(r)
+	if err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidParameter, err.Error())
+		return
+	}
+
+	cursor := struct {
+		Slot      int64  `json:"slot"`
+		BlockRoot string `json:"root"`
+	}{Slot: -1}
+	if err := decodeApiV2Cursor(r, &cursor); err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidCursor, err.Error())
+		return
+	}
+	blockRoot, err := hex.DecodeString(cursor.BlockRoot)
+	if err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidCursor, "invalid cursor")
+		return
+	}
+
+	index, _, ok := getApiV2ValidatorIndex(w, r, mux.Vars(r)["indexOrPubkey"])
+	if !ok {
+		return
+	}
+
+	blocks := []*types.ApiV2Block{}
+	err = db.ReaderDb.Select(&blocks, `
+		SELECT `+apiV2BlockColumns+`
+		FROM blocks
+		WHERE proposer = $1 AND ($2 < 0 OR (slot, blockroot) < ($2, $3))
+		ORDER BY slot DESC, blockroot DESC
+		LIMIT $4`, index, cursor.Slot, blockRoot, limit+1)
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving proposals of validator %v for api v2", index)
+		sendApiV2Error(w, r, http.StatusInternalServerError, types.ApiV2ErrorCodeInternal, "could not retrieve db results")
+		return
+	}
+
+	next := ""
+	if len(blocks) > limit {
+		blocks = blocks[:limit]
+		cursor.Slot = int64(blocks[limit-1].Slot)
+		cursor.BlockRoot = hex.EncodeToString(blocks[limit-1].BlockRoot)
+		next = encodeApiV2Cursor(cursor)
+	}
+	sendApiV2Page(w, r, blocks, limit, next)
+}
+
+// ApiV2ValidatorBalances returns a page of the balance history of a validator ordered descending by epoch
+func ApiV2ValidatorBalances(w http.ResponseWriter, r *http.Request) {
+	limit, startEpoch, index, activationEpoch, ok := getApiV2EpochPage(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := apiV2BalanceHistory(index, startEpoch, int64(limit))
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving balance history of validator %v for api v2", index)
+		sendApiV2Error(w, r, http.StatusInternalServerError, types.ApiV2ErrorCodeInternal, "could not retrieve db results")
+		return
+	}
+
+	balances := make([]*types.ApiV2ValidatorBalance, 0, len(history))
+	for _, b := range history {
+		balances = append(balances, &types.ApiV2ValidatorBalance{Epoch: b.Epoch, Balance: b.Balance, EffectiveBalance: b.EffectiveBalance})
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Epoch > balances[j].Epoch })
+
+	sendApiV2Page(w, r, balances, limit, nextApiV2EpochCursor(startEpoch, limit, activationEpoch))
+}
+
+// ApiV2ValidatorAttestations returns a page of the attestations of a validator ordered descending by epoch
+func ApiV2ValidatorAttestations(w http.ResponseWriter, r *http.Request) {
+	limit, startEpoch, index, activationEpoch, ok := getApiV2EpochPage(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := apiV2AttestationHistory(index, startEpoch, int64(limit))
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving attestation history of validator %v for api v2", index)
+		sendApiV2Error(w, r, http.StatusInternalServerError, types.ApiV2ErrorCodeInternal, "could not retrieve db results")
+		return
+	}
+
+	attestations := make([]*types.ApiV2Attestation, 0, len(history))
+	for _, a := range history {
+		attestations = append(attestations, &types.ApiV2Attestation{
+			Epoch:          a.Epoch,
+			AttesterSlot:   a.AttesterSlot,
+			CommitteeIndex: a.CommitteeIndex,
+			Status:         a.Status,
+			InclusionSlot:  a.InclusionSlot,
+			Delay:          a.Delay,
+		})
+	}
+	sort.Slice(attestations, func(i, j int) bool { return attestations[i].Epoch > attestations[j].Epoch })
+
+	sendApiV2Page(w, r, attestations, limit, nextApiV2EpochCursor(startEpoch, limit, activationEpoch))
+}
+
+// getApiV2EpochPage reads the parameters of the per epoch history endpoints, a page covers the limit epochs up to and including the
+// epoch of the cursor. If a parameter is invalid an error response is sent and ok is false.
+func getApiV2EpochPage(w http.ResponseWriter, r *http.Request) (limit int, startEpoch uint64, index uint64, activationEpoch uint64, ok bool) {
+	limit, err := getApiV2Limit(r)
+	if err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidParameter, err.Error())
+		return 0, 0, 0, 0, false
+	}
+
+	cursor := struct {
+		Epoch int64 `json:"epoch"`
+	}{Epoch: -1}
+	if err := decodeApiV2Cursor(r, &cursor); err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidCursor, err.Error())
+		return 0, 0, 0, 0, false
+	}
+	startEpoch = apiV2LatestEpoch()
+	if cursor.Epoch >= 0 && uint64(cursor.Epoch) < startEpoch {
+		startEpoch = uint64(cursor.Epoch)
+	}
+	if uint64(limit) > startEpoch+1 {
+		limit = int(startEpoch + 1)
+	}
+
+	index, activationEpoch, ok = getApiV2ValidatorIndex(w, r, mux.Vars(r)["indexOrPubkey"])
+	return limit, startEpoch, index, activationEpoch, ok
+}
+
+// nextApiV2EpochCursor returns the cursor of the page following the limit epochs up to startEpoch. The history is sparse, so the
+// last page is the one that reaches the activation epoch of the validator and not the first one with fewer entries than the limit.
+func nextApiV2EpochCursor(startEpoch uint64, limit int, activationEpoch uint64) string {
+	if startEpoch < uint64(limit) || startEpoch-uint64(limit) < activationEpoch {
+		return ""
+	}
+	return encodeApiV2Cursor(struct {
+		Epoch int64 `json:"epoch"`
+	}{Epoch: int64(startEpoch) - int64(limit)})
+}
+
+// ApiV2ValidatorDeposits returns a page of the deposits of a validator ordered descending by block
+func ApiV2ValidatorDeposits(w http.ResponseWriter, r *http.Request) {
+	limit, err := getApiV2Limit(r)
+	if err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidParameter, err.Error())
+		return
+	}
+
+	cursor := struct {
+		BlockNumber int64 `json:"block"`
+		TxIndex     int64 `json:"tx"`
+	}{BlockNumber: -1}
+	if err := decodeApiV2Cursor(r, &cursor); err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidCursor, err.Error())
+		return
+	}
+
+	index, _, ok := getApiV2ValidatorIndex(w, r, mux.Vars(r)["indexOrPubkey"])
+	if !ok {
+		return
+	}
+
+	deposits := []*struct {
+		TxIndex int64 `db:"tx_index"`
+		types.ApiV2Deposit
+	}{}
+	err = db.ReaderDb.Select(&deposits, `
+		SELECT d.tx_index, `+apiV2DepositColumns+`
+		FROM eth1_deposits d
+		INNER JOIN validators v ON v.pubkey = d.publickey
+		WHERE v.validatorindex = $1 AND ($2 < 0 OR (d.block_number, d.tx_index) < ($2, $3))
+		ORDER BY d.block_number DESC, d.tx_index DESC
+		LIMIT $4`, index, cursor.BlockNumber, cursor.TxIndex, limit+1)
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving deposits of validator %v for api v2", index)
+		sendApiV2Error(w, r, http.StatusInternalServerError, types.ApiV2ErrorCodeInternal, "could not retrieve db results")
+		return
+	}
+
+	next := ""
+	if len(deposits) > limit {
+		deposits = deposits[:limit]
+		cursor.BlockNumber, cursor.TxIndex = int64(deposits[limit-1].BlockNumber), deposits[limit-1].TxIndex
+		next = encodeApiV2Cursor(cursor)
+	}
+	data := make([]*types.ApiV2Deposit, 0, len(deposits))
+	for _, d := range deposits {
+		data = append(data, &d.ApiV2Deposit)
+	}
+	sendApiV2Page(w, r, data, limit, next)
+}
+
+// ApiV2AddressValidators returns a page of the validators an execution layer address deposited for ordered by public key
+func ApiV2AddressValidators(w http.ResponseWriter, r *http.Request) {
+	address, err := hex.DecodeString(strings.TrimPrefix(mux.Vars(r)["address"], "0x"))
+	if err != nil || len(address) != 20 {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidParameter, "invalid address provided")
+		return
+	}
+	limit, err := getApiV2Limit(r)
+	if err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidParameter, err.Error())
+		return
+	}
+
+	cursor := struct {
+		Pubkey string `json:"pubkey"`
+	}{}
+	if err := decodeApiV2Cursor(r, &cursor); err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidCursor, err.Error())
+		return
+	}
+	pubkey, err := hex.DecodeString(cursor.Pubkey)
+	if err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidCursor, "invalid cursor")
+		return
+	}
+
+	validators := []*types.ApiV2AddressValidator{}
+	err = db.ReaderDb.Select(&validators, `
+		SELECT d.publickey, v.validatorindex, BOOL_OR(d.valid_signature) AS valid_signature
+		FROM eth1_deposits d
+		LEFT JOIN validators v ON v.pubkey = d.publickey
+		WHERE d.from_address = $1 AND d.publickey > $2
+		GROUP BY d.publickey, v.validatorindex
+		ORDER BY d.publickey
+		LIMIT $3`, address, pubkey, limit+1)
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving validators of address %x for api v2", address)
+		sendApiV2Error(w, r, http.StatusInternalServerError, types.ApiV2ErrorCodeInternal, "could not retrieve db results")
+		return
+	}
+
+	next := ""
+	if len(validators) > limit {
+		validators = validators[:limit]
+		cursor.Pubkey = hex.EncodeToString(validators[limit-1].Pubkey)
+		next = encodeApiV2Cursor(cursor)
+	}
+	sendApiV2Page(w, r, validators, limit, next)
+}
+
+// ApiV2Graffitiwall returns a page of graffitiwall pixels ordered descending by the slot they were painted in
+func ApiV2Graffitiwall(w http.ResponseWriter, r *http.Request) {
+	limit, err := getApiV2Limit(r)
+	if err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidParameter, err.Error())
+		return
+	}
+
+	cursor := struct {
+		Slot int64 `json:"slot"`
+		X    int64 `json:"x"`
+		Y    int64 `json:"y"`
+	}{Slot: -1}
+	if err := decodeApiV2Cursor(r, &cursor); err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidCursor, err.Error())
+		return
+	}
+
+	pixels := []*types.ApiV2GraffitiwallPixel{}
+	err = db.ReaderDb.Select(&pixels, `
+		SELECT x, y, color, slot, validator
+		FROM graffitiwall
+		WHERE $1 < 0 OR (slot, x, y) < ($1, $2, $3)
+		ORDER BY slot DESC, x DESC, y DESC
+		LIMIT $4`, cursor.Slot, cursor.X, cursor.Y, limit+1)
+	if err != nil {
+		logger.WithError(err).Error("error retrieving graffitiwall for api v2")
+		sendApiV2Error(w, r, http.StatusInternalServerError, types.ApiV2ErrorCodeInternal, "could not retrieve db results")
+		return
+	}
+
+	next := ""
+	if len(pixels) > limit {
+		pixels = pixels[:limit]
+		last := pixels[limit-1]
+		cursor.Slot, cursor.X, cursor.Y = int64(last.Slot), int64(last.X), int64(last.Y)
+		next = encodeApiV2Cursor(cursor)
+	}
+	sendApiV2Page(w, r, pixels, limit, next)
+}
+
+const apiV2EpochColumns = `epoch, blockscount, proposerslashingscount, attesterslashingscount, attestationscount, depositscount,
+	voluntaryexitscount, validatorscount, averagevalidatorbalance, totalvalidatorbalance, COALESCE(finalized, false) AS finalized,
+	COALESCE(eligibleether, 0) AS eligibleether, COALESCE(globalparticipationrate, 0) AS globalparticipationrate, COALESCE(votedether, 0) AS votedether`
+
+const apiV2DepositColumns = `d.tx_hash, d.block_number, EXTRACT(epoch FROM d.block_ts)::bigint AS block_ts, d.from_address, d.publickey,
+	d.withdrawal_credentials, d.amount, d.valid_signature`
+
+const apiV2BlockColumns = `epoch, slot, blockroot, parentroot, stateroot, proposer, status, graffiti, COALESCE(graffiti_text, '') AS graffiti_text,
+	attestationscount, depositscount, voluntaryexitscount, proposerslashingscount, attesterslashingscount, syncaggregate_participation,
+	exec_block_number, exec_block_hash, exec_fee_recipient, exec_transactions_count`
+
+func getApiV2Epoch(param string) (uint64, error) {
+	switch param {
+	case "latest":
+		return services.LatestEpoch(), nil
+	case "finalized":
+		return services.LatestFinalizedEpoch(), nil
+	}
+	epoch, err := strconv.ParseUint(param, 10, 64)
+	if err != nil || epoch > uint64(1<<31-1) {
+		return 0, fmt.Errorf("invalid epoch provided")
+	}
+	return epoch, nil
+}
+
+// getApiV2ValidatorIndex resolves a validator index or public key of a known validator together with its activation epoch. If the
+// validator can not be resolved an error response is sent and ok is false.
+func getApiV2ValidatorIndex(w http.ResponseWriter, r *http.Request, param string) (index uint64, activationEpoch uint64, ok bool) {
+	if !strings.HasPrefix(param, "0x") {
+		var err error
+		index, err = strconv.ParseUint(param, 10, 64)
+		if err != nil || index > uint64(1<<31-1) {
+			sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidParameter, "invalid validator index provided")
+			return 0, 0, false
+		}
+	} else {
+		pubkey, err := hex.DecodeString(param[2:])
+		if err != nil || len(pubkey) != 48 {
+			sendApiV2Error(w, r, http.StatusBadRequest, types.ApiV2ErrorCodeInvalidParameter, "invalid validator public key provided")
+			return 0, 0, false
+		}
+		err = db.ReaderDb.Get(&index, "SELECT validatorindex FROM validators WHERE pubkey = $1", pubkey)
+		if err == sql.ErrNoRows {
+			sendApiV2Error(w, r, http.StatusNotFound, types.ApiV2ErrorCodeNotFound, "validator not found")
+			return 0, 0, false
+		}
+		if err != nil {
+			logger.WithError(err).Errorf("error retrieving index of validator %v for api v2", param)
+			sendApiV2Error(w, r, http.StatusInternalServerError, types.ApiV2ErrorCodeInternal, "could not retrieve db results")
+			return 0, 0, false
+		}
+	}
+
+	activationEpoch, err := apiV2ValidatorActivationEpoch(index)
+	if err == sql.ErrNoRows {
+		sendApiV2Error(w, r, http.StatusNotFound, types.ApiV2ErrorCodeNotFound, "validator not found")
+		return 0, 0, false
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving activation epoch of validator %v for api v2", index)
+		sendApiV2Error(w, r, http.StatusInternalServerError, types.ApiV2ErrorCodeInternal, "could not retrieve db results")
+		return 0, 0, false
+	}
+	return index, activationEpoch, true
+}
+
+func getApiV2Limit(r *http.Request) (int, error) {
+	q := r.URL.Query()
+	if !q.Has("limit") {
+		return apiV2DefaultLimit, nil
+	}
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit < 1 || limit > apiV2DefaultLimit {
+		return 0, fmt.Errorf("invalid limit parameter, it has to be between 1 and %v", apiV2DefaultLimit)
+	}
+	return limit, nil
+}
+
+// decodeApiV2Cursor reads the cursor query parameter into the endpoint specific cursor, the cursor is left untouched if the parameter is missing
+func decodeApiV2Cursor(r *http.Request, cursor interface{}) error {
+	param := r.URL.Query().Get("cursor")
+	if param == "" {
+		return nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(param)
+	if err != nil {
+		return fmt.Errorf("invalid cursor")
+	}
+	d := json.NewDecoder(strings.NewReader(string(b)))
+	d.DisallowUnknownFields()
+	if err := d.Decode(cursor); err != nil {
+		return fmt.Errorf("invalid cursor")
+	}
+	return nil
+}
+
+// encodeApiV2Cursor returns an opaque cursor, clients must not rely on its content
+func encodeApiV2Cursor(cursor interface{}) string {
+	b, err := json.Marshal(cursor)
+	if err != nil {
+		logger.WithError(err).Error("error encoding api v2 cursor")
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func sendApiV2Data(w http.ResponseWriter, r *http.Request, data interface{}) {
+	sendApiV2Response(w, r, &types.ApiV2Response{Data: data})
+}
+
+func sendApiV2Page(w http.ResponseWriter, r *http.Request, data interface{}, limit int, nextCursor string) {
+	sendApiV2Response(w, r, &types.ApiV2Response{Data: data, Pagination: &types.ApiV2Pagination{Limit: limit, NextCursor: nextCursor}})
+}
+
+func sendApiV2Response(w http.ResponseWriter, r *http.Request, response *types.ApiV2Response) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		logger.Errorf("error serializing json data for API %v route: %v", r.URL.String(), err)
+	}
+}
+
+func sendApiV2Error(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(&types.ApiV2ErrorResponse{Error: types.ApiV2Error{Status: status, Code: code, Message: message}})
+	if err != nil {
+		logger.Errorf("error serializing json error for API %v route: %v", r.URL.String(), err)
+	}
+}
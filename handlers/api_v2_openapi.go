@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"encoding/json"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var apiV2Spec []byte
+var apiV2SpecOnce sync.Once
+
+// ApiV2OpenApiSpec serves the OpenAPI 3 spec of the v2 api, the spec is generated from ApiV2Routes and the response types
+func ApiV2OpenApiSpec(w http.ResponseWriter, r *http.Request) {
+	apiV2SpecOnce.Do(func() {
+		var err error
+		apiV2Spec, err = json.Marshal(BuildApiV2OpenApiSpec())
+		if err != nil {
+			logger.WithError(err).Error("error serializing api v2 spec")
+		}
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	if apiV2Spec == nil {
+		sendApiV2Error(w, r, http.StatusInternalServerError, types.ApiV2ErrorCodeInternal, "could not generate spec")
+		return
+	}
+	_, err := w.Write(apiV2Spec)
+	if err != nil {
+		logger.Errorf("error writing api v2 spec: %v", err)
+	}
+}
+
+// BuildApiV2OpenApiSpec generates the OpenAPI 3 document describing ApiV2Routes
+func BuildApiV2OpenApiSpec() map[string]interface{} {
+	schemas := map[string]interface{}{}
+	paths := map[string]interface{}{}
+
+	errorSchema := apiV2Schema(reflect.TypeOf(types.ApiV2ErrorResponse{}), schemas)
+	paginationSchema := apiV2Schema(reflect.TypeOf(types.ApiV2Pagination{}), schemas)
+
+	for _, route := range ApiV2Routes {
+		parameters := []interface{}{}
+		for _, p := range route.Params {
+			parameters = append(parameters, apiV2ParamSpec(p))
+		}
+		if route.Paginated {
+			parameters = append(parameters,
+				apiV2ParamSpec(apiV2Param{Name: "cursor", In: "query", Type: "string", Description: "Cursor returned as next_cursor by the previous page"}),
+				apiV2ParamSpec(apiV2Param{Name: "limit", In: "query", Type: "integer", Description: fmt.Sprintf("Maximum number of items to return, up to %v", apiV2DefaultLimit)}),
+			)
+		}
+
+		data := apiV2Schema(reflect.TypeOf(route.Response), schemas)
+		body := map[string]interface{}{
+			"type":     "object",
+			"required": []string{"data"},
+			"properties": map[string]interface{}{
+				"data": data,
+			},
+		}
+		if route.Paginated {
+			body["required"] = []string{"data", "pagination"}
+			body["properties"] = map[string]interface{}{
+				"data":       map[string]interface{}{"type": "array", "items": data},
+				"pagination": paginationSchema,
+			}
+		}
+
+		responses := map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "OK",
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": body}},
+			},
+		}
+		for _, status := range append(route.Errors, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusInternalServerError) {
+			responses[fmt.Sprint(status)] = map[string]interface{}{
+				"description": http.StatusText(status),
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": errorSchema}},
+			}
+		}
+
+		operation := map[string]interface{}{
+			"operationId": route.OperationID,
+			"summary":     route.Summary,
+			"description": route.Description,
+			"tags":        []string{route.Tag},
+			"parameters":  parameters,
+			"responses":   responses,
+		}
+
+		pathItem, ok := paths[route.Path].(map[string]interface{})
+		if !ok {
+			pathItem = map[string]interface{}{}
+			paths[route.Path] = pathItem
+		}
+		pathItem[strings.ToLower(route.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "beaconcha.in Explorer API",
+			"version":     "2.0",
+			"description": "Cursor paginated api of the beaconcha.in explorer",
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"url": fmt.Sprintf("https://%v/api/v2", utils.Config.Frontend.SiteDomain)},
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"ApiKeyQuery":  map[string]interface{}{"type": "apiKey", "in": "query", "name": "apikey"},
+				"ApiKeyHeader": map[string]interface{}{"type": "apiKey", "in": "header", "name": "apikey"},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{},
+			map[string]interface{}{"ApiKeyQuery": []string{}},
+			map[string]interface{}{"ApiKeyHeader": []string{}},
+		},
+	}
+}
+
+func apiV2ParamSpec(p apiV2Param) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        p.Name,
+		"in":          p.In,
+		"description": p.Description,
+		"required":    p.Required,
+		"schema":      map[string]interface{}{"type": p.Type},
+	}
+}
+
+var hexBytesType = reflect.TypeOf(hexutil.Bytes{})
+var timeType = reflect.TypeOf(time.Time{})
+
+// apiV2Schema returns the json schema of t as it is serialized by encoding/json, named structs of the types package are added to schemas and referenced
+func apiV2Schema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	switch t {
+	case hexBytesType:
+		return map[string]interface{}{"type": "string", "pattern": "^0x([0-9a-f]{2})*$"}
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := apiV2Schema(t.Elem(), schemas)
+		if ref, ok := s["$ref"]; ok {
+			return map[string]interface{}{"allOf": []interface{}{map[string]interface{}{"$ref": ref}}, "nullable": true}
+		}
+		s["nullable"] = true
+		return s
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": apiV2Schema(t.Elem(), schemas), "nullable": t.Kind() == reflect.Slice}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": apiV2Schema(t.Elem(), schemas)}
+	case reflect.Struct:
+		name := strings.TrimPrefix(t.Name(), "ApiV2")
+		if t.Name() != "" && t.PkgPath() == reflect.TypeOf(types.ApiV2Response{}).PkgPath() {
+			if _, exists := schemas[name]; !exists {
+				// reserve the name before recursing so self referencing types terminate
+				schemas[name] = map[string]interface{}{}
+				schemas[name] = apiV2StructSchema(t, schemas)
+			}
+			return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+		}
+		return apiV2StructSchema(t, schemas)
+	}
+	return map[string]interface{}{}
+}
+
+func apiV2StructSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		omitEmpty := false
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				omitEmpty = omitEmpty || opt == "omitempty"
+			}
+		}
+		properties[name] = apiV2Schema(f.Type, schemas)
+		if !omitEmpty {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+
+	s := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
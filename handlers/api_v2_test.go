@@ -0,0 +1,474 @@
+package handlers
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+// emptyDriver is a database driver whose queries never return any rows, it lets the handlers run without a database
+type emptyDriver struct{}
+type emptyConn struct{}
+type emptyStmt struct{}
+type emptyRows struct{}
+
+func (emptyDriver) Open(name string) (driver.Conn, error)         { return emptyConn{}, nil }
+func (emptyConn) Prepare(query string) (driver.Stmt, error)       { return emptyStmt{}, nil }
+func (emptyConn) Close() error                                    { return nil }
+func (emptyConn) Begin() (driver.Tx, error)                       { return nil, fmt.Errorf("transactions are not supported") }
+func (emptyStmt) Close() error                                    { return nil }
+func (emptyStmt) NumInput() int                                   { return -1 }
+func (emptyStmt) Exec(args []driver.Value) (driver.Result, error) { return driver.RowsAffected(0), nil }
+func (emptyStmt) Query(args []driver.Value) (driver.Rows, error)  { return emptyRows{}, nil }
+func (emptyRows) Columns() []string                               { return []string{} }
+func (emptyRows) Close() error                                    { return nil }
+func (emptyRows) Next(dest []driver.Value) error                  { return io.EOF }
+
+func TestMain(m *testing.M) {
+	utils.Config = &types.Config{}
+	utils.Config.Frontend.SiteDomain = "beaconcha.in"
+
+	sql.Register("apiv2empty", emptyDriver{})
+	conn, err := sql.Open("apiv2empty", "")
+	if err != nil {
+		panic(err)
+	}
+	db.ReaderDb = sqlx.NewDb(conn, "postgres")
+
+	// without a cache and bigtable the history endpoints see an empty chain at epoch 1000
+	apiV2LatestEpoch = func() uint64 { return 1000 }
+	apiV2ValidatorActivationEpoch = func(index uint64) (uint64, error) { return 0, nil }
+	apiV2BalanceHistory = func(index uint64, startEpoch uint64, limit int64) ([]*types.ValidatorBalance, error) { return nil, nil }
+	apiV2AttestationHistory = func(index uint64, startEpoch uint64, limit int64) ([]*types.ValidatorAttestation, error) {
+		return nil, nil
+	}
+	os.Exit(m.Run())
+}
+
+func apiV2TestSpec(t *testing.T) map[string]interface{} {
+	// the spec is checked the way clients see it, after a round trip through json
+	rec := httptest.NewRecorder()
+	ApiV2OpenApiSpec(rec, httptest.NewRequest("GET", "/api/v2/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("spec endpoint returned status %v", rec.Code)
+	}
+	spec := map[string]interface{}{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("spec is not valid json: %v", err)
+	}
+	return spec
+}
+
+func apiV2TestRouter() *mux.Router {
+	router := mux.NewRouter()
+	RegisterApiV2Routes(router.PathPrefix("/api/v2").Subrouter())
+	return router
+}
+
+// TestApiV2RouterMatchesSpec makes sure every route of the router is documented with all of its path parameters and vice versa
+func TestApiV2RouterMatchesSpec(t *testing.T) {
+	spec := apiV2TestSpec(t)
+	paths := spec["paths"].(map[string]interface{})
+
+	routed := map[string]bool{}
+	err := apiV2TestRouter().Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		path := strings.TrimPrefix(tpl, "/api/v2")
+		if path == "/openapi.json" {
+			return nil
+		}
+		for _, method := range methods {
+			if method == "OPTIONS" {
+				continue
+			}
+			routed[method+" "+path] = true
+
+			operation, ok := paths[path].(map[string]interface{})[strings.ToLower(method)].(map[string]interface{})
+			if !ok {
+				t.Errorf("%v %v is routed but missing in the spec", method, path)
+				continue
+			}
+
+			declared := map[string]bool{}
+			for _, p := range operation["parameters"].([]interface{}) {
+				param := p.(map[string]interface{})
+				if param["in"] == "path" {
+					declared[param["name"].(string)] = true
+					if param["required"] != true {
+						t.Errorf("%v %v path parameter %v is not required", method, path, param["name"])
+					}
+				}
+			}
+			for _, m := range regexp.MustCompile(`{(\w+)}`).FindAllStringSubmatch(path, -1) {
+				if !declared[m[1]] {
+					t.Errorf("%v %v path parameter %v is not documented", method, path, m[1])
+				}
+				delete(declared, m[1])
+			}
+			for name := range declared {
+				t.Errorf("%v %v documents the path parameter %v which is not part of the route", method, path, name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, item := range paths {
+		for method := range item.(map[string]interface{}) {
+			if !routed[strings.ToUpper(method)+" "+path] {
+				t.Errorf("%v %v is in the spec but not routed", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+// TestApiV2HandlersMatchSpec calls every v2 handler with valid and invalid input and validates status codes and bodies against the spec
+func TestApiV2HandlersMatchSpec(t *testing.T) {
+	spec := apiV2TestSpec(t)
+	paths := spec["paths"].(map[string]interface{})
+	router := apiV2TestRouter()
+
+	validValues := map[string][]string{
+		"epoch":         {"1"},
+		"indexOrPubkey": {"1", "0x" + strings.Repeat("ab", 48)},
+		"address":       {"0x" + strings.Repeat("cd", 20)},
+	}
+
+	for _, route := range ApiV2Routes {
+		operation := paths[route.Path].(map[string]interface{})[strings.ToLower(route.Method)].(map[string]interface{})
+
+		type testCase struct {
+			url            string
+			expectedStatus int
+		}
+		valid := []string{route.Path}
+		for _, p := range route.Params {
+			if p.In != "path" {
+				continue
+			}
+			expanded := []string{}
+			for _, u := range valid {
+				for _, v := range validValues[p.Name] {
+					expanded = append(expanded, strings.Replace(u, "{"+p.Name+"}", v, 1))
+				}
+			}
+			valid = expanded
+		}
+
+		cases := []testCase{}
+		for _, u := range valid {
+			cases = append(cases, testCase{url: u})
+			if route.Paginated {
+				cases = append(cases,
+					testCase{url: u + "?limit=10"},
+					testCase{url: u + "?limit=0", expectedStatus: http.StatusBadRequest},
+					testCase{url: u + "?limit=101", expectedStatus: http.StatusBadRequest},
+					testCase{url: u + "?cursor=not-a-cursor", expectedStatus: http.StatusBadRequest},
+					testCase{url: u + "?cursor=" + encodeApiV2Cursor(map[string]int{"unknown": 1}), expectedStatus: http.StatusBadRequest},
+				)
+			}
+		}
+		for _, p := range route.Params {
+			if p.In != "path" {
+				continue
+			}
+			u := valid[0]
+			for _, v := range validValues[p.Name] {
+				u = strings.Replace(u, v, "invalid", 1)
+			}
+			cases = append(cases, testCase{url: u, expectedStatus: http.StatusBadRequest})
+		}
+
+		for _, c := range cases {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(route.Method, "/api/v2"+c.url, nil))
+
+			if c.expectedStatus != 0 && rec.Code != c.expectedStatus {
+				t.Errorf("%v %v returned status %v, expected %v", route.Method, c.url, rec.Code, c.expectedStatus)
+			}
+			if c.expectedStatus == 0 && rec.Code != http.StatusOK && rec.Code != http.StatusNotFound {
+				t.Errorf("%v %v returned status %v: %v", route.Method, c.url, rec.Code, rec.Body.String())
+			}
+
+			response, ok := operation["responses"].(map[string]interface{})[fmt.Sprint(rec.Code)].(map[string]interface{})
+			if !ok {
+				t.Errorf("%v %v returned status %v which is not documented", route.Method, c.url, rec.Code)
+				continue
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("%v %v returned content type %q", route.Method, c.url, ct)
+			}
+			schema := response["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+
+			var body interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Errorf("%v %v returned invalid json: %v", route.Method, c.url, err)
+				continue
+			}
+			for _, err := range validateApiV2Schema(spec, schema, body, "body") {
+				t.Errorf("%v %v: %v", route.Method, c.url, err)
+			}
+			if rec.Code != http.StatusOK && int(body.(map[string]interface{})["error"].(map[string]interface{})["status"].(float64)) != rec.Code {
+				t.Errorf("%v %v returned an error status that does not match the response status", route.Method, c.url)
+			}
+		}
+	}
+}
+
+// TestApiV2ResponseTypesMatchSpec validates populated values of every response type, the handler tests only see empty results
+func TestApiV2ResponseTypesMatchSpec(t *testing.T) {
+	spec := apiV2TestSpec(t)
+	paths := spec["paths"].(map[string]interface{})
+
+	slot := uint64(64)
+	samples := map[string]interface{}{
+		"/epochs":                                  types.ApiV2Epoch{Epoch: 2, Finalized: true, GlobalParticipationRate: 0.99},
+		"/epochs/{epoch}":                          types.ApiV2Epoch{Epoch: 2, Finalized: true, GlobalParticipationRate: 0.99},
+		"/epochs/{epoch}/blocks":                   types.ApiV2Block{Slot: 64, BlockRoot: []byte{0xab}, ExecBlockNumber: &slot, Status: "1"},
+		"/validators/{indexOrPubkey}":              types.ApiV2Validator{ValidatorIndex: 1, Pubkey: []byte{0xab}, LastAttestationSlot: &slot},
+		"/validators/{indexOrPubkey}/proposals":    types.ApiV2Block{Slot: 64, Status: "2"},
+		"/validators/{indexOrPubkey}/balances":     types.ApiV2ValidatorBalance{Epoch: 2, Balance: 32000000000, EffectiveBalance: 32000000000},
+		"/validators/{indexOrPubkey}/attestations": types.ApiV2Attestation{Epoch: 2, AttesterSlot: 64, CommitteeIndex: 3, Status: 1, InclusionSlot: 65, Delay: 1},
+		"/validators/{indexOrPubkey}/deposits":     types.ApiV2Deposit{TxHash: []byte{0xab}, BlockNumber: 1, Amount: 32000000000, ValidSignature: true},
+		"/addresses/{address}/validators":          types.ApiV2AddressValidator{Pubkey: []byte{0xab}, ValidatorIndex: &slot, ValidSignature: true},
+		"/graffitiwall":                            types.ApiV2GraffitiwallPixel{X: 1, Y: 2, Color: "ffffff", Slot: 64},
+	}
+
+	for _, route := range ApiV2Routes {
+		sample, ok := samples[route.Path]
+		if !ok {
+			t.Errorf("no sample response for %v", route.Path)
+			continue
+		}
+
+		var response interface{} = &types.ApiV2Response{Data: sample}
+		if route.Paginated {
+			response = &types.ApiV2Response{Data: []interface{}{sample}, Pagination: &types.ApiV2Pagination{Limit: 1, NextCursor: "abc"}}
+		}
+		b, err := json.Marshal(response)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body interface{}
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Fatal(err)
+		}
+
+		operation := paths[route.Path].(map[string]interface{})[strings.ToLower(route.Method)].(map[string]interface{})
+		schema := operation["responses"].(map[string]interface{})["200"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+		for _, err := range validateApiV2Schema(spec, schema, body, "body") {
+			t.Errorf("%v: %v", route.Path, err)
+		}
+	}
+}
+
+// TestApiV2EpochHistoryPagination walks the balance history of a validator page by page, every epoch since the activation of the
+// validator has to be returned exactly once even if pages are sparse
+func TestApiV2EpochHistoryPagination(t *testing.T) {
+	defer func(latest func() uint64, history func(uint64, uint64, int64) ([]*types.ValidatorBalance, error), activation func(uint64) (uint64, error)) {
+		apiV2LatestEpoch, apiV2BalanceHistory, apiV2ValidatorActivationEpoch = latest, history, activation
+	}(apiV2LatestEpoch, apiV2BalanceHistory, apiV2ValidatorActivationEpoch)
+
+	tests := []struct {
+		name            string
+		activationEpoch uint64
+		missing         map[uint64]bool
+		pages           int
+	}{
+		{name: "validator active since genesis", pages: 3},
+		{name: "validator activated after genesis", activationEpoch: 12, pages: 2},
+		{name: "sparse pages do not end the history", missing: map[uint64]bool{20: true, 21: true, 8: true}, pages: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiV2LatestEpoch = func() uint64 { return 24 }
+			apiV2ValidatorActivationEpoch = func(index uint64) (uint64, error) { return tt.activationEpoch, nil }
+			apiV2BalanceHistory = func(index uint64, startEpoch uint64, limit int64) ([]*types.ValidatorBalance, error) {
+				// bigtable returns the epochs of a page in no particular order
+				balances := []*types.ValidatorBalance{}
+				for epoch := startEpoch - uint64(limit) + 1; epoch <= startEpoch; epoch++ {
+					if epoch < tt.activationEpoch || tt.missing[epoch] {
+						continue
+					}
+					balances = append(balances, &types.ValidatorBalance{Epoch: epoch, Balance: 32e9 + epoch})
+				}
+				return balances, nil
+			}
+
+			router := apiV2TestRouter()
+			returned := map[uint64]bool{}
+			url := "/api/v2/validators/1/balances?limit=10"
+			pages := 0
+			for ; url != ""; pages++ {
+				if pages > 3 {
+					t.Fatalf("pagination did not terminate")
+				}
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("%v returned status %v: %v", url, rec.Code, rec.Body.String())
+				}
+				response := struct {
+					Data       []types.ApiV2ValidatorBalance `json:"data"`
+					Pagination types.ApiV2Pagination         `json:"pagination"`
+				}{}
+				if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+					t.Fatal(err)
+				}
+				for i, b := range response.Data {
+					if returned[b.Epoch] {
+						t.Fatalf("%v returned epoch %v twice", url, b.Epoch)
+					}
+					if i > 0 && b.Epoch >= response.Data[i-1].Epoch {
+						t.Fatalf("%v returned epochs out of order", url)
+					}
+					returned[b.Epoch] = true
+				}
+				url = ""
+				if response.Pagination.NextCursor != "" {
+					url = "/api/v2/validators/1/balances?limit=10&cursor=" + response.Pagination.NextCursor
+				}
+			}
+
+			if pages != tt.pages {
+				t.Errorf("expected %v pages, got %v", tt.pages, pages)
+			}
+			for epoch := tt.activationEpoch; epoch <= 24; epoch++ {
+				if !returned[epoch] && !tt.missing[epoch] {
+					t.Errorf("epoch %v was not returned", epoch)
+				}
+			}
+		})
+	}
+}
+
+func TestApiV2UnknownValidatorNotFound(t *testing.T) {
+	defer func(activation func(uint64) (uint64, error)) { apiV2ValidatorActivationEpoch = activation }(apiV2ValidatorActivationEpoch)
+	apiV2ValidatorActivationEpoch = func(index uint64) (uint64, error) { return 0, sql.ErrNoRows }
+
+	router := apiV2TestRouter()
+	for _, path := range []string{"", "/proposals", "/balances", "/attestations", "/deposits"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v2/validators/12345"+path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%v of an unknown validator returned status %v, expected %v", path, rec.Code, http.StatusNotFound)
+		}
+	}
+}
+
+// validateApiV2Schema checks value against the subset of OpenAPI 3 schemas the spec generator produces, undocumented properties are reported as well
+func validateApiV2Schema(spec map[string]interface{}, schema map[string]interface{}, value interface{}, path string) []error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		resolved, ok := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})[name].(map[string]interface{})
+		if !ok {
+			return []error{fmt.Errorf("%v: unresolvable reference %v", path, ref)}
+		}
+		return validateApiV2Schema(spec, resolved, value, path)
+	}
+	if value == nil {
+		if schema["nullable"] == true {
+			return nil
+		}
+		return []error{fmt.Errorf("%v: null is not allowed", path)}
+	}
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		errs := []error{}
+		for _, s := range allOf {
+			errs = append(errs, validateApiV2Schema(spec, s.(map[string]interface{}), value, path)...)
+		}
+		return errs
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return []error{fmt.Errorf("%v: expected an object, got %T", path, value)}
+		}
+		errs := []error{}
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				if _, exists := obj[r.(string)]; !exists {
+					errs = append(errs, fmt.Errorf("%v: required property %v is missing", path, r))
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p, ok := properties[k].(map[string]interface{}); ok {
+				errs = append(errs, validateApiV2Schema(spec, p, obj[k], path+"."+k)...)
+			} else if additional != nil {
+				errs = append(errs, validateApiV2Schema(spec, additional, obj[k], path+"."+k)...)
+			} else {
+				errs = append(errs, fmt.Errorf("%v: property %v is not documented", path, k))
+			}
+		}
+		return errs
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			return []error{fmt.Errorf("%v: expected an array, got %T", path, value)}
+		}
+		errs := []error{}
+		for i, v := range arr {
+			errs = append(errs, validateApiV2Schema(spec, schema["items"].(map[string]interface{}), v, fmt.Sprintf("%v[%v]", path, i))...)
+		}
+		return errs
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return []error{fmt.Errorf("%v: expected a string, got %T", path, value)}
+		}
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(s) {
+			return []error{fmt.Errorf("%v: %q does not match %v", path, s, pattern)}
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			return []error{fmt.Errorf("%v: expected a number, got %T", path, value)}
+		}
+		if schema["type"] == "integer" && n != float64(int64(n)) {
+			return []error{fmt.Errorf("%v: expected an integer, got %v", path, n)}
+		}
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			return []error{fmt.Errorf("%v: %v is below the minimum %v", path, n, min)}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []error{fmt.Errorf("%v: expected a boolean, got %T", path, value)}
+		}
+	}
+	return nil
+}
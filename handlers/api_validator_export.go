@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"eth2-exporter/db"
+	"eth2-exporter/services"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+const (
+	validatorExportMaxValidators      = 100000
+	validatorExportMaxDays            = 365
+	validatorExportMaxValidatorDays   = 10000000
+	validatorExportMaxUnfinishedJobs  = 2
+	validatorExportMaxRequestBodySize = 2 << 20
+)
+
+type validatorExportRequest struct {
+	Validators []uint64 `json:"validators"`
+	StartDate  string   `json:"start_date"`
+	EndDate    string   `json:"end_date"`
+	Format     string   `json:"format"`
+}
+
+// ApiValidatorExportCreate godoc
+// @Summary Create an export of the daily data of up to 100000 validators
+// @Tags User
+// @Description The export is created in the background, its status can be retrieved from /api/v1/user/validators/export/{id} and the user receives an email with the download link once it is finished.
+// @Description The export contains one row per validator and day with the status, balances, income, missed attestations and proposals of the validator, balances and amounts are in Gwei.
+// @Description The date range is limited to 365 days and the number of validators times the number of days to 10000000.
+// @Produce json
+// @Param body body validatorExportRequest true "validators, start_date and end_date (YYYY-MM-DD, UTC) and format (csv or parquet)"
+// @Success 200 {object} types.ApiResponse{data=types.ValidatorExportJob}
+// @Security ApiKeyAuth
+// @Router /api/v1/user/validators/export [post]
+func ApiValidatorExportCreate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+	if !user.Authenticated {
+		sendErrorWithCodeResponse(w, r.URL.String(), "not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	req := &validatorExportRequest{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, validatorExportMaxRequestBodySize)).Decode(req)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	job, err := newValidatorExportJob(req)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), err.Error(), http.StatusBadRequest)
+		return
+	}
+	job.UserID = user.UserID
+
+	unfinished, err := db.CountUnfinishedValidatorExportJobs(user.UserID)
+	if err != nil {
+		logger.WithError(err).Errorf("error counting validator export jobs of user %v", user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve db results", http.StatusInternalServerError)
+		return
+	}
+	if unfinished >= validatorExportMaxUnfinishedJobs {
+		sendErrorWithCodeResponse(w, r.URL.String(), fmt.Sprintf("only %v exports can be processed at the same time, please wait until your previous exports are finished", validatorExportMaxUnfinishedJobs), http.StatusTooManyRequests)
+		return
+	}
+
+	err = db.CreateValidatorExportJob(job)
+	if err != nil {
+		logger.WithError(err).Errorf("error creating validator export job of user %v", user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not update db", http.StatusInternalServerError)
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{job})
+}
+
+// newValidatorExportJob validates the request and returns the pending job for it
+func newValidatorExportJob(req *validatorExportRequest) (*types.ValidatorExportJob, error) {
+	if req.Format == "" {
+		req.Format = types.ValidatorExportFormatCSV
+	}
+	if req.Format != types.ValidatorExportFormatCSV && req.Format != types.ValidatorExportFormatParquet {
+		return nil, fmt.Errorf("invalid format, expected csv or parquet")
+	}
+
+	validators := make(pq.Int64Array, 0, len(req.Validators))
+	seen := make(map[uint64]bool, len(req.Validators))
+	for _, v := range req.Validators {
+		if v > math.MaxInt32 {
+			return nil, fmt.Errorf("invalid validator index %v", v)
+		}
+		if !seen[v] {
+			seen[v] = true
+			validators = append(validators, int64(v))
+		}
+	}
+	if len(validators) == 0 {
+		return nil, fmt.Errorf("no validators provided")
+	}
+	if len(validators) > validatorExportMaxValidators {
+		return nil, fmt.Errorf("only a maximum of %v validators can be exported", validatorExportMaxValidators)
+	}
+
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start_date, expected YYYY-MM-DD")
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end_date, expected YYYY-MM-DD")
+	}
+	genesis := time.Unix(int64(utils.Config.Chain.GenesisTimestamp), 0)
+	if end.Before(start) || end.Before(genesis) {
+		return nil, fmt.Errorf("invalid date range")
+	}
+	if start.Before(genesis) {
+		start = genesis
+	}
+	if today := time.Now().UTC(); end.After(today) {
+		end = today
+	}
+
+	startDay := utils.TimeToDay(uint64(start.Unix()))
+	endDay := utils.TimeToDay(uint64(end.Unix()))
+	days := endDay - startDay + 1
+	if days > validatorExportMaxDays {
+		return nil, fmt.Errorf("only a maximum of %v days can be exported", validatorExportMaxDays)
+	}
+	if uint64(len(validators))*days > validatorExportMaxValidatorDays {
+		return nil, fmt.Errorf("the number of validators times the number of days must not exceed %v", validatorExportMaxValidatorDays)
+	}
+
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("error generating download token: %w", err)
+	}
+
+	return &types.ValidatorExportJob{
+		Network:         utils.GetNetwork(),
+		Validators:      validators,
+		StartDay:        startDay,
+		EndDay:          endDay,
+		Format:          req.Format,
+		Status:          types.ValidatorExportStatusPending,
+		DownloadToken:   token,
+		CreatedTime:     time.Now(),
+		ValidatorsCount: len(validators),
+	}, nil
+}
+
+// ApiValidatorExportStatus godoc
+// @Summary Get the status of a validator export
+// @Tags User
+// @Description The download_url is set once the export is done, it can be used without authentication.
+// @Produce json
+// @Param id path int true "Id of the export"
+// @Success 200 {object} types.ApiResponse{data=types.ValidatorExportJob}
+// @Security ApiKeyAuth
+// @Router /api/v1/user/validators/export/{id} [get]
+func ApiValidatorExportStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+	if !user.Authenticated {
+		sendErrorWithCodeResponse(w, r.URL.String(), "not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	job, ok := getValidatorExportJob(w, r)
+	if !ok {
+		return
+	}
+	if job.UserID != user.UserID {
+		sendErrorWithCodeResponse(w, r.URL.String(), "export not found", http.StatusNotFound)
+		return
+	}
+	if job.Status == types.ValidatorExportStatusDone {
+		job.DownloadURL = services.ValidatorExportDownloadURL(job)
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{job})
+}
+
+// ApiValidatorExportDownload godoc
+// @Summary Download the file of a finished validator export
+// @Tags Validator
+// @Description The link including the token is sent by email and returned by /api/v1/user/validators/export/{id}.
+// @Produce octet-stream
+// @Param id path int true "Id of the export"
+// @Param token query string true "Download token of the export"
+// @Router /api/v1/validators/export/{id}/download [get]
+func ApiValidatorExportDownload(w http.ResponseWriter, r *http.Request) {
+	job, ok := getValidatorExportJob(w, r)
+	if !ok {
+		return
+	}
+
+	token, err := hex.DecodeString(r.URL.Query().Get("token"))
+	if err != nil || subtle.ConstantTimeCompare(token, job.DownloadToken) != 1 {
+		sendErrorWithCodeResponse(w, r.URL.String(), "export not found", http.StatusNotFound)
+		return
+	}
+	if job.Status != types.ValidatorExportStatusDone {
+		sendErrorWithCodeResponse(w, r.URL.String(), fmt.Sprintf("export is %v", job.Status), http.StatusConflict)
+		return
+	}
+
+	f, err := services.OpenValidatorExport(r.Context(), job)
+	if err != nil {
+		logger.WithError(err).Errorf("error opening validator export %v", job.ID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve export", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	contentType := "text/csv"
+	if job.Format == types.ValidatorExportFormatParquet {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", services.ValidatorExportFileName(job)))
+	if job.FileSize != nil {
+		w.Header().Set("Content-Length", strconv.FormatInt(*job.FileSize, 10))
+	}
+	_, err = io.Copy(w, f)
+	if err != nil {
+		logger.WithError(err).Warnf("error sending validator export %v", job.ID)
+	}
+}
+
+// getValidatorExportJob returns the export job of the id in the route, exports of other networks are not found
+func getValidatorExportJob(w http.ResponseWriter, r *http.Request) (*types.ValidatorExportJob, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "invalid export id", http.StatusBadRequest)
+		return nil, false
+	}
+
+	job, err := db.GetValidatorExportJob(id)
+	if err == sql.ErrNoRows || (err == nil && job.Network != utils.GetNetwork()) {
+		sendErrorWithCodeResponse(w, r.URL.String(), "export not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving validator export %v", id)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve db results", http.StatusInternalServerError)
+		return nil, false
+	}
+	return job, true
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"eth2-exporter/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestAccessToken returns an oauth access token of the user api signed with a test secret
+func newTestAccessToken(t *testing.T, userID uint64) string {
+	utils.Config.Frontend.JwtSigningSecret = strings.Repeat("ab", 32)
+	utils.Config.Frontend.JwtValidityInMinutes = 10
+	token, _, err := utils.CreateAccessToken(userID, 1, 1, "standard", "")
+	if err != nil {
+		t.Fatalf("error creating access token: %v", err)
+	}
+	return token
+}
+
+func TestApiValidatorExportCreateReadsBodyBehindAuthMiddleware(t *testing.T) {
+	handler := ApiUserAuthMiddleware(http.HandlerFunc(ApiValidatorExportCreate))
+
+	// the body is valid json but requests no validators, the handler has to reject it after decoding it
+	req := httptest.NewRequest("POST", "/api/v1/user/validators/export", strings.NewReader(`{"validators":[],"start_date":"2022-01-01","end_date":"2022-01-02"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+newTestAccessToken(t, 1))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	response := struct {
+		Status string `json:"status"`
+	}{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response %v: %v", rec.Body.String(), err)
+	}
+	if rec.Code != http.StatusBadRequest || !strings.Contains(response.Status, "no validators provided") {
+		t.Errorf("expected the handler to decode the body and reject the empty validator list, got %v: %v", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"eth2-exporter/services"
+	"eth2-exporter/types"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ApiValidatorSnapshot godoc
+// @Summary Get the whole validator set at a finalized epoch
+// @Tags Validator
+// @Description Returns the status, balance, effective balance, activation and exit epochs and withdrawal credentials of all validators in the state of the first slot of the epoch.
+// @Description If the explorer is connected to an archive node the data is taken from its state (source "node"), otherwise it is reconstructed from the stored balances and the current validator data (source "reconstructed").
+// @Description Reconstructed snapshots contain the withdrawal credentials of the epoch as far as their changes were recorded and are available for the finalized epochs of the last 30 days, snapshots of an archive node for all finalized epochs.
+// @Description Building a snapshot takes a while, until it is built 202 is returned and the request should be retried after the time of the Retry-After header. The response is large and should be requested with gzip encoding.
+// @Produce json
+// @Param epoch query int true "Finalized epoch"
+// @Success 200 {object} types.ApiResponse{data=types.ApiValidatorSnapshot}
+// @Success 202 {object} types.ApiResponse
+// @Failure 400 {object} types.ApiResponse
+// @Failure 429 {object} types.ApiResponse
+// @Router /api/v1/validators/snapshot [get]
+func ApiValidatorSnapshot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	epoch, err := strconv.ParseUint(r.URL.Query().Get("epoch"), 10, 64)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "invalid epoch provided", http.StatusBadRequest)
+		return
+	}
+	finalized := services.LatestFinalizedEpoch()
+	if epoch > finalized {
+		sendErrorWithCodeResponse(w, r.URL.String(), fmt.Sprintf("epoch must not be after the latest finalized epoch %v", finalized), http.StatusBadRequest)
+		return
+	}
+	if oldest := services.ValidatorSnapshotOldestEpoch(finalized); epoch < oldest {
+		sendErrorWithCodeResponse(w, r.URL.String(), fmt.Sprintf("epoch must not be before epoch %v", oldest), http.StatusBadRequest)
+		return
+	}
+
+	path, err := services.ValidatorSnapshotPath(epoch)
+	if err == services.ErrValidatorSnapshotPending {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusAccepted)
+		err = json.NewEncoder(w).Encode(&types.ApiResponse{Status: "PENDING: the snapshot is being built, retry later"})
+		if err != nil {
+			logger.Errorf("error serializing json data for API %v route: %v", r.URL.String(), err)
+		}
+		return
+	}
+	if err == services.ErrValidatorSnapshotQueueFull {
+		w.Header().Set("Retry-After", "60")
+		sendErrorWithCodeResponse(w, r.URL.String(), "too many snapshots are being built, retry later", http.StatusTooManyRequests)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving validator snapshot of epoch %v", epoch)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve validator snapshot", http.StatusInternalServerError)
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		logger.WithError(err).Errorf("error opening validator snapshot of epoch %v", epoch)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve validator snapshot", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	// the snapshot is stored compressed, it is only decompressed for clients which do not accept gzip
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, err = io.Copy(w, f)
+	} else {
+		var gz *gzip.Reader
+		gz, err = gzip.NewReader(f)
+		if err == nil {
+			_, err = io.Copy(w, gz)
+		}
+	}
+	if err != nil {
+		logger.WithError(err).Warnf("error sending validator snapshot of epoch %v", epoch)
+	}
+}
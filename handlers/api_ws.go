@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"eth2-exporter/services"
+	"eth2-exporter/types"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteTimeout   = time.Second * 10
+	wsPongTimeout    = time.Second * 60
+	wsPingInterval   = time.Second * 30
+	wsMaxMessageSize = 64 * 1024
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	// the api is public and served with CORS enabled, so connections from any origin are accepted
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// wsRequest is sent by clients to change the topics of their connection
+type wsRequest struct {
+	Action string   `json:"action"`
+	Topics []string `json:"topics"`
+}
+
+// wsResponse acknowledges a wsRequest, Topics holds all topics the connection is subscribed to afterwards
+type wsResponse struct {
+	Type   string   `json:"type"`
+	Topics []string `json:"topics,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// ApiWebsocket godoc
+// @Summary Stream of new slots, finalized epochs, gas prices, relay blocks and validator events
+// @Tags Websocket
+// @Description Upgrades the connection to a websocket. Clients send {"action": "subscribe" | "unsubscribe", "topics": [...]} to select the events they receive.
+// @Description Available topics are "slots", "finalized_epochs", "gas", "relay_blocks" and "validator:{index}", the latter delivers the proposal and attestation duties and status changes of the validator.
+// @Description Events are sent as {"topic", "type", "data"}, subscribers which do not keep up with the events are disconnected.
+// @Router /api/v1/ws [get]
+func ApiWebsocket(w http.ResponseWriter, r *http.Request) {
+	if !services.EventStreamEnabled() {
+		sendErrorWithCodeResponse(w, r.URL.String(), "the event stream is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	maxValidators := getUserPremium(r).MaxValidators
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already responded with an error
+		return
+	}
+	defer conn.Close()
+
+	sub := services.NewEventSubscription()
+	defer sub.Close()
+
+	responses := make(chan *wsResponse, 16)
+	done := make(chan struct{})
+	defer close(done)
+	go wsWriteLoop(conn, sub, responses, done)
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			if _, ok := err.(*websocket.CloseError); !ok && !strings.Contains(err.Error(), "use of closed network connection") {
+				logger.WithError(err).Debug("error reading websocket message")
+			}
+			return
+		}
+
+		req := &wsRequest{}
+		err = json.Unmarshal(msg, req)
+		if err == nil {
+			switch req.Action {
+			case "subscribe":
+				err = validateWsTopics(sub, req.Topics, maxValidators)
+				if err == nil {
+					sub.Subscribe(req.Topics...)
+				}
+			case "unsubscribe":
+				sub.Unsubscribe(req.Topics...)
+			default:
+				err = fmt.Errorf("unknown action %q, expected subscribe or unsubscribe", req.Action)
+			}
+		} else {
+			err = fmt.Errorf("invalid request, expected {\"action\": string, \"topics\": [string]}")
+		}
+
+		res := &wsResponse{Type: req.Action, Topics: sub.Topics()}
+		if err != nil {
+			res = &wsResponse{Type: "error", Error: err.Error()}
+		}
+
+		select {
+		case responses <- res:
+		default:
+			// the client sends requests faster than they can be answered
+			return
+		}
+	}
+}
+
+// wsWriteLoop is the only writer of conn, it sends events, responses and pings until done is closed or the subscription is dropped
+func wsWriteLoop(conn *websocket.Conn, sub *services.EventSubscription, responses <-chan *wsResponse, done <-chan struct{}) {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	write := func(msg interface{}) bool {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		return conn.WriteJSON(msg) == nil
+	}
+
+	for {
+		ok := true
+		select {
+		case <-done:
+			return
+		case e, open := <-sub.C:
+			if !open {
+				conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "subscriber does not keep up with the events"))
+				conn.Close()
+				return
+			}
+			ok = write(e)
+		case res := <-responses:
+			ok = write(res)
+		case <-ping.C:
+			ok = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)) == nil
+		}
+		if !ok {
+			// unblock the read loop of the handler
+			conn.Close()
+			return
+		}
+	}
+}
+
+func validateWsTopics(sub *services.EventSubscription, topics []string, maxValidators int) error {
+	if len(topics) == 0 {
+		return fmt.Errorf("no topics provided")
+	}
+
+	validators := map[string]bool{}
+	for _, topic := range sub.Topics() {
+		if strings.HasPrefix(topic, types.EventTopicValidatorPrefix) {
+			validators[topic] = true
+		}
+	}
+
+	for _, topic := range topics {
+		switch topic {
+		case types.EventTopicSlots, types.EventTopicFinalizedEpochs, types.EventTopicGas, types.EventTopicRelayBlocks:
+			continue
+		}
+		if !strings.HasPrefix(topic, types.EventTopicValidatorPrefix) {
+			return fmt.Errorf("unknown topic %q", topic)
+		}
+		index := strings.TrimPrefix(topic, types.EventTopicValidatorPrefix)
+		// only the canonical form is accepted, "validator:01" would never receive events
+		if i, err := strconv.ParseUint(index, 10, 64); err != nil || strconv.FormatUint(i, 10) != index {
+			return fmt.Errorf("invalid validator index in topic %q", topic)
+		}
+		validators[topic] = true
+	}
+	if len(validators) > maxValidators {
+		return fmt.Errorf("only a maximum of %v validators can be subscribed to", maxValidators)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"eth2-exporter/services"
+	"eth2-exporter/types"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestApiWebsocket(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(ApiWebsocket))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/api/v1/ws", nil)
+	if err != nil {
+		t.Fatalf("error connecting: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+
+	res := &wsResponse{}
+	for _, req := range []string{`{"action": "subscribe", "topics": ["unknown"]}`, `{"action": "subscribe", "topics": ["validator:01"]}`, `not json`} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(req)); err != nil {
+			t.Fatalf("error writing request: %v", err)
+		}
+		if err := conn.ReadJSON(res); err != nil || res.Type != "error" {
+			t.Errorf("expected an error response for %v, got %+v, %v", req, res, err)
+		}
+	}
+
+	topics := []string{}
+	for i := 0; i <= 100; i++ {
+		topics = append(topics, fmt.Sprintf(`"validator:%d"`, i))
+	}
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"action": "subscribe", "topics": [`+strings.Join(topics, ",")+`]}`))
+	if err := conn.ReadJSON(res); err != nil || res.Type != "error" {
+		t.Errorf("expected the validator limit to be enforced, got %+v, %v", res, err)
+	}
+
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"action": "subscribe", "topics": ["slots", "validator:5"]}`))
+	if err := conn.ReadJSON(res); err != nil || res.Type != "subscribe" || strings.Join(res.Topics, ",") != "slots,validator:5" {
+		t.Fatalf("unexpected subscribe response %+v, %v", res, err)
+	}
+
+	services.PublishEvent(types.EventTopicGas, types.EventTypeGas, &types.EventGas{})
+	services.PublishEvent(types.EventTopicSlots, types.EventTypeSlot, &types.EventSlot{Slot: 42})
+	e := &types.Event{}
+	if err := conn.ReadJSON(e); err != nil || e.Topic != types.EventTopicSlots || !strings.Contains(string(e.Data), `"slot":42`) {
+		t.Errorf("expected the slot event, got %+v, %v", e, err)
+	}
+
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"action": "unsubscribe", "topics": ["slots"]}`))
+	if err := conn.ReadJSON(res); err != nil || strings.Join(res.Topics, ",") != "validator:5" {
+		t.Errorf("unexpected unsubscribe response %+v, %v", res, err)
+	}
+	if services.HasEventSubscribers(types.EventTopicSlots) {
+		t.Errorf("expected no subscribers of the slots topic after unsubscribing")
+	}
+}
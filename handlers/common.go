@@ -192,24 +192,11 @@ func GetCurrencySymbol(r *http.Request) string {
 		return "$"
 	}
 
-	switch cookie.Value {
-	case "AUD":
-		return "A$"
-	case "CAD":
-		return "C$"
-	case "CNY":
-		return "¥"
-	case "EUR":
-		return "€"
-	case "GBP":
-		return "£"
-	case "JPY":
-		return "¥"
-	case "RUB":
-		return "₽"
-	default:
+	symbol := price.GetSymbol(cookie.Value)
+	if symbol == "" {
 		return "$"
 	}
+	return symbol
 }
 
 func GetCurrentPrice(r *http.Request) uint64 {
@@ -13,12 +13,12 @@ import (
 	"math/rand"
 	"net/http"
 	"sort"
-	"time"
 
 	"strconv"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
 	"github.com/lib/pq"
 	"golang.org/x/sync/errgroup"
 )
@@ -55,6 +55,7 @@ func parseValidatorsFromQueryString(str string, validatorLimit int) ([]uint64, e
 	return validators, nil
 }
 
+// Heatmap shows the duties of the validators as tiled heatmap. Without validators a random sample of the network is shown.
 func Heatmap(w http.ResponseWriter, r *http.Request) {
 
 	var heatmapTemplate = templates.GetTemplate("layout.html", "heatmap.html")
@@ -62,78 +63,67 @@ func Heatmap(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 	validatorLimit := getUserPremium(r).MaxValidators
 
-	heatmapData := types.HeatmapData{}
-	heatmapData.ValidatorLimit = validatorLimit
-
-	min := 1
-	max := 400000
-
-	validatorCount := 100
-	count, err := strconv.Atoi(r.URL.Query().Get("count"))
-	if err == nil && count > 0 && count <= 1000 {
-		validatorCount = count
+	heatmapData := types.HeatmapData{
+		ValidatorLimit: validatorLimit,
+		TileSize:       services.HeatmapTileSize,
+		MaxZoom:        services.HeatmapMaxZoom,
 	}
 
-	validatorMap := make(map[uint64]bool)
-	for len(validatorMap) < validatorCount {
-		validatorMap[uint64(rand.Intn(max-min)+min)] = true
-	}
-	validators := make([]uint64, 0, len(validatorMap))
-	for key := range validatorMap {
-		validators = append(validators, key)
+	q := r.URL.Query()
+	validators, err := parseValidatorsFromQueryString(q.Get("validators"), validatorLimit)
+	if err != nil {
+		http.Error(w, "Invalid query", http.StatusBadRequest)
+		return
 	}
-	sort.Slice(validators, func(i, j int) bool { return validators[i] < validators[j] })
 
-	validatorsCatagoryMap := make(map[uint64]int)
-	for index, validator := range validators {
-		validatorsCatagoryMap[validator] = index
-	}
-	heatmapData.Validators = validators
+	if len(validators) == 0 {
+		min := 1
+		max := 400000
 
-	endEpoch := services.LatestFinalizedEpoch()
-	epochs := make([]uint64, 0, 100)
-	epochsCatagoryMap := make(map[uint64]int)
-	for e := endEpoch - 99; e <= endEpoch; e++ {
-		epochs = append(epochs, e)
-		epochsCatagoryMap[e] = len(epochs) - 1
+		validatorCount := 100
+		count, err := strconv.Atoi(q.Get("count"))
+		if err == nil && count > 0 && count <= 1000 {
+			validatorCount = count
+		}
 
+		validatorMap := make(map[uint64]bool)
+		for len(validatorMap) < validatorCount {
+			validatorMap[uint64(rand.Intn(max-min)+min)] = true
+		}
+		for key := range validatorMap {
+			validators = append(validators, key)
+		}
 	}
-	heatmapData.Epochs = epochs
+	sort.Slice(validators, func(i, j int) bool { return validators[i] < validators[j] })
+	heatmapData.Validators = validators
 
-	start := time.Now()
-	if len(validators) == 0 {
-		logger.WithError(err).WithField("route", r.URL.String()).Error("error no validators provided")
-		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
-		return
+	epochCount := uint64(1000)
+	if count, err := strconv.ParseUint(q.Get("epochs"), 10, 64); err == nil && count > 0 && count <= 10000 {
+		epochCount = count
 	}
-	incomeData, err := db.BigtableClient.GetValidatorIncomeDetailsHistory(validators, endEpoch, 100)
-	if err != nil {
-		logger.WithError(err).WithField("route", r.URL.String()).Error("error loading validator income history data")
-		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
-		return
+	heatmapData.EndEpoch = services.LatestFinalizedEpoch()
+	if heatmapData.EndEpoch >= epochCount {
+		heatmapData.StartEpoch = heatmapData.EndEpoch - epochCount + 1
 	}
 
-	heatmapData.IncomeData = make([][3]int64, 0, validatorCount*100)
-	for validator, epochs := range incomeData {
-		for epoch, income := range epochs {
-			income := int64(income.AttestationHeadReward+income.AttestationSourceReward+income.AttestationTargetReward) - int64(income.AttestationSourcePenalty+income.AttestationTargetPenalty)
-			if income > heatmapData.MaxIncome {
-				heatmapData.MaxIncome = income
-			}
-			if income < heatmapData.MinIncome {
-				heatmapData.MinIncome = income
-			}
-			heatmapData.IncomeData = append(heatmapData.IncomeData, [3]int64{int64(epochsCatagoryMap[epoch]), int64(validatorsCatagoryMap[validator]), income})
-		}
+	// show the most detailed zoom level that fits the heatmap into a few tiles
+	size := uint64(len(validators))
+	if epochCount > size {
+		size = epochCount
 	}
-	sort.Slice(heatmapData.IncomeData, func(i, j int) bool {
-		if heatmapData.IncomeData[i][0] != heatmapData.IncomeData[j][0] {
-			return heatmapData.IncomeData[i][0] < heatmapData.IncomeData[j][0]
+	for z := services.HeatmapMaxZoom; z >= 0; z-- {
+		heatmapData.Zoom = z
+		if uint64(services.HeatmapCellsPerTile(z)*4) >= size {
+			break
 		}
-		return heatmapData.IncomeData[i][1] < heatmapData.IncomeData[j][1]
-	})
+	}
 
-	logger.Infof("retrieved income history of %v validators in %v", len(incomeData), time.Since(start))
+	heatmapData.SetID, err = services.StoreHeatmapValidatorSet(validators)
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Error("error storing heatmap validators")
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
 
 	data := InitPageData(w, r, "dashboard", "/heatmap", "Validator Heatmap")
 	data.HeaderAd = true
@@ -144,6 +134,37 @@ func Heatmap(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// HeatmapTile returns a png tile of the duty heatmap
+func HeatmapTile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	zoom, errZoom := strconv.Atoi(vars["zoom"])
+	x, errX := strconv.Atoi(vars["x"])
+	y, errY := strconv.Atoi(vars["y"])
+	if errZoom != nil || errX != nil || errY != nil {
+		http.Error(w, "Invalid query", http.StatusBadRequest)
+		return
+	}
+
+	tile, final, err := services.GetDutyHeatmapTile(vars["set"], zoom, x, y)
+	if err == services.ErrHeatmapTileNotFound {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Error("error rendering heatmap tile")
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	if final {
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age=60")
+	}
+	w.Write(tile)
+}
+
 func Dashboard(w http.ResponseWriter, r *http.Request) {
 
 	var dashboardTemplate = templates.GetTemplate("layout.html", "dashboard.html")
@@ -542,6 +563,33 @@ func DashboardDataEffectiveness(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// DashboardDataPercentiles ranks the average validator of the dashboard within the network
+func DashboardDataPercentiles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	validatorLimit := getUserPremium(r).MaxValidators
+	queryValidators, err := parseValidatorsFromQueryString(q.Get("validators"), validatorLimit)
+	if err != nil || len(queryValidators) == 0 {
+		http.Error(w, "Invalid query", 400)
+		return
+	}
+
+	percentiles, err := services.GetPerformancePercentiles(queryValidators)
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Errorf("error retrieving performance percentiles")
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(percentiles)
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Errorf("error enconding json response")
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+}
+
 func DashboardDataProposalsHistory(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -66,7 +66,9 @@ func InitPageData(w http.ResponseWriter, r *http.Request, active, path, title st
 			JpyTruncPrice:         "",
 			Currency:              GetCurrency(r),
 			CurrentPriceFormatted: GetCurrentPriceFormatted(r),
+			CurrentRoundPrice:     GetCurrentPrice(r),
 			CurrentSymbol:         GetCurrencySymbol(r),
+			PriceStale:            price.IsStale(),
 		},
 		Mainnet:            utils.Config.Chain.Config.ConfigName == "mainnet",
 		DepositContract:    utils.Config.Indexer.Eth1DepositContractAddress,
@@ -105,6 +107,10 @@ func InitPageData(w http.ResponseWriter, r *http.Request, active, path, title st
 	data.Rates.CadTruncPrice = utils.KFormatterEthPrice(data.Rates.CadRoundPrice)
 	data.Rates.AudTruncPrice = utils.KFormatterEthPrice(data.Rates.AudRoundPrice)
 	data.Rates.JpyTruncPrice = utils.KFormatterEthPrice(data.Rates.JpyRoundPrice)
+	data.Rates.CurrentTruncPrice = utils.KFormatterEthPrice(data.Rates.CurrentRoundPrice)
+	for _, currency := range price.GetCurrencies() {
+		data.Rates.Currencies = append(data.Rates.Currencies, types.PageCurrency{Code: currency, Name: price.GetCurrencyName(currency)})
+	}
 
 	acceptedLangs := strings.Split(r.Header.Get("Accept-Language"), ",")
 	if len(acceptedLangs) > 0 {
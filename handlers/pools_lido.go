@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"encoding/json"
+	"eth2-exporter/db"
+	"eth2-exporter/templates"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// the rewards are the change of the ether pooled by lido, the apr is annualized over the time since the previous report
+const lidoOracleReportsQuery = `
+	select
+		lido_oracle_reports.epoch,
+		lido_oracle_reports.block,
+		lido_oracle_reports.tx_hash,
+		(lido_oracle_reports.beacon_balance / 1e9)::bigint as beacon_balance,
+		lido_oracle_reports.beacon_validators,
+		((lido_oracle_reports.post_total_pooled_ether - lido_oracle_reports.pre_total_pooled_ether) / 1e9)::bigint as rewards,
+		case when lido_oracle_reports.pre_total_pooled_ether > 0 and lido_oracle_reports.time_elapsed > 0
+			then ((lido_oracle_reports.post_total_pooled_ether - lido_oracle_reports.pre_total_pooled_ether) / lido_oracle_reports.pre_total_pooled_ether * 31536000 / lido_oracle_reports.time_elapsed)::float8
+			else 0
+		end as apr,
+		cnt.total_count
+	from lido_oracle_reports
+	left join (select count(*) from lido_oracle_reports) cnt(total_count) ON true
+	order by %s %s
+	limit $1
+	offset $2`
+
+// PoolsLido returns the lido protocol page using a go template
+func PoolsLido(w http.ResponseWriter, r *http.Request) {
+	var poolsLidoTemplate = templates.GetTemplate("layout.html", "pools_lido.html")
+
+	w.Header().Set("Content-Type", "text/html")
+	data := InitPageData(w, r, "pools/lido", "/pools/lido", "Lido")
+	data.HeaderAd = true
+
+	pageData := &types.LidoPageData{}
+	err := db.ReaderDb.QueryRow(`select count(*), count(*) filter (where active) from lido_node_operators`).Scan(&pageData.NodeOperators, &pageData.ActiveNodeOperators)
+	if err != nil {
+		logger.Errorf("error getting lido node operators from db: %v", err)
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+	err = db.ReaderDb.QueryRow(`
+		select count(*), count(*) filter (where validators.status in ('active_online', 'active_offline'))
+		from lido_signing_keys
+		inner join validators on validators.pubkey = lido_signing_keys.pubkey
+		where lido_signing_keys.removed_block is null`).Scan(&pageData.Validators, &pageData.ActiveValidators)
+	if err != nil {
+		logger.Errorf("error getting lido validators from db: %v", err)
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+	var reports []*types.LidoPageDataOracleReport
+	err = db.ReaderDb.Select(&reports, fmt.Sprintf(lidoOracleReportsQuery, "epoch", "desc"), 1, 0)
+	if err != nil {
+		logger.Errorf("error getting latest lido oracle report from db: %v", err)
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+	if len(reports) > 0 {
+		pageData.LatestReport = reports[0]
+	}
+	data.Data = pageData
+
+	if handleTemplateError(w, r, poolsLidoTemplate.ExecuteTemplate(w, "layout", data)) != nil {
+		return // an error has occurred and was processed
+	}
+}
+
+func PoolsLidoDataNodeOperators(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+	draw, err := strconv.ParseUint(q.Get("draw"), 10, 64)
+	if err != nil {
+		logger.Errorf("error converting datatables data parameter from string to int: %v", err)
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+	start, err := strconv.ParseUint(q.Get("start"), 10, 64)
+	if err != nil {
+		logger.Errorf("error converting datatables start parameter from string to int: %v", err)
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+	length, err := strconv.ParseUint(q.Get("length"), 10, 64)
+	if err != nil {
+		logger.Errorf("error converting datatables length parameter from string to int: %v", err)
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+	if length > 100 {
+		length = 100
+	}
+	search := q.Get("search[value]")
+	if len(search) > 128 {
+		search = search[:128]
+	}
+
+	orderColumn := q.Get("order[0][column]")
+	orderByMap := map[string]string{
+		"0": "name",
+		"1": "reward_address",
+		"2": "used_signing_keys",
+		"3": "active_validators",
+		"4": "income7d",
+		"5": "income31d",
+		"6": "slashed_validators",
+		"7": "stopped_validators",
+	}
+	orderBy, exists := orderByMap[orderColumn]
+	if !exists {
+		orderBy = "active_validators"
+	}
+	orderDir := q.Get("order[0][dir]")
+	if orderDir != "desc" && orderDir != "asc" {
+		orderDir = "desc"
+	}
+
+	var operators []types.LidoPageDataNodeOperator
+	err = db.ReaderDb.Select(&operators, fmt.Sprintf(`
+		with
+			matched_operators as (
+				select registry_address, id from lido_node_operators where $3 = '' or name ilike $4 or encode(reward_address, 'hex') like $5
+			),
+			operator_validators as (
+				select
+					lido_signing_keys.registry_address,
+					lido_signing_keys.operator_id,
+					count(*) filter (where validators.status in ('active_online', 'active_offline')) as active_validators,
+					count(*) filter (where validators.slashed) as slashed_validators,
+					coalesce(sum(validator_performance.performance7d), 0)::bigint as income7d,
+					coalesce(sum(validator_performance.performance31d), 0)::bigint as income31d
+				from lido_signing_keys
+				inner join validators on validators.pubkey = lido_signing_keys.pubkey
+				left join validator_performance on validator_performance.validatorindex = validators.validatorindex
+				where lido_signing_keys.removed_block is null
+				group by lido_signing_keys.registry_address, lido_signing_keys.operator_id
+			)
+		select
+			lido_node_operators.id,
+			lido_node_operators.name,
+			lido_node_operators.reward_address,
+			lido_node_operators.active,
+			lido_node_operators.staking_limit,
+			lido_node_operators.stopped_validators,
+			lido_node_operators.total_signing_keys,
+			lido_node_operators.used_signing_keys,
+			coalesce(operator_validators.active_validators, 0) as active_validators,
+			coalesce(operator_validators.slashed_validators, 0) as slashed_validators,
+			coalesce(operator_validators.income7d, 0) as income7d,
+			coalesce(operator_validators.income31d, 0) as income31d,
+			cnt.total_count
+		from lido_node_operators
+		inner join matched_operators on matched_operators.registry_address = lido_node_operators.registry_address and matched_operators.id = lido_node_operators.id
+		left join operator_validators on operator_validators.registry_address = lido_node_operators.registry_address and operator_validators.operator_id = lido_node_operators.id
+		left join (select count(*) from matched_operators) cnt(total_count) ON true
+		order by %s %s
+		limit $1
+		offset $2`, orderBy, orderDir), length, start, search, "%"+search+"%", strings.ToLower(strings.TrimPrefix(search, "0x"))+"%")
+	if err != nil {
+		logger.Errorf("error getting lido node operators from db (with search: %v): %v", search, err)
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+
+	recordsTotal := uint64(0)
+	if len(operators) > 0 {
+		recordsTotal = operators[0].TotalCount
+	}
+
+	tableData := make([][]interface{}, 0, len(operators))
+	for _, row := range operators {
+		entry := []interface{}{}
+		entry = append(entry, template.HTMLEscapeString(row.Name))
+		entry = append(entry, utils.FormatEth1Address(row.RewardAddress))
+		entry = append(entry, fmt.Sprintf("%v / %v", row.UsedSigningKeys, row.TotalSigningKeys))
+		entry = append(entry, row.ActiveValidators)
+		entry = append(entry, utils.FormatIncome(row.Income7d, "ETH"))
+		entry = append(entry, utils.FormatIncome(row.Income31d, "ETH"))
+		entry = append(entry, row.SlashedValidators)
+		entry = append(entry, row.StoppedValidators)
+		entry = append(entry, row.Active)
+		tableData = append(tableData, entry)
+	}
+
+	data := &types.DataTableResponse{
+		Draw:            draw,
+		RecordsTotal:    recordsTotal,
+		RecordsFiltered: recordsTotal,
+		Data:            tableData,
+	}
+
+	err = json.NewEncoder(w).Encode(data)
+	if err != nil {
+		logger.Errorf("error enconding json response for %v route: %v", r.URL.String(), err)
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+}
+
+func PoolsLidoDataOracleReports(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+	draw, err := strconv.ParseUint(q.Get("draw"), 10, 64)
+	if err != nil {
+		logger.Errorf("error converting datatables data parameter from string to int: %v", err)
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+	start, err := strconv.ParseUint(q.Get("start"), 10, 64)
+	if err != nil {
+		logger.Errorf("error converting datatables start parameter from string to int: %v", err)
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+	length, err := strconv.ParseUint(q.Get("length"), 10, 64)
+	if err != nil {
+		logger.Errorf("error converting datatables length parameter from string to int: %v", err)
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+	if length > 100 {
+		length = 100
+	}
+
+	orderColumn := q.Get("order[0][column]")
+	orderByMap := map[string]string{
+		"0": "epoch",
+		"1": "beacon_validators",
+		"2": "beacon_balance",
+		"3": "rewards",
+		"4": "apr",
+	}
+	orderBy, exists := orderByMap[orderColumn]
+	if !exists {
+		orderBy = "epoch"
+	}
+	orderDir := q.Get("order[0][dir]")
+	if orderDir != "desc" && orderDir != "asc" {
+		orderDir = "desc"
+	}
+
+	var reports []types.LidoPageDataOracleReport
+	err = db.ReaderDb.Select(&reports, fmt.Sprintf(lidoOracleReportsQuery, orderBy, orderDir), length, start)
+	if err != nil {
+		logger.Errorf("error getting lido oracle reports from db: %v", err)
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+
+	recordsTotal := uint64(0)
+	if len(reports) > 0 {
+		recordsTotal = reports[0].TotalCount
+	}
+
+	tableData := make([][]interface{}, 0, len(reports))
+	for _, row := range reports {
+		entry := []interface{}{}
+		entry = append(entry, utils.FormatEpoch(row.Epoch))
+		entry = append(entry, row.BeaconValidators)
+		entry = append(entry, utils.FormatBalance(row.BeaconBalance, "ETH"))
+		entry = append(entry, utils.FormatIncome(row.Rewards, "ETH"))
+		entry = append(entry, row.APR)
+		entry = append(entry, utils.FormatEth1TxHash(row.TxHash))
+		tableData = append(tableData, entry)
+	}
+
+	data := &types.DataTableResponse{
+		Draw:            draw,
+		RecordsTotal:    recordsTotal,
+		RecordsFiltered: recordsTotal,
+		Data:            tableData,
+	}
+
+	err = json.NewEncoder(w).Encode(data)
+	if err != nil {
+		logger.Errorf("error enconding json response for %v route: %v", r.URL.String(), err)
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+}
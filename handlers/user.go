@@ -7,6 +7,7 @@ import (
 	"errors"
 	"eth2-exporter/db"
 	"eth2-exporter/mail"
+	"eth2-exporter/services"
 	"eth2-exporter/templates"
 	"eth2-exporter/types"
 	"eth2-exporter/utils"
@@ -89,19 +90,18 @@ func UserSettings(w http.ResponseWriter, r *http.Request) {
 	}
 
 	maxDaily := 10000
-	maxMonthly := 30000
+	apiPackage := "standard"
 	if subscription.PriceID != nil {
+		apiPackage = utils.GetApiPackage(*subscription.PriceID)
 		if *subscription.PriceID == utils.Config.Frontend.Stripe.Sapphire {
 			maxDaily = 100000
-			maxMonthly = 500000
 		} else if *subscription.PriceID == utils.Config.Frontend.Stripe.Emerald {
 			maxDaily = 200000
-			maxMonthly = 1000000
 		} else if *subscription.PriceID == utils.Config.Frontend.Stripe.Diamond {
 			maxDaily = -1
-			maxMonthly = 4000000
 		}
 	}
+	maxMonthly := GetApiPlanByPackage(apiPackage).RequestsPerMonth
 
 	userSettingsData.ApiStatistics = &types.ApiStatistics{}
 
@@ -146,6 +146,55 @@ func UserSettings(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// UserApiUsage renders the api usage of the user's api key in the current month
+func UserApiUsage(w http.ResponseWriter, r *http.Request) {
+	var usageTemplate = templates.GetTemplate("layout.html", "user/api_usage.html")
+
+	w.Header().Set("Content-Type", "text/html")
+	user := getUser(r)
+
+	subscription, err := db.StripeGetUserSubscription(user.UserID, utils.GROUP_API)
+	if err != nil && err != sql.ErrNoRows {
+		logger.WithError(err).Errorf("error retrieving the api subscription of user %v", user.UserID)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	apiPackage := "standard"
+	if subscription.PriceID != nil {
+		apiPackage = utils.GetApiPackage(*subscription.PriceID)
+	}
+	plan := GetApiPlanByPackage(apiPackage)
+
+	pageData := &types.ApiUsagePageData{
+		Package:             plan.Package,
+		RequestsPerSecond:   plan.RequestsPerSecond,
+		RequestsPerMonth:    plan.RequestsPerMonth,
+		RateLimitingEnabled: utils.Config.Frontend.ApiRateLimit.Enabled,
+	}
+
+	if subscription.ApiKey != nil && len(*subscription.ApiKey) > 0 {
+		now := time.Now()
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		pageData.Calls, pageData.Days, err = db.GetApiKeyUsage(*subscription.ApiKey, monthStart)
+		if err != nil {
+			logger.WithError(err).Errorf("error retrieving the api usage of user %v", user.UserID)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		for _, c := range pageData.Calls {
+			pageData.UsedThisMonth += int(c.Count)
+		}
+	}
+
+	data := InitPageData(w, r, "user", "/user/settings/api", "API Usage")
+	data.Data = pageData
+
+	if handleTemplateError(w, r, usageTemplate.ExecuteTemplate(w, "layout", data)) != nil {
+		return // an error has occurred and was processed
+	}
+}
+
 // GenerateAPIKey generates an API key for users that do not yet have a key.
 func GenerateAPIKey(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
@@ -719,7 +768,7 @@ func UserNotificationsCenter(w http.ResponseWriter, r *http.Request) {
 			if event == string(types.NetworkLivenessIncreasedEventName) {
 				networkData, err = getUserNetworkEvents(user.UserID)
 				if err != nil {
-					logger.Errorf("error retrieving network data for users: %v ", user.UserID, err)
+					logger.Errorf("error retrieving network data for user %v: %v", user.UserID, err)
 					http.Error(w, "Internal server error", http.StatusServiceUnavailable)
 					return
 				}
@@ -767,7 +816,7 @@ func UserNotificationsCenter(w http.ResponseWriter, r *http.Request) {
 
 	machines, err := db.BigtableClient.GetMachineMetricsMachineNames(user.UserID)
 	if err != nil {
-		logger.Errorf("error retrieving user machines: %v ", user.UserID, err)
+		logger.Errorf("error retrieving machines of user %v: %v", user.UserID, err)
 		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
 		return
 	}
@@ -791,7 +840,7 @@ func UserNotificationsCenter(w http.ResponseWriter, r *http.Request) {
 			user_id = $1
 	`, user.UserID)
 	if err != nil {
-		logger.Errorf("error retrieving notification channels: %v ", user.UserID, err)
+		logger.Errorf("error retrieving notification channels of user %v: %v", user.UserID, err)
 		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
 		return
 	}
@@ -1038,6 +1087,8 @@ func UserSubscriptionsData(w http.ResponseWriter, r *http.Request) {
 			} else {
 				pubkey = utils.FormatPublicKey(h)
 			}
+		} else if strings.HasPrefix(sub.EventFilter, types.SubscriptionRuleFilterPrefix) {
+			pubkey = template.HTML(template.HTMLEscapeString(sub.EventFilter))
 		} else if sub.EventName == string(types.TaxReportEventName) {
 			pubkey = template.HTML(`<a href="/rewards">report</a>`)
 		} else if strings.HasPrefix(string(sub.EventName), "monitoring_") {
@@ -1700,7 +1751,7 @@ func MultipleUsersNotificationsSubscribe(w http.ResponseWriter, r *http.Request)
 	}
 
 	if len(jsonObjects) > 100 {
-		logger.Errorf("Max number bundle subscribe is 100", err)
+		logger.Errorf("Max number bundle subscribe is 100: %v", err)
 		sendErrorResponse(w, r.URL.String(), "Max number bundle subscribe is 100")
 		return
 	}
@@ -1752,7 +1803,7 @@ func MultipleUsersNotificationsSubscribeWeb(w http.ResponseWriter, r *http.Reque
 	}
 
 	if len(jsonObjects) > 100 {
-		logger.Errorf("Max number bundle subscribe is 100", err)
+		logger.Errorf("Max number bundle subscribe is 100: %v", err)
 		sendErrorResponse(w, r.URL.String(), "Max number bundle subscribe is 100")
 		return
 	}
@@ -1782,7 +1833,6 @@ func MultipleUsersNotificationsSubscribeWeb(w http.ResponseWriter, r *http.Reque
 func internUserNotificationsSubscribe(event, filter string, threshold float64, w http.ResponseWriter, r *http.Request) bool {
 	w.Header().Set("Content-Type", "text/html")
 	user := getUser(r)
-	filter = strings.Replace(filter, "0x", "", -1)
 
 	event = strings.TrimPrefix(event, utils.GetNetwork()+":")
 
@@ -1793,6 +1843,25 @@ func internUserNotificationsSubscribe(event, filter string, threshold float64, w
 		return false
 	}
 
+	if strings.HasPrefix(filter, types.SubscriptionRuleFilterPrefix) { // tag filter = add a rule for all validators with the tag
+		tag, ok := parseSubscriptionRuleTag(eventName, filter, w, r)
+		if !ok {
+			return false
+		}
+		userPremium := getUserPremium(r)
+		if !userPremium.NotificationThresholds && eventName == types.ValidatorIsOfflineEventName {
+			threshold = 3
+		}
+		err = db.AddSubscriptionRule(user.UserID, utils.GetNetwork(), eventName, tag, threshold)
+		if err != nil {
+			logger.Errorf("error could not ADD subscription rule for user %v eventName %v tag %v: %v", user.UserID, eventName, tag, err)
+			ErrorOrJSONResponse(w, r, "Internal server error", http.StatusInternalServerError)
+			return false
+		}
+		return true
+	}
+	filter = strings.Replace(filter, "0x", "", -1)
+
 	isPkey := !pkeyRegex.MatchString(filter)
 	filterLen := len(filter)
 
@@ -1926,7 +1995,7 @@ func MultipleUsersNotificationsUnsubscribe(w http.ResponseWriter, r *http.Reques
 	}
 
 	if len(jsonObjects) > 100 {
-		logger.Errorf("Max number bundle unsubscribe is 100", err)
+		logger.Errorf("Max number bundle unsubscribe is 100: %v", err)
 		sendErrorResponse(w, r.URL.String(), "Max number bundle unsubscribe is 100")
 		return
 	}
@@ -1958,7 +2027,6 @@ func internUserNotificationsUnsubscribe(event, filter string, w http.ResponseWri
 	w.Header().Set("Content-Type", "text/html")
 	user := getUser(r)
 
-	filter = strings.Replace(filter, "0x", "", -1)
 	event = strings.TrimPrefix(event, utils.GetNetwork()+":")
 
 	eventName, err := types.EventNameFromString(event)
@@ -1968,6 +2036,21 @@ func internUserNotificationsUnsubscribe(event, filter string, w http.ResponseWri
 		return false
 	}
 
+	if strings.HasPrefix(filter, types.SubscriptionRuleFilterPrefix) { // tag filter = remove the rule of the tag
+		tag, ok := parseSubscriptionRuleTag(eventName, filter, w, r)
+		if !ok {
+			return false
+		}
+		err = db.DeleteSubscriptionRule(user.UserID, utils.GetNetwork(), eventName, tag)
+		if err != nil {
+			logger.Errorf("error could not REMOVE subscription rule for user %v eventName %v tag %v: %v", user.UserID, eventName, tag, err)
+			ErrorOrJSONResponse(w, r, "Internal server error", http.StatusInternalServerError)
+			return false
+		}
+		return true
+	}
+	filter = strings.Replace(filter, "0x", "", -1)
+
 	isPkey := !pkeyRegex.MatchString(filter)
 	filterLen := len(filter)
 
@@ -2036,12 +2119,116 @@ func internUserNotificationsUnsubscribe(event, filter string, w http.ResponseWri
 	return true
 }
 
+// parseSubscriptionRuleTag returns the tag of a "tag:<tag>" event filter, rules are only supported for validator events
+func parseSubscriptionRuleTag(eventName types.EventName, filter string, w http.ResponseWriter, r *http.Request) (string, bool) {
+	tag := strings.TrimPrefix(filter, types.SubscriptionRuleFilterPrefix)
+	if tag == "" || len(tag) > 100 {
+		logger.Errorf("error invalid tag for subscription rule: %v", filter)
+		ErrorOrJSONResponse(w, r, "Invalid tag", http.StatusBadRequest)
+		return "", false
+	}
+
+	for _, ev := range types.AddWatchlistEvents {
+		if ev.Event == eventName {
+			return tag, true
+		}
+	}
+	logger.Errorf("error subscription rules are not supported for event %v", eventName)
+	ErrorOrJSONResponse(w, r, "Tag filters are only supported for validator events", http.StatusBadRequest)
+	return "", false
+}
+
+// UserNotificationsRules godoc
+// @Summary Get the tag based subscription rules of a user. Rules are created and removed by subscribing to or unsubscribing from an event with the filter "tag:<tag>"
+// @Tags User
+// @Produce json
+// @Success 200 {object} types.ApiResponse{data=[]types.SubscriptionRule}
+// @Failure 400 {object} types.ApiResponse
+// @Failure 500 {object} types.ApiResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/user/notifications/rules [get]
+func UserNotificationsRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+	if !user.Authenticated {
+		sendErrorWithCodeResponse(w, r.URL.String(), "not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	rules, err := db.GetSubscriptionRules(user.UserID, utils.GetNetwork())
+	if err != nil {
+		logger.WithError(err).Errorf("error getting subscription rules of user %v", user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve db results", http.StatusInternalServerError)
+		return
+	}
+	for _, rule := range rules {
+		rule.EventName = strings.TrimPrefix(rule.EventName, utils.GetNetwork()+":")
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{rules})
+}
+
+// UserNotificationsLocale godoc
+// @Summary Get or set the locale notifications and emails are sent in. The locale is set by posting one of the supported locales
+// @Tags User
+// @Produce json
+// @Param locale body string false "Locale to use for notifications, e.g. en-US or ru-RU"
+// @Success 200 {object} types.ApiResponse
+// @Failure 400 {object} types.ApiResponse
+// @Failure 500 {object} types.ApiResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/user/notifications/locale [get]
+// @Router /api/v1/user/notifications/locale [post]
+func UserNotificationsLocale(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+	if !user.Authenticated {
+		sendErrorWithCodeResponse(w, r.URL.String(), "not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		locale := FormValueOrJSON(r, "locale")
+		if utils.NormalizeLocale(locale) != locale {
+			sendErrorWithCodeResponse(w, r.URL.String(), fmt.Sprintf("invalid locale, supported locales are %v", strings.Join(utils.SupportedLocales, ", ")), http.StatusBadRequest)
+			return
+		}
+		err := db.SetUserLocale(user.UserID, locale)
+		if err != nil {
+			logger.WithError(err).Errorf("error setting locale of user %v", user.UserID)
+			sendErrorWithCodeResponse(w, r.URL.String(), "could not update db", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	locale, err := db.GetUserLocale(user.UserID)
+	if err != nil {
+		logger.WithError(err).Errorf("error getting locale of user %v", user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve db results", http.StatusInternalServerError)
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{map[string]interface{}{
+		"locale":            locale,
+		"supported_locales": utils.SupportedLocales,
+	}})
+}
+
 func UserNotificationsUnsubscribe(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 	user := getUser(r)
 	q := r.URL.Query()
 	event := q.Get("event")
 	filter := q.Get("filter")
+
+	if strings.HasPrefix(filter, types.SubscriptionRuleFilterPrefix) {
+		if internUserNotificationsUnsubscribe(event, filter, w, r) {
+			OKResponse(w, r)
+		}
+		return
+	}
 	filter = strings.Replace(filter, "0x", "", -1)
 
 	event = strings.TrimPrefix(event, utils.GetNetwork()+":")
@@ -2170,6 +2357,60 @@ type UsersNotificationsRequest struct {
 	JoinValidator bool     `json:"join_validator"`
 }
 
+// UserNotificationsSimulate renders a synthetic notification for one of the user's subscriptions on every configured channel
+// (email, push, webhook, discord) and, if deliver is set, sends it through the notification queue
+func UserNotificationsSimulate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+	if !user.Authenticated {
+		sendErrorWithCodeResponse(w, r.URL.String(), "not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	subscriptionParam := FormValueOrJSON(r, "subscription_id")
+	if subscriptionParam == "" {
+		subscriptionParam = q.Get("subscription_id")
+	}
+	subscriptionID, err := strconv.ParseUint(subscriptionParam, 10, 64)
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), "invalid subscription_id provided")
+		return
+	}
+	deliverParam := FormValueOrJSON(r, "deliver")
+	if deliverParam == "" {
+		deliverParam = q.Get("deliver")
+	}
+	deliver := deliverParam == "true"
+
+	sub, err := services.GetUserSubscription(user.UserID, subscriptionID)
+	if err == sql.ErrNoRows {
+		sendErrorWithCodeResponse(w, r.URL.String(), "subscription not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("error getting subscription %v of user %v", subscriptionID, user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve db results", http.StatusInternalServerError)
+		return
+	}
+
+	n, err := services.SimulateNotification(sub)
+	if err != nil {
+		sendErrorResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	preview, err := services.PreviewNotification(sub, n, deliver)
+	if err != nil {
+		logger.WithError(err).Errorf("error rendering simulated notification for subscription %v", subscriptionID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "error rendering notification", http.StatusInternalServerError)
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{preview})
+}
+
 // UserNotificationsSubscribed godoc
 // @Summary Get a set of events a user is subscribed to
 // @Tags User
@@ -2436,6 +2677,21 @@ func NotificationWebhookPage(w http.ResponseWriter, r *http.Request) {
 			EventName:  types.ValidatorMissedAttestationEventName,
 			Active:     utils.ElementExists(wh.EventNames, string(types.ValidatorMissedAttestationEventName)),
 		})
+		events = append(events, types.EventNameCheckbox{
+			EventLabel: "Exit Initiated",
+			EventName:  types.ValidatorExitInitiatedEventName,
+			Active:     utils.ElementExists(wh.EventNames, string(types.ValidatorExitInitiatedEventName)),
+		})
+		events = append(events, types.EventNameCheckbox{
+			EventLabel: "Withdrawal Credentials Changed",
+			EventName:  types.ValidatorWithdrawalCredentialsChangedEventName,
+			Active:     utils.ElementExists(wh.EventNames, string(types.ValidatorWithdrawalCredentialsChangedEventName)),
+		})
+		events = append(events, types.EventNameCheckbox{
+			EventLabel: "Deposit Credentials Mismatch",
+			EventName:  types.ValidatorDepositCredentialsMismatchEventName,
+			Active:     utils.ElementExists(wh.EventNames, string(types.ValidatorDepositCredentialsMismatchEventName)),
+		})
 		events = append(events, types.EventNameCheckbox{
 			EventLabel: "Machine Offline",
 			EventName:  types.MonitoringMachineOfflineEventName,
@@ -2451,6 +2707,11 @@ func NotificationWebhookPage(w http.ResponseWriter, r *http.Request) {
 			EventName:  types.MonitoringMachineCpuLoadEventName,
 			Active:     utils.ElementExists(wh.EventNames, string(types.MonitoringMachineCpuLoadEventName)),
 		})
+		events = append(events, types.EventNameCheckbox{
+			EventLabel: "Scheduled Report",
+			EventName:  types.ScheduledReportEventName,
+			Active:     utils.ElementExists(wh.EventNames, string(types.ScheduledReportEventName)),
+		})
 
 		isDiscord := false
 
@@ -2529,6 +2790,18 @@ func NotificationWebhookPage(w http.ResponseWriter, r *http.Request) {
 		EventLabel: "Attestation Missed",
 		EventName:  types.ValidatorMissedAttestationEventName,
 	})
+	events = append(events, types.EventNameCheckbox{
+		EventLabel: "Exit Initiated",
+		EventName:  types.ValidatorExitInitiatedEventName,
+	})
+	events = append(events, types.EventNameCheckbox{
+		EventLabel: "Withdrawal Credentials Changed",
+		EventName:  types.ValidatorWithdrawalCredentialsChangedEventName,
+	})
+	events = append(events, types.EventNameCheckbox{
+		EventLabel: "Deposit Credentials Mismatch",
+		EventName:  types.ValidatorDepositCredentialsMismatchEventName,
+	})
 	events = append(events, types.EventNameCheckbox{
 		EventLabel: "Machine Offline",
 		EventName:  types.MonitoringMachineOfflineEventName,
@@ -2541,6 +2814,10 @@ func NotificationWebhookPage(w http.ResponseWriter, r *http.Request) {
 		EventLabel: "Machine CPU",
 		EventName:  types.MonitoringMachineCpuLoadEventName,
 	})
+	events = append(events, types.EventNameCheckbox{
+		EventLabel: "Scheduled Report",
+		EventName:  types.ScheduledReportEventName,
+	})
 
 	pageData.Events = events
 
@@ -2578,9 +2855,13 @@ func UsersAddWebhook(w http.ResponseWriter, r *http.Request) {
 	validatorGotSlashed := r.FormValue(string(types.ValidatorGotSlashedEventName)) == "on"
 	validatorSyncCommiteeSoon := r.FormValue(string(types.SyncCommitteeSoon)) == "on"
 	validatorAttestationMissed := r.FormValue(string(types.ValidatorMissedAttestationEventName)) == "on"
+	validatorExitInitiated := r.FormValue(string(types.ValidatorExitInitiatedEventName)) == "on"
+	validatorWithdrawalCredentialsChanged := r.FormValue(string(types.ValidatorWithdrawalCredentialsChangedEventName)) == "on"
+	validatorDepositCredentialsMismatch := r.FormValue(string(types.ValidatorDepositCredentialsMismatchEventName)) == "on"
 	monitoringMachineOffline := r.FormValue(string(types.MonitoringMachineOfflineEventName)) == "on"
 	monitoringHddAlmostfull := r.FormValue(string(types.MonitoringMachineDiskAlmostFullEventName)) == "on"
 	monitoringCpuLoad := r.FormValue(string(types.MonitoringMachineCpuLoadEventName)) == "on"
+	scheduledReport := r.FormValue(string(types.ScheduledReportEventName)) == "on"
 	discord := r.FormValue("discord") == "on"
 
 	if discord {
@@ -2597,9 +2878,13 @@ func UsersAddWebhook(w http.ResponseWriter, r *http.Request) {
 	events[string(types.ValidatorGotSlashedEventName)] = validatorGotSlashed
 	events[string(types.SyncCommitteeSoon)] = validatorSyncCommiteeSoon
 	events[string(types.ValidatorMissedAttestationEventName)] = validatorAttestationMissed
+	events[string(types.ValidatorExitInitiatedEventName)] = validatorExitInitiated
+	events[string(types.ValidatorWithdrawalCredentialsChangedEventName)] = validatorWithdrawalCredentialsChanged
+	events[string(types.ValidatorDepositCredentialsMismatchEventName)] = validatorDepositCredentialsMismatch
 	events[string(types.MonitoringMachineOfflineEventName)] = monitoringMachineOffline
 	events[string(types.MonitoringMachineDiskAlmostFullEventName)] = monitoringHddAlmostfull
 	events[string(types.MonitoringMachineCpuLoadEventName)] = monitoringCpuLoad
+	events[string(types.ScheduledReportEventName)] = scheduledReport
 
 	eventNames := make([]string, 0)
 
@@ -2725,9 +3010,13 @@ func UsersEditWebhook(w http.ResponseWriter, r *http.Request) {
 	validatorGotSlashed := r.FormValue(string(types.ValidatorGotSlashedEventName)) == "on"
 	validatorSyncCommiteeSoon := r.FormValue(string(types.SyncCommitteeSoon)) == "on"
 	validatorAttestationMissed := r.FormValue(string(types.ValidatorMissedAttestationEventName)) == "on"
+	validatorExitInitiated := r.FormValue(string(types.ValidatorExitInitiatedEventName)) == "on"
+	validatorWithdrawalCredentialsChanged := r.FormValue(string(types.ValidatorWithdrawalCredentialsChangedEventName)) == "on"
+	validatorDepositCredentialsMismatch := r.FormValue(string(types.ValidatorDepositCredentialsMismatchEventName)) == "on"
 	monitoringMachineOffline := r.FormValue(string(types.MonitoringMachineOfflineEventName)) == "on"
 	monitoringHddAlmostfull := r.FormValue(string(types.MonitoringMachineDiskAlmostFullEventName)) == "on"
 	monitoringCpuLoad := r.FormValue(string(types.MonitoringMachineCpuLoadEventName)) == "on"
+	scheduledReport := r.FormValue(string(types.ScheduledReportEventName)) == "on"
 	discord := r.FormValue("discord") == "on"
 
 	if discord {
@@ -2744,9 +3033,13 @@ func UsersEditWebhook(w http.ResponseWriter, r *http.Request) {
 	events[string(types.ValidatorGotSlashedEventName)] = validatorGotSlashed
 	events[string(types.SyncCommitteeSoon)] = validatorSyncCommiteeSoon
 	events[string(types.ValidatorMissedAttestationEventName)] = validatorAttestationMissed
+	events[string(types.ValidatorExitInitiatedEventName)] = validatorExitInitiated
+	events[string(types.ValidatorWithdrawalCredentialsChangedEventName)] = validatorWithdrawalCredentialsChanged
+	events[string(types.ValidatorDepositCredentialsMismatchEventName)] = validatorDepositCredentialsMismatch
 	events[string(types.MonitoringMachineOfflineEventName)] = monitoringMachineOffline
 	events[string(types.MonitoringMachineDiskAlmostFullEventName)] = monitoringHddAlmostfull
 	events[string(types.MonitoringMachineCpuLoadEventName)] = monitoringCpuLoad
+	events[string(types.ScheduledReportEventName)] = scheduledReport
 
 	eventNames := make([]string, 0)
 
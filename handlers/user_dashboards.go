@@ -0,0 +1,535 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"eth2-exporter/cache"
+	"eth2-exporter/db"
+	"eth2-exporter/services"
+	"eth2-exporter/templates"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	dashboardMaxPerUser    = 10
+	dashboardMaxGroups     = 20
+	dashboardMaxValidators = 10000
+	dashboardMaxNameLength = 100
+	dashboardAPRDays       = 31
+
+	// the aggregates of a group are cached per epoch, the local cache is refreshed more often so a new epoch is picked up quickly
+	dashboardSummaryCacheExpiration      = time.Hour
+	dashboardSummaryCacheLocalExpiration = time.Minute
+)
+
+type dashboardRequest struct {
+	Name   string `json:"name"`
+	Groups []struct {
+		Name       string   `json:"name"`
+		Validators []uint64 `json:"validators"`
+	} `json:"groups"`
+}
+
+// UserDashboards returns the saved dashboards of the user with their groups
+func UserDashboards(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+
+	dashboards, err := db.GetDashboards(user.UserID, utils.GetNetwork())
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving dashboards of user %v", user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve db results", http.StatusInternalServerError)
+		return
+	}
+	for _, dashboard := range dashboards {
+		setDashboardShareURL(dashboard)
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{dashboards})
+}
+
+// UserDashboardCreate saves a new dashboard of the user
+func UserDashboardCreate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+
+	req := &dashboardRequest{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(req)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "invalid request body", http.StatusBadRequest)
+		return
+	}
+	dashboard, err := newDashboard(req)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), err.Error(), http.StatusBadRequest)
+		return
+	}
+	dashboard.UserID = user.UserID
+	dashboard.CreatedTime = dashboard.UpdatedTime
+
+	count, err := db.CountDashboards(user.UserID, dashboard.Network)
+	if err != nil {
+		logger.WithError(err).Errorf("error counting dashboards of user %v", user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not retrieve db results", http.StatusInternalServerError)
+		return
+	}
+	if count >= dashboardMaxPerUser {
+		sendErrorWithCodeResponse(w, r.URL.String(), fmt.Sprintf("only a maximum of %v dashboards can be saved", dashboardMaxPerUser), http.StatusBadRequest)
+		return
+	}
+
+	err = db.CreateDashboard(dashboard)
+	if err != nil {
+		if isUniqueViolation(err) {
+			sendErrorWithCodeResponse(w, r.URL.String(), "a dashboard with this name already exists", http.StatusConflict)
+			return
+		}
+		logger.WithError(err).Errorf("error creating dashboard of user %v", user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not update db", http.StatusInternalServerError)
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{dashboard})
+}
+
+// UserDashboardUpdate renames a dashboard of the user and replaces its groups
+func UserDashboardUpdate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+
+	id, err := strconv.ParseUint(mux.Vars(r)["dashboardID"], 10, 64)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "invalid dashboard id", http.StatusBadRequest)
+		return
+	}
+	req := &dashboardRequest{}
+	err = json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(req)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "invalid request body", http.StatusBadRequest)
+		return
+	}
+	dashboard, err := newDashboard(req)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), err.Error(), http.StatusBadRequest)
+		return
+	}
+	dashboard.ID = id
+	dashboard.UserID = user.UserID
+
+	err = db.UpdateDashboard(dashboard)
+	if err == sql.ErrNoRows {
+		sendErrorWithCodeResponse(w, r.URL.String(), "dashboard not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		if isUniqueViolation(err) {
+			sendErrorWithCodeResponse(w, r.URL.String(), "a dashboard with this name already exists", http.StatusConflict)
+			return
+		}
+		logger.WithError(err).Errorf("error updating dashboard %v of user %v", id, user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not update db", http.StatusInternalServerError)
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{dashboard})
+}
+
+// UserDashboardDelete deletes a dashboard of the user, its share link stops working
+func UserDashboardDelete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+
+	id, err := strconv.ParseUint(mux.Vars(r)["dashboardID"], 10, 64)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "invalid dashboard id", http.StatusBadRequest)
+		return
+	}
+
+	err = db.DeleteDashboard(user.UserID, id)
+	if err == sql.ErrNoRows {
+		sendErrorWithCodeResponse(w, r.URL.String(), "dashboard not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("error deleting dashboard %v of user %v", id, user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not update db", http.StatusInternalServerError)
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), nil)
+}
+
+// UserDashboardShare enables or disables the read-only share link of a dashboard of the user.
+// Enabling it again creates a new link, previous links stop working.
+func UserDashboardShare(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+	user := getUser(r)
+
+	id, err := strconv.ParseUint(mux.Vars(r)["dashboardID"], 10, 64)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "invalid dashboard id", http.StatusBadRequest)
+		return
+	}
+	req := struct {
+		Enabled bool `json:"enabled"`
+	}{}
+	err = json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req)
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var token []byte
+	if req.Enabled {
+		token = make([]byte, 16)
+		if _, err := rand.Read(token); err != nil {
+			logger.WithError(err).Errorf("error generating share token")
+			sendErrorWithCodeResponse(w, r.URL.String(), "could not create share link", http.StatusInternalServerError)
+			return
+		}
+	}
+	err = db.SetDashboardShareToken(user.UserID, id, token)
+	if err == sql.ErrNoRows {
+		sendErrorWithCodeResponse(w, r.URL.String(), "dashboard not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("error updating share token of dashboard %v of user %v", id, user.UserID)
+		sendErrorWithCodeResponse(w, r.URL.String(), "could not update db", http.StatusInternalServerError)
+		return
+	}
+
+	dashboard := &types.Dashboard{ID: id, ShareToken: token}
+	setDashboardShareURL(dashboard)
+	sendOKResponse(j, r.URL.String(), []interface{}{map[string]string{"share_url": dashboard.ShareURL}})
+}
+
+// newDashboard validates the request and returns the dashboard for it
+func newDashboard(req *dashboardRequest) (*types.Dashboard, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > dashboardMaxNameLength {
+		return nil, fmt.Errorf("the name of the dashboard must be between 1 and %v characters long", dashboardMaxNameLength)
+	}
+	if len(req.Groups) == 0 || len(req.Groups) > dashboardMaxGroups {
+		return nil, fmt.Errorf("a dashboard must have between 1 and %v groups", dashboardMaxGroups)
+	}
+
+	dashboard := &types.Dashboard{
+		Network:     utils.GetNetwork(),
+		Name:        name,
+		UpdatedTime: time.Now(),
+		Groups:      make([]*types.DashboardGroup, 0, len(req.Groups)),
+	}
+	total := 0
+	for i, g := range req.Groups {
+		groupName := strings.TrimSpace(g.Name)
+		if groupName == "" {
+			groupName = fmt.Sprintf("Group %d", i+1)
+		}
+		if len(groupName) > dashboardMaxNameLength {
+			return nil, fmt.Errorf("the name of a group must not be longer than %v characters", dashboardMaxNameLength)
+		}
+
+		validators := make(pq.Int64Array, 0, len(g.Validators))
+		seen := make(map[uint64]bool, len(g.Validators))
+		for _, v := range g.Validators {
+			if v > math.MaxInt32 {
+				return nil, fmt.Errorf("invalid validator index %v", v)
+			}
+			if !seen[v] {
+				seen[v] = true
+				validators = append(validators, int64(v))
+			}
+		}
+		if len(validators) == 0 {
+			return nil, fmt.Errorf("group %q has no validators", groupName)
+		}
+		total += len(validators)
+		dashboard.Groups = append(dashboard.Groups, &types.DashboardGroup{Name: groupName, Validators: validators})
+	}
+	if total > dashboardMaxValidators {
+		return nil, fmt.Errorf("a dashboard can hold a maximum of %v validators", dashboardMaxValidators)
+	}
+	return dashboard, nil
+}
+
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+func setDashboardShareURL(dashboard *types.Dashboard) {
+	if len(dashboard.ShareToken) > 0 {
+		dashboard.ShareURL = fmt.Sprintf("https://%s/dashboard/shared/%x", utils.Config.Frontend.SiteDomain, dashboard.ShareToken)
+	}
+}
+
+// UserDashboardView renders a saved dashboard of the user
+func UserDashboardView(w http.ResponseWriter, r *http.Request) {
+	user := getUser(r)
+	id, err := strconv.ParseUint(mux.Vars(r)["dashboardID"], 10, 64)
+	if err != nil {
+		dashboardNotFound(w, r)
+		return
+	}
+	dashboard, err := db.GetDashboard(user.UserID, id)
+	if err == nil && dashboard.Network != utils.GetNetwork() {
+		err = sql.ErrNoRows
+	}
+	renderDashboardView(w, r, dashboard, err, false)
+}
+
+// SharedDashboardView renders a dashboard shared by its owner, it can not be modified
+func SharedDashboardView(w http.ResponseWriter, r *http.Request) {
+	token, err := hex.DecodeString(mux.Vars(r)["token"])
+	if err != nil || len(token) == 0 {
+		dashboardNotFound(w, r)
+		return
+	}
+	dashboard, err := db.GetSharedDashboard(utils.GetNetwork(), token)
+	renderDashboardView(w, r, dashboard, err, true)
+}
+
+func renderDashboardView(w http.ResponseWriter, r *http.Request, dashboard *types.Dashboard, err error, shared bool) {
+	if err == sql.ErrNoRows {
+		dashboardNotFound(w, r)
+		return
+	}
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving dashboard")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	var dashboardViewTemplate = templates.GetTemplate("layout.html", "dashboardView.html")
+	w.Header().Set("Content-Type", "text/html")
+
+	pageData := &types.DashboardViewPageData{
+		Dashboard: dashboard,
+		Groups:    make([]*types.DashboardGroupSummary, len(dashboard.Groups)),
+		Shared:    shared,
+	}
+	if !shared {
+		setDashboardShareURL(dashboard)
+		pageData.CsrfField = csrf.TemplateField(r)
+	}
+
+	g := &errgroup.Group{}
+	for i, group := range dashboard.Groups {
+		i, group := i, group
+		g.Go(func() error {
+			summary, err := getDashboardGroupSummary(group)
+			if err != nil {
+				return fmt.Errorf("error retrieving summary of dashboard group %v: %w", group.ID, err)
+			}
+			pageData.Groups[i] = summary
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		logger.WithError(err).Errorf("error retrieving dashboard %v", dashboard.ID)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	validatorLimit := getUserPremium(r).MaxValidators
+	for i, summary := range pageData.Groups {
+		if summary.Validators <= validatorLimit {
+			indices := make([]string, 0, len(dashboard.Groups[i].Validators))
+			for _, v := range dashboard.Groups[i].Validators {
+				indices = append(indices, strconv.FormatInt(v, 10))
+			}
+			summary.DashboardURL = "/dashboard?validators=" + strings.Join(indices, ",")
+		}
+	}
+	pageData.Total = sumDashboardGroupSummaries(pageData.Groups)
+	pageData.APRChart = dashboardAPRChart(pageData.Groups)
+
+	data := InitPageData(w, r, "dashboard", "/dashboard", dashboard.Name)
+	data.Data = pageData
+
+	if handleTemplateError(w, r, dashboardViewTemplate.ExecuteTemplate(w, "layout", data)) != nil {
+		return // an error has occurred and was processed
+	}
+}
+
+func dashboardNotFound(w http.ResponseWriter, r *http.Request) {
+	var notFoundTemplate = templates.GetTemplate("layout.html", "dashboardnotfound.html")
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusNotFound)
+
+	data := InitPageData(w, r, "dashboard", "/dashboard", "Dashboard not found")
+	if handleTemplateError(w, r, notFoundTemplate.ExecuteTemplate(w, "layout", data)) != nil {
+		return // an error has occurred and was processed
+	}
+}
+
+// getDashboardGroupSummary returns the aggregates of a group of the latest epoch.
+// Groups with the same validators share their cache entry, regardless of the dashboard they belong to.
+func getDashboardGroupSummary(group *types.DashboardGroup) (*types.DashboardGroupSummary, error) {
+	validators := make([]uint64, 0, len(group.Validators))
+	for _, v := range group.Validators {
+		validators = append(validators, uint64(v))
+	}
+	sort.Slice(validators, func(i, j int) bool { return validators[i] < validators[j] })
+
+	epoch := services.LatestEpoch()
+	hash := sha256.New()
+	for _, v := range validators {
+		binary.Write(hash, binary.BigEndian, v)
+	}
+	cacheKey := fmt.Sprintf("%d:frontend:dashboardGroupSummary:%d:%x", utils.Config.Chain.Config.DepositChainID, epoch, hash.Sum(nil))
+
+	summary := &types.DashboardGroupSummary{}
+	if wanted, err := cache.TieredCache.GetWithLocalTimeout(cacheKey, dashboardSummaryCacheLocalExpiration, summary); err == nil {
+		summary = wanted.(*types.DashboardGroupSummary)
+	} else {
+		summary, err = computeDashboardGroupSummary(validators, epoch)
+		if err != nil {
+			return nil, err
+		}
+		err = cache.TieredCache.Set(cacheKey, summary, dashboardSummaryCacheExpiration)
+		if err != nil {
+			logger.Errorf("error caching dashboard group summary: %v", err)
+		}
+	}
+
+	// the cached aggregates are shared, the group details are only set on a copy
+	res := *summary
+	res.GroupID = group.ID
+	res.Name = group.Name
+	return &res, nil
+}
+
+func computeDashboardGroupSummary(validators []uint64, epoch uint64) (*types.DashboardGroupSummary, error) {
+	summary := &types.DashboardGroupSummary{Validators: len(validators), Epoch: epoch}
+
+	var active []uint64
+	err := db.ReaderDb.Select(&active, `SELECT validatorindex FROM validators WHERE validatorindex = ANY($1) AND activationepoch <= $2 AND exitepoch > $2`, pq.Array(validators), epoch)
+	if err != nil {
+		return nil, err
+	}
+	summary.ActiveValidators = len(active)
+
+	err = db.ReaderDb.Get(&summary.Balance, `SELECT COALESCE(SUM(balance), 0) FROM validators WHERE validatorindex = ANY($1)`, pq.Array(validators))
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.ReaderDb.QueryRow(`
+		SELECT
+			COUNT(*) FILTER (WHERE status = '1'),
+			COUNT(*) FILTER (WHERE status = '2'),
+			COUNT(*) FILTER (WHERE status = '3')
+		FROM blocks
+		WHERE proposer = ANY($1)`, pq.Array(validators)).Scan(&summary.ProposedBlocks, &summary.MissedBlocks, &summary.OrphanedBlocks)
+	if err != nil {
+		return nil, err
+	}
+
+	earnings, err := GetValidatorEarnings(validators, "ETH")
+	if err != nil {
+		return nil, err
+	}
+	if earnings != nil {
+		summary.IncomeLastDay = earnings.LastDay
+		summary.IncomeLastWeek = earnings.LastWeek
+		summary.IncomeLastMonth = earnings.LastMonth
+		summary.IncomeTotal = earnings.Total
+	}
+
+	summary.Percentiles, err = services.GetPerformancePercentiles(validators)
+	if err != nil {
+		return nil, err
+	}
+
+	lastDay := utils.TimeToDay(uint64(utils.EpochToTime(epoch).Unix()))
+	firstDay := uint64(0)
+	if lastDay >= dashboardAPRDays {
+		firstDay = lastDay - dashboardAPRDays
+	}
+	summary.APRHistory, err = db.GetValidatorAPRHistory(validators, firstDay, lastDay)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(active) > 0 && epoch > 0 {
+		effectiveness, err := db.BigtableClient.GetValidatorEffectiveness(active, epoch-1)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range effectiveness {
+			summary.Effectiveness += e.AttestationEfficiency
+		}
+		if len(effectiveness) > 0 {
+			summary.Effectiveness /= float64(len(effectiveness))
+		}
+	}
+	return summary, nil
+}
+
+// dashboardAPRChart returns the apr series of the groups and of ETH.STORE for comparison
+func dashboardAPRChart(summaries []*types.DashboardGroupSummary) []*types.DashboardAPRSeries {
+	series := make([]*types.DashboardAPRSeries, 0, len(summaries)+1)
+	ethStore := &types.DashboardAPRSeries{Name: "ETH.STORE"}
+	ethStoreDays := make(map[int64]bool)
+	for _, s := range summaries {
+		group := &types.DashboardAPRSeries{Name: s.Name, Data: make([]*types.DashboardAPRPoint, 0, len(s.APRHistory))}
+		for _, h := range s.APRHistory {
+			ts := utils.DayToTime(h.Day).Unix() * 1000
+			group.Data = append(group.Data, &types.DashboardAPRPoint{X: ts, Y: h.APR * 100, CL: h.CLAPR * 100, EL: h.ELAPR * 100})
+			if h.EthStoreAPR != nil && !ethStoreDays[h.Day] {
+				ethStoreDays[h.Day] = true
+				ethStore.Data = append(ethStore.Data, &types.DashboardAPRPoint{X: ts, Y: *h.EthStoreAPR * 100})
+			}
+		}
+		series = append(series, group)
+	}
+	sort.Slice(ethStore.Data, func(i, j int) bool { return ethStore.Data[i].X < ethStore.Data[j].X })
+	return append(series, ethStore)
+}
+
+// sumDashboardGroupSummaries returns the aggregates of all groups, validators that are part of multiple groups are counted multiple times
+func sumDashboardGroupSummaries(summaries []*types.DashboardGroupSummary) *types.DashboardGroupSummary {
+	total := &types.DashboardGroupSummary{Name: "Total"}
+	for _, s := range summaries {
+		total.Validators += s.Validators
+		total.ActiveValidators += s.ActiveValidators
+		total.Balance += s.Balance
+		total.IncomeLastDay += s.IncomeLastDay
+		total.IncomeLastWeek += s.IncomeLastWeek
+		total.IncomeLastMonth += s.IncomeLastMonth
+		total.IncomeTotal += s.IncomeTotal
+		total.ProposedBlocks += s.ProposedBlocks
+		total.MissedBlocks += s.MissedBlocks
+		total.OrphanedBlocks += s.OrphanedBlocks
+		// the effectiveness is weighted by the number of active validators of the group
+		total.Effectiveness += s.Effectiveness * float64(s.ActiveValidators)
+		total.Epoch = s.Epoch
+	}
+	if total.ActiveValidators > 0 {
+		total.Effectiveness /= float64(total.ActiveValidators)
+	}
+	return total
+}
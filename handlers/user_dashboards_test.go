@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"eth2-exporter/types"
+	"testing"
+)
+
+func TestNewDashboard(t *testing.T) {
+	req := &dashboardRequest{Name: " home "}
+	req.Groups = append(req.Groups, struct {
+		Name       string   `json:"name"`
+		Validators []uint64 `json:"validators"`
+	}{Name: "", Validators: []uint64{3, 1, 3}})
+
+	dashboard, err := newDashboard(req)
+	if err != nil {
+		t.Fatalf("error creating dashboard: %v", err)
+	}
+	if dashboard.Name != "home" || len(dashboard.Groups) != 1 || dashboard.Groups[0].Name != "Group 1" || len(dashboard.Groups[0].Validators) != 2 {
+		t.Errorf("unexpected dashboard %+v", dashboard)
+	}
+
+	if _, err := newDashboard(&dashboardRequest{Name: "empty"}); err == nil {
+		t.Errorf("expected an error for a dashboard without groups")
+	}
+
+	req.Groups[0].Validators = nil
+	if _, err := newDashboard(req); err == nil {
+		t.Errorf("expected an error for a group without validators")
+	}
+}
+
+func TestSumDashboardGroupSummaries(t *testing.T) {
+	total := sumDashboardGroupSummaries([]*types.DashboardGroupSummary{
+		{Validators: 3, ActiveValidators: 3, Balance: 96, IncomeLastDay: 3, Effectiveness: 100, ProposedBlocks: 1},
+		{Validators: 2, ActiveValidators: 1, Balance: 32, IncomeLastDay: -1, Effectiveness: 60, MissedBlocks: 1},
+	})
+	if total.Validators != 5 || total.ActiveValidators != 4 || total.Balance != 128 || total.IncomeLastDay != 2 || total.ProposedBlocks != 1 || total.MissedBlocks != 1 {
+		t.Errorf("unexpected total %+v", total)
+	}
+	if total.Effectiveness != 90 {
+		t.Errorf("unexpected effectiveness %v", total.Effectiveness)
+	}
+}
+
+func TestDashboardAPRChart(t *testing.T) {
+	ethStore := 0.05
+	chart := dashboardAPRChart([]*types.DashboardGroupSummary{
+		{Name: "a", APRHistory: []*types.ValidatorAPRHistory{{Day: 2, APR: 0.04, CLAPR: 0.03, ELAPR: 0.01, EthStoreAPR: &ethStore}, {Day: 1, APR: 0.02}}},
+		{Name: "b", APRHistory: []*types.ValidatorAPRHistory{{Day: 2, APR: 0.06, EthStoreAPR: &ethStore}}},
+	})
+	if len(chart) != 3 || chart[2].Name != "ETH.STORE" {
+		t.Fatalf("expected a series per group and ETH.STORE, got %+v", chart)
+	}
+	if len(chart[0].Data) != 2 || chart[0].Data[0].Y != 4 || chart[0].Data[0].CL != 3 || chart[0].Data[0].EL != 1 {
+		t.Errorf("unexpected group series %+v", chart[0].Data[0])
+	}
+	if len(chart[2].Data) != 1 || chart[2].Data[0].Y != 5 {
+		t.Errorf("unexpected ETH.STORE series %+v", chart[2].Data)
+	}
+}
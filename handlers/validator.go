@@ -628,10 +628,14 @@ func Validator(w http.ResponseWriter, r *http.Request) {
 			rpln.claimed_smoothing_pool     AS claimed_smoothing_pool,
 			rpln.unclaimed_smoothing_pool   AS unclaimed_smoothing_pool,
 			rpln.unclaimed_rpl_rewards      AS unclaimed_rpl_rewards,
-			COALESCE(rpln.smoothing_pool_opted_in, false)    AS smoothing_pool_opted_in 
+			COALESCE(rpln.smoothing_pool_opted_in, false)    AS smoothing_pool_opted_in,
+			rplrp.rpl_collateral            AS projected_rpl,
+			rplrp.smoothing_pool_eth        AS projected_smoothing_pool_eth,
+			rplrp.interval_end              AS projection_interval_end
 		FROM validators
 		LEFT JOIN rocketpool_minipools rplm ON rplm.pubkey = validators.pubkey
 		LEFT JOIN rocketpool_nodes rpln ON rplm.node_address = rpln.address
+		LEFT JOIN rocketpool_reward_projections rplrp ON rplm.node_address = rplrp.node_address
 		WHERE validators.validatorindex = $1`, index)
 	if err == nil && (validatorPageData.Rocketpool.MinipoolAddress != nil || validatorPageData.Rocketpool.NodeAddress != nil) {
 		validatorPageData.IsRocketpool = true
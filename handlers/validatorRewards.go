@@ -42,14 +42,14 @@ func ValidatorRewards(w http.ResponseWriter, r *http.Request) {
 			from information_schema.columns 
 			where table_name = 'price'`)
 	if err != nil {
-		logger.Errorf("error getting eth1-deposits-distribution for stake pools: %w", err)
+		logger.Errorf("error getting eth1-deposits-distribution for stake pools: %v", err)
 	}
 
 	var minTime time.Time
 	err = db.ReaderDb.Get(&minTime,
 		`select ts from price order by ts asc limit 1`)
 	if err != nil {
-		logger.Errorf("error getting min ts: %w", err)
+		logger.Errorf("error getting min ts: %v", err)
 	}
 
 	data.Data = rewardsResp{Currencies: supportedCurrencies, CsrfField: csrf.TemplateField(r), MinDateTimestamp: uint64(minTime.Unix()), ShowSubscriptions: data.User.Authenticated}
@@ -64,7 +64,7 @@ func getUserRewardSubscriptions(uid uint64) [][]string {
 	err := db.FrontendWriterDB.Select(&dbResp,
 		`select * from users_subscriptions where event_name=$1 AND user_id=$2`, strings.ToLower(utils.GetNetwork())+":"+string(types.TaxReportEventName), uid)
 	if err != nil {
-		logger.Errorf("error getting prices: %w", err)
+		logger.Errorf("error getting prices: %v", err)
 	}
 
 	res := make([][]string, len(dbResp))
@@ -91,7 +91,7 @@ func isValidCurrency(currency string) bool {
 		from information_schema.columns 
 		where table_name = 'price' AND column_name=$1;`, currency)
 	if err != nil {
-		logger.Errorf("error checking currency: %w", err)
+		logger.Errorf("error checking currency: %v", err)
 		return false
 	}
 
@@ -175,6 +175,16 @@ func DownloadRewardsHistoricalData(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	switch format := q.Get("format"); format {
+	case "", "pdf":
+	case "csv", "json":
+		downloadRewardLots(w, r, validatorArr, currency, start, end, format)
+		return
+	default:
+		http.Error(w, "Invalid query, unsupported format", 400)
+		return
+	}
+
 	hist := services.GetValidatorHist(validatorArr, currency, start, end)
 
 	if len(hist.History) == 0 {
@@ -197,6 +207,67 @@ func DownloadRewardsHistoricalData(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// downloadRewardLots writes the income of the validators as individual lots, the cost basis timezone and the
+// price granularity (day or month) are read from the timezone and granularity query parameters
+func downloadRewardLots(w http.ResponseWriter, r *http.Request, validators []uint64, currency string, start, end uint64, format string) {
+	q := r.URL.Query()
+
+	location := time.UTC
+	if tz := q.Get("timezone"); tz != "" {
+		var err error
+		location, err = time.LoadLocation(tz)
+		if err != nil {
+			http.Error(w, "Invalid query, unknown timezone", 400)
+			return
+		}
+	}
+
+	granularity := q.Get("granularity")
+	if granularity == "" {
+		granularity = types.RewardLotPriceGranularityDay
+	}
+	if granularity != types.RewardLotPriceGranularityDay && granularity != types.RewardLotPriceGranularityMonth {
+		http.Error(w, "Invalid query, unsupported price granularity", 400)
+		return
+	}
+
+	if !isValidCurrency(strings.ToLower(currency)) {
+		http.Error(w, "Invalid query, unsupported currency", 400)
+		return
+	}
+
+	report, err := services.GetValidatorRewardLots(validators, currency, start, end, location, granularity)
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Error("error getting reward lots")
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+
+	filename := fmt.Sprintf("income_lots_%v_%v.%v", report.Start.Format("20060102"), report.End.Format("20060102"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%v", filename))
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(report)
+		if err != nil {
+			logger.WithError(err).WithField("route", r.URL.String()).Error("error encoding json response")
+		}
+		return
+	}
+
+	data, err := services.GenerateRewardLotsCsv(report)
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Error("error generating csv")
+		http.Error(w, "Internal server error", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	_, err = w.Write(data)
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Error("error writing response")
+	}
+}
+
 func RewardNotificationSubscribe(w http.ResponseWriter, r *http.Request) {
 	SetAutoContentType(w, r)
 	user := getUser(r)
@@ -36,8 +36,11 @@ func SendHTMLMail(to, subject string, msg types.Email, attachment []types.EmailA
 		fmt.Println("Email Attachments will not work with SMTP server")
 		err = SendMailSMTP(to, body.Bytes())
 	} else if utils.Config.Frontend.Mail.Mailgun.PrivateKey != "" {
-		err = renderer.ExecuteTemplate(&body, "layout", MailTemplate{Mail: msg, Domain: utils.Config.Frontend.SiteDomain})
-		content := string(body.Bytes())
+		var content string
+		content, err = RenderHTMLMail(msg)
+		if err != nil {
+			return err
+		}
 		err = SendMailMailgun(to, subject, content, createTextMessage(msg), attachment)
 	} else {
 		logrus.Errorf("error sending reset-email: invalid config for mail-service", err)
@@ -46,6 +49,16 @@ func SendHTMLMail(to, subject string, msg types.Email, attachment []types.EmailA
 	return err
 }
 
+// RenderHTMLMail renders the given message into the html mail layout, exactly as it is sent by SendHTMLMail.
+func RenderHTMLMail(msg types.Email) (string, error) {
+	var body bytes.Buffer
+	err := templates.GetTemplate("mail/layout.html").ExecuteTemplate(&body, "layout", MailTemplate{Mail: msg, Domain: utils.Config.Frontend.SiteDomain})
+	if err != nil {
+		return "", fmt.Errorf("error rendering mail template: %w", err)
+	}
+	return body.String(), nil
+}
+
 // SendMail sends an email to the given address with the given message.
 // It will use smtp if configured otherwise it will use gunmail if configured.
 func SendTextMail(to, subject, msg string, attachment []types.EmailAttachment) error {
@@ -62,7 +75,7 @@ func SendTextMail(to, subject, msg string, attachment []types.EmailAttachment) e
 }
 
 func createTextMessage(msg types.Email) string {
-	return fmt.Sprintf("%s\n\n%s\n\n― You are receiving this because you are staking on Ethermine Staking. You can manage your subscriptions at %s.", msg.Title, msg.Body, msg.SubscriptionManageURL)
+	return fmt.Sprintf("%s\n\n%s\n\n%s", msg.Title, msg.Body, utils.Tr(msg.Lang, "mail_text_footer", string(msg.SubscriptionManageURL)))
 }
 
 // SendMail sends an email to the given address with the given message.
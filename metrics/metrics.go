@@ -1,8 +1,11 @@
 package metrics
 
 import (
+	"bufio"
 	"database/sql"
 	"eth2-exporter/version"
+	"fmt"
+	"net"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -59,6 +62,22 @@ var (
 		Name: "notifications_sent",
 		Help: "Counter of notifications sent with the channel and notification type in the label",
 	}, []string{"channel", "status"})
+	NotificationsQueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "notifications_queue_depth",
+		Help: "Gauge of notifications in the queue with the channel and state (pending, leased, failed) in the label",
+	}, []string{"channel", "state"})
+	NotificationsQueueAge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "notifications_queue_age",
+		Help: "Age in seconds of the oldest undelivered notification with the channel in the label",
+	}, []string{"channel"})
+	NotificationsDeadLettered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notifications_dead_lettered",
+		Help: "Counter of notifications that exhausted all delivery attempts with the channel in the label",
+	}, []string{"channel"})
+	ApiRateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "api_rate_limited",
+		Help: "Counter of api requests rejected by the rate limiter with the plan and the exceeded window (second, month) in the label",
+	}, []string{"package", "window"})
 )
 
 var logger = logrus.New().WithField("module", "metrics")
@@ -133,6 +152,16 @@ func (r *responseWriterDelegator) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// Hijack lets websocket handlers take over the connection
+func (r *responseWriterDelegator) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	r.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
 // Serve serves prometheus metrics on the given address under /metrics
 func Serve(addr string) error {
 	router := http.NewServeMux()
@@ -1,8 +1,11 @@
 package price
 
 import (
-	"encoding/json"
-	"net/http"
+	"context"
+	"eth2-exporter/types"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,62 +14,118 @@ import (
 
 var logger = logrus.New().WithField("module", "price")
 
-type EthPrice struct {
-	Ethereum struct {
-		Cad float64 `json:"cad"`
-		Cny float64 `json:"cny"`
-		Eur float64 `json:"eur"`
-		Jpy float64 `json:"jpy"`
-		Rub float64 `json:"rub"`
-		Usd float64 `json:"usd"`
-		Gbp float64 `json:"gbp"`
-		Aud float64 `json:"aud"`
-	} `json:"ethereum"`
+// DefaultCurrencies are offered if no currencies are configured
+var DefaultCurrencies = []string{"USD", "EUR", "GBP", "CNY", "RUB", "CAD", "AUD", "JPY"}
+
+// defaultSources are queried on mainnet if no sources are configured
+var defaultSources = []string{"coingecko", "kraken", "coinbase"}
+
+const defaultStaleAfter = time.Minute * 10
+
+var currencySymbols = map[string]string{
+	"EUR": "€",
+	"USD": "$",
+	"RUB": "₽",
+	"CNY": "¥",
+	"CAD": "C$",
+	"AUD": "A$",
+	"JPY": "¥",
+	"GBP": "£",
+	"CHF": "CHF",
+	"INR": "₹",
+	"KRW": "₩",
+	"BRL": "R$",
+}
+
+var currencyNames = map[string]string{
+	"EUR": "Euro",
+	"USD": "United States Dollar",
+	"RUB": "Russian Ruble",
+	"CNY": "Chinese Yuan",
+	"CAD": "Canadian Dollar",
+	"AUD": "Australian Dollar",
+	"JPY": "Japanese Yen",
+	"GBP": "Pound Sterling",
+	"CHF": "Swiss Franc",
+	"INR": "Indian Rupee",
+	"KRW": "South Korean Won",
+	"BRL": "Brazilian Real",
 }
 
-var ethPrice = new(EthPrice)
+var ethPrices = map[string]float64{}
+var ethPriceLastUpdated time.Time
 var ethPriceMux = &sync.RWMutex{}
 
-func Init(chainId uint64) {
-	go updateEthPrice(chainId)
+var sources []Source
+var currencies = DefaultCurrencies
+var staleAfter = defaultStaleAfter
+
+// Init configures the price sources and starts updating the ETH price every minute.
+// On chains other than mainnet the price is only retrieved if sources are configured explicitly.
+func Init(chainId uint64, cfg *types.PriceConfig) {
+	Configure(chainId, cfg)
+	go updateEthPrice()
 }
 
-func updateEthPrice(chainId uint64) {
-	errorRetrievingEthPriceCount := 0
-	for {
-		fetchPrice(chainId, &errorRetrievingEthPriceCount)
-		time.Sleep(time.Minute)
+// Configure sets up the price sources and currencies without retrieving the current price, e.g. for the historic price service
+func Configure(chainId uint64, cfg *types.PriceConfig) {
+	var err error
+	sources, err = newSources(chainId, cfg)
+	if err != nil {
+		logger.Fatalf("error initializing price sources: %v", err)
+	}
+
+	if len(cfg.Currencies) > 0 {
+		currencies = make([]string, 0, len(cfg.Currencies))
+		for _, currency := range cfg.Currencies {
+			currencies = append(currencies, strings.ToUpper(strings.TrimSpace(currency)))
+		}
+	}
+	if cfg.StaleAfter > 0 {
+		staleAfter = cfg.StaleAfter
 	}
 }
 
-func fetchPrice(chainId uint64, errorRetrievingEthPriceCount *int) {
-	if chainId != 1 {
-		ethPrice = &EthPrice{
-			Ethereum: struct {
-				Cad float64 "json:\"cad\""
-				Cny float64 "json:\"cny\""
-				Eur float64 "json:\"eur\""
-				Jpy float64 "json:\"jpy\""
-				Rub float64 "json:\"rub\""
-				Usd float64 "json:\"usd\""
-				Gbp float64 "json:\"gbp\""
-				Aud float64 "json:\"aud\""
-			}{
-				Cad: 0,
-				Cny: 0,
-				Eur: 0,
-				Jpy: 0,
-				Rub: 0,
-				Usd: 0,
-				Gbp: 0,
-				Aud: 0,
-			},
+func newSources(chainId uint64, cfg *types.PriceConfig) ([]Source, error) {
+	names := cfg.Sources
+	if len(names) == 0 {
+		if chainId == 1 {
+			names = defaultSources
+		} else if cfg.StaticFile != "" {
+			names = []string{"static"}
 		}
+	}
+
+	res := make([]Source, 0, len(names))
+	for _, name := range names {
+		source, err := NewSource(name, cfg)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, source)
+	}
+	return res, nil
+}
+
+func updateEthPrice() {
+	if len(sources) == 0 {
 		return
 	}
 
-	client := &http.Client{Timeout: time.Second * 10}
-	resp, err := client.Get("https://api.coingecko.com/api/v3/simple/price?ids=ethereum&vs_currencies=usd%2Ceur%2Crub%2Ccny%2Ccad%2Cjpy%2Cgbp%2Caud")
+	errorRetrievingEthPriceCount := 0
+	for {
+		fetchPrice(&errorRetrievingEthPriceCount)
+		time.Sleep(time.Minute)
+	}
+}
+
+func fetchPrice(errorRetrievingEthPriceCount *int) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	prices, err := fetchMedianPrices(ctx, sources, currencies, func(source Source) (map[string]float64, error) {
+		return source.EthPrices(ctx, currencies)
+	})
 	if err != nil {
 		*errorRetrievingEthPriceCount++
 		if *errorRetrievingEthPriceCount <= 3 { // warn 3 times, before throwing errors starting with the fourth time
@@ -75,69 +134,156 @@ func fetchPrice(chainId uint64, errorRetrievingEthPriceCount *int) {
 			logger.Errorf("error (%d) retrieving ETH price: %v", *errorRetrievingEthPriceCount, err)
 		}
 		return
-	} else {
-		*errorRetrievingEthPriceCount = 0
 	}
+	*errorRetrievingEthPriceCount = 0
 
 	ethPriceMux.Lock()
 	defer ethPriceMux.Unlock()
-	defer resp.Body.Close()
+	ethPrices = prices
+	ethPriceLastUpdated = time.Now()
+}
 
-	err = json.NewDecoder(resp.Body).Decode(&ethPrice)
-	if err != nil {
-		logger.Errorf("error decoding ETH price json response to struct: %v", err)
-		return
+// GetHistoricEthPrices returns the median ETH price in the given currencies of all configured sources that support historic prices for the given day.
+// If none of the configured sources supports historic prices CoinGecko is used.
+func GetHistoricEthPrices(day time.Time, currencies []string) (map[string]float64, error) {
+	historicSources := make([]Source, 0, len(sources))
+	for _, source := range sources {
+		if _, ok := source.(HistoricSource); ok {
+			historicSources = append(historicSources, source)
+		}
 	}
+	if len(historicSources) == 0 {
+		historicSources = append(historicSources, &coinGeckoSource{})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	return fetchMedianPrices(ctx, historicSources, currencies, func(source Source) (map[string]float64, error) {
+		return source.(HistoricSource).HistoricEthPrices(ctx, day, currencies)
+	})
 }
 
+// fetchMedianPrices queries all sources concurrently and returns the median price per currency.
+// An error is only returned if none of the sources returned a price.
+func fetchMedianPrices(ctx context.Context, sources []Source, currencies []string, fetch func(source Source) (map[string]float64, error)) (map[string]float64, error) {
+	results := make([]map[string]float64, len(sources))
+	errs := make([]error, len(sources))
+
+	wg := &sync.WaitGroup{}
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source Source) {
+			defer wg.Done()
+			results[i], errs[i] = fetch(source)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("%v: %w", source.Name(), errs[i])
+			}
+		}(i, source)
+	}
+	wg.Wait()
+
+	prices := medianPrices(results, currencies)
+	if len(prices) == 0 {
+		errMsgs := make([]string, 0, len(errs))
+		for _, err := range errs {
+			if err != nil {
+				errMsgs = append(errMsgs, err.Error())
+			}
+		}
+		if len(errMsgs) == 0 {
+			return nil, fmt.Errorf("no prices returned by any source")
+		}
+		return nil, fmt.Errorf("%v", strings.Join(errMsgs, "; "))
+	}
+	for _, err := range errs {
+		if err != nil {
+			logger.Warnf("error retrieving ETH price: %v", err)
+		}
+	}
+	return prices, nil
+}
+
+// medianPrices returns the median of the positive prices per currency, currencies without any price are omitted
+func medianPrices(results []map[string]float64, currencies []string) map[string]float64 {
+	prices := make(map[string]float64, len(currencies))
+	for _, currency := range currencies {
+		values := make([]float64, 0, len(results))
+		for _, result := range results {
+			if result[currency] > 0 {
+				values = append(values, result[currency])
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		sort.Float64s(values)
+		if len(values)%2 == 1 {
+			prices[currency] = values[len(values)/2]
+		} else {
+			prices[currency] = (values[len(values)/2-1] + values[len(values)/2]) / 2
+		}
+	}
+	return prices
+}
+
+// GetEthPrice returns the current ETH price in the given currency. Unknown currencies (e.g. ETH) return 1.
 func GetEthPrice(currency string) float64 {
+	if !IsAvailableCurrency(currency) {
+		return 1
+	}
+
 	ethPriceMux.RLock()
 	defer ethPriceMux.RUnlock()
+	return ethPrices[currency]
+}
 
-	switch currency {
-	case "EUR":
-		return ethPrice.Ethereum.Eur
-	case "USD":
-		return ethPrice.Ethereum.Usd
-	case "RUB":
-		return ethPrice.Ethereum.Rub
-	case "CNY":
-		return ethPrice.Ethereum.Cny
-	case "CAD":
-		return ethPrice.Ethereum.Cad
-	case "AUD":
-		return ethPrice.Ethereum.Aud
-	case "JPY":
-		return ethPrice.Ethereum.Jpy
-	case "GBP":
-		return ethPrice.Ethereum.Gbp
-	default:
-		return 1
+// GetCurrencies returns the configured currencies
+func GetCurrencies() []string {
+	return currencies
+}
+
+// IsAvailableCurrency returns true if the currency is one of the configured currencies
+func IsAvailableCurrency(currency string) bool {
+	for _, c := range currencies {
+		if c == currency {
+			return true
+		}
 	}
+	return false
+}
+
+// LastUpdated returns the time the ETH price was last retrieved successfully
+func LastUpdated() time.Time {
+	ethPriceMux.RLock()
+	defer ethPriceMux.RUnlock()
+	return ethPriceLastUpdated
+}
+
+// IsStale returns true if price sources are configured but the ETH price has not been updated recently
+func IsStale() bool {
+	if len(sources) == 0 {
+		return false
+	}
+	return time.Since(LastUpdated()) > staleAfter
 }
 
 func GetSymbol(currency string) string {
+	if symbol, ok := currencySymbols[currency]; ok {
+		return symbol
+	}
+	if IsAvailableCurrency(currency) {
+		return currency
+	}
+	return ""
+}
 
-	switch currency {
-	case "EUR":
-		return "€"
-	case "USD":
-		return "$"
-	case "RUB":
-		return "₽"
-	case "CNY":
-		return "¥"
-	case "CAD":
-		return "C$"
-	case "AUD":
-		return "A$"
-	case "JPY":
-		return "¥"
-	case "GBP":
-		return "£"
-	default:
-		return ""
+// GetCurrencyName returns the name of the currency, or the currency code if the name is not known
+func GetCurrencyName(currency string) string {
+	if name, ok := currencyNames[currency]; ok {
+		return name
 	}
+	return currency
 }
 
 func GetEthRoundPrice(currency float64) uint64 {
@@ -0,0 +1,88 @@
+package price
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testSource struct {
+	name   string
+	prices map[string]float64
+	err    error
+}
+
+func (s *testSource) Name() string {
+	return s.name
+}
+
+func (s *testSource) EthPrices(ctx context.Context, currencies []string) (map[string]float64, error) {
+	return s.prices, s.err
+}
+
+func TestMedianPrices(t *testing.T) {
+	results := []map[string]float64{
+		{"USD": 1000, "EUR": 900, "GBP": 800},
+		{"USD": 1300, "EUR": 950},
+		{"USD": 1100, "EUR": 0},
+		nil,
+	}
+	got := medianPrices(results, []string{"USD", "EUR", "GBP", "JPY"})
+	// missing and zero prices are ignored, an even number of prices is averaged and currencies without any price are omitted
+	want := map[string]float64{"USD": 1100, "EUR": 925, "GBP": 800}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, expected %v", got, want)
+	}
+}
+
+func TestFetchMedianPrices(t *testing.T) {
+	fetch := func(source Source) (map[string]float64, error) {
+		return source.EthPrices(context.Background(), nil)
+	}
+
+	sources := []Source{
+		&testSource{name: "a", prices: map[string]float64{"USD": 1000}},
+		&testSource{name: "b", err: fmt.Errorf("unavailable")},
+		&testSource{name: "c", prices: map[string]float64{"USD": 3000}},
+	}
+	prices, err := fetchMedianPrices(context.Background(), sources, []string{"USD"}, fetch)
+	if err != nil {
+		t.Fatalf("a failing source must not fail the price: %v", err)
+	}
+	if prices["USD"] != 2000 {
+		t.Errorf("expected the median of the working sources, got %v", prices["USD"])
+	}
+
+	_, err = fetchMedianPrices(context.Background(), []Source{&testSource{name: "b", err: fmt.Errorf("unavailable")}}, []string{"USD"}, fetch)
+	if err == nil {
+		t.Errorf("expected an error if no source returned a price")
+	}
+}
+
+func TestIsStale(t *testing.T) {
+	defer func(s []Source, updated time.Time, after time.Duration) {
+		sources, ethPriceLastUpdated, staleAfter = s, updated, after
+	}(sources, ethPriceLastUpdated, staleAfter)
+	staleAfter = time.Minute * 10
+
+	sources = nil
+	ethPriceLastUpdated = time.Time{}
+	if IsStale() {
+		t.Errorf("a price without sources can not be stale")
+	}
+
+	sources = []Source{&testSource{name: "a"}}
+	if !IsStale() {
+		t.Errorf("expected a price that was never retrieved to be stale")
+	}
+	ethPriceLastUpdated = time.Now().Add(-time.Minute * 5)
+	if IsStale() {
+		t.Errorf("expected a price retrieved 5 minutes ago to be fresh")
+	}
+	ethPriceLastUpdated = time.Now().Add(-time.Minute * 11)
+	if !IsStale() {
+		t.Errorf("expected a price retrieved 11 minutes ago to be stale")
+	}
+}
@@ -0,0 +1,232 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"eth2-exporter/types"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Source retrieves the current ETH price in a set of currencies.
+// Currencies the source does not offer are omitted from the result.
+type Source interface {
+	Name() string
+	EthPrices(ctx context.Context, currencies []string) (map[string]float64, error)
+}
+
+// HistoricSource is a Source that can also retrieve the ETH price of a past day
+type HistoricSource interface {
+	Source
+	HistoricEthPrices(ctx context.Context, day time.Time, currencies []string) (map[string]float64, error)
+}
+
+// NewSource returns the price source with the given name
+func NewSource(name string, cfg *types.PriceConfig) (Source, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "coingecko":
+		return &coinGeckoSource{}, nil
+	case "kraken":
+		return &krakenSource{}, nil
+	case "coinbase":
+		return &coinbaseSource{}, nil
+	case "static":
+		if cfg.StaticFile == "" {
+			return nil, fmt.Errorf("price source static requires a static file")
+		}
+		return &staticSource{path: cfg.StaticFile}, nil
+	default:
+		return nil, fmt.Errorf("unknown price source %v", name)
+	}
+}
+
+var httpClient = &http.Client{Timeout: time.Second * 10}
+
+func getJSON(ctx context.Context, url string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %v from %v", resp.StatusCode, req.URL.Host)
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+type coinGeckoSource struct{}
+
+func (s *coinGeckoSource) Name() string {
+	return "coingecko"
+}
+
+func (s *coinGeckoSource) EthPrices(ctx context.Context, currencies []string) (map[string]float64, error) {
+	res := map[string]map[string]float64{}
+	err := getJSON(ctx, fmt.Sprintf("https://api.coingecko.com/api/v3/simple/price?ids=ethereum&vs_currencies=%s", url.QueryEscape(strings.ToLower(strings.Join(currencies, ",")))), &res)
+	if err != nil {
+		return nil, err
+	}
+	return selectPrices(res["ethereum"], currencies, strings.ToLower), nil
+}
+
+func (s *coinGeckoSource) HistoricEthPrices(ctx context.Context, day time.Time, currencies []string) (map[string]float64, error) {
+	res := struct {
+		MarketData struct {
+			CurrentPrice map[string]float64 `json:"current_price"`
+		} `json:"market_data"`
+	}{}
+	err := getJSON(ctx, fmt.Sprintf("https://api.coingecko.com/api/v3/coins/ethereum/history?date=%s", day.Format("02-01-2006")), &res)
+	if err != nil {
+		return nil, err
+	}
+	return selectPrices(res.MarketData.CurrentPrice, currencies, strings.ToLower), nil
+}
+
+type krakenSource struct{}
+
+func (s *krakenSource) Name() string {
+	return "kraken"
+}
+
+// EthPrices queries the ticker of every currency separately as kraken rejects the whole request if a single pair is unknown
+func (s *krakenSource) EthPrices(ctx context.Context, currencies []string) (map[string]float64, error) {
+	prices := make(map[string]float64, len(currencies))
+	var lastErr error
+	for _, currency := range currencies {
+		res := struct {
+			Error  []string `json:"error"`
+			Result map[string]struct {
+				LastTrade []string `json:"c"`
+			} `json:"result"`
+		}{}
+		err := getJSON(ctx, fmt.Sprintf("https://api.kraken.com/0/public/Ticker?pair=ETH%s", url.QueryEscape(currency)), &res)
+		if err != nil {
+			return nil, err
+		}
+		if len(res.Error) > 0 {
+			lastErr = fmt.Errorf("error retrieving pair ETH%s: %v", currency, strings.Join(res.Error, ", "))
+			continue
+		}
+		for _, ticker := range res.Result {
+			if len(ticker.LastTrade) == 0 {
+				continue
+			}
+			price, err := strconv.ParseFloat(ticker.LastTrade[0], 64)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing price of pair ETH%s: %w", currency, err)
+			}
+			prices[currency] = price
+		}
+	}
+	if len(prices) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return prices, nil
+}
+
+type coinbaseSource struct{}
+
+func (s *coinbaseSource) Name() string {
+	return "coinbase"
+}
+
+func (s *coinbaseSource) EthPrices(ctx context.Context, currencies []string) (map[string]float64, error) {
+	res := struct {
+		Data struct {
+			Rates map[string]string `json:"rates"`
+		} `json:"data"`
+	}{}
+	err := getJSON(ctx, "https://api.coinbase.com/v2/exchange-rates?currency=ETH", &res)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64, len(currencies))
+	for _, currency := range currencies {
+		rate, ok := res.Data.Rates[currency]
+		if !ok {
+			continue
+		}
+		price, err := strconv.ParseFloat(rate, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing price of currency %v: %w", currency, err)
+		}
+		prices[currency] = price
+	}
+	return prices, nil
+}
+
+func (s *coinbaseSource) HistoricEthPrices(ctx context.Context, day time.Time, currencies []string) (map[string]float64, error) {
+	prices := make(map[string]float64, len(currencies))
+	for _, currency := range currencies {
+		res := struct {
+			Data struct {
+				Amount string `json:"amount"`
+			} `json:"data"`
+		}{}
+		err := getJSON(ctx, fmt.Sprintf("https://api.coinbase.com/v2/prices/ETH-%s/spot?date=%s", url.PathEscape(currency), day.Format("2006-01-02")), &res)
+		if err != nil {
+			// unsupported currencies are answered with an error status, the other currencies are still usable
+			logger.Debugf("error retrieving historic coinbase price of currency %v: %v", currency, err)
+			continue
+		}
+		price, err := strconv.ParseFloat(res.Data.Amount, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing price of currency %v: %w", currency, err)
+		}
+		prices[currency] = price
+	}
+	if len(prices) == 0 {
+		return nil, fmt.Errorf("no historic prices for day %v", day.Format("2006-01-02"))
+	}
+	return prices, nil
+}
+
+// staticSource reads the prices from a json file mapping currencies to prices, e.g. {"USD": 1000}.
+// The file is read on every update so it can be changed while the explorer is running, the same prices are used for all past days.
+type staticSource struct {
+	path string
+}
+
+func (s *staticSource) Name() string {
+	return "static"
+}
+
+func (s *staticSource) EthPrices(ctx context.Context, currencies []string) (map[string]float64, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res := map[string]float64{}
+	err = json.NewDecoder(f).Decode(&res)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding static price file %v: %w", s.path, err)
+	}
+	return selectPrices(res, currencies, strings.ToUpper), nil
+}
+
+func (s *staticSource) HistoricEthPrices(ctx context.Context, day time.Time, currencies []string) (map[string]float64, error) {
+	return s.EthPrices(ctx, currencies)
+}
+
+// selectPrices picks the requested currencies from prices, whose keys are formatted by key
+func selectPrices(prices map[string]float64, currencies []string, key func(string) string) map[string]float64 {
+	res := make(map[string]float64, len(currencies))
+	for _, currency := range currencies {
+		if price, ok := prices[key(currency)]; ok {
+			res[currency] = price
+		}
+	}
+	return res
+}
@@ -28,6 +28,9 @@ import (
 // LighthouseLatestHeadEpoch is used to cache the latest head epoch for participation requests
 var LighthouseLatestHeadEpoch uint64 = 0
 
+// CurrentLighthouseClient is used by the frontend to retrieve the duties of epochs which have not been exported yet
+var CurrentLighthouseClient *LighthouseClient
+
 // LighthouseClient holds the Lighthouse client info
 type LighthouseClient struct {
 	endpoint            string
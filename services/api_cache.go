@@ -0,0 +1,35 @@
+package services
+
+import (
+	"eth2-exporter/cache"
+	"eth2-exporter/utils"
+	"fmt"
+	"time"
+)
+
+func apiResponseCacheGenerationKey(epoch uint64) string {
+	return fmt.Sprintf("%d:frontend:apiResponseCacheGeneration:%d", utils.Config.Chain.Config.DepositChainID, epoch)
+}
+
+// ApiResponseCacheGeneration returns the generation of the shared api response cache of an epoch, cached responses of previous generations are not used anymore
+func ApiResponseCacheGeneration(epoch uint64) uint64 {
+	// the generation is not set until the cache of the epoch is invalidated for the first time
+	if wanted, err := cache.TieredCache.GetUint64WithLocalTimeout(apiResponseCacheGenerationKey(epoch), time.Second*5); err == nil {
+		return wanted
+	}
+	return 0
+}
+
+// InvalidateApiResponseCache starts a new generation of the shared api response cache of the epochs, it is called by the exporter
+// when a reorg changed already exported blocks of them
+func InvalidateApiResponseCache(epochs []uint64) error {
+	generation := uint64(time.Now().UnixNano())
+	for _, epoch := range epochs {
+		// the generation has to outlive the cached responses, they expire after a week
+		err := cache.TieredCache.SetUint64(apiResponseCacheGenerationKey(epoch), generation, time.Hour*24*8)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"eth2-exporter/cache"
+	"eth2-exporter/db"
+	"eth2-exporter/rpc"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// epochAssignments are the duties of an epoch as they are retrieved from the node and cached
+type epochAssignments struct {
+	Committees    []*types.ApiEpochCommittee `json:"committees"`
+	Proposers     []*types.ApiEpochProposer  `json:"proposers"`
+	SyncCommittee []uint64                   `json:"sync_committee"`
+}
+
+// getEpochAssignments returns the committees, proposers and sync committee of an epoch from the node, they are cached until the epoch is finalized
+func getEpochAssignments(epoch uint64) (*epochAssignments, error) {
+	cacheKey := fmt.Sprintf("%d:frontend:assignments:%d", utils.Config.Chain.Config.DepositChainID, epoch)
+	if wanted, err := cache.TieredCache.GetWithLocalTimeout(cacheKey, time.Minute, &epochAssignments{}); err == nil {
+		return wanted.(*epochAssignments), nil
+	}
+
+	if rpc.CurrentLighthouseClient == nil {
+		return nil, fmt.Errorf("no beacon node configured")
+	}
+	assignments, err := rpc.CurrentLighthouseClient.GetEpochAssignments(epoch)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving assignments of epoch %v: %w", epoch, err)
+	}
+	res, err := newEpochAssignments(assignments)
+	if err != nil {
+		return nil, err
+	}
+
+	// the proposers of not yet finalized epochs can change with a reorg
+	expiration := time.Duration(utils.Config.Chain.Config.SecondsPerSlot) * time.Second
+	if epoch <= LatestFinalizedEpoch() {
+		expiration = time.Hour * 24
+	}
+	err = cache.TieredCache.Set(cacheKey, res, expiration)
+	if err != nil {
+		logger.Errorf("error caching assignments of epoch %v: %v", epoch, err)
+	}
+	return res, nil
+}
+
+func newEpochAssignments(assignments *types.EpochAssignments) (*epochAssignments, error) {
+	res := &epochAssignments{
+		Committees:    []*types.ApiEpochCommittee{},
+		Proposers:     make([]*types.ApiEpochProposer, 0, len(assignments.ProposerAssignments)),
+		SyncCommittee: assignments.SyncAssignments,
+	}
+
+	committees := map[[2]uint64]*types.ApiEpochCommittee{}
+	for key, validator := range assignments.AttestorAssignments {
+		// the keys are formatted by utils.FormatAttestorAssignmentKey
+		parts := strings.Split(key, "-")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid attestor assignment key %q", key)
+		}
+		var values [3]uint64
+		for i, part := range parts {
+			v, err := strconv.ParseUint(part, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid attestor assignment key %q", key)
+			}
+			values[i] = v
+		}
+		slot, index, position := values[0], values[1], values[2]
+
+		c := committees[[2]uint64{slot, index}]
+		if c == nil {
+			c = &types.ApiEpochCommittee{Slot: slot, Index: index}
+			committees[[2]uint64{slot, index}] = c
+			res.Committees = append(res.Committees, c)
+		}
+		for uint64(len(c.Validators)) <= position {
+			c.Validators = append(c.Validators, 0)
+		}
+		c.Validators[position] = validator
+	}
+	sort.Slice(res.Committees, func(i, j int) bool {
+		if res.Committees[i].Slot != res.Committees[j].Slot {
+			return res.Committees[i].Slot < res.Committees[j].Slot
+		}
+		return res.Committees[i].Index < res.Committees[j].Index
+	})
+
+	for slot, validator := range assignments.ProposerAssignments {
+		res.Proposers = append(res.Proposers, &types.ApiEpochProposer{Slot: slot, Proposer: validator, Status: "0"})
+	}
+	sort.Slice(res.Proposers, func(i, j int) bool { return res.Proposers[i].Slot < res.Proposers[j].Slot })
+	return res, nil
+}
+
+// storedAttestationAssignments returns the attestation assignments the exporter stored for an epoch, tests replace it as there is no bigtable
+var storedAttestationAssignments = func(epoch uint64) (map[string]uint64, error) {
+	return db.BigtableClient.GetAttestationAssignments(epoch)
+}
+
+// GetEpochCommittees returns the attestation committees of an epoch, the epoch can be at most one epoch after the latest epoch.
+// The committees of exported epochs are read from the stored assignments, the node is only asked for the committees of the next epoch
+// and of epochs which were exported before the committee positions were stored.
+func GetEpochCommittees(epoch uint64) ([]*types.ApiEpochCommittee, error) {
+	if epoch <= LatestEpoch() {
+		cacheKey := fmt.Sprintf("%d:frontend:committees:%d", utils.Config.Chain.Config.DepositChainID, epoch)
+		if wanted, err := cache.TieredCache.GetWithLocalTimeout(cacheKey, time.Minute, &[]*types.ApiEpochCommittee{}); err == nil {
+			return *wanted.(*[]*types.ApiEpochCommittee), nil
+		}
+
+		committees, err := getStoredEpochCommittees(epoch)
+		if err != nil {
+			return nil, err
+		}
+		if committees != nil {
+			// the committees of an exported epoch only change with a reorg across the epoch boundary
+			expiration := time.Duration(utils.Config.Chain.Config.SecondsPerSlot*utils.Config.Chain.Config.SlotsPerEpoch) * time.Second
+			if epoch <= LatestFinalizedEpoch() {
+				expiration = time.Hour * 24
+			}
+			err = cache.TieredCache.Set(cacheKey, &committees, expiration)
+			if err != nil {
+				logger.Errorf("error caching committees of epoch %v: %v", epoch, err)
+			}
+			return committees, nil
+		}
+	}
+
+	assignments, err := getEpochAssignments(epoch)
+	if err != nil {
+		return nil, err
+	}
+	return assignments.Committees, nil
+}
+
+// getStoredEpochCommittees returns the committees of an exported epoch from the stored assignments, nil is returned if they were
+// stored without the committee positions
+func getStoredEpochCommittees(epoch uint64) ([]*types.ApiEpochCommittee, error) {
+	stored, err := storedAttestationAssignments(epoch)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving stored assignments of epoch %v: %w", epoch, err)
+	}
+	if len(stored) == 0 {
+		return nil, nil
+	}
+	assignments, err := newEpochAssignments(&types.EpochAssignments{AttestorAssignments: stored})
+	if err != nil {
+		return nil, err
+	}
+	return assignments.Committees, nil
+}
+
+// GetEpochProposers returns the proposer duties of an epoch.
+// Exported epochs are read from the blocks table, the duties of the next epoch are retrieved from the node.
+func GetEpochProposers(epoch uint64) ([]*types.ApiEpochProposer, error) {
+	if epoch > LatestEpoch() {
+		assignments, err := getEpochAssignments(epoch)
+		if err != nil {
+			return nil, err
+		}
+		return assignments.Proposers, nil
+	}
+
+	proposers := []*types.ApiEpochProposer{}
+	// orphaned blocks are only returned if there is no canonical block or missed proposal in the slot
+	err := db.ReaderDb.Select(&proposers, `
+		SELECT DISTINCT ON (slot) slot, proposer, status
+		FROM blocks
+		WHERE epoch = $1
+		ORDER BY slot, status = '3'`, epoch)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving proposers of epoch %v: %w", epoch, err)
+	}
+	return proposers, nil
+}
+
+// GetValidatorDuties returns the attestation, proposal and sync committee duties of a validator in the epochs from fromEpoch to toEpoch.
+// Duties of exported epochs are read from the stored data, duties of the next epoch are retrieved from the node.
+func GetValidatorDuties(validator, fromEpoch, toEpoch uint64) (*types.ApiValidatorDuties, error) {
+	duties := &types.ApiValidatorDuties{
+		ValidatorIndex: validator,
+		Attestations:   []*types.ApiValidatorAttestationDuty{},
+		Proposals:      []*types.ApiEpochProposer{},
+		SyncCommittee:  []*types.ApiValidatorSyncDuty{},
+	}
+
+	latestEpoch := LatestEpoch()
+	if fromEpoch <= latestEpoch {
+		end := toEpoch
+		if end > latestEpoch {
+			end = latestEpoch
+		}
+		limit := int64(end - fromEpoch + 1)
+
+		attestations, err := db.BigtableClient.GetValidatorAttestationHistory([]uint64{validator}, end, limit)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving attestations of validator %v: %w", validator, err)
+		}
+		for _, a := range attestations[validator] {
+			duties.Attestations = append(duties.Attestations, &types.ApiValidatorAttestationDuty{
+				Epoch:         a.Epoch,
+				AttesterSlot:  a.AttesterSlot,
+				Status:        a.Status,
+				InclusionSlot: a.InclusionSlot,
+			})
+		}
+
+		err = db.ReaderDb.Select(&duties.Proposals, `
+			SELECT slot, proposer, status
+			FROM blocks
+			WHERE proposer = $1 AND epoch BETWEEN $2 AND $3
+			ORDER BY slot`, validator, fromEpoch, end)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving proposals of validator %v: %w", validator, err)
+		}
+
+		syncDuties, err := db.BigtableClient.GetValidatorSyncDutiesHistory([]uint64{validator}, end, limit)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving sync duties of validator %v: %w", validator, err)
+		}
+		for _, s := range syncDuties[validator] {
+			duties.SyncCommittee = append(duties.SyncCommittee, &types.ApiValidatorSyncDuty{
+				Slot:   s.Slot,
+				Status: s.Status,
+			})
+		}
+	}
+
+	scheduledFrom := latestEpoch + 1
+	if fromEpoch > scheduledFrom {
+		scheduledFrom = fromEpoch
+	}
+	for epoch := scheduledFrom; epoch <= toEpoch; epoch++ {
+		assignments, err := getEpochAssignments(epoch)
+		if err != nil {
+			return nil, err
+		}
+		addScheduledValidatorDuties(duties, assignments, epoch)
+	}
+
+	sort.Slice(duties.Attestations, func(i, j int) bool { return duties.Attestations[i].AttesterSlot < duties.Attestations[j].AttesterSlot })
+	sort.Slice(duties.SyncCommittee, func(i, j int) bool { return duties.SyncCommittee[i].Slot < duties.SyncCommittee[j].Slot })
+	return duties, nil
+}
+
+// addScheduledValidatorDuties adds the duties of the validator of duties in the epoch from the assignments of the node
+func addScheduledValidatorDuties(duties *types.ApiValidatorDuties, assignments *epochAssignments, epoch uint64) {
+	validator := duties.ValidatorIndex
+	for _, c := range assignments.Committees {
+		for _, v := range c.Validators {
+			if v == validator {
+				index := c.Index
+				duties.Attestations = append(duties.Attestations, &types.ApiValidatorAttestationDuty{
+					Epoch:          epoch,
+					AttesterSlot:   c.Slot,
+					CommitteeIndex: &index,
+					Scheduled:      true,
+				})
+				break
+			}
+		}
+	}
+	for _, p := range assignments.Proposers {
+		if p.Proposer == validator {
+			duties.Proposals = append(duties.Proposals, p)
+		}
+	}
+	for _, v := range assignments.SyncCommittee {
+		if v == validator {
+			firstSlot := epoch * utils.Config.Chain.Config.SlotsPerEpoch
+			for slot := firstSlot; slot < firstSlot+utils.Config.Chain.Config.SlotsPerEpoch; slot++ {
+				duties.SyncCommittee = append(duties.SyncCommittee, &types.ApiValidatorSyncDuty{Slot: slot, Scheduled: true})
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"reflect"
+	"testing"
+)
+
+func TestEpochAssignments(t *testing.T) {
+	chainConfig := utils.Config.Chain.Config
+	defer func() { utils.Config.Chain.Config = chainConfig }()
+	utils.Config.Chain.Config.SlotsPerEpoch = 2
+
+	assignments, err := newEpochAssignments(&types.EpochAssignments{
+		ProposerAssignments: map[uint64]uint64{21: 7, 20: 3},
+		AttestorAssignments: map[string]uint64{
+			utils.FormatAttestorAssignmentKey(21, 0, 1): 4,
+			utils.FormatAttestorAssignmentKey(20, 1, 0): 5,
+			utils.FormatAttestorAssignmentKey(21, 0, 0): 7,
+			utils.FormatAttestorAssignmentKey(20, 0, 0): 3,
+		},
+		SyncAssignments: []uint64{4, 5},
+	})
+	if err != nil {
+		t.Fatalf("error converting assignments: %v", err)
+	}
+
+	wantCommittees := []*types.ApiEpochCommittee{
+		{Slot: 20, Index: 0, Validators: []uint64{3}},
+		{Slot: 20, Index: 1, Validators: []uint64{5}},
+		{Slot: 21, Index: 0, Validators: []uint64{7, 4}},
+	}
+	if !reflect.DeepEqual(assignments.Committees, wantCommittees) {
+		t.Errorf("unexpected committees %+v", assignments.Committees)
+	}
+	if len(assignments.Proposers) != 2 || assignments.Proposers[0].Slot != 20 || assignments.Proposers[1].Proposer != 7 {
+		t.Errorf("unexpected proposers %+v", assignments.Proposers)
+	}
+
+	duties := &types.ApiValidatorDuties{ValidatorIndex: 4}
+	addScheduledValidatorDuties(duties, assignments, 10)
+	if len(duties.Attestations) != 1 || duties.Attestations[0].AttesterSlot != 21 || *duties.Attestations[0].CommitteeIndex != 0 || !duties.Attestations[0].Scheduled {
+		t.Errorf("unexpected attestation duties %+v", duties.Attestations)
+	}
+	if len(duties.Proposals) != 0 {
+		t.Errorf("unexpected proposal duties %+v", duties.Proposals)
+	}
+	if len(duties.SyncCommittee) != 2 || duties.SyncCommittee[0].Slot != 20 || duties.SyncCommittee[1].Slot != 21 {
+		t.Errorf("unexpected sync duties %+v", duties.SyncCommittee)
+	}
+
+	if _, err := newEpochAssignments(&types.EpochAssignments{AttestorAssignments: map[string]uint64{"1-2": 1}}); err == nil {
+		t.Errorf("expected an error for an invalid assignment key")
+	}
+}
+
+func TestStoredEpochCommittees(t *testing.T) {
+	defer func(stored func(uint64) (map[string]uint64, error)) { storedAttestationAssignments = stored }(storedAttestationAssignments)
+
+	storedAttestationAssignments = func(epoch uint64) (map[string]uint64, error) {
+		if epoch != 10 {
+			// exported before the committee positions were stored
+			return map[string]uint64{}, nil
+		}
+		return map[string]uint64{
+			utils.FormatAttestorAssignmentKey(320, 1, 0): 9,
+			utils.FormatAttestorAssignmentKey(320, 0, 1): 2,
+			utils.FormatAttestorAssignmentKey(320, 0, 0): 5,
+		}, nil
+	}
+
+	committees, err := getStoredEpochCommittees(10)
+	if err != nil {
+		t.Fatalf("error retrieving stored committees: %v", err)
+	}
+	want := []*types.ApiEpochCommittee{
+		{Slot: 320, Index: 0, Validators: []uint64{5, 2}},
+		{Slot: 320, Index: 1, Validators: []uint64{9}},
+	}
+	if !reflect.DeepEqual(committees, want) {
+		t.Errorf("unexpected committees %+v", committees)
+	}
+
+	committees, err = getStoredEpochCommittees(11)
+	if err != nil || committees != nil {
+		t.Errorf("expected no committees for an epoch without stored positions, got %+v, %v", committees, err)
+	}
+}
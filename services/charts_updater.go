@@ -1580,15 +1580,20 @@ func poolsDistributionChartData() (*types.GenericChartData, error) {
 		Address   string `json:"address"`
 		Y         uint64 `json:"y"`
 		Drilldown string `json:"drilldown"`
+		// Attributed is the number of validators that were attributed by heuristics with an average confidence of Confidence
+		Attributed uint64  `json:"attributed"`
+		Confidence float64 `json:"confidence"`
 	}
 
 	rows := []struct {
-		Name  string
-		Count uint64
+		Name       string
+		Count      uint64
+		Attributed uint64
+		Confidence float64
 	}{}
 
 	err = db.ReaderDb.Select(&rows, `
-	select coalesce(pool, 'Unknown') as name, count(*) as count from validators left outer join validator_pool on validators.pubkey = validator_pool.publickey where validators.status in ('active_online', 'active_offline') group by pool order by count(*) desc`)
+	select coalesce(pool, 'Unknown') as name, count(*) as count, count(confidence) as attributed, coalesce(avg(confidence), 0) as confidence from validators left outer join validator_pool on validators.pubkey = validator_pool.publickey where validators.status in ('active_online', 'active_offline') group by pool order by count(*) desc`)
 	if err != nil {
 		return nil, fmt.Errorf("error getting eth1-deposits-distribution: %w", err)
 	}
@@ -1596,8 +1601,10 @@ func poolsDistributionChartData() (*types.GenericChartData, error) {
 
 	for _, row := range rows {
 		seriesData = append(seriesData, seriesDataItem{
-			Name: row.Name,
-			Y:    row.Count,
+			Name:       row.Name,
+			Y:          row.Count,
+			Attributed: row.Attributed,
+			Confidence: row.Confidence,
 		})
 	}
 
@@ -1606,7 +1613,7 @@ func poolsDistributionChartData() (*types.GenericChartData, error) {
 		Type:             "pie",
 		Title:            "Pool Distribution",
 		Subtitle:         "Validator distribution by staking pool.",
-		TooltipFormatter: `function(){ return '<b>'+this.point.name+'</b><br\>Percentage: '+this.point.percentage.toFixed(2)+'%<br\>Validators: '+this.point.y }`,
+		TooltipFormatter: `function(){ return '<b>'+this.point.name+'</b><br\>Percentage: '+this.point.percentage.toFixed(2)+'%<br\>Validators: '+this.point.y+(this.point.attributed ? '<br\>Attributed by heuristics: '+this.point.attributed+' (avg. confidence '+(this.point.confidence*100).toFixed(0)+'%)' : '') }`,
 		PlotOptionsPie: `{
 			borderWidth: 1,
 			borderColor: null, 
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"eth2-exporter/cache"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"sort"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// HeatmapTileSize is the width and height of a heatmap tile in pixels
+const HeatmapTileSize = 256
+
+// HeatmapMaxZoom is the most detailed zoom level, zoom level 0 shows 1024 epochs and validators per tile
+const HeatmapMaxZoom = 4
+
+const (
+	heatmapSetCacheExpiration       = time.Hour * 24 * 7
+	heatmapFinalTileCacheExpiration = time.Hour * 24 * 7
+	heatmapTileCacheExpiration      = time.Hour
+	heatmapTileCacheLocalExpiration = time.Minute
+	// the bigtable history functions read every validator of the chain above 1000 validators
+	heatmapValidatorBatchSize = 500
+)
+
+var ErrHeatmapTileNotFound = errors.New("heatmap tile not found")
+
+// heatmapCell is the duty outcome of a validator in an epoch, higher values take precedence
+// when multiple duties fall into the same cell or cells are merged on lower zoom levels
+type heatmapCell uint8
+
+const (
+	heatmapCellNone heatmapCell = iota
+	heatmapCellAttested
+	heatmapCellSync
+	heatmapCellProposed
+	heatmapCellMissedSync
+	heatmapCellMissedAttestation
+	heatmapCellMissedProposal
+)
+
+var heatmapPalette = map[heatmapCell]color.RGBA{
+	heatmapCellNone:              {0, 0, 0, 0},
+	heatmapCellAttested:          {40, 167, 69, 255},
+	heatmapCellSync:              {23, 162, 184, 255},
+	heatmapCellProposed:          {48, 96, 207, 255},
+	heatmapCellMissedSync:        {253, 126, 20, 255},
+	heatmapCellMissedAttestation: {220, 53, 69, 255},
+	heatmapCellMissedProposal:    {111, 66, 193, 255},
+}
+
+// HeatmapCellsPerTile returns the number of epochs and validators a tile covers on the zoom level
+func HeatmapCellsPerTile(zoom int) int {
+	return 1024 >> zoom
+}
+
+// StoreHeatmapValidatorSet stores the validators so tiles can reference them by a short id
+func StoreHeatmapValidatorSet(validators []uint64) (string, error) {
+	sorted := make([]uint64, len(validators))
+	copy(sorted, validators)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	hash := sha256.New()
+	for _, v := range sorted {
+		binary.Write(hash, binary.BigEndian, v)
+	}
+	id := hex.EncodeToString(hash.Sum(nil)[:16])
+
+	err := cache.TieredCache.Set(heatmapSetCacheKey(id), sorted, heatmapSetCacheExpiration)
+	if err != nil {
+		return "", fmt.Errorf("error storing heatmap validator set: %w", err)
+	}
+	return id, nil
+}
+
+func heatmapSetCacheKey(id string) string {
+	return fmt.Sprintf("%d:frontend:heatmapSet:%s", utils.Config.Chain.Config.DepositChainID, id)
+}
+
+// GetDutyHeatmapTile returns the tile as png. Tiles are addressed by the epoch (x) and the position of the validator in the set (y).
+// Final is set if the tile only covers finalized epochs and will not change anymore.
+func GetDutyHeatmapTile(setID string, zoom, x, y int) (tile []byte, final bool, err error) {
+	if zoom < 0 || zoom > HeatmapMaxZoom || x < 0 || y < 0 {
+		return nil, false, ErrHeatmapTileNotFound
+	}
+
+	wanted, err := cache.TieredCache.GetWithLocalTimeout(heatmapSetCacheKey(setID), heatmapTileCacheLocalExpiration, &[]uint64{})
+	if err != nil {
+		return nil, false, ErrHeatmapTileNotFound
+	}
+	set := *wanted.(*[]uint64)
+
+	cellsPerTile := HeatmapCellsPerTile(zoom)
+	if y*cellsPerTile >= len(set) {
+		return nil, false, ErrHeatmapTileNotFound
+	}
+	validators := set[y*cellsPerTile:]
+	if len(validators) > cellsPerTile {
+		validators = validators[:cellsPerTile]
+	}
+
+	startEpoch := uint64(x * cellsPerTile)
+	endEpoch := startEpoch + uint64(cellsPerTile) - 1
+	finalizedEpoch := LatestFinalizedEpoch()
+	final = endEpoch <= finalizedEpoch
+
+	cacheKey := fmt.Sprintf("%d:frontend:heatmapTile:%s:%d:%d:%d", utils.Config.Chain.Config.DepositChainID, setID, zoom, x, y)
+	expiration := heatmapFinalTileCacheExpiration
+	if !final {
+		cacheKey = fmt.Sprintf("%s:%d", cacheKey, finalizedEpoch)
+		expiration = heatmapTileCacheExpiration
+	}
+	if cached, err := cache.TieredCache.GetStringWithLocalTimeout(cacheKey, heatmapTileCacheLocalExpiration); err == nil {
+		return []byte(cached), final, nil
+	}
+
+	cells := make([][]heatmapCell, len(validators))
+	for i := range cells {
+		cells[i] = make([]heatmapCell, cellsPerTile)
+	}
+	if startEpoch <= finalizedEpoch {
+		if endEpoch > finalizedEpoch {
+			endEpoch = finalizedEpoch
+		}
+		err = loadDutyHeatmapCells(cells, validators, startEpoch, endEpoch)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	tile, err = renderDutyHeatmapTile(cells, cellsPerTile)
+	if err != nil {
+		return nil, false, err
+	}
+	err = cache.TieredCache.SetString(cacheKey, string(tile), expiration)
+	if err != nil {
+		logger.Errorf("error caching heatmap tile: %v", err)
+	}
+	return tile, final, nil
+}
+
+// loadDutyHeatmapCells fills the cells of the validators (rows) between startEpoch and endEpoch (columns)
+func loadDutyHeatmapCells(cells [][]heatmapCell, validators []uint64, startEpoch, endEpoch uint64) error {
+	rows := make(map[uint64]int, len(validators))
+	for i, v := range validators {
+		rows[v] = i
+	}
+	slotsPerEpoch := utils.Config.Chain.Config.SlotsPerEpoch
+
+	// the history is read from endEpoch down to, but excluding, endEpoch - limit
+	limit := int64(endEpoch-startEpoch) + 1
+	if startEpoch == 0 {
+		limit = int64(endEpoch)
+	}
+
+	// the cells of a batch do not overlap with the cells of other batches, but all duty types of a batch write to the same cells
+	g := errgroup.Group{}
+	for start := 0; start < len(validators); start += heatmapValidatorBatchSize {
+		end := start + heatmapValidatorBatchSize
+		if end > len(validators) {
+			end = len(validators)
+		}
+		batch := validators[start:end]
+		g.Go(func() error {
+			attestations, err := db.BigtableClient.GetValidatorAttestationHistory(batch, endEpoch, limit)
+			if err != nil {
+				return fmt.Errorf("error getting attestation history: %w", err)
+			}
+			proposals, err := db.BigtableClient.GetValidatorProposalHistory(batch, endEpoch, limit)
+			if err != nil {
+				return fmt.Errorf("error getting proposal history: %w", err)
+			}
+			syncDuties, err := db.BigtableClient.GetValidatorSyncDutiesHistory(batch, endEpoch, limit)
+			if err != nil {
+				return fmt.Errorf("error getting sync duties history: %w", err)
+			}
+
+			set := func(validator, epoch uint64, cell heatmapCell) {
+				row, ok := rows[validator]
+				if !ok || epoch < startEpoch || epoch > endEpoch {
+					return
+				}
+				if col := epoch - startEpoch; cells[row][col] < cell {
+					cells[row][col] = cell
+				}
+			}
+			for validator, history := range attestations {
+				for _, a := range history {
+					set(validator, a.Epoch, heatmapAttestationCell(a))
+				}
+			}
+			for validator, history := range proposals {
+				for _, p := range history {
+					set(validator, p.Slot/slotsPerEpoch, heatmapProposalCell(p))
+				}
+			}
+			for validator, history := range syncDuties {
+				for _, s := range history {
+					set(validator, s.Slot/slotsPerEpoch, heatmapSyncCell(s))
+				}
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+func heatmapAttestationCell(a *types.ValidatorAttestation) heatmapCell {
+	if a.Status == 1 {
+		return heatmapCellAttested
+	}
+	return heatmapCellMissedAttestation
+}
+
+func heatmapProposalCell(p *types.ValidatorProposal) heatmapCell {
+	if p.Status == 1 {
+		return heatmapCellProposed
+	}
+	return heatmapCellMissedProposal
+}
+
+func heatmapSyncCell(s *types.ValidatorSyncParticipation) heatmapCell {
+	if s.Status == 1 {
+		return heatmapCellSync
+	}
+	return heatmapCellMissedSync
+}
+
+// renderDutyHeatmapTile draws the cells of a tile, merging cells into a single pixel if the tile covers more cells than it has pixels
+func renderDutyHeatmapTile(cells [][]heatmapCell, cellsPerTile int) ([]byte, error) {
+	merge, scale := 1, 1
+	if cellsPerTile > HeatmapTileSize {
+		merge = cellsPerTile / HeatmapTileSize
+	} else {
+		scale = HeatmapTileSize / cellsPerTile
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, HeatmapTileSize, HeatmapTileSize))
+	for py := 0; py*merge < len(cells) && py*scale < HeatmapTileSize; py++ {
+		for px := 0; px*scale < HeatmapTileSize; px++ {
+			cell := heatmapCellNone
+			for row := py * merge; row < (py+1)*merge && row < len(cells); row++ {
+				for col := px * merge; col < (px+1)*merge && col < len(cells[row]); col++ {
+					if cells[row][col] > cell {
+						cell = cells[row][col]
+					}
+				}
+			}
+			if cell == heatmapCellNone {
+				continue
+			}
+			c := heatmapPalette[cell]
+			for y := py * scale; y < (py+1)*scale; y++ {
+				for x := px * scale; x < (px+1)*scale; x++ {
+					img.SetRGBA(x, y, c)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
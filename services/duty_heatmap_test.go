@@ -0,0 +1,64 @@
+package services
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+func TestRenderDutyHeatmapTile(t *testing.T) {
+	// zoomed out, 4x4 cells are merged into a pixel and the most severe outcome wins
+	cells := make([][]heatmapCell, 8)
+	for i := range cells {
+		cells[i] = make([]heatmapCell, 1024)
+		for j := range cells[i] {
+			cells[i][j] = heatmapCellAttested
+		}
+	}
+	cells[5][6] = heatmapCellMissedAttestation
+	cells[6][5] = heatmapCellProposed
+
+	tile, err := renderDutyHeatmapTile(cells, 1024)
+	if err != nil {
+		t.Fatalf("error rendering tile: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(tile))
+	if err != nil {
+		t.Fatalf("error decoding tile: %v", err)
+	}
+	if img.Bounds().Dx() != HeatmapTileSize || img.Bounds().Dy() != HeatmapTileSize {
+		t.Fatalf("unexpected tile size %v", img.Bounds())
+	}
+
+	tests := []struct {
+		x, y int
+		want heatmapCell
+	}{
+		{0, 0, heatmapCellAttested},
+		{1, 1, heatmapCellMissedAttestation},
+		{2, 1, heatmapCellAttested},
+		{0, 2, heatmapCellNone},
+	}
+	for _, tt := range tests {
+		r, g, b, a := img.At(tt.x, tt.y).RGBA()
+		want := heatmapPalette[tt.want]
+		if uint8(r>>8) != want.R || uint8(g>>8) != want.G || uint8(b>>8) != want.B || uint8(a>>8) != want.A {
+			t.Errorf("pixel %v,%v: got %v,%v,%v,%v, want %v", tt.x, tt.y, r>>8, g>>8, b>>8, a>>8, want)
+		}
+	}
+
+	// zoomed in, a cell spans multiple pixels
+	tile, err = renderDutyHeatmapTile([][]heatmapCell{make([]heatmapCell, 64)}, 64)
+	if err != nil {
+		t.Fatalf("error rendering tile: %v", err)
+	}
+	if _, err := png.Decode(bytes.NewReader(tile)); err != nil {
+		t.Fatalf("error decoding tile: %v", err)
+	}
+}
+
+func TestHeatmapCellsPerTile(t *testing.T) {
+	if HeatmapCellsPerTile(0) != 1024 || HeatmapCellsPerTile(2) != HeatmapTileSize || HeatmapCellsPerTile(HeatmapMaxZoom) != 64 {
+		t.Errorf("unexpected cells per tile")
+	}
+}
@@ -0,0 +1,434 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"eth2-exporter/cache"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// number of events that can be queued for a subscriber before it is dropped
+const eventSubscriptionBuffer = 256
+
+// maximum number of epochs or slots the updaters publish events for at once, e.g. after a restart
+const maxEventBacklog = 64
+
+// EventSubscription receives the events of its topics on C. C is closed when the subscription
+// is closed or when the subscriber does not keep up with the published events.
+type EventSubscription struct {
+	C chan *types.Event
+
+	mux    sync.Mutex
+	topics map[string]bool
+	closed bool
+}
+
+var eventHub = struct {
+	sync.RWMutex
+	subscriptions map[string]map[*EventSubscription]bool
+}{subscriptions: make(map[string]map[*EventSubscription]bool)}
+
+var eventBridge *cache.RedisCache
+
+// eventStreamDisabled is set if the events of the updaters can not reach this process, subscribers would never receive an event
+var eventStreamDisabled bool
+
+func NewEventSubscription() *EventSubscription {
+	return &EventSubscription{
+		C:      make(chan *types.Event, eventSubscriptionBuffer),
+		topics: make(map[string]bool),
+	}
+}
+
+func (s *EventSubscription) Subscribe(topics ...string) {
+	eventHub.Lock()
+	defer eventHub.Unlock()
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.closed {
+		return
+	}
+	for _, topic := range topics {
+		s.topics[topic] = true
+		if eventHub.subscriptions[topic] == nil {
+			eventHub.subscriptions[topic] = make(map[*EventSubscription]bool)
+		}
+		eventHub.subscriptions[topic][s] = true
+	}
+}
+
+func (s *EventSubscription) Unsubscribe(topics ...string) {
+	eventHub.Lock()
+	defer eventHub.Unlock()
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for _, topic := range topics {
+		s.unsubscribe(topic)
+	}
+}
+
+// unsubscribe has to be called with the locks of the hub and the subscription held
+func (s *EventSubscription) unsubscribe(topic string) {
+	delete(s.topics, topic)
+	delete(eventHub.subscriptions[topic], s)
+	if len(eventHub.subscriptions[topic]) == 0 {
+		delete(eventHub.subscriptions, topic)
+	}
+}
+
+// Topics returns the sorted topics of the subscription
+func (s *EventSubscription) Topics() []string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	topics := make([]string, 0, len(s.topics))
+	for topic := range s.topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+func (s *EventSubscription) Close() {
+	eventHub.Lock()
+	defer eventHub.Unlock()
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.closed {
+		return
+	}
+	for topic := range s.topics {
+		s.unsubscribe(topic)
+	}
+	s.closed = true
+	close(s.C)
+}
+
+// PublishEvent sends an event to all subscribers of topic. If the redis bridge is initialized the event is
+// published over redis and delivered by every instance that is connected to the bridge, including this one.
+// If the event can not be published to redis it is still delivered to the subscribers of this instance.
+func PublishEvent(topic, eventType string, data interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		logger.Errorf("error marshalling %v event: %v", eventType, err)
+		return
+	}
+	e := &types.Event{Topic: topic, Type: eventType, Data: raw}
+
+	if eventBridge != nil {
+		msg, err := json.Marshal(e)
+		if err != nil {
+			logger.Errorf("error marshalling %v event: %v", eventType, err)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+		err = eventBridge.Publish(ctx, eventBridgeChannel(), msg)
+		if err == nil {
+			return
+		}
+		logger.Errorf("error publishing %v event to redis, delivering it to local subscribers only: %v", eventType, err)
+	}
+	deliverEvent(e)
+}
+
+// HasEventSubscribers returns whether a subscriber of this instance is subscribed to topic
+func HasEventSubscribers(topic string) bool {
+	eventHub.RLock()
+	defer eventHub.RUnlock()
+	return len(eventHub.subscriptions[topic]) > 0
+}
+
+func deliverEvent(e *types.Event) {
+	eventHub.RLock()
+	dropped := []*EventSubscription{}
+	for s := range eventHub.subscriptions[e.Topic] {
+		select {
+		case s.C <- e:
+		default:
+			dropped = append(dropped, s)
+		}
+	}
+	eventHub.RUnlock()
+
+	for _, s := range dropped {
+		logger.Warnf("dropping event subscription of topics %v, the subscriber does not keep up", s.Topics())
+		s.Close()
+	}
+
+	if e.Type == types.EventTypeFinalizedEpoch {
+		epoch := &types.EventFinalizedEpoch{}
+		err := json.Unmarshal(e.Data, epoch)
+		if err != nil {
+			logger.Errorf("error unmarshalling finalized epoch event: %v", err)
+			return
+		}
+		go deliverAttestationEvents(epoch.Epoch)
+	}
+}
+
+// deliverAttestationEvents sends the attestation duties of a finalized epoch to the local subscribers of validator topics.
+// Attestations are not published by the updater as that would mean an event for every validator in every epoch.
+func deliverAttestationEvents(epoch uint64) {
+	eventHub.RLock()
+	validators := []uint64{}
+	for topic := range eventHub.subscriptions {
+		if index, err := strconv.ParseUint(strings.TrimPrefix(topic, types.EventTopicValidatorPrefix), 10, 64); err == nil && strings.HasPrefix(topic, types.EventTopicValidatorPrefix) {
+			validators = append(validators, index)
+		}
+	}
+	eventHub.RUnlock()
+
+	if len(validators) == 0 || db.BigtableClient == nil {
+		return
+	}
+
+	history, err := db.BigtableClient.GetValidatorAttestationHistory(validators, epoch, 1)
+	if err != nil {
+		logger.Errorf("error retrieving attestation history of epoch %v for events: %v", epoch, err)
+		return
+	}
+	for validator, attestations := range history {
+		for _, a := range attestations {
+			data, err := json.Marshal(&types.EventValidatorAttestation{
+				Validator:     validator,
+				Epoch:         a.Epoch,
+				AttesterSlot:  a.AttesterSlot,
+				Status:        a.Status,
+				InclusionSlot: a.InclusionSlot,
+				Delay:         a.Delay,
+			})
+			if err != nil {
+				logger.Errorf("error marshalling attestation event: %v", err)
+				continue
+			}
+			deliverEvent(&types.Event{Topic: validatorEventTopic(validator), Type: types.EventTypeAttestation, Data: data})
+		}
+	}
+}
+
+// InitEventStream prepares the event hub of a process. Whenever a redis endpoint is configured the hub is connected to the redis bridge,
+// without one events are only delivered in-process. As the events are published by the updaters of Init a process that does not
+// run them in-process can not serve event subscribers without the bridge, the event stream of such a process stays disabled.
+func InitEventStream(redisAddress string, updatersInProcess bool) error {
+	eventStreamDisabled = !updatersInProcess
+	if redisAddress == "" {
+		if !updatersInProcess {
+			logger.Warnf("no redis endpoint configured, the event stream is disabled as the events are published by the frontend data updater")
+			return nil
+		}
+		logger.Warnf("no redis endpoint configured, events are only delivered to subscribers of this instance")
+		return nil
+	}
+	err := InitEventBridge(redisAddress)
+	if err != nil {
+		return err
+	}
+	eventStreamDisabled = false
+	return nil
+}
+
+// EventStreamEnabled returns whether subscribers of this instance receive the published events
+func EventStreamEnabled() bool {
+	return !eventStreamDisabled
+}
+
+// InitEventBridge connects the event hub to redis, afterwards published events are distributed over redis
+func InitEventBridge(redisAddress string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	bridge, err := cache.InitRedisCache(ctx, redisAddress)
+	if err != nil {
+		return err
+	}
+	messages, err := bridge.Subscribe(context.Background(), eventBridgeChannel())
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for msg := range messages {
+			e := &types.Event{}
+			err := json.Unmarshal([]byte(msg.Payload), e)
+			if err != nil {
+				logger.Errorf("error unmarshalling event received from redis: %v", err)
+				continue
+			}
+			deliverEvent(e)
+		}
+		logger.Error("redis event bridge subscription closed")
+	}()
+
+	eventBridge = bridge
+	logger.Infof("initialized redis event bridge")
+	return nil
+}
+
+func eventBridgeChannel() string {
+	return fmt.Sprintf("%d:events", utils.Config.Chain.Config.DepositChainID)
+}
+
+func validatorEventTopic(validator uint64) string {
+	return fmt.Sprintf("%s%d", types.EventTopicValidatorPrefix, validator)
+}
+
+// publishSlotEvents publishes the slots exported after the given slot together with the proposal duties and slashings
+// of their validators. It returns the latest published slot, on the first call no events are published.
+func publishSlotEvents(after uint64) (uint64, error) {
+	if after == 0 {
+		var latest uint64
+		err := db.ReaderDb.Get(&latest, "SELECT COALESCE(MAX(slot), 0) FROM blocks WHERE status != '0'")
+		return latest, err
+	}
+
+	slots := []*types.EventSlot{}
+	err := db.ReaderDb.Select(&slots, `
+		SELECT epoch, slot, proposer, status, blockroot, exec_block_number
+		FROM blocks
+		WHERE slot > $1 AND status != '0'
+		ORDER BY slot
+		LIMIT $2`, after, maxEventBacklog)
+	if err != nil {
+		return after, err
+	}
+	if len(slots) == 0 {
+		return after, nil
+	}
+
+	latest := after
+	for _, s := range slots {
+		PublishEvent(types.EventTopicSlots, types.EventTypeSlot, s)
+		PublishEvent(validatorEventTopic(s.Proposer), types.EventTypeProposal, &types.EventValidatorProposal{Validator: s.Proposer, Slot: s.Slot, Status: s.Status})
+		latest = s.Slot
+	}
+
+	slashed := []struct {
+		Validator uint64 `db:"validator"`
+		Slot      uint64 `db:"slot"`
+	}{}
+	err = db.ReaderDb.Select(&slashed, `
+		SELECT proposerindex AS validator, block_slot AS slot FROM blocks_proposerslashings WHERE block_slot > $1 AND block_slot <= $2
+		UNION
+		SELECT i AS validator, s.block_slot AS slot
+		FROM blocks_attesterslashings s, unnest(s.attestation1_indices) i
+		WHERE s.block_slot > $1 AND s.block_slot <= $2 AND i = ANY(s.attestation2_indices)`, after, latest)
+	if err != nil {
+		return latest, err
+	}
+	for _, s := range slashed {
+		PublishEvent(validatorEventTopic(s.Validator), types.EventTypeStatus, &types.EventValidatorStatus{Validator: s.Validator, Epoch: utils.EpochOfSlot(s.Slot), Status: "slashed"})
+	}
+	return latest, nil
+}
+
+// publishEpochEvents publishes the scheduled proposals and the validator status changes of the epochs after the given epoch up to latest
+func publishEpochEvents(after, latest uint64) error {
+	if after == 0 || latest <= after {
+		return nil
+	}
+	if latest-after > maxEventBacklog {
+		after = latest - maxEventBacklog
+	}
+
+	epochs := make([]uint64, 0, latest-after)
+	for epoch := after + 1; epoch <= latest; epoch++ {
+		epochs = append(epochs, epoch)
+	}
+
+	scheduled := []*types.EventValidatorProposal{}
+	err := db.ReaderDb.Select(&scheduled, `
+		SELECT proposer AS validator, slot, status
+		FROM blocks
+		WHERE epoch = ANY($1) AND status = '0'
+		ORDER BY slot`, pq.Array(epochs))
+	if err != nil {
+		return err
+	}
+	for _, p := range scheduled {
+		PublishEvent(validatorEventTopic(p.Validator), types.EventTypeProposalScheduled, p)
+	}
+
+	changes := []*types.EventValidatorStatus{}
+	err = db.ReaderDb.Select(&changes, `
+		SELECT validatorindex AS validator, activationeligibilityepoch AS epoch, 'eligible' AS status FROM validators WHERE activationeligibilityepoch = ANY($1)
+		UNION ALL
+		SELECT validatorindex AS validator, activationepoch AS epoch, 'activated' AS status FROM validators WHERE activationepoch = ANY($1)
+		UNION ALL
+		SELECT validatorindex AS validator, exitepoch AS epoch, 'exited' AS status FROM validators WHERE exitepoch = ANY($1)
+		UNION ALL
+		SELECT validatorindex AS validator, withdrawableepoch AS epoch, 'withdrawable' AS status FROM validators WHERE withdrawableepoch = ANY($1)
+		ORDER BY epoch, validator`, pq.Array(epochs))
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		PublishEvent(validatorEventTopic(c.Validator), types.EventTypeStatus, c)
+	}
+	return nil
+}
+
+// publishFinalizedEpochEvents publishes the epochs finalized after the given epoch up to latest
+func publishFinalizedEpochEvents(after, latest uint64) error {
+	if after == 0 || latest <= after {
+		return nil
+	}
+
+	epochs := []*types.EventFinalizedEpoch{}
+	err := db.ReaderDb.Select(&epochs, `
+		SELECT epoch, validatorscount, eligibleether, votedether, globalparticipationrate
+		FROM epochs
+		WHERE epoch > $1 AND epoch <= $2
+		ORDER BY epoch
+		LIMIT $3`, after, latest, maxEventBacklog)
+	if err != nil {
+		return err
+	}
+	for _, e := range epochs {
+		PublishEvent(types.EventTopicFinalizedEpochs, types.EventTypeFinalizedEpoch, e)
+	}
+	return nil
+}
+
+// publishRelayBlockEvents publishes the recent relay blocks proposed after the given slot and returns the latest relay block slot.
+// On the first call no events are published.
+func publishRelayBlockEvents(after uint64, blocks []*types.RelaysRespBlock) uint64 {
+	latest := after
+	for i := len(blocks) - 1; i >= 0; i-- {
+		b := blocks[i]
+		if b.Slot <= after {
+			continue
+		}
+		if after != 0 {
+			relays := make([]string, 0, len(b.Tags))
+			for _, tag := range b.Tags {
+				relays = append(relays, tag.Name)
+			}
+			PublishEvent(types.EventTopicRelayBlocks, types.EventTypeRelayBlock, &types.EventRelayBlock{
+				Slot:                 b.Slot,
+				Proposer:             b.Proposer,
+				Value:                b.Value,
+				Builder:              b.Builder,
+				ProposerFeeRecipient: b.ProposerFeeRecipient,
+				Relays:               relays,
+			})
+		}
+		if b.Slot > latest {
+			latest = b.Slot
+		}
+	}
+	return latest
+}
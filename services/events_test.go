@@ -0,0 +1,222 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"eth2-exporter/cache"
+	"eth2-exporter/types"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventSubscriptionDroppedWhenFull(t *testing.T) {
+	slow := NewEventSubscription()
+	slow.Subscribe(types.EventTopicGas)
+	fast := NewEventSubscription()
+	fast.Subscribe(types.EventTopicGas, types.EventTopicSlots)
+	defer fast.Close()
+
+	for i := 0; i <= eventSubscriptionBuffer; i++ {
+		PublishEvent(types.EventTopicGas, types.EventTypeGas, &types.EventGas{Timestamp: int64(i)})
+		// drain the fast subscriber so only the slow one falls behind
+		<-fast.C
+	}
+
+	received := 0
+	for range slow.C {
+		received++
+	}
+	if received != eventSubscriptionBuffer {
+		t.Errorf("expected %v buffered events before the subscription was closed, got %v", eventSubscriptionBuffer, received)
+	}
+	if len(slow.Topics()) != 0 {
+		t.Errorf("expected the dropped subscription to have no topics, got %v", slow.Topics())
+	}
+	if !HasEventSubscribers(types.EventTopicGas) {
+		t.Errorf("expected the fast subscriber to stay subscribed")
+	}
+}
+
+// fakeRedis is a minimal redis server that only supports PING, SUBSCRIBE and PUBLISH, enough for the event bridge
+type fakeRedis struct {
+	listener net.Listener
+	// failPublish makes PUBLISH return an error, e.g. as redis does when it runs out of memory
+	failPublish bool
+
+	mux         sync.Mutex
+	subscribers map[string][]net.Conn
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeRedis{listener: listener, subscribers: make(map[string][]net.Conn)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(conn)
+		}
+	}()
+	return r
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readRespCommand(reader)
+		if err != nil {
+			return
+		}
+		r.mux.Lock()
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			fmt.Fprint(conn, "+PONG\r\n")
+		case "SUBSCRIBE":
+			for i, channel := range args[1:] {
+				r.subscribers[channel] = append(r.subscribers[channel], conn)
+				fmt.Fprintf(conn, "*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:%d\r\n", len(channel), channel, i+1)
+			}
+		case "PUBLISH":
+			if r.failPublish {
+				fmt.Fprint(conn, "-ERR publish failed\r\n")
+				break
+			}
+			for _, s := range r.subscribers[args[1]] {
+				fmt.Fprintf(s, "*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(args[1]), args[1], len(args[2]), args[2])
+			}
+			fmt.Fprintf(conn, ":%d\r\n", len(r.subscribers[args[1]]))
+		default:
+			fmt.Fprint(conn, "+OK\r\n")
+		}
+		r.mux.Unlock()
+	}
+}
+
+// readRespCommand reads a command sent as an array of bulk strings
+func readRespCommand(reader *bufio.Reader) ([]string, error) {
+	readLine := func(prefix byte) (int, error) {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return 0, err
+		}
+		if len(line) < 3 || line[0] != prefix {
+			return 0, fmt.Errorf("unexpected line %q", line)
+		}
+		return strconv.Atoi(strings.TrimSpace(line[1:]))
+	}
+	n, err := readLine('*')
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		l, err := readLine('$')
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, l+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:l]))
+	}
+	return args, nil
+}
+
+// TestEventBridgeDeliversAcrossProcesses covers the deployment where the frontend data updater publishes the events and
+// the explorer serves the subscribers, both only share the redis bridge
+func TestEventBridgeDeliversAcrossProcesses(t *testing.T) {
+	if err := InitEventStream("", false); err != nil || EventStreamEnabled() {
+		t.Errorf("expected the event stream to be disabled without error when the updaters run in another process and no redis endpoint is configured: %v", err)
+	}
+	if err := InitEventStream("", true); err != nil || !EventStreamEnabled() {
+		t.Errorf("expected in-process delivery to work without redis: %v", err)
+	}
+
+	redis := newFakeRedis(t)
+	defer redis.listener.Close()
+
+	// the explorer side, it only receives events over the bridge
+	if err := InitEventStream(redis.listener.Addr().String(), false); err != nil || !EventStreamEnabled() {
+		t.Fatalf("expected the event stream to be enabled over the bridge: %v", err)
+	}
+	defer func() { eventBridge = nil }()
+
+	sub := NewEventSubscription()
+	sub.Subscribe(types.EventTopicGas)
+	defer sub.Close()
+
+	// the updater side is another process with its own connection, it publishes the events the way PublishEvent does
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	updater, err := cache.InitRedisCache(ctx, redis.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(&types.EventGas{Timestamp: 1})
+	msg, _ := json.Marshal(&types.Event{Topic: types.EventTopicGas, Type: types.EventTypeGas, Data: data})
+	if err := updater.Publish(ctx, eventBridgeChannel(), msg); err != nil {
+		t.Fatal(err)
+	}
+
+	// events published by the bridged process itself take the round trip over redis as well
+	PublishEvent(types.EventTopicGas, types.EventTypeGas, &types.EventGas{Timestamp: 2})
+
+	for want := int64(1); want <= 2; want++ {
+		select {
+		case e := <-sub.C:
+			gas := &types.EventGas{}
+			if err := json.Unmarshal(e.Data, gas); err != nil {
+				t.Fatal(err)
+			}
+			if gas.Timestamp != want {
+				t.Errorf("expected the event with timestamp %v, got %v", want, gas.Timestamp)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("event %v was not delivered over the redis bridge", want)
+		}
+	}
+}
+
+func TestPublishEventDeliversLocallyWhenRedisFails(t *testing.T) {
+	redis := newFakeRedis(t)
+	redis.failPublish = true
+	defer redis.listener.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	bridge, err := cache.InitRedisCache(ctx, redis.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventBridge = bridge
+	defer func() { eventBridge = nil }()
+
+	sub := NewEventSubscription()
+	sub.Subscribe(types.EventTopicGas)
+	defer sub.Close()
+
+	PublishEvent(types.EventTopicGas, types.EventTypeGas, &types.EventGas{Timestamp: 3})
+	select {
+	case e := <-sub.C:
+		gas := &types.EventGas{}
+		if err := json.Unmarshal(e.Data, gas); err != nil || gas.Timestamp != 3 {
+			t.Errorf("unexpected event %s, %v", e.Data, err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatalf("the event was not delivered to the local subscriber after the redis publish failed")
+	}
+}
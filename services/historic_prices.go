@@ -1,16 +1,21 @@
 package services
 
 import (
-	"encoding/json"
+	"database/sql"
 	"eth2-exporter/db"
 	"eth2-exporter/metrics"
-	"eth2-exporter/types"
+	"eth2-exporter/price"
 	"eth2-exporter/utils"
-	"fmt"
-	"net/http"
 	"time"
 )
 
+// historicPriceCurrencies are the currencies of the columns of the price table, they are stored independent of the configured currencies
+var historicPriceCurrencies = []string{"EUR", "USD", "RUB", "CNY", "CAD", "JPY", "GBP", "AUD"}
+
+// historicPriceColumns selects a row of the price table into types.Price, currencies without a price of the day are 0
+const historicPriceColumns = `ts, COALESCE(eur, 0) AS eur, COALESCE(usd, 0) AS usd, COALESCE(rub, 0) AS rub, COALESCE(cny, 0) AS cny,
+	COALESCE(cad, 0) AS cad, COALESCE(jpy, 0) AS jpy, COALESCE(gbp, 0) AS gbp, COALESCE(aud, 0) AS aud`
+
 func StartHistoricPriceService() {
 	for true {
 		updateHistoricPrices()
@@ -40,24 +45,20 @@ func updateHistoricPrices() error {
 	for currentDay.Before(time.Now()) {
 		currentDayTrunc := currentDay.Truncate(time.Hour * 24)
 		if !datesMap[currentDayTrunc.Format("01-02-2006")] {
-			historicPrice, err := fetchHistoricPrice(currentDayTrunc)
+			logger.Infof("fetching historic prices for day %v", currentDayTrunc)
+			historicPrices, err := price.GetHistoricEthPrices(currentDayTrunc, historicPriceCurrencies)
 
 			if err != nil {
 				logger.Errorf("error retrieving historic eth prices for day %v: %v", currentDayTrunc, err)
 				currentDay = currentDay.Add(time.Hour * 24)
 				continue
 			}
+			// a day is only stored once, currencies none of the sources returned a price for are stored as null instead of a zero price
+			if missing := missingHistoricPriceCurrencies(historicPrices); len(missing) > 0 {
+				logger.Warnf("no historic eth price was returned for %v on day %v", missing, currentDayTrunc)
+			}
 			_, err = db.WriterDb.Exec("INSERT INTO price (ts, eur, usd, rub, cny, cad, jpy, gbp, aud) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
-				currentDayTrunc,
-				historicPrice.MarketData.CurrentPrice.Eur,
-				historicPrice.MarketData.CurrentPrice.Usd,
-				historicPrice.MarketData.CurrentPrice.Rub,
-				historicPrice.MarketData.CurrentPrice.Cny,
-				historicPrice.MarketData.CurrentPrice.Cad,
-				historicPrice.MarketData.CurrentPrice.Jpy,
-				historicPrice.MarketData.CurrentPrice.Gbp,
-				historicPrice.MarketData.CurrentPrice.Aud,
-			)
+				append([]interface{}{currentDayTrunc}, historicPriceValues(historicPrices)...)...)
 			if err != nil {
 				logger.Errorf("error saving historic eth prices for day %v: %v", currentDayTrunc, err)
 				currentDay = currentDay.Add(time.Hour * 24)
@@ -69,16 +70,23 @@ func updateHistoricPrices() error {
 	return nil
 }
 
-func fetchHistoricPrice(ts time.Time) (*types.HistoricEthPrice, error) {
-	logger.Infof("fetching historic prices for day %v", ts)
-	client := &http.Client{Timeout: time.Second * 10}
-	resp, err := client.Get(fmt.Sprintf("https://api.coingecko.com/api/v3/coins/ethereum/history?date=%s", ts.Truncate(time.Hour*24).Format("02-01-2006")))
-
-	if err != nil {
-		return nil, err
+// missingHistoricPriceCurrencies returns the stored currencies without a price
+func missingHistoricPriceCurrencies(prices map[string]float64) []string {
+	missing := []string{}
+	for _, currency := range historicPriceCurrencies {
+		if prices[currency] <= 0 {
+			missing = append(missing, currency)
+		}
 	}
+	return missing
+}
 
-	priceData := &types.HistoricEthPrice{}
-	err = json.NewDecoder(resp.Body).Decode(&priceData)
-	return priceData, err
+// historicPriceValues returns the prices of the stored currencies in the order of the columns, currencies without a price are null
+func historicPriceValues(prices map[string]float64) []interface{} {
+	values := make([]interface{}, 0, len(historicPriceCurrencies))
+	for _, currency := range historicPriceCurrencies {
+		price := prices[currency]
+		values = append(values, sql.NullFloat64{Float64: price, Valid: price > 0})
+	}
+	return values
 }
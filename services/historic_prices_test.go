@@ -0,0 +1,39 @@
+package services
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestMissingHistoricPriceCurrencies(t *testing.T) {
+	complete := map[string]float64{"EUR": 1, "USD": 1, "RUB": 1, "CNY": 1, "CAD": 1, "JPY": 1, "GBP": 1, "AUD": 1, "CHF": 1}
+	if missing := missingHistoricPriceCurrencies(complete); len(missing) != 0 {
+		t.Errorf("expected no missing currencies, got %v", missing)
+	}
+
+	incomplete := map[string]float64{"EUR": 1, "USD": 1, "RUB": 0, "CNY": 1, "CAD": 1, "JPY": 1, "GBP": 1}
+	if missing := missingHistoricPriceCurrencies(incomplete); !reflect.DeepEqual(missing, []string{"RUB", "AUD"}) {
+		t.Errorf("expected RUB and AUD to be missing, got %v", missing)
+	}
+}
+
+func TestHistoricPriceValuesStoresMissingCurrenciesAsNull(t *testing.T) {
+	values := historicPriceValues(map[string]float64{"EUR": 1500, "USD": 1600, "JPY": 0})
+	if len(values) != len(historicPriceCurrencies) {
+		t.Fatalf("expected a value for every price column, got %v", len(values))
+	}
+	for i, currency := range historicPriceCurrencies {
+		v := values[i].(sql.NullFloat64)
+		switch currency {
+		case "EUR", "USD":
+			if !v.Valid || v.Float64 == 0 {
+				t.Errorf("expected the price of %v to be stored, got %+v", currency, v)
+			}
+		default:
+			if v.Valid {
+				t.Errorf("expected %v without a price to be null, got %+v", currency, v)
+			}
+		}
+	}
+}
@@ -2,7 +2,6 @@ package services
 
 import (
 	"bytes"
-	"context"
 	"crypto/sha256"
 	"database/sql"
 	"database/sql/driver"
@@ -25,6 +24,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	gcp_bigtable "cloud.google.com/go/bigtable"
@@ -90,7 +90,7 @@ func notificationCollector() {
 			logger.Infof("collecting notifications for epoch %v", epoch)
 
 			// Network DB Notifications (network related)
-			notifications, err := collectNotifications(epoch)
+			notifications, depositsBlock, err := collectNotifications(epoch)
 
 			if err != nil {
 				logger.Errorf("error collection notifications: %v", err)
@@ -98,14 +98,21 @@ func notificationCollector() {
 				break
 			}
 
-			_, err = db.WriterDb.Exec("INSERT INTO epochs_notified VALUES ($1, NOW())", epoch)
+			// the notifications are written to the queue in a single transaction (outbox) together with the queued epoch, so a crash
+			// before the epoch is marked as notified does not queue them twice
+			err = queueEpochNotifications(epoch, "network", notifications, db.FrontendWriterDB)
 			if err != nil {
-				logger.Errorf("error marking notification status for epoch %v in db: %v", epoch, err)
+				logger.Errorf("error queuing notifications for epoch %v: %v", epoch, err)
 				ReportStatus("notification-collector", "Error", nil)
 				break
 			}
 
-			queueNotifications(notifications, db.FrontendWriterDB) // this caused the collected notifications to be queuened and sent
+			err = markEpochNotified(epoch, depositsBlock)
+			if err != nil {
+				logger.Errorf("error marking notification status for epoch %v in db: %v", epoch, err)
+				ReportStatus("notification-collector", "Error", nil)
+				break
+			}
 
 			// Network DB Notifications (user related, must only run on one instance ever!!!!)
 			if utils.Config.Notifications.UserDBNotifications {
@@ -117,7 +124,11 @@ func notificationCollector() {
 					continue
 				}
 
-				queueNotifications(userNotifications, db.FrontendWriterDB)
+				err = queueEpochNotifications(epoch, "user_db", userNotifications, db.FrontendWriterDB)
+				if err != nil {
+					logger.Errorf("error queuing user db notifications for epoch %v: %v", epoch, err)
+					ReportStatus("notification-collector", "Error", nil)
+				}
 			}
 
 			logger.
@@ -134,32 +145,33 @@ func notificationCollector() {
 	}
 }
 
+// markEpochNotified records the epoch and the last checked deposit block as notified
+func markEpochNotified(epoch, depositsBlock uint64) error {
+	tx, err := db.WriterDb.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT INTO epochs_notified VALUES ($1, NOW())", epoch)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO deposits_notified VALUES ($1, NOW()) ON CONFLICT DO NOTHING", depositsBlock)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// the notificationSender is responsible for delivering queued notifications
+// queue items are leased row by row (see claimNotificationQueueItems), so several sender instances can run in parallel
+// and items of a crashed instance are picked up again once their lease expired
 func notificationSender() {
 	for {
 		start := time.Now()
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*300)
-
-		conn, err := db.FrontendReaderDB.Conn(ctx)
-		if err != nil {
-			logger.WithError(err).Error("error creating connection")
-			cancel()
-			continue
-		}
-
-		_, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock(500)`)
-		if err != nil {
-			logger.WithError(err).Errorf("error getting advisory lock from db")
-
-			conn.Close()
-			if err != nil {
-				logger.WithError(err).Warn("error returning connection to connection pool (advisory lock)")
-			}
-			cancel()
-			continue
-		}
 
-		logger.Info("lock obtained")
-		err = dispatchNotifications(db.FrontendWriterDB)
+		err := dispatchNotifications(db.FrontendWriterDB)
 		if err != nil {
 			logger.WithError(err).Error("error dispatching notifications")
 		}
@@ -168,42 +180,21 @@ func notificationSender() {
 		if err != nil {
 			logger.WithError(err).Errorf("error garbage collecting the notification queue")
 		}
-		logger.WithField("duration", time.Since(start)).Info("notifications dispatched and garbage collected")
-		metrics.TaskDuration.WithLabelValues("service_notifications_sender").Observe(time.Since(start).Seconds())
-
-		unlocked := false
-		rows, err := conn.QueryContext(ctx, `SELECT pg_advisory_unlock(500)`)
-		if err != nil {
-			logger.WithError(err).Errorf("error executing advisory unlock")
-
-			conn.Close()
-			if err != nil {
-				logger.WithError(err).Warn("error returning connection to connection pool (advisory unlock)")
-			}
-			cancel()
-			continue
-		}
-
-		for rows.Next() {
-			rows.Scan(&unlocked)
-		}
-
-		if !unlocked {
-			logger.Error("error releasing advisory lock unlocked: ", unlocked)
-		}
 
-		conn.Close()
+		err = updateNotificationQueueMetrics(db.FrontendWriterDB)
 		if err != nil {
-			logger.Warn("error returning connection to connection pool")
+			logger.WithError(err).Errorf("error updating notification queue metrics")
 		}
-		cancel()
+		logger.WithField("duration", time.Since(start)).Info("notifications dispatched and garbage collected")
+		metrics.TaskDuration.WithLabelValues("service_notifications_sender").Observe(time.Since(start).Seconds())
 
 		ReportStatus("notification-sender", "Running", nil)
 		time.Sleep(time.Second * 30)
 	}
 }
 
-func collectNotifications(epoch uint64) (map[uint64]map[types.EventName][]types.Notification, error) {
+// collectNotifications collects the notifications of an epoch, it also returns the last block whose deposits have been checked
+func collectNotifications(epoch uint64) (map[uint64]map[types.EventName][]types.Notification, uint64, error) {
 	notificationsByUserID := map[uint64]map[types.EventName][]types.Notification{}
 	start := time.Now()
 	var err error
@@ -222,11 +213,11 @@ func collectNotifications(epoch uint64) (map[uint64]map[types.EventName][]types.
 
 	if err != nil {
 		logger.Errorf("failed to do epochs table coherence check, aborting: %v", err)
-		return nil, err
+		return nil, 0, err
 	}
 	if !dbIsCoherent {
 		logger.Errorf("epochs coherence check failed, aborting.")
-		return nil, fmt.Errorf("epochs coherence check failed, aborting")
+		return nil, 0, fmt.Errorf("epochs coherence check failed, aborting")
 	}
 
 	logger.Infof("Started collecting notifications")
@@ -234,74 +225,95 @@ func collectNotifications(epoch uint64) (map[uint64]map[types.EventName][]types.
 	err = collectAttestationAndOfflineValidatorNotifications(notificationsByUserID, 0, epoch)
 	if err != nil {
 		metrics.Errors.WithLabelValues("notifications_collect_missed_attestation").Inc()
-		return nil, fmt.Errorf("error collecting validator_attestation_missed notifications: %v", err)
+		return nil, 0, fmt.Errorf("error collecting validator_attestation_missed notifications: %v", err)
 	}
 	logger.Infof("collecting attestation & offline notifications took: %v\n", time.Since(start))
 
 	err = collectBlockProposalNotifications(notificationsByUserID, 1, types.ValidatorExecutedProposalEventName, epoch)
 	if err != nil {
 		metrics.Errors.WithLabelValues("notifications_collect_executed_block_proposal").Inc()
-		return nil, fmt.Errorf("error collecting validator_proposal_submitted notifications: %v", err)
+		return nil, 0, fmt.Errorf("error collecting validator_proposal_submitted notifications: %v", err)
 	}
 	logger.Infof("collecting block proposal proposed notifications took: %v\n", time.Since(start))
 
 	err = collectBlockProposalNotifications(notificationsByUserID, 2, types.ValidatorMissedProposalEventName, epoch)
 	if err != nil {
 		metrics.Errors.WithLabelValues("notifications_collect_missed_block_proposal").Inc()
-		return nil, fmt.Errorf("error collecting validator_proposal_missed notifications: %v", err)
+		return nil, 0, fmt.Errorf("error collecting validator_proposal_missed notifications: %v", err)
 	}
 	logger.Infof("collecting block proposal missed notifications took: %v\n", time.Since(start))
 
 	err = collectValidatorGotSlashedNotifications(notificationsByUserID, epoch)
 	if err != nil {
 		metrics.Errors.WithLabelValues("notifications_collect_validator_got_slashed").Inc()
-		return nil, fmt.Errorf("error collecting validator_got_slashed notifications: %v", err)
+		return nil, 0, fmt.Errorf("error collecting validator_got_slashed notifications: %v", err)
 	}
 	logger.Infof("collecting validator got slashed notifications took: %v\n", time.Since(start))
 
+	err = collectValidatorExitInitiatedNotifications(notificationsByUserID, epoch)
+	if err != nil {
+		metrics.Errors.WithLabelValues("notifications_collect_validator_exit_initiated").Inc()
+		return nil, 0, fmt.Errorf("error collecting validator_exit_initiated notifications: %v", err)
+	}
+	logger.Infof("collecting validator exit initiated notifications took: %v\n", time.Since(start))
+
+	err = collectValidatorWithdrawalCredentialsChangedNotifications(notificationsByUserID, epoch)
+	if err != nil {
+		metrics.Errors.WithLabelValues("notifications_collect_validator_withdrawal_credentials_changed").Inc()
+		return nil, 0, fmt.Errorf("error collecting validator_withdrawal_credentials_changed notifications: %v", err)
+	}
+	logger.Infof("collecting validator withdrawal credentials changed notifications took: %v\n", time.Since(start))
+
+	depositsBlock, err := collectDepositCredentialsMismatchNotifications(notificationsByUserID, epoch)
+	if err != nil {
+		metrics.Errors.WithLabelValues("notifications_collect_validator_deposit_credentials_mismatch").Inc()
+		return nil, 0, fmt.Errorf("error collecting validator_deposit_credentials_mismatch notifications: %v", err)
+	}
+	logger.Infof("collecting deposit credentials mismatch notifications took: %v\n", time.Since(start))
+
 	err = collectNetworkNotifications(notificationsByUserID, types.NetworkLivenessIncreasedEventName)
 	if err != nil {
 		metrics.Errors.WithLabelValues("notifications_collect_network").Inc()
-		return nil, fmt.Errorf("error collecting network notifications: %v", err)
+		return nil, 0, fmt.Errorf("error collecting network notifications: %v", err)
 	}
 	logger.Infof("collecting network notifications took: %v\n", time.Since(start))
 
 	err = collectRocketpoolComissionNotifications(notificationsByUserID, types.RocketpoolCommissionThresholdEventName)
 	if err != nil {
 		metrics.Errors.WithLabelValues("notifications_collect_rocketpool_comission").Inc()
-		return nil, fmt.Errorf("error collecting rocketpool commission: %v", err)
+		return nil, 0, fmt.Errorf("error collecting rocketpool commission: %v", err)
 	}
 	logger.Infof("collecting rocketpool commissions took: %v\n", time.Since(start))
 
 	err = collectRocketpoolRewardClaimRoundNotifications(notificationsByUserID, types.RocketpoolNewClaimRoundStartedEventName)
 	if err != nil {
 		metrics.Errors.WithLabelValues("notifications_collect_rocketpool_reward_claim").Inc()
-		return nil, fmt.Errorf("error collecting new rocketpool claim round: %v", err)
+		return nil, 0, fmt.Errorf("error collecting new rocketpool claim round: %v", err)
 	}
 	logger.Infof("collecting rocketpool claim round took: %v\n", time.Since(start))
 
 	err = collectRocketpoolRPLCollateralNotifications(notificationsByUserID, types.RocketpoolColleteralMaxReached, epoch)
 	if err != nil {
 		metrics.Errors.WithLabelValues("notifications_collect_rocketpool_rpl_collateral_max_reached").Inc()
-		return nil, fmt.Errorf("error collecting rocketpool max collateral: %v", err)
+		return nil, 0, fmt.Errorf("error collecting rocketpool max collateral: %v", err)
 	}
 	logger.Infof("collecting rocketpool max collateral took: %v\n", time.Since(start))
 
 	err = collectRocketpoolRPLCollateralNotifications(notificationsByUserID, types.RocketpoolColleteralMinReached, epoch)
 	if err != nil {
 		metrics.Errors.WithLabelValues("notifications_collect_rocketpool_rpl_collateral_min_reached").Inc()
-		return nil, fmt.Errorf("error collecting rocketpool min collateral: %v", err)
+		return nil, 0, fmt.Errorf("error collecting rocketpool min collateral: %v", err)
 	}
 	logger.Infof("collecting rocketpool min collateral took: %v\n", time.Since(start))
 
 	err = collectSyncCommittee(notificationsByUserID, types.SyncCommitteeSoon, epoch)
 	if err != nil {
 		metrics.Errors.WithLabelValues("notifications_collect_sync_committee").Inc()
-		return nil, fmt.Errorf("error collecting sync committee: %v", err)
+		return nil, 0, fmt.Errorf("error collecting sync committee: %v", err)
 	}
 	logger.Infof("collecting sync committee took: %v\n", time.Since(start))
 
-	return notificationsByUserID, nil
+	return notificationsByUserID, depositsBlock, nil
 }
 
 func collectUserDbNotifications(epoch uint64) (map[uint64]map[types.EventName][]types.Notification, error) {
@@ -353,10 +365,47 @@ func collectUserDbNotifications(epoch uint64) (map[uint64]map[types.EventName][]
 	return notificationsByUserID, nil
 }
 
-func queueNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, useDB *sqlx.DB) {
+// queueEpochNotifications queues the notifications collected from source for an epoch exactly once. The epoch is recorded in
+// notification_queued_epochs within the queue transaction, if the collector crashes before it marked the epoch as notified
+// the notifications of the epoch are not queued a second time.
+func queueEpochNotifications(epoch uint64, source string, notificationsByUserID map[uint64]map[types.EventName][]types.Notification, useDB *sqlx.DB) error {
+	tx, err := useDB.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting notification queue transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO notification_queued_epochs (network, epoch, source, queued_ts) VALUES ($1, $2, $3, now()) ON CONFLICT DO NOTHING`, utils.GetNetwork(), epoch, source)
+	if err != nil {
+		return fmt.Errorf("error recording the queued epoch %v: %w", epoch, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error recording the queued epoch %v: %w", epoch, err)
+	}
+	if rowsAffected == 0 {
+		logger.Warnf("%v notifications of epoch %v have already been queued, skipping them", source, epoch)
+		return nil
+	}
+
+	err = queueNotificationsTx(notificationsByUserID, tx)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing notification queue transaction: %w", err)
+	}
+	return nil
+}
+
+// queueNotificationsTx writes the notifications of all channels to the notification_queue table and updates the state of the
+// affected subscriptions. Everything happens in the passed transaction, so either all or none of the notifications are queued.
+func queueNotificationsTx(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, tx *sqlx.Tx) error {
 	subByEpoch := map[uint64][]uint64{}
 
-	// prevent multiple events being sent with the same subscription id
+	// prevent multiple events being sent with the same subscription id, subscription rules send one event per validator
 	for user, notifications := range notificationsByUserID {
 		for eventType, events := range notifications {
 			filteredEvents := make([]types.Notification, 0)
@@ -364,7 +413,7 @@ func queueNotifications(notificationsByUserID map[uint64]map[types.EventName][]t
 			for _, ev := range events {
 				isDuplicate := false
 				for _, fe := range filteredEvents {
-					if fe.GetSubscriptionID() == ev.GetSubscriptionID() {
+					if fe.GetSubscriptionID() == ev.GetSubscriptionID() && fe.GetEventFilter() == ev.GetEventFilter() {
 						isDuplicate = true
 					}
 				}
@@ -376,19 +425,9 @@ func queueNotifications(notificationsByUserID map[uint64]map[types.EventName][]t
 		}
 	}
 
-	err := queueEmailNotifications(notificationsByUserID, useDB)
+	err := queueNotificationChannels(notificationsByUserID, tx)
 	if err != nil {
-		logger.WithError(err).Error("error queuing email notifications")
-	}
-
-	err = queuePushNotification(notificationsByUserID, useDB)
-	if err != nil {
-		logger.WithError(err).Error("error queuing push notifications")
-	}
-
-	err = queueWebhookNotifications(notificationsByUserID, useDB)
-	if err != nil {
-		logger.WithError(err).Error("error queuing webhook notifications")
+		return err
 	}
 
 	for _, events := range notificationsByUserID {
@@ -405,10 +444,10 @@ func queueNotifications(notificationsByUserID map[uint64]map[types.EventName][]t
 	}
 	for epoch, subIDs := range subByEpoch {
 		// update that we've queued the subscription (last sent rather means last queued)
-		err := db.UpdateSubscriptionsLastSent(subIDs, time.Now(), epoch, useDB)
+		err := db.UpdateSubscriptionsLastSent(subIDs, time.Now(), epoch, tx)
 		if err != nil {
-			logger.Errorf("error updating sent-time of sent notifications: %v", err)
 			metrics.Errors.WithLabelValues("notifications_updating_sent_time").Inc()
+			return fmt.Errorf("error updating sent-time of sent notifications: %w", err)
 		}
 	}
 	// update internal state of subscriptions
@@ -436,11 +475,61 @@ func queueNotifications(notificationsByUserID map[uint64]map[types.EventName][]t
 		for subID := range subs {
 			subArray = append(subArray, int64(subID))
 		}
-		_, err := db.FrontendWriterDB.Exec(`UPDATE users_subscriptions SET internal_state = $1 WHERE id = ANY($2)`, state, pq.Int64Array(subArray))
+		_, err := tx.Exec(`UPDATE users_subscriptions SET internal_state = $1 WHERE id = ANY($2)`, state, pq.Int64Array(subArray))
 		if err != nil {
-			logger.Errorf("failed to update internal state of notifcations: %v", err)
+			return fmt.Errorf("failed to update internal state of notifcations: %w", err)
 		}
 	}
+
+	// subscription rules keep last sent and internal state per validator
+	ruleSubIDs := []uint64{}
+	ruleFilters := []string{}
+	ruleEpochs := []uint64{}
+	ruleStates := []string{}
+	for _, notificationMap := range notificationsByUserID {
+		for _, notifications := range notificationMap {
+			for _, n := range notifications {
+				ruleSubIDs = append(ruleSubIDs, n.GetSubscriptionID())
+				ruleFilters = append(ruleFilters, n.GetEventFilter())
+				ruleEpochs = append(ruleEpochs, n.GetEpoch())
+				ruleStates = append(ruleStates, n.GetLatestState())
+			}
+		}
+	}
+	err = db.UpdateSubscriptionRuleStates(ruleSubIDs, ruleFilters, ruleEpochs, ruleStates, time.Now(), tx)
+	if err != nil {
+		return fmt.Errorf("failed to update state of subscription rules: %w", err)
+	}
+	return nil
+}
+
+// queueNotificationChannels writes the notifications to the queue of every channel (email, push, webhooks) the users have configured
+func queueNotificationChannels(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, tx *sqlx.Tx) error {
+	userIDs := []uint64{}
+	for userID := range notificationsByUserID {
+		userIDs = append(userIDs, userID)
+	}
+	localesByUserID, err := db.GetUserLocalesByIds(userIDs)
+	if err != nil {
+		metrics.Errors.WithLabelValues("notifications_get_user_locale_by_id").Inc()
+		return fmt.Errorf("error getting locales of users: %w", err)
+	}
+
+	err = queueEmailNotifications(notificationsByUserID, localesByUserID, tx)
+	if err != nil {
+		return fmt.Errorf("error queuing email notifications: %w", err)
+	}
+
+	err = queuePushNotification(notificationsByUserID, localesByUserID, tx)
+	if err != nil {
+		return fmt.Errorf("error queuing push notifications: %w", err)
+	}
+
+	err = queueWebhookNotifications(notificationsByUserID, localesByUserID, tx)
+	if err != nil {
+		return fmt.Errorf("error queuing webhook notifications: %w", err)
+	}
+	return nil
 }
 
 func dispatchNotifications(useDB *sqlx.DB) error {
@@ -469,9 +558,10 @@ func dispatchNotifications(useDB *sqlx.DB) error {
 }
 
 // garbageCollectNotificationQueue deletes entries from the notification queue that have been processed
+// dead-lettered entries are kept for notificationDeadLetterRetention so they can be inspected
 func garbageCollectNotificationQueue(useDB *sqlx.DB) error {
 
-	rows, err := useDB.Exec(`DELETE FROM notification_queue where (sent < now() - INTERVAL '30 minutes') OR (created < now() - INTERVAL '1 hour')`)
+	rows, err := useDB.Exec(`DELETE FROM notification_queue where (sent < now() - INTERVAL '30 minutes') OR (failed < now() - $1 * INTERVAL '1 second')`, notificationDeadLetterRetention.Seconds())
 	if err != nil {
 		return fmt.Errorf("error deleting from notification_queue %w", err)
 	}
@@ -491,7 +581,7 @@ func getNetwork() string {
 	return ""
 }
 
-func queuePushNotification(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, useDB *sqlx.DB) error {
+func queuePushNotification(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, localesByUserID map[uint64]string, tx *sqlx.Tx) error {
 	userIDs := []uint64{}
 	for userID := range notificationsByUserID {
 		userIDs = append(userIDs, userID)
@@ -509,85 +599,98 @@ func queuePushNotification(notificationsByUserID map[uint64]map[types.EventName]
 			continue
 		}
 
-		go func(userTokens []string, userNotifications map[types.EventName][]types.Notification) {
-			var batch []*messaging.Message
-			for event, ns := range userNotifications {
-				for _, n := range ns {
-					added := false
-					for _, userToken := range userTokens {
-						notification := new(messaging.Notification)
-						notification.Title = fmt.Sprintf("%s%s", getNetwork(), n.GetTitle())
-						notification.Body = n.GetInfo(false)
-						if notification.Body == "" {
-							continue
-						}
-						added = true
+		lang := localesByUserID[userID]
+		batch := buildPushMessages(userTokens, userNotifications, lang)
+		for event, ns := range userNotifications {
+			for _, n := range ns {
+				if n.GetInfo(lang, false) != "" {
+					metrics.NotificationsQueued.WithLabelValues("push", string(event)).Inc()
+				}
+			}
+		}
 
-						message := new(messaging.Message)
-						message.Notification = notification
-						message.Token = userToken
+		transitPushContent := types.TransitPushContent{
+			Messages: batch,
+		}
 
-						message.APNS = new(messaging.APNSConfig)
-						message.APNS.Payload = new(messaging.APNSPayload)
-						message.APNS.Payload.Aps = new(messaging.Aps)
-						message.APNS.Payload.Aps.Sound = "default"
+		_, err = tx.Exec(`INSERT INTO notification_queue (created, channel, content) VALUES ($1, 'push', $2)`, time.Now(), transitPushContent)
+		if err != nil {
+			return fmt.Errorf("error writing transit push notification to db: %w", err)
+		}
+	}
+	return nil
+}
 
-						batch = append(batch, message)
-					}
-					if added {
-						metrics.NotificationsQueued.WithLabelValues("push", string(event)).Inc()
-					}
+// buildPushMessages creates a firebase message for every notification and device token of a user
+func buildPushMessages(userTokens []string, userNotifications map[types.EventName][]types.Notification, lang string) []*messaging.Message {
+	var batch []*messaging.Message
+	for _, ns := range userNotifications {
+		for _, n := range ns {
+			for _, userToken := range userTokens {
+				notification := new(messaging.Notification)
+				notification.Title = fmt.Sprintf("%s%s", getNetwork(), n.GetTitle(lang))
+				notification.Body = n.GetInfo(lang, false)
+				if notification.Body == "" {
+					continue
 				}
-			}
 
-			transitPushContent := types.TransitPushContent{
-				Messages: batch,
-			}
+				message := new(messaging.Message)
+				message.Notification = notification
+				message.Token = userToken
 
-			_, err = useDB.Exec(`INSERT INTO notification_queue (created, channel, content) VALUES ($1, 'push', $2)`, time.Now(), transitPushContent)
-			if err != nil {
-				logger.WithError(err).Errorf("error writing transit push notification to db")
-				return
+				message.APNS = new(messaging.APNSConfig)
+				message.APNS.Payload = new(messaging.APNSPayload)
+				message.APNS.Payload.Aps = new(messaging.Aps)
+				message.APNS.Payload.Aps.Sound = "default"
+
+				batch = append(batch, message)
 			}
-		}(userTokens, userNotifications)
+		}
 	}
-	return nil
+	return batch
 }
 
 func sendPushNotifications(useDB *sqlx.DB) error {
 	var notificationQueueItem []types.TransitPush
 
-	err := useDB.Select(&notificationQueueItem, `SELECT
-		id,
-		created,
-		sent,
-		channel,
-		content
-	FROM notification_queue where sent is null and channel = 'push' order by created asc`)
+	err := claimNotificationQueueItems(useDB, "push", &notificationQueueItem)
 	if err != nil {
-		return fmt.Errorf("error querying notification queue, err: %w", err)
+		return err
 	}
 
 	logger.Infof("processing %v push notifications", len(notificationQueueItem))
 
 	for _, n := range notificationQueueItem {
+		owned, err := renewNotificationLease(useDB, n.Id)
+		if err != nil {
+			return err
+		}
+		if !owned {
+			logger.Warnf("lost the lease of push notification %v, skipping it", n.Id)
+			continue
+		}
 		_, err = notify.SendPushBatch(n.Content.Messages)
 		if err != nil {
 			metrics.Errors.WithLabelValues("notifications_send_push_batch").Inc()
 			logger.WithError(err).Error("error sending firebase batch job")
-		} else {
-			metrics.NotificationsSent.WithLabelValues("push", "200").Add(float64(len(n.Content.Messages)))
+
+			err = markNotificationsFailed(useDB, "push", []uint64{n.Id}, err)
+			if err != nil {
+				return err
+			}
+			continue
 		}
+		metrics.NotificationsSent.WithLabelValues("push", "200").Add(float64(len(n.Content.Messages)))
 
-		_, err = useDB.Exec(`UPDATE notification_queue set sent = now() where id = $1`, n.Id)
+		err = markNotificationsSent(useDB, []uint64{n.Id})
 		if err != nil {
-			return fmt.Errorf("error updating sent status for push notification with id: %v, err: %w", n.Id, err)
+			return err
 		}
 	}
 	return nil
 }
 
-func queueEmailNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, useDB *sqlx.DB) error {
+func queueEmailNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, localesByUserID map[uint64]string, tx *sqlx.Tx) error {
 	userIDs := []uint64{}
 	for userID := range notificationsByUserID {
 		userIDs = append(userIDs, userID)
@@ -606,175 +709,196 @@ func queueEmailNotifications(notificationsByUserID map[uint64]map[types.EventNam
 			// metrics.Errors.WithLabelValues("notifications_mail_not_found").Inc()
 			continue
 		}
-		go func(userEmail string, userNotifications map[types.EventName][]types.Notification) {
-			notification := ""
-			othernotifications := ""
-			i := 0
-			for notificationEvent := range userNotifications {
-				if i == 0 {
-					notification = string(notificationEvent)
-				} else if i == 1 {
-					othernotifications = fmt.Sprintf(" and %s", notificationEvent)
-				}
-				i++
-			}
-			if i > 1 {
-				othernotifications = fmt.Sprintf(",... and %d other notifications", i)
-			}
-			subject := fmt.Sprintf("%s: %s", utils.Config.Frontend.SiteDomain, notification+othernotifications)
-			attachments := []types.EmailAttachment{}
-
-			var msg types.Email
 
-			if utils.Config.Chain.Name != "mainnet" {
-				msg.Body += template.HTML(fmt.Sprintf("<b>Notice: This email contains notifications for the %s network!</b><br>", utils.Config.Chain.Name))
-			}
+		transitEmailContent, err := buildEmailNotification(userEmail, userNotifications, localesByUserID[userID], tx)
+		if err != nil {
+			return err
+		}
 
-			for event, ns := range userNotifications {
-				if len(msg.Body) > 0 {
-					msg.Body += "<br>"
-				}
-				event_title := event
-				if event == types.TaxReportEventName {
-					event_title = "income_history"
-				}
-				msg.Body += template.HTML(fmt.Sprintf("%s<br>====<br><br>", types.EventLabel[event_title]))
-				unsubURL := "https://" + utils.Config.Frontend.SiteDomain + "/notifications/unsubscribe"
-				for i, n := range ns {
-					unsubHash := n.GetUnsubscribeHash()
-					if unsubHash == "" {
-						id := n.GetSubscriptionID()
-
-						tx, err := db.FrontendWriterDB.Beginx()
-						if err != nil {
-							logger.WithError(err).Error("error starting transaction")
-						}
-						var sub types.Subscription
-						err = tx.Get(&sub, `
-							SELECT
-								id,
-								user_id,
-								event_name,
-								event_filter,
-								last_sent_ts,
-								last_sent_epoch,
-								created_ts,
-								created_epoch,
-								event_threshold
-							FROM users_subscriptions
-							WHERE id = $1
-						`, id)
-						if err != nil {
-							logger.WithError(err).Error("error getting user subscription by subscription id")
-							tx.Rollback()
-						}
+		_, err = tx.Exec(`INSERT INTO notification_queue (created, channel, content) VALUES ($1, 'email', $2)`, time.Now(), transitEmailContent)
+		if err != nil {
+			return fmt.Errorf("error writing transit email to db: %w", err)
+		}
 
-						raw := fmt.Sprintf("%v%v%v%v", sub.ID, sub.UserID, sub.EventName, sub.CreatedTime)
-						digest := sha256.Sum256([]byte(raw))
+		for event, ns := range userNotifications {
+			metrics.NotificationsQueued.WithLabelValues("email", string(event)).Add(float64(len(ns)))
+		}
+	}
+	return nil
+}
 
-						_, err = tx.Exec("UPDATE users_subscriptions set unsubscribe_hash = $1 where id = $2", digest[:], id)
-						if err != nil {
-							logger.WithError(err).Error("error updating users subscriptions table with unsubscribe hash")
-							tx.Rollback()
-						}
+// buildEmailNotification renders the notifications of a user into a single email
+// missing unsubscribe hashes are created within the passed transaction, if tx is nil the unsubscribe link is omitted
+func buildEmailNotification(userEmail string, userNotifications map[types.EventName][]types.Notification, lang string, tx *sqlx.Tx) (types.TransitEmailContent, error) {
+	var err error
+	notification := ""
+	othernotifications := ""
+	i := 0
+	for notificationEvent := range userNotifications {
+		if i == 0 {
+			notification = string(notificationEvent)
+		} else if i == 1 {
+			othernotifications = utils.Tr(lang, "notification_email_subject_and", string(notificationEvent))
+		}
+		i++
+	}
+	if i > 1 {
+		othernotifications = utils.TrPlural(lang, "notification_email_subject_others", i, fmt.Sprint(i))
+	}
+	subject := fmt.Sprintf("%s: %s", utils.Config.Frontend.SiteDomain, notification+othernotifications)
+	attachments := []types.EmailAttachment{}
 
-						err = tx.Commit()
-						if err != nil {
-							logger.WithError(err).Error("error committing transaction to update users subscriptions with an unsubscribe hash")
-							tx.Rollback()
-						}
+	msg := types.Email{Lang: lang}
 
-						unsubHash = hex.EncodeToString(digest[:])
-					}
-					if i == 0 {
-						unsubURL += "?hash=" + html.EscapeString(unsubHash)
-					} else {
-						unsubURL += "&hash=" + html.EscapeString(unsubHash)
-					}
-					msg.UnSubURL = template.HTML(fmt.Sprintf(`<a style="color: white" onMouseOver="this.style.color='#F5B498'" onMouseOut="this.style.color='#FFFFFF'" href="%v">Unsubscribe</a>`, unsubURL))
-					msg.Body += template.HTML(fmt.Sprintf("%s<br>", n.GetInfo(true)))
-					if att := n.GetEmailAttachment(); att != nil {
-						attachments = append(attachments, *att)
-					}
+	if utils.Config.Chain.Name != "mainnet" {
+		msg.Body += template.HTML(fmt.Sprintf("<b>%s</b><br>", utils.Tr(lang, "notification_email_network_notice", utils.Config.Chain.Name)))
+	}
 
-					metrics.NotificationsQueued.WithLabelValues("email", string(event)).Inc()
-				}
-				if event == "validator_balance_decreased" {
-					msg.Body += template.HTML("<br>You will not receive any further balance decrease mails for these validators until the balance of a validator is increasing again.<br>")
+	for event, ns := range userNotifications {
+		if len(msg.Body) > 0 {
+			msg.Body += "<br>"
+		}
+		msg.Body += template.HTML(fmt.Sprintf("%s<br>====<br><br>", utils.Tr(lang, "event_label_"+string(event))))
+		unsubURL := "https://" + utils.Config.Frontend.SiteDomain + "/notifications/unsubscribe"
+		unsubHashes := 0
+		for _, n := range ns {
+			unsubHash := n.GetUnsubscribeHash()
+			if unsubHash == "" && tx != nil {
+				unsubHash, err = createUnsubscribeHash(tx, n.GetSubscriptionID())
+				if err != nil {
+					return types.TransitEmailContent{}, err
 				}
 			}
+			if unsubHash != "" {
+				if unsubHashes == 0 {
+					unsubURL += "?hash=" + html.EscapeString(unsubHash)
+				} else {
+					unsubURL += "&hash=" + html.EscapeString(unsubHash)
+				}
+				unsubHashes++
+				msg.UnSubURL = template.HTML(fmt.Sprintf(`<a style="color: white" onMouseOver="this.style.color='#F5B498'" onMouseOut="this.style.color='#FFFFFF'" href="%v">%s</a>`, unsubURL, utils.Tr(lang, "notification_email_unsubscribe")))
+			}
+			msg.Body += template.HTML(fmt.Sprintf("%s<br>", n.GetInfo(lang, true)))
+			if att := n.GetEmailAttachment(); att != nil {
+				attachments = append(attachments, *att)
+			}
+		}
+		if event == "validator_balance_decreased" {
+			msg.Body += template.HTML(fmt.Sprintf("<br>%s<br>", utils.Tr(lang, "notification_email_balance_decreased_notice")))
+		}
+	}
+
+	// msg.Body += template.HTML(fmt.Sprintf("<br>Best regards<br>\n%s", utils.Config.Frontend.SiteDomain))
+	msg.SubscriptionManageURL = template.HTML(fmt.Sprintf(`<a href="%v" style="color: white" onMouseOver="this.style.color='#F5B498'" onMouseOut="this.style.color='#FFFFFF'">%s</a>`, "https://"+utils.Config.Frontend.SiteDomain+"/user/notifications", utils.Tr(lang, "notification_email_manage")))
+
+	return types.TransitEmailContent{
+		Address:     userEmail,
+		Subject:     subject,
+		Email:       msg,
+		Attachments: attachments,
+	}, nil
+}
+
+// createUnsubscribeHash generates and stores the unsubscribe hash of a subscription that does not have one yet
+func createUnsubscribeHash(tx *sqlx.Tx, id uint64) (string, error) {
+	var sub types.Subscription
+	err := tx.Get(&sub, `
+		SELECT
+			id,
+			user_id,
+			event_name,
+			event_filter,
+			last_sent_ts,
+			last_sent_epoch,
+			created_ts,
+			created_epoch,
+			event_threshold
+		FROM users_subscriptions
+		WHERE id = $1
+	`, id)
+	if err == sql.ErrNoRows {
+		// the subscription has been removed in the meantime
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error getting user subscription by subscription id: %w", err)
+	}
 
-			// msg.Body += template.HTML(fmt.Sprintf("<br>Best regards<br>\n%s", utils.Config.Frontend.SiteDomain))
-			msg.SubscriptionManageURL = template.HTML(fmt.Sprintf(`<a href="%v" style="color: white" onMouseOver="this.style.color='#F5B498'" onMouseOut="this.style.color='#FFFFFF'">Manage</a>`, "https://"+utils.Config.Frontend.SiteDomain+"/user/notifications"))
-
-			transitEmailContent := types.TransitEmailContent{
-				Address:     userEmail,
-				Subject:     subject,
-				Email:       msg,
-				Attachments: attachments,
-			}
+	raw := fmt.Sprintf("%v%v%v%v", sub.ID, sub.UserID, sub.EventName, sub.CreatedTime)
+	digest := sha256.Sum256([]byte(raw))
 
-			_, err = useDB.Exec(`INSERT INTO notification_queue (created, channel, content) VALUES ($1, 'email', $2)`, time.Now(), transitEmailContent)
-			if err != nil {
-				logger.WithError(err).Errorf("error writing transit email to db")
-			}
-		}(userEmail, userNotifications)
+	_, err = tx.Exec("UPDATE users_subscriptions set unsubscribe_hash = $1 where id = $2", digest[:], id)
+	if err != nil {
+		return "", fmt.Errorf("error updating users subscriptions table with unsubscribe hash: %w", err)
 	}
-	return nil
+
+	return hex.EncodeToString(digest[:]), nil
 }
 
 func sendEmailNotifications(useDb *sqlx.DB) error {
 	var notificationQueueItem []types.TransitEmail
 
-	err := useDb.Select(&notificationQueueItem, `SELECT
-		id,
-		created,
-		sent,
-		channel,
-		content
-	FROM notification_queue where sent is null and channel = 'email' order by created asc`)
+	err := claimNotificationQueueItems(useDb, "email", &notificationQueueItem)
 	if err != nil {
-		return fmt.Errorf("error querying notification queue, err: %w", err)
+		return err
 	}
 
 	logger.Infof("processing %v email notifications", len(notificationQueueItem))
 
 	for _, n := range notificationQueueItem {
+		owned, err := renewNotificationLease(useDb, n.Id)
+		if err != nil {
+			return err
+		}
+		if !owned {
+			logger.Warnf("lost the lease of email notification %v, skipping it", n.Id)
+			continue
+		}
 		err = mail.SendMailRateLimited(n.Content.Address, n.Content.Subject, n.Content.Email, n.Content.Attachments)
 		if err != nil {
-			if !strings.Contains(err.Error(), "rate limit has been exceeded") {
-				metrics.Errors.WithLabelValues("notifications_send_email").Inc()
-				logger.WithError(err).Error("error sending email notification")
-				// 	_, err := tx.Exec(`DELETE FROM notification_queue where id = $1`, n.Id)
-				// 	if err != nil {
-				// 		return fmt.Errorf("error deleting from notification queue: %w", err)
-				// 	}
-				// 	err = tx.Commit()
-				// 	if err != nil {
-				// 		tx.Rollback()
-				// 		return fmt.Errorf("error committing transaction")
-				// 	}
-				// 	continue
-			} else {
-				metrics.NotificationsSent.WithLabelValues("email", "200").Inc()
+			if strings.Contains(err.Error(), "rate limit has been exceeded") {
+				// the daily mail limit of the recipient is reached, retrying would not help
+				err = markNotificationsDeadLettered(useDb, "email", []uint64{n.Id}, err.Error())
+				if err != nil {
+					return err
+				}
+				continue
 			}
-			//else {
-			// 	tx.Rollback()
-			// 	return fmt.Errorf("error sending notification-email: %w", err)
-			// }
+
+			metrics.Errors.WithLabelValues("notifications_send_email").Inc()
+			logger.WithError(err).Error("error sending email notification")
+
+			err = markNotificationsFailed(useDb, "email", []uint64{n.Id}, err)
+			if err != nil {
+				return err
+			}
+			continue
 		}
-		_, err = useDb.Exec(`UPDATE notification_queue set sent = now() where id = $1`, n.Id)
+		metrics.NotificationsSent.WithLabelValues("email", "200").Inc()
+
+		err = markNotificationsSent(useDb, []uint64{n.Id})
 		if err != nil {
-			return fmt.Errorf("error updating sent status for email notification with id: %v, err: %w", n.Id, err)
+			return err
 		}
 	}
 	return nil
 }
 
-func queueWebhookNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, useDB *sqlx.DB) error {
+// webhookSubscribedToEvent reports whether the webhook is subscribed to the type of event
+func webhookSubscribedToEvent(w types.UserWebhook, event types.EventName) bool {
+	for _, name := range w.EventNames {
+		if name == string(event) {
+			return true
+		}
+	}
+	return false
+}
+
+func queueWebhookNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, localesByUserID map[uint64]string, tx *sqlx.Tx) error {
 	for userID, userNotifications := range notificationsByUserID {
+		lang := localesByUserID[userID]
 		var webhooks []types.UserWebhook
-		err := useDB.Select(&webhooks, `
+		err := tx.Select(&webhooks, `
 			SELECT
 				id,
 				user_id,
@@ -800,22 +924,13 @@ func queueWebhookNotifications(notificationsByUserID map[uint64]map[types.EventN
 		// send the notifications to each registered webhook
 		for _, w := range webhooks {
 			for event, notifications := range userNotifications {
-				eventSubscribed := false
-				// check if the webhook is subscribed to the type of event
-				for _, w := range w.EventNames {
-					if w == string(event) {
-						eventSubscribed = true
-						break
-					}
-				}
-				if eventSubscribed {
+				if webhookSubscribedToEvent(w, event) {
 					if len(notifications) > 0 {
 						// reset Retries
 						if w.Retries > 5 && w.LastSent.Valid && w.LastSent.Time.Add(time.Hour).Before(time.Now()) {
-							_, err = useDB.Exec(`UPDATE users_webhooks SET retries = 0 WHERE id = $1;`, w.ID)
+							_, err = tx.Exec(`UPDATE users_webhooks SET retries = 0 WHERE id = $1;`, w.ID)
 							if err != nil {
-								logger.WithError(err).Errorf("error updating users_webhooks table; setting retries to zero")
-								continue
+								return fmt.Errorf("error updating users_webhooks table; setting retries to zero: %w", err)
 							}
 						} else if w.Retries > 5 && !w.LastSent.Valid {
 							logger.Warn("error webhook has more than 5 retries and does not have a valid last_sent timestamp")
@@ -844,42 +959,13 @@ func queueWebhookNotifications(notificationsByUserID map[uint64]map[types.EventN
 								l_notifs++
 							}
 
-							fields := []types.DiscordEmbedField{
-								{
-									Name:   "Epoch",
-									Value:  fmt.Sprintf("[%[1]v](https://%[2]s/%[1]v)", n.GetEpoch(), utils.Config.Frontend.SiteDomain+"/epoch"),
-									Inline: false,
-								},
-							}
-
-							if strings.HasPrefix(string(n.GetEventName()), "monitoring") || n.GetEventName() == types.EthClientUpdateEventName || n.GetEventName() == types.RocketpoolColleteralMaxReached || n.GetEventName() == types.RocketpoolColleteralMinReached {
-								fields = append(fields,
-									types.DiscordEmbedField{
-										Name:   "Target",
-										Value:  fmt.Sprintf("%v", n.GetEventFilter()),
-										Inline: false,
-									})
-							}
-							discordNotifMap[w.ID][l_notifs-1].DiscordRequest.Embeds = append(discordNotifMap[w.ID][l_notifs-1].DiscordRequest.Embeds, types.DiscordEmbed{
-								Type:        "rich",
-								Color:       "16745472",
-								Description: n.GetInfoMarkdown(),
-								Title:       n.GetTitle(),
-								Fields:      fields,
-							})
+							discordNotifMap[w.ID][l_notifs-1].DiscordRequest.Embeds = append(discordNotifMap[w.ID][l_notifs-1].DiscordRequest.Embeds, buildDiscordEmbed(n, lang))
 						} else {
 							notifs = append(notifs, types.TransitWebhook{
 								Channel: w.Destination.String,
 								Content: types.TransitWebhookContent{
 									Webhook: w,
-									Event: types.WebhookEvent{
-										Network:     utils.GetNetwork(),
-										Name:        string(n.GetEventName()),
-										Title:       n.GetTitle(),
-										Description: n.GetInfo(false),
-										Epoch:       n.GetEpoch(),
-										Target:      n.GetEventFilter(),
-									},
+									Event:   buildWebhookEvent(n, lang),
 								},
 							})
 						}
@@ -889,63 +975,85 @@ func queueWebhookNotifications(notificationsByUserID map[uint64]map[types.EventN
 		}
 		// process notifs
 		for _, n := range notifs {
-			_, err = useDB.Exec(`INSERT INTO notification_queue (created, channel, content) VALUES (now(), $1, $2);`, n.Channel, n.Content)
+			_, err = tx.Exec(`INSERT INTO notification_queue (created, channel, content) VALUES (now(), $1, $2);`, n.Channel, n.Content)
 			if err != nil {
-				logger.WithError(err).Errorf("error inserting into webhooks_queue")
-			} else {
-				metrics.NotificationsQueued.WithLabelValues(n.Channel, n.Content.Event.Name).Inc()
+				return fmt.Errorf("error inserting into webhooks_queue: %w", err)
 			}
+			metrics.NotificationsQueued.WithLabelValues(n.Channel, n.Content.Event.Name).Inc()
 		}
 		// process discord notifs
 		for _, dNotifs := range discordNotifMap {
 			for _, n := range dNotifs {
-				_, err = useDB.Exec(`INSERT INTO notification_queue (created, channel, content) VALUES (now(), 'webhook_discord', $1);`, n)
+				_, err = tx.Exec(`INSERT INTO notification_queue (created, channel, content) VALUES (now(), 'webhook_discord', $1);`, n)
 				if err != nil {
-					logger.WithError(err).Errorf("error inserting into webhooks_queue (discord)")
-					continue
-				} else {
-					metrics.NotificationsQueued.WithLabelValues("webhook_discord", "multi").Inc()
+					return fmt.Errorf("error inserting into webhooks_queue (discord): %w", err)
 				}
+				metrics.NotificationsQueued.WithLabelValues("webhook_discord", "multi").Inc()
 			}
 		}
 	}
 	return nil
 }
 
+// buildWebhookEvent creates the payload that is posted to a generic webhook for a notification
+func buildWebhookEvent(n types.Notification, lang string) types.WebhookEvent {
+	return types.WebhookEvent{
+		Network:     utils.GetNetwork(),
+		Name:        string(n.GetEventName()),
+		Title:       n.GetTitle(lang),
+		Description: n.GetInfo(lang, false),
+		Epoch:       n.GetEpoch(),
+		Target:      n.GetEventFilter(),
+	}
+}
+
+// buildDiscordEmbed creates the discord embed of a notification, up to 10 embeds are sent in a single discord request
+func buildDiscordEmbed(n types.Notification, lang string) types.DiscordEmbed {
+	fields := []types.DiscordEmbedField{
+		{
+			Name:   utils.Tr(lang, "notification_discord_epoch"),
+			Value:  fmt.Sprintf("[%[1]v](https://%[2]s/%[1]v)", n.GetEpoch(), utils.Config.Frontend.SiteDomain+"/epoch"),
+			Inline: false,
+		},
+	}
+
+	if strings.HasPrefix(string(n.GetEventName()), "monitoring") || n.GetEventName() == types.EthClientUpdateEventName || n.GetEventName() == types.RocketpoolColleteralMaxReached || n.GetEventName() == types.RocketpoolColleteralMinReached {
+		fields = append(fields,
+			types.DiscordEmbedField{
+				Name:   utils.Tr(lang, "notification_discord_target"),
+				Value:  fmt.Sprintf("%v", n.GetEventFilter()),
+				Inline: false,
+			})
+	}
+	return types.DiscordEmbed{
+		Type:        "rich",
+		Color:       "16745472",
+		Description: n.GetInfoMarkdown(lang),
+		Title:       n.GetTitle(lang),
+		Fields:      fields,
+	}
+}
+
 func sendWebhookNotifications(useDB *sqlx.DB) error {
 	var notificationQueueItem []types.TransitWebhook
 
-	err := useDB.Select(&notificationQueueItem, `SELECT
-		id,
-		created,
-		sent,
-		channel,
-		content
-	FROM notification_queue where sent is null and channel = 'webhook' order by created asc`)
+	err := claimNotificationQueueItems(useDB, "webhook", &notificationQueueItem)
 	if err != nil {
-		return fmt.Errorf("error querying notification queue, err: %w", err)
+		return err
 	}
 	client := &http.Client{Timeout: time.Second * 30}
 
 	logger.Infof("processing %v webhook notifications", len(notificationQueueItem))
 
-	// now := time.Now()
+	wg := &sync.WaitGroup{}
 	for _, n := range notificationQueueItem {
 		// do not retry after 5 attempts
 		if n.Content.Webhook.Retries > 5 {
-			// if n.Content.Webhook.LastSent.Valid && n.Content.Webhook.LastSent.Time.Add(time.Hour*1).Before(now) {
-			// 	_, err = useDB.Exec(`UPDATE users_webhooks SET retries = 0 WHERE id = $1;`, n.Content.Webhook.ID)
-			// 	if err != nil {
-			// 		logger.WithError(err).Errorf("error updating users_webhooks table; setting retries to zero")
-			// 		continue
-			// 	}
-			// } else {
-			_, err := db.FrontendWriterDB.Exec(`DELETE FROM notification_queue where id = $1`, n.Id)
+			err := markNotificationsDeadLettered(useDB, "webhook", []uint64{n.Id}, "webhook exceeded the maximum number of retries")
 			if err != nil {
-				return fmt.Errorf("error deleting from notification queue: %w", err)
+				return err
 			}
 			continue
-			// }
 		}
 
 		reqBody := new(bytes.Buffer)
@@ -957,14 +1065,16 @@ func sendWebhookNotifications(useDB *sqlx.DB) error {
 
 		_, err = url.Parse(n.Content.Webhook.Url)
 		if err != nil {
-			_, err := db.FrontendWriterDB.Exec(`DELETE FROM notification_queue where id = $1`, n.Id)
+			err := markNotificationsDeadLettered(useDB, "webhook", []uint64{n.Id}, fmt.Sprintf("invalid webhook url: %v", err))
 			if err != nil {
-				return fmt.Errorf("error deleting from notification queue: %w", err)
+				return err
 			}
 			continue
 		}
 
+		wg.Add(1)
 		go func(n types.TransitWebhook) {
+			defer wg.Done()
 			if n.Content.Webhook.Retries > 0 {
 				time.Sleep(time.Duration(n.Content.Webhook.Retries) * time.Second)
 			}
@@ -976,7 +1086,7 @@ func sendWebhookNotifications(useDB *sqlx.DB) error {
 			}
 
 			if resp != nil && resp.StatusCode < 400 {
-				_, err := useDB.Exec(`UPDATE notification_queue SET sent = now();`)
+				err := markNotificationsSent(useDB, []uint64{n.Id})
 				if err != nil {
 					logger.WithError(err).Errorf("error updating notification_queue table")
 					return
@@ -1000,6 +1110,15 @@ func sendWebhookNotifications(useDB *sqlx.DB) error {
 					errResp.Body = string(b)
 				}
 
+				sendErr := err
+				if sendErr == nil {
+					sendErr = fmt.Errorf("webhook responded with status %v", errResp.Status)
+				}
+				err = markNotificationsFailed(useDB, "webhook", []uint64{n.Id}, sendErr)
+				if err != nil {
+					logger.WithError(err).Errorf("error updating notification_queue table")
+				}
+
 				_, err = useDB.Exec(`UPDATE users_webhooks SET retries = retries + 1, last_sent = now(), request = $2, response = $3 WHERE id = $1;`, n.Content.Webhook.ID, n.Content, errResp)
 				if err != nil {
 					logger.WithError(err).Errorf("error updating users_webhooks table; increasing retries")
@@ -1009,21 +1128,16 @@ func sendWebhookNotifications(useDB *sqlx.DB) error {
 		}(n)
 
 	}
+	wg.Wait()
 	return nil
 }
 
 func sendDiscordNotifications(useDB *sqlx.DB) error {
 	var notificationQueueItem []types.TransitDiscord
 
-	err := useDB.Select(&notificationQueueItem, `SELECT
-		id,
-		created,
-		sent,
-		channel,
-		content
-	FROM notification_queue where sent is null and channel = 'webhook_discord' order by created asc`)
+	err := claimNotificationQueueItems(useDB, "webhook_discord", &notificationQueueItem)
 	if err != nil {
-		return fmt.Errorf("error querying notification queue, err: %w", err)
+		return err
 	}
 	client := &http.Client{Timeout: time.Second * 30}
 
@@ -1036,7 +1150,10 @@ func sendDiscordNotifications(useDB *sqlx.DB) error {
 	for _, n := range notificationQueueItem {
 		// purge the event from existence if the retry counter is over 5
 		if n.Content.Webhook.Retries > 5 {
-			db.FrontendWriterDB.Exec(`DELETE FROM notification_queue where id = $1`, n.Id)
+			err := markNotificationsDeadLettered(useDB, "webhook_discord", []uint64{n.Id}, "webhook exceeded the maximum number of retries")
+			if err != nil {
+				return err
+			}
 			continue
 		}
 		if _, exists := webhookMap[n.Content.Webhook.ID]; !exists {
@@ -1047,55 +1164,76 @@ func sendDiscordNotifications(useDB *sqlx.DB) error {
 		}
 		notifMap[n.Content.Webhook.ID] = append(notifMap[n.Content.Webhook.ID], n)
 	}
+	wg := &sync.WaitGroup{}
 	for _, webhook := range webhookMap {
+		wg.Add(1)
 		go func(webhook types.UserWebhook, reqs []types.TransitDiscord) {
+			defer wg.Done()
+			sentIds := make([]uint64, 0)
+			var sendErr error
 			defer func() {
 				// update retries counters in db based on end result
-				_, err = useDB.Exec(`UPDATE users_webhooks SET retries = $1, last_sent = now() WHERE id = $2;`, webhook.Retries, webhook.ID)
+				_, err := useDB.Exec(`UPDATE users_webhooks SET retries = $1, last_sent = now() WHERE id = $2;`, webhook.Retries, webhook.ID)
 				if err != nil {
 					logger.Warnf("failed to update retries counter to %v for webhook %v: %v", webhook.Retries, webhook.ID, err)
 				}
 
-				// mark notifcations as sent in db
-				ids := make([]uint64, 0)
-				for _, req := range reqs {
-					ids = append(ids, req.Id)
-				}
-				_, err = db.FrontendWriterDB.Exec(`UPDATE notification_queue SET sent = now() where id = ANY($1)`, pq.Array(ids))
+				// mark delivered notifcations as sent in db, the remaining ones are retried later
+				err = markNotificationsSent(useDB, sentIds)
 				if err != nil {
 					logger.Warnf("failed to update sent for notifcations in queue: %v", err)
 				}
+
+				failedIds := make([]uint64, 0)
+				for _, req := range reqs[len(sentIds):] {
+					failedIds = append(failedIds, req.Id)
+				}
+				err = markNotificationsFailed(useDB, "webhook_discord", failedIds, sendErr)
+				if err != nil {
+					logger.Warnf("failed to update failed notifcations in queue: %v", err)
+				}
 			}()
 
-			_, err = url.Parse(webhook.Url)
+			_, err := url.Parse(webhook.Url)
 			if err != nil {
 				logger.Errorf("invalid url for webhook id %v: %v", webhook.ID, err)
+				sendErr = fmt.Errorf("invalid webhook url: %w", err)
 				return
 			}
 
 			for i := 0; i < len(reqs); i++ {
 				if webhook.Retries > 5 {
+					sendErr = fmt.Errorf("webhook exceeded the maximum number of retries")
 					break // stop
 				}
 				// sleep between retries
 				time.Sleep(time.Duration(webhook.Retries) * time.Second)
+				owned, leaseErr := renewNotificationLease(useDB, reqs[i].Id)
+				if leaseErr != nil || !owned {
+					// the remaining requests are released, items of another instance are not touched by markNotificationsFailed
+					sendErr = fmt.Errorf("error renewing the lease of discord notification %v: %v", reqs[i].Id, leaseErr)
+					break
+				}
 
 				reqBody := new(bytes.Buffer)
 				err := json.NewEncoder(reqBody).Encode(reqs[i].Content.DiscordRequest)
 				if err != nil {
 					logger.Errorf("error marschalling discord webhook event: %v", err)
-					continue // skip
+					sentIds = append(sentIds, reqs[i].Id) // skip, retrying would not help
+					continue
 				}
 
 				logger.Infof("discord request webhook body: %s", reqBody.String())
 				resp, err := client.Post(webhook.Url, "application/json", reqBody)
 				if err != nil {
 					logger.Errorf("error sending discord webhook request: %v", err)
+					sendErr = err
 				} else {
 					metrics.NotificationsSent.WithLabelValues("webhook_discord", resp.Status).Inc()
 				}
 				if resp != nil && resp.StatusCode < 400 {
 					webhook.Retries = 0
+					sentIds = append(sentIds, reqs[i].Id)
 				} else {
 					webhook.Retries++
 					var errResp types.ErrorResponse
@@ -1103,11 +1241,12 @@ func sendDiscordNotifications(useDB *sqlx.DB) error {
 					if resp != nil {
 						b, err := io.ReadAll(resp.Body)
 						if err != nil {
-							logger.Error("error reading body for discord webhook response: %v", err)
+							logger.Errorf("error reading body for discord webhook response: %v", err)
 						} else {
 							errResp.Body = string(b)
 						}
 						errResp.Status = resp.Status
+						sendErr = fmt.Errorf("discord webhook responded with status %v", resp.Status)
 					}
 					logger.Errorf("error pushing discord webhook: %v", errResp.Body)
 
@@ -1121,12 +1260,30 @@ func sendDiscordNotifications(useDB *sqlx.DB) error {
 			}
 		}(webhook, notifMap[webhook.ID])
 	}
+	wg.Wait()
 
 	return nil
 }
 
-func getUrlPart(validatorIndex uint64) string {
-	return fmt.Sprintf(` For more information visit: https://%s/validator/%v.`, utils.Config.Frontend.SiteDomain, validatorIndex)
+func getUrlPart(lang string, validator interface{}) string {
+	return utils.Tr(lang, "notification_url_part", utils.Config.Frontend.SiteDomain, fmt.Sprint(validator))
+}
+
+const (
+	notificationFormatText = iota
+	notificationFormatHTML
+	notificationFormatMarkdown
+)
+
+// formatNotificationLink renders text as a link to path on the explorer in the given notification format
+func formatNotificationLink(format int, text interface{}, path string) string {
+	switch format {
+	case notificationFormatHTML:
+		return fmt.Sprintf(`<a href="https://%s%s">%v</a>`, utils.Config.Frontend.SiteDomain, path, text)
+	case notificationFormatMarkdown:
+		return fmt.Sprintf(`[%v](https://%s%s)`, text, utils.Config.Frontend.SiteDomain, path)
+	}
+	return fmt.Sprint(text)
 }
 
 func collectBlockProposalNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, status uint64, eventName types.EventName, epoch uint64) error {
@@ -1272,31 +1429,35 @@ func (n *validatorProposalNotification) GetEventName() types.EventName {
 	return n.EventName
 }
 
-func (n *validatorProposalNotification) GetInfo(includeUrl bool) string {
-	var generalPart = ""
+func (n *validatorProposalNotification) GetInfo(lang string, includeUrl bool) string {
+	generalPart := n.info(lang, notificationFormatText)
+	if includeUrl {
+		return generalPart + getUrlPart(lang, n.ValidatorIndex)
+	}
+	return generalPart
+}
+
+func (n *validatorProposalNotification) info(lang string, format int) string {
+	validator := formatNotificationLink(format, n.ValidatorIndex, fmt.Sprintf("/validator/%v", n.ValidatorIndex))
 	switch n.Status {
 	case 0:
-		generalPart = fmt.Sprintf(`New scheduled block proposal for Validator %v.`, n.ValidatorIndex)
+		return utils.Tr(lang, "notification_proposal_scheduled_info", validator)
 	case 1:
-		generalPart = fmt.Sprintf(`Validator %v proposed a new block with %v ETH execution reward.`, n.ValidatorIndex, n.Reward)
+		return utils.Tr(lang, "notification_proposal_submitted_info", validator, fmt.Sprint(n.Reward))
 	case 2:
-		generalPart = fmt.Sprintf(`Validator %v missed a block proposal.`, n.ValidatorIndex)
-	}
-
-	if includeUrl {
-		return generalPart + getUrlPart(n.ValidatorIndex)
+		return utils.Tr(lang, "notification_proposal_missed_info", validator)
 	}
-	return generalPart
+	return ""
 }
 
-func (n *validatorProposalNotification) GetTitle() string {
+func (n *validatorProposalNotification) GetTitle(lang string) string {
 	switch n.Status {
 	case 0:
-		return "Block Proposal Scheduled"
+		return utils.Tr(lang, "notification_proposal_scheduled_title")
 	case 1:
-		return "New Block Proposal"
+		return utils.Tr(lang, "notification_proposal_submitted_title")
 	case 2:
-		return "Block Proposal Missed"
+		return utils.Tr(lang, "notification_proposal_missed_title")
 	}
 	return "-"
 }
@@ -1305,18 +1466,8 @@ func (n *validatorProposalNotification) GetEventFilter() string {
 	return n.EventFilter
 }
 
-func (n *validatorProposalNotification) GetInfoMarkdown() string {
-	var generalPart = ""
-	switch n.Status {
-	case 0:
-		generalPart = fmt.Sprintf(`New scheduled block proposal for Validator [%[1]v](https://%[2]v/%[1]v).`, n.ValidatorIndex, utils.Config.Frontend.SiteDomain+"/validator")
-	case 1:
-		generalPart = fmt.Sprintf(`Validator [%[1]v](https://%[2]v/%[1]v) proposed a new block with %[3]v ETH execution reward.`, n.ValidatorIndex, utils.Config.Frontend.SiteDomain+"/validator", n.Reward)
-	case 2:
-		generalPart = fmt.Sprintf(`Validator [%[1]v](https://%[2]v/%[1]v) missed a block proposal.`, n.ValidatorIndex, utils.Config.Frontend.SiteDomain+"/validator")
-	}
-
-	return generalPart
+func (n *validatorProposalNotification) GetInfoMarkdown(lang string) string {
+	return n.info(lang, notificationFormatMarkdown)
 }
 
 func collectAttestationAndOfflineValidatorNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, status uint64, epoch uint64) error {
@@ -1420,7 +1571,7 @@ func collectAttestationAndOfflineValidatorNotifications(notificationsByUserID ma
 			}
 			isDuplicate := false
 			for _, userEvent := range notificationsByUserID[*sub.UserID][n.GetEventName()] {
-				if userEvent.GetSubscriptionID() == n.SubscriptionID {
+				if userEvent.GetSubscriptionID() == n.SubscriptionID && userEvent.GetEventFilter() == n.EventFilter {
 					isDuplicate = true
 				}
 			}
@@ -1530,7 +1681,7 @@ func collectAttestationAndOfflineValidatorNotifications(notificationsByUserID ma
 			}
 			isDuplicate := false
 			for _, userEvent := range notificationsByUserID[*sub.UserID][n.GetEventName()] {
-				if userEvent.GetSubscriptionID() == n.SubscriptionID {
+				if userEvent.GetSubscriptionID() == n.SubscriptionID && userEvent.GetEventFilter() == n.EventFilter {
 					isDuplicate = true
 					break
 				}
@@ -1589,7 +1740,7 @@ func collectAttestationAndOfflineValidatorNotifications(notificationsByUserID ma
 			}
 			isDuplicate := false
 			for _, userEvent := range notificationsByUserID[*sub.UserID][n.GetEventName()] {
-				if userEvent.GetSubscriptionID() == n.SubscriptionID {
+				if userEvent.GetSubscriptionID() == n.SubscriptionID && userEvent.GetEventFilter() == n.EventFilter {
 					isDuplicate = true
 					break
 				}
@@ -1634,259 +1785,634 @@ func (n *validatorIsOfflineNotification) GetEpoch() uint64 {
 	return n.EventEpoch
 }
 
-func (n *validatorIsOfflineNotification) GetInfo(includeUrl bool) string {
+func (n *validatorIsOfflineNotification) GetInfo(lang string, includeUrl bool) string {
+	if includeUrl {
+		return n.info(lang, notificationFormatHTML)
+	}
+	return n.info(lang, notificationFormatText)
+}
+
+func (n *validatorIsOfflineNotification) info(lang string, format int) string {
+	validator := formatNotificationLink(format, n.ValidatorIndex, fmt.Sprintf("/validator/%v", n.ValidatorIndex))
+	epoch := formatNotificationLink(format, n.EventEpoch, fmt.Sprintf("/epoch/%v", n.EventEpoch))
 	if n.IsOffline {
-		if includeUrl {
-			return fmt.Sprintf(`Validator <a href="https://%[3]v/validator/%[1]v">%[1]v</a> is offline since epoch <a href="https://%[3]v/epoch/%[2]v">%[2]v</a>).`, n.ValidatorIndex, n.EventEpoch, utils.Config.Frontend.SiteDomain)
-		} else {
-			return fmt.Sprintf(`Validator %v is offline since epoch %v.`, n.ValidatorIndex, n.EventEpoch)
-		}
+		return utils.Tr(lang, "notification_validator_offline_info", validator, epoch)
+	}
+	return utils.TrPlural(lang, "notification_validator_online_info", int(n.EpochsOffline), validator, epoch, fmt.Sprint(n.EpochsOffline))
+}
+
+func (n *validatorIsOfflineNotification) GetTitle(lang string) string {
+	if n.IsOffline {
+		return utils.Tr(lang, "notification_validator_offline_title")
 	} else {
-		if includeUrl {
-			return fmt.Sprintf(`Validator <a href="https://%[3]v/validator/%[1]v">%[1]v</a> is back online since epoch <a href="https://%[3]v/epoch/%[2]v">%[2]v</a> (was offline for %[4]v epoch(s)).`, n.ValidatorIndex, n.EventEpoch, utils.Config.Frontend.SiteDomain, n.EpochsOffline)
-		} else {
-			return fmt.Sprintf(`Validator %v is back online since epoch %v (was offline for %v epoch(s)).`, n.ValidatorIndex, n.EventEpoch, n.EpochsOffline)
+		return utils.Tr(lang, "notification_validator_online_title")
+	}
+}
+
+func (n *validatorIsOfflineNotification) GetEventFilter() string {
+	return n.EventFilter
+}
+
+func (n *validatorIsOfflineNotification) GetEmailAttachment() *types.EmailAttachment {
+	return nil
+}
+
+func (n *validatorIsOfflineNotification) GetUnsubscribeHash() string {
+	if n.UnsubscribeHash.Valid {
+		return n.UnsubscribeHash.String
+	}
+	return ""
+}
+
+func (n *validatorIsOfflineNotification) GetInfoMarkdown(lang string) string {
+	return n.info(lang, notificationFormatMarkdown)
+}
+
+type validatorAttestationNotification struct {
+	SubscriptionID     uint64
+	ValidatorIndex     uint64
+	ValidatorPublicKey string
+	Epoch              uint64
+	Status             uint64 // * Can be 0 = scheduled | missed, 1 executed
+	EventName          types.EventName
+	Slot               uint64
+	InclusionSlot      uint64
+	EventFilter        string
+	UnsubscribeHash    sql.NullString
+}
+
+func (n *validatorAttestationNotification) GetLatestState() string {
+	return ""
+}
+
+func (n *validatorAttestationNotification) GetSubscriptionID() uint64 {
+	return n.SubscriptionID
+}
+
+func (n *validatorAttestationNotification) GetEventName() types.EventName {
+	return n.EventName
+}
+
+func (n *validatorAttestationNotification) GetEpoch() uint64 {
+	return n.Epoch
+}
+
+func (n *validatorAttestationNotification) GetInfo(lang string, includeUrl bool) string {
+	if includeUrl {
+		return n.info(lang, notificationFormatHTML)
+	}
+	return n.info(lang, notificationFormatText)
+}
+
+func (n *validatorAttestationNotification) info(lang string, format int) string {
+	validator := formatNotificationLink(format, n.ValidatorIndex, fmt.Sprintf("/validator/%v", n.ValidatorIndex))
+	slot := formatNotificationLink(format, n.Slot, fmt.Sprintf("/slot/%v", n.Slot))
+	switch n.Status {
+	case 0:
+		return utils.Tr(lang, "notification_attestation_missed_info", validator, slot)
+	case 1:
+		return utils.Tr(lang, "notification_attestation_submitted_info", validator, slot)
+	}
+	return ""
+}
+
+func (n *validatorAttestationNotification) GetTitle(lang string) string {
+	switch n.Status {
+	case 0:
+		return utils.Tr(lang, "notification_attestation_missed_title")
+	case 1:
+		return utils.Tr(lang, "notification_attestation_submitted_title")
+	}
+	return "-"
+}
+
+func (n *validatorAttestationNotification) GetEventFilter() string {
+	return n.EventFilter
+}
+
+func (n *validatorAttestationNotification) GetEmailAttachment() *types.EmailAttachment {
+	return nil
+}
+
+func (n *validatorAttestationNotification) GetUnsubscribeHash() string {
+	if n.UnsubscribeHash.Valid {
+		return n.UnsubscribeHash.String
+	}
+	return ""
+}
+
+func (n *validatorAttestationNotification) GetInfoMarkdown(lang string) string {
+	return n.info(lang, notificationFormatMarkdown)
+}
+
+type validatorGotSlashedNotification struct {
+	SubscriptionID  uint64
+	ValidatorIndex  uint64
+	Epoch           uint64
+	Slasher         uint64
+	Reason          string
+	EventFilter     string
+	UnsubscribeHash sql.NullString
+}
+
+func (n *validatorGotSlashedNotification) GetLatestState() string {
+	return ""
+}
+
+func (n *validatorGotSlashedNotification) GetUnsubscribeHash() string {
+	if n.UnsubscribeHash.Valid {
+		return n.UnsubscribeHash.String
+	}
+	return ""
+}
+
+func (n *validatorGotSlashedNotification) GetEmailAttachment() *types.EmailAttachment {
+	return nil
+}
+
+func (n *validatorGotSlashedNotification) GetSubscriptionID() uint64 {
+	return n.SubscriptionID
+}
+
+func (n *validatorGotSlashedNotification) GetEpoch() uint64 {
+	return n.Epoch
+}
+
+func (n *validatorGotSlashedNotification) GetEventName() types.EventName {
+	return types.ValidatorGotSlashedEventName
+}
+
+func (n *validatorGotSlashedNotification) GetInfo(lang string, includeUrl bool) string {
+	generalPart := n.info(lang, notificationFormatText)
+	if includeUrl {
+		return generalPart + getUrlPart(lang, n.ValidatorIndex)
+	}
+	return generalPart
+}
+
+func (n *validatorGotSlashedNotification) info(lang string, format int) string {
+	validator := formatNotificationLink(format, n.ValidatorIndex, fmt.Sprintf("/validator/%v", n.ValidatorIndex))
+	epoch := formatNotificationLink(format, n.Epoch, fmt.Sprintf("/epoch/%v", n.Epoch))
+	slasher := formatNotificationLink(format, n.Slasher, fmt.Sprintf("/validator/%v", n.Slasher))
+	return utils.Tr(lang, "notification_validator_slashed_info", validator, epoch, slasher, n.Reason)
+}
+
+func (n *validatorGotSlashedNotification) GetTitle(lang string) string {
+	return utils.Tr(lang, "notification_validator_slashed_title")
+}
+
+func (n *validatorGotSlashedNotification) GetEventFilter() string {
+	return n.EventFilter
+}
+
+func (n *validatorGotSlashedNotification) GetInfoMarkdown(lang string) string {
+	return n.info(lang, notificationFormatMarkdown)
+}
+
+func collectValidatorGotSlashedNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, epoch uint64) error {
+	dbResult, err := db.GetValidatorsGotSlashed(epoch)
+	if err != nil {
+		return fmt.Errorf("error getting slashed validators from database, err: %w", err)
+	}
+	if len(dbResult) == 0 {
+		return nil
+	}
+
+	pubkeys := make([]string, 0, len(dbResult))
+	slashingsByPubkey := make(map[string]int, len(dbResult))
+	for i, event := range dbResult {
+		pubkey := hex.EncodeToString(event.SlashedValidatorPubkey)
+		pubkeys = append(pubkeys, pubkey)
+		slashingsByPubkey[pubkey] = i
+	}
+
+	subscribers, err := getValidatorSubscribers(types.ValidatorGotSlashedEventName, pubkeys)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subscribers {
+		i, ok := slashingsByPubkey[sub.EventFilter]
+		if !ok {
+			continue
 		}
-	}
-}
+		event := dbResult[i]
 
-func (n *validatorIsOfflineNotification) GetTitle() string {
-	if n.IsOffline {
-		return "Validator is Offline"
-	} else {
-		return "Validator Back Online"
+		logger.Infof("creating %v notification for validator %v in epoch %v", event.SlashedValidatorPubkey, event.Reason, epoch)
+
+		n := &validatorGotSlashedNotification{
+			SubscriptionID:  sub.ID,
+			Slasher:         event.SlasherIndex,
+			Epoch:           event.Epoch,
+			Reason:          event.Reason,
+			ValidatorIndex:  event.SlashedValidatorIndex,
+			EventFilter:     sub.EventFilter,
+			UnsubscribeHash: sub.UnsubscribeHash,
+		}
+		addNotification(notificationsByUserID, sub.UserID, n)
 	}
+
+	return nil
 }
 
-func (n *validatorIsOfflineNotification) GetEventFilter() string {
-	return n.EventFilter
+type validatorExitInitiatedNotification struct {
+	SubscriptionID  uint64
+	ValidatorIndex  uint64
+	Epoch           uint64
+	Slot            uint64
+	EventFilter     string
+	UnsubscribeHash sql.NullString
 }
 
-func (n *validatorIsOfflineNotification) GetEmailAttachment() *types.EmailAttachment {
-	return nil
+func (n *validatorExitInitiatedNotification) GetLatestState() string {
+	return ""
 }
 
-func (n *validatorIsOfflineNotification) GetUnsubscribeHash() string {
+func (n *validatorExitInitiatedNotification) GetUnsubscribeHash() string {
 	if n.UnsubscribeHash.Valid {
 		return n.UnsubscribeHash.String
 	}
 	return ""
 }
 
-func (n *validatorIsOfflineNotification) GetInfoMarkdown() string {
-	if n.IsOffline {
-		return fmt.Sprintf(`Validator [%[1]v](https://%[3]v/validator/%[1]v) is offline since epoch [%[2]v](https://%[3]v/epoch/%[2]v).`, n.ValidatorIndex, n.EventEpoch, utils.Config.Frontend.SiteDomain)
-	} else {
-		return fmt.Sprintf(`Validator [%[1]v](https://%[3]v/validator/%[1]v) is back online since epoch [%[2]v](https://%[3]v/epoch/%[2]v) (was offline for %[4]v epoch(s)).`, n.ValidatorIndex, n.EventEpoch, utils.Config.Frontend.SiteDomain, n.EpochsOffline)
-	}
-}
-
-type validatorAttestationNotification struct {
-	SubscriptionID     uint64
-	ValidatorIndex     uint64
-	ValidatorPublicKey string
-	Epoch              uint64
-	Status             uint64 // * Can be 0 = scheduled | missed, 1 executed
-	EventName          types.EventName
-	Slot               uint64
-	InclusionSlot      uint64
-	EventFilter        string
-	UnsubscribeHash    sql.NullString
-}
-
-func (n *validatorAttestationNotification) GetLatestState() string {
-	return ""
+func (n *validatorExitInitiatedNotification) GetEmailAttachment() *types.EmailAttachment {
+	return nil
 }
 
-func (n *validatorAttestationNotification) GetSubscriptionID() uint64 {
+func (n *validatorExitInitiatedNotification) GetSubscriptionID() uint64 {
 	return n.SubscriptionID
 }
 
-func (n *validatorAttestationNotification) GetEventName() types.EventName {
-	return n.EventName
+func (n *validatorExitInitiatedNotification) GetEpoch() uint64 {
+	return n.Epoch
 }
 
-func (n *validatorAttestationNotification) GetEpoch() uint64 {
-	return n.Epoch
+func (n *validatorExitInitiatedNotification) GetEventName() types.EventName {
+	return types.ValidatorExitInitiatedEventName
 }
 
-func (n *validatorAttestationNotification) GetInfo(includeUrl bool) string {
-	var generalPart = ""
+func (n *validatorExitInitiatedNotification) GetInfo(lang string, includeUrl bool) string {
+	generalPart := n.info(lang, notificationFormatText)
 	if includeUrl {
-		switch n.Status {
-		case 0:
-			generalPart = fmt.Sprintf(`Validator <a href="https://%[3]v/validator/%[1]v">%[1]v</a> missed an attestation at slot <a href="https://%[3]v/slot/%[2]v">%[2]v</a>.`, n.ValidatorIndex, n.Slot, utils.Config.Frontend.SiteDomain)
-			//generalPart = fmt.Sprintf(`New scheduled attestation for Validator %v at slot %v.`, n.ValidatorIndex, n.Slot)
-		case 1:
-			generalPart = fmt.Sprintf(`Validator <a href="https://%[3]v/validator/%[1]v">%[1]v</a> submitted a successful attestation for slot  <a href="https://%[3]v/slot/%[2]v">%[2]v</a>.`, n.ValidatorIndex, n.Slot, utils.Config.Frontend.SiteDomain)
-		}
-		// return generalPart + getUrlPart(n.ValidatorIndex)
-	} else {
-		switch n.Status {
-		case 0:
-			generalPart = fmt.Sprintf(`Validator %v missed an attestation at slot %v.`, n.ValidatorIndex, n.Slot)
-			//generalPart = fmt.Sprintf(`New scheduled attestation for Validator %v at slot %v.`, n.ValidatorIndex, n.Slot)
-		case 1:
-			generalPart = fmt.Sprintf(`Validator %v submitted a successful attestation for slot %v.`, n.ValidatorIndex, n.Slot)
-		}
+		return generalPart + getUrlPart(lang, n.ValidatorIndex)
 	}
 	return generalPart
 }
 
-func (n *validatorAttestationNotification) GetTitle() string {
-	switch n.Status {
-	case 0:
-		return "Attestation Missed"
-	case 1:
-		return "Attestation Submitted"
-	}
-	return "-"
+func (n *validatorExitInitiatedNotification) info(lang string, format int) string {
+	validator := formatNotificationLink(format, n.ValidatorIndex, fmt.Sprintf("/validator/%v", n.ValidatorIndex))
+	slot := formatNotificationLink(format, n.Slot, fmt.Sprintf("/slot/%v", n.Slot))
+	return utils.Tr(lang, "notification_validator_exit_initiated_info", validator, slot)
 }
 
-func (n *validatorAttestationNotification) GetEventFilter() string {
+func (n *validatorExitInitiatedNotification) GetTitle(lang string) string {
+	return utils.Tr(lang, "notification_validator_exit_initiated_title")
+}
+
+func (n *validatorExitInitiatedNotification) GetEventFilter() string {
 	return n.EventFilter
 }
 
-func (n *validatorAttestationNotification) GetEmailAttachment() *types.EmailAttachment {
-	return nil
+func (n *validatorExitInitiatedNotification) GetInfoMarkdown(lang string) string {
+	return n.info(lang, notificationFormatMarkdown)
 }
 
-func (n *validatorAttestationNotification) GetUnsubscribeHash() string {
-	if n.UnsubscribeHash.Valid {
-		return n.UnsubscribeHash.String
+func collectValidatorExitInitiatedNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, epoch uint64) error {
+	exits, err := db.GetValidatorsExitInitiated(epoch)
+	if err != nil {
+		return fmt.Errorf("error getting voluntary exits from database, err: %w", err)
+	}
+	if len(exits) == 0 {
+		return nil
 	}
-	return ""
-}
 
-func (n *validatorAttestationNotification) GetInfoMarkdown() string {
-	var generalPart = ""
-	switch n.Status {
-	case 0:
-		generalPart = fmt.Sprintf(`Validator [%[1]v](https://%[3]v/validator/%[1]v) missed an attestation at slot [%[2]v](https://%[3]v/slot/%[2]v).`, n.ValidatorIndex, n.Slot, utils.Config.Frontend.SiteDomain)
-	case 1:
-		generalPart = fmt.Sprintf(`Validator [%[1]v](https://%[3]v/validator/%[1]v) submitted a successful attestation for slot [%[2]v](https://%[3]v/slot/%[2]v).`, n.ValidatorIndex, n.Slot, utils.Config.Frontend.SiteDomain)
+	pubkeys := make([]string, 0, len(exits))
+	exitsByPubkey := make(map[string]*types.ValidatorExitInitiated, len(exits))
+	for _, exit := range exits {
+		pubkey := hex.EncodeToString(exit.Pubkey)
+		pubkeys = append(pubkeys, pubkey)
+		exitsByPubkey[pubkey] = exit
 	}
-	return generalPart
+
+	subscribers, err := getValidatorSubscribers(types.ValidatorExitInitiatedEventName, pubkeys)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subscribers {
+		exit := exitsByPubkey[sub.EventFilter]
+		if exit == nil {
+			continue
+		}
+		logger.Infof("creating %v notification for validator %v in epoch %v", types.ValidatorExitInitiatedEventName, exit.ValidatorIndex, epoch)
+
+		n := &validatorExitInitiatedNotification{
+			SubscriptionID:  sub.ID,
+			ValidatorIndex:  exit.ValidatorIndex,
+			Epoch:           exit.Epoch,
+			Slot:            exit.Slot,
+			EventFilter:     sub.EventFilter,
+			UnsubscribeHash: sub.UnsubscribeHash,
+		}
+		addNotification(notificationsByUserID, sub.UserID, n)
+	}
+
+	return nil
 }
 
-type validatorGotSlashedNotification struct {
-	SubscriptionID  uint64
-	ValidatorIndex  uint64
-	Epoch           uint64
-	Slasher         uint64
-	Reason          string
-	EventFilter     string
-	UnsubscribeHash sql.NullString
+// validatorWithdrawalCredentialsNotification is used for changed withdrawal credentials as well as for deposits whose
+// withdrawal credentials do not match the existing ones
+type validatorWithdrawalCredentialsNotification struct {
+	SubscriptionID           uint64
+	ValidatorIndex           sql.NullInt64
+	Pubkey                   []byte
+	Epoch                    uint64
+	EventName                types.EventName
+	OldWithdrawalCredentials []byte
+	NewWithdrawalCredentials []byte
+	Amount                   uint64
+	TxHash                   []byte
+	EventFilter              string
+	UnsubscribeHash          sql.NullString
 }
 
-func (n *validatorGotSlashedNotification) GetLatestState() string {
+func (n *validatorWithdrawalCredentialsNotification) GetLatestState() string {
 	return ""
 }
 
-func (n *validatorGotSlashedNotification) GetUnsubscribeHash() string {
+func (n *validatorWithdrawalCredentialsNotification) GetUnsubscribeHash() string {
 	if n.UnsubscribeHash.Valid {
 		return n.UnsubscribeHash.String
 	}
 	return ""
 }
 
-func (n *validatorGotSlashedNotification) GetEmailAttachment() *types.EmailAttachment {
+func (n *validatorWithdrawalCredentialsNotification) GetEmailAttachment() *types.EmailAttachment {
 	return nil
 }
 
-func (n *validatorGotSlashedNotification) GetSubscriptionID() uint64 {
+func (n *validatorWithdrawalCredentialsNotification) GetSubscriptionID() uint64 {
 	return n.SubscriptionID
 }
 
-func (n *validatorGotSlashedNotification) GetEpoch() uint64 {
+func (n *validatorWithdrawalCredentialsNotification) GetEpoch() uint64 {
 	return n.Epoch
 }
 
-func (n *validatorGotSlashedNotification) GetEventName() types.EventName {
-	return types.ValidatorGotSlashedEventName
+func (n *validatorWithdrawalCredentialsNotification) GetEventName() types.EventName {
+	return n.EventName
+}
+
+// validatorName returns the index of the validator or, for deposits of validators that are not yet known to the beacon chain, its shortened pubkey
+func (n *validatorWithdrawalCredentialsNotification) validatorName() string {
+	if n.ValidatorIndex.Valid {
+		return fmt.Sprintf("%v", n.ValidatorIndex.Int64)
+	}
+	if len(n.Pubkey) > 6 {
+		return fmt.Sprintf("0x%x…", n.Pubkey[:6])
+	}
+	return fmt.Sprintf("0x%x", n.Pubkey)
+}
+
+func (n *validatorWithdrawalCredentialsNotification) validatorLink() string {
+	if n.ValidatorIndex.Valid {
+		return fmt.Sprintf("%v", n.ValidatorIndex.Int64)
+	}
+	return fmt.Sprintf("%x", n.Pubkey)
 }
 
-func (n *validatorGotSlashedNotification) GetInfo(includeUrl bool) string {
-	generalPart := fmt.Sprintf(`Validator %v has been slashed at epoch %v by validator %v for %s.`, n.ValidatorIndex, n.Epoch, n.Slasher, n.Reason)
+func (n *validatorWithdrawalCredentialsNotification) GetInfo(lang string, includeUrl bool) string {
+	generalPart := n.info(lang, notificationFormatText)
 	if includeUrl {
-		return generalPart + getUrlPart(n.ValidatorIndex)
+		return generalPart + getUrlPart(lang, n.validatorLink())
 	}
 	return generalPart
 }
 
-func (n *validatorGotSlashedNotification) GetTitle() string {
-	return "Validator got Slashed"
+func (n *validatorWithdrawalCredentialsNotification) info(lang string, format int) string {
+	validator := formatNotificationLink(format, n.validatorName(), fmt.Sprintf("/validator/%v", n.validatorLink()))
+	oldCredentials := fmt.Sprintf("0x%x", n.OldWithdrawalCredentials)
+	newCredentials := fmt.Sprintf("0x%x", n.NewWithdrawalCredentials)
+	if format == notificationFormatMarkdown {
+		oldCredentials = "`" + oldCredentials + "`"
+		newCredentials = "`" + newCredentials + "`"
+	}
+	switch n.EventName {
+	case types.ValidatorWithdrawalCredentialsChangedEventName:
+		epoch := formatNotificationLink(format, n.Epoch, fmt.Sprintf("/epoch/%v", n.Epoch))
+		return utils.Tr(lang, "notification_withdrawal_credentials_changed_info", validator, oldCredentials, newCredentials, epoch)
+	case types.ValidatorDepositCredentialsMismatchEventName:
+		tx := formatNotificationLink(format, fmt.Sprintf("0x%x", n.TxHash), fmt.Sprintf("/tx/0x%x", n.TxHash))
+		return utils.Tr(lang, "notification_deposit_credentials_mismatch_info", fmt.Sprintf("%.4f", float64(n.Amount)/1e9), validator, tx, newCredentials, oldCredentials)
+	}
+	return ""
+}
+
+func (n *validatorWithdrawalCredentialsNotification) GetTitle(lang string) string {
+	switch n.EventName {
+	case types.ValidatorWithdrawalCredentialsChangedEventName:
+		return utils.Tr(lang, "notification_withdrawal_credentials_changed_title")
+	case types.ValidatorDepositCredentialsMismatchEventName:
+		return utils.Tr(lang, "notification_deposit_credentials_mismatch_title")
+	}
+	return "-"
 }
 
-func (n *validatorGotSlashedNotification) GetEventFilter() string {
+func (n *validatorWithdrawalCredentialsNotification) GetEventFilter() string {
 	return n.EventFilter
 }
 
-func (n *validatorGotSlashedNotification) GetInfoMarkdown() string {
-	generalPart := fmt.Sprintf(`Validator [%[1]v](https://%[5]v/validator/%[1]v) has been slashed at epoch [%[2]v](https://%[5]v/epoch/%[2]v) by validator [%[3]v](https://%[5]v/validator/%[3]v) for %[4]s.`, n.ValidatorIndex, n.Epoch, n.Slasher, n.Reason, utils.Config.Frontend.SiteDomain)
-	return generalPart
+func (n *validatorWithdrawalCredentialsNotification) GetInfoMarkdown(lang string) string {
+	return n.info(lang, notificationFormatMarkdown)
 }
 
-func collectValidatorGotSlashedNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, epoch uint64) error {
-	dbResult, err := db.GetValidatorsGotSlashed(epoch)
+func collectValidatorWithdrawalCredentialsChangedNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, epoch uint64) error {
+	changes, err := db.GetValidatorsWithdrawalCredentialsChanged(epoch)
 	if err != nil {
-		return fmt.Errorf("error getting slashed validators from database, err: %w", err)
+		return fmt.Errorf("error getting withdrawal credential changes from database, err: %w", err)
 	}
-	query := ""
-	resultsLen := len(dbResult)
-	for i, event := range dbResult {
-		query += fmt.Sprintf(`SELECT %d as ref, id, user_id, ENCODE(unsubscribe_hash, 'hex') as unsubscribe_hash from users_subscriptions where event_name = $1 AND event_filter = '%x'`, i, event.SlashedValidatorPubkey)
-		if i < resultsLen-1 {
-			query += " UNION "
+	if len(changes) == 0 {
+		return nil
+	}
+
+	pubkeys := make([]string, 0, len(changes))
+	changesByPubkey := make(map[string]*types.ValidatorWithdrawalCredentialsChange, len(changes))
+	for _, change := range changes {
+		pubkey := hex.EncodeToString(change.Pubkey)
+		pubkeys = append(pubkeys, pubkey)
+		changesByPubkey[pubkey] = change
+	}
+
+	subscribers, err := getValidatorSubscribers(types.ValidatorWithdrawalCredentialsChangedEventName, pubkeys)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subscribers {
+		change := changesByPubkey[sub.EventFilter]
+		if change == nil {
+			continue
+		}
+		logger.Infof("creating %v notification for validator %v in epoch %v", types.ValidatorWithdrawalCredentialsChangedEventName, change.ValidatorIndex, epoch)
+
+		n := &validatorWithdrawalCredentialsNotification{
+			SubscriptionID:           sub.ID,
+			ValidatorIndex:           sql.NullInt64{Int64: int64(change.ValidatorIndex), Valid: true},
+			Pubkey:                   change.Pubkey,
+			Epoch:                    change.Epoch,
+			EventName:                types.ValidatorWithdrawalCredentialsChangedEventName,
+			OldWithdrawalCredentials: change.OldWithdrawalCredentials,
+			NewWithdrawalCredentials: change.NewWithdrawalCredentials,
+			EventFilter:              sub.EventFilter,
+			UnsubscribeHash:          sub.UnsubscribeHash,
 		}
+		addNotification(notificationsByUserID, sub.UserID, n)
 	}
 
-	if query == "" {
-		return nil
+	return nil
+}
+
+// the deposit cursor, the deposits and their subscribers are read through these functions so the collection can be tested without a database
+var getDepositsNotifiedBlock = db.GetDepositsNotifiedBlock
+var getLatestDepositBlock = db.GetLatestDepositBlock
+var getDepositCredentials = db.GetDepositCredentials
+var getDepositSubscribers = getValidatorSubscribers
+
+// depositCredentialsMismatch returns the withdrawal credentials a deposit conflicts with. Deposits to an existing pubkey never change
+// its withdrawal credentials, a later deposit whose credentials differ from both the first valid deposit of the pubkey and the current
+// credentials of the validator is either a mistake or a front-running attempt.
+func depositCredentialsMismatch(d *types.DepositCredentials) ([]byte, bool) {
+	if d.FirstTxHash == nil || bytes.Equal(d.TxHash, d.FirstTxHash) {
+		return nil, false
+	}
+	existing := d.FirstWithdrawalCredentials
+	if d.ValidatorWithdrawalCredentials != nil {
+		existing = d.ValidatorWithdrawalCredentials
+	}
+	if bytes.Equal(d.WithdrawalCredentials, d.FirstWithdrawalCredentials) || bytes.Equal(d.WithdrawalCredentials, existing) {
+		return nil, false
+	}
+	return existing, true
+}
+
+// collectDepositCredentialsMismatchNotifications checks the deposits of the blocks indexed since the last run and returns the last
+// checked block, the collector persists it once the notifications are queued
+func collectDepositCredentialsMismatchNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, epoch uint64) (uint64, error) {
+	notifiedBlock, exists, err := getDepositsNotifiedBlock()
+	if err != nil {
+		return 0, fmt.Errorf("error getting last notified deposit block from database, err: %w", err)
+	}
+	lastBlock, err := getLatestDepositBlock()
+	if err != nil {
+		return 0, fmt.Errorf("error getting last deposit block from database, err: %w", err)
+	}
+	if !exists {
+		// the first run only starts the cursor, historic deposits are not notified
+		return lastBlock, nil
+	}
+	if lastBlock <= notifiedBlock {
+		return notifiedBlock, nil
 	}
 
-	var subscribers []struct {
-		Ref             uint64         `db:"ref"`
-		Id              uint64         `db:"id"`
-		UserId          uint64         `db:"user_id"`
-		UnsubscribeHash sql.NullString `db:"unsubscribe_hash"`
+	deposits, err := getDepositCredentials(notifiedBlock, lastBlock)
+	if err != nil {
+		return 0, fmt.Errorf("error getting deposits from database, err: %w", err)
 	}
 
-	name := string(types.ValidatorGotSlashedEventName)
-	if utils.Config.Chain.Config.ConfigName != "" {
-		name = utils.Config.Chain.Config.ConfigName + ":" + name
+	type mismatchedDeposit struct {
+		*types.DepositCredentials
+		ExistingWithdrawalCredentials []byte
+	}
+	pubkeys := make([]string, 0, len(deposits))
+	depositsByPubkey := make(map[string][]mismatchedDeposit, len(deposits))
+	for _, deposit := range deposits {
+		existing, mismatch := depositCredentialsMismatch(deposit)
+		if !mismatch {
+			continue
+		}
+		pubkey := hex.EncodeToString(deposit.Pubkey)
+		if _, exists := depositsByPubkey[pubkey]; !exists {
+			pubkeys = append(pubkeys, pubkey)
+		}
+		depositsByPubkey[pubkey] = append(depositsByPubkey[pubkey], mismatchedDeposit{deposit, existing})
+	}
+	if len(pubkeys) == 0 {
+		return lastBlock, nil
 	}
-	err = db.FrontendWriterDB.Select(&subscribers, query, name)
+
+	subscribers, err := getDepositSubscribers(types.ValidatorDepositCredentialsMismatchEventName, pubkeys)
 	if err != nil {
-		return fmt.Errorf("error querying subscribers, err: %w", err)
+		return 0, err
 	}
 
 	for _, sub := range subscribers {
-		event := dbResult[sub.Ref]
-
-		logger.Infof("creating %v notification for validator %v in epoch %v", event.SlashedValidatorPubkey, event.Reason, epoch)
+		for _, deposit := range depositsByPubkey[sub.EventFilter] {
+			logger.Infof("creating %v notification for deposit 0x%x in epoch %v", types.ValidatorDepositCredentialsMismatchEventName, deposit.TxHash, epoch)
 
-		n := &validatorGotSlashedNotification{
-			SubscriptionID:  sub.Id,
-			Slasher:         event.SlasherIndex,
-			Epoch:           event.Epoch,
-			Reason:          event.Reason,
-			ValidatorIndex:  event.SlashedValidatorIndex,
-			EventFilter:     hex.EncodeToString(event.SlashedValidatorPubkey),
-			UnsubscribeHash: sub.UnsubscribeHash,
+			n := &validatorWithdrawalCredentialsNotification{
+				SubscriptionID:           sub.ID,
+				ValidatorIndex:           deposit.ValidatorIndex,
+				Pubkey:                   deposit.Pubkey,
+				Epoch:                    epoch,
+				EventName:                types.ValidatorDepositCredentialsMismatchEventName,
+				OldWithdrawalCredentials: deposit.ExistingWithdrawalCredentials,
+				NewWithdrawalCredentials: deposit.WithdrawalCredentials,
+				Amount:                   deposit.Amount,
+				TxHash:                   deposit.TxHash,
+				EventFilter:              sub.EventFilter,
+				UnsubscribeHash:          sub.UnsubscribeHash,
+			}
+			addNotification(notificationsByUserID, sub.UserID, n)
 		}
+	}
 
-		if _, exists := notificationsByUserID[sub.UserId]; !exists {
-			notificationsByUserID[sub.UserId] = map[types.EventName][]types.Notification{}
-		}
-		if _, exists := notificationsByUserID[sub.UserId][n.GetEventName()]; !exists {
-			notificationsByUserID[sub.UserId][n.GetEventName()] = []types.Notification{}
+	return lastBlock, nil
+}
+
+type validatorSubscriber struct {
+	ID              uint64         `db:"id"`
+	UserID          uint64         `db:"user_id"`
+	EventFilter     string         `db:"event_filter"`
+	UnsubscribeHash sql.NullString `db:"unsubscribe_hash"`
+}
+
+// getValidatorSubscribers returns the subscriptions of the given event for the given validator pubkeys (hex encoded, without 0x prefix),
+// including the validators covered by subscription rules
+func getValidatorSubscribers(eventName types.EventName, pubkeys []string) ([]validatorSubscriber, error) {
+	var subscribers []validatorSubscriber
+	err := db.FrontendWriterDB.Select(&subscribers, `
+		SELECT id, user_id, event_filter, ENCODE(unsubscribe_hash, 'hex') AS unsubscribe_hash
+		FROM users_subscriptions
+		WHERE event_name = $1 AND event_filter = ANY($2)`, utils.GetNetwork()+":"+string(eventName), pq.StringArray(pubkeys))
+	if err != nil {
+		return nil, fmt.Errorf("error querying subscribers of %v, err: %w", eventName, err)
+	}
+
+	ruleSubs, err := db.GetSubscriptionRuleSubs(eventName, pubkeys)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range ruleSubs {
+		if sub.ID == nil || sub.UserID == nil {
+			continue
 		}
-		notificationsByUserID[sub.UserId][n.GetEventName()] = append(notificationsByUserID[sub.UserId][n.GetEventName()], n)
-		metrics.NotificationsCollected.WithLabelValues(string(n.GetEventName())).Inc()
+		subscribers = append(subscribers, validatorSubscriber{
+			ID:              *sub.ID,
+			UserID:          *sub.UserID,
+			EventFilter:     sub.EventFilter,
+			UnsubscribeHash: sub.UnsubscribeHash,
+		})
 	}
+	return subscribers, nil
+}
 
-	return nil
+// addNotification adds a collected notification of the given user to the notification map
+func addNotification(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, userID uint64, n types.Notification) {
+	if _, exists := notificationsByUserID[userID]; !exists {
+		notificationsByUserID[userID] = map[types.EventName][]types.Notification{}
+	}
+	notificationsByUserID[userID][n.GetEventName()] = append(notificationsByUserID[userID][n.GetEventName()], n)
+	metrics.NotificationsCollected.WithLabelValues(string(n.GetEventName())).Inc()
 }
 
 type ethClientNotification struct {
@@ -1925,51 +2451,15 @@ func (n *ethClientNotification) GetEventName() types.EventName {
 	return types.EthClientUpdateEventName
 }
 
-func (n *ethClientNotification) GetInfo(includeUrl bool) string {
-	generalPart := fmt.Sprintf(`A new version for %s is available.`, n.EthClient)
+func (n *ethClientNotification) GetInfo(lang string, includeUrl bool) string {
+	generalPart := utils.Tr(lang, "notification_eth_client_update_info", n.EthClient)
 	if includeUrl {
-		url := ""
-		switch n.EthClient {
-		case "Geth":
-			url = "https://github.com/ethereum/go-ethereum/releases"
-		case "Nethermind":
-			url = "https://github.com/NethermindEth/nethermind/releases"
-		case "OpenEthereum":
-			url = "https://github.com/openethereum/openethereum/releases"
-		case "Teku":
-			url = "https://github.com/ConsenSys/teku/releases"
-		case "Prysm":
-			url = "https://github.com/prysmaticlabs/prysm/releases"
-		case "Nimbus":
-			url = "https://github.com/status-im/nimbus-eth2/releases"
-		case "Lighthouse":
-			url = "https://github.com/sigp/lighthouse/releases"
-		case "Erigon":
-			url = "https://github.com/ledgerwatch/erigon/releases"
-		case "Rocketpool":
-			url = "https://github.com/rocket-pool/smartnode-install/releases"
-		case "MEV-Boost":
-			url = "https://github.com/flashbots/mev-boost/releases"
-		case "Lodestar":
-			url = "https://github.com/chainsafe/lodestar/releases"
-		default:
-			url = "https://beaconcha.in/ethClients"
-		}
-
-		return generalPart + " " + url
+		return generalPart + " " + n.releasesUrl()
 	}
 	return generalPart
 }
 
-func (n *ethClientNotification) GetTitle() string {
-	return fmt.Sprintf("New %s update", n.EthClient)
-}
-
-func (n *ethClientNotification) GetEventFilter() string {
-	return n.EventFilter
-}
-
-func (n *ethClientNotification) GetInfoMarkdown() string {
+func (n *ethClientNotification) releasesUrl() string {
 	url := ""
 	switch n.EthClient {
 	case "Geth":
@@ -1997,10 +2487,19 @@ func (n *ethClientNotification) GetInfoMarkdown() string {
 	default:
 		url = "https://beaconcha.in/ethClients"
 	}
+	return url
+}
 
-	generalPart := fmt.Sprintf(`A new version for [%s](%s) is available.`, n.EthClient, url)
+func (n *ethClientNotification) GetTitle(lang string) string {
+	return utils.Tr(lang, "notification_eth_client_update_title", n.EthClient)
+}
 
-	return generalPart
+func (n *ethClientNotification) GetEventFilter() string {
+	return n.EventFilter
+}
+
+func (n *ethClientNotification) GetInfoMarkdown(lang string) string {
+	return utils.Tr(lang, "notification_eth_client_update_info", fmt.Sprintf("[%s](%s)", n.EthClient, n.releasesUrl()))
 }
 
 func collectEthClientNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, eventName types.EventName) error {
@@ -2261,38 +2760,36 @@ func (n *monitorMachineNotification) GetEventName() types.EventName {
 	return n.EventName
 }
 
-func (n *monitorMachineNotification) GetInfo(includeUrl bool) string {
-	switch n.EventName {
-	case types.MonitoringMachineDiskAlmostFullEventName:
-		return fmt.Sprintf(`Your staking machine "%v" is running low on storage space.`, n.MachineName)
-	case types.MonitoringMachineOfflineEventName:
-		return fmt.Sprintf(`Your staking machine "%v" might be offline. It has not been seen for a couple minutes now.`, n.MachineName)
-	case types.MonitoringMachineCpuLoadEventName:
-		return fmt.Sprintf(`Your staking machine "%v" has reached your configured CPU usage threshold.`, n.MachineName)
-	case types.MonitoringMachineSwitchedToETH1FallbackEventName:
-		return fmt.Sprintf(`Your staking machine "%v" has switched to your configured ETH1 fallback`, n.MachineName)
-	case types.MonitoringMachineSwitchedToETH2FallbackEventName:
-		return fmt.Sprintf(`Your staking machine "%v" has switched to your configured ETH2 fallback`, n.MachineName)
-	case types.MonitoringMachineMemoryUsageEventName:
-		return fmt.Sprintf(`Your staking machine "%v" has reached your configured RAM threshold.`, n.MachineName)
+func (n *monitorMachineNotification) GetInfo(lang string, includeUrl bool) string {
+	key := n.localeKey()
+	if key == "" {
+		return ""
 	}
-	return ""
+	return utils.Tr(lang, key+"_info", n.MachineName)
+}
+
+func (n *monitorMachineNotification) GetTitle(lang string) string {
+	key := n.localeKey()
+	if key == "" {
+		return ""
+	}
+	return utils.Tr(lang, key+"_title")
 }
 
-func (n *monitorMachineNotification) GetTitle() string {
+func (n *monitorMachineNotification) localeKey() string {
 	switch n.EventName {
 	case types.MonitoringMachineDiskAlmostFullEventName:
-		return "Storage Warning"
+		return "notification_machine_disk_full"
 	case types.MonitoringMachineOfflineEventName:
-		return "Staking Machine Offline"
+		return "notification_machine_offline"
 	case types.MonitoringMachineCpuLoadEventName:
-		return "High CPU Load"
+		return "notification_machine_cpu_load"
 	case types.MonitoringMachineSwitchedToETH1FallbackEventName:
-		return "ETH1 Fallback Active"
+		return "notification_machine_eth1_fallback"
 	case types.MonitoringMachineSwitchedToETH2FallbackEventName:
-		return "ETH2 Fallback Active"
+		return "notification_machine_eth2_fallback"
 	case types.MonitoringMachineMemoryUsageEventName:
-		return "Memory Warning"
+		return "notification_machine_memory_usage"
 	}
 	return ""
 }
@@ -2301,8 +2798,8 @@ func (n *monitorMachineNotification) GetEventFilter() string {
 	return n.MachineName
 }
 
-func (n *monitorMachineNotification) GetInfoMarkdown() string {
-	return n.GetInfo(false)
+func (n *monitorMachineNotification) GetInfoMarkdown(lang string) string {
+	return n.GetInfo(lang, false)
 }
 
 type taxReportNotification struct {
@@ -2370,21 +2867,20 @@ func (n *taxReportNotification) GetEventName() types.EventName {
 	return types.TaxReportEventName
 }
 
-func (n *taxReportNotification) GetInfo(includeUrl bool) string {
-	generalPart := fmt.Sprint(`Please find attached the income history of your selected validators.`)
-	return generalPart
+func (n *taxReportNotification) GetInfo(lang string, includeUrl bool) string {
+	return utils.Tr(lang, "notification_tax_report_info")
 }
 
-func (n *taxReportNotification) GetTitle() string {
-	return fmt.Sprint("Income Report")
+func (n *taxReportNotification) GetTitle(lang string) string {
+	return utils.Tr(lang, "notification_tax_report_title")
 }
 
 func (n *taxReportNotification) GetEventFilter() string {
 	return n.EventFilter
 }
 
-func (n *taxReportNotification) GetInfoMarkdown() string {
-	return n.GetInfo(false)
+func (n *taxReportNotification) GetInfoMarkdown(lang string) string {
+	return n.GetInfo(lang, false)
 }
 
 func collectTaxReportNotificationNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, eventName types.EventName) error {
@@ -2472,22 +2968,20 @@ func (n *networkNotification) GetEventName() types.EventName {
 	return types.NetworkLivenessIncreasedEventName
 }
 
-func (n *networkNotification) GetInfo(includeUrl bool) string {
-	generalPart := fmt.Sprintf(`Network experienced finality issues. Learn more at https://%v/charts/network_liveness`, utils.Config.Frontend.SiteDomain)
-	return generalPart
+func (n *networkNotification) GetInfo(lang string, includeUrl bool) string {
+	return utils.Tr(lang, "notification_network_liveness_info", utils.Config.Frontend.SiteDomain)
 }
 
-func (n *networkNotification) GetTitle() string {
-	return "Beaconchain Network Issues"
+func (n *networkNotification) GetTitle(lang string) string {
+	return utils.Tr(lang, "notification_network_liveness_title")
 }
 
 func (n *networkNotification) GetEventFilter() string {
 	return n.EventFilter
 }
 
-func (n *networkNotification) GetInfoMarkdown() string {
-	generalPart := fmt.Sprintf(`Network experienced finality issues ([view chart](https://%v/charts/network_liveness)).`, utils.Config.Frontend.SiteDomain)
-	return generalPart
+func (n *networkNotification) GetInfoMarkdown(lang string) string {
+	return utils.Tr(lang, "notification_network_liveness_info_markdown", utils.Config.Frontend.SiteDomain)
 }
 
 func collectNetworkNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, eventName types.EventName) error {
@@ -2579,16 +3073,20 @@ func (n *rocketpoolNotification) GetEventName() types.EventName {
 	return n.EventName
 }
 
-func (n *rocketpoolNotification) GetInfo(includeUrl bool) string {
+func (n *rocketpoolNotification) GetInfo(lang string, includeUrl bool) string {
 	switch n.EventName {
 	case types.RocketpoolCommissionThresholdEventName:
-		return fmt.Sprintf(`The current RPL commission rate of %v has reached your configured threshold.`, n.ExtraData)
+		return utils.Tr(lang, "notification_rocketpool_commission_info", n.ExtraData)
 	case types.RocketpoolNewClaimRoundStartedEventName:
-		return fmt.Sprintf(`A new reward round has started. You can now claim your rewards from the previous round.`)
+		extras := strings.Split(n.ExtraData, "|")
+		if len(extras) == 2 {
+			return utils.Tr(lang, "notification_rocketpool_claim_round_projection_info", extras[0], extras[1])
+		}
+		return utils.Tr(lang, "notification_rocketpool_claim_round_info")
 	case types.RocketpoolColleteralMaxReached:
-		return `Your RPL collateral has reached your configured threshold at 150%.`
+		return utils.Tr(lang, "notification_rocketpool_collateral_max_info")
 	case types.RocketpoolColleteralMinReached:
-		return `Your RPL collateral has reached your configured threshold at 10%.`
+		return utils.Tr(lang, "notification_rocketpool_collateral_min_info")
 	case types.SyncCommitteeSoon:
 		extras := strings.Split(n.ExtraData, "|")
 		if len(extras) != 3 {
@@ -2603,24 +3101,24 @@ func (n *rocketpoolNotification) GetInfo(includeUrl bool) string {
 			inTime = time.Until(utils.EpochToTime(syncStartEpoch))
 		}
 
-		return fmt.Sprintf(`Your validator %v has been elected to be part of the next sync committee. The additional duties start at epoch %v, which is in %s and will last for a day until epoch %v.`, extras[0], extras[1], inTime.Round(time.Second), extras[2])
+		return utils.Tr(lang, "notification_sync_committee_soon_info", extras[0], extras[1], inTime.Round(time.Second).String(), extras[2])
 	}
 
 	return ""
 }
 
-func (n *rocketpoolNotification) GetTitle() string {
+func (n *rocketpoolNotification) GetTitle(lang string) string {
 	switch n.EventName {
 	case types.RocketpoolCommissionThresholdEventName:
-		return fmt.Sprintf(`Rocketpool Commission`)
+		return utils.Tr(lang, "notification_rocketpool_commission_title")
 	case types.RocketpoolNewClaimRoundStartedEventName:
-		return fmt.Sprintf(`Rocketpool Claim Available`)
+		return utils.Tr(lang, "notification_rocketpool_claim_round_title")
 	case types.RocketpoolColleteralMaxReached:
-		return `Rocketpool Max Collateral`
+		return utils.Tr(lang, "notification_rocketpool_collateral_max_title")
 	case types.RocketpoolColleteralMinReached:
-		return `Rocketpool Min Collateral`
+		return utils.Tr(lang, "notification_rocketpool_collateral_min_title")
 	case types.SyncCommitteeSoon:
-		return `Sync Committee Duty`
+		return utils.Tr(lang, "notification_sync_committee_soon_title")
 	}
 	return ""
 }
@@ -2629,8 +3127,8 @@ func (n *rocketpoolNotification) GetEventFilter() string {
 	return n.EventFilter
 }
 
-func (n *rocketpoolNotification) GetInfoMarkdown() string {
-	return n.GetInfo(false)
+func (n *rocketpoolNotification) GetInfoMarkdown(lang string) string {
+	return n.GetInfo(lang, false)
 }
 
 func collectRocketpoolComissionNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, eventName types.EventName) error {
@@ -2719,6 +3217,18 @@ func collectRocketpoolRewardClaimRoundNotifications(notificationsByUserID map[ui
 			return err
 		}
 
+		pubkeys := make(pq.ByteaArray, 0, len(dbResult))
+		for _, r := range dbResult {
+			pubkey, err := hex.DecodeString(strings.TrimPrefix(r.EventFilter, "0x"))
+			if err == nil && len(pubkey) > 0 {
+				pubkeys = append(pubkeys, pubkey)
+			}
+		}
+		projections, err := getRocketpoolRewardProjections(pubkeys)
+		if err != nil {
+			return err
+		}
+
 		for _, r := range dbResult {
 			n := &rocketpoolNotification{
 				SubscriptionID:  r.SubscriptionID,
@@ -2726,6 +3236,7 @@ func collectRocketpoolRewardClaimRoundNotifications(notificationsByUserID map[ui
 				Epoch:           r.Epoch,
 				EventFilter:     r.EventFilter,
 				EventName:       eventName,
+				ExtraData:       projections[strings.ToLower(strings.TrimPrefix(r.EventFilter, "0x"))],
 				UnsubscribeHash: r.UnsubscribeHash,
 			}
 			if _, exists := notificationsByUserID[r.UserID]; !exists {
@@ -2742,6 +3253,35 @@ func collectRocketpoolRewardClaimRoundNotifications(notificationsByUserID map[ui
 	return nil
 }
 
+// getRocketpoolRewardProjections returns the projected rewards of the current interval of the nodes of the given minipool validators, formatted as "<rpl>|<eth>" by hex pubkey
+func getRocketpoolRewardProjections(pubkeys pq.ByteaArray) (map[string]string, error) {
+	projections := make(map[string]string, len(pubkeys))
+	if len(pubkeys) == 0 {
+		return projections, nil
+	}
+
+	var dbResult []struct {
+		Pubkey           []byte   `db:"pubkey"`
+		RPLCollateral    BigFloat `db:"rpl_collateral"`
+		SmoothingPoolETH BigFloat `db:"smoothing_pool_eth"`
+	}
+	err := db.WriterDb.Select(&dbResult, `
+		SELECT rplm.pubkey, rplrp.rpl_collateral, rplrp.smoothing_pool_eth
+		FROM rocketpool_minipools rplm
+		INNER JOIN rocketpool_reward_projections rplrp ON rplrp.node_address = rplm.node_address
+		WHERE rplm.pubkey = ANY($1)`, pubkeys)
+	if err != nil {
+		return nil, fmt.Errorf("error getting rocketpool reward projections: %w", err)
+	}
+
+	for _, r := range dbResult {
+		rpl := new(big.Float).Quo(r.RPLCollateral.bigFloat(), big.NewFloat(1e18))
+		eth := new(big.Float).Quo(r.SmoothingPoolETH.bigFloat(), big.NewFloat(1e18))
+		projections[hex.EncodeToString(r.Pubkey)] = rpl.Text('f', 2) + " RPL|" + eth.Text('f', 4) + " ETH"
+	}
+	return projections, nil
+}
+
 func collectRocketpoolRPLCollateralNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, eventName types.EventName, epoch uint64) error {
 
 	pubkeys, subMap, err := db.GetSubsForEventFilter(eventName)
@@ -2927,6 +3467,23 @@ func collectSyncCommittee(notificationsByUserID map[uint64]map[types.EventName][
 		return err
 	}
 
+	ruleSubs, err := db.GetSubscriptionRuleSubs(eventName, pubKeys)
+	if err != nil {
+		return err
+	}
+	for _, sub := range ruleSubs {
+		if sub.ID == nil || sub.UserID == nil || (sub.LastSent != nil && sub.LastSent.After(time.Now().Add(-26*time.Hour))) {
+			continue
+		}
+		dbResult = append(dbResult, struct {
+			SubscriptionID  uint64         `db:"id"`
+			UserID          uint64         `db:"user_id"`
+			Epoch           uint64         `db:"created_epoch"`
+			EventFilter     string         `db:"event_filter"`
+			UnsubscribeHash sql.NullString `db:"unsubscribe_hash"`
+		}{*sub.ID, *sub.UserID, sub.CreatedEpoch, sub.EventFilter, sub.UnsubscribeHash})
+	}
+
 	for _, r := range dbResult {
 		n := &rocketpoolNotification{
 			SubscriptionID:  r.SubscriptionID,
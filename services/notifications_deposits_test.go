@@ -0,0 +1,124 @@
+package services
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/hex"
+	"eth2-exporter/types"
+	"testing"
+)
+
+func TestDepositCredentialsMismatch(t *testing.T) {
+	creds := func(b byte) []byte { return bytes.Repeat([]byte{b}, 32) }
+
+	tests := []struct {
+		name     string
+		deposit  *types.DepositCredentials
+		mismatch bool
+		existing []byte
+	}{
+		{
+			name:    "first deposit of the pubkey",
+			deposit: &types.DepositCredentials{TxHash: []byte{1}, WithdrawalCredentials: creds(1), FirstTxHash: []byte{1}, FirstWithdrawalCredentials: creds(1)},
+		},
+		{
+			name:    "pubkey without a valid deposit",
+			deposit: &types.DepositCredentials{TxHash: []byte{2}, WithdrawalCredentials: creds(2)},
+		},
+		{
+			name:    "top up with the credentials of the first deposit",
+			deposit: &types.DepositCredentials{TxHash: []byte{2}, WithdrawalCredentials: creds(1), FirstTxHash: []byte{1}, FirstWithdrawalCredentials: creds(1)},
+		},
+		{
+			name:    "top up with the changed credentials of the validator",
+			deposit: &types.DepositCredentials{TxHash: []byte{2}, WithdrawalCredentials: creds(3), FirstTxHash: []byte{1}, FirstWithdrawalCredentials: creds(1), ValidatorWithdrawalCredentials: creds(3)},
+		},
+		{
+			name:     "deposit before the validator exists",
+			deposit:  &types.DepositCredentials{TxHash: []byte{2}, WithdrawalCredentials: creds(2), FirstTxHash: []byte{1}, FirstWithdrawalCredentials: creds(1)},
+			mismatch: true,
+			existing: creds(1),
+		},
+		{
+			name:     "deposit to an existing validator",
+			deposit:  &types.DepositCredentials{TxHash: []byte{2}, WithdrawalCredentials: creds(2), FirstTxHash: []byte{1}, FirstWithdrawalCredentials: creds(1), ValidatorWithdrawalCredentials: creds(3)},
+			mismatch: true,
+			existing: creds(3),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing, mismatch := depositCredentialsMismatch(tt.deposit)
+			if mismatch != tt.mismatch || !bytes.Equal(existing, tt.existing) {
+				t.Errorf("expected mismatch %v with existing credentials %x, got %v with %x", tt.mismatch, tt.existing, mismatch, existing)
+			}
+		})
+	}
+}
+
+func TestCollectDepositCredentialsMismatchCursor(t *testing.T) {
+	notified, latest, deposits, subscribers := getDepositsNotifiedBlock, getLatestDepositBlock, getDepositCredentials, getDepositSubscribers
+	defer func() {
+		getDepositsNotifiedBlock, getLatestDepositBlock, getDepositCredentials, getDepositSubscribers = notified, latest, deposits, subscribers
+	}()
+
+	pubkey := bytes.Repeat([]byte{0xab}, 48)
+	mismatched := &types.DepositCredentials{
+		TxHash:                     []byte{2},
+		Pubkey:                     pubkey,
+		WithdrawalCredentials:      bytes.Repeat([]byte{2}, 32),
+		FirstTxHash:                []byte{1},
+		FirstWithdrawalCredentials: bytes.Repeat([]byte{1}, 32),
+	}
+
+	var notifiedBlock, latestBlock uint64
+	var notifiedExists bool
+	var queriedRange []uint64
+	getDepositsNotifiedBlock = func() (uint64, bool, error) { return notifiedBlock, notifiedExists, nil }
+	getLatestDepositBlock = func() (uint64, error) { return latestBlock, nil }
+	getDepositCredentials = func(afterBlock, toBlock uint64) ([]*types.DepositCredentials, error) {
+		queriedRange = []uint64{afterBlock, toBlock}
+		return []*types.DepositCredentials{mismatched}, nil
+	}
+	getDepositSubscribers = func(eventName types.EventName, pubkeys []string) ([]validatorSubscriber, error) {
+		return []validatorSubscriber{{ID: 1, UserID: 2, EventFilter: hex.EncodeToString(pubkey), UnsubscribeHash: sql.NullString{}}}, nil
+	}
+
+	tests := []struct {
+		name           string
+		notifiedBlock  uint64
+		notifiedExists bool
+		latestBlock    uint64
+		expectedBlock  uint64
+		expectedRange  []uint64
+		notifications  int
+	}{
+		{name: "the first run starts the cursor at the latest deposit", latestBlock: 100, expectedBlock: 100},
+		{name: "new deposits are checked and advance the cursor", notifiedBlock: 100, notifiedExists: true, latestBlock: 120, expectedBlock: 120, expectedRange: []uint64{100, 120}, notifications: 1},
+		{name: "the cursor stays without new deposits", notifiedBlock: 120, notifiedExists: true, latestBlock: 120, expectedBlock: 120},
+		{name: "the cursor does not move back if deposits were removed", notifiedBlock: 120, notifiedExists: true, latestBlock: 110, expectedBlock: 120},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifiedBlock, notifiedExists, latestBlock = tt.notifiedBlock, tt.notifiedExists, tt.latestBlock
+			queriedRange = nil
+
+			notificationsByUserID := map[uint64]map[types.EventName][]types.Notification{}
+			block, err := collectDepositCredentialsMismatchNotifications(notificationsByUserID, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if block != tt.expectedBlock {
+				t.Errorf("expected the cursor at block %v, got %v", tt.expectedBlock, block)
+			}
+			if len(queriedRange) != len(tt.expectedRange) || (len(tt.expectedRange) > 0 && (queriedRange[0] != tt.expectedRange[0] || queriedRange[1] != tt.expectedRange[1])) {
+				t.Errorf("expected the deposits of the blocks %v to be checked, got %v", tt.expectedRange, queriedRange)
+			}
+			if n := len(notificationsByUserID[2][types.ValidatorDepositCredentialsMismatchEventName]); n != tt.notifications {
+				t.Errorf("expected %v notifications, got %v", tt.notifications, n)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"database/sql"
+	"eth2-exporter/mail"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	utils.LocalesDir = "../locales"
+	utils.Config = &types.Config{}
+	utils.Config.Frontend.SiteDomain = "beaconcha.in"
+	utils.Config.Chain.Name = "prater"
+	utils.Config.Chain.Config.SecondsPerSlot = 12
+	utils.Config.Chain.Config.SlotsPerEpoch = 32
+	os.Exit(m.Run())
+}
+
+// i18nTestNotifications returns a notification for every notification type and variant that is rendered from the locale bundles
+func i18nTestNotifications() []types.Notification {
+	notifications := []types.Notification{
+		&validatorGotSlashedNotification{ValidatorIndex: 1, Epoch: 2, Slasher: 3, Reason: "Attestation Violation"},
+		&validatorExitInitiatedNotification{ValidatorIndex: 1, Epoch: 2, Slot: 64},
+		&validatorWithdrawalCredentialsNotification{ValidatorIndex: sql.NullInt64{Int64: 1, Valid: true}, Epoch: 2, EventName: types.ValidatorWithdrawalCredentialsChangedEventName, OldWithdrawalCredentials: []byte{0x00, 0x01}, NewWithdrawalCredentials: []byte{0x01, 0x02}},
+		&validatorWithdrawalCredentialsNotification{Pubkey: []byte{0xab}, EventName: types.ValidatorDepositCredentialsMismatchEventName, Amount: 32e9, TxHash: []byte{0xcd}, OldWithdrawalCredentials: []byte{0x00, 0x01}, NewWithdrawalCredentials: []byte{0x01, 0x02}},
+		&ethClientNotification{EthClient: "Geth"},
+		&networkNotification{},
+		&simulatedNotification{ValidatorIndex: 1, EventName: types.ValidatorBalanceDecreasedEventName},
+		&simulatedNotification{EventName: types.NetworkValidatorExitQueueFullEventName},
+	}
+	for _, format := range []string{types.ScheduledReportFormatHTML, types.ScheduledReportFormatCSV} {
+		notifications = append(notifications, &scheduledReportNotification{
+			Report: &types.ScheduledReport{ID: 1, Name: "Report", Period: "weekly", Format: format},
+			Run:    &types.ScheduledReportRun{ID: 1, ReportID: 1, Format: format, PeriodStart: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), PeriodEnd: time.Date(2022, 1, 8, 0, 0, 0, 0, time.UTC)},
+			File:   []byte("<table></table>"),
+		})
+	}
+	notifications = append(notifications,
+		&validatorProposalNotification{ValidatorIndex: 1, Status: 0, EventName: types.ValidatorExecutedProposalEventName},
+		&validatorProposalNotification{ValidatorIndex: 1, Status: 1, Reward: 0.1, EventName: types.ValidatorExecutedProposalEventName},
+		&validatorProposalNotification{ValidatorIndex: 1, Status: 2, EventName: types.ValidatorMissedProposalEventName},
+		&validatorAttestationNotification{ValidatorIndex: 1, Slot: 64, Status: 0, EventName: types.ValidatorMissedAttestationEventName},
+		&validatorAttestationNotification{ValidatorIndex: 1, Slot: 64, Status: 1, EventName: types.ValidatorMissedAttestationEventName},
+		&validatorIsOfflineNotification{ValidatorIndex: 1, EventEpoch: 2, IsOffline: true, EventName: types.ValidatorIsOfflineEventName},
+	)
+	// cover every plural category of the supported locales
+	for _, epochs := range []uint64{0, 1, 2, 5, 11, 21, 101} {
+		notifications = append(notifications, &validatorIsOfflineNotification{ValidatorIndex: 1, EventEpoch: 2, EpochsOffline: epochs, EventName: types.ValidatorIsOfflineEventName})
+	}
+	for _, eventName := range []types.EventName{
+		types.MonitoringMachineDiskAlmostFullEventName,
+		types.MonitoringMachineOfflineEventName,
+		types.MonitoringMachineCpuLoadEventName,
+		types.MonitoringMachineSwitchedToETH1FallbackEventName,
+		types.MonitoringMachineSwitchedToETH2FallbackEventName,
+		types.MonitoringMachineMemoryUsageEventName,
+	} {
+		notifications = append(notifications, &monitorMachineNotification{MachineName: "machine", EventName: eventName})
+	}
+	for _, eventName := range []types.EventName{
+		types.RocketpoolCommissionThresholdEventName,
+		types.RocketpoolNewClaimRoundStartedEventName,
+		types.RocketpoolColleteralMaxReached,
+		types.RocketpoolColleteralMinReached,
+	} {
+		notifications = append(notifications, &rocketpoolNotification{EventName: eventName, ExtraData: "10.00%"})
+	}
+	notifications = append(notifications, &rocketpoolNotification{EventName: types.RocketpoolNewClaimRoundStartedEventName, ExtraData: "1.00 RPL|0.1000 ETH"})
+	notifications = append(notifications, &rocketpoolNotification{EventName: types.SyncCommitteeSoon, ExtraData: "1|256|512"})
+	return notifications
+}
+
+func TestNotificationLocalesContainAllKeys(t *testing.T) {
+	missing := map[string]bool{}
+	utils.OnMissingTranslation = func(lang, key string) {
+		missing[lang+": "+key] = true
+	}
+	defer func() { utils.OnMissingTranslation = nil }()
+
+	notifications := append(i18nTestNotifications(), &taxReportNotification{})
+	for _, lang := range utils.SupportedLocales {
+		for _, n := range notifications {
+			texts := []string{n.GetTitle(lang), n.GetInfo(lang, false), n.GetInfo(lang, true), n.GetInfoMarkdown(lang)}
+			for _, text := range texts {
+				if text == "" || strings.Contains(text, "%!") {
+					t.Errorf("%v notification %v rendered an invalid text in locale %v: %q", n.GetEventName(), n.GetTitle(utils.DefaultLocale), lang, text)
+				}
+			}
+			buildDiscordEmbed(n, lang)
+		}
+
+		for eventName := range types.EventLabel {
+			utils.Tr(lang, "event_label_"+string(eventName))
+		}
+
+		// the email subject pluralizes the number of event types, adding them one by one covers the plural categories
+		userNotifications := map[types.EventName][]types.Notification{}
+		for _, n := range i18nTestNotifications() {
+			userNotifications[n.GetEventName()] = append(userNotifications[n.GetEventName()], n)
+			email, err := buildEmailNotification("user@example.com", userNotifications, lang, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = mail.RenderHTMLMail(email.Email)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		utils.Tr(lang, "mail_text_footer", "")
+	}
+
+	for key := range missing {
+		t.Errorf("missing locale key %v", key)
+	}
+}
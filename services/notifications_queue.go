@@ -0,0 +1,191 @@
+package services
+
+import (
+	"database/sql"
+	"eth2-exporter/metrics"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	// notificationLeaseDuration is the time a sender instance exclusively owns a claimed queue item.
+	// The lease is renewed right before the delivery of every item (see renewNotificationLease), so it only
+	// has to be longer than the time it takes to deliver a single item.
+	notificationLeaseDuration = time.Minute * 5
+	// notificationClaimBatchSize is the maximum number of queue items claimed per channel and dispatch run
+	notificationClaimBatchSize = 500
+	// notificationMaxAttempts is the number of delivery attempts before an item is moved to the dead-letter state
+	notificationMaxAttempts = 8
+	notificationBaseBackoff = time.Second * 30
+	notificationMaxBackoff  = time.Hour * 6
+	// notificationDeadLetterRetention is the time dead-lettered items are kept around for inspection
+	notificationDeadLetterRetention = time.Hour * 24 * 7
+)
+
+// notificationWorkerID identifies this sender instance as the owner of a lease in the notification_queue table
+var notificationWorkerID = func() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%08x", hostname, os.Getpid(), rand.New(rand.NewSource(time.Now().UnixNano())).Uint32())
+}()
+
+// claimNotificationQueueItems leases up to notificationClaimBatchSize deliverable items of the given channel for this instance.
+// Rows that are currently leased by another instance are skipped, rows whose lease expired (e.g. because the owner crashed)
+// are claimed again. Every claim counts as a delivery attempt.
+func claimNotificationQueueItems(useDB *sqlx.DB, channel string, dest interface{}) error {
+	err := useDB.Select(dest, `
+		UPDATE notification_queue SET
+			locked_by = $1,
+			locked_until = now() + $2 * interval '1 second',
+			attempts = attempts + 1
+		WHERE id IN (
+			SELECT id
+			FROM notification_queue
+			WHERE
+				channel = $3 AND
+				sent IS NULL AND
+				failed IS NULL AND
+				(next_attempt IS NULL OR next_attempt <= now()) AND
+				(locked_until IS NULL OR locked_until < now())
+			ORDER BY created ASC
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, created, sent, channel, content`, notificationWorkerID, notificationLeaseDuration.Seconds(), channel, notificationClaimBatchSize)
+	if err != nil {
+		return fmt.Errorf("error claiming %v items from the notification queue: %w", channel, err)
+	}
+	return nil
+}
+
+// renewNotificationLease extends the lease of a claimed queue item right before it is delivered, so that the lease of the
+// items at the end of a large batch does not expire while the items before them are sent. It returns false if the lease
+// has been lost in the meantime, the item must then not be delivered by this instance.
+func renewNotificationLease(useDB *sqlx.DB, id uint64) (bool, error) {
+	res, err := useDB.Exec(`
+		UPDATE notification_queue SET
+			locked_until = now() + $3 * interval '1 second'
+		WHERE id = $1 AND locked_by = $2 AND sent IS NULL`, id, notificationWorkerID, notificationLeaseDuration.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("error renewing the lease of notification %v: %w", id, err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error renewing the lease of notification %v: %w", id, err)
+	}
+	return rowsAffected == 1, nil
+}
+
+// markNotificationsSent marks leased queue items as delivered. Items whose lease has been lost in the meantime are not touched.
+func markNotificationsSent(useDB *sqlx.DB, ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := useDB.Exec(`
+		UPDATE notification_queue SET
+			sent = now(),
+			locked_by = NULL,
+			locked_until = NULL
+		WHERE id = ANY($1) AND locked_by = $2`, pq.Array(ids), notificationWorkerID)
+	if err != nil {
+		return fmt.Errorf("error marking notifications %v as sent: %w", ids, err)
+	}
+	return nil
+}
+
+// markNotificationsFailed releases the lease of queue items after a failed delivery attempt and schedules the next attempt
+// with exponential backoff. Items that exhausted notificationMaxAttempts are moved to the dead-letter state.
+func markNotificationsFailed(useDB *sqlx.DB, channel string, ids []uint64, sendErr error) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	errMsg := ""
+	if sendErr != nil {
+		errMsg = sendErr.Error()
+	}
+
+	var deadLettered int
+	err := useDB.Get(&deadLettered, `
+		WITH updated AS (
+			UPDATE notification_queue SET
+				locked_by = NULL,
+				locked_until = NULL,
+				last_error = $3,
+				next_attempt = now() + LEAST($4 * power(2, GREATEST(attempts - 1, 0)), $5) * interval '1 second',
+				failed = CASE WHEN attempts >= $6 THEN now() ELSE NULL END
+			WHERE id = ANY($1) AND locked_by = $2
+			RETURNING failed
+		)
+		SELECT COUNT(*) FROM updated WHERE failed IS NOT NULL`,
+		pq.Array(ids), notificationWorkerID, errMsg, notificationBaseBackoff.Seconds(), notificationMaxBackoff.Seconds(), notificationMaxAttempts)
+	if err != nil {
+		return fmt.Errorf("error marking notifications %v as failed: %w", ids, err)
+	}
+	if deadLettered > 0 {
+		logger.Warnf("moved %v %v notifications to the dead-letter state", deadLettered, channel)
+		metrics.NotificationsDeadLettered.WithLabelValues(channel).Add(float64(deadLettered))
+	}
+	return nil
+}
+
+// markNotificationsDeadLettered moves leased queue items to the dead-letter state without further delivery attempts,
+// e.g. because their destination is invalid.
+func markNotificationsDeadLettered(useDB *sqlx.DB, channel string, ids []uint64, reason string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	res, err := useDB.Exec(`
+		UPDATE notification_queue SET
+			locked_by = NULL,
+			locked_until = NULL,
+			last_error = $3,
+			failed = now()
+		WHERE id = ANY($1) AND locked_by = $2`, pq.Array(ids), notificationWorkerID, reason)
+	if err != nil {
+		return fmt.Errorf("error moving notifications %v to the dead-letter state: %w", ids, err)
+	}
+	rowsAffected, _ := res.RowsAffected()
+	metrics.NotificationsDeadLettered.WithLabelValues(channel).Add(float64(rowsAffected))
+	return nil
+}
+
+// updateNotificationQueueMetrics exports the depth and the age of the notification queue per channel
+func updateNotificationQueueMetrics(useDB *sqlx.DB) error {
+	var stats []struct {
+		Channel string          `db:"channel"`
+		Pending uint64          `db:"pending"`
+		Leased  uint64          `db:"leased"`
+		Failed  uint64          `db:"failed"`
+		MaxAge  sql.NullFloat64 `db:"max_age"`
+	}
+	err := useDB.Select(&stats, `
+		SELECT
+			channel,
+			COUNT(*) FILTER (WHERE failed IS NULL AND (locked_until IS NULL OR locked_until < now())) AS pending,
+			COUNT(*) FILTER (WHERE failed IS NULL AND locked_until >= now()) AS leased,
+			COUNT(*) FILTER (WHERE failed IS NOT NULL) AS failed,
+			EXTRACT(epoch FROM now() - MIN(created) FILTER (WHERE failed IS NULL)) AS max_age
+		FROM notification_queue
+		WHERE sent IS NULL
+		GROUP BY channel`)
+	if err != nil {
+		return fmt.Errorf("error retrieving notification queue stats: %w", err)
+	}
+
+	metrics.NotificationsQueueDepth.Reset()
+	metrics.NotificationsQueueAge.Reset()
+	for _, s := range stats {
+		metrics.NotificationsQueueDepth.WithLabelValues(s.Channel, "pending").Set(float64(s.Pending))
+		metrics.NotificationsQueueDepth.WithLabelValues(s.Channel, "leased").Set(float64(s.Leased))
+		metrics.NotificationsQueueDepth.WithLabelValues(s.Channel, "failed").Set(float64(s.Failed))
+		metrics.NotificationsQueueAge.WithLabelValues(s.Channel).Set(s.MaxAge.Float64)
+	}
+	return nil
+}
@@ -0,0 +1,411 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"eth2-exporter/db"
+	"eth2-exporter/mail"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetUserSubscription returns the subscription with the given id if it belongs to the given user
+func GetUserSubscription(userID, subscriptionID uint64) (*types.Subscription, error) {
+	sub := &types.Subscription{}
+	err := db.FrontendWriterDB.Get(sub, `
+		SELECT
+			id,
+			user_id,
+			event_name,
+			event_filter,
+			last_sent_ts,
+			last_sent_epoch,
+			created_ts,
+			created_epoch,
+			event_threshold,
+			ENCODE(unsubscribe_hash, 'hex') AS unsubscribe_hash,
+			internal_state
+		FROM users_subscriptions
+		WHERE id = $1 AND user_id = $2
+	`, subscriptionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// SimulateNotification creates a synthetic notification for the event and filter of the given subscription.
+// The notification is built from the same types the collectors use, so titles and texts are identical to real notifications.
+func SimulateNotification(sub *types.Subscription) (types.Notification, error) {
+	if sub.ID == nil || sub.UserID == nil {
+		return nil, fmt.Errorf("subscription is missing its id or user")
+	}
+	eventName, err := types.EventNameFromString(strings.TrimPrefix(sub.EventName, utils.GetNetwork()+":"))
+	if err != nil {
+		return nil, err
+	}
+
+	// the simulation also runs outside of the frontend (cmd/misc) where the cache is not initialized, so the epoch is read from the db
+	epoch, err := db.GetLatestEpoch()
+	if err != nil {
+		return nil, fmt.Errorf("error getting latest epoch: %w", err)
+	}
+	// previews of subscriptions without an unsubscribe hash omit the unsubscribe link, delivering one creates the hash like a real notification
+	unsubscribeHash := sub.UnsubscribeHash
+
+	validatorIndex := uint64(0)
+	if pubkey, err := hex.DecodeString(strings.TrimPrefix(sub.EventFilter, "0x")); err == nil && len(pubkey) == 48 {
+		validatorIndex, err = db.GetValidatorIndex(pubkey)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("error getting validator index of %v: %w", sub.EventFilter, err)
+		}
+	}
+
+	switch eventName {
+	case types.ValidatorExecutedProposalEventName, types.ValidatorMissedProposalEventName:
+		status := uint64(1)
+		if eventName == types.ValidatorMissedProposalEventName {
+			status = 2
+		}
+		return &validatorProposalNotification{
+			SubscriptionID:  *sub.ID,
+			ValidatorIndex:  validatorIndex,
+			Epoch:           epoch,
+			Status:          status,
+			EventName:       eventName,
+			EventFilter:     sub.EventFilter,
+			Reward:          0.05,
+			UnsubscribeHash: unsubscribeHash,
+		}, nil
+	case types.ValidatorMissedAttestationEventName:
+		return &validatorAttestationNotification{
+			SubscriptionID:  *sub.ID,
+			ValidatorIndex:  validatorIndex,
+			Epoch:           epoch,
+			Status:          0,
+			EventName:       eventName,
+			Slot:            epoch * utils.Config.Chain.Config.SlotsPerEpoch,
+			EventFilter:     sub.EventFilter,
+			UnsubscribeHash: unsubscribeHash,
+		}, nil
+	case types.ValidatorIsOfflineEventName:
+		return &validatorIsOfflineNotification{
+			SubscriptionID:  *sub.ID,
+			ValidatorIndex:  validatorIndex,
+			EventEpoch:      epoch,
+			EpochsOffline:   3,
+			IsOffline:       true,
+			EventName:       eventName,
+			EventFilter:     sub.EventFilter,
+			UnsubscribeHash: unsubscribeHash,
+		}, nil
+	case types.ValidatorGotSlashedEventName:
+		return &validatorGotSlashedNotification{
+			SubscriptionID:  *sub.ID,
+			ValidatorIndex:  validatorIndex,
+			Epoch:           epoch,
+			Slasher:         0,
+			Reason:          "Attestation Violation",
+			EventFilter:     sub.EventFilter,
+			UnsubscribeHash: unsubscribeHash,
+		}, nil
+	case types.ValidatorExitInitiatedEventName:
+		return &validatorExitInitiatedNotification{
+			SubscriptionID:  *sub.ID,
+			ValidatorIndex:  validatorIndex,
+			Epoch:           epoch,
+			Slot:            epoch * utils.Config.Chain.Config.SlotsPerEpoch,
+			EventFilter:     sub.EventFilter,
+			UnsubscribeHash: unsubscribeHash,
+		}, nil
+	case types.ValidatorWithdrawalCredentialsChangedEventName, types.ValidatorDepositCredentialsMismatchEventName:
+		pubkey, _ := hex.DecodeString(strings.TrimPrefix(sub.EventFilter, "0x"))
+		oldCredentials := make([]byte, 32)
+		newCredentials := append([]byte{0x01}, make([]byte, 31)...)
+		return &validatorWithdrawalCredentialsNotification{
+			SubscriptionID:           *sub.ID,
+			ValidatorIndex:           sql.NullInt64{Int64: int64(validatorIndex), Valid: true},
+			Pubkey:                   pubkey,
+			Epoch:                    epoch,
+			EventName:                eventName,
+			OldWithdrawalCredentials: oldCredentials,
+			NewWithdrawalCredentials: newCredentials,
+			Amount:                   1e9,
+			TxHash:                   make([]byte, 32),
+			EventFilter:              sub.EventFilter,
+			UnsubscribeHash:          unsubscribeHash,
+		}, nil
+	case types.EthClientUpdateEventName:
+		return &ethClientNotification{
+			SubscriptionID:  *sub.ID,
+			UserID:          *sub.UserID,
+			Epoch:           epoch,
+			EthClient:       sub.EventFilter,
+			EventFilter:     sub.EventFilter,
+			UnsubscribeHash: unsubscribeHash,
+		}, nil
+	case types.MonitoringMachineOfflineEventName, types.MonitoringMachineDiskAlmostFullEventName, types.MonitoringMachineCpuLoadEventName,
+		types.MonitoringMachineMemoryUsageEventName, types.MonitoringMachineSwitchedToETH1FallbackEventName, types.MonitoringMachineSwitchedToETH2FallbackEventName:
+		return &monitorMachineNotification{
+			SubscriptionID:  *sub.ID,
+			MachineName:     sub.EventFilter,
+			UserID:          *sub.UserID,
+			Epoch:           epoch,
+			EventName:       eventName,
+			UnsubscribeHash: unsubscribeHash,
+		}, nil
+	case types.TaxReportEventName:
+		return &taxReportNotification{
+			SubscriptionID:  *sub.ID,
+			UserID:          *sub.UserID,
+			Epoch:           epoch,
+			EventFilter:     sub.EventFilter,
+			UnsubscribeHash: unsubscribeHash,
+		}, nil
+	case types.NetworkLivenessIncreasedEventName:
+		return &networkNotification{
+			SubscriptionID:  *sub.ID,
+			UserID:          *sub.UserID,
+			Epoch:           epoch,
+			EventFilter:     sub.EventFilter,
+			UnsubscribeHash: unsubscribeHash,
+		}, nil
+	case types.RocketpoolCommissionThresholdEventName, types.RocketpoolNewClaimRoundStartedEventName,
+		types.RocketpoolColleteralMinReached, types.RocketpoolColleteralMaxReached:
+		return &rocketpoolNotification{
+			SubscriptionID:  *sub.ID,
+			UserID:          *sub.UserID,
+			Epoch:           epoch,
+			EventFilter:     sub.EventFilter,
+			EventName:       eventName,
+			ExtraData:       fmt.Sprintf("%.2f%%", sub.EventThreshold*100),
+			UnsubscribeHash: unsubscribeHash,
+		}, nil
+	case types.SyncCommitteeSoon:
+		period := utils.SyncPeriodOfEpoch(epoch) + 1
+		return &rocketpoolNotification{
+			SubscriptionID:  *sub.ID,
+			UserID:          *sub.UserID,
+			Epoch:           epoch,
+			EventFilter:     sub.EventFilter,
+			EventName:       eventName,
+			ExtraData:       fmt.Sprintf("%v|%v|%v", validatorIndex, utils.FirstEpochOfSyncPeriod(period), utils.FirstEpochOfSyncPeriod(period+1)-1),
+			UnsubscribeHash: unsubscribeHash,
+		}, nil
+	case types.ValidatorBalanceDecreasedEventName, types.ValidatorDidSlashEventName, types.ValidatorReceivedDepositEventName,
+		types.NetworkSlashingEventName, types.NetworkValidatorActivationQueueFullEventName, types.NetworkValidatorActivationQueueNotFullEventName,
+		types.NetworkValidatorExitQueueFullEventName, types.NetworkValidatorExitQueueNotFullEventName:
+		return &simulatedNotification{
+			SubscriptionID:  *sub.ID,
+			ValidatorIndex:  validatorIndex,
+			Epoch:           epoch,
+			EventName:       eventName,
+			EventFilter:     sub.EventFilter,
+			UnsubscribeHash: unsubscribeHash,
+		}, nil
+	case types.ScheduledReportEventName:
+		return simulateScheduledReportNotification(sub)
+	}
+
+	return nil, fmt.Errorf("simulating %v notifications is not supported", eventName)
+}
+
+// simulateScheduledReportNotification renders an empty report of the latest period, the event filter of the subscription is the id of the report
+func simulateScheduledReportNotification(sub *types.Subscription) (types.Notification, error) {
+	report := &types.ScheduledReport{
+		UserID: *sub.UserID,
+		Name:   "Scheduled report",
+		Period: types.ScheduledReportPeriodWeekly,
+		Format: types.ScheduledReportFormatHTML,
+	}
+	if reportID, err := strconv.ParseUint(sub.EventFilter, 10, 64); err == nil {
+		r, err := db.GetScheduledReport(*sub.UserID, reportID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("error getting scheduled report %v: %w", reportID, err)
+		}
+		if err == nil {
+			report = r
+		}
+	}
+
+	end := time.Now().UTC().Truncate(time.Hour * 24)
+	run := &types.ScheduledReportRun{
+		ReportID:    report.ID,
+		UserID:      report.UserID,
+		PeriodStart: scheduledReportPeriodStart(report.Period, end),
+		PeriodEnd:   end,
+		Format:      report.Format,
+	}
+	file, err := renderScheduledReport(report.Format, report.Name, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering scheduled report preview: %w", err)
+	}
+	return &scheduledReportNotification{Report: report, Run: run, File: file}, nil
+}
+
+// simulatedNotification previews events that are not collected by the notification service (yet), it only renders a generic text
+type simulatedNotification struct {
+	SubscriptionID  uint64
+	ValidatorIndex  uint64
+	Epoch           uint64
+	EventName       types.EventName
+	EventFilter     string
+	UnsubscribeHash sql.NullString
+}
+
+func (n *simulatedNotification) GetLatestState() string {
+	return ""
+}
+
+func (n *simulatedNotification) GetUnsubscribeHash() string {
+	if n.UnsubscribeHash.Valid {
+		return n.UnsubscribeHash.String
+	}
+	return ""
+}
+
+func (n *simulatedNotification) GetEmailAttachment() *types.EmailAttachment {
+	return nil
+}
+
+func (n *simulatedNotification) GetSubscriptionID() uint64 {
+	return n.SubscriptionID
+}
+
+func (n *simulatedNotification) GetEpoch() uint64 {
+	return n.Epoch
+}
+
+func (n *simulatedNotification) GetEventName() types.EventName {
+	return n.EventName
+}
+
+func (n *simulatedNotification) isValidatorEvent() bool {
+	return strings.HasPrefix(string(n.EventName), "validator_")
+}
+
+func (n *simulatedNotification) GetInfo(lang string, includeUrl bool) string {
+	label := utils.Tr(lang, "event_label_"+string(n.EventName))
+	if !n.isValidatorEvent() {
+		return utils.Tr(lang, "notification_simulated_network_info", label)
+	}
+	info := utils.Tr(lang, "notification_simulated_validator_info", label, fmt.Sprint(n.ValidatorIndex))
+	if includeUrl {
+		info += getUrlPart(lang, n.ValidatorIndex)
+	}
+	return info
+}
+
+func (n *simulatedNotification) GetTitle(lang string) string {
+	return utils.Tr(lang, "event_label_"+string(n.EventName))
+}
+
+func (n *simulatedNotification) GetEventFilter() string {
+	return n.EventFilter
+}
+
+func (n *simulatedNotification) GetInfoMarkdown(lang string) string {
+	return n.GetInfo(lang, false)
+}
+
+// PreviewNotification renders the given notification for every channel the subscription's user has configured.
+// If deliver is set the notification is also written to the notification queue and sent by the notification sender.
+func PreviewNotification(sub *types.Subscription, n types.Notification, deliver bool) (*types.NotificationPreview, error) {
+	userID := *sub.UserID
+	userNotifications := map[types.EventName][]types.Notification{n.GetEventName(): {n}}
+
+	lang, err := db.GetUserLocale(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting locale of user %v: %w", userID, err)
+	}
+
+	preview := &types.NotificationPreview{
+		SubscriptionID: n.GetSubscriptionID(),
+		EventName:      n.GetEventName(),
+		EventFilter:    n.GetEventFilter(),
+		Title:          n.GetTitle(lang),
+		Description:    n.GetInfo(lang, false),
+		Webhooks:       []types.TransitWebhookContent{},
+		Discord:        []types.DiscordReq{},
+	}
+
+	emailsByUserID, err := db.GetUserEmailsByIds([]uint64{userID})
+	if err != nil {
+		return nil, fmt.Errorf("error getting email of user %v: %w", userID, err)
+	}
+	preview.Email, err = buildEmailNotification(emailsByUserID[userID], userNotifications, lang, nil)
+	if err != nil {
+		return nil, err
+	}
+	preview.EmailHTML, err = mail.RenderHTMLMail(preview.Email.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	tokensByUserID, err := db.GetUserPushTokenByIds([]uint64{userID})
+	if err != nil {
+		return nil, fmt.Errorf("error getting push tokens of user %v: %w", userID, err)
+	}
+	preview.Push = buildPushMessages(tokensByUserID[userID], userNotifications, lang)
+
+	var webhooks []types.UserWebhook
+	err = db.FrontendWriterDB.Select(&webhooks, `
+		SELECT
+			id,
+			user_id,
+			url,
+			retries,
+			event_names,
+			destination
+		FROM users_webhooks
+		WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting webhooks of user %v: %w", userID, err)
+	}
+	for _, w := range webhooks {
+		// like the real delivery only webhooks subscribed to the event receive it
+		if !webhookSubscribedToEvent(w, n.GetEventName()) {
+			continue
+		}
+		if w.Destination.Valid && w.Destination.String == "webhook_discord" {
+			preview.Discord = append(preview.Discord, types.DiscordReq{
+				Username: utils.Config.Frontend.SiteDomain,
+				Embeds:   []types.DiscordEmbed{buildDiscordEmbed(n, lang)},
+			})
+		} else {
+			preview.Webhooks = append(preview.Webhooks, types.TransitWebhookContent{
+				Webhook: w,
+				Event:   buildWebhookEvent(n, lang),
+			})
+		}
+	}
+
+	if !deliver {
+		return preview, nil
+	}
+
+	// the subscription state (last sent, internal state) is deliberately left untouched
+	tx, err := db.FrontendWriterDB.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = queueNotificationChannels(map[uint64]map[types.EventName][]types.Notification{userID: userNotifications}, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("error committing simulated notification: %w", err)
+	}
+	preview.Delivered = true
+
+	return preview, nil
+}
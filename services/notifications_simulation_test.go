@@ -0,0 +1,47 @@
+package services
+
+import (
+	"database/sql"
+	"eth2-exporter/types"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestWebhookSubscribedToEvent(t *testing.T) {
+	w := types.UserWebhook{EventNames: pq.StringArray{string(types.ValidatorGotSlashedEventName), string(types.ValidatorExecutedProposalEventName)}}
+	if !webhookSubscribedToEvent(w, types.ValidatorGotSlashedEventName) {
+		t.Errorf("expected the webhook to be subscribed to %v", types.ValidatorGotSlashedEventName)
+	}
+	if webhookSubscribedToEvent(w, types.ValidatorMissedAttestationEventName) {
+		t.Errorf("expected the webhook not to be subscribed to %v", types.ValidatorMissedAttestationEventName)
+	}
+}
+
+func TestEmailUnsubscribeLinkOnlyWithHash(t *testing.T) {
+	withoutHash := map[types.EventName][]types.Notification{
+		types.ValidatorGotSlashedEventName: {&validatorGotSlashedNotification{ValidatorIndex: 1, Epoch: 2, Slasher: 3, Reason: "Attestation Violation"}},
+	}
+	email, err := buildEmailNotification("user@example.com", withoutHash, "en", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if email.Email.UnSubURL != "" {
+		t.Errorf("expected no unsubscribe link without an unsubscribe hash, got %v", email.Email.UnSubURL)
+	}
+
+	withHash := map[types.EventName][]types.Notification{
+		types.ValidatorGotSlashedEventName: {
+			&validatorGotSlashedNotification{ValidatorIndex: 1, Epoch: 2, Slasher: 3, Reason: "Attestation Violation"},
+			&validatorGotSlashedNotification{ValidatorIndex: 2, Epoch: 2, Slasher: 3, Reason: "Attestation Violation", UnsubscribeHash: sql.NullString{String: "abcd", Valid: true}},
+		},
+	}
+	email, err = buildEmailNotification("user@example.com", withHash, "en", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(email.Email.UnSubURL), "/notifications/unsubscribe?hash=abcd\"") {
+		t.Errorf("expected the unsubscribe link to only contain the existing hash, got %v", email.Email.UnSubURL)
+	}
+}
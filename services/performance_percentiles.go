@@ -0,0 +1,260 @@
+package services
+
+import (
+	"database/sql"
+	"eth2-exporter/cache"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PerformancePercentileParticipationDays is the number of exported statistics days the attestation participation is computed over
+const PerformancePercentileParticipationDays = 7
+
+// PerformancePercentileIncome365dDays is the number of exported statistics days the 365d income is computed over
+const PerformancePercentileIncome365dDays = 365
+
+const (
+	performanceQuantilesCacheExpiration      = time.Hour
+	performanceQuantilesCacheLocalExpiration = time.Minute * 10
+)
+
+var performancePercentileMetrics = []string{
+	types.PerformanceMetricIncome1d,
+	types.PerformanceMetricIncome7d,
+	types.PerformanceMetricIncome31d,
+	types.PerformanceMetricIncome365d,
+	types.PerformanceMetricAttestationParticipation,
+}
+
+// performanceQuantileFractions are the quantiles that are computed of the network distributions, in steps of 1%
+var performanceQuantileFractions = func() pq.Float64Array {
+	fractions := make(pq.Float64Array, 101)
+	for i := range fractions {
+		fractions[i] = float64(i) / 100
+	}
+	return fractions
+}()
+
+// the share of attestations that were not missed in percent, of every validator that was active during the whole day
+const performanceParticipationRatesQuery = `
+	SELECT
+		validator_stats.validatorindex,
+		100 * (1 - SUM(validator_stats.missed_attestations)::float8 / (COUNT(*) * $3)) AS rate
+	FROM validator_stats
+	INNER JOIN validators ON validators.validatorindex = validator_stats.validatorindex
+	WHERE validator_stats.day BETWEEN $1 AND $2
+		AND validators.activationepoch <= validator_stats.day * $3
+		AND validators.exitepoch >= (validator_stats.day + 1) * $3
+		%s
+	GROUP BY validator_stats.validatorindex`
+
+// performanceIncomeQuery aggregates the 1d, 7d and 31d income of every validator that is not exited and was active during the whole period,
+// aggregate is a format string that is passed the income column
+func performanceIncomeQuery(aggregate, filter string) string {
+	return fmt.Sprintf(`
+		SELECT
+			%[1]s FILTER (WHERE validators.activationepoch <= $1 - $2),
+			%[2]s FILTER (WHERE validators.activationepoch <= $1 - 7 * $2),
+			%[3]s FILTER (WHERE validators.activationepoch <= $1 - 31 * $2)
+		FROM validator_performance
+		INNER JOIN validators ON validators.validatorindex = validator_performance.validatorindex
+		WHERE validators.exitepoch > $1
+			%[4]s`, fmt.Sprintf(aggregate, "performance1d"), fmt.Sprintf(aggregate, "performance7d"), fmt.Sprintf(aggregate, "performance31d"), filter)
+}
+
+// the income of the exported statistics days between $2 and $1 of every validator that was active during all of them,
+// the end balance of the day before the period is the start balance and deposits during the period are not income
+const performanceIncome365dQuery = `
+	SELECT
+		validator_stats.validatorindex,
+		validator_stats.end_balance - COALESCE(start_stats.end_balance, 0) - COALESCE(deposits.amount, 0) AS income
+	FROM validator_stats
+	INNER JOIN validators ON validators.validatorindex = validator_stats.validatorindex
+	LEFT JOIN validator_stats start_stats ON start_stats.validatorindex = validator_stats.validatorindex AND start_stats.day = $2 - 1
+	LEFT JOIN (
+		SELECT validatorindex, SUM(deposits_amount) AS amount
+		FROM validator_stats
+		WHERE day BETWEEN $2 AND $1 AND deposits_amount > 0
+			%[1]s
+		GROUP BY validatorindex
+	) deposits ON deposits.validatorindex = validator_stats.validatorindex
+	WHERE validator_stats.day = $1 AND validator_stats.end_balance IS NOT NULL
+		AND validators.activationepoch <= $2 * $3
+		AND validators.exitepoch >= ($1 + 1) * $3
+		%[1]s`
+
+// GetNetworkPerformanceQuantiles returns the distributions of the income and attestation participation of all active validators.
+// The result is cached, the income is refreshed hourly while the participation only changes with every exported statistics day.
+func GetNetworkPerformanceQuantiles() (*types.NetworkPerformanceQuantiles, error) {
+	day, err := lastExportedStatsDay()
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("%d:frontend:networkPerformanceQuantiles:%d", utils.Config.Chain.Config.DepositChainID, day)
+	if wanted, err := cache.TieredCache.GetWithLocalTimeout(cacheKey, performanceQuantilesCacheLocalExpiration, &types.NetworkPerformanceQuantiles{}); err == nil {
+		return wanted.(*types.NetworkPerformanceQuantiles), nil
+	}
+
+	quantiles, err := computeNetworkPerformanceQuantiles(day)
+	if err != nil {
+		return nil, err
+	}
+	err = cache.TieredCache.Set(cacheKey, quantiles, performanceQuantilesCacheExpiration)
+	if err != nil {
+		logger.Errorf("error caching network performance quantiles: %v", err)
+	}
+	return quantiles, nil
+}
+
+func lastExportedStatsDay() (uint64, error) {
+	var day uint64
+	err := db.ReaderDb.Get(&day, "SELECT COALESCE(MAX(day), 0) FROM validator_stats_status WHERE status")
+	if err != nil {
+		return 0, fmt.Errorf("error getting last exported statistics day: %w", err)
+	}
+	return day, nil
+}
+
+func epochsPerDay() uint64 {
+	return (24 * 60 * 60) / utils.Config.Chain.Config.SlotsPerEpoch / utils.Config.Chain.Config.SecondsPerSlot
+}
+
+// statsDayRange returns the first and last of the exported statistics days of a period of days ending at day
+func statsDayRange(day, days uint64) (uint64, uint64) {
+	if day < days-1 {
+		return 0, day
+	}
+	return day - (days - 1), day
+}
+
+func computeNetworkPerformanceQuantiles(day uint64) (*types.NetworkPerformanceQuantiles, error) {
+	var income1d, income7d, income31d, income365d, participation pq.Float64Array
+	err := db.ReaderDb.QueryRow(performanceIncomeQuery("percentile_cont($3::float8[]) WITHIN GROUP (ORDER BY %s)", ""),
+		LatestEpoch(), epochsPerDay(), performanceQuantileFractions).Scan(&income1d, &income7d, &income31d)
+	if err != nil {
+		return nil, fmt.Errorf("error getting income quantiles: %w", err)
+	}
+
+	start, end := statsDayRange(day, PerformancePercentileIncome365dDays)
+	err = db.ReaderDb.QueryRow(fmt.Sprintf(`
+		WITH incomes AS (%s)
+		SELECT percentile_cont($4::float8[]) WITHIN GROUP (ORDER BY income) FROM incomes`, fmt.Sprintf(performanceIncome365dQuery, "")),
+		end, start, epochsPerDay(), performanceQuantileFractions).Scan(&income365d)
+	if err != nil {
+		return nil, fmt.Errorf("error getting 365d income quantiles: %w", err)
+	}
+
+	start, end = statsDayRange(day, PerformancePercentileParticipationDays)
+	err = db.ReaderDb.QueryRow(fmt.Sprintf(`
+		WITH rates AS (%s)
+		SELECT percentile_cont($4::float8[]) WITHIN GROUP (ORDER BY rate) FROM rates`, fmt.Sprintf(performanceParticipationRatesQuery, "")),
+		start, end, epochsPerDay(), performanceQuantileFractions).Scan(&participation)
+	if err != nil {
+		return nil, fmt.Errorf("error getting attestation participation quantiles: %w", err)
+	}
+
+	quantiles := &types.NetworkPerformanceQuantiles{
+		Day: day,
+		Quantiles: map[string][]float64{
+			types.PerformanceMetricIncome1d:                 gweiToEth(income1d),
+			types.PerformanceMetricIncome7d:                 gweiToEth(income7d),
+			types.PerformanceMetricIncome31d:                gweiToEth(income31d),
+			types.PerformanceMetricIncome365d:               gweiToEth(income365d),
+			types.PerformanceMetricAttestationParticipation: participation,
+		},
+	}
+	return quantiles, nil
+}
+
+func gweiToEth(values []float64) []float64 {
+	res := make([]float64, len(values))
+	for i, v := range values {
+		res[i] = v / 1e9
+	}
+	return res
+}
+
+// GetPerformancePercentiles compares the average validator of the group with the network distributions.
+// Only validators that were active during the whole period of a metric are compared, metrics without data are omitted.
+func GetPerformancePercentiles(validators []uint64) ([]*types.PerformancePercentile, error) {
+	network, err := GetNetworkPerformanceQuantiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var income1d, income7d, income31d, income365d, participation sql.NullFloat64
+	err = db.ReaderDb.QueryRow(performanceIncomeQuery("AVG(%s) / 1e9", "AND validators.validatorindex = ANY($3)"),
+		LatestEpoch(), epochsPerDay(), pq.Array(validators)).Scan(&income1d, &income7d, &income31d)
+	if err != nil {
+		return nil, fmt.Errorf("error getting income of validators: %w", err)
+	}
+
+	start, end := statsDayRange(network.Day, PerformancePercentileIncome365dDays)
+	err = db.ReaderDb.QueryRow(fmt.Sprintf(`
+		WITH incomes AS (%s)
+		SELECT AVG(income) / 1e9 FROM incomes`, fmt.Sprintf(performanceIncome365dQuery, "AND validator_stats.validatorindex = ANY($4)")),
+		end, start, epochsPerDay(), pq.Array(validators)).Scan(&income365d)
+	if err != nil {
+		return nil, fmt.Errorf("error getting 365d income of validators: %w", err)
+	}
+
+	start, end = statsDayRange(network.Day, PerformancePercentileParticipationDays)
+	err = db.ReaderDb.QueryRow(fmt.Sprintf(`
+		WITH rates AS (%s)
+		SELECT AVG(rate) FROM rates`, fmt.Sprintf(performanceParticipationRatesQuery, "AND validator_stats.validatorindex = ANY($4)")),
+		start, end, epochsPerDay(), pq.Array(validators)).Scan(&participation)
+	if err != nil {
+		return nil, fmt.Errorf("error getting attestation participation of validators: %w", err)
+	}
+
+	values := map[string]sql.NullFloat64{
+		types.PerformanceMetricIncome1d:                 income1d,
+		types.PerformanceMetricIncome7d:                 income7d,
+		types.PerformanceMetricIncome31d:                income31d,
+		types.PerformanceMetricIncome365d:               income365d,
+		types.PerformanceMetricAttestationParticipation: participation,
+	}
+	return comparePerformance(network, values), nil
+}
+
+func comparePerformance(network *types.NetworkPerformanceQuantiles, values map[string]sql.NullFloat64) []*types.PerformancePercentile {
+	res := make([]*types.PerformancePercentile, 0, len(performancePercentileMetrics))
+	for _, metric := range performancePercentileMetrics {
+		value := values[metric]
+		quantiles := network.Quantiles[metric]
+		if !value.Valid || len(quantiles) != len(performanceQuantileFractions) {
+			continue
+		}
+		res = append(res, &types.PerformancePercentile{
+			Metric:           metric,
+			Value:            value.Float64,
+			Percentile:       percentileRank(quantiles, value.Float64),
+			NetworkMedian:    quantiles[50],
+			NetworkTopDecile: quantiles[90],
+		})
+	}
+	return res
+}
+
+// percentileRank returns the share of the network in percent that performed worse than the value,
+// interpolated linearly between the quantiles.
+func percentileRank(quantiles []float64, value float64) float64 {
+	last := len(quantiles) - 1
+	if last < 1 || value < quantiles[0] {
+		return 0
+	}
+	if value >= quantiles[last] {
+		return 100
+	}
+	upper := sort.Search(len(quantiles), func(i int) bool { return quantiles[i] > value })
+	lower := upper - 1
+	step := 100 / float64(last)
+	return step * (float64(lower) + (value-quantiles[lower])/(quantiles[upper]-quantiles[lower]))
+}
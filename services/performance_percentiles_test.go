@@ -0,0 +1,81 @@
+package services
+
+import (
+	"database/sql"
+	"eth2-exporter/types"
+	"testing"
+)
+
+func TestPercentileRank(t *testing.T) {
+	quantiles := make([]float64, 101)
+	for i := range quantiles {
+		quantiles[i] = float64(i) * 2
+	}
+
+	tests := []struct {
+		value float64
+		want  float64
+	}{
+		{-1, 0},
+		{0, 0},
+		{100, 50},
+		{101, 50.5},
+		{200, 100},
+		{300, 100},
+	}
+	for _, tt := range tests {
+		if got := percentileRank(quantiles, tt.value); got != tt.want {
+			t.Errorf("percentileRank(%v) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	// validators with the same income as a large part of the network rank above all of them
+	flat := make([]float64, 101)
+	for i := 31; i < len(flat); i++ {
+		flat[i] = float64(i)
+	}
+	if got := percentileRank(flat, 0); got != 30 {
+		t.Errorf("percentileRank of a tied value = %v, want 30", got)
+	}
+}
+
+func TestComparePerformance(t *testing.T) {
+	quantiles := make([]float64, 101)
+	for i := range quantiles {
+		quantiles[i] = float64(i)
+	}
+	network := &types.NetworkPerformanceQuantiles{Quantiles: map[string][]float64{
+		types.PerformanceMetricIncome7d:                 quantiles,
+		types.PerformanceMetricAttestationParticipation: quantiles,
+	}}
+
+	res := comparePerformance(network, map[string]sql.NullFloat64{
+		types.PerformanceMetricIncome1d:                 {Float64: 1, Valid: true},
+		types.PerformanceMetricIncome7d:                 {Float64: 75, Valid: true},
+		types.PerformanceMetricAttestationParticipation: {},
+	})
+	if len(res) != 1 {
+		t.Fatalf("expected only the 7d income to be compared, got %v entries", len(res))
+	}
+	if res[0].Metric != types.PerformanceMetricIncome7d || res[0].Percentile != 75 || res[0].NetworkMedian != 50 || res[0].NetworkTopDecile != 90 {
+		t.Errorf("unexpected comparison %+v", res[0])
+	}
+}
+
+func TestStatsDayRange(t *testing.T) {
+	tests := []struct {
+		day, days  uint64
+		start, end uint64
+	}{
+		{1000, PerformancePercentileIncome365dDays, 636, 1000},
+		{364, PerformancePercentileIncome365dDays, 0, 364},
+		{10, PerformancePercentileIncome365dDays, 0, 10},
+		{10, PerformancePercentileParticipationDays, 4, 10},
+	}
+	for _, tt := range tests {
+		start, end := statsDayRange(tt.day, tt.days)
+		if start != tt.start || end != tt.end {
+			t.Errorf("statsDayRange(%v, %v) = %v, %v, want %v, %v", tt.day, tt.days, start, end, tt.start, tt.end)
+		}
+	}
+}
@@ -1 +1,384 @@
 package services
+
+import (
+	"bytes"
+	"encoding/hex"
+	"eth2-exporter/db"
+	"eth2-exporter/metrics"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PoolAttributionMinConfidence is the combined confidence a pool needs to be assigned to a validator
+const PoolAttributionMinConfidence = 0.5
+
+// poolAttributionStakePoolsConfidence is the confidence of the deposit addresses of stake_pools_stats
+const poolAttributionStakePoolsConfidence = 0.8
+
+// PoolAttributionRuleFunc returns the attributions of all validators matching one of the rules, all rules are of the same type
+type PoolAttributionRuleFunc func(rules []*types.PoolAttributionRule) ([]*types.PoolAttribution, error)
+
+var poolAttributionRulesMux = &sync.RWMutex{}
+var poolAttributionRules = map[string]PoolAttributionRuleFunc{
+	types.PoolAttributionDepositAddress:        attributeByDepositAddress,
+	types.PoolAttributionWithdrawalCredentials: attributeByWithdrawalCredentials,
+	types.PoolAttributionGraffiti:              attributeByGraffiti,
+	types.PoolAttributionFeeRecipient:          attributeByFeeRecipient,
+	types.PoolAttributionContractFactory:       attributeByContractFactory,
+}
+
+// RegisterPoolAttributionRule adds or replaces the implementation of a rule type
+func RegisterPoolAttributionRule(ruleType string, f PoolAttributionRuleFunc) {
+	poolAttributionRulesMux.Lock()
+	defer poolAttributionRulesMux.Unlock()
+	poolAttributionRules[ruleType] = f
+}
+
+// UpdatePoolAttributions evaluates all pool attribution rules and assigns every validator to the pool with the highest combined confidence
+func UpdatePoolAttributions() error {
+	start := time.Now()
+	defer func() {
+		metrics.TaskDuration.WithLabelValues("service_pool_attributions").Observe(time.Since(start).Seconds())
+	}()
+
+	rules, err := db.GetPoolAttributionRules()
+	if err != nil {
+		return fmt.Errorf("error getting pool attribution rules: %w", err)
+	}
+
+	if utils.Config.Chain.Config.ConfigName == "mainnet" || utils.Config.Chain.Config.ConfigName == "prater" {
+		var stakePools []*types.PoolAttributionRule
+		err = db.ReaderDb.Select(&stakePools, `SELECT DISTINCT address AS pattern, name AS pool FROM stake_pools_stats`)
+		if err != nil {
+			return fmt.Errorf("error getting stake pools: %w", err)
+		}
+		for _, pool := range stakePools {
+			pool.Type = types.PoolAttributionDepositAddress
+			pool.Confidence = poolAttributionStakePoolsConfidence
+			rules = append(rules, pool)
+		}
+	}
+
+	rulesByType := make(map[string][]*types.PoolAttributionRule)
+	for _, rule := range rules {
+		rulesByType[rule.Type] = append(rulesByType[rule.Type], rule)
+	}
+
+	attributions := make([]*types.PoolAttribution, 0)
+	poolAttributionRulesMux.RLock()
+	for ruleType, rules := range rulesByType {
+		f, ok := poolAttributionRules[ruleType]
+		if !ok {
+			logger.Warnf("skipping %v pool attribution rules of unknown type %v", len(rules), ruleType)
+			continue
+		}
+		res, err := f(rules)
+		if err != nil {
+			// a failing rule type must not remove the attributions of the other types
+			poolAttributionRulesMux.RUnlock()
+			return fmt.Errorf("error evaluating %v pool attribution rules: %w", ruleType, err)
+		}
+		attributions = append(attributions, res...)
+	}
+	poolAttributionRulesMux.RUnlock()
+
+	attributions = dedupePoolAttributions(attributions)
+	for _, a := range attributions {
+		a.UpdatedTime = start
+	}
+	resolved := resolvePoolAttributions(attributions)
+
+	err = db.SavePoolAttributions(attributions, resolved)
+	if err != nil {
+		return fmt.Errorf("error saving pool attributions: %w", err)
+	}
+	logger.Infof("attributed %v validators to pools from %v matches of %v rules, took %v", len(resolved), len(attributions), len(rules), time.Since(start))
+	return nil
+}
+
+// dedupePoolAttributions keeps the match with the highest confidence per validator, pool and rule type
+func dedupePoolAttributions(attributions []*types.PoolAttribution) []*types.PoolAttribution {
+	type key struct {
+		publickey string
+		pool      string
+		ruleType  string
+	}
+	best := make(map[key]*types.PoolAttribution, len(attributions))
+	res := make([]*types.PoolAttribution, 0, len(attributions))
+	for _, a := range attributions {
+		k := key{string(a.Publickey), a.Pool, a.RuleType}
+		if b, ok := best[k]; ok {
+			if a.Confidence > b.Confidence {
+				*b = *a
+			}
+			continue
+		}
+		best[k] = a
+		res = append(res, a)
+	}
+	return res
+}
+
+// resolvePoolAttributions combines the confidences of the rule types that attribute a validator to the same pool
+// as independent evidence (1 - (1-c1)(1-c2)...) and returns the pool with the highest combined confidence per validator.
+func resolvePoolAttributions(attributions []*types.PoolAttribution) []*types.PoolAttribution {
+	type key struct {
+		publickey string
+		pool      string
+	}
+	remaining := make(map[key]float64)
+	for _, a := range attributions {
+		k := key{string(a.Publickey), a.Pool}
+		if _, ok := remaining[k]; !ok {
+			remaining[k] = 1
+		}
+		remaining[k] *= 1 - a.Confidence
+	}
+
+	resolved := make(map[string]*types.PoolAttribution)
+	for k, r := range remaining {
+		confidence := 1 - r
+		if confidence < PoolAttributionMinConfidence {
+			continue
+		}
+		current, ok := resolved[k.publickey]
+		if !ok || confidence > current.Confidence || (confidence == current.Confidence && k.pool < current.Pool) {
+			resolved[k.publickey] = &types.PoolAttribution{Publickey: []byte(k.publickey), Pool: k.pool, Confidence: confidence}
+		}
+	}
+
+	res := make([]*types.PoolAttribution, 0, len(resolved))
+	for _, a := range resolved {
+		res = append(res, a)
+	}
+	sort.Slice(res, func(i, j int) bool { return bytes.Compare(res[i].Publickey, res[j].Publickey) < 0 })
+	return res
+}
+
+func newPoolAttribution(publickey []byte, rule *types.PoolAttributionRule, evidence string) *types.PoolAttribution {
+	return &types.PoolAttribution{
+		Publickey:  publickey,
+		Pool:       rule.Pool,
+		RuleID:     rule.ID,
+		RuleType:   rule.Type,
+		Evidence:   evidence,
+		Confidence: rule.Confidence,
+	}
+}
+
+// rulesByAddress parses the patterns of the rules as hex encoded addresses
+func rulesByAddress(rules []*types.PoolAttributionRule) (map[string][]*types.PoolAttributionRule, pq.ByteaArray) {
+	res := make(map[string][]*types.PoolAttributionRule, len(rules))
+	addresses := make(pq.ByteaArray, 0, len(rules))
+	for _, rule := range rules {
+		address, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(rule.Pattern), "0x"))
+		if err != nil || len(address) != 20 {
+			logger.Warnf("skipping %v pool attribution rule %v of pool %v: invalid address %v", rule.Type, rule.ID.Int64, rule.Pool, rule.Pattern)
+			continue
+		}
+		if _, ok := res[string(address)]; !ok {
+			addresses = append(addresses, address)
+		}
+		res[string(address)] = append(res[string(address)], rule)
+	}
+	return res, addresses
+}
+
+// attributeByAddress attributes the validators returned by the query, which selects the publickey and the matched address of the rules ($1)
+func attributeByAddress(rules []*types.PoolAttributionRule, query string, evidence string) ([]*types.PoolAttribution, error) {
+	byAddress, addresses := rulesByAddress(rules)
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	rows, err := db.ReaderDb.Query(query, addresses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*types.PoolAttribution, 0)
+	for rows.Next() {
+		var publickey, address []byte
+		err := rows.Scan(&publickey, &address)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range byAddress[string(address)] {
+			res = append(res, newPoolAttribution(publickey, rule, fmt.Sprintf(evidence, address)))
+		}
+	}
+	return res, rows.Err()
+}
+
+// attributeByDepositAddress attributes validators whose deposits were sent from one of the addresses of a pool
+func attributeByDepositAddress(rules []*types.PoolAttributionRule) ([]*types.PoolAttribution, error) {
+	return attributeByAddress(rules, `
+		SELECT DISTINCT publickey, from_address
+		FROM eth1_deposits
+		WHERE from_address = ANY($1) AND valid_signature`, "deposit from 0x%x")
+}
+
+// attributeByWithdrawalCredentials attributes validators whose 0x01 withdrawal credentials point to one of the addresses of a pool
+func attributeByWithdrawalCredentials(rules []*types.PoolAttributionRule) ([]*types.PoolAttribution, error) {
+	return attributeByAddress(rules, `
+		SELECT pubkey, substring(withdrawalcredentials from 13)
+		FROM validators
+		WHERE substring(withdrawalcredentials from 1 for 1) = '\x01' AND substring(withdrawalcredentials from 13) = ANY($1)`, "withdrawal credentials of 0x%x")
+}
+
+// attributeByFeeRecipient attributes validators that proposed blocks paying the fees to one of the addresses of a pool
+func attributeByFeeRecipient(rules []*types.PoolAttributionRule) ([]*types.PoolAttribution, error) {
+	return attributeByAddress(rules, `
+		SELECT DISTINCT validators.pubkey, blocks.exec_fee_recipient
+		FROM blocks
+		INNER JOIN validators ON validators.validatorindex = blocks.proposer
+		WHERE blocks.status = '1' AND blocks.exec_fee_recipient = ANY($1)`, "fee recipient 0x%x")
+}
+
+// attributeByGraffiti attributes validators that proposed a block with a graffiti matching the regular expression of a pool
+func attributeByGraffiti(rules []*types.PoolAttributionRule) ([]*types.PoolAttribution, error) {
+	res := make([]*types.PoolAttribution, 0)
+	for _, rule := range rules {
+		var matches []struct {
+			Publickey []byte `db:"pubkey"`
+			Slot      uint64 `db:"slot"`
+			Graffiti  string `db:"graffiti_text"`
+		}
+		err := db.ReaderDb.Select(&matches, `
+			SELECT DISTINCT ON (validators.pubkey) validators.pubkey, blocks.slot, blocks.graffiti_text
+			FROM blocks
+			INNER JOIN validators ON validators.validatorindex = blocks.proposer
+			WHERE blocks.status = '1' AND blocks.graffiti_text ~* $1
+			ORDER BY validators.pubkey, blocks.slot DESC`, rule.Pattern)
+		if err != nil {
+			// an invalid expression only skips its rule
+			logger.Warnf("skipping graffiti pool attribution rule %v of pool %v: %v", rule.ID.Int64, rule.Pool, err)
+			continue
+		}
+		for _, m := range matches {
+			res = append(res, newPoolAttribution(m.Publickey, rule, fmt.Sprintf("graffiti %q in slot %v", m.Graffiti, m.Slot)))
+		}
+	}
+	return res, nil
+}
+
+// poolFactoryPageSize is the number of index rows read from bigtable per page when looking up the contracts deployed by a factory
+const poolFactoryPageSize = 1000
+
+// attributeByContractFactory attributes validators whose deposits were sent from, or whose withdrawal credentials point to,
+// a contract deployed by one of the addresses of a pool. Both contracts created by transactions sent from the factory and
+// contracts the factory created itself via CREATE or CREATE2 are found.
+func attributeByContractFactory(rules []*types.PoolAttributionRule) ([]*types.PoolAttribution, error) {
+	byAddress, factories := rulesByAddress(rules)
+
+	deployed := make(map[string][]*types.PoolAttributionRule)
+	deployedBy := make(map[string][]byte)
+	contracts := make(pq.ByteaArray, 0)
+	credentials := make(pq.ByteaArray, 0)
+	for _, factory := range factories {
+		created, err := factoryDeployments(factory)
+		if err != nil {
+			return nil, fmt.Errorf("error getting contracts of factory 0x%x: %w", factory, err)
+		}
+		for _, contract := range created {
+			if _, ok := deployed[string(contract)]; !ok {
+				contracts = append(contracts, contract)
+				credentials = append(credentials, append(append([]byte{0x01}, make([]byte, 11)...), contract...))
+			}
+			deployed[string(contract)] = byAddress[string(factory)]
+			deployedBy[string(contract)] = factory
+		}
+	}
+	if len(contracts) == 0 {
+		return nil, nil
+	}
+
+	rows, err := db.ReaderDb.Query(`
+		SELECT DISTINCT publickey, from_address FROM eth1_deposits WHERE from_address = ANY($1) AND valid_signature
+		UNION
+		SELECT pubkey, substring(withdrawalcredentials from 13) FROM validators WHERE withdrawalcredentials = ANY($2)`, contracts, credentials)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*types.PoolAttribution, 0)
+	for rows.Next() {
+		var publickey, contract []byte
+		err := rows.Scan(&publickey, &contract)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range deployed[string(contract)] {
+			res = append(res, newPoolAttribution(publickey, rule, fmt.Sprintf("contract 0x%x deployed by 0x%x", contract, deployedBy[string(contract)])))
+		}
+	}
+	return res, rows.Err()
+}
+
+// factoryDeployments returns the addresses of all contracts deployed by the factory. The deployments are stored and only
+// the contract creation indexes of the transactions sent from the factory and of the CREATE and CREATE2 calls of the
+// factory are read back to the latest stored deployment. After the internal transaction index has been backfilled
+// (see TransformItx) the stored deployments of the factory have to be deleted so its whole history is read again.
+func factoryDeployments(factory []byte) ([][]byte, error) {
+	contracts, since, err := db.GetPoolFactoryDeployments(factory)
+	if err != nil {
+		return nil, fmt.Errorf("error getting stored deployments: %w", err)
+	}
+
+	deployments := make([]*types.PoolFactoryDeployment, 0)
+	pageToken := fmt.Sprintf("%d:I:TX:%x:%s:", utils.Config.Chain.Config.DepositChainID, factory, db.FILTER_CONTRACT)
+	for pageToken != "" {
+		txs, lastKey, err := db.BigtableClient.GetEth1TxForAddress(pageToken, poolFactoryPageSize)
+		if err != nil {
+			return nil, err
+		}
+		pageToken = lastKey
+		for _, tx := range txs {
+			created := tx.GetTime().AsTime()
+			if created.Before(since) {
+				pageToken = ""
+				break
+			}
+			if tx.IsContractCreation && bytes.Equal(tx.From, factory) {
+				deployments = append(deployments, &types.PoolFactoryDeployment{Factory: factory, Contract: tx.To, CreatedTime: created})
+			}
+		}
+	}
+
+	pageToken = fmt.Sprintf("%d:I:ITX:%x:%s:", utils.Config.Chain.Config.DepositChainID, factory, db.FILTER_CONTRACT)
+	for pageToken != "" {
+		itxs, lastKey, err := db.BigtableClient.GetEth1ItxForAddress(pageToken, factory, poolFactoryPageSize)
+		if err != nil {
+			return nil, err
+		}
+		pageToken = lastKey
+		for _, itx := range itxs {
+			created := itx.GetTime().AsTime()
+			if created.Before(since) {
+				pageToken = ""
+				break
+			}
+			if bytes.Equal(itx.From, factory) {
+				deployments = append(deployments, &types.PoolFactoryDeployment{Factory: factory, Contract: itx.To, CreatedTime: created})
+			}
+		}
+	}
+
+	err = db.SavePoolFactoryDeployments(deployments)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deployments {
+		contracts = append(contracts, d.Contract)
+	}
+	return contracts, nil
+}
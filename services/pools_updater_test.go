@@ -0,0 +1,66 @@
+package services
+
+import (
+	"eth2-exporter/types"
+	"math"
+	"testing"
+)
+
+func TestDedupePoolAttributions(t *testing.T) {
+	attributions := []*types.PoolAttribution{
+		{Publickey: []byte{1}, Pool: "a", RuleType: types.PoolAttributionGraffiti, Confidence: 0.3},
+		{Publickey: []byte{1}, Pool: "a", RuleType: types.PoolAttributionGraffiti, Confidence: 0.6},
+		{Publickey: []byte{1}, Pool: "a", RuleType: types.PoolAttributionFeeRecipient, Confidence: 0.4},
+		{Publickey: []byte{2}, Pool: "a", RuleType: types.PoolAttributionGraffiti, Confidence: 0.2},
+	}
+
+	res := dedupePoolAttributions(attributions)
+	if len(res) != 3 {
+		t.Fatalf("got %v attributions, want 3", len(res))
+	}
+	if res[0].Confidence != 0.6 {
+		t.Errorf("got confidence %v for the duplicated rule type, want 0.6", res[0].Confidence)
+	}
+}
+
+func TestResolvePoolAttributions(t *testing.T) {
+	attributions := []*types.PoolAttribution{
+		// two independent weak matches combine to 1 - 0.6*0.6 = 0.64
+		{Publickey: []byte{1}, Pool: "a", RuleType: types.PoolAttributionGraffiti, Confidence: 0.4},
+		{Publickey: []byte{1}, Pool: "a", RuleType: types.PoolAttributionFeeRecipient, Confidence: 0.4},
+		{Publickey: []byte{1}, Pool: "b", RuleType: types.PoolAttributionDepositAddress, Confidence: 0.6},
+		// below the threshold
+		{Publickey: []byte{2}, Pool: "a", RuleType: types.PoolAttributionGraffiti, Confidence: 0.3},
+		// ties are resolved by the name of the pool
+		{Publickey: []byte{3}, Pool: "d", RuleType: types.PoolAttributionGraffiti, Confidence: 0.7},
+		{Publickey: []byte{3}, Pool: "c", RuleType: types.PoolAttributionFeeRecipient, Confidence: 0.7},
+	}
+
+	res := resolvePoolAttributions(attributions)
+	if len(res) != 2 {
+		t.Fatalf("got %v resolved validators, want 2", len(res))
+	}
+	if res[0].Pool != "a" || math.Abs(res[0].Confidence-0.64) > 1e-9 {
+		t.Errorf("got pool %v with confidence %v for validator 1, want a with 0.64", res[0].Pool, res[0].Confidence)
+	}
+	if res[1].Pool != "c" {
+		t.Errorf("got pool %v for validator 3, want c", res[1].Pool)
+	}
+}
+
+func TestRulesByAddress(t *testing.T) {
+	rules := []*types.PoolAttributionRule{
+		{Pool: "a", Pattern: "0x00000000219AB540356cBB839Cbe05303d7705Fa"},
+		{Pool: "b", Pattern: "00000000219ab540356cbb839cbe05303d7705fa"},
+		{Pool: "c", Pattern: "0x1234"},
+		{Pool: "d", Pattern: "not an address"},
+	}
+
+	byAddress, addresses := rulesByAddress(rules)
+	if len(addresses) != 1 {
+		t.Fatalf("got %v addresses, want 1", len(addresses))
+	}
+	if got := len(byAddress[string(addresses[0])]); got != 2 {
+		t.Errorf("got %v rules for the address, want 2", got)
+	}
+}
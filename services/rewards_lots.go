@@ -0,0 +1,290 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// GetValidatorRewardLots returns the income of the validators between start and end (unix timestamps) as individual lots.
+// Each lot is valued at the price of the day, or the average price of the month, it was received on in the given timezone.
+func GetValidatorRewardLots(validators []uint64, currency string, start, end uint64, location *time.Location, granularity string) (*types.RewardLotsReport, error) {
+	currency = strings.ToLower(currency)
+	if _, ok := priceOfCurrency(&types.Price{}, currency); !ok {
+		return nil, fmt.Errorf("unsupported currency %v", currency)
+	}
+	if granularity != types.RewardLotPriceGranularityDay && granularity != types.RewardLotPriceGranularityMonth {
+		return nil, fmt.Errorf("unsupported price granularity %v", granularity)
+	}
+	if end == 0 || end > uint64(time.Now().Unix()) {
+		end = uint64(time.Now().Unix())
+	}
+	if start > end {
+		return nil, fmt.Errorf("start %v is after end %v", start, end)
+	}
+
+	lots, err := getConsensusRewardLots(validators, utils.TimeToDay(start), utils.TimeToDay(end))
+	if err != nil {
+		return nil, fmt.Errorf("error getting consensus reward lots: %w", err)
+	}
+	executionLots, err := getExecutionRewardLots(validators, utils.TimeToSlot(start), utils.TimeToSlot(end))
+	if err != nil {
+		return nil, fmt.Errorf("error getting execution reward lots: %w", err)
+	}
+	lots = append(lots, executionLots...)
+
+	// a month of margin so the monthly average can be computed for the first and last lot in any timezone
+	var prices []types.Price
+	err = db.ReaderDb.Select(&prices, `select `+historicPriceColumns+` from price where ts >= TO_TIMESTAMP($1) and ts <= TO_TIMESTAMP($2) order by ts`,
+		int64(start)-32*24*60*60, end+32*24*60*60)
+	if err != nil {
+		return nil, fmt.Errorf("error getting prices: %w", err)
+	}
+
+	report := &types.RewardLotsReport{
+		Validators:       validators,
+		Currency:         currency,
+		Timezone:         location.String(),
+		PriceGranularity: granularity,
+		Start:            time.Unix(int64(start), 0).In(location),
+		End:              time.Unix(int64(end), 0).In(location),
+	}
+	priceRewardLots(report, lots, prices, location)
+	return report, nil
+}
+
+func getConsensusRewardLots(validators []uint64, startDay, endDay uint64) ([]*types.RewardLot, error) {
+	var rows []struct {
+		ValidatorIndex uint64 `db:"validatorindex"`
+		Day            uint64 `db:"day"`
+		Income         int64  `db:"income"`
+	}
+	// the start balance of a day is the end balance of the previous day, so the day before the range is queried as well
+	err := db.ReaderDb.Select(&rows, `
+		select validatorindex, day, end_balance - start_balance - deposits_amount as income
+		from (
+			select
+				validatorindex,
+				day,
+				coalesce(lag(end_balance) over (partition by validatorindex order by day), start_balance, 0) as start_balance,
+				coalesce(end_balance, 0) as end_balance,
+				coalesce(deposits_amount, 0) as deposits_amount
+			from validator_stats
+			where validatorindex = ANY($1) and day between $2 - 1 and $3
+		) as stats
+		where day between $2 and $3
+		order by day, validatorindex`, pq.Array(validators), startDay, endDay)
+	if err != nil {
+		return nil, err
+	}
+
+	lots := make([]*types.RewardLot, 0, len(rows))
+	for _, row := range rows {
+		day := row.Day
+		lots = append(lots, &types.RewardLot{
+			// the income of a day is final at the end of the day
+			Timestamp:      utils.DayToTime(int64(day) + 1).Add(-time.Second),
+			Type:           types.RewardLotTypeConsensus,
+			ValidatorIndex: row.ValidatorIndex,
+			Day:            &day,
+			AmountEth:      decimal.New(row.Income, -9),
+		})
+	}
+	return lots, nil
+}
+
+func getExecutionRewardLots(validators []uint64, startSlot, endSlot uint64) ([]*types.RewardLot, error) {
+	var proposals []struct {
+		Slot         uint64 `db:"slot"`
+		Proposer     uint64 `db:"proposer"`
+		BlockNumber  uint64 `db:"exec_block_number"`
+		BlockHash    []byte `db:"exec_block_hash"`
+		FeeRecipient []byte `db:"exec_fee_recipient"`
+	}
+	err := db.ReaderDb.Select(&proposals, `
+		select slot, proposer, exec_block_number, exec_block_hash, exec_fee_recipient
+		from blocks
+		where proposer = ANY($1) and status = '1' and exec_block_number > 0 and slot between $2 and $3
+		order by slot`, pq.Array(validators), startSlot, endSlot)
+	if err != nil {
+		return nil, err
+	}
+	if len(proposals) == 0 {
+		return nil, nil
+	}
+
+	blockNumbers := make([]uint64, 0, len(proposals))
+	blockHashes := make([][]byte, 0, len(proposals))
+	for _, proposal := range proposals {
+		blockNumbers = append(blockNumbers, proposal.BlockNumber)
+		blockHashes = append(blockHashes, proposal.BlockHash)
+	}
+
+	// if a block was delivered by multiple relays the highest value is used, the payment goes to the same fee recipient
+	var relaysData []types.RelaysData
+	err = db.ReaderDb.Select(&relaysData, `
+		select distinct on (exec_block_hash) proposer_fee_recipient, value, exec_block_hash, tag_id, builder_pubkey
+		from relays_blocks
+		where exec_block_hash = ANY($1)
+		order by exec_block_hash, value desc`, pq.ByteaArray(blockHashes))
+	if err != nil {
+		return nil, err
+	}
+	relaysDataMap := make(map[common.Hash]types.RelaysData, len(relaysData))
+	for _, relayData := range relaysData {
+		relaysDataMap[common.BytesToHash(relayData.ExecBlockHash)] = relayData
+	}
+
+	blocks, err := db.BigtableClient.GetBlocksIndexedMultiple(blockNumbers, uint64(len(blockNumbers)))
+	if err != nil {
+		return nil, err
+	}
+	blocksMap := make(map[uint64]*types.Eth1BlockIndexed, len(blocks))
+	for _, block := range blocks {
+		blocksMap[block.GetNumber()] = block
+	}
+
+	lots := make([]*types.RewardLot, 0, len(proposals))
+	for _, proposal := range proposals {
+		slot, blockNumber := proposal.Slot, proposal.BlockNumber
+		lot := &types.RewardLot{
+			Timestamp:      utils.SlotToTime(slot),
+			Type:           types.RewardLotTypeExecution,
+			ValidatorIndex: proposal.Proposer,
+			Slot:           &slot,
+			BlockNumber:    &blockNumber,
+			FeeRecipient:   common.BytesToAddress(proposal.FeeRecipient).Hex(),
+		}
+		if relayData, ok := relaysDataMap[common.BytesToHash(proposal.BlockHash)]; ok {
+			lot.FeeRecipient = common.BytesToAddress(relayData.MevRecipient).Hex()
+			lot.MevRelay = relayData.TagID
+			lot.AmountEth = decimal.NewFromBigInt(relayData.MevBribe.BigInt(), -18)
+		} else if block, ok := blocksMap[blockNumber]; ok {
+			lot.AmountEth = decimal.NewFromBigInt(utils.Eth1TotalReward(block), -18)
+		} else {
+			logger.Warnf("execution block %v of slot %v not found, its reward is not included in the reward lots", blockNumber, slot)
+			continue
+		}
+		lots = append(lots, lot)
+	}
+	return lots, nil
+}
+
+// priceRewardLots values the lots with the prices of the report currency and adds them to the report sorted by time
+func priceRewardLots(report *types.RewardLotsReport, lots []*types.RewardLot, prices []types.Price, location *time.Location) {
+	// the price table holds one price per utc day
+	dailyPrices := make(map[string]decimal.Decimal, len(prices))
+	monthlySums := map[string]decimal.Decimal{}
+	monthlyCounts := map[string]int64{}
+	for i := range prices {
+		p, _ := priceOfCurrency(&prices[i], report.Currency)
+		day := prices[i].TS.UTC().Format("2006-01-02")
+		dailyPrices[day] = decimal.NewFromFloat(p)
+		monthlySums[day[:7]] = monthlySums[day[:7]].Add(decimal.NewFromFloat(p))
+		monthlyCounts[day[:7]]++
+	}
+
+	report.TotalEth = decimal.Zero
+	report.TotalValue = decimal.Zero
+	for _, lot := range lots {
+		lot.Timestamp = lot.Timestamp.In(location)
+		if report.PriceGranularity == types.RewardLotPriceGranularityMonth {
+			month := lot.Timestamp.Format("2006-01")
+			if monthlyCounts[month] > 0 {
+				lot.Price = monthlySums[month].Div(decimal.NewFromInt(monthlyCounts[month])).Round(8)
+			}
+		} else {
+			lot.Price = dailyPrices[lot.Timestamp.Format("2006-01-02")] // the price defaults to 0 if it is missing
+		}
+		lot.Value = lot.AmountEth.Mul(lot.Price).Round(8)
+		report.TotalEth = report.TotalEth.Add(lot.AmountEth)
+		report.TotalValue = report.TotalValue.Add(lot.Value)
+	}
+
+	sort.SliceStable(lots, func(i, j int) bool {
+		if !lots[i].Timestamp.Equal(lots[j].Timestamp) {
+			return lots[i].Timestamp.Before(lots[j].Timestamp)
+		}
+		return lots[i].ValidatorIndex < lots[j].ValidatorIndex
+	})
+	report.Lots = lots
+}
+
+// GenerateRewardLotsCsv returns the lots of the report as csv, one row per lot
+func GenerateRewardLotsCsv(report *types.RewardLotsReport) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+
+	err := w.Write([]string{"timestamp", "type", "validator_index", "day", "slot", "block_number", "fee_recipient", "mev_relay", "amount_eth", "price_" + report.Currency, "value_" + report.Currency})
+	if err != nil {
+		return nil, err
+	}
+	for _, lot := range report.Lots {
+		err = w.Write([]string{
+			lot.Timestamp.Format(time.RFC3339),
+			lot.Type,
+			strconv.FormatUint(lot.ValidatorIndex, 10),
+			formatOptionalUint(lot.Day),
+			formatOptionalUint(lot.Slot),
+			formatOptionalUint(lot.BlockNumber),
+			lot.FeeRecipient,
+			lot.MevRelay,
+			lot.AmountEth.String(),
+			lot.Price.String(),
+			lot.Value.String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func formatOptionalUint(value *uint64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatUint(*value, 10)
+}
+
+// IsReportCurrency returns true if incomes can be valued in the lower case currency, i.e. the price table has a column for it
+func IsReportCurrency(currency string) bool {
+	_, ok := priceOfCurrency(&types.Price{}, currency)
+	return ok
+}
+
+// priceOfCurrency returns the price of a row of the price table in the given lower case currency
+func priceOfCurrency(p *types.Price, currency string) (float64, bool) {
+	switch currency {
+	case "eur":
+		return p.EUR, true
+	case "usd":
+		return p.USD, true
+	case "gbp":
+		return p.GBP, true
+	case "cad":
+		return p.CAD, true
+	case "cny":
+		return p.CNY, true
+	case "jpy":
+		return p.JPY, true
+	case "rub":
+		return p.RUB, true
+	case "aud":
+		return p.AUD, true
+	default:
+		return 0, false
+	}
+}
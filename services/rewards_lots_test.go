@@ -0,0 +1,67 @@
+package services
+
+import (
+	"eth2-exporter/types"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestPriceRewardLots(t *testing.T) {
+	day, slot := uint64(10), uint64(100)
+	location, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("error loading location: %v", err)
+	}
+	prices := []types.Price{
+		{TS: time.Date(2022, 1, 31, 0, 0, 0, 0, time.UTC), USD: 2000},
+		{TS: time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC), USD: 3000},
+		{TS: time.Date(2022, 2, 2, 0, 0, 0, 0, time.UTC), USD: 4000},
+	}
+	newLots := func() []*types.RewardLot {
+		return []*types.RewardLot{
+			// 2022-02-01 07:00 in Tokyo
+			{Timestamp: time.Date(2022, 1, 31, 22, 0, 0, 0, time.UTC), Type: types.RewardLotTypeExecution, ValidatorIndex: 2, Slot: &slot, FeeRecipient: "0xabc", MevRelay: "flashbots", AmountEth: decimal.RequireFromString("0.05")},
+			{Timestamp: time.Date(2022, 1, 31, 12, 0, 0, 0, time.UTC), Type: types.RewardLotTypeConsensus, ValidatorIndex: 1, Day: &day, AmountEth: decimal.RequireFromString("0.002")},
+		}
+	}
+
+	report := &types.RewardLotsReport{Currency: "usd", PriceGranularity: types.RewardLotPriceGranularityDay}
+	priceRewardLots(report, newLots(), prices, location)
+	if len(report.Lots) != 2 || report.Lots[0].ValidatorIndex != 1 {
+		t.Fatalf("unexpected lots %+v", report.Lots)
+	}
+	if !report.Lots[0].Price.Equal(decimal.NewFromInt(2000)) || !report.Lots[1].Price.Equal(decimal.NewFromInt(3000)) {
+		t.Errorf("unexpected daily prices %v and %v", report.Lots[0].Price, report.Lots[1].Price)
+	}
+	if !report.TotalEth.Equal(decimal.RequireFromString("0.052")) || !report.TotalValue.Equal(decimal.NewFromInt(154)) {
+		t.Errorf("unexpected totals %v ETH and %v USD", report.TotalEth, report.TotalValue)
+	}
+
+	report = &types.RewardLotsReport{Currency: "usd", PriceGranularity: types.RewardLotPriceGranularityMonth}
+	priceRewardLots(report, newLots(), prices, location)
+	if !report.Lots[1].Price.Equal(decimal.NewFromInt(3500)) {
+		t.Errorf("unexpected monthly price %v", report.Lots[1].Price)
+	}
+
+	csv, err := GenerateRewardLotsCsv(report)
+	if err != nil {
+		t.Fatalf("error generating csv: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(csv)), "\n")
+	want := []string{
+		"timestamp,type,validator_index,day,slot,block_number,fee_recipient,mev_relay,amount_eth,price_usd,value_usd",
+		"2022-01-31T21:00:00+09:00,consensus_reward,1,10,,,,,0.002,2000,4",
+		"2022-02-01T07:00:00+09:00,execution_reward,2,,100,,0xabc,flashbots,0.05,3500,175",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %v lines, got %v", len(want), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %v: got %q, expected %q", i, lines[i], want[i])
+		}
+	}
+}
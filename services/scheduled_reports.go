@@ -0,0 +1,737 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/csv"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/lib/pq"
+)
+
+const (
+	scheduledReportPollInterval = time.Minute
+	// reports whose worker stopped while generating them are claimed again after the lease expired
+	scheduledReportLease = time.Hour
+	// ScheduledReportMaxValidators limits the validators of a report, including the validators of a tag
+	ScheduledReportMaxValidators = 1000
+	// a period whose report could not be generated is retried with an exponential backoff before it is given up as failed
+	scheduledReportMaxAttempts  = 5
+	scheduledReportRetryBackoff = time.Minute * 10
+	// reports are deferred until the validator statistics of the last day of their period have been exported
+	scheduledReportStatsWait = time.Minute * 15
+)
+
+// InitScheduledReports starts the worker generating the scheduled reports of the network, the reports are archived in the storage of the validator export
+func InitScheduledReports() error {
+	s, err := newValidatorExportStorage()
+	if err != nil {
+		return err
+	}
+	scheduledReportStorage = s
+
+	go scheduledReportWorker()
+	return nil
+}
+
+var scheduledReportStorage ValidatorExportStorage
+
+// ScheduledReportNextRun returns the end of the next period of a report after t, periods start on monday, the first day of the month or the first day of the year in UTC
+func ScheduledReportNextRun(period string, t time.Time) (time.Time, error) {
+	t = t.UTC()
+	switch period {
+	case types.ScheduledReportPeriodWeekly:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		days := (8 - int(day.Weekday())) % 7
+		if days == 0 {
+			days = 7
+		}
+		return day.AddDate(0, 0, days), nil
+	case types.ScheduledReportPeriodMonthly:
+		return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC), nil
+	case types.ScheduledReportPeriodYearly:
+		return time.Date(t.Year()+1, 1, 1, 0, 0, 0, 0, time.UTC), nil
+	default:
+		return time.Time{}, fmt.Errorf("unknown report period %q", period)
+	}
+}
+
+// scheduledReportPeriodStart returns the start of the period of a report ending at end
+func scheduledReportPeriodStart(period string, end time.Time) time.Time {
+	switch period {
+	case types.ScheduledReportPeriodWeekly:
+		return end.AddDate(0, 0, -7)
+	case types.ScheduledReportPeriodMonthly:
+		return end.AddDate(0, -1, 0)
+	default:
+		return end.AddDate(-1, 0, 0)
+	}
+}
+
+// ScheduledReportDownloadURL returns the link to the archived file of a report run, it can be used without authentication
+func ScheduledReportDownloadURL(run *types.ScheduledReportRun) string {
+	return fmt.Sprintf("https://%s/api/v1/reports/runs/%d/download?token=%x", utils.Config.Frontend.SiteDomain, run.ID, run.DownloadToken)
+}
+
+// ScheduledReportFileName returns the name under which the file of a report run is offered for download
+func ScheduledReportFileName(run *types.ScheduledReportRun) string {
+	return fmt.Sprintf("report_%s_%d_%s_%s.%s", utils.GetNetwork(), run.ReportID, run.PeriodStart.Format("20060102"), run.PeriodEnd.Format("20060102"), run.Format)
+}
+
+// OpenScheduledReportRun returns the archived file of a report run
+func OpenScheduledReportRun(ctx context.Context, run *types.ScheduledReportRun) (io.ReadCloser, error) {
+	if scheduledReportStorage == nil {
+		return nil, fmt.Errorf("scheduled reports are not enabled")
+	}
+	if run.Status != types.ScheduledReportRunStatusDone || run.FileKey == nil {
+		return nil, fmt.Errorf("report run %v has no file", run.ID)
+	}
+	return scheduledReportStorage.Open(ctx, *run.FileKey)
+}
+
+func scheduledReportWorker() {
+	for {
+		report, err := db.ClaimDueScheduledReport(utils.GetNetwork(), scheduledReportLease)
+		if err == sql.ErrNoRows {
+			time.Sleep(scheduledReportPollInterval)
+			continue
+		}
+		if err != nil {
+			logger.Errorf("error claiming scheduled report: %v", err)
+			time.Sleep(scheduledReportPollInterval)
+			continue
+		}
+		processScheduledReport(report)
+	}
+}
+
+func processScheduledReport(report *types.ScheduledReport) {
+	start := time.Now()
+	run := &types.ScheduledReportRun{
+		ReportID:    report.ID,
+		UserID:      report.UserID,
+		PeriodStart: scheduledReportPeriodStart(report.Period, report.NextRunTime),
+		PeriodEnd:   report.NextRunTime,
+		Format:      report.Format,
+		Status:      types.ScheduledReportRunStatusDone,
+		CreatedTime: start,
+	}
+	// missed periods, e.g. while the worker was stopped, are not generated retroactively
+	nextRun, err := ScheduledReportNextRun(report.Period, start)
+	if err != nil {
+		logger.Errorf("error scheduling report %v: %v", report.ID, err)
+		return
+	}
+
+	if _, lastDay, hasDays := getScheduledReportDays(run.PeriodStart, run.PeriodEnd); hasDays {
+		exported, err := scheduledReportStatsExported(lastDay)
+		if err != nil {
+			logger.Errorf("error checking the statistics of scheduled report %v: %v", report.ID, err)
+		}
+		if err != nil || !exported {
+			logger.Infof("deferring scheduled report %v until the statistics of day %v are exported", report.ID, lastDay)
+			err = db.DeferScheduledReport(report.ID, time.Now().Add(scheduledReportStatsWait))
+			if err != nil {
+				logger.Errorf("error deferring scheduled report %v: %v", report.ID, err)
+			}
+			return
+		}
+	}
+
+	file, err := generateScheduledReport(report, run)
+	if err == nil {
+		run.DownloadToken, err = generateDownloadToken()
+	}
+	if err == nil {
+		key := fmt.Sprintf("%s/reports/%s", utils.GetNetwork(), ScheduledReportFileName(run))
+		err = archiveScheduledReport(key, file)
+		if err == nil {
+			size := int64(len(file))
+			run.FileKey = &key
+			run.FileSize = &size
+		}
+	}
+	if err != nil && report.FailedRuns+1 < scheduledReportMaxAttempts {
+		retry := scheduledReportRetryDelay(report.FailedRuns)
+		logger.Warnf("error generating scheduled report %v, retrying in %v: %v", report.ID, retry, err)
+		err = db.RetryScheduledReport(report.ID, time.Now().Add(retry))
+		if err != nil {
+			logger.Errorf("error scheduling retry of scheduled report %v: %v", report.ID, err)
+		}
+		return
+	}
+	if err != nil {
+		logger.Errorf("error generating scheduled report %v after %v attempts: %v", report.ID, scheduledReportMaxAttempts, err)
+		msg := "the report could not be generated"
+		run.Status = types.ScheduledReportRunStatusFailed
+		run.Error = &msg
+		if run.DownloadToken == nil {
+			run.DownloadToken = []byte{}
+		}
+	}
+
+	err = db.FinishScheduledReportRun(run, nextRun)
+	if err != nil {
+		logger.Errorf("error storing run of scheduled report %v: %v", report.ID, err)
+		return
+	}
+	if run.Status != types.ScheduledReportRunStatusDone {
+		return
+	}
+	logger.Infof("generated scheduled report %v (%v bytes) in %v", report.ID, len(file), time.Since(start))
+
+	err = queueScheduledReportNotification(&scheduledReportNotification{Report: report, Run: run, File: file})
+	if err != nil {
+		logger.Errorf("error queuing notification of scheduled report %v: %v", report.ID, err)
+	}
+}
+
+// scheduledReportRetryDelay returns the time to wait before a report is generated again after it failed failedRuns times before
+func scheduledReportRetryDelay(failedRuns int) time.Duration {
+	return scheduledReportRetryBackoff << failedRuns
+}
+
+// scheduledReportStatsExported returns whether the validator statistics up to and including day have been exported
+var scheduledReportStatsExported = func(day uint64) (bool, error) {
+	var exported bool
+	err := db.ReaderDb.Get(&exported, "SELECT COALESCE(MAX(day) >= $1, false) FROM validator_stats_status WHERE status", day)
+	return exported, err
+}
+
+func generateDownloadToken() ([]byte, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("error generating download token: %w", err)
+	}
+	return token, nil
+}
+
+func archiveScheduledReport(key string, file []byte) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	f, err := scheduledReportStorage.Create(ctx, key)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(file); err != nil {
+		cancel()
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// scheduledReportTable is a section of a report, all formats render the same tables
+type scheduledReportTable struct {
+	Title  string
+	Header []string
+	Rows   [][]string
+}
+
+func generateScheduledReport(report *types.ScheduledReport, run *types.ScheduledReportRun) ([]byte, error) {
+	validators, truncated, err := getScheduledReportValidators(report)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := getScheduledReportTables(report, validators, run.PeriodStart, run.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	title := fmt.Sprintf("%s (%s - %s)", report.Name, run.PeriodStart.Format("2006-01-02"), run.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02"))
+	notes := []string{}
+	if truncated {
+		notes = append(notes, scheduledReportTruncatedNote(*report.Tag))
+	}
+	return renderScheduledReport(report.Format, title, notes, tables)
+}
+
+func scheduledReportTruncatedNote(tag string) string {
+	return fmt.Sprintf("The tag %s has more than %d validators, the report only covers the first %d validators ordered by public key.", tag, ScheduledReportMaxValidators, ScheduledReportMaxValidators)
+}
+
+// getScheduledReportValidators returns the validators of the report, the validators of a tag are resolved when the report is generated.
+// Tags with more than ScheduledReportMaxValidators validators are truncated to the first validators ordered by public key.
+func getScheduledReportValidators(report *types.ScheduledReport) ([]uint64, bool, error) {
+	if report.Tag == nil {
+		validators := make([]uint64, 0, len(report.Validators))
+		for _, v := range report.Validators {
+			validators = append(validators, uint64(v))
+		}
+		return validators, false, nil
+	}
+
+	var pubkeys [][]byte
+	err := db.FrontendReaderDB.Select(&pubkeys, `
+		SELECT validator_publickey
+		FROM users_validators_tags
+		WHERE user_id = $1 AND tag = $2
+		ORDER BY validator_publickey
+		LIMIT $3`, report.UserID, report.Network+":"+*report.Tag, ScheduledReportMaxValidators+1)
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting validators of tag %v: %w", *report.Tag, err)
+	}
+	truncated := len(pubkeys) > ScheduledReportMaxValidators
+	if truncated {
+		pubkeys = pubkeys[:ScheduledReportMaxValidators]
+	}
+	validators := []uint64{}
+	err = db.ReaderDb.Select(&validators, `SELECT validatorindex FROM validators WHERE pubkey = ANY($1) ORDER BY validatorindex`, pq.ByteaArray(pubkeys))
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting indices of tag %v: %w", *report.Tag, err)
+	}
+	return validators, truncated, nil
+}
+
+// getScheduledReportDays returns the first and last day of the validator statistics that lie completely within the period
+func getScheduledReportDays(start, end time.Time) (uint64, uint64, bool) {
+	firstDay := utils.TimeToDay(uint64(start.Unix()))
+	if utils.DayToTime(int64(firstDay)).Before(start) {
+		firstDay++
+	}
+	endDay := utils.TimeToDay(uint64(end.Unix()))
+	if endDay <= firstDay {
+		return 0, 0, false
+	}
+	return firstDay, endDay - 1, true
+}
+
+func getScheduledReportTables(report *types.ScheduledReport, validators []uint64, start, end time.Time) ([]scheduledReportTable, error) {
+	tables := make([]scheduledReportTable, 0, len(report.Sections))
+	firstDay, lastDay, hasDays := getScheduledReportDays(start, end)
+	for _, section := range report.Sections {
+		table := scheduledReportTable{}
+		var err error
+		switch section {
+		case types.ScheduledReportSectionIncome:
+			table = scheduledReportIncomeTable(validators, report.Currency, firstDay, lastDay, hasDays)
+		case types.ScheduledReportSectionEffectiveness:
+			table, err = scheduledReportEffectivenessTable(validators, firstDay, lastDay, hasDays)
+		case types.ScheduledReportSectionMissedDuties:
+			table, err = scheduledReportMissedDutiesTable(validators, firstDay, lastDay, hasDays)
+		case types.ScheduledReportSectionProposals:
+			table, err = scheduledReportProposalsTable(validators, start, end)
+		default:
+			return nil, fmt.Errorf("unknown report section %q", section)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error getting report section %v: %w", section, err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func scheduledReportIncomeTable(validators []uint64, currency string, firstDay, lastDay uint64, hasDays bool) scheduledReportTable {
+	cur := strings.ToUpper(currency)
+	table := scheduledReportTable{
+		Title:  "Income",
+		Header: []string{"Date", "Balance (ETH)", "Income (ETH)", "ETH Price", fmt.Sprintf("Income (%s)", cur)},
+	}
+	if !hasDays || len(validators) == 0 {
+		return table
+	}
+	// GetValidatorHist reports the days after the day of the start timestamp
+	start := uint64(0)
+	if firstDay > 0 {
+		start = uint64(utils.DayToTime(int64(firstDay) - 1).Unix())
+	}
+	hist := GetValidatorHist(validators, currency, start, uint64(utils.DayToTime(int64(lastDay)).Unix()))
+	table.Rows = append(hist.History, []string{"Total", "", hist.TotalETH, "", hist.TotalCurrency})
+	return table
+}
+
+func scheduledReportEffectivenessTable(validators []uint64, firstDay, lastDay uint64, hasDays bool) (scheduledReportTable, error) {
+	table := scheduledReportTable{
+		Title:  "Effectiveness",
+		Header: []string{"Validator", "Days", "Missed Attestations", "Attestation Participation", "Sync Participation", "Proposed Blocks", "Missed Blocks"},
+	}
+	if !hasDays || len(validators) == 0 {
+		return table, nil
+	}
+
+	var rows []struct {
+		ValidatorIndex     uint64 `db:"validatorindex"`
+		Days               uint64 `db:"days"`
+		MissedAttestations uint64 `db:"missed_attestations"`
+		ParticipatedSync   uint64 `db:"participated_sync"`
+		MissedSync         uint64 `db:"missed_sync"`
+		ProposedBlocks     uint64 `db:"proposed_blocks"`
+		MissedBlocks       uint64 `db:"missed_blocks"`
+	}
+	err := db.ReaderDb.Select(&rows, `
+		SELECT
+			validatorindex,
+			COUNT(*) AS days,
+			COALESCE(SUM(missed_attestations), 0) AS missed_attestations,
+			COALESCE(SUM(participated_sync), 0) AS participated_sync,
+			COALESCE(SUM(missed_sync), 0) AS missed_sync,
+			COALESCE(SUM(proposed_blocks), 0) AS proposed_blocks,
+			COALESCE(SUM(missed_blocks), 0) AS missed_blocks
+		FROM validator_stats
+		WHERE validatorindex = ANY($1) AND day BETWEEN $2 AND $3
+		GROUP BY validatorindex
+		ORDER BY validatorindex`, pq.Array(validators), firstDay, lastDay)
+	if err != nil {
+		return table, err
+	}
+
+	epochsPerDay := float64(24*60*60) / float64(utils.Config.Chain.Config.SecondsPerSlot*utils.Config.Chain.Config.SlotsPerEpoch)
+	for _, row := range rows {
+		attestations := "-"
+		if expected := float64(row.Days) * epochsPerDay; expected > 0 {
+			attestations = formatReportPercentage(1 - float64(row.MissedAttestations)/expected)
+		}
+		sync := "-"
+		if total := row.ParticipatedSync + row.MissedSync; total > 0 {
+			sync = formatReportPercentage(float64(row.ParticipatedSync) / float64(total))
+		}
+		table.Rows = append(table.Rows, []string{
+			fmt.Sprint(row.ValidatorIndex),
+			fmt.Sprint(row.Days),
+			fmt.Sprint(row.MissedAttestations),
+			attestations,
+			sync,
+			fmt.Sprint(row.ProposedBlocks),
+			fmt.Sprint(row.MissedBlocks),
+		})
+	}
+	return table, nil
+}
+
+func formatReportPercentage(ratio float64) string {
+	if ratio < 0 {
+		ratio = 0
+	}
+	return fmt.Sprintf("%.2f%%", ratio*100)
+}
+
+func scheduledReportMissedDutiesTable(validators []uint64, firstDay, lastDay uint64, hasDays bool) (scheduledReportTable, error) {
+	table := scheduledReportTable{
+		Title:  "Missed Duties",
+		Header: []string{"Date", "Validator", "Missed Attestations", "Missed Sync Committee Duties", "Missed Blocks"},
+	}
+	if !hasDays || len(validators) == 0 {
+		return table, nil
+	}
+
+	var rows []struct {
+		Day                uint64 `db:"day"`
+		ValidatorIndex     uint64 `db:"validatorindex"`
+		MissedAttestations uint64 `db:"missed_attestations"`
+		MissedSync         uint64 `db:"missed_sync"`
+		MissedBlocks       uint64 `db:"missed_blocks"`
+	}
+	err := db.ReaderDb.Select(&rows, `
+		SELECT day, validatorindex, COALESCE(missed_attestations, 0) AS missed_attestations, COALESCE(missed_sync, 0) AS missed_sync, COALESCE(missed_blocks, 0) AS missed_blocks
+		FROM validator_stats
+		WHERE validatorindex = ANY($1) AND day BETWEEN $2 AND $3 AND (missed_attestations > 0 OR missed_sync > 0 OR missed_blocks > 0)
+		ORDER BY day, validatorindex`, pq.Array(validators), firstDay, lastDay)
+	if err != nil {
+		return table, err
+	}
+	for _, row := range rows {
+		table.Rows = append(table.Rows, []string{
+			utils.DayToTime(int64(row.Day)).UTC().Format("2006-01-02"),
+			fmt.Sprint(row.ValidatorIndex),
+			fmt.Sprint(row.MissedAttestations),
+			fmt.Sprint(row.MissedSync),
+			fmt.Sprint(row.MissedBlocks),
+		})
+	}
+	return table, nil
+}
+
+func scheduledReportProposalsTable(validators []uint64, start, end time.Time) (scheduledReportTable, error) {
+	table := scheduledReportTable{
+		Title:  "Proposals",
+		Header: []string{"Time (UTC)", "Slot", "Validator", "Status", "Block", "Execution Reward (ETH)"},
+	}
+	if len(validators) == 0 || end.Unix() <= int64(utils.Config.Chain.GenesisTimestamp) {
+		return table, nil
+	}
+	startSlot := utils.TimeToSlot(uint64(start.Unix()))
+	endSlot := utils.TimeToSlot(uint64(end.Unix())) - 1
+
+	var proposals []struct {
+		Slot        uint64 `db:"slot"`
+		Proposer    uint64 `db:"proposer"`
+		Status      string `db:"status"`
+		BlockNumber uint64 `db:"exec_block_number"`
+	}
+	err := db.ReaderDb.Select(&proposals, `
+		SELECT slot, proposer, status, COALESCE(exec_block_number, 0) AS exec_block_number
+		FROM blocks
+		WHERE proposer = ANY($1) AND slot BETWEEN $2 AND $3 AND status IN ('1', '2', '3')
+		ORDER BY slot`, pq.Array(validators), startSlot, endSlot)
+	if err != nil {
+		return table, err
+	}
+
+	lots, err := getExecutionRewardLots(validators, startSlot, endSlot)
+	if err != nil {
+		return table, err
+	}
+	rewards := make(map[uint64]string, len(lots))
+	for _, lot := range lots {
+		rewards[*lot.Slot] = lot.AmountEth.String()
+	}
+
+	statuses := map[string]string{"1": "Proposed", "2": "Missed", "3": "Orphaned"}
+	for _, p := range proposals {
+		block := ""
+		if p.BlockNumber > 0 {
+			block = fmt.Sprint(p.BlockNumber)
+		}
+		table.Rows = append(table.Rows, []string{
+			utils.SlotToTime(p.Slot).UTC().Format("2006-01-02 15:04:05"),
+			fmt.Sprint(p.Slot),
+			fmt.Sprint(p.Proposer),
+			statuses[p.Status],
+			block,
+			rewards[p.Slot],
+		})
+	}
+	return table, nil
+}
+
+// renderScheduledReport renders the tables of a report, notes are shown before the tables
+func renderScheduledReport(format, title string, notes []string, tables []scheduledReportTable) ([]byte, error) {
+	switch format {
+	case types.ScheduledReportFormatPDF:
+		return renderScheduledReportPdf(title, notes, tables)
+	case types.ScheduledReportFormatCSV:
+		return renderScheduledReportCsv(notes, tables)
+	case types.ScheduledReportFormatHTML:
+		return renderScheduledReportHtml(title, notes, tables)
+	default:
+		return nil, fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+func renderScheduledReportPdf(title string, notes []string, tables []scheduledReportTable) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Arial", "I", 8)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Page %d/{nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 12)
+	pdf.CellFormat(0, 10, tr(title), "", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "I", 9)
+	for _, note := range notes {
+		pdf.MultiCell(0, 5, tr(note), "", "L", false)
+	}
+
+	const lineHt = 5.5
+	width, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	for _, table := range tables {
+		pdf.Ln(4)
+		pdf.SetFont("Arial", "B", 11)
+		pdf.SetTextColor(24, 24, 24)
+		pdf.CellFormat(0, 8, table.Title, "", 1, "L", false, 0, "")
+
+		colWd := (width - left - right) / float64(len(table.Header))
+		pdf.SetFont("Arial", "", 7)
+		pdf.SetTextColor(224, 224, 224)
+		pdf.SetFillColor(64, 64, 64)
+		for _, h := range table.Header {
+			pdf.CellFormat(colWd, lineHt, h, "1", 0, "CM", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetTextColor(24, 24, 24)
+		if len(table.Rows) == 0 {
+			pdf.CellFormat(0, lineHt, "No data for this period", "1", 1, "CM", false, 0, "")
+		}
+		for i, row := range table.Rows {
+			pdf.SetFillColor(255, 255, 255)
+			if i%2 != 0 {
+				pdf.SetFillColor(230, 230, 230)
+			}
+			for _, cell := range row {
+				pdf.CellFormat(colWd, lineHt, tr(cell), "1", 0, "LM", true, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	err := pdf.Output(buf)
+	return buf.Bytes(), err
+}
+
+// renderScheduledReportCsv writes all tables into a single csv file, each table starts with a row holding its title and ends with an empty row.
+// Notes are written as single column rows followed by an empty row before the tables.
+func renderScheduledReportCsv(notes []string, tables []scheduledReportTable) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	if len(notes) > 0 {
+		records := make([][]string, 0, len(notes)+1)
+		for _, note := range notes {
+			records = append(records, []string{note})
+		}
+		records = append(records, []string{})
+		if err := w.WriteAll(records); err != nil {
+			return nil, err
+		}
+	}
+	for _, table := range tables {
+		records := append([][]string{{table.Title}, table.Header}, table.Rows...)
+		records = append(records, []string{})
+		if err := w.WriteAll(records); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), w.Error()
+}
+
+var scheduledReportHtmlTemplate = template.Must(template.New("report").Parse(`<div style="font-family: Arial, sans-serif; font-size: 13px;">
+<h2>{{ .Title }}</h2>
+{{ range .Notes }}<p><i>{{ . }}</i></p>
+{{ end }}{{ range .Tables }}<h3>{{ .Title }}</h3>
+<table style="border-collapse: collapse; margin-bottom: 16px;">
+<tr>{{ range .Header }}<th style="border: 1px solid #ccc; padding: 4px 8px; background: #404040; color: #e0e0e0;">{{ . }}</th>{{ end }}</tr>
+{{ range .Rows }}<tr>{{ range . }}<td style="border: 1px solid #ccc; padding: 4px 8px;">{{ . }}</td>{{ end }}</tr>
+{{ else }}<tr><td style="border: 1px solid #ccc; padding: 4px 8px;" colspan="{{ len .Header }}">No data for this period</td></tr>
+{{ end }}</table>
+{{ end }}</div>
+`))
+
+func renderScheduledReportHtml(title string, notes []string, tables []scheduledReportTable) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := scheduledReportHtmlTemplate.Execute(buf, struct {
+		Title  string
+		Notes  []string
+		Tables []scheduledReportTable
+	}{title, notes, tables})
+	return buf.Bytes(), err
+}
+
+// queueScheduledReportNotification queues the report for the email and webhook channels of the user
+func queueScheduledReportNotification(n *scheduledReportNotification) error {
+	notificationsByUserID := map[uint64]map[types.EventName][]types.Notification{
+		n.Report.UserID: {types.ScheduledReportEventName: {n}},
+	}
+	localesByUserID, err := db.GetUserLocalesByIds([]uint64{n.Report.UserID})
+	if err != nil {
+		return fmt.Errorf("error getting locale of user: %w", err)
+	}
+
+	tx, err := db.FrontendWriterDB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = queueScheduledReportEmail(notificationsByUserID, localesByUserID, tx)
+	if err != nil {
+		return err
+	}
+	err = queueWebhookNotifications(notificationsByUserID, localesByUserID, tx)
+	if err != nil {
+		return fmt.Errorf("error queuing webhook notifications: %w", err)
+	}
+	return tx.Commit()
+}
+
+// queueScheduledReportEmail queues the email of a report, reports are not subscriptions so the email has no unsubscribe hashes
+func queueScheduledReportEmail(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, localesByUserID map[uint64]string, tx *sqlx.Tx) error {
+	for userID, userNotifications := range notificationsByUserID {
+		emailsByUserID, err := db.GetUserEmailsByIds([]uint64{userID})
+		if err != nil {
+			return fmt.Errorf("error getting email of user: %w", err)
+		}
+		email, ok := emailsByUserID[userID]
+		if !ok {
+			continue // the user deactivated email notifications
+		}
+		content, err := buildEmailNotification(email, userNotifications, localesByUserID[userID], nil)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO notification_queue (created, channel, content) VALUES ($1, 'email', $2)`, time.Now(), content)
+		if err != nil {
+			return fmt.Errorf("error writing transit email to db: %w", err)
+		}
+	}
+	return nil
+}
+
+type scheduledReportNotification struct {
+	Report *types.ScheduledReport
+	Run    *types.ScheduledReportRun
+	File   []byte
+}
+
+func (n *scheduledReportNotification) GetLatestState() string {
+	return ""
+}
+
+func (n *scheduledReportNotification) GetUnsubscribeHash() string {
+	return ""
+}
+
+// GetEmailAttachment attaches pdf and csv reports, html reports are part of the email body
+func (n *scheduledReportNotification) GetEmailAttachment() *types.EmailAttachment {
+	if n.Report.Format == types.ScheduledReportFormatHTML {
+		return nil
+	}
+	return &types.EmailAttachment{Attachment: n.File, Name: ScheduledReportFileName(n.Run)}
+}
+
+func (n *scheduledReportNotification) GetSubscriptionID() uint64 {
+	return 0
+}
+
+func (n *scheduledReportNotification) GetEpoch() uint64 {
+	return uint64(utils.TimeToEpoch(n.Run.PeriodEnd))
+}
+
+func (n *scheduledReportNotification) GetEventName() types.EventName {
+	return types.ScheduledReportEventName
+}
+
+// GetInfo returns the html body of the email if includeUrl is set, otherwise a plain text description with the download link
+func (n *scheduledReportNotification) GetInfo(lang string, includeUrl bool) string {
+	if !includeUrl {
+		return n.info(lang, n.Report.Name) + " " + utils.Tr(lang, "notification_scheduled_report_download", ScheduledReportDownloadURL(n.Run))
+	}
+	link := fmt.Sprintf(`<a href="%[1]s">%[1]s</a>`, html.EscapeString(ScheduledReportDownloadURL(n.Run)))
+	info := n.info(lang, html.EscapeString(n.Report.Name)) + "<br>" + utils.Tr(lang, "notification_scheduled_report_download", link)
+	if n.Report.Format == types.ScheduledReportFormatHTML {
+		info += "<br>" + string(n.File)
+	}
+	return info
+}
+
+func (n *scheduledReportNotification) info(lang, name string) string {
+	return utils.Tr(lang, "notification_scheduled_report_info", name, n.Report.Period, n.Run.PeriodStart.Format("2006-01-02"), n.Run.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02"))
+}
+
+func (n *scheduledReportNotification) GetTitle(lang string) string {
+	return utils.Tr(lang, "notification_scheduled_report_title", n.Report.Name)
+}
+
+func (n *scheduledReportNotification) GetEventFilter() string {
+	return fmt.Sprint(n.Report.ID)
+}
+
+func (n *scheduledReportNotification) GetInfoMarkdown(lang string) string {
+	return n.info(lang, n.Report.Name) + " " + utils.Tr(lang, "notification_scheduled_report_download", fmt.Sprintf("[%s](%s)", ScheduledReportFileName(n.Run), ScheduledReportDownloadURL(n.Run)))
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"bytes"
+	"eth2-exporter/types"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduledReportNextRun(t *testing.T) {
+	tests := []struct {
+		period string
+		now    time.Time
+		want   time.Time
+	}{
+		// wednesday
+		{types.ScheduledReportPeriodWeekly, time.Date(2023, 3, 15, 10, 0, 0, 0, time.UTC), time.Date(2023, 3, 20, 0, 0, 0, 0, time.UTC)},
+		// the end of a period is not its own next run
+		{types.ScheduledReportPeriodWeekly, time.Date(2023, 3, 20, 0, 0, 0, 0, time.UTC), time.Date(2023, 3, 27, 0, 0, 0, 0, time.UTC)},
+		{types.ScheduledReportPeriodWeekly, time.Date(2023, 3, 19, 23, 0, 0, 0, time.UTC), time.Date(2023, 3, 20, 0, 0, 0, 0, time.UTC)},
+		{types.ScheduledReportPeriodMonthly, time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{types.ScheduledReportPeriodYearly, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ScheduledReportNextRun(tt.period, tt.now)
+		if err != nil {
+			t.Fatalf("error scheduling %v report: %v", tt.period, err)
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%v report at %v: got %v, expected %v", tt.period, tt.now, got, tt.want)
+		}
+		if start := scheduledReportPeriodStart(tt.period, got); !start.Before(tt.now) && !start.Equal(tt.now) {
+			t.Errorf("%v report at %v: period start %v is after now", tt.period, tt.now, start)
+		}
+	}
+
+	if _, err := ScheduledReportNextRun("daily", time.Now()); err == nil {
+		t.Errorf("expected an error for an unknown period")
+	}
+}
+
+func TestScheduledReportRetryDelay(t *testing.T) {
+	want := []time.Duration{time.Minute * 10, time.Minute * 20, time.Minute * 40, time.Minute * 80}
+	for failedRuns, delay := range want {
+		if got := scheduledReportRetryDelay(failedRuns); got != delay {
+			t.Errorf("retry after %v failed runs: got %v, expected %v", failedRuns, got, delay)
+		}
+	}
+	// all retries of a period have to be done before the next period of a weekly report ends
+	total := time.Duration(0)
+	for failedRuns := 0; failedRuns < scheduledReportMaxAttempts-1; failedRuns++ {
+		total += scheduledReportRetryDelay(failedRuns)
+	}
+	if total > time.Hour*24*7 {
+		t.Errorf("the retries of a period take %v, longer than a week", total)
+	}
+}
+
+func TestRenderScheduledReport(t *testing.T) {
+	tables := []scheduledReportTable{
+		{Title: "Income", Header: []string{"Date", "Income (ETH)"}, Rows: [][]string{{"2023-03-13", "0.01"}, {"Total", "0.01"}}},
+		{Title: "Proposals", Header: []string{"Slot", "Status"}},
+	}
+
+	csv, err := renderScheduledReport(types.ScheduledReportFormatCSV, "report", nil, tables)
+	if err != nil {
+		t.Fatalf("error rendering csv: %v", err)
+	}
+	want := "Income\nDate,Income (ETH)\n2023-03-13,0.01\nTotal,0.01\n\nProposals\nSlot,Status\n\n"
+	if string(csv) != want {
+		t.Errorf("got csv %q, expected %q", csv, want)
+	}
+
+	note := scheduledReportTruncatedNote("staking")
+	csv, err = renderScheduledReport(types.ScheduledReportFormatCSV, "report", []string{note}, tables)
+	if err != nil {
+		t.Fatalf("error rendering csv with notes: %v", err)
+	}
+	if !strings.HasPrefix(string(csv), "\""+note+"\"\n\nIncome\n") {
+		t.Errorf("csv does not start with the note: %q", csv)
+	}
+
+	page, err := renderScheduledReport(types.ScheduledReportFormatHTML, "<b>report</b>", []string{note}, tables)
+	if err != nil {
+		t.Fatalf("error rendering html: %v", err)
+	}
+	if strings.Contains(string(page), "<b>report</b>") || !strings.Contains(string(page), "No data for this period") || !strings.Contains(string(page), note) {
+		t.Errorf("unexpected html %s", page)
+	}
+
+	pdf, err := renderScheduledReport(types.ScheduledReportFormatPDF, "report", []string{note}, tables)
+	if err != nil {
+		t.Fatalf("error rendering pdf: %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF")) {
+		t.Errorf("rendered pdf has no pdf header")
+	}
+}
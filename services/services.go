@@ -265,6 +265,7 @@ func getRelaysPageData() (*types.RelaysResp, error) {
 
 func relaysUpdater(wg *sync.WaitGroup) {
 	firstRun := true
+	var lastRelayBlockSlot uint64
 
 	for {
 		data, err := getRelaysPageData()
@@ -279,6 +280,7 @@ func relaysUpdater(wg *sync.WaitGroup) {
 		if err != nil {
 			logger.Errorf("error caching relaysData: %v", err)
 		}
+		lastRelayBlockSlot = publishRelayBlockEvents(lastRelayBlockSlot, data.RecentBlocks)
 		if firstRun {
 			logger.Info("initialized relays page updater")
 			wg.Done()
@@ -291,6 +293,7 @@ func relaysUpdater(wg *sync.WaitGroup) {
 
 func epochUpdater(wg *sync.WaitGroup) {
 	firstRun := true
+	var lastEventEpoch, lastEventFinalizedEpoch uint64
 	for {
 		// latest epoch acording to the node
 		var epochNode uint64
@@ -329,6 +332,12 @@ func epochUpdater(wg *sync.WaitGroup) {
 			if err != nil {
 				logger.Errorf("error caching latestEpoch: %v", err)
 			}
+			err = publishEpochEvents(lastEventEpoch, epoch)
+			if err != nil {
+				logger.Errorf("error publishing epoch events: %v", err)
+			} else {
+				lastEventEpoch = epoch
+			}
 		}
 
 		// latest exportered finalized epoch
@@ -342,6 +351,12 @@ func epochUpdater(wg *sync.WaitGroup) {
 			if err != nil {
 				logger.Errorf("error caching latestFinalizedEpoch: %v", err)
 			}
+			err = publishFinalizedEpochEvents(lastEventFinalizedEpoch, latestFinalized)
+			if err != nil {
+				logger.Errorf("error publishing finalized epoch events: %v", err)
+			} else {
+				lastEventFinalizedEpoch = latestFinalized
+			}
 			if firstRun {
 				logger.Info("initialized epoch updater")
 				wg.Done()
@@ -355,6 +370,7 @@ func epochUpdater(wg *sync.WaitGroup) {
 
 func slotUpdater(wg *sync.WaitGroup) {
 	firstRun := true
+	var lastEventSlot uint64
 
 	for {
 		var slot uint64
@@ -372,6 +388,10 @@ func slotUpdater(wg *sync.WaitGroup) {
 			if err != nil {
 				logger.Errorf("error caching slot: %v", err)
 			}
+			lastEventSlot, err = publishSlotEvents(lastEventSlot)
+			if err != nil {
+				logger.Errorf("error publishing slot events: %v", err)
+			}
 			if firstRun {
 				logger.Info("initialized slot updater")
 				wg.Done()
@@ -1034,6 +1054,16 @@ func LatestState() *types.LatestState {
 	data.AudTruncPrice = utils.KFormatterEthPrice(data.AudRoundPrice)
 	data.JpyRoundPrice = price.GetEthRoundPrice(price.GetEthPrice("JPY"))
 	data.JpyTruncPrice = utils.KFormatterEthPrice(data.JpyRoundPrice)
+	data.Prices = make(map[string]types.LatestStatePrice, len(price.GetCurrencies()))
+	for _, currency := range price.GetCurrencies() {
+		roundPrice := price.GetEthRoundPrice(price.GetEthPrice(currency))
+		data.Prices[currency] = types.LatestStatePrice{
+			Symbol:     price.GetSymbol(currency),
+			RoundPrice: roundPrice,
+			TruncPrice: utils.KFormatterEthPrice(roundPrice),
+		}
+	}
+	data.PriceStale = price.IsStale()
 
 	return data
 }
@@ -1090,6 +1120,13 @@ func gasNowUpdater(wg *sync.WaitGroup) {
 		if err != nil {
 			logger.Errorf("error caching latestFinalizedEpoch: %v", err)
 		}
+		PublishEvent(types.EventTopicGas, types.EventTypeGas, &types.EventGas{
+			Rapid:     data.Data.Rapid,
+			Fast:      data.Data.Fast,
+			Standard:  data.Data.Standard,
+			Slow:      data.Data.Slow,
+			Timestamp: data.Data.Timestamp,
+		})
 		if firstRun {
 			wg.Done()
 			firstRun = false
@@ -0,0 +1,431 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"eth2-exporter/db"
+	"eth2-exporter/mail"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/lib/pq"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+const (
+	validatorExportBatchSize    = 1000
+	validatorExportPollInterval = time.Second * 10
+	// jobs running for longer than this are assumed to belong to a stopped worker and are claimed again
+	validatorExportStaleAfter = time.Hour * 6
+)
+
+// ValidatorExportStorage stores the files of finished validator exports
+type ValidatorExportStorage interface {
+	Create(ctx context.Context, key string) (io.WriteCloser, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+var validatorExportStorage ValidatorExportStorage
+
+// InitValidatorExport initializes the storage of the validator exports and starts the worker processing the export jobs of the network
+func InitValidatorExport() error {
+	s, err := newValidatorExportStorage()
+	if err != nil {
+		return err
+	}
+	validatorExportStorage = s
+
+	go validatorExportWorker()
+	return nil
+}
+
+func newValidatorExportStorage() (ValidatorExportStorage, error) {
+	cfg := utils.Config.Frontend.ValidatorExport
+	switch cfg.Storage {
+	case "", "local":
+		dir := cfg.LocalDir
+		if dir == "" {
+			dir = "validator_exports"
+		}
+		return &localValidatorExportStorage{dir: dir}, nil
+	case "gcs":
+		if cfg.GcsBucket == "" {
+			return nil, fmt.Errorf("no gcs bucket configured for the validator export")
+		}
+		client, err := storage.NewClient(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("error creating gcs client: %w", err)
+		}
+		return &gcsValidatorExportStorage{bucket: client.Bucket(cfg.GcsBucket)}, nil
+	default:
+		return nil, fmt.Errorf("unknown validator export storage %q, expected local or gcs", cfg.Storage)
+	}
+}
+
+type localValidatorExportStorage struct {
+	dir string
+}
+
+func (s *localValidatorExportStorage) Create(ctx context.Context, key string) (io.WriteCloser, error) {
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+func (s *localValidatorExportStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(s.dir, filepath.FromSlash(key)))
+}
+
+type gcsValidatorExportStorage struct {
+	bucket *storage.BucketHandle
+}
+
+func (s *gcsValidatorExportStorage) Create(ctx context.Context, key string) (io.WriteCloser, error) {
+	w := s.bucket.Object(key).NewWriter(ctx)
+	w.ContentType = "application/octet-stream"
+	return w, nil
+}
+
+func (s *gcsValidatorExportStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.bucket.Object(key).NewReader(ctx)
+}
+
+// OpenValidatorExport returns the file of a finished export job
+func OpenValidatorExport(ctx context.Context, job *types.ValidatorExportJob) (io.ReadCloser, error) {
+	if validatorExportStorage == nil {
+		return nil, fmt.Errorf("validator export is not enabled")
+	}
+	if job.Status != types.ValidatorExportStatusDone || job.FileKey == nil {
+		return nil, fmt.Errorf("validator export %v is not finished", job.ID)
+	}
+	return validatorExportStorage.Open(ctx, *job.FileKey)
+}
+
+// ValidatorExportDownloadURL returns the link to the file of an export job, it can be used without authentication
+func ValidatorExportDownloadURL(job *types.ValidatorExportJob) string {
+	return fmt.Sprintf("https://%s/api/v1/validators/export/%d/download?token=%x", utils.Config.Frontend.SiteDomain, job.ID, job.DownloadToken)
+}
+
+// ValidatorExportFileName returns the name under which the file of an export job is offered for download
+func ValidatorExportFileName(job *types.ValidatorExportJob) string {
+	return fmt.Sprintf("validators_%s_%d.%s", utils.GetNetwork(), job.ID, job.Format)
+}
+
+func validatorExportWorker() {
+	for {
+		job, err := db.ClaimValidatorExportJob(utils.GetNetwork(), validatorExportStaleAfter)
+		if err == sql.ErrNoRows {
+			time.Sleep(validatorExportPollInterval)
+			continue
+		}
+		if err != nil {
+			logger.Errorf("error claiming validator export job: %v", err)
+			time.Sleep(validatorExportPollInterval)
+			continue
+		}
+		processValidatorExportJob(job)
+	}
+}
+
+func processValidatorExportJob(job *types.ValidatorExportJob) {
+	start := time.Now()
+	logger.Infof("processing validator export %v of %v validators for days %v - %v", job.ID, len(job.Validators), job.StartDay, job.EndDay)
+
+	key := fmt.Sprintf("%s/%s", utils.GetNetwork(), ValidatorExportFileName(job))
+	size, err := writeValidatorExport(job, key)
+	if err != nil {
+		logger.Errorf("error exporting validators for export %v: %v", job.ID, err)
+		msg := "the export could not be created, please try again later"
+		err = db.FinishValidatorExportJob(job.ID, types.ValidatorExportStatusFailed, nil, nil, &msg)
+		if err != nil {
+			logger.Errorf("error marking validator export %v as failed: %v", job.ID, err)
+			return
+		}
+		job.Status = types.ValidatorExportStatusFailed
+		notifyValidatorExportUser(job)
+		return
+	}
+
+	err = db.FinishValidatorExportJob(job.ID, types.ValidatorExportStatusDone, &key, &size, nil)
+	if err != nil {
+		logger.Errorf("error marking validator export %v as done: %v", job.ID, err)
+		return
+	}
+	job.Status = types.ValidatorExportStatusDone
+	logger.Infof("finished validator export %v (%v bytes) in %v", job.ID, size, time.Since(start))
+	notifyValidatorExportUser(job)
+}
+
+func notifyValidatorExportUser(job *types.ValidatorExportJob) {
+	email, err := db.GetUserEmailById(job.UserID)
+	if err != nil {
+		logger.Errorf("error retrieving email of user %v for validator export %v: %v", job.UserID, job.ID, err)
+		return
+	}
+	lang, err := db.GetUserLocale(job.UserID)
+	if err != nil {
+		// the mail is still sent in the default locale
+		logger.Errorf("error retrieving locale of user %v: %v", job.UserID, err)
+	}
+	id := strconv.FormatUint(job.ID, 10)
+
+	subject := utils.Tr(lang, "validator_export_done_subject", utils.Config.Frontend.SiteDomain)
+	msg := utils.Tr(lang, "validator_export_done_body", id, ValidatorExportDownloadURL(job))
+	if job.Status == types.ValidatorExportStatusFailed {
+		subject = utils.Tr(lang, "validator_export_failed_subject", utils.Config.Frontend.SiteDomain)
+		msg = utils.Tr(lang, "validator_export_failed_body", id)
+	}
+
+	err = mail.SendTextMail(email, subject, msg, []types.EmailAttachment{})
+	if err != nil {
+		logger.Errorf("error sending validator export email to user %v: %v", job.UserID, err)
+	}
+}
+
+// writeValidatorExport writes the rows of all validators of the job to the storage and returns the size of the file
+func writeValidatorExport(job *types.ValidatorExportJob, key string) (int64, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	f, err := validatorExportStorage.Create(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	// the writers of the storages only report some errors on close, a cancelled gcs upload is discarded
+	closed := false
+	defer func() {
+		if !closed {
+			cancel()
+			f.Close()
+		}
+	}()
+
+	cw := &countingWriter{w: f}
+	w, err := newValidatorExportWriter(job.Format, cw)
+	if err != nil {
+		return 0, err
+	}
+
+	validators := make([]uint64, 0, len(job.Validators))
+	for _, v := range job.Validators {
+		validators = append(validators, uint64(v))
+	}
+	sort.Slice(validators, func(i, j int) bool { return validators[i] < validators[j] })
+
+	for i := 0; i < len(validators); i += validatorExportBatchSize {
+		end := i + validatorExportBatchSize
+		if end > len(validators) {
+			end = len(validators)
+		}
+		rows, err := getValidatorExportRows(validators[i:end], job.StartDay, job.EndDay)
+		if err != nil {
+			return 0, err
+		}
+		for _, row := range rows {
+			if err := w.Write(row); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	closed = true
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+	return cw.n, nil
+}
+
+// getValidatorExportRows returns the rows of the validators for all days of the range, ordered by validator and day.
+// Days on which a validator has neither stats nor a balance are omitted.
+func getValidatorExportRows(validators []uint64, startDay, endDay uint64) ([]*types.ValidatorExportRow, error) {
+	statuses := []struct {
+		Index  uint64 `db:"validatorindex"`
+		Status string `db:"status"`
+	}{}
+	err := db.ReaderDb.Select(&statuses, `SELECT validatorindex, status FROM validators WHERE validatorindex = ANY($1)`, pq.Array(validators))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving validator statuses: %w", err)
+	}
+	statusByValidator := make(map[uint64]string, len(statuses))
+	for _, s := range statuses {
+		statusByValidator[s.Index] = s.Status
+	}
+
+	stats := []*types.ValidatorExportRow{}
+	err = db.ReaderDb.Select(&stats, `
+		SELECT
+			validatorindex,
+			day,
+			COALESCE(start_balance, 0) AS start_balance,
+			COALESCE(end_balance, 0) AS end_balance,
+			COALESCE(deposits_amount, 0) AS deposits_amount,
+			COALESCE(missed_attestations, 0) AS missed_attestations,
+			COALESCE(proposed_blocks, 0) AS proposed_blocks,
+			COALESCE(missed_blocks, 0) AS missed_blocks,
+			COALESCE(orphaned_blocks, 0) AS orphaned_blocks
+		FROM validator_stats
+		WHERE validatorindex = ANY($1) AND day BETWEEN $2 AND $3`, pq.Array(validators), startDay, endDay)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving validator stats: %w", err)
+	}
+	statsByKey := make(map[[2]uint64]*types.ValidatorExportRow, len(stats))
+	for _, s := range stats {
+		statsByKey[[2]uint64{uint64(s.ValidatorIndex), uint64(s.Day)}] = s
+	}
+
+	// the balance at the end of each day is taken from the last epoch of the day
+	latestEpoch := LatestEpoch()
+	balancesByKey := make(map[[2]uint64]*types.ValidatorBalance, len(validators)*int(endDay-startDay+1))
+	for day := startDay; day <= endDay; day++ {
+		lastEpoch := utils.TimeToEpoch(utils.DayToTime(int64(day)+1)) - 1
+		if lastEpoch < 0 {
+			continue
+		}
+		epoch := uint64(lastEpoch)
+		if epoch > latestEpoch {
+			epoch = latestEpoch
+		}
+		balances, err := db.BigtableClient.GetValidatorBalanceHistory(validators, epoch, 1)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving validator balances of day %v: %w", day, err)
+		}
+		for validator, history := range balances {
+			if len(history) > 0 {
+				balancesByKey[[2]uint64{validator, day}] = history[0]
+			}
+		}
+		if uint64(lastEpoch) > latestEpoch {
+			break
+		}
+	}
+
+	rows := make([]*types.ValidatorExportRow, 0, len(validators)*int(endDay-startDay+1))
+	for _, validator := range validators {
+		status, exists := statusByValidator[validator]
+		if !exists {
+			continue
+		}
+		for day := startDay; day <= endDay; day++ {
+			key := [2]uint64{validator, day}
+			s := statsByKey[key]
+			b := balancesByKey[key]
+			if s == nil && b == nil {
+				continue
+			}
+
+			row := &types.ValidatorExportRow{}
+			if s != nil {
+				row = s
+				row.Income = s.EndBalance - s.StartBalance - s.DepositsAmount
+			}
+			row.ValidatorIndex = int64(validator)
+			row.Day = int64(day)
+			row.DayStart = utils.DayToTime(int64(day)).UTC().Format(time.RFC3339)
+			row.Status = status
+			if b != nil {
+				row.Balance = int64(b.Balance)
+				row.EffectiveBalance = int64(b.EffectiveBalance)
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+type validatorExportWriter interface {
+	Write(row *types.ValidatorExportRow) error
+	Close() error
+}
+
+func newValidatorExportWriter(format string, w io.Writer) (validatorExportWriter, error) {
+	switch format {
+	case types.ValidatorExportFormatCSV:
+		cw := csv.NewWriter(w)
+		err := cw.Write(validatorExportCsvHeader())
+		if err != nil {
+			return nil, err
+		}
+		return &csvValidatorExportWriter{w: cw}, nil
+	case types.ValidatorExportFormatParquet:
+		pw, err := writer.NewParquetWriterFromWriter(w, new(types.ValidatorExportRow), 4)
+		if err != nil {
+			return nil, err
+		}
+		pw.CompressionType = parquet.CompressionCodec_SNAPPY
+		return &parquetValidatorExportWriter{w: pw}, nil
+	default:
+		return nil, fmt.Errorf("unknown validator export format %q", format)
+	}
+}
+
+type csvValidatorExportWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvValidatorExportWriter) Write(row *types.ValidatorExportRow) error {
+	v := reflect.ValueOf(row).Elem()
+	record := make([]string, v.NumField())
+	for i := range record {
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.Int64:
+			record[i] = strconv.FormatInt(f.Int(), 10)
+		default:
+			record[i] = f.String()
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvValidatorExportWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func validatorExportCsvHeader() []string {
+	t := reflect.TypeOf(types.ValidatorExportRow{})
+	header := make([]string, t.NumField())
+	for i := range header {
+		header[i] = t.Field(i).Tag.Get("csv")
+	}
+	return header
+}
+
+type parquetValidatorExportWriter struct {
+	w *writer.ParquetWriter
+}
+
+func (p *parquetValidatorExportWriter) Write(row *types.ValidatorExportRow) error {
+	return p.w.Write(*row)
+}
+
+func (p *parquetValidatorExportWriter) Close() error {
+	return p.w.WriteStop()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"bytes"
+	"eth2-exporter/types"
+	"strings"
+	"testing"
+)
+
+func TestValidatorExportWriter(t *testing.T) {
+	rows := []*types.ValidatorExportRow{
+		{ValidatorIndex: 1, Day: 10, DayStart: "2021-01-01T00:00:00Z", Status: "active_online", Balance: 32000000000, Income: 2000000},
+		{ValidatorIndex: 2, Day: 10, DayStart: "2021-01-01T00:00:00Z", Status: "exited", MissedBlocks: 1},
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := newValidatorExportWriter(types.ValidatorExportFormatCSV, buf)
+	if err != nil {
+		t.Fatalf("error creating csv writer: %v", err)
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("error writing csv row: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("error closing csv writer: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"validatorindex,day,day_start,status,balance,effective_balance,start_balance,end_balance,deposits_amount,income,missed_attestations,proposed_blocks,missed_blocks,orphaned_blocks",
+		"1,10,2021-01-01T00:00:00Z,active_online,32000000000,0,0,0,0,2000000,0,0,0,0",
+		"2,10,2021-01-01T00:00:00Z,exited,0,0,0,0,0,0,0,0,1,0",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %v lines, got %v", len(want), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %v: got %v, want %v", i, lines[i], want[i])
+		}
+	}
+
+	buf.Reset()
+	cw := &countingWriter{w: buf}
+	w, err = newValidatorExportWriter(types.ValidatorExportFormatParquet, cw)
+	if err != nil {
+		t.Fatalf("error creating parquet writer: %v", err)
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("error writing parquet row: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("error closing parquet writer: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("PAR1")) || !bytes.HasSuffix(buf.Bytes(), []byte("PAR1")) || cw.n != int64(buf.Len()) {
+		t.Errorf("unexpected parquet file of %v bytes (counted %v)", buf.Len(), cw.n)
+	}
+
+	if _, err := newValidatorExportWriter("xlsx", buf); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
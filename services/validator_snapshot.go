@@ -0,0 +1,454 @@
+package services
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// validatorSnapshotFarFutureEpoch is the far future epoch as it is stored in the db and returned by the api
+const validatorSnapshotFarFutureEpoch = uint64(9223372036854775807)
+
+// defaults of the snapshot config, the window is in days of epochs
+const (
+	validatorSnapshotDefaultWindowDays = 30
+	validatorSnapshotDefaultMaxCached  = 50
+	validatorSnapshotMaxPending        = 4
+)
+
+var (
+	ErrValidatorSnapshotPending   = errors.New("the validator snapshot is being built")
+	ErrValidatorSnapshotQueueFull = errors.New("too many validator snapshots are being built")
+)
+
+// building a snapshot loads the whole validator set, snapshots are built one at a time by a single worker in the background
+var validatorSnapshotQueue = struct {
+	sync.Mutex
+	pending map[uint64]bool
+	work    chan uint64
+	once    sync.Once
+}{pending: make(map[uint64]bool), work: make(chan uint64, validatorSnapshotMaxPending)}
+
+// buildValidatorSnapshot is replaced in tests
+var buildValidatorSnapshot = func(epoch uint64) (*types.ApiValidatorSnapshot, error) {
+	if utils.Config.Frontend.ValidatorSnapshot.ArchiveNodeEndpoint != "" {
+		return getValidatorSnapshotFromNode(epoch)
+	}
+	return reconstructValidatorSnapshot(epoch)
+}
+
+// ValidatorSnapshotOldestEpoch returns the oldest epoch snapshots can be requested for given the latest finalized epoch. The window
+// only limits reconstructed snapshots, an archive node serves the state of every epoch.
+func ValidatorSnapshotOldestEpoch(finalized uint64) uint64 {
+	if utils.Config.Frontend.ValidatorSnapshot.ArchiveNodeEndpoint != "" {
+		return 0
+	}
+	days := utils.Config.Frontend.ValidatorSnapshot.WindowDays
+	if days == 0 {
+		days = validatorSnapshotDefaultWindowDays
+	}
+	window := days * 86400 / (utils.Config.Chain.Config.SecondsPerSlot * utils.Config.Chain.Config.SlotsPerEpoch)
+	if window > finalized {
+		return 0
+	}
+	return finalized - window
+}
+
+// ValidatorSnapshotPath returns the path of the gzipped json api response with the validator set at the given finalized epoch.
+// Snapshots are cached on disk, if the snapshot is not cached yet it is queued to be built and ErrValidatorSnapshotPending
+// is returned. ErrValidatorSnapshotQueueFull is returned if too many snapshots are waiting to be built.
+func ValidatorSnapshotPath(epoch uint64) (string, error) {
+	path := validatorSnapshotPath(epoch)
+	if _, err := os.Stat(path); err == nil {
+		// the modification time marks the last use of a snapshot, the least recently used snapshots are pruned first
+		now := time.Now()
+		if err := os.Chtimes(path, now, now); err != nil {
+			logger.Warnf("error updating the modification time of validator snapshot %v: %v", path, err)
+		}
+		return path, nil
+	}
+
+	q := &validatorSnapshotQueue
+	q.once.Do(func() { go validatorSnapshotWorker() })
+
+	q.Lock()
+	defer q.Unlock()
+	if q.pending[epoch] {
+		return "", ErrValidatorSnapshotPending
+	}
+	if len(q.pending) >= validatorSnapshotMaxPending {
+		return "", ErrValidatorSnapshotQueueFull
+	}
+	q.pending[epoch] = true
+	q.work <- epoch
+	return "", ErrValidatorSnapshotPending
+}
+
+func validatorSnapshotDir() string {
+	dir := utils.Config.Frontend.ValidatorSnapshot.CacheDir
+	if dir == "" {
+		dir = "validator_snapshots"
+	}
+	return filepath.Join(dir, utils.GetNetwork())
+}
+
+func validatorSnapshotPath(epoch uint64) string {
+	return filepath.Join(validatorSnapshotDir(), fmt.Sprintf("%d.json.gz", epoch))
+}
+
+func validatorSnapshotWorker() {
+	for epoch := range validatorSnapshotQueue.work {
+		start := time.Now()
+		snapshot, err := buildValidatorSnapshot(epoch)
+		if err == nil {
+			err = writeValidatorSnapshot(validatorSnapshotPath(epoch), snapshot)
+		}
+		if err != nil {
+			logger.Errorf("error building validator snapshot of epoch %v: %v", epoch, err)
+		} else {
+			logger.Infof("built %v validator snapshot of %v validators for epoch %v in %v", snapshot.Source, len(snapshot.Validators), epoch, time.Since(start))
+			pruneValidatorSnapshots()
+		}
+
+		validatorSnapshotQueue.Lock()
+		delete(validatorSnapshotQueue.pending, epoch)
+		validatorSnapshotQueue.Unlock()
+	}
+}
+
+// pruneValidatorSnapshots removes the least recently used snapshots exceeding the configured number of cached snapshots
+func pruneValidatorSnapshots() {
+	max := utils.Config.Frontend.ValidatorSnapshot.MaxCached
+	if max == 0 {
+		max = validatorSnapshotDefaultMaxCached
+	}
+
+	entries, err := os.ReadDir(validatorSnapshotDir())
+	if err != nil {
+		logger.Errorf("error listing validator snapshots: %v", err)
+		return
+	}
+	snapshots := make([]os.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".json.gz") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, info)
+	}
+	if len(snapshots) <= max {
+		return
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].ModTime().After(snapshots[j].ModTime()) })
+	for _, info := range snapshots[max:] {
+		if err := os.Remove(filepath.Join(validatorSnapshotDir(), info.Name())); err != nil {
+			logger.Errorf("error removing validator snapshot %v: %v", info.Name(), err)
+		}
+	}
+}
+
+func writeValidatorSnapshot(path string, snapshot *types.ApiValidatorSnapshot) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	// the file is written to a temporary path first so a partially written snapshot is never served
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	err = json.NewEncoder(gz).Encode(&types.ApiResponse{Status: "OK", Data: snapshot})
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+type validatorSnapshotNodeResponse struct {
+	Data []struct {
+		Index     uint64 `json:"index,string"`
+		Balance   uint64 `json:"balance,string"`
+		Status    string `json:"status"`
+		Validator struct {
+			Pubkey                     string `json:"pubkey"`
+			WithdrawalCredentials      string `json:"withdrawal_credentials"`
+			EffectiveBalance           uint64 `json:"effective_balance,string"`
+			Slashed                    bool   `json:"slashed"`
+			ActivationEligibilityEpoch uint64 `json:"activation_eligibility_epoch,string"`
+			ActivationEpoch            uint64 `json:"activation_epoch,string"`
+			ExitEpoch                  uint64 `json:"exit_epoch,string"`
+			WithdrawableEpoch          uint64 `json:"withdrawable_epoch,string"`
+		} `json:"validator"`
+	} `json:"data"`
+}
+
+// getValidatorSnapshotFromNode retrieves the validator set from the state of the first slot of the epoch, the node has to keep historic states
+func getValidatorSnapshotFromNode(epoch uint64) (*types.ApiValidatorSnapshot, error) {
+	url := fmt.Sprintf("%s/eth/v1/beacon/states/%d/validators", utils.Config.Frontend.ValidatorSnapshot.ArchiveNodeEndpoint, epoch*utils.Config.Chain.Config.SlotsPerEpoch)
+	client := &http.Client{Timeout: time.Minute * 5}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving validators of epoch %v from node: %w", epoch, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error retrieving validators of epoch %v from node: status %v", epoch, resp.StatusCode)
+	}
+
+	parsed := &validatorSnapshotNodeResponse{}
+	err = json.NewDecoder(resp.Body).Decode(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing validators of epoch %v: %w", epoch, err)
+	}
+
+	snapshot := &types.ApiValidatorSnapshot{
+		Epoch:      epoch,
+		Source:     "node",
+		Validators: make([]*types.ApiValidatorSnapshotEntry, 0, len(parsed.Data)),
+	}
+	for _, v := range parsed.Data {
+		snapshot.Validators = append(snapshot.Validators, &types.ApiValidatorSnapshotEntry{
+			ValidatorIndex:             v.Index,
+			Pubkey:                     v.Validator.Pubkey,
+			Status:                     v.Status,
+			Balance:                    v.Balance,
+			EffectiveBalance:           v.Validator.EffectiveBalance,
+			Slashed:                    v.Validator.Slashed,
+			ActivationEligibilityEpoch: validatorSnapshotEpoch(v.Validator.ActivationEligibilityEpoch),
+			ActivationEpoch:            validatorSnapshotEpoch(v.Validator.ActivationEpoch),
+			ExitEpoch:                  validatorSnapshotEpoch(v.Validator.ExitEpoch),
+			WithdrawableEpoch:          validatorSnapshotEpoch(v.Validator.WithdrawableEpoch),
+			WithdrawalCredentials:      v.Validator.WithdrawalCredentials,
+		})
+	}
+	sort.Slice(snapshot.Validators, func(i, j int) bool {
+		return snapshot.Validators[i].ValidatorIndex < snapshot.Validators[j].ValidatorIndex
+	})
+	return snapshot, nil
+}
+
+// validatorSnapshotEpoch maps the far future epoch of the node to the one of the db
+func validatorSnapshotEpoch(epoch uint64) uint64 {
+	if epoch == math.MaxUint64 {
+		return validatorSnapshotFarFutureEpoch
+	}
+	return epoch
+}
+
+// reconstructValidatorSnapshot derives the validator set of an epoch from the balances stored in bigtable and the current validator data.
+// Activation and exit epochs are only included once they have been assigned at the epoch. The withdrawal credentials are the ones before
+// the first recorded change after the epoch and exits without a voluntary exit or slashing (ejections) are assumed to be initiated as
+// early as possible.
+func reconstructValidatorSnapshot(epoch uint64) (*types.ApiValidatorSnapshot, error) {
+	balances, err := db.BigtableClient.GetValidatorBalanceHistory([]uint64{}, epoch, 1)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving validator balances of epoch %v: %w", epoch, err)
+	}
+	if len(balances) == 0 {
+		return nil, fmt.Errorf("no validator balances stored for epoch %v", epoch)
+	}
+
+	validators := []struct {
+		Index                      uint64 `db:"validatorindex"`
+		Pubkey                     []byte `db:"pubkey"`
+		WithdrawalCredentials      []byte `db:"withdrawalcredentials"`
+		Slashed                    bool   `db:"slashed"`
+		ActivationEligibilityEpoch uint64 `db:"activationeligibilityepoch"`
+		ActivationEpoch            uint64 `db:"activationepoch"`
+		ExitEpoch                  uint64 `db:"exitepoch"`
+		WithdrawableEpoch          uint64 `db:"withdrawableepoch"`
+	}{}
+	err = db.ReaderDb.Select(&validators, `
+		SELECT validatorindex, pubkey, withdrawalcredentials, slashed, activationeligibilityepoch, activationepoch, exitepoch, withdrawableepoch
+		FROM validators
+		ORDER BY validatorindex`)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving validators: %w", err)
+	}
+
+	// credentials changes are recorded with the first epoch the new credentials were seen, so the credentials of the epoch are the old ones
+	// of the first change after it
+	changes := []struct {
+		Index                 uint64 `db:"validatorindex"`
+		WithdrawalCredentials []byte `db:"old_withdrawalcredentials"`
+	}{}
+	err = db.ReaderDb.Select(&changes, `
+		SELECT DISTINCT ON (validatorindex) validatorindex, old_withdrawalcredentials
+		FROM validator_withdrawalcredentials_changes
+		WHERE epoch > $1
+		ORDER BY validatorindex, epoch`, epoch)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving withdrawal credentials changes: %w", err)
+	}
+	oldCredentials := make(map[uint64][]byte, len(changes))
+	for _, c := range changes {
+		oldCredentials[c.Index] = c.WithdrawalCredentials
+	}
+
+	// slots of the canonical blocks which included the first voluntary exit or slashing of a validator
+	exits := []struct {
+		Index     uint64  `db:"validatorindex"`
+		ExitSlot  *uint64 `db:"exit_slot"`
+		SlashSlot *uint64 `db:"slash_slot"`
+	}{}
+	err = db.ReaderDb.Select(&exits, `
+		SELECT validatorindex, MIN(exit_slot) AS exit_slot, MIN(slash_slot) AS slash_slot
+		FROM (
+			SELECT ve.validatorindex, ve.block_slot AS exit_slot, NULL::int AS slash_slot
+			FROM blocks_voluntaryexits ve
+			INNER JOIN blocks b ON b.slot = ve.block_slot AND b.blockroot = ve.block_root AND b.status = '1'
+			UNION ALL
+			SELECT ps.proposerindex, NULL, ps.block_slot
+			FROM blocks_proposerslashings ps
+			INNER JOIN blocks b ON b.slot = ps.block_slot AND b.blockroot = ps.block_root AND b.status = '1'
+			UNION ALL
+			SELECT s.validatorindex, NULL, s.block_slot
+			FROM (
+				SELECT a.block_slot, a.block_root, a.attestation2_indices, UNNEST(a.attestation1_indices) AS validatorindex
+				FROM blocks_attesterslashings a
+			) s
+			INNER JOIN blocks b ON b.slot = s.block_slot AND b.blockroot = s.block_root AND b.status = '1'
+			WHERE s.validatorindex = ANY(s.attestation2_indices)
+		) e
+		GROUP BY validatorindex`)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving voluntary exits and slashings: %w", err)
+	}
+	exitSlots := make(map[uint64]uint64, len(exits))
+	slashSlots := make(map[uint64]uint64, len(exits))
+	for _, e := range exits {
+		if e.SlashSlot != nil {
+			slashSlots[e.Index] = *e.SlashSlot
+		}
+		if e.ExitSlot != nil {
+			exitSlots[e.Index] = *e.ExitSlot
+		}
+	}
+
+	snapshot := &types.ApiValidatorSnapshot{
+		Epoch:      epoch,
+		Source:     "reconstructed",
+		Validators: make([]*types.ApiValidatorSnapshotEntry, 0, len(balances)),
+	}
+	for _, v := range validators {
+		history := balances[v.Index]
+		if len(history) == 0 || history[0].Epoch != epoch {
+			// the deposit of the validator has not been processed yet
+			continue
+		}
+		credentials := v.WithdrawalCredentials
+		if old, changed := oldCredentials[v.Index]; changed {
+			credentials = old
+		}
+		entry := &types.ApiValidatorSnapshotEntry{
+			ValidatorIndex:             v.Index,
+			Pubkey:                     fmt.Sprintf("%#x", v.Pubkey),
+			Balance:                    history[0].Balance,
+			EffectiveBalance:           history[0].EffectiveBalance,
+			ActivationEligibilityEpoch: v.ActivationEligibilityEpoch,
+			ActivationEpoch:            v.ActivationEpoch,
+			ExitEpoch:                  v.ExitEpoch,
+			WithdrawableEpoch:          v.WithdrawableEpoch,
+			WithdrawalCredentials:      fmt.Sprintf("%#x", credentials),
+		}
+		exitSlot, hasExit := exitSlots[v.Index]
+		slashSlot, hasSlash := slashSlots[v.Index]
+		reconstructValidatorSnapshotEntry(entry, epoch, v.Slashed, hasExit, exitSlot, hasSlash, slashSlot)
+		snapshot.Validators = append(snapshot.Validators, entry)
+	}
+	return snapshot, nil
+}
+
+// reconstructValidatorSnapshotEntry resets the epochs of entry which were not yet assigned at the epoch and sets the status of the validator
+func reconstructValidatorSnapshotEntry(entry *types.ApiValidatorSnapshotEntry, epoch uint64, slashed, hasExit bool, exitSlot uint64, hasSlash bool, slashSlot uint64) {
+	far := validatorSnapshotFarFutureEpoch
+	// epochs are assigned during the epoch processing at most MAX_SEED_LOOKAHEAD + 1 epochs ahead
+	lookahead := utils.Config.Chain.Config.MaxSeedLookahead
+	stateSlot := epoch * utils.Config.Chain.Config.SlotsPerEpoch
+
+	if entry.ActivationEligibilityEpoch > epoch {
+		entry.ActivationEligibilityEpoch = far
+	}
+	if entry.ActivationEpoch != far && entry.ActivationEpoch > epoch+lookahead {
+		entry.ActivationEpoch = far
+	}
+
+	// a slashing also initiates the exit of the validator
+	if hasSlash && (!hasExit || slashSlot < exitSlot) {
+		hasExit = true
+		exitSlot = slashSlot
+	}
+	exitInitiated := entry.ExitEpoch != far
+	if hasExit {
+		exitInitiated = exitInitiated && exitSlot <= stateSlot
+	} else {
+		exitInitiated = exitInitiated && entry.ExitEpoch <= epoch+lookahead
+	}
+	if !exitInitiated {
+		entry.ExitEpoch = far
+		entry.WithdrawableEpoch = far
+	}
+
+	if hasSlash {
+		entry.Slashed = slashSlot <= stateSlot
+	} else {
+		entry.Slashed = slashed && exitInitiated
+	}
+
+	switch {
+	case entry.ActivationEpoch > epoch:
+		if entry.ActivationEligibilityEpoch == far {
+			entry.Status = "pending_initialized"
+		} else {
+			entry.Status = "pending_queued"
+		}
+	case epoch < entry.ExitEpoch:
+		if entry.Slashed {
+			entry.Status = "active_slashed"
+		} else if entry.ExitEpoch != far {
+			entry.Status = "active_exiting"
+		} else {
+			entry.Status = "active_ongoing"
+		}
+	case epoch < entry.WithdrawableEpoch:
+		if entry.Slashed {
+			entry.Status = "exited_slashed"
+		} else {
+			entry.Status = "exited_unslashed"
+		}
+	default:
+		if entry.Balance == 0 {
+			entry.Status = "withdrawal_done"
+		} else {
+			entry.Status = "withdrawal_possible"
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReconstructValidatorSnapshotEntry(t *testing.T) {
+	chainConfig := utils.Config.Chain.Config
+	defer func() { utils.Config.Chain.Config = chainConfig }()
+	utils.Config.Chain.Config.SlotsPerEpoch = 32
+	utils.Config.Chain.Config.MaxSeedLookahead = 4
+
+	far := validatorSnapshotFarFutureEpoch
+	tests := []struct {
+		name                  string
+		entry                 types.ApiValidatorSnapshotEntry
+		epoch                 uint64
+		slashed, hasExit      bool
+		exitSlot              uint64
+		hasSlash              bool
+		slashSlot             uint64
+		status                string
+		activation, exit      uint64
+		wantSlashed           bool
+		withdrawable          uint64
+		activationEligibility uint64
+	}{
+		{
+			name:  "deposited",
+			entry: types.ApiValidatorSnapshotEntry{ActivationEligibilityEpoch: 101, ActivationEpoch: 120, ExitEpoch: far, WithdrawableEpoch: far, Balance: 32e9},
+			epoch: 100, status: "pending_initialized", activationEligibility: far, activation: far, exit: far, withdrawable: far,
+		},
+		{
+			name:  "queued",
+			entry: types.ApiValidatorSnapshotEntry{ActivationEligibilityEpoch: 90, ActivationEpoch: 120, ExitEpoch: far, WithdrawableEpoch: far, Balance: 32e9},
+			epoch: 100, status: "pending_queued", activationEligibility: 90, activation: far, exit: far, withdrawable: far,
+		},
+		{
+			name:  "activation assigned",
+			entry: types.ApiValidatorSnapshotEntry{ActivationEligibilityEpoch: 90, ActivationEpoch: 104, ExitEpoch: far, WithdrawableEpoch: far, Balance: 32e9},
+			epoch: 100, status: "pending_queued", activationEligibility: 90, activation: 104, exit: far, withdrawable: far,
+		},
+		{
+			name:  "exit not yet initiated",
+			entry: types.ApiValidatorSnapshotEntry{ActivationEpoch: 0, ExitEpoch: 200, WithdrawableEpoch: 456, Balance: 32e9},
+			epoch: 100, hasExit: true, exitSlot: 150 * 32, status: "active_ongoing", exit: far, withdrawable: far,
+		},
+		{
+			name:  "exiting",
+			entry: types.ApiValidatorSnapshotEntry{ActivationEpoch: 0, ExitEpoch: 200, WithdrawableEpoch: 456, Balance: 32e9},
+			epoch: 100, hasExit: true, exitSlot: 99 * 32, status: "active_exiting", exit: 200, withdrawable: 456,
+		},
+		{
+			name:  "slashed",
+			entry: types.ApiValidatorSnapshotEntry{ActivationEpoch: 0, ExitEpoch: 105, WithdrawableEpoch: 8292, Balance: 31e9},
+			epoch: 100, slashed: true, hasSlash: true, slashSlot: 99 * 32, status: "active_slashed", exit: 105, withdrawable: 8292, wantSlashed: true,
+		},
+		{
+			name:  "slashed later",
+			entry: types.ApiValidatorSnapshotEntry{ActivationEpoch: 0, ExitEpoch: 205, WithdrawableEpoch: 8292, Balance: 32e9},
+			epoch: 100, slashed: true, hasSlash: true, slashSlot: 200 * 32, status: "active_ongoing", exit: far, withdrawable: far,
+		},
+		{
+			name:  "ejected",
+			entry: types.ApiValidatorSnapshotEntry{ActivationEpoch: 0, ExitEpoch: 90, WithdrawableEpoch: 346, Balance: 16e9},
+			epoch: 100, status: "exited_unslashed", exit: 90, withdrawable: 346,
+		},
+		{
+			name:  "withdrawn",
+			entry: types.ApiValidatorSnapshotEntry{ActivationEpoch: 0, ExitEpoch: 10, WithdrawableEpoch: 50, Balance: 0},
+			epoch: 100, hasExit: true, exitSlot: 5 * 32, status: "withdrawal_done", exit: 10, withdrawable: 50,
+		},
+	}
+
+	for _, test := range tests {
+		entry := test.entry
+		reconstructValidatorSnapshotEntry(&entry, test.epoch, test.slashed, test.hasExit, test.exitSlot, test.hasSlash, test.slashSlot)
+		if entry.Status != test.status || entry.Slashed != test.wantSlashed || entry.ActivationEligibilityEpoch != test.activationEligibility ||
+			entry.ActivationEpoch != test.activation || entry.ExitEpoch != test.exit || entry.WithdrawableEpoch != test.withdrawable {
+			t.Errorf("%v: got %+v", test.name, entry)
+		}
+	}
+}
+
+func TestValidatorSnapshotPathBuildsInBackground(t *testing.T) {
+	snapshotConfig := utils.Config.Frontend.ValidatorSnapshot
+	defer func() { utils.Config.Frontend.ValidatorSnapshot = snapshotConfig }()
+	utils.Config.Frontend.ValidatorSnapshot.CacheDir = t.TempDir()
+	utils.Config.Frontend.ValidatorSnapshot.MaxCached = 2
+
+	release := make(chan bool)
+	defer func(build func(uint64) (*types.ApiValidatorSnapshot, error)) { buildValidatorSnapshot = build }(buildValidatorSnapshot)
+	buildValidatorSnapshot = func(epoch uint64) (*types.ApiValidatorSnapshot, error) {
+		<-release
+		return &types.ApiValidatorSnapshot{Epoch: epoch, Source: "test"}, nil
+	}
+
+	// requests do not wait for the snapshot, the number of snapshots waiting to be built is limited
+	for epoch := uint64(1); epoch <= validatorSnapshotMaxPending; epoch++ {
+		if _, err := ValidatorSnapshotPath(epoch); err != ErrValidatorSnapshotPending {
+			t.Fatalf("expected snapshot %v to be pending, got %v", epoch, err)
+		}
+	}
+	if _, err := ValidatorSnapshotPath(1); err != ErrValidatorSnapshotPending {
+		t.Errorf("expected a repeated request to report the pending snapshot, got %v", err)
+	}
+	if _, err := ValidatorSnapshotPath(validatorSnapshotMaxPending + 1); err != ErrValidatorSnapshotQueueFull {
+		t.Errorf("expected the queue to be full, got %v", err)
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		validatorSnapshotQueue.Lock()
+		pending := len(validatorSnapshotQueue.pending)
+		validatorSnapshotQueue.Unlock()
+		if pending == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("snapshots were not built")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	// only the most recently built snapshots are kept
+	entries, err := os.ReadDir(validatorSnapshotDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 cached snapshots, got %v", len(entries))
+	}
+	if _, err := ValidatorSnapshotPath(validatorSnapshotMaxPending); err != nil {
+		t.Errorf("expected the last snapshot to be cached, got %v", err)
+	}
+}
+
+func TestPruneValidatorSnapshotsKeepsRecentlyUsed(t *testing.T) {
+	snapshotConfig := utils.Config.Frontend.ValidatorSnapshot
+	defer func() { utils.Config.Frontend.ValidatorSnapshot = snapshotConfig }()
+	utils.Config.Frontend.ValidatorSnapshot.CacheDir = t.TempDir()
+	utils.Config.Frontend.ValidatorSnapshot.MaxCached = 2
+
+	if err := os.MkdirAll(validatorSnapshotDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for epoch := 1; epoch <= 3; epoch++ {
+		path := filepath.Join(validatorSnapshotDir(), fmt.Sprintf("%d.json.gz", epoch))
+		if err := os.WriteFile(path, []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now, now.Add(-time.Duration(epoch)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// serving the oldest snapshot marks it as recently used, the second oldest one is pruned instead
+	if _, err := ValidatorSnapshotPath(3); err != nil {
+		t.Fatal(err)
+	}
+	pruneValidatorSnapshots()
+
+	for epoch, kept := range map[uint64]bool{1: true, 2: false, 3: true} {
+		_, err := os.Stat(validatorSnapshotPath(epoch))
+		if kept != (err == nil) {
+			t.Errorf("expected snapshot %v to be kept: %v, stat returned %v", epoch, kept, err)
+		}
+	}
+}
+
+func TestValidatorSnapshotOldestEpoch(t *testing.T) {
+	snapshotConfig := utils.Config.Frontend.ValidatorSnapshot
+	defer func() { utils.Config.Frontend.ValidatorSnapshot = snapshotConfig }()
+	utils.Config.Frontend.ValidatorSnapshot.WindowDays = 1
+
+	// 225 epochs per day with 12 second slots and 32 slots per epoch
+	if oldest := ValidatorSnapshotOldestEpoch(1000); oldest != 775 {
+		t.Errorf("expected the window to start at epoch 775, got %v", oldest)
+	}
+	if oldest := ValidatorSnapshotOldestEpoch(100); oldest != 0 {
+		t.Errorf("expected the window to start at epoch 0, got %v", oldest)
+	}
+
+	utils.Config.Frontend.ValidatorSnapshot.ArchiveNodeEndpoint = "http://localhost:4000"
+	if oldest := ValidatorSnapshotOldestEpoch(1000); oldest != 0 {
+		t.Errorf("expected no window with an archive node, got %v", oldest)
+	}
+}
@@ -42,46 +42,15 @@ function updateBanner() {
 
       let userCurrency = getCookie("currency")
 
-      switch (userCurrency) {
-        case "AUD":
-          if (data.audRoundPrice && data.audTruncPrice) {
-            return (ethPriceHandle.innerHTML = "<span class='currency-symbol'>A$ </span>" + "<span class='k-formatted-price'>" + data.audTruncPrice + "</span>" + "<span class='price'>" + addCommas(data.audRoundPrice) + "</span>")
-          }
-          break
-        case "CAD":
-          if (data.cadRoundPrice && data.cadTruncPrice) {
-            return (ethPriceHandle.innerHTML = "<span class='currency-symbol'>C$ </span>" + "<span class='k-formatted-price'>" + data.cadTruncPrice + "</span>" + "<span class='price'>" + addCommas(data.cadRoundPrice) + "</span>")
-          }
-          break
-        case "CNY":
-          if (data.cnyRoundPrice && data.cnyTruncPrice) {
-            return (ethPriceHandle.innerHTML = "<span class='currency-symbol'>¥ </span>" + "<span class='k-formatted-price'>" + data.cnyTruncPrice + "</span>" + "<span class='price'>" + addCommas(data.cnyRoundPrice) + "</span>")
-          }
-          break
-        case "EUR":
-          if (data.eurRoundPrice && data.eurTruncPrice) {
-            return (ethPriceHandle.innerHTML = "<span class='currency-symbol'>€ </span>" + "<span class='k-formatted-price'>" + data.eurTruncPrice + "</span>" + "<span class='price'>" + addCommas(data.eurRoundPrice) + "</span>")
-          }
-          break
-        case "GBP":
-          if (data.gbpRoundPrice && data.gbpTruncPrice) {
-            return (ethPriceHandle.innerHTML = "<span class='currency-symbol'>£ </span>" + "<span class='k-formatted-price'>" + data.gbpTruncPrice + "</span>" + "<span class='price'>" + addCommas(data.gbpRoundPrice) + "</span>")
-          }
-          break
-        case "JPY":
-          if (data.jpyRoundPrice && data.jpyTruncPrice) {
-            return (ethPriceHandle.innerHTML = "<span class='currency-symbol'>¥ </span>" + "<span class='k-formatted-price'>" + data.jpyTruncPrice + "</span>" + "<span class='price'>" + addCommas(data.jpyRoundPrice) + "</span>")
-          }
-          break
-        case "RUB":
-          if (data.rubRoundPrice && data.rubTruncPrice) {
-            return (ethPriceHandle.innerHTML = "<span class='currency-symbol'>₽ </span>" + "<span class='k-formatted-price'>" + data.rubTruncPrice + "</span>" + "<span class='price'>" + addCommas(data.rubRoundPrice) + "</span>")
-          }
-          break
-        default:
-          if (data.usdRoundPrice && data.usdTruncPrice) {
-            return (ethPriceHandle.innerHTML = "<span class='currency-symbol'>$ </span>" + "<span class='k-formatted-price'>" + data.usdTruncPrice + "</span>" + "<span class='price'>" + addCommas(data.usdRoundPrice) + "</span>")
-          }
+      var priceStaleHandle = document.getElementById("banner-price-stale")
+      if (priceStaleHandle) {
+        if (data.priceStale) priceStaleHandle.classList.remove("d-none")
+        else priceStaleHandle.classList.add("d-none")
+      }
+
+      var userPrice = data.prices && (data.prices[userCurrency] || data.prices["USD"])
+      if (userPrice && userPrice.roundPrice && userPrice.truncPrice) {
+        return (ethPriceHandle.innerHTML = "<span class='currency-symbol'>" + userPrice.symbol + " </span>" + "<span class='k-formatted-price'>" + userPrice.truncPrice + "</span>" + "<span class='price'>" + addCommas(userPrice.roundPrice) + "</span>")
       }
 
       // always visible
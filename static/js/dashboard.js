@@ -210,9 +210,38 @@ function addValidatorUpdateUI() {
       setValidatorEffectiveness("validator-eff-total", sum)
     })
   })
+  fetch(`/dashboard/data/percentiles${getValidatorQueryString()}`, {
+    method: "GET",
+  }).then((res) => {
+    if (!res.ok) return
+    res.json().then((data) => {
+      setValidatorPercentiles("validator-percentile", data)
+    })
+  })
   showProposedHistoryTable()
 }
 
+function setValidatorPercentiles(id, percentiles) {
+  const labels = {
+    income_1d: "Income 1d",
+    income_7d: "Income 7d",
+    income_31d: "Income 31d",
+    income_365d: "Income 365d",
+    attestation_participation: "Attestation Participation",
+  }
+  let rank = "-"
+  let details = []
+  for (let p of percentiles || []) {
+    if (p.metric === "income_7d") rank = `P${p.percentile.toFixed(0)}`
+    let unit = p.metric === "attestation_participation" ? "%" : " ETH"
+    let digits = p.metric === "attestation_participation" ? 2 : 5
+    details.push(`${labels[p.metric]}: P${p.percentile.toFixed(0)} (median ${p.network_median.toFixed(digits)}${unit}, top 10% ${p.network_top_decile.toFixed(digits)}${unit})`)
+  }
+  $("#" + id)
+    .text(rank)
+    .attr("data-original-title", details.join("<br>"))
+}
+
 function showSelectedValidator() {
   setTimeout(function () {
     $("span[id^=dropdownMenuButton]").each(function (el, item) {
@@ -1,6 +1,6 @@
 var csrfToken = ""
 
-const VALIDATOR_EVENTS = ["validator_attestation_missed", "validator_proposal_missed", "validator_proposal_submitted", "validator_got_slashed", "validator_synccommittee_soon", "validator_is_offline"]
+const VALIDATOR_EVENTS = ["validator_attestation_missed", "validator_proposal_missed", "validator_proposal_submitted", "validator_got_slashed", "validator_synccommittee_soon", "validator_is_offline", "validator_exit_initiated", "validator_withdrawal_credentials_changed", "validator_deposit_credentials_mismatch"]
 
 // const MONITORING_EVENTS = ['monitoring_machine_offline', 'monitoring_hdd_almostfull', 'monitoring_cpu_load']
 
@@ -577,6 +577,11 @@ function loadValidatorsData(data) {
                   case "validator_is_offline":
                     badgeColor = "badge-light"
                     break
+                  case "validator_exit_initiated":
+                  case "validator_withdrawal_credentials_changed":
+                  case "validator_deposit_credentials_mismatch":
+                    badgeColor = "badge-warning"
+                    break
                 }
                 notifications += `<span style="font-size: 12px; font-weight: 500;" class="badge badge-pill ${badgeColor} ${textColor} badge-custom-size mr-1 my-1">${n.replace("validator", "").replaceAll("_", " ")}</span>`
               }
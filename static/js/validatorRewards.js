@@ -477,7 +477,13 @@ $(document).ready(function () {
       })
   })
 
+  if (!$("#timezone").val()) {
+    $("#timezone").val(Intl.DateTimeFormat().resolvedOptions().timeZone || "UTC")
+  }
+
   if (qry.length > 1) {
+    $("#download-lots-csv").attr("href", `/rewards/hist/download${qry}&format=csv`)
+    $("#download-lots-json").attr("href", `/rewards/hist/download${qry}&format=json`)
     fetch(`/rewards/hist${qry}`, {
       method: "GET",
     })
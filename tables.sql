@@ -36,14 +36,62 @@ create index idx_validators_balanceactivation on validators (balanceactivation);
 create index idx_validators_activationepoch on validators (activationepoch);
 CREATE INDEX validators_is_offline_vali_idx ON validators (validatorindex, lastattestationslot, pubkey);
 
+drop table if exists validator_withdrawalcredentials_changes;
+create table validator_withdrawalcredentials_changes
+(
+    validatorindex            int    not null,
+    epoch                     bigint not null,
+    old_withdrawalcredentials bytea  not null,
+    new_withdrawalcredentials bytea  not null,
+    primary key (validatorindex, epoch)
+);
+create index idx_validator_withdrawalcredentials_changes_epoch on validator_withdrawalcredentials_changes (epoch);
+
 drop table if exists validator_pool;
 create table validator_pool
 (
-    publickey bytea not null,
-    pool      varchar(40),
+    publickey  bytea not null,
+    pool       varchar(40),
+    confidence float, /* set if the pool was assigned by the attribution rules, null for authoritative sources */
     primary key (publickey)
 );
 
+drop table if exists pool_attribution_rules;
+create table pool_attribution_rules
+(
+    id         serial      not null,
+    pool       varchar(40) not null,
+    rule_type  varchar(30) not null, /* deposit_address, withdrawal_credentials, graffiti, fee_recipient or contract_factory */
+    pattern    text        not null,
+    confidence float       not null check (confidence > 0 and confidence <= 1),
+    created_ts timestamp without time zone not null default now(),
+    primary key (id),
+    unique (rule_type, pattern, pool)
+);
+
+drop table if exists validator_pool_attributions;
+create table validator_pool_attributions
+(
+    publickey  bytea       not null,
+    pool       varchar(40) not null,
+    rule_type  varchar(30) not null,
+    rule_id    int, /* null for rules derived from stake_pools_stats */
+    evidence   text        not null,
+    confidence float       not null,
+    updated_ts timestamp without time zone not null,
+    primary key (publickey, pool, rule_type)
+);
+
+/* contracts deployed by the factories of the contract_factory rules, the latest deployment of a factory is the cursor of its next lookup */
+drop table if exists pool_factory_deployments;
+create table pool_factory_deployments
+(
+    factory    bytea not null,
+    contract   bytea not null,
+    created_ts timestamp without time zone not null,
+    primary key (factory, contract)
+);
+
 drop table if exists validator_names;
 create table validator_names
 (
@@ -157,6 +205,28 @@ create table validator_stats_status
     primary key (day)
 );
 
+drop table if exists validator_apr;
+create table validator_apr
+(
+    validatorindex    int    not null,
+    day               int    not null,
+    effective_balance bigint not null, /* in gwei */
+    cl_income         bigint not null, /* in gwei */
+    el_income         numeric not null, /* in wei */
+    cl_apr            float  not null,
+    el_apr            float  not null,
+    primary key (validatorindex, day)
+);
+create index idx_validator_apr_day on validator_apr (day);
+
+drop table if exists validator_apr_status;
+create table validator_apr_status
+(
+    day       int     not null,
+    execution boolean not null, /* false if the execution layer income of the day was not yet exported to eth_store_stats */
+    primary key (day)
+);
+
 drop table if exists validator_attestation_streaks;
 create table validator_attestation_streaks
 (
@@ -200,6 +270,10 @@ create table validatorqueue_exit
 
 create table epochs_notified (epoch int not null primary key, sentOn timestamp not null);
 
+-- last block whose deposits have been checked for mismatched withdrawal credentials, written together with epochs_notified
+drop table if exists deposits_notified;
+create table deposits_notified (block_number int not null primary key, sentOn timestamp not null);
+
 drop table if exists epochs;
 create table epochs
 (
@@ -443,6 +517,7 @@ create table users
     register_ts             timestamp without time zone,
     api_key                 character varying(256) unique,
     stripe_customer_id      character varying(256) unique,
+    locale                  character varying(10)  not null default 'en-US',
     primary key (id, email)
 );
 
@@ -560,6 +635,19 @@ create table users_subscriptions
 );
 create index idx_users_subscriptions_unsubscribe_hash on users_subscriptions (unsubscribe_hash);
 
+-- a subscription with the event filter "tag:<tag>" is a rule that covers all validators of the user that carry the tag
+-- (users_validators_tags), the validators are resolved by the notification collector and their state is kept per validator
+drop table if exists users_subscription_rule_states;
+create table users_subscription_rule_states
+(
+    subscription_id int     not null,
+    event_filter    text    not null,
+    last_sent_ts    timestamp without time zone,
+    last_sent_epoch int,
+    internal_state  varchar,
+    primary key (subscription_id, event_filter)
+);
+
 CREATE TYPE notification_channels as ENUM ('webhook_discord', 'webhook', 'email', 'push');
 
 drop table if exists users_notification_channels;
@@ -578,7 +666,25 @@ create table notification_queue(
     sent                timestamp without time zone, -- record when the transaction was dispatched
     -- delivered           timestamp without time zone,  --record when the transaction arrived
     channel             notification_channels not null,
-    content             jsonb not null
+    content             jsonb not null,
+    locked_by           character varying(200), -- id of the sender instance currently holding the lease
+    locked_until        timestamp without time zone, -- lease expiry, afterwards the row can be claimed again
+    attempts            int not null default 0,
+    next_attempt        timestamp without time zone, -- earliest time of the next delivery attempt (exponential backoff)
+    last_error          text,
+    failed              timestamp without time zone, -- dead-letter: set once all delivery attempts are exhausted
+    primary key (id)
+);
+create index idx_notification_queue_pending on notification_queue (channel, created) where sent is null and failed is null;
+
+-- epochs whose notifications have been queued, written in the same transaction as the queue items so every epoch is queued once
+drop table if exists notification_queued_epochs;
+create table notification_queued_epochs(
+    network             character varying(20) not null,
+    epoch               int not null,
+    source              character varying(20) not null, -- network or user_db notifications
+    queued_ts           timestamp without time zone not null,
+    primary key (network, epoch, source)
 );
 
 -- deprecated
@@ -653,6 +759,119 @@ create table api_statistics
     primary key (ts, apikey, call)
 );
 
+-- bulk exports of the daily data of up to 100k validators, processed asynchronously by the frontend
+drop table if exists validator_export_jobs;
+create table validator_export_jobs
+(
+    id             serial                      not null,
+    user_id        int                         not null,
+    network        character varying(20)       not null,
+    validators     int[]                       not null,
+    start_day      int                         not null,
+    end_day        int                         not null,
+    format         character varying(10)       not null,
+    status         character varying(10)       not null default 'pending',
+    error          text,
+    file_key       text,
+    file_size      bigint,
+    download_token bytea                       not null,
+    created_ts     timestamp without time zone not null,
+    started_ts     timestamp without time zone,
+    finished_ts    timestamp without time zone,
+    primary key (id)
+);
+create index idx_validator_export_jobs_user_id on validator_export_jobs (user_id);
+create index idx_validator_export_jobs_status on validator_export_jobs (network, status);
+
+-- named dashboards of users, dashboards with a share token can be viewed read-only by anyone with the share link
+drop table if exists users_dashboards;
+create table users_dashboards
+(
+    id          serial                      not null,
+    user_id     int                         not null,
+    network     character varying(20)       not null,
+    name        character varying(100)      not null,
+    share_token bytea,
+    created_ts  timestamp without time zone not null,
+    updated_ts  timestamp without time zone not null,
+    primary key (id),
+    unique (user_id, network, name),
+    unique (share_token)
+);
+
+-- validator groups of the dashboards of users
+drop table if exists users_dashboard_groups;
+create table users_dashboard_groups
+(
+    id           serial                 not null,
+    dashboard_id int                    not null,
+    name         character varying(100) not null,
+    validators   int[]                  not null,
+    primary key (id)
+);
+create index idx_users_dashboard_groups_dashboard_id on users_dashboard_groups (dashboard_id);
+
+-- reports defined by users that are generated for every week, month or year, next_run_ts is the end of the next period to report
+drop table if exists users_scheduled_reports;
+create table users_scheduled_reports
+(
+    id           serial                      not null,
+    user_id      int                         not null,
+    network      character varying(20)       not null,
+    name         character varying(100)      not null,
+    validators   int[],
+    tag          character varying(100),
+    period       character varying(10)       not null,
+    currency     character varying(10)       not null,
+    sections     text[]                      not null,
+    format       character varying(10)       not null,
+    next_run_ts  timestamp without time zone not null,
+    last_run_ts  timestamp without time zone,
+    locked_until timestamp without time zone, -- lease of the worker generating the report
+    failed_runs  int                         not null default 0, -- failed attempts to generate the current period
+    created_ts   timestamp without time zone not null,
+    primary key (id),
+    unique (user_id, network, name)
+);
+create index idx_users_scheduled_reports_next_run on users_scheduled_reports (network, next_run_ts);
+
+-- archive of the generated scheduled reports
+drop table if exists users_scheduled_report_runs;
+create table users_scheduled_report_runs
+(
+    id             serial                      not null,
+    report_id      int                         not null,
+    user_id        int                         not null,
+    period_start   timestamp without time zone not null,
+    period_end     timestamp without time zone not null,
+    format         character varying(10)       not null,
+    status         character varying(10)       not null,
+    error          text,
+    file_key       text,
+    file_size      bigint,
+    download_token bytea                       not null,
+    created_ts     timestamp without time zone not null,
+    primary key (id)
+);
+create index idx_users_scheduled_report_runs_report_id on users_scheduled_report_runs (report_id);
+
+-- personal access tokens of users for the user api, only the sha256 hash of a token is stored
+drop table if exists personal_access_tokens;
+create table personal_access_tokens
+(
+    id           serial                      not null,
+    user_id      int                         not null,
+    name         character varying(100)      not null,
+    token_hash   bytea                       not null unique,
+    token_prefix character varying(20)       not null,
+    scopes       text[]                      not null,
+    created_ts   timestamp without time zone not null,
+    expires_ts   timestamp without time zone,
+    last_used_ts timestamp without time zone,
+    primary key (id)
+);
+create index idx_personal_access_tokens_user_id on personal_access_tokens (user_id);
+
 drop table if exists stake_pools_stats;
 create table stake_pools_stats
 (
@@ -668,14 +887,15 @@ drop table if exists price;
 create table price
 (
     ts     timestamp without time zone not null,
-    eur numeric(20,10)                not null,
-    usd numeric(20,10)                not null,
-    rub numeric(20,10)                not null,
-    cny numeric(20,10)                not null,
-    cad numeric(20,10)                not null,
-    jpy numeric(20,10)                not null,
-    gbp numeric(20,10)                not null,
-    aud numeric(20,10)                not null,
+    -- a currency is null if none of the price sources returned a price for it on the day
+    eur numeric(20,10),
+    usd numeric(20,10),
+    rub numeric(20,10),
+    cny numeric(20,10),
+    cad numeric(20,10),
+    jpy numeric(20,10),
+    gbp numeric(20,10),
+    aud numeric(20,10),
     primary key (ts)
 );
 
@@ -841,6 +1061,77 @@ create table rocketpool_reward_tree
     primary key(id)
 );
 
+-- projection of the rewards of the current interval, updated hourly by the rocketpool exporter
+drop table if exists rocketpool_reward_projections;
+create table rocketpool_reward_projections
+(
+    rocketpool_storage_address bytea not null,
+    node_address bytea not null,
+    interval_start timestamp without time zone not null,
+    interval_end timestamp without time zone not null,
+    rpl_collateral numeric not null, -- wei
+    smoothing_pool_eth numeric not null, -- wei
+    smoothing_pool_minipools int not null,
+    smoothing_pool_participation float not null,
+    updated_ts timestamp without time zone not null,
+
+    primary key(rocketpool_storage_address, node_address)
+);
+
+drop table if exists lido_node_operators;
+create table lido_node_operators
+(
+    registry_address bytea not null,
+
+    id int not null,
+    name text not null,
+    reward_address bytea not null,
+    active bool not null,
+    staking_limit bigint not null,
+    stopped_validators bigint not null,
+    total_signing_keys bigint not null,
+    used_signing_keys bigint not null,
+    primary key(registry_address, id)
+);
+
+drop table if exists lido_signing_keys;
+create table lido_signing_keys
+(
+    registry_address bytea not null,
+
+    pubkey bytea not null,
+    operator_id int not null,
+    added_block bigint not null,
+    removed_block bigint, -- only keys that were not deposited yet can be removed
+    primary key(registry_address, pubkey)
+);
+create index idx_lido_signing_keys_operator on lido_signing_keys (registry_address, operator_id);
+
+drop table if exists lido_oracle_reports;
+create table lido_oracle_reports
+(
+    oracle_address bytea not null,
+
+    epoch bigint not null,
+    block bigint not null,
+    tx_hash bytea not null,
+    beacon_balance numeric not null, -- wei
+    beacon_validators bigint not null,
+    pre_total_pooled_ether numeric not null, -- wei
+    post_total_pooled_ether numeric not null, -- wei, the rewards or penalties of the report are post - pre
+    time_elapsed bigint not null, -- seconds since the previous report
+    total_shares numeric not null,
+    primary key(oracle_address, epoch)
+);
+
+drop table if exists lido_exporter_status;
+create table lido_exporter_status
+(
+    registry_address bytea not null,
+    last_block bigint not null, -- the events of the registry and the oracle are exported up to and including this block
+    primary key(registry_address)
+);
+
 drop table if exists eth_store_stats;
 create table eth_store_stats
 (
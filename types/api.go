@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/pkg/errors"
 )
 
@@ -220,3 +221,293 @@ type ApiEth1AddressResponse struct {
 		Currency string  `json:"currency,omitempty"`
 	} `json:"tokens"`
 }
+
+// ExecutionPageApiResponse is a page of execution layer data, the next page is retrieved by passing the page token of the response
+type ExecutionPageApiResponse struct {
+	Data      interface{} `json:"data"`
+	PageToken string      `json:"pageToken,omitempty"`
+}
+
+type ExecutionTransactionApiResponse struct {
+	Hash               string   `json:"txHash"`
+	BlockNumber        uint64   `json:"blockNumber"`
+	Timestamp          uint64   `json:"timestamp"`
+	From               string   `json:"from"`
+	To                 string   `json:"to"`
+	Method             string   `json:"method"`
+	Value              *big.Int `json:"value"`
+	TxFee              *big.Int `json:"txFee"`
+	GasPrice           *big.Int `json:"gasPrice"`
+	IsContractCreation bool     `json:"isContractCreation"`
+	InvokesContract    bool     `json:"invokesContract"`
+	Status             uint64   `json:"status"`
+	ErrorMessage       string   `json:"errorMessage,omitempty"`
+}
+
+type ExecutionTransactionDetailApiResponse struct {
+	ExecutionTransactionApiResponse
+	Nonce           uint64   `json:"nonce"`
+	Type            uint8    `json:"type"`
+	Position        uint     `json:"position"`
+	GasLimit        uint64   `json:"gasLimit"`
+	GasUsed         uint64   `json:"gasUsed"`
+	BaseFee         *big.Int `json:"baseFee"`
+	MaxFee          *big.Int `json:"maxFee"`
+	MaxPriorityFee  *big.Int `json:"maxPriorityFee"`
+	ContractAddress string   `json:"contractAddress,omitempty"`
+	Finalized       bool     `json:"finalized"`
+}
+
+type ExecutionInternalTxApiResponse struct {
+	ParentHash  string   `json:"parentHash"`
+	BlockNumber uint64   `json:"blockNumber"`
+	Timestamp   uint64   `json:"timestamp"`
+	Type        string   `json:"type"`
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Value       *big.Int `json:"value"`
+}
+
+type ExecutionTokenTransferApiResponse struct {
+	ParentHash   string   `json:"parentHash"`
+	BlockNumber  uint64   `json:"blockNumber"`
+	Timestamp    uint64   `json:"timestamp"`
+	Standard     string   `json:"standard"`
+	TokenAddress string   `json:"tokenAddress"`
+	From         string   `json:"from"`
+	To           string   `json:"to"`
+	Value        *big.Int `json:"value,omitempty"`
+	TokenId      *big.Int `json:"tokenId,omitempty"`
+	Operator     string   `json:"operator,omitempty"`
+}
+
+type ExecutionUncleApiResponse struct {
+	BlockNumber uint64   `json:"blockNumber"`
+	UncleNumber uint64   `json:"uncleNumber"`
+	Timestamp   uint64   `json:"timestamp"`
+	GasLimit    uint64   `json:"gasLimit"`
+	GasUsed     uint64   `json:"gasUsed"`
+	BaseFee     *big.Int `json:"baseFee"`
+	Difficulty  *big.Int `json:"difficulty"`
+	Reward      *big.Int `json:"reward"`
+}
+
+// ApiV2Response is the envelope of every successful v2 response
+type ApiV2Response struct {
+	Data       interface{}      `json:"data"`
+	Pagination *ApiV2Pagination `json:"pagination,omitempty"`
+}
+
+// ApiV2Pagination is returned by list endpoints, the next page is requested by passing NextCursor as the cursor query parameter
+type ApiV2Pagination struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+const (
+	ApiV2ErrorCodeInvalidParameter = "invalid_parameter"
+	ApiV2ErrorCodeInvalidCursor    = "invalid_cursor"
+	ApiV2ErrorCodeNotFound         = "not_found"
+	ApiV2ErrorCodeUnauthorized     = "unauthorized"
+	ApiV2ErrorCodeRateLimited      = "rate_limited"
+	ApiV2ErrorCodeInternal         = "internal_error"
+)
+
+// ApiV2ErrorResponse is the body of every failed v2 response
+type ApiV2ErrorResponse struct {
+	Error ApiV2Error `json:"error"`
+}
+
+type ApiV2Error struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ApiV2Epoch struct {
+	Epoch                   uint64  `db:"epoch" json:"epoch"`
+	BlocksCount             uint64  `db:"blockscount" json:"blocks_count"`
+	ProposerSlashingsCount  uint64  `db:"proposerslashingscount" json:"proposer_slashings_count"`
+	AttesterSlashingsCount  uint64  `db:"attesterslashingscount" json:"attester_slashings_count"`
+	AttestationsCount       uint64  `db:"attestationscount" json:"attestations_count"`
+	DepositsCount           uint64  `db:"depositscount" json:"deposits_count"`
+	VoluntaryExitsCount     uint64  `db:"voluntaryexitscount" json:"voluntary_exits_count"`
+	ValidatorsCount         uint64  `db:"validatorscount" json:"validators_count"`
+	AverageValidatorBalance uint64  `db:"averagevalidatorbalance" json:"average_validator_balance"`
+	TotalValidatorBalance   uint64  `db:"totalvalidatorbalance" json:"total_validator_balance"`
+	Finalized               bool    `db:"finalized" json:"finalized"`
+	EligibleEther           uint64  `db:"eligibleether" json:"eligible_ether"`
+	GlobalParticipationRate float64 `db:"globalparticipationrate" json:"global_participation_rate"`
+	VotedEther              uint64  `db:"votedether" json:"voted_ether"`
+}
+
+type ApiV2Block struct {
+	Epoch                      uint64        `db:"epoch" json:"epoch"`
+	Slot                       uint64        `db:"slot" json:"slot"`
+	BlockRoot                  hexutil.Bytes `db:"blockroot" json:"block_root"`
+	ParentRoot                 hexutil.Bytes `db:"parentroot" json:"parent_root"`
+	StateRoot                  hexutil.Bytes `db:"stateroot" json:"state_root"`
+	Proposer                   uint64        `db:"proposer" json:"proposer"`
+	Status                     string        `db:"status" json:"status"`
+	Graffiti                   hexutil.Bytes `db:"graffiti" json:"graffiti"`
+	GraffitiText               string        `db:"graffiti_text" json:"graffiti_text"`
+	AttestationsCount          uint64        `db:"attestationscount" json:"attestations_count"`
+	DepositsCount              uint64        `db:"depositscount" json:"deposits_count"`
+	VoluntaryExitsCount        uint64        `db:"voluntaryexitscount" json:"voluntary_exits_count"`
+	ProposerSlashingsCount     uint64        `db:"proposerslashingscount" json:"proposer_slashings_count"`
+	AttesterSlashingsCount     uint64        `db:"attesterslashingscount" json:"attester_slashings_count"`
+	SyncAggregateParticipation float64       `db:"syncaggregate_participation" json:"sync_aggregate_participation"`
+	ExecBlockNumber            *uint64       `db:"exec_block_number" json:"exec_block_number"`
+	ExecBlockHash              hexutil.Bytes `db:"exec_block_hash" json:"exec_block_hash"`
+	ExecFeeRecipient           hexutil.Bytes `db:"exec_fee_recipient" json:"exec_fee_recipient"`
+	ExecTransactionsCount      uint64        `db:"exec_transactions_count" json:"exec_transactions_count"`
+}
+
+type ApiV2Validator struct {
+	ValidatorIndex             uint64        `db:"validatorindex" json:"validator_index"`
+	Pubkey                     hexutil.Bytes `db:"pubkey" json:"pubkey"`
+	WithdrawalCredentials      hexutil.Bytes `db:"withdrawalcredentials" json:"withdrawal_credentials"`
+	Balance                    uint64        `db:"balance" json:"balance"`
+	EffectiveBalance           uint64        `db:"effectivebalance" json:"effective_balance"`
+	Slashed                    bool          `db:"slashed" json:"slashed"`
+	ActivationEligibilityEpoch uint64        `db:"activationeligibilityepoch" json:"activation_eligibility_epoch"`
+	ActivationEpoch            uint64        `db:"activationepoch" json:"activation_epoch"`
+	ExitEpoch                  uint64        `db:"exitepoch" json:"exit_epoch"`
+	WithdrawableEpoch          uint64        `db:"withdrawableepoch" json:"withdrawable_epoch"`
+	LastAttestationSlot        *uint64       `db:"lastattestationslot" json:"last_attestation_slot"`
+	Status                     string        `db:"status" json:"status"`
+}
+
+type ApiV2GraffitiwallPixel struct {
+	X         uint64 `db:"x" json:"x"`
+	Y         uint64 `db:"y" json:"y"`
+	Color     string `db:"color" json:"color"`
+	Slot      uint64 `db:"slot" json:"slot"`
+	Validator uint64 `db:"validator" json:"validator"`
+}
+
+type ApiV2ValidatorBalance struct {
+	Epoch            uint64 `json:"epoch"`
+	Balance          uint64 `json:"balance"`
+	EffectiveBalance uint64 `json:"effective_balance"`
+}
+
+type ApiV2Attestation struct {
+	Epoch          uint64 `json:"epoch"`
+	AttesterSlot   uint64 `json:"attester_slot"`
+	CommitteeIndex uint64 `json:"committee_index"`
+	Status         uint64 `json:"status"`
+	InclusionSlot  uint64 `json:"inclusion_slot"`
+	Delay          int64  `json:"delay"`
+}
+
+type ApiV2Proposal struct {
+	Slot   uint64 `json:"slot"`
+	Status uint64 `json:"status"`
+}
+
+type ApiV2Deposit struct {
+	TxHash                hexutil.Bytes `db:"tx_hash" json:"tx_hash"`
+	BlockNumber           uint64        `db:"block_number" json:"block_number"`
+	BlockTs               int64         `db:"block_ts" json:"block_ts"`
+	FromAddress           hexutil.Bytes `db:"from_address" json:"from_address"`
+	Pubkey                hexutil.Bytes `db:"publickey" json:"pubkey"`
+	WithdrawalCredentials hexutil.Bytes `db:"withdrawal_credentials" json:"withdrawal_credentials"`
+	Amount                uint64        `db:"amount" json:"amount"`
+	ValidSignature        bool          `db:"valid_signature" json:"valid_signature"`
+}
+
+// ApiV2AddressValidator is a validator an address sent a deposit for, the index is null until the validator is part of the validator set
+type ApiV2AddressValidator struct {
+	Pubkey         hexutil.Bytes `db:"publickey" json:"pubkey"`
+	ValidatorIndex *uint64       `db:"validatorindex" json:"validator_index"`
+	ValidSignature bool          `db:"valid_signature" json:"valid_signature"`
+}
+
+type ApiV2ExecutionBlock struct {
+	Number           uint64        `json:"number"`
+	Hash             hexutil.Bytes `json:"hash"`
+	ParentHash       hexutil.Bytes `json:"parent_hash"`
+	FeeRecipient     hexutil.Bytes `json:"fee_recipient"`
+	GasLimit         uint64        `json:"gas_limit"`
+	GasUsed          uint64        `json:"gas_used"`
+	Timestamp        int64         `json:"timestamp"`
+	BaseFee          string        `json:"base_fee"`
+	TransactionCount uint64        `json:"transaction_count"`
+}
+
+type ApiV2Address struct {
+	Address    hexutil.Bytes `json:"address"`
+	Name       string        `json:"name"`
+	EthBalance string        `json:"eth_balance"`
+}
+
+// ApiValidatorSnapshot is the validator set in the state of the first slot of an epoch
+type ApiValidatorSnapshot struct {
+	Epoch uint64 `json:"epoch"`
+	// Source is "node" if the snapshot was retrieved from an archive node or "reconstructed" if it was derived from the stored data
+	Source     string                       `json:"source"`
+	Validators []*ApiValidatorSnapshotEntry `json:"validators"`
+}
+
+type ApiValidatorSnapshotEntry struct {
+	ValidatorIndex             uint64 `json:"validatorindex"`
+	Pubkey                     string `json:"pubkey"`
+	Status                     string `json:"status"`
+	Balance                    uint64 `json:"balance"`
+	EffectiveBalance           uint64 `json:"effectivebalance"`
+	Slashed                    bool   `json:"slashed"`
+	ActivationEligibilityEpoch uint64 `json:"activationeligibilityepoch"`
+	ActivationEpoch            uint64 `json:"activationepoch"`
+	ExitEpoch                  uint64 `json:"exitepoch"`
+	WithdrawableEpoch          uint64 `json:"withdrawableepoch"`
+	WithdrawalCredentials      string `json:"withdrawalcredentials"`
+}
+
+type ApiEpochCommittee struct {
+	Slot       uint64   `json:"slot"`
+	Index      uint64   `json:"index"`
+	Validators []uint64 `json:"validators"`
+}
+
+// ApiEpochProposer is the proposer duty of a slot, the status is the block status (0 = scheduled, 1 = proposed, 2 = missed, 3 = orphaned)
+type ApiEpochProposer struct {
+	Slot     uint64 `db:"slot" json:"slot"`
+	Proposer uint64 `db:"proposer" json:"proposer"`
+	Status   string `db:"status" json:"status"`
+}
+
+type ApiValidatorDuties struct {
+	ValidatorIndex uint64                         `json:"validatorindex"`
+	Attestations   []*ApiValidatorAttestationDuty `json:"attestations"`
+	Proposals      []*ApiEpochProposer            `json:"proposals"`
+	SyncCommittee  []*ApiValidatorSyncDuty        `json:"synccommittee"`
+}
+
+// ApiValidatorAttestationDuty is an attestation duty of a validator, the status is 1 if the attestation was included and 0 otherwise.
+// The committee is only known for scheduled duties.
+type ApiValidatorAttestationDuty struct {
+	Epoch          uint64  `json:"epoch"`
+	AttesterSlot   uint64  `json:"attesterslot"`
+	CommitteeIndex *uint64 `json:"committeeindex,omitempty"`
+	Status         uint64  `json:"status"`
+	InclusionSlot  uint64  `json:"inclusionslot"`
+	Scheduled      bool    `json:"scheduled"`
+}
+
+// ApiValidatorSyncDuty is a sync committee duty of a validator, the status is 1 if the validator participated and 0 otherwise
+type ApiValidatorSyncDuty struct {
+	Slot      uint64 `json:"slot"`
+	Status    uint64 `json:"status"`
+	Scheduled bool   `json:"scheduled"`
+}
+
+// ApiValidatorPoolResponse is the pool a validator is attributed to with the matched rules.
+// Confidence is null if the pool is known from an authoritative source like the Rocket Pool contracts.
+type ApiValidatorPoolResponse struct {
+	ValidatorIndex uint64             `db:"validatorindex" json:"validatorindex"`
+	Pubkey         hexutil.Bytes      `db:"pubkey" json:"pubkey"`
+	Pool           *string            `db:"pool" json:"pool"`
+	Confidence     *float64           `db:"confidence" json:"confidence"`
+	Attributions   []*PoolAttribution `db:"-" json:"attributions"`
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"html/template"
+	"time"
 )
 
 // Config is a struct to hold the configuration data
@@ -67,13 +68,37 @@ type Config struct {
 		Debug                          bool   `yaml:"debug" envconfig:"FRONTEND_DEBUG"`
 		BeaconchainETHPoolBridgeSecret string `yaml:"beaconchainETHPoolBridgeSecret" envconfig:"FRONTEND_BEACONCHAIN_ETHPOOL_BRIDGE_SECRET"`
 		Kong                           string `yaml:"kong" envconfig:"FRONTEND_KONG"`
-		OnlyAPI                        bool   `yaml:"onlyAPI" envconfig:"FRONTEND_ONLY_API"`
-		CsrfAuthKey                    string `yaml:"csrfAuthKey" envconfig:"FRONTEND_CSRF_AUTHKEY"`
-		CsrfInsecure                   bool   `yaml:"csrfInsecure" envconfig:"FRONTEND_CSRF_INSECURE"`
-		DisableCharts                  bool   `yaml:"disableCharts" envconfig:"disableCharts"`
-		RecaptchaSiteKey               string `yaml:"recaptchaSiteKey" envconfig:"FRONTEND_RECAPTCHA_SITEKEY"`
-		RecaptchaSecretKey             string `yaml:"recaptchaSecretKey" envconfig:"FRONTEND_RECAPTCHA_SECRETKEY"`
-		Enabled                        bool   `yaml:"enabled" envconfig:"FRONTEND_ENABLED"`
+		ApiRateLimit                   struct {
+			Enabled bool `yaml:"enabled" envconfig:"FRONTEND_API_RATE_LIMIT_ENABLED"`
+			// addresses or cidr ranges of the proxies in front of the explorer, their X-Forwarded-For header is used to identify clients without an api key
+			TrustedProxies []string `yaml:"trustedProxies" envconfig:"FRONTEND_API_RATE_LIMIT_TRUSTED_PROXIES"`
+		} `yaml:"apiRateLimit"`
+		ValidatorExport struct {
+			Enabled bool `yaml:"enabled" envconfig:"FRONTEND_VALIDATOR_EXPORT_ENABLED"`
+			// storage of the export files, either "local" or "gcs"
+			Storage   string `yaml:"storage" envconfig:"FRONTEND_VALIDATOR_EXPORT_STORAGE"`
+			LocalDir  string `yaml:"localDir" envconfig:"FRONTEND_VALIDATOR_EXPORT_LOCAL_DIR"`
+			GcsBucket string `yaml:"gcsBucket" envconfig:"FRONTEND_VALIDATOR_EXPORT_GCS_BUCKET"`
+		} `yaml:"validatorExport"`
+		ScheduledReports struct {
+			// the reports are archived in the storage of the validator export
+			Enabled bool `yaml:"enabled" envconfig:"FRONTEND_SCHEDULED_REPORTS_ENABLED"`
+		} `yaml:"scheduledReports"`
+		ValidatorSnapshot struct {
+			// beacon node with the historic states, if it is not set snapshots are reconstructed from the stored data
+			ArchiveNodeEndpoint string `yaml:"archiveNodeEndpoint" envconfig:"FRONTEND_VALIDATOR_SNAPSHOT_ARCHIVE_NODE_ENDPOINT"`
+			CacheDir            string `yaml:"cacheDir" envconfig:"FRONTEND_VALIDATOR_SNAPSHOT_CACHE_DIR"`
+			// without an archive node snapshots are reconstructed and can be requested for the finalized epochs of the last WindowDays days (default 30), at most MaxCached (default 50) are kept on disk
+			WindowDays uint64 `yaml:"windowDays" envconfig:"FRONTEND_VALIDATOR_SNAPSHOT_WINDOW_DAYS"`
+			MaxCached  int    `yaml:"maxCached" envconfig:"FRONTEND_VALIDATOR_SNAPSHOT_MAX_CACHED"`
+		} `yaml:"validatorSnapshot"`
+		OnlyAPI            bool   `yaml:"onlyAPI" envconfig:"FRONTEND_ONLY_API"`
+		CsrfAuthKey        string `yaml:"csrfAuthKey" envconfig:"FRONTEND_CSRF_AUTHKEY"`
+		CsrfInsecure       bool   `yaml:"csrfInsecure" envconfig:"FRONTEND_CSRF_INSECURE"`
+		DisableCharts      bool   `yaml:"disableCharts" envconfig:"disableCharts"`
+		RecaptchaSiteKey   string `yaml:"recaptchaSiteKey" envconfig:"FRONTEND_RECAPTCHA_SITEKEY"`
+		RecaptchaSecretKey string `yaml:"recaptchaSecretKey" envconfig:"FRONTEND_RECAPTCHA_SECRETKEY"`
+		Enabled            bool   `yaml:"enabled" envconfig:"FRONTEND_ENABLED"`
 		// Imprint is deprdecated place imprint file into the legal directory
 		Imprint      string `yaml:"imprint" envconfig:"FRONTEND_IMPRINT"`
 		LegalDir     string `yaml:"legalDir" envconfig:"FRONTEND_LEGAL"`
@@ -168,15 +193,30 @@ type Config struct {
 		StorageContractAddress    string `yaml:"storageContractAddress" envconfig:"ROCKETPOOL_EXPORTER_STORAGE_CONTRACT_ADDRESS"`
 		StorageContractFirstBlock uint64 `yaml:"storageContractFirstBlock" envconfig:"ROCKETPOOL_EXPORTER_STORAGE_CONTRACT_FIRST_BLOCK"`
 	} `yaml:"rocketpoolExporter"`
+	LidoExporter struct {
+		Enabled                         bool   `yaml:"enabled" envconfig:"LIDO_EXPORTER_ENABLED"`
+		NodeOperatorsRegistryAddress    string `yaml:"nodeOperatorsRegistryAddress" envconfig:"LIDO_EXPORTER_NODE_OPERATORS_REGISTRY_ADDRESS"`
+		NodeOperatorsRegistryFirstBlock uint64 `yaml:"nodeOperatorsRegistryFirstBlock" envconfig:"LIDO_EXPORTER_NODE_OPERATORS_REGISTRY_FIRST_BLOCK"`
+		OracleAddress                   string `yaml:"oracleAddress" envconfig:"LIDO_EXPORTER_ORACLE_ADDRESS"`
+	} `yaml:"lidoExporter"`
 	MevBoostRelayExporter struct {
 		Enabled bool `yaml:"enabled" envconfig:"MEVBOOSTRELAY_EXPORTER_ENABLED"`
 	} `yaml:"mevBoostRelayExporter"`
+	Price PriceConfig `yaml:"price"`
 	Pprof struct {
 		Enabled bool   `yaml:"enabled" envconfig:"PPROF_ENABLED"`
 		Port    string `yaml:"port" envconfig:"PPROF_PORT"`
 	} `yaml:"pprof"`
 }
 
+// PriceConfig configures where the ETH price is retrieved from and in which currencies it is offered
+type PriceConfig struct {
+	Sources    []string      `yaml:"sources" envconfig:"PRICE_SOURCES"`       // coingecko, kraken, coinbase or static
+	Currencies []string      `yaml:"currencies" envconfig:"PRICE_CURRENCIES"` // ISO 4217 codes, defaults to the currencies of the frontend
+	StaticFile string        `yaml:"staticFile" envconfig:"PRICE_STATIC_FILE"`
+	StaleAfter time.Duration `yaml:"staleAfter" envconfig:"PRICE_STALE_AFTER"`
+}
+
 type DatabaseConfig struct {
 	Username string
 	Password string
@@ -0,0 +1,91 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Topics of the event stream, validator events are published to EventTopicValidatorPrefix followed by the validator index
+const (
+	EventTopicSlots           = "slots"
+	EventTopicFinalizedEpochs = "finalized_epochs"
+	EventTopicGas             = "gas"
+	EventTopicRelayBlocks     = "relay_blocks"
+	EventTopicValidatorPrefix = "validator:"
+)
+
+const (
+	EventTypeSlot              = "slot"
+	EventTypeFinalizedEpoch    = "finalized_epoch"
+	EventTypeGas               = "gas"
+	EventTypeRelayBlock        = "relay_block"
+	EventTypeProposalScheduled = "proposal_scheduled"
+	EventTypeProposal          = "proposal"
+	EventTypeAttestation       = "attestation"
+	EventTypeStatus            = "status"
+)
+
+// Event is a message delivered to the subscribers of a topic
+type Event struct {
+	Topic string          `json:"topic"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+}
+
+type EventSlot struct {
+	Epoch           uint64        `db:"epoch" json:"epoch"`
+	Slot            uint64        `db:"slot" json:"slot"`
+	Proposer        uint64        `db:"proposer" json:"proposer"`
+	Status          string        `db:"status" json:"status"`
+	BlockRoot       hexutil.Bytes `db:"blockroot" json:"block_root"`
+	ExecBlockNumber *uint64       `db:"exec_block_number" json:"exec_block_number"`
+}
+
+type EventFinalizedEpoch struct {
+	Epoch                   uint64  `db:"epoch" json:"epoch"`
+	ValidatorsCount         uint64  `db:"validatorscount" json:"validators_count"`
+	EligibleEther           uint64  `db:"eligibleether" json:"eligible_ether"`
+	VotedEther              uint64  `db:"votedether" json:"voted_ether"`
+	GlobalParticipationRate float64 `db:"globalparticipationrate" json:"global_participation_rate"`
+}
+
+type EventGas struct {
+	Rapid     *big.Int `json:"rapid"`
+	Fast      *big.Int `json:"fast"`
+	Standard  *big.Int `json:"standard"`
+	Slow      *big.Int `json:"slow"`
+	Timestamp int64    `json:"timestamp"`
+}
+
+type EventRelayBlock struct {
+	Slot                 uint64        `json:"slot"`
+	Proposer             uint64        `json:"proposer"`
+	Value                WeiString     `json:"value"`
+	Builder              hexutil.Bytes `json:"builder"`
+	ProposerFeeRecipient hexutil.Bytes `json:"proposer_fee_recipient"`
+	Relays               []string      `json:"relays"`
+}
+
+type EventValidatorProposal struct {
+	Validator uint64 `json:"validator"`
+	Slot      uint64 `json:"slot"`
+	Status    string `json:"status"`
+}
+
+type EventValidatorAttestation struct {
+	Validator     uint64 `json:"validator"`
+	Epoch         uint64 `json:"epoch"`
+	AttesterSlot  uint64 `json:"attester_slot"`
+	Status        uint64 `json:"status"`
+	InclusionSlot uint64 `json:"inclusion_slot"`
+	Delay         int64  `json:"delay"`
+}
+
+// EventValidatorStatus is published when a validator becomes eligible for activation, is activated, exits or is slashed
+type EventValidatorStatus struct {
+	Validator uint64 `json:"validator"`
+	Epoch     uint64 `json:"epoch"`
+	Status    string `json:"status"`
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/jackc/pgtype"
 	"github.com/pkg/errors"
@@ -282,6 +283,38 @@ type Eth1Deposit struct {
 	ValidSignature        bool   `db:"valid_signature"`
 }
 
+// ValidatorExitInitiated is a struct to hold a voluntary exit that has been included in a canonical block
+type ValidatorExitInitiated struct {
+	Slot           uint64 `db:"slot"`
+	Epoch          uint64 `db:"epoch"`
+	ValidatorIndex uint64 `db:"validatorindex"`
+	Pubkey         []byte `db:"pubkey"`
+}
+
+// ValidatorWithdrawalCredentialsChange is a struct to hold a change of the withdrawal credentials of a validator
+type ValidatorWithdrawalCredentialsChange struct {
+	Epoch                    uint64 `db:"epoch"`
+	ValidatorIndex           uint64 `db:"validatorindex"`
+	Pubkey                   []byte `db:"pubkey"`
+	OldWithdrawalCredentials []byte `db:"old_withdrawalcredentials"`
+	NewWithdrawalCredentials []byte `db:"new_withdrawalcredentials"`
+}
+
+// DepositCredentials is a struct to hold a deposit together with the withdrawal credentials of the first valid deposit of its pubkey
+// and of its validator, the first deposit and the validator are empty if they do not exist
+type DepositCredentials struct {
+	TxHash                         []byte        `db:"tx_hash"`
+	BlockTs                        time.Time     `db:"block_ts"`
+	FromAddress                    []byte        `db:"from_address"`
+	Pubkey                         []byte        `db:"publickey"`
+	ValidatorIndex                 sql.NullInt64 `db:"validatorindex"`
+	Amount                         uint64        `db:"amount"`
+	WithdrawalCredentials          []byte        `db:"withdrawal_credentials"`
+	FirstTxHash                    []byte        `db:"first_tx_hash"`
+	FirstWithdrawalCredentials     []byte        `db:"first_withdrawal_credentials"`
+	ValidatorWithdrawalCredentials []byte        `db:"validator_withdrawalcredentials"`
+}
+
 // Eth2Deposit is a struct to hold eth2-deposit data
 type Eth2Deposit struct {
 	BlockSlot             uint64 `db:"block_slot"`
@@ -309,172 +342,6 @@ type PerformanceDay struct {
 	APR                    decimal.Decimal `db:"apr"`
 }
 
-type HistoricEthPrice struct {
-	MarketData struct {
-		CurrentPrice struct {
-			Aed float64 `json:"aed"`
-			Ars float64 `json:"ars"`
-			Aud float64 `json:"aud"`
-			Bdt float64 `json:"bdt"`
-			Bhd float64 `json:"bhd"`
-			Bmd float64 `json:"bmd"`
-			Brl float64 `json:"brl"`
-			Btc float64 `json:"btc"`
-			Cad float64 `json:"cad"`
-			Chf float64 `json:"chf"`
-			Clp float64 `json:"clp"`
-			Cny float64 `json:"cny"`
-			Czk float64 `json:"czk"`
-			Dkk float64 `json:"dkk"`
-			Eth float64 `json:"eth"`
-			Eur float64 `json:"eur"`
-			Gbp float64 `json:"gbp"`
-			Hkd float64 `json:"hkd"`
-			Huf float64 `json:"huf"`
-			Idr float64 `json:"idr"`
-			Ils float64 `json:"ils"`
-			Inr float64 `json:"inr"`
-			Jpy float64 `json:"jpy"`
-			Krw float64 `json:"krw"`
-			Kwd float64 `json:"kwd"`
-			Lkr float64 `json:"lkr"`
-			Ltc float64 `json:"ltc"`
-			Mmk float64 `json:"mmk"`
-			Mxn float64 `json:"mxn"`
-			Myr float64 `json:"myr"`
-			Ngn float64 `json:"ngn"`
-			Nok float64 `json:"nok"`
-			Nzd float64 `json:"nzd"`
-			Php float64 `json:"php"`
-			Pkr float64 `json:"pkr"`
-			Pln float64 `json:"pln"`
-			Rub float64 `json:"rub"`
-			Sar float64 `json:"sar"`
-			Sek float64 `json:"sek"`
-			Sgd float64 `json:"sgd"`
-			Thb float64 `json:"thb"`
-			Try float64 `json:"try"`
-			Twd float64 `json:"twd"`
-			Uah float64 `json:"uah"`
-			Usd float64 `json:"usd"`
-			Vef float64 `json:"vef"`
-			Vnd float64 `json:"vnd"`
-			Xag float64 `json:"xag"`
-			Xau float64 `json:"xau"`
-			Xdr float64 `json:"xdr"`
-			Zar float64 `json:"zar"`
-		} `json:"current_price"`
-		MarketCap struct {
-			Aed float64 `json:"aed"`
-			Ars float64 `json:"ars"`
-			Aud float64 `json:"aud"`
-			Bdt float64 `json:"bdt"`
-			Bhd float64 `json:"bhd"`
-			Bmd float64 `json:"bmd"`
-			Brl float64 `json:"brl"`
-			Btc float64 `json:"btc"`
-			Cad float64 `json:"cad"`
-			Chf float64 `json:"chf"`
-			Clp float64 `json:"clp"`
-			Cny float64 `json:"cny"`
-			Czk float64 `json:"czk"`
-			Dkk float64 `json:"dkk"`
-			Eth float64 `json:"eth"`
-			Eur float64 `json:"eur"`
-			Gbp float64 `json:"gbp"`
-			Hkd float64 `json:"hkd"`
-			Huf float64 `json:"huf"`
-			Idr float64 `json:"idr"`
-			Ils float64 `json:"ils"`
-			Inr float64 `json:"inr"`
-			Jpy float64 `json:"jpy"`
-			Krw float64 `json:"krw"`
-			Kwd float64 `json:"kwd"`
-			Lkr float64 `json:"lkr"`
-			Ltc float64 `json:"ltc"`
-			Mmk float64 `json:"mmk"`
-			Mxn float64 `json:"mxn"`
-			Myr float64 `json:"myr"`
-			Ngn float64 `json:"ngn"`
-			Nok float64 `json:"nok"`
-			Nzd float64 `json:"nzd"`
-			Php float64 `json:"php"`
-			Pkr float64 `json:"pkr"`
-			Pln float64 `json:"pln"`
-			Rub float64 `json:"rub"`
-			Sar float64 `json:"sar"`
-			Sek float64 `json:"sek"`
-			Sgd float64 `json:"sgd"`
-			Thb float64 `json:"thb"`
-			Try float64 `json:"try"`
-			Twd float64 `json:"twd"`
-			Uah float64 `json:"uah"`
-			Usd float64 `json:"usd"`
-			Vef float64 `json:"vef"`
-			Vnd float64 `json:"vnd"`
-			Xag float64 `json:"xag"`
-			Xau float64 `json:"xau"`
-			Xdr float64 `json:"xdr"`
-			Zar float64 `json:"zar"`
-		} `json:"market_cap"`
-		TotalVolume struct {
-			Aed float64 `json:"aed"`
-			Ars float64 `json:"ars"`
-			Aud float64 `json:"aud"`
-			Bdt float64 `json:"bdt"`
-			Bhd float64 `json:"bhd"`
-			Bmd float64 `json:"bmd"`
-			Brl float64 `json:"brl"`
-			Btc float64 `json:"btc"`
-			Cad float64 `json:"cad"`
-			Chf float64 `json:"chf"`
-			Clp float64 `json:"clp"`
-			Cny float64 `json:"cny"`
-			Czk float64 `json:"czk"`
-			Dkk float64 `json:"dkk"`
-			Eth float64 `json:"eth"`
-			Eur float64 `json:"eur"`
-			Gbp float64 `json:"gbp"`
-			Hkd float64 `json:"hkd"`
-			Huf float64 `json:"huf"`
-			Idr float64 `json:"idr"`
-			Ils float64 `json:"ils"`
-			Inr float64 `json:"inr"`
-			Jpy float64 `json:"jpy"`
-			Krw float64 `json:"krw"`
-			Kwd float64 `json:"kwd"`
-			Lkr float64 `json:"lkr"`
-			Ltc float64 `json:"ltc"`
-			Mmk float64 `json:"mmk"`
-			Mxn float64 `json:"mxn"`
-			Myr float64 `json:"myr"`
-			Ngn float64 `json:"ngn"`
-			Nok float64 `json:"nok"`
-			Nzd float64 `json:"nzd"`
-			Php float64 `json:"php"`
-			Pkr float64 `json:"pkr"`
-			Pln float64 `json:"pln"`
-			Rub float64 `json:"rub"`
-			Sar float64 `json:"sar"`
-			Sek float64 `json:"sek"`
-			Sgd float64 `json:"sgd"`
-			Thb float64 `json:"thb"`
-			Try float64 `json:"try"`
-			Twd float64 `json:"twd"`
-			Uah float64 `json:"uah"`
-			Usd float64 `json:"usd"`
-			Vef float64 `json:"vef"`
-			Vnd float64 `json:"vnd"`
-			Xag float64 `json:"xag"`
-			Xau float64 `json:"xau"`
-			Xdr float64 `json:"xdr"`
-			Zar float64 `json:"zar"`
-		} `json:"total_volume"`
-	} `json:"market_data"`
-	Name   string `json:"name"`
-	Symbol string `json:"symbol"`
-}
-
 type Relay struct {
 	ID          string         `db:"tag_id"`
 	Endpoint    string         `db:"endpoint"`
@@ -589,3 +456,40 @@ func (b *WeiString) BigInt() *big.Int {
 	num.Mul(num, mul)
 	return num
 }
+
+// Rule types of the pool attribution
+const (
+	PoolAttributionDepositAddress        = "deposit_address"
+	PoolAttributionWithdrawalCredentials = "withdrawal_credentials"
+	PoolAttributionGraffiti              = "graffiti"
+	PoolAttributionFeeRecipient          = "fee_recipient"
+	PoolAttributionContractFactory       = "contract_factory"
+)
+
+// PoolAttributionRule attributes validators matching the pattern to the pool.
+// Depending on the type the pattern is a hex encoded address or a case insensitive regular expression for graffitis.
+type PoolAttributionRule struct {
+	ID         sql.NullInt64 `db:"id"`
+	Pool       string        `db:"pool"`
+	Type       string        `db:"rule_type"`
+	Pattern    string        `db:"pattern"`
+	Confidence float64       `db:"confidence"`
+}
+
+// PoolAttribution is the match of a rule for a validator, the matches are kept as audit trail of the pool a validator is attributed to
+type PoolAttribution struct {
+	Publickey   []byte        `db:"publickey" json:"-"`
+	Pool        string        `db:"pool" json:"pool"`
+	RuleID      sql.NullInt64 `db:"rule_id" json:"-"`
+	RuleType    string        `db:"rule_type" json:"rule_type"`
+	Evidence    string        `db:"evidence" json:"evidence"`
+	Confidence  float64       `db:"confidence" json:"confidence"`
+	UpdatedTime time.Time     `db:"updated_ts" json:"updated_ts"`
+}
+
+// PoolFactoryDeployment is a contract deployed by the factory of a contract_factory rule
+type PoolFactoryDeployment struct {
+	Factory     []byte    `db:"factory"`
+	Contract    []byte    `db:"contract"`
+	CreatedTime time.Time `db:"created_ts"`
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/lib/pq"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 )
 
 type EventName string
@@ -46,6 +47,10 @@ const (
 	RocketpoolColleteralMinReached                   EventName = "rocketpool_colleteral_min"
 	RocketpoolColleteralMaxReached                   EventName = "rocketpool_colleteral_max"
 	SyncCommitteeSoon                                EventName = "validator_synccommittee_soon"
+	ValidatorExitInitiatedEventName                  EventName = "validator_exit_initiated"
+	ValidatorWithdrawalCredentialsChangedEventName   EventName = "validator_withdrawal_credentials_changed"
+	ValidatorDepositCredentialsMismatchEventName     EventName = "validator_deposit_credentials_mismatch"
+	ScheduledReportEventName                         EventName = "user_scheduled_report"
 )
 
 var UserIndexEvents = []EventName{
@@ -88,6 +93,10 @@ var EventLabel map[EventName]string = map[EventName]string{
 	RocketpoolColleteralMinReached:                   "You reached the rocketpool min collateral",
 	RocketpoolColleteralMaxReached:                   "You reached the rocketpool max collateral",
 	SyncCommitteeSoon:                                "Your validator(s) will soon be part of the sync committee",
+	ValidatorExitInitiatedEventName:                  "Your validator(s) initiated a voluntary exit",
+	ValidatorWithdrawalCredentialsChangedEventName:   "Your validator(s) withdrawal credentials changed",
+	ValidatorDepositCredentialsMismatchEventName:     "Your validator(s) received a deposit with mismatching withdrawal credentials",
+	ScheduledReportEventName:                         "Your scheduled report is available",
 }
 
 func IsUserIndexed(event EventName) bool {
@@ -127,6 +136,10 @@ var EventNames = []EventName{
 	RocketpoolColleteralMinReached,
 	RocketpoolColleteralMaxReached,
 	SyncCommitteeSoon,
+	ValidatorExitInitiatedEventName,
+	ValidatorWithdrawalCredentialsChangedEventName,
+	ValidatorDepositCredentialsMismatchEventName,
+	ScheduledReportEventName,
 }
 
 type EventNameDesc struct {
@@ -169,6 +182,18 @@ var AddWatchlistEvents = []EventNameDesc{
 		Desc:  "Attestations missed",
 		Event: ValidatorMissedAttestationEventName,
 	},
+	{
+		Desc:  "Voluntary exit initiated",
+		Event: ValidatorExitInitiatedEventName,
+	},
+	{
+		Desc:  "Withdrawal credentials changed",
+		Event: ValidatorWithdrawalCredentialsChangedEventName,
+	},
+	{
+		Desc:  "Deposit with mismatching withdrawal credentials",
+		Event: ValidatorDepositCredentialsMismatchEventName,
+	},
 }
 
 // this is the source of truth for the network events that are supported by the user/notification page
@@ -202,17 +227,20 @@ const (
 	ValidatorTagsWatchlist Tag = "watchlist"
 )
 
+// SubscriptionRuleFilterPrefix marks an event filter that targets all validators with a tag instead of a single pubkey (e.g. "tag:teku-eu")
+const SubscriptionRuleFilterPrefix = "tag:"
+
 type Notification interface {
 	GetLatestState() string
 	GetSubscriptionID() uint64
 	GetEventName() EventName
 	GetEpoch() uint64
-	GetInfo(includeUrl bool) string
-	GetTitle() string
+	GetInfo(lang string, includeUrl bool) string
+	GetTitle(lang string) string
 	GetEventFilter() string
 	GetEmailAttachment() *EmailAttachment
 	GetUnsubscribeHash() string
-	GetInfoMarkdown() string
+	GetInfoMarkdown(lang string) string
 }
 
 // func UnMarschal
@@ -232,6 +260,18 @@ type Subscription struct {
 	State           sql.NullString `db:"internal_state" swaggertype:"string"`
 }
 
+// SubscriptionRule subscribes a user to an event for all of their validators that carry the given tag
+type SubscriptionRule struct {
+	ID             uint64    `db:"id" json:"id"`
+	UserID         uint64    `db:"user_id" json:"-"`
+	EventName      string    `db:"event_name" json:"event_name"`
+	Tag            string    `db:"tag" json:"tag"`
+	EventThreshold float64   `db:"event_threshold" json:"event_threshold"`
+	MaxValidators  uint64    `db:"max_validators" json:"max_validators"`
+	CreatedTime    time.Time `db:"created_ts" json:"created_ts"`
+	Validators     uint64    `db:"validators" json:"validators"`
+}
+
 type TaggedValidators struct {
 	UserID             uint64 `db:"user_id"`
 	Tag                string `db:"tag"`
@@ -408,6 +448,21 @@ func (a TransitPushContent) Value() (driver.Value, error) {
 	return json.Marshal(a)
 }
 
+// NotificationPreview holds the content a notification produces on every channel of a user
+type NotificationPreview struct {
+	SubscriptionID uint64                  `json:"subscription_id"`
+	EventName      EventName               `json:"event_name"`
+	EventFilter    string                  `json:"event_filter"`
+	Title          string                  `json:"title"`
+	Description    string                  `json:"description"`
+	Email          TransitEmailContent     `json:"email"`
+	EmailHTML      string                  `json:"email_html"`
+	Push           []*messaging.Message    `json:"push"`
+	Webhooks       []TransitWebhookContent `json:"webhooks"`
+	Discord        []DiscordReq            `json:"discord"`
+	Delivered      bool                    `json:"delivered"`
+}
+
 type EmailAttachment struct {
 	Attachment []byte `json:"attachment"`
 	Name       string `json:"name"`
@@ -418,6 +473,7 @@ type Email struct {
 	Body                  template.HTML `json:"body"`
 	SubscriptionManageURL template.HTML `json:"subscriptionManageUrl"`
 	UnSubURL              template.HTML `json:"unSubURL"`
+	Lang                  string        `json:"lang,omitempty"`
 }
 
 type UserWebhook struct {
@@ -523,3 +579,211 @@ type RawMempoolTransaction struct {
 	GasPrice  *hexutil.Big    `json:"gasPrice"`
 	Nonce     *hexutil.Big    `json:"nonce"`
 }
+
+// ValidatorExportJob is an asynchronous export of the daily data of a set of validators, it is processed by services.InitValidatorExport
+type ValidatorExportJob struct {
+	ID              uint64        `db:"id" json:"id"`
+	UserID          uint64        `db:"user_id" json:"-"`
+	Network         string        `db:"network" json:"-"`
+	Validators      pq.Int64Array `db:"validators" json:"-"`
+	StartDay        uint64        `db:"start_day" json:"start_day"`
+	EndDay          uint64        `db:"end_day" json:"end_day"`
+	Format          string        `db:"format" json:"format"`
+	Status          string        `db:"status" json:"status"`
+	Error           *string       `db:"error" json:"error,omitempty"`
+	FileKey         *string       `db:"file_key" json:"-"`
+	FileSize        *int64        `db:"file_size" json:"file_size,omitempty"`
+	DownloadToken   []byte        `db:"download_token" json:"-"`
+	CreatedTime     time.Time     `db:"created_ts" json:"created_ts"`
+	StartedTime     *time.Time    `db:"started_ts" json:"started_ts,omitempty"`
+	FinishedTime    *time.Time    `db:"finished_ts" json:"finished_ts,omitempty"`
+	DownloadURL     string        `db:"-" json:"download_url,omitempty"`
+	ValidatorsCount int           `db:"validators_count" json:"validators_count"`
+}
+
+const (
+	ValidatorExportStatusPending = "pending"
+	ValidatorExportStatusRunning = "running"
+	ValidatorExportStatusDone    = "done"
+	ValidatorExportStatusFailed  = "failed"
+
+	ValidatorExportFormatCSV     = "csv"
+	ValidatorExportFormatParquet = "parquet"
+)
+
+// ValidatorExportRow is a row of a validator export, one per validator and day. Balances and amounts are in Gwei.
+type ValidatorExportRow struct {
+	ValidatorIndex     int64  `parquet:"name=validatorindex, type=INT64" csv:"validatorindex" db:"validatorindex"`
+	Day                int64  `parquet:"name=day, type=INT64" csv:"day" db:"day"`
+	DayStart           string `parquet:"name=day_start, type=BYTE_ARRAY, convertedtype=UTF8" csv:"day_start" db:"day_start"`
+	Status             string `parquet:"name=status, type=BYTE_ARRAY, convertedtype=UTF8" csv:"status" db:"status"`
+	Balance            int64  `parquet:"name=balance, type=INT64" csv:"balance" db:"balance"`
+	EffectiveBalance   int64  `parquet:"name=effective_balance, type=INT64" csv:"effective_balance" db:"effective_balance"`
+	StartBalance       int64  `parquet:"name=start_balance, type=INT64" csv:"start_balance" db:"start_balance"`
+	EndBalance         int64  `parquet:"name=end_balance, type=INT64" csv:"end_balance" db:"end_balance"`
+	DepositsAmount     int64  `parquet:"name=deposits_amount, type=INT64" csv:"deposits_amount" db:"deposits_amount"`
+	Income             int64  `parquet:"name=income, type=INT64" csv:"income" db:"income"`
+	MissedAttestations int64  `parquet:"name=missed_attestations, type=INT64" csv:"missed_attestations" db:"missed_attestations"`
+	ProposedBlocks     int64  `parquet:"name=proposed_blocks, type=INT64" csv:"proposed_blocks" db:"proposed_blocks"`
+	MissedBlocks       int64  `parquet:"name=missed_blocks, type=INT64" csv:"missed_blocks" db:"missed_blocks"`
+	OrphanedBlocks     int64  `parquet:"name=orphaned_blocks, type=INT64" csv:"orphaned_blocks" db:"orphaned_blocks"`
+}
+
+// RewardLotsReport lists the income of a set of validators as individual lots, each valued at the fiat price at the time it was received
+type RewardLotsReport struct {
+	Validators       []uint64        `json:"validators"`
+	Currency         string          `json:"currency"`
+	Timezone         string          `json:"timezone"`
+	PriceGranularity string          `json:"price_granularity"`
+	Start            time.Time       `json:"start"`
+	End              time.Time       `json:"end"`
+	TotalEth         decimal.Decimal `json:"total_eth"`
+	TotalValue       decimal.Decimal `json:"total_value"`
+	Lots             []*RewardLot    `json:"lots"`
+}
+
+// RewardLot is a single income event of a validator. Consensus rewards are aggregated per validator and day,
+// execution rewards are reported per proposed block and go to the fee recipient of the block or of the mev relay.
+type RewardLot struct {
+	Timestamp      time.Time       `json:"timestamp"`
+	Type           string          `json:"type"`
+	ValidatorIndex uint64          `json:"validator_index"`
+	Day            *uint64         `json:"day,omitempty"`
+	Slot           *uint64         `json:"slot,omitempty"`
+	BlockNumber    *uint64         `json:"block_number,omitempty"`
+	FeeRecipient   string          `json:"fee_recipient,omitempty"`
+	MevRelay       string          `json:"mev_relay,omitempty"`
+	AmountEth      decimal.Decimal `json:"amount_eth"`
+	Price          decimal.Decimal `json:"price"`
+	Value          decimal.Decimal `json:"value"`
+}
+
+const (
+	RewardLotTypeConsensus = "consensus_reward"
+	RewardLotTypeExecution = "execution_reward"
+
+	RewardLotPriceGranularityDay   = "day"
+	RewardLotPriceGranularityMonth = "month"
+)
+
+// ScheduledReport is a report defined by a user that is generated for every period (week, month or year) by services.InitScheduledReports.
+// The report covers either a fixed set of validators or all validators of the user that carry a tag.
+type ScheduledReport struct {
+	ID          uint64         `db:"id" json:"id"`
+	UserID      uint64         `db:"user_id" json:"-"`
+	Network     string         `db:"network" json:"-"`
+	Name        string         `db:"name" json:"name"`
+	Validators  pq.Int64Array  `db:"validators" json:"validators,omitempty"`
+	Tag         *string        `db:"tag" json:"tag,omitempty"`
+	Period      string         `db:"period" json:"period"`
+	Currency    string         `db:"currency" json:"currency"`
+	Sections    pq.StringArray `db:"sections" json:"sections"`
+	Format      string         `db:"format" json:"format"`
+	NextRunTime time.Time      `db:"next_run_ts" json:"next_run_ts"`
+	LastRunTime *time.Time     `db:"last_run_ts" json:"last_run_ts,omitempty"`
+	FailedRuns  int            `db:"failed_runs" json:"-"`
+	CreatedTime time.Time      `db:"created_ts" json:"created_ts"`
+}
+
+// ScheduledReportRun is a generated report of a period, the file is archived so it can be downloaded again
+type ScheduledReportRun struct {
+	ID            uint64    `db:"id" json:"id"`
+	ReportID      uint64    `db:"report_id" json:"report_id"`
+	UserID        uint64    `db:"user_id" json:"-"`
+	PeriodStart   time.Time `db:"period_start" json:"period_start"`
+	PeriodEnd     time.Time `db:"period_end" json:"period_end"`
+	Format        string    `db:"format" json:"format"`
+	Status        string    `db:"status" json:"status"`
+	Error         *string   `db:"error" json:"error,omitempty"`
+	FileKey       *string   `db:"file_key" json:"-"`
+	FileSize      *int64    `db:"file_size" json:"file_size,omitempty"`
+	DownloadToken []byte    `db:"download_token" json:"-"`
+	CreatedTime   time.Time `db:"created_ts" json:"created_ts"`
+	DownloadURL   string    `db:"-" json:"download_url,omitempty"`
+}
+
+const (
+	ScheduledReportPeriodWeekly  = "weekly"
+	ScheduledReportPeriodMonthly = "monthly"
+	ScheduledReportPeriodYearly  = "yearly"
+
+	ScheduledReportSectionIncome        = "income"
+	ScheduledReportSectionEffectiveness = "effectiveness"
+	ScheduledReportSectionMissedDuties  = "missed_duties"
+	ScheduledReportSectionProposals     = "proposals"
+
+	ScheduledReportFormatPDF  = "pdf"
+	ScheduledReportFormatCSV  = "csv"
+	ScheduledReportFormatHTML = "html"
+
+	ScheduledReportRunStatusDone   = "done"
+	ScheduledReportRunStatusFailed = "failed"
+)
+
+var ScheduledReportSections = []string{ScheduledReportSectionIncome, ScheduledReportSectionEffectiveness, ScheduledReportSectionMissedDuties, ScheduledReportSectionProposals}
+
+// Dashboard is a named dashboard of a user holding groups of validators.
+// If the share token is set the dashboard can be viewed read-only by anyone with the share link.
+type Dashboard struct {
+	ID          uint64            `db:"id" json:"id"`
+	UserID      uint64            `db:"user_id" json:"-"`
+	Network     string            `db:"network" json:"-"`
+	Name        string            `db:"name" json:"name"`
+	ShareToken  []byte            `db:"share_token" json:"-"`
+	CreatedTime time.Time         `db:"created_ts" json:"created_ts"`
+	UpdatedTime time.Time         `db:"updated_ts" json:"updated_ts"`
+	Groups      []*DashboardGroup `db:"-" json:"groups"`
+	ShareURL    string            `db:"-" json:"share_url,omitempty"`
+}
+
+// DashboardGroup is a named set of validators of a dashboard
+type DashboardGroup struct {
+	ID          uint64        `db:"id" json:"id"`
+	DashboardID uint64        `db:"dashboard_id" json:"-"`
+	Name        string        `db:"name" json:"name"`
+	Validators  pq.Int64Array `db:"validators" json:"validators"`
+}
+
+// PersonalAccessToken is a token created by a user to access the user api with a limited set of scopes.
+// Only the sha256 hash of the token is stored, the token itself is shown once after it has been created.
+type PersonalAccessToken struct {
+	ID           uint64         `db:"id" json:"id"`
+	UserID       uint64         `db:"user_id" json:"-"`
+	Name         string         `db:"name" json:"name"`
+	TokenHash    []byte         `db:"token_hash" json:"-"`
+	TokenPrefix  string         `db:"token_prefix" json:"token_prefix"`
+	Scopes       pq.StringArray `db:"scopes" json:"scopes"`
+	CreatedTime  time.Time      `db:"created_ts" json:"created_ts"`
+	ExpiresTime  *time.Time     `db:"expires_ts" json:"expires_ts,omitempty"`
+	LastUsedTime *time.Time     `db:"last_used_ts" json:"last_used_ts,omitempty"`
+	Token        string         `db:"-" json:"token,omitempty"`
+}
+
+// HasScope returns true if the token has been granted the scope
+func (t *PersonalAccessToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	PersonalAccessTokenScopeWatchlistRead      = "watchlist:read"
+	PersonalAccessTokenScopeWatchlistWrite     = "watchlist:write"
+	PersonalAccessTokenScopeNotificationsRead  = "notifications:read"
+	PersonalAccessTokenScopeNotificationsWrite = "notifications:write"
+	PersonalAccessTokenScopeStatsRead          = "stats:read"
+	PersonalAccessTokenScopeStatsWrite         = "stats:write"
+)
+
+// PersonalAccessTokenScopes are all scopes that can be granted to a personal access token
+var PersonalAccessTokenScopes = []string{
+	PersonalAccessTokenScopeWatchlistRead,
+	PersonalAccessTokenScopeWatchlistWrite,
+	PersonalAccessTokenScopeNotificationsRead,
+	PersonalAccessTokenScopeNotificationsWrite,
+	PersonalAccessTokenScopeStatsRead,
+	PersonalAccessTokenScopeStatsWrite,
+}
@@ -71,8 +71,12 @@ type PageRates struct {
 	JpyTruncPrice         template.HTML
 	Currency              string
 	CurrentPriceFormatted template.HTML
+	CurrentRoundPrice     uint64
+	CurrentTruncPrice     template.HTML
 	CurrentSymbol         string
 	ExchangeRate          float64
+	Currencies            []PageCurrency
+	PriceStale            bool
 }
 
 // Meta is a struct to hold metadata about the page
@@ -89,33 +93,48 @@ type Meta struct {
 }
 
 // LatestState is a struct to hold data for the banner
+// PageCurrency is a currency the ETH price can be displayed in
+type PageCurrency struct {
+	Code string
+	Name string
+}
+
 type LatestState struct {
-	LastProposedSlot      uint64        `json:"lastProposedSlot"`
-	CurrentSlot           uint64        `json:"currentSlot"`
-	CurrentEpoch          uint64        `json:"currentEpoch"`
-	CurrentFinalizedEpoch uint64        `json:"currentFinalizedEpoch"`
-	FinalityDelay         uint64        `json:"finalityDelay"`
-	IsSyncing             bool          `json:"syncing"`
-	EthPrice              float64       `json:"ethPrice"`
-	EthRoundPrice         uint64        `json:"ethRoundPrice"`
-	EthTruncPrice         template.HTML `json:"ethTruncPrice"`
-	UsdRoundPrice         uint64        `json:"usdRoundPrice"`
-	UsdTruncPrice         template.HTML `json:"usdTruncPrice"`
-	EurRoundPrice         uint64        `json:"eurRoundPrice"`
-	EurTruncPrice         template.HTML `json:"eurTruncPrice"`
-	GbpRoundPrice         uint64        `json:"gbpRoundPrice"`
-	GbpTruncPrice         template.HTML `json:"gbpTruncPrice"`
-	CnyRoundPrice         uint64        `json:"cnyRoundPrice"`
-	CnyTruncPrice         template.HTML `json:"cnyTruncPrice"`
-	RubRoundPrice         uint64        `json:"rubRoundPrice"`
-	RubTruncPrice         template.HTML `json:"rubTruncPrice"`
-	CadRoundPrice         uint64        `json:"cadRoundPrice"`
-	CadTruncPrice         template.HTML `json:"cadTruncPrice"`
-	AudRoundPrice         uint64        `json:"audRoundPrice"`
-	AudTruncPrice         template.HTML `json:"audTruncPrice"`
-	JpyRoundPrice         uint64        `json:"jpyRoundPrice"`
-	JpyTruncPrice         template.HTML `json:"jpyTruncPrice"`
-	Currency              string        `json:"currency"`
+	LastProposedSlot      uint64                      `json:"lastProposedSlot"`
+	CurrentSlot           uint64                      `json:"currentSlot"`
+	CurrentEpoch          uint64                      `json:"currentEpoch"`
+	CurrentFinalizedEpoch uint64                      `json:"currentFinalizedEpoch"`
+	FinalityDelay         uint64                      `json:"finalityDelay"`
+	IsSyncing             bool                        `json:"syncing"`
+	EthPrice              float64                     `json:"ethPrice"`
+	EthRoundPrice         uint64                      `json:"ethRoundPrice"`
+	EthTruncPrice         template.HTML               `json:"ethTruncPrice"`
+	UsdRoundPrice         uint64                      `json:"usdRoundPrice"`
+	UsdTruncPrice         template.HTML               `json:"usdTruncPrice"`
+	EurRoundPrice         uint64                      `json:"eurRoundPrice"`
+	EurTruncPrice         template.HTML               `json:"eurTruncPrice"`
+	GbpRoundPrice         uint64                      `json:"gbpRoundPrice"`
+	GbpTruncPrice         template.HTML               `json:"gbpTruncPrice"`
+	CnyRoundPrice         uint64                      `json:"cnyRoundPrice"`
+	CnyTruncPrice         template.HTML               `json:"cnyTruncPrice"`
+	RubRoundPrice         uint64                      `json:"rubRoundPrice"`
+	RubTruncPrice         template.HTML               `json:"rubTruncPrice"`
+	CadRoundPrice         uint64                      `json:"cadRoundPrice"`
+	CadTruncPrice         template.HTML               `json:"cadTruncPrice"`
+	AudRoundPrice         uint64                      `json:"audRoundPrice"`
+	AudTruncPrice         template.HTML               `json:"audTruncPrice"`
+	JpyRoundPrice         uint64                      `json:"jpyRoundPrice"`
+	JpyTruncPrice         template.HTML               `json:"jpyTruncPrice"`
+	Currency              string                      `json:"currency"`
+	Prices                map[string]LatestStatePrice `json:"prices"`
+	PriceStale            bool                        `json:"priceStale"`
+}
+
+// LatestStatePrice is the current ETH price in one of the configured currencies
+type LatestStatePrice struct {
+	Symbol     string        `json:"symbol"`
+	RoundPrice uint64        `json:"roundPrice"`
+	TruncPrice template.HTML `json:"truncPrice"`
 }
 
 type Stats struct {
@@ -386,6 +405,10 @@ type RocketpoolValidatorPageData struct {
 	SmoothingPoolOptIn   bool       `db:"smoothing_pool_opted_in"`
 	PenaltyCount         *uint64    `db:"penalty_count"`
 	RocketscanUrl        string     `db:"-"`
+	// projection of the rewards of the node in the current interval
+	ProjectedRPL              *string    `db:"projected_rpl"`
+	ProjectedSmoothingPoolETH *string    `db:"projected_smoothing_pool_eth"`
+	ProjectionIntervalEnd     *time.Time `db:"projection_interval_end"`
 }
 
 type ValidatorStatsTablePageData struct {
@@ -458,6 +481,20 @@ type ValidatorIncomeHistory struct {
 	DepositAmount sql.NullInt64 `db:"deposits_amount"`
 }
 
+// ValidatorAPRHistory is the annualized income of a set of validators on a day, relative to their effective balance.
+// Incomes are in gwei, APRs are fractions.
+type ValidatorAPRHistory struct {
+	Day              int64    `db:"day" json:"day"`
+	Validators       int64    `db:"validators" json:"validators"`
+	EffectiveBalance int64    `db:"effective_balance" json:"effective_balance"`
+	CLIncome         int64    `db:"cl_income" json:"cl_income"`
+	ELIncome         int64    `db:"el_income" json:"el_income"`
+	CLAPR            float64  `db:"cl_apr" json:"cl_apr"`
+	ELAPR            float64  `db:"el_apr" json:"el_apr"`
+	APR              float64  `db:"apr" json:"apr"`
+	EthStoreAPR      *float64 `db:"eth_store_apr" json:"eth_store_apr"`
+}
+
 type ValidatorBalanceHistoryChartData struct {
 	Epoch   uint64
 	Balance uint64
@@ -895,19 +932,107 @@ type ChartsPageDataChart struct {
 }
 
 type HeatmapData struct {
-	// BalanceHistory DashboardValidatorBalanceHistory `json:"balance_history"`
-	// Earnings       ValidatorEarnings                `json:"earnings"`
-	// Validators     [][]interface{}                  `json:"validators"`
-	Csrf           string `json:"csrf"`
-	ValidatorLimit int    `json:"valLimit"`
-	Epochs         []uint64
-	Validators     []uint64
-	IncomeData     [][3]int64
-	MinIncome      int64
-	MaxIncome      int64
+	ValidatorLimit int `json:"valLimit"`
+	// SetID references the validators of the heatmap in the tile urls
+	SetID      string
+	Validators []uint64
+	StartEpoch uint64
+	EndEpoch   uint64
+	TileSize   int
+	Zoom       int
+	MaxZoom    int
 }
 
 // DashboardData is a struct to hold data for the dashboard-page
+// DashboardGroupSummary holds the aggregates of a validator group of a saved dashboard, balances and incomes are in Gwei
+type DashboardGroupSummary struct {
+	GroupID          uint64  `json:"group_id"`
+	Name             string  `json:"name"`
+	Validators       int     `json:"validators"`
+	ActiveValidators int     `json:"active_validators"`
+	Balance          uint64  `json:"balance"`
+	IncomeLastDay    int64   `json:"income_last_day"`
+	IncomeLastWeek   int64   `json:"income_last_week"`
+	IncomeLastMonth  int64   `json:"income_last_month"`
+	IncomeTotal      int64   `json:"income_total"`
+	Effectiveness    float64 `json:"effectiveness"`
+	ProposedBlocks   uint64  `json:"proposed_blocks"`
+	MissedBlocks     uint64  `json:"missed_blocks"`
+	OrphanedBlocks   uint64  `json:"orphaned_blocks"`
+	Epoch            uint64  `json:"epoch"`
+	// Percentiles ranks the average validator of the group within the network
+	Percentiles []*PerformancePercentile `json:"percentiles"`
+	// APRHistory is the daily apr of the group over the last month
+	APRHistory []*ValidatorAPRHistory `json:"apr_history"`
+	// DashboardURL links to the live dashboard of the group if it is small enough to be passed in the query string
+	DashboardURL string `json:"-"`
+}
+
+// Performance metrics that are compared against the network
+const (
+	PerformanceMetricIncome1d                 = "income_1d"
+	PerformanceMetricIncome7d                 = "income_7d"
+	PerformanceMetricIncome31d                = "income_31d"
+	PerformanceMetricIncome365d               = "income_365d"
+	PerformanceMetricAttestationParticipation = "attestation_participation"
+)
+
+// NetworkPerformanceQuantiles holds the quantiles in 1% steps (101 values) of the per validator performance metrics of the network
+type NetworkPerformanceQuantiles struct {
+	Day       uint64               `json:"day"`
+	Quantiles map[string][]float64 `json:"quantiles"`
+}
+
+// PerformancePercentile compares the average validator of a group with the network.
+// Income values are in ETH, the attestation participation is the share of attestations that were not missed in percent.
+type PerformancePercentile struct {
+	Metric           string  `json:"metric"`
+	Value            float64 `json:"value"`
+	Percentile       float64 `json:"percentile"`
+	NetworkMedian    float64 `json:"network_median"`
+	NetworkTopDecile float64 `json:"network_top_decile"`
+}
+
+// MetricLabel returns the human readable name of the metric
+func (p *PerformancePercentile) MetricLabel() string {
+	switch p.Metric {
+	case PerformanceMetricIncome1d:
+		return "Income 1d"
+	case PerformanceMetricIncome7d:
+		return "Income 7d"
+	case PerformanceMetricIncome31d:
+		return "Income 31d"
+	case PerformanceMetricIncome365d:
+		return "Income 365d"
+	case PerformanceMetricAttestationParticipation:
+		return "Attestation Participation"
+	}
+	return p.Metric
+}
+
+// DashboardAPRSeries is a chart series of the daily apr in percent, split into consensus and execution layer
+type DashboardAPRSeries struct {
+	Name string               `json:"name"`
+	Data []*DashboardAPRPoint `json:"data"`
+}
+
+type DashboardAPRPoint struct {
+	X  int64   `json:"x"`
+	Y  float64 `json:"y"`
+	CL float64 `json:"cl"`
+	EL float64 `json:"el"`
+}
+
+// DashboardViewPageData is the page data of a saved dashboard, shared dashboards are viewed read-only
+type DashboardViewPageData struct {
+	Dashboard *Dashboard
+	Groups    []*DashboardGroupSummary
+	Total     *DashboardGroupSummary
+	Shared    bool
+	APRChart  []*DashboardAPRSeries
+	CsrfField template.HTML
+}
+
 type DashboardData struct {
 	// BalanceHistory DashboardValidatorBalanceHistory `json:"balance_history"`
 	// Earnings       ValidatorEarnings                `json:"earnings"`
@@ -1244,6 +1369,61 @@ type ApiStatistics struct {
 	MaxMonthly *int
 }
 
+// ApiStatisticsCount is the number of calls of an api route made with an api key within an hour
+type ApiStatisticsCount struct {
+	Ts     time.Time `db:"ts" json:"ts"`
+	ApiKey string    `db:"apikey" json:"-"`
+	Call   string    `db:"call" json:"call"`
+	Count  int64     `db:"count" json:"count"`
+}
+
+type ApiUsagePageData struct {
+	Package             string
+	RequestsPerSecond   int
+	RequestsPerMonth    int
+	UsedThisMonth       int
+	Calls               []*ApiStatisticsCount
+	Days                []*ApiStatisticsCount
+	RateLimitingEnabled bool
+}
+
+// LidoPageData is the summary of the lido protocol, LatestReport is nil until the first oracle report is exported
+type LidoPageData struct {
+	NodeOperators       uint64
+	ActiveNodeOperators uint64
+	Validators          uint64
+	ActiveValidators    uint64
+	LatestReport        *LidoPageDataOracleReport
+}
+
+type LidoPageDataNodeOperator struct {
+	TotalCount        uint64 `db:"total_count"`
+	ID                uint64 `db:"id"`
+	Name              string `db:"name"`
+	RewardAddress     []byte `db:"reward_address"`
+	Active            bool   `db:"active"`
+	StakingLimit      uint64 `db:"staking_limit"`
+	StoppedValidators uint64 `db:"stopped_validators"`
+	TotalSigningKeys  uint64 `db:"total_signing_keys"`
+	UsedSigningKeys   uint64 `db:"used_signing_keys"`
+	ActiveValidators  uint64 `db:"active_validators"`
+	SlashedValidators uint64 `db:"slashed_validators"`
+	Income7d          int64  `db:"income7d"`  // gwei
+	Income31d         int64  `db:"income31d"` // gwei
+}
+
+// LidoPageDataOracleReport is a report of the lido oracle, Rewards is negative if the validators of the protocol were penalized since the previous report
+type LidoPageDataOracleReport struct {
+	TotalCount       uint64  `db:"total_count"`
+	Epoch            uint64  `db:"epoch"`
+	Block            uint64  `db:"block"`
+	TxHash           []byte  `db:"tx_hash"`
+	BeaconBalance    uint64  `db:"beacon_balance"` // gwei
+	BeaconValidators uint64  `db:"beacon_validators"`
+	Rewards          int64   `db:"rewards"` // gwei
+	APR              float64 `db:"apr"`
+}
+
 type RocketpoolPageData struct{}
 type RocketpoolPageDataMinipool struct {
 	TotalCount               uint64    `db:"total_count"`
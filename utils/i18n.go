@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/kataras/i18n"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is used whenever a user has not selected a locale or a key is missing in the selected one
+const DefaultLocale = "en-US"
+
+// SupportedLocales lists the locales which have a bundle in the locales directory
+var SupportedLocales = []string{"en-US", "ru-RU"}
+
+// OnMissingTranslation is called for every key that could not be found in the requested locale.
+// It is used by tests to make sure all locales contain every key that is in use.
+var OnMissingTranslation func(lang, key string)
+
+// LocalesDir is the directory the locale bundles are loaded from
+var LocalesDir = "locales"
+
+var localiser *i18n.I18n
+var localiserOnce sync.Once
+
+// making sure language files are loaded only once
+func getLocaliser() *i18n.I18n {
+	localiserOnce.Do(func() {
+		var err error
+		localiser, err = i18n.New(i18n.Glob(LocalesDir+"/*/*"), SupportedLocales...)
+		if err != nil {
+			logger.WithError(err).Error("error loading locales")
+		}
+	})
+	return localiser
+}
+
+var notificationLocaliser *i18n.I18n
+var notificationLocaliserOnce sync.Once
+
+// getNotificationLocaliser returns a strict localiser for notification and email texts that reports missing keys
+func getNotificationLocaliser() *i18n.I18n {
+	notificationLocaliserOnce.Do(func() {
+		config := i18n.DefaultLoaderConfig
+		config.DefaultMessageFunc = func(langInput, langMatched, key string, args ...interface{}) string {
+			if OnMissingTranslation != nil {
+				OnMissingTranslation(langMatched, key)
+			}
+			return ""
+		}
+
+		var err error
+		notificationLocaliser, err = i18n.New(i18n.Glob(LocalesDir+"/*/*", config), SupportedLocales...)
+		if err != nil {
+			logger.WithError(err).Error("error loading locales")
+			return
+		}
+		// the fallback to the DefaultLocale is done by Tr so missing keys are reported for the requested locale only
+		notificationLocaliser.Strict = true
+	})
+	return notificationLocaliser
+}
+
+// NormalizeLocale returns the supported locale matching lang or the DefaultLocale
+func NormalizeLocale(lang string) string {
+	for _, l := range SupportedLocales {
+		if strings.EqualFold(l, lang) {
+			return l
+		}
+	}
+	return DefaultLocale
+}
+
+// Tr returns the notification or email text of key in the given locale, falling back to the DefaultLocale and finally to the key itself.
+// Arguments are passed to the format string of the translation, numbers should be passed pre-formatted as strings.
+func Tr(lang string, key string, args ...interface{}) string {
+	l := getNotificationLocaliser()
+	if l == nil {
+		return key
+	}
+	msg := l.Tr(lang, key, args...)
+	if msg == "" && NormalizeLocale(lang) != DefaultLocale {
+		msg = l.Tr(DefaultLocale, key, args...)
+	}
+	if msg == "" {
+		return key
+	}
+	return msg
+}
+
+// TrPlural returns the text of key for the plural category of count in the given locale.
+// The locale has to define the keys key_one, key_few, key_many and key_other as required by the CLDR rules of the language,
+// key_other is used if the required category is missing.
+func TrPlural(lang string, key string, count int, args ...interface{}) string {
+	lang = NormalizeLocale(lang)
+	form := pluralFormName(plural.Cardinal.MatchPlural(language.Make(lang), count, 0, 0, 0, 0))
+
+	l := getNotificationLocaliser()
+	if l != nil && form != "other" {
+		if msg := l.Tr(lang, key+"_"+form, args...); msg != "" {
+			return msg
+		}
+	}
+	return Tr(lang, key+"_other", args...)
+}
+
+func pluralFormName(form plural.Form) string {
+	switch form {
+	case plural.Zero:
+		return "zero"
+	case plural.One:
+		return "one"
+	case plural.Two:
+		return "two"
+	case plural.Few:
+		return "few"
+	case plural.Many:
+		return "many"
+	}
+	return "other"
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -231,21 +232,28 @@ func AuthorizedAPIMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Parse a json post request right here and attach the content
-		// as context to the request. By doing this here,
-		// we can use base.FormValueOrJson(key) without multiple parsings of the same body
-		if r.Method == "POST" && r.Header.Get("Content-Type") == "application/json" {
-			body, err := ioutil.ReadAll(r.Body)
-			if err == nil {
-				keyVal := make(map[string]interface{})
-				json.Unmarshal(body, &keyVal)
-				context.Set(r, JsonBodyKey, keyVal)
-				context.Set(r, JsonBodyNakedKey, body)
-			}
-		}
-
-		context.Set(r, ClaimsContextKey, claims)
-		context.Set(r, MobileAuthorizedKey, true)
+		SetAuthorizedAPIContext(r, claims)
 		next.ServeHTTP(w, r)
 	})
 }
+
+// SetAuthorizedAPIContext attaches the claims of an authorized api request and its json body to the request context
+func SetAuthorizedAPIContext(r *http.Request, claims *CustomClaims) {
+	// Parse a json post request right here and attach the content
+	// as context to the request. By doing this here,
+	// we can use base.FormValueOrJson(key) without multiple parsings of the same body
+	if r.Method == "POST" && r.Header.Get("Content-Type") == "application/json" {
+		body, err := ioutil.ReadAll(r.Body)
+		if err == nil {
+			keyVal := make(map[string]interface{})
+			json.Unmarshal(body, &keyVal)
+			context.Set(r, JsonBodyKey, keyVal)
+			context.Set(r, JsonBodyNakedKey, body)
+			// handlers that decode the body themselves still have to be able to read it
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+	}
+
+	context.Set(r, ClaimsContextKey, claims)
+	context.Set(r, MobileAuthorizedKey, true)
+}
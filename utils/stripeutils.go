@@ -12,3 +12,27 @@ func GetPurchaseGroup(priceId string) string {
 	}
 	return ""
 }
+
+// GetMaxValidators returns the number of validators the premium package allows to watch and to cover by subscription rules
+func GetMaxValidators(pkg string) int {
+	if pkg == "whale" {
+		return 300
+	}
+	return 100
+}
+
+// GetApiPackage returns the name of the api plan of the given stripe price, prices outside of the api purchase group map to the free plan
+func GetApiPackage(priceId string) string {
+	if priceId == "" {
+		return "standard"
+	}
+	switch priceId {
+	case Config.Frontend.Stripe.Sapphire:
+		return "sapphire"
+	case Config.Frontend.Stripe.Emerald:
+		return "emerald"
+	case Config.Frontend.Stripe.Diamond:
+		return "diamond"
+	}
+	return "standard"
+}
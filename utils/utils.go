@@ -18,6 +18,7 @@ import (
 	"log"
 	"math"
 	"math/big"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -35,7 +36,6 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/params"
-	"github.com/kataras/i18n"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
@@ -45,20 +45,6 @@ import (
 // Config is the globally accessible configuration
 var Config *types.Config
 
-var localiser *i18n.I18n
-
-// making sure language files are loaded only once
-func getLocaliser() *i18n.I18n {
-	if localiser == nil {
-		localiser, err := i18n.New(i18n.Glob("locales/*/*"), "en-US", "ru-RU")
-		if err != nil {
-			log.Println(err)
-		}
-		return localiser
-	}
-	return localiser
-}
-
 var HashLikeRegex = regexp.MustCompile(`^[0-9a-fA-F]{0,96}$`)
 
 // GetTemplateFuncs will get the template functions
@@ -910,3 +896,60 @@ func ReverseSlice[S ~[]E, E any](s S) {
 func AddBigInts(a, b []byte) []byte {
 	return new(big.Int).Add(new(big.Int).SetBytes(a), new(big.Int).SetBytes(b)).Bytes()
 }
+
+// GetClientIP returns the ip of the client of a request, the X-Forwarded-For header is only used if the request was made by
+// one of the trusted proxies of the api rate limit config and its entries are read from right to left until the first untrusted address
+func GetClientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	trusted := []*net.IPNet{}
+	for _, proxy := range Config.Frontend.ApiRateLimit.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if strings.Contains(proxy, ":") {
+				proxy += "/128"
+			} else {
+				proxy += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			logrus.Warnf("invalid trusted proxy %v: %v", proxy, err)
+			continue
+		}
+		trusted = append(trusted, network)
+	}
+	isTrusted := func(ip string) bool {
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return false
+		}
+		for _, network := range trusted {
+			if network.Contains(parsed) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !isTrusted(ip) {
+		return ip
+	}
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		forwardedIP := strings.TrimSpace(forwarded[i])
+		if forwardedIP == "" {
+			continue
+		}
+		if net.ParseIP(forwardedIP) == nil {
+			break
+		}
+		ip = forwardedIP
+		if !isTrusted(forwardedIP) {
+			break
+		}
+	}
+	return ip
+}